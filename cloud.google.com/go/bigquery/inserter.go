@@ -0,0 +1,458 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	gax "github.com/googleapis/gax-go"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/semaphore"
+	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/support/bundler"
+)
+
+const (
+	// The maximum number of rows in an insertAll request.
+	maxInsertRequestRows = 10000
+
+	// The maximum size of an insertAll request, in bytes.
+	maxInsertRequestBytes = 10 << 20
+)
+
+var (
+	// ErrOversizedRow is returned by Inserter.Insert for a row that is too large
+	// to be sent in an insertAll request.
+	ErrOversizedRow = bundler.ErrOversizedItem
+
+	errInserterStopped = errors.New("bigquery: Insert called after Stop")
+)
+
+// An Inserter does batched streaming inserts into a BigQuery table in the
+// background. Rows passed to Insert are accumulated and sent according to the
+// Inserter's InsertSettings. Rows that fail with a transient error are retried
+// with the same insert ID, so that BigQuery can deduplicate them. Rows that
+// cannot be inserted are passed to DeadLetter.
+//
+// An Inserter uses the SkipInvalidRows, IgnoreUnknownValues and
+// TableTemplateSuffix settings of the Uploader that created it.
+//
+// It is safe for concurrent use. Call Stop to send the remaining rows and
+// release the Inserter's resources.
+type Inserter struct {
+	u *Uploader
+
+	// InsertSettings control the batching, retrying and flow control of
+	// inserted rows. Changes to InsertSettings after the first call to Insert
+	// have no effect. The default is DefaultInsertSettings.
+	InsertSettings InsertSettings
+
+	// DeadLetter, if non-nil, is called with each row that could not be
+	// inserted, and the reason. The error is a RowInsertionError if BigQuery
+	// rejected the row, and the error of the insertAll request otherwise.
+	// Its RowIndex is the index of the row in the src argument of Insert.
+	// DeadLetter may be called concurrently from several goroutines.
+	//
+	// If DeadLetter is nil, rows that cannot be inserted are discarded.
+	DeadLetter func(row ValueSaver, err error)
+
+	mu      sync.Mutex
+	stopped bool
+	bundler *bundler.Bundler
+	fc      *flowController
+	pending map[string]bool // insert IDs of the rows not yet inserted or discarded
+
+	// insertAll sends an insertAll request. It is replaced in tests.
+	insertAll func(context.Context, *bq.TableDataInsertAllRequest) (*bq.TableDataInsertAllResponse, error)
+}
+
+// InsertSettings control the batching, retrying and flow control of rows
+// inserted by an Inserter.
+type InsertSettings struct {
+	// Send a non-empty batch after this delay has passed.
+	DelayThreshold time.Duration
+
+	// Send a batch when it has this many rows. The maximum is 10,000.
+	CountThreshold int
+
+	// Send a batch when its size in bytes reaches this value.
+	ByteThreshold int
+
+	// The maximum number of rows that have been passed to Insert but not yet
+	// inserted or discarded. When it is reached, Insert blocks. If less than 1,
+	// there is no limit.
+	MaxOutstandingRows int
+
+	// The maximum total size of the rows that have been passed to Insert but
+	// not yet inserted or discarded. When it is reached, Insert blocks. If less
+	// than 1, there is no limit.
+	MaxOutstandingBytes int
+
+	// The number of goroutines that send insertAll requests concurrently.
+	// Defaults to a multiple of GOMAXPROCS.
+	NumGoroutines int
+
+	// The maximum time that the Inserter will try to insert a batch of rows,
+	// including retries. Rows that have not been inserted by then are passed
+	// to DeadLetter. If zero, rows are retried until they are inserted or
+	// fail permanently.
+	Timeout time.Duration
+}
+
+// DefaultInsertSettings holds the default values for Inserters' InsertSettings.
+var DefaultInsertSettings = InsertSettings{
+	DelayThreshold:      time.Second,
+	CountThreshold:      500,
+	ByteThreshold:       1e6,
+	MaxOutstandingRows:  10000,
+	MaxOutstandingBytes: 1e9,
+	Timeout:             60 * time.Second,
+}
+
+// The backoff between retries of rows that failed with a transient error.
+// It is replaced in tests.
+var insertRetryBackoff = gax.Backoff{
+	Initial:    1 * time.Second,
+	Max:        32 * time.Second,
+	Multiplier: 2,
+}
+
+// Inserter returns an Inserter that inserts rows into u's table in the
+// background. The returned Inserter may optionally be further configured
+// before its Insert method is called.
+func (u *Uploader) Inserter() *Inserter {
+	i := &Inserter{
+		u:              u,
+		InsertSettings: DefaultInsertSettings,
+		pending:        make(map[string]bool),
+	}
+	i.insertAll = func(ctx context.Context, req *bq.TableDataInsertAllRequest) (*bq.TableDataInsertAllResponse, error) {
+		call := u.t.c.bqs.Tabledata.InsertAll(u.t.ProjectID, u.t.DatasetID, u.t.TableID, req)
+		call = call.Context(ctx)
+		setClientHeader(call.Header())
+		var res *bq.TableDataInsertAllResponse
+		err := runWithRetry(ctx, func() (err error) {
+			res, err = call.Do()
+			return err
+		})
+		return res, err
+	}
+	return i
+}
+
+// An insertedRow is a row passed to Inserter.Insert.
+type insertedRow struct {
+	saver ValueSaver
+	row   *bq.TableDataInsertAllRequestRows
+	index int   // the index of the row in the src argument of Insert
+	size  int   // the estimated size of the row in a request
+	err   error // the error of the last attempt to insert the row
+}
+
+// Insert adds one or more rows to be inserted. src is interpreted as for
+// Uploader.Put.
+//
+// Insert returns once the rows have been added to a batch; it does not wait
+// for them to be sent. It blocks while the Inserter has MaxOutstandingRows
+// rows or MaxOutstandingBytes bytes outstanding, returning ctx.Err() if ctx is
+// done first. In that case, some of the rows may already have been added.
+//
+// A row whose insert ID is the same as that of a row that is outstanding is
+// discarded as a duplicate. Rows without an insert ID are given a random one.
+func (i *Inserter) Insert(ctx context.Context, src interface{}) error {
+	savers, err := valueSavers(src)
+	if err != nil {
+		return err
+	}
+	var rows []*insertedRow
+	for idx, saver := range savers {
+		row, insertID, err := saver.Save()
+		if err != nil {
+			return err
+		}
+		if insertID == "" {
+			insertID = randomIDFn()
+		}
+		m := make(map[string]bq.JsonValue)
+		for k, v := range row {
+			m[k] = bq.JsonValue(v)
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		size := len(b) + len(insertID)
+		if size > maxInsertRequestBytes {
+			return ErrOversizedRow
+		}
+		rows = append(rows, &insertedRow{
+			saver: saver,
+			row:   &bq.TableDataInsertAllRequestRows{InsertId: insertID, Json: m},
+			index: idx,
+			size:  size,
+		})
+	}
+
+	if err := i.init(); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		i.mu.Lock()
+		dup := i.pending[r.row.InsertId]
+		i.pending[r.row.InsertId] = true
+		i.mu.Unlock()
+		if dup {
+			continue
+		}
+		if err := i.fc.acquire(ctx, r.size); err != nil {
+			i.done(r, nil, false)
+			return err
+		}
+		if err := i.bundler.Add(r, r.size); err != nil {
+			i.done(r, nil, true)
+			return err
+		}
+	}
+	return nil
+}
+
+// init starts the bundler on first use.
+func (i *Inserter) init() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.stopped {
+		return errInserterStopped
+	}
+	if i.bundler != nil {
+		return nil
+	}
+	s := i.InsertSettings
+	i.fc = newFlowController(s.MaxOutstandingRows, s.MaxOutstandingBytes)
+	i.bundler = bundler.NewBundler(&insertedRow{}, func(items interface{}) {
+		ctx := context.Background()
+		if s.Timeout != 0 {
+			var cancel func()
+			ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+			defer cancel()
+		}
+		i.insertBatch(ctx, items.([]*insertedRow))
+	})
+	i.bundler.DelayThreshold = s.DelayThreshold
+	i.bundler.BundleCountThreshold = s.CountThreshold
+	if i.bundler.BundleCountThreshold > maxInsertRequestRows {
+		i.bundler.BundleCountThreshold = maxInsertRequestRows
+	}
+	i.bundler.BundleByteThreshold = s.ByteThreshold
+	i.bundler.BundleByteLimit = maxInsertRequestBytes
+	// Flow control is done by i.fc.
+	i.bundler.BufferedByteLimit = int(^uint(0) >> 1)
+	if s.NumGoroutines > 0 {
+		i.bundler.HandlerLimit = s.NumGoroutines
+	} else {
+		i.bundler.HandlerLimit = 10 * runtime.GOMAXPROCS(0)
+	}
+	return nil
+}
+
+// Flush blocks until all the rows passed to Insert so far have been inserted
+// or discarded.
+func (i *Inserter) Flush() {
+	i.mu.Lock()
+	b := i.bundler
+	i.mu.Unlock()
+	if b != nil {
+		b.Flush()
+	}
+}
+
+// Stop inserts the remaining rows and stops the Inserter. It returns once all
+// outstanding rows have been inserted or discarded. Calls to Insert after
+// Stop return an error.
+func (i *Inserter) Stop() {
+	i.mu.Lock()
+	i.stopped = true
+	i.mu.Unlock()
+	i.Flush()
+}
+
+// insertBatch inserts rows, retrying those that fail with a transient error
+// until ctx is done.
+func (i *Inserter) insertBatch(ctx context.Context, rows []*insertedRow) {
+	backoff := insertRetryBackoff
+	for {
+		rows = i.insertRows(ctx, rows)
+		if len(rows) == 0 {
+			return
+		}
+		if err := gax.Sleep(ctx, backoff.Pause()); err != nil {
+			for _, r := range rows {
+				i.done(r, r.err, true)
+			}
+			return
+		}
+	}
+}
+
+// insertRows sends one insertAll request for rows, splitting it if it is too
+// large. It returns the rows that failed with a transient error.
+func (i *Inserter) insertRows(ctx context.Context, rows []*insertedRow) []*insertedRow {
+	req := &bq.TableDataInsertAllRequest{
+		TemplateSuffix:      i.u.TableTemplateSuffix,
+		IgnoreUnknownValues: i.u.IgnoreUnknownValues,
+		SkipInvalidRows:     i.u.SkipInvalidRows,
+	}
+	for _, r := range rows {
+		req.Rows = append(req.Rows, r.row)
+	}
+	res, err := i.insertAll(ctx, req)
+	if err != nil {
+		if tooLarge(err) && len(rows) > 1 {
+			n := len(rows) / 2
+			return append(i.insertRows(ctx, rows[:n]), i.insertRows(ctx, rows[n:])...)
+		}
+		for _, r := range rows {
+			i.done(r, err, true)
+		}
+		return nil
+	}
+	rowErrs := make(map[int64][]*bq.ErrorProto)
+	for _, ie := range res.InsertErrors {
+		rowErrs[ie.Index] = append(rowErrs[ie.Index], ie.Errors...)
+	}
+	var retry []*insertedRow
+	for idx, r := range rows {
+		errs, ok := rowErrs[int64(idx)]
+		if !ok {
+			i.done(r, nil, true)
+			continue
+		}
+		rie := RowInsertionError{InsertID: r.row.InsertId, RowIndex: r.index}
+		transient := true
+		for _, e := range errs {
+			rie.Errors = append(rie.Errors, bqToError(e))
+			transient = transient && transientRowError(e.Reason)
+		}
+		if transient {
+			r.err = &rie
+			retry = append(retry, r)
+		} else {
+			i.done(r, &rie, true)
+		}
+	}
+	return retry
+}
+
+// done finishes with r, passing it to DeadLetter if err is non-nil.
+// acquired reports whether r holds flow control.
+func (i *Inserter) done(r *insertedRow, err error, acquired bool) {
+	if err != nil && i.DeadLetter != nil {
+		i.DeadLetter(r.saver, err)
+	}
+	i.mu.Lock()
+	delete(i.pending, r.row.InsertId)
+	i.mu.Unlock()
+	if acquired {
+		i.fc.release(r.size)
+	}
+}
+
+// transientRowError reports whether a row that failed for reason may succeed
+// if it is sent again. Rows are "stopped" when another row in the request is
+// invalid and SkipInvalidRows is false.
+func transientRowError(reason string) bool {
+	switch reason {
+	case "stopped", "timeout", "backendError", "internalError", "rateLimitExceeded":
+		return true
+	default:
+		return false
+	}
+}
+
+// tooLarge reports whether err means that an insertAll request was too large.
+func tooLarge(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == http.StatusRequestEntityTooLarge ||
+		(e.Code == http.StatusBadRequest && strings.Contains(e.Message, "exceeds the limit"))
+}
+
+// flowController limits the number and total size of outstanding rows.
+type flowController struct {
+	maxSize           int
+	semCount, semSize *semaphore.Weighted
+}
+
+// newFlowController creates a new flowController that ensures no more than
+// maxCount rows or maxSize bytes are outstanding at once. If maxCount or
+// maxSize is < 1, then an unlimited number of rows or bytes is permitted,
+// respectively.
+func newFlowController(maxCount, maxSize int) *flowController {
+	fc := &flowController{maxSize: maxSize}
+	if maxCount > 0 {
+		fc.semCount = semaphore.NewWeighted(int64(maxCount))
+	}
+	if maxSize > 0 {
+		fc.semSize = semaphore.NewWeighted(int64(maxSize))
+	}
+	return fc
+}
+
+// acquire blocks until one row of size bytes can proceed or ctx is done.
+// It returns nil in the first case, or ctx.Err() in the second.
+//
+// acquire allows large rows to proceed by treating a size greater than maxSize
+// as if it were equal to maxSize.
+func (f *flowController) acquire(ctx context.Context, size int) error {
+	if f.semCount != nil {
+		if err := f.semCount.Acquire(ctx, 1); err != nil {
+			return err
+		}
+	}
+	if f.semSize != nil {
+		if err := f.semSize.Acquire(ctx, f.bound(size)); err != nil {
+			if f.semCount != nil {
+				f.semCount.Release(1)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// release notes that one row of size bytes is no longer outstanding.
+func (f *flowController) release(size int) {
+	if f.semCount != nil {
+		f.semCount.Release(1)
+	}
+	if f.semSize != nil {
+		f.semSize.Release(f.bound(size))
+	}
+}
+
+func (f *flowController) bound(size int) int64 {
+	if size > f.maxSize {
+		return int64(f.maxSize)
+	}
+	return int64(size)
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	gax "github.com/googleapis/gax-go"
+	"golang.org/x/net/context"
+	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+)
+
+// fakeInsertAll records insertAll requests and responds to them with respond.
+type fakeInsertAll struct {
+	mu      sync.Mutex
+	reqs    [][]string // insert IDs of each request
+	respond func(ids []string) (*bq.TableDataInsertAllResponse, error)
+}
+
+func (f *fakeInsertAll) insertAll(_ context.Context, req *bq.TableDataInsertAllRequest) (*bq.TableDataInsertAllResponse, error) {
+	var ids []string
+	for _, r := range req.Rows {
+		ids = append(ids, r.InsertId)
+	}
+	f.mu.Lock()
+	f.reqs = append(f.reqs, ids)
+	f.mu.Unlock()
+	if f.respond == nil {
+		return &bq.TableDataInsertAllResponse{}, nil
+	}
+	return f.respond(ids)
+}
+
+func (f *fakeInsertAll) requests() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reqs
+}
+
+func newTestInserter(f *fakeInsertAll) *Inserter {
+	i := (&Uploader{}).Inserter()
+	i.InsertSettings.DelayThreshold = time.Hour
+	i.insertAll = f.insertAll
+	return i
+}
+
+func noInsertBackoff() func() {
+	prev := insertRetryBackoff
+	insertRetryBackoff = gax.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+	return func() { insertRetryBackoff = prev }
+}
+
+func savers(ids ...string) []ValueSaver {
+	var vs []ValueSaver
+	for _, id := range ids {
+		vs = append(vs, testSaver{insertID: id, row: map[string]Value{"id": id}})
+	}
+	return vs
+}
+
+type deadLetters struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (d *deadLetters) add(row ValueSaver, err error) {
+	_, id, _ := row.Save()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.errs == nil {
+		d.errs = make(map[string]error)
+	}
+	d.errs[id] = err
+}
+
+func TestInserterBatching(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeInsertAll{}
+	i := newTestInserter(f)
+	i.InsertSettings.CountThreshold = 2
+	i.InsertSettings.NumGoroutines = 1
+	if err := i.Insert(ctx, savers("a", "b", "c")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+	got := f.requests()
+	if len(got) != 2 || len(got[0]) != 2 || len(got[1]) != 1 {
+		t.Errorf("got requests %v, want two requests of 2 and 1 rows", got)
+	}
+	if err := i.Insert(ctx, savers("d")); err != errInserterStopped {
+		t.Errorf("Insert after Stop: got %v, want %v", err, errInserterStopped)
+	}
+}
+
+func TestInserterDelayThreshold(t *testing.T) {
+	f := &fakeInsertAll{}
+	i := newTestInserter(f)
+	i.InsertSettings.DelayThreshold = 10 * time.Millisecond
+	if err := i.Insert(context.Background(), savers("a")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(f.requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("row was not sent after DelayThreshold")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	i.Stop()
+}
+
+func TestInserterRetries(t *testing.T) {
+	defer noInsertBackoff()()
+	prev := randomIDFn
+	n := 0
+	randomIDFn = func() string { n++; return "r" + strconv.Itoa(n) }
+	defer func() { randomIDFn = prev }()
+
+	// The first attempt fails "a" transiently, "b" permanently and stops "c";
+	// the retry fails "a" transiently again.
+	attempt := 0
+	f := &fakeInsertAll{respond: func(ids []string) (*bq.TableDataInsertAllResponse, error) {
+		attempt++
+		res := &bq.TableDataInsertAllResponse{}
+		for idx, id := range ids {
+			var reason string
+			switch {
+			case id == "a" && attempt <= 2:
+				reason = "backendError"
+			case id == "b":
+				reason = "invalid"
+			case id == "r1" && attempt == 1:
+				reason = "stopped"
+			}
+			if reason != "" {
+				res.InsertErrors = append(res.InsertErrors, &bq.TableDataInsertAllResponseInsertErrors{
+					Index:  int64(idx),
+					Errors: []*bq.ErrorProto{{Reason: reason}},
+				})
+			}
+		}
+		return res, nil
+	}}
+	var dl deadLetters
+	i := newTestInserter(f)
+	i.DeadLetter = dl.add
+	if err := i.Insert(context.Background(), savers("a", "b", "")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+
+	got := f.requests()
+	want := [][]string{{"a", "b", "r1"}, {"a", "r1"}, {"a"}}
+	if !equalRequests(got, want) {
+		t.Errorf("got requests %v, want %v", got, want)
+	}
+	if len(dl.errs) != 1 {
+		t.Fatalf("got dead letters %v, want only b", dl.errs)
+	}
+	rie, ok := dl.errs["b"].(*RowInsertionError)
+	if !ok {
+		t.Fatalf("got %#v, want *RowInsertionError", dl.errs["b"])
+	}
+	if rie.InsertID != "b" || rie.RowIndex != 1 || len(rie.Errors) != 1 {
+		t.Errorf("got %+v, want error for row 1 with insert ID b", rie)
+	}
+}
+
+func TestInserterTimeout(t *testing.T) {
+	defer noInsertBackoff()()
+	f := &fakeInsertAll{respond: func(ids []string) (*bq.TableDataInsertAllResponse, error) {
+		return &bq.TableDataInsertAllResponse{
+			InsertErrors: []*bq.TableDataInsertAllResponseInsertErrors{
+				{Index: 0, Errors: []*bq.ErrorProto{{Reason: "rateLimitExceeded"}}},
+			},
+		}, nil
+	}}
+	var dl deadLetters
+	i := newTestInserter(f)
+	i.InsertSettings.Timeout = 50 * time.Millisecond
+	i.DeadLetter = dl.add
+	if err := i.Insert(context.Background(), savers("a")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+	if len(f.requests()) < 2 {
+		t.Errorf("got %d requests, want retries", len(f.requests()))
+	}
+	if _, ok := dl.errs["a"].(*RowInsertionError); !ok {
+		t.Errorf("got dead letter %v, want RowInsertionError for a", dl.errs["a"])
+	}
+}
+
+func TestInserterRequestError(t *testing.T) {
+	// Requests with more than one row are too large.
+	f := &fakeInsertAll{respond: func(ids []string) (*bq.TableDataInsertAllResponse, error) {
+		if len(ids) > 1 {
+			return nil, &googleapi.Error{Code: http.StatusRequestEntityTooLarge}
+		}
+		if ids[0] == "c" {
+			return nil, &googleapi.Error{Code: http.StatusForbidden}
+		}
+		return &bq.TableDataInsertAllResponse{}, nil
+	}}
+	var dl deadLetters
+	i := newTestInserter(f)
+	i.DeadLetter = dl.add
+	if err := i.Insert(context.Background(), savers("a", "b", "c")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+	got := f.requests()
+	want := [][]string{{"a", "b", "c"}, {"a"}, {"b", "c"}, {"b"}, {"c"}}
+	if !equalRequests(got, want) {
+		t.Errorf("got requests %v, want %v", got, want)
+	}
+	if len(dl.errs) != 1 {
+		t.Fatalf("got dead letters %v, want only c", dl.errs)
+	}
+	if e, ok := dl.errs["c"].(*googleapi.Error); !ok || e.Code != http.StatusForbidden {
+		t.Errorf("got %v, want 403 error", dl.errs["c"])
+	}
+}
+
+func TestInserterDedupe(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeInsertAll{}
+	i := newTestInserter(f)
+	if err := i.Insert(ctx, savers("a", "b", "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Insert(ctx, savers("b")); err != nil {
+		t.Fatal(err)
+	}
+	i.Flush()
+	// Once a row is inserted, its insert ID may be used again.
+	if err := i.Insert(ctx, savers("a")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+	got := f.requests()
+	want := [][]string{{"a", "b"}, {"a"}}
+	if !equalRequests(got, want) {
+		t.Errorf("got requests %v, want %v", got, want)
+	}
+}
+
+func TestInserterFlowControl(t *testing.T) {
+	release := make(chan struct{})
+	f := &fakeInsertAll{respond: func([]string) (*bq.TableDataInsertAllResponse, error) {
+		<-release
+		return &bq.TableDataInsertAllResponse{}, nil
+	}}
+	i := newTestInserter(f)
+	i.InsertSettings.CountThreshold = 1
+	i.InsertSettings.MaxOutstandingRows = 1
+	if err := i.Insert(context.Background(), savers("a")); err != nil {
+		t.Fatal(err)
+	}
+	// The second row must wait for the first to be inserted.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := i.Insert(ctx, savers("b")); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := i.Insert(context.Background(), savers("b")); err != nil {
+		t.Fatal(err)
+	}
+	i.Stop()
+}
+
+func TestInserterOversizedRow(t *testing.T) {
+	i := newTestInserter(&fakeInsertAll{})
+	big := make([]byte, maxInsertRequestBytes)
+	row := testSaver{row: map[string]Value{"big": string(big)}}
+	if err := i.Insert(context.Background(), row); err != ErrOversizedRow {
+		t.Errorf("got %v, want %v", err, ErrOversizedRow)
+	}
+}
+
+// equalRequests compares requests ignoring their order, which depends on the
+// scheduling of retries.
+func equalRequests(got, want [][]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	key := func(rs [][]string) []string {
+		var ks []string
+		for _, r := range rs {
+			k := ""
+			for _, id := range r {
+				k += id + ","
+			}
+			ks = append(ks, k)
+		}
+		sort.Strings(ks)
+		return ks
+	}
+	g, w := key(got), key(want)
+	for i := range g {
+		if g[i] != w[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1547,6 +1547,18 @@ func TestIntegration_QueryDryRun(t *testing.T) {
 	if s.Statistics.Details.(*QueryStatistics).Schema == nil {
 		t.Fatal("no schema")
 	}
+
+	q.DryRun = false
+	qs, err := q.DryRunStatistics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qs.TotalBytesProcessed == 0 {
+		t.Error("DryRunStatistics: no bytes processed")
+	}
+	if len(qs.ReferencedTables) == 0 {
+		t.Error("DryRunStatistics: no referenced tables")
+	}
 }
 
 func TestIntegration_ExtractExternal(t *testing.T) {
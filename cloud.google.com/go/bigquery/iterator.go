@@ -88,7 +88,7 @@ type RowIterator struct {
 //   INTEGER     int, int8, int16, int32, int64, uint8, uint16, uint32
 //   FLOAT       float32, float64
 //   BYTES       []byte
-//   TIMESTAMP   time.Time
+//   TIMESTAMP   time.Time, civil.Timestamp
 //   DATE        civil.Date
 //   TIME        civil.Time
 //   DATETIME    civil.DateTime
@@ -78,6 +78,8 @@ var (
 	typeOfDateTime = reflect.TypeOf(civil.DateTime{})
 	typeOfGoTime   = reflect.TypeOf(time.Time{})
 	typeOfRat      = reflect.TypeOf(&big.Rat{})
+	// typeOfCivilTimestamp is distinct from typeOfTime, which is civil.Time.
+	typeOfCivilTimestamp = reflect.TypeOf(civil.Timestamp{})
 )
 
 // A QueryParameter is a parameter to a query.
@@ -97,7 +99,10 @@ type QueryParameter struct {
 	// bool: BOOL
 	// string: STRING
 	// []byte: BYTES
-	// time.Time: TIMESTAMP
+	// time.Time, civil.Timestamp: TIMESTAMP
+	// civil.Date: DATE
+	// civil.Time: TIME
+	// civil.DateTime: DATETIME
 	// *big.Rat: NUMERIC
 	// Arrays and slices of the above.
 	// Structs of the above. Only the exported fields are used.
@@ -138,7 +143,7 @@ func paramType(t reflect.Type) (*bq.QueryParameterType, error) {
 		return timeParamType, nil
 	case typeOfDateTime:
 		return dateTimeParamType, nil
-	case typeOfGoTime:
+	case typeOfGoTime, typeOfCivilTimestamp:
 		return timestampParamType, nil
 	case typeOfRat:
 		return numericParamType, nil
@@ -223,6 +228,10 @@ func paramValue(v reflect.Value) (bq.QueryParameterValue, error) {
 		res.Value = v.Interface().(time.Time).Format(timestampFormat)
 		return res, nil
 
+	case typeOfCivilTimestamp:
+		res.Value = CivilTimestampString(v.Interface().(civil.Timestamp))
+		return res, nil
+
 	case typeOfRat:
 		res.Value = NumericString(v.Interface().(*big.Rat))
 		return res, nil
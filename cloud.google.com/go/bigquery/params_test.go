@@ -124,6 +124,35 @@ func TestParamValueScalar(t *testing.T) {
 	}
 }
 
+func TestParamValueCivilTimestamp(t *testing.T) {
+	// civil.Timestamp values are not in scalarTests, because they are
+	// converted back to time.Time.
+	dt := civil.DateTime{Date: civil.Date{Year: 2016, Month: 3, Day: 20}, Time: civil.Time{Hour: 4, Minute: 5, Second: 6, Nanosecond: 789000000}}
+	for _, test := range []struct {
+		val  civil.Timestamp
+		want string
+	}{
+		{civil.Timestamp{DateTime: dt}, "2016-03-20 04:05:06.789000"},
+		{civil.Timestamp{DateTime: dt, Zone: "-01:02"}, "2016-03-20 04:05:06.789000-01:02"},
+		{civil.Timestamp{DateTime: dt, Zone: "America/Los_Angeles"}, "2016-03-20 04:05:06.789000 America/Los_Angeles"},
+	} {
+		got, err := paramValue(reflect.ValueOf(test.val))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := sval(test.want); !testutil.Equal(got, want) {
+			t.Errorf("%v:\ngot  %+v\nwant %+v", test.val, got, want)
+		}
+	}
+	pt, err := paramType(reflect.TypeOf(civil.Timestamp{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt != timestampParamType {
+		t.Errorf("got %+v, want %+v", pt, timestampParamType)
+	}
+}
+
 func TestParamValueArray(t *testing.T) {
 	qpv := bq.QueryParameterValue{ArrayValues: []*bq.QueryParameterValue{
 		{Value: "1"},
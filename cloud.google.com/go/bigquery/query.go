@@ -310,3 +310,35 @@ func (q *Query) Read(ctx context.Context) (*RowIterator, error) {
 	}
 	return job.Read(ctx)
 }
+
+// DryRunStatistics validates the query without running it, and returns
+// statistics about it, including the tables it references, the number of bytes
+// it would process and, for standard SQL queries, the schema of its results.
+// It ignores the DryRun field of q.
+//
+// An invalid query results in the same error that Run would return.
+func (q *Query) DryRunStatistics(ctx context.Context) (qs *QueryStatistics, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Query.DryRunStatistics")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	dq := *q
+	dq.DryRun = true
+	job, err := dq.newJob()
+	if err != nil {
+		return nil, err
+	}
+	j, err := q.client.insertJob(ctx, job, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := j.LastStatus()
+	if s != nil && s.Err() != nil {
+		return nil, s.Err()
+	}
+	if s != nil && s.Statistics != nil {
+		if qs, ok := s.Statistics.Details.(*QueryStatistics); ok {
+			return qs, nil
+		}
+	}
+	return nil, errors.New("bigquery: dry run returned no query statistics")
+}
@@ -15,14 +15,19 @@
 package bigquery
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	"cloud.google.com/go/internal/testutil"
+	"golang.org/x/net/context"
 
 	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
 )
 
 func defaultQueryJob() *bq.Job {
@@ -408,3 +413,55 @@ func TestQueryLegacySQL(t *testing.T) {
 		t.Error("Parameters and UseLegacySQL: got nil, want error")
 	}
 }
+
+func TestQueryDryRunStatistics(t *testing.T) {
+	var gotJob bq.Job
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotJob); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res := &bq.Job{
+			JobReference: &bq.JobReference{ProjectId: "p"},
+			Status:       &bq.JobStatus{State: "DONE"},
+			Statistics: &bq.JobStatistics{
+				TotalBytesProcessed: 100,
+				Query: &bq.JobStatistics2{
+					TotalBytesProcessed: 100,
+					ReferencedTables: []*bq.TableReference{
+						{ProjectId: "p", DatasetId: "d", TableId: "t"},
+					},
+					Schema: &bq.TableSchema{Fields: []*bq.TableFieldSchema{{Name: "a", Type: "STRING"}}},
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := NewClient(ctx, "p", option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := c.Query("SELECT a FROM d.t")
+	qs, err := q.DryRunStatistics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotJob.Configuration.DryRun {
+		t.Error("job was not a dry run")
+	}
+	if q.DryRun {
+		t.Error("DryRunStatistics changed q.DryRun")
+	}
+	if qs.TotalBytesProcessed != 100 {
+		t.Errorf("got TotalBytesProcessed %d, want 100", qs.TotalBytesProcessed)
+	}
+	if len(qs.ReferencedTables) != 1 || qs.ReferencedTables[0].FullyQualifiedName() != "p:d.t" {
+		t.Errorf("got ReferencedTables %v, want p:d.t", qs.ReferencedTables)
+	}
+	if want := (Schema{{Name: "a", Type: StringFieldType}}); !testutil.Equal(qs.Schema, want) {
+		t.Errorf("got schema %v, want %v", qs.Schema, want)
+	}
+}
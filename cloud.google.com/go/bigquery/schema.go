@@ -154,7 +154,7 @@ var typeOfByteSlice = reflect.TypeOf([]byte{})
 //   INTEGER     int, int8, int16, int32, int64, uint8, uint16, uint32
 //   FLOAT       float32, float64
 //   BYTES       []byte
-//   TIMESTAMP   time.Time
+//   TIMESTAMP   time.Time, civil.Timestamp
 //   DATE        civil.Date
 //   TIME        civil.Time
 //   DATETIME    civil.DateTime
@@ -260,7 +260,7 @@ func inferFieldSchema(rt reflect.Type, nullable bool) (*FieldSchema, error) {
 	switch rt {
 	case typeOfByteSlice:
 		return &FieldSchema{Required: !nullable, Type: BytesFieldType}, nil
-	case typeOfGoTime:
+	case typeOfGoTime, typeOfCivilTimestamp:
 		return &FieldSchema{Required: true, Type: TimestampFieldType}, nil
 	case typeOfDate:
 		return &FieldSchema{Required: true, Type: DateFieldType}, nil
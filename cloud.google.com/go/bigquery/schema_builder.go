@@ -0,0 +1,362 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// A SchemaBuilder infers a Schema from sample rows in JSON or CSV format, in a
+// way similar to BigQuery's schema auto-detection.
+//
+// The type of each field is the narrowest type that holds all of its sample
+// values. Integers and floats combine to FLOAT; other combinations of scalar
+// types are inferred to be STRING. A field whose sample values are all null is
+// inferred to be a nullable STRING. A field with an array value is inferred to
+// be repeated. Other fields are inferred to be required if they have a non-null
+// value in every sample row, and nullable otherwise.
+//
+// Fields appear in the schema in the order in which they were first seen.
+type SchemaBuilder struct {
+	root inferredRecord
+}
+
+// inferredRecord accumulates the fields of a table or of a RECORD field.
+type inferredRecord struct {
+	count  int // the number of values of the record
+	fields []*inferredField
+	byName map[string]*inferredField // keyed by lower-case name
+}
+
+type inferredField struct {
+	name     string
+	typ      FieldType // empty until a non-null value is seen
+	present  int       // the number of records in which the field is non-null
+	repeated bool
+	scalar   bool // whether a non-repeated value was seen
+	record   *inferredRecord
+}
+
+// NewSchemaBuilder returns an empty SchemaBuilder.
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{}
+}
+
+// AddJSON adds the rows read from r, which must contain a sequence of JSON
+// objects, such as newline-delimited JSON.
+func (b *SchemaBuilder) AddJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		v, err := decodeOrderedJSON(dec)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		obj, ok := v.(jsonObject)
+		if !ok {
+			return fmt.Errorf("bigquery: JSON row is not an object: %v", v)
+		}
+		if err := b.root.add(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// AddCSV adds the rows read from r, which must be in CSV format. The first
+// record holds the names of the fields. Empty values are treated as null.
+func (b *SchemaBuilder) AddCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return errors.New("bigquery: CSV has no header")
+	}
+	if err != nil {
+		return err
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var obj jsonObject
+		for i, s := range rec {
+			var v interface{}
+			if s != "" {
+				v = csvValue(s)
+			}
+			obj = append(obj, jsonMember{header[i], v})
+		}
+		if err := b.root.add(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// Schema returns the schema inferred from the rows added so far.
+func (b *SchemaBuilder) Schema() Schema {
+	return b.root.schema()
+}
+
+func (r *inferredRecord) add(obj jsonObject) error {
+	if r.byName == nil {
+		r.byName = make(map[string]*inferredField)
+	}
+	r.count++
+	for _, m := range obj {
+		if m.value == nil {
+			if _, ok := r.byName[strings.ToLower(m.name)]; !ok {
+				r.field(m.name)
+			}
+			continue
+		}
+		f := r.field(m.name)
+		var err error
+		if vs, ok := m.value.([]interface{}); ok {
+			err = f.addArray(vs)
+		} else {
+			err = f.addScalar(m.value)
+		}
+		if err != nil {
+			return err
+		}
+		f.present++
+	}
+	return nil
+}
+
+func (r *inferredRecord) field(name string) *inferredField {
+	f, ok := r.byName[strings.ToLower(name)]
+	if !ok {
+		f = &inferredField{name: name}
+		r.byName[strings.ToLower(name)] = f
+		r.fields = append(r.fields, f)
+	}
+	return f
+}
+
+func (r *inferredRecord) schema() Schema {
+	var s Schema
+	for _, f := range r.fields {
+		fs := &FieldSchema{
+			Name:     f.name,
+			Type:     f.typ,
+			Repeated: f.repeated,
+			Required: !f.repeated && f.typ != "" && f.present == r.count,
+		}
+		if fs.Type == "" {
+			fs.Type = StringFieldType
+		}
+		if f.record != nil {
+			fs.Schema = f.record.schema()
+		}
+		s = append(s, fs)
+	}
+	return s
+}
+
+func (f *inferredField) addArray(vs []interface{}) error {
+	if f.scalar {
+		return fmt.Errorf("bigquery: field %q is both repeated and not repeated", f.name)
+	}
+	f.repeated = true
+	for _, v := range vs {
+		if _, ok := v.([]interface{}); ok {
+			return fmt.Errorf("bigquery: field %q has nested arrays", f.name)
+		}
+		if v == nil {
+			return fmt.Errorf("bigquery: repeated field %q has a null element", f.name)
+		}
+		if err := f.addValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *inferredField) addScalar(v interface{}) error {
+	if f.repeated {
+		return fmt.Errorf("bigquery: field %q is both repeated and not repeated", f.name)
+	}
+	f.scalar = true
+	return f.addValue(v)
+}
+
+func (f *inferredField) addValue(v interface{}) error {
+	var t FieldType
+	switch v := v.(type) {
+	case jsonObject:
+		if f.record == nil {
+			f.record = &inferredRecord{}
+		}
+		if err := f.record.add(v); err != nil {
+			return err
+		}
+		t = RecordFieldType
+	case bool:
+		t = BooleanFieldType
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			t = IntegerFieldType
+		} else {
+			t = FloatFieldType
+		}
+	case string:
+		t = stringType(v)
+	case FieldType: // from csvValue
+		t = v
+	default:
+		return fmt.Errorf("bigquery: field %q has unsupported value %v (%T)", f.name, v, v)
+	}
+	if (f.typ == RecordFieldType) != (t == RecordFieldType) && f.typ != "" {
+		return fmt.Errorf("bigquery: field %q is both a RECORD and a %s", f.name, nonRecord(f.typ, t))
+	}
+	f.typ = mergeFieldTypes(f.typ, t)
+	return nil
+}
+
+func nonRecord(t1, t2 FieldType) FieldType {
+	if t1 == RecordFieldType {
+		return t2
+	}
+	return t1
+}
+
+// mergeFieldTypes returns the narrowest type that holds values of both t1 and
+// t2. t1 may be empty.
+func mergeFieldTypes(t1, t2 FieldType) FieldType {
+	switch {
+	case t1 == "" || t1 == t2:
+		return t2
+	case t1 == IntegerFieldType && t2 == FloatFieldType,
+		t1 == FloatFieldType && t2 == IntegerFieldType:
+		return FloatFieldType
+	default:
+		return StringFieldType
+	}
+}
+
+// stringType returns the type of a string value: one of the date and time
+// types if it has the format that BigQuery uses for them, and STRING otherwise.
+func stringType(s string) FieldType {
+	if _, err := civil.ParseDate(s); err == nil {
+		return DateFieldType
+	}
+	if _, err := civil.ParseTime(s); err == nil {
+		return TimeFieldType
+	}
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+	if _, err := civil.ParseDateTime(s); err == nil {
+		return DateTimeFieldType
+	}
+	if strings.HasSuffix(s, " UTC") {
+		s = strings.TrimSuffix(s, " UTC") + "Z"
+	}
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return TimestampFieldType
+	}
+	return StringFieldType
+}
+
+// csvValue returns the type of a non-empty CSV value. Unlike JSON, CSV does
+// not distinguish numbers and booleans from strings.
+func csvValue(s string) FieldType {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return IntegerFieldType
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return FloatFieldType
+	}
+	if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+		return BooleanFieldType
+	}
+	return stringType(s)
+}
+
+// A jsonObject is a decoded JSON object whose members are in their original
+// order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	name  string
+	value interface{}
+}
+
+// decodeOrderedJSON decodes the next JSON value from dec, which must use
+// json.Number. It is like dec.Decode into an interface{}, except that objects
+// are decoded to jsonObjects.
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := jsonObject{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			obj = append(obj, jsonMember{tok.(string), v})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return obj, nil
+	case json.Delim('['):
+		vs := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			vs = append(vs, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return vs, nil
+	default:
+		return tok, nil
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/internal/pretty"
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestSchemaBuilderJSON(t *testing.T) {
+	const rows = `
+{"name": "a", "n": 1, "x": 1, "tags": ["t1"], "addr": {"city": "c", "zip": 1}, "when": "2018-01-02 03:04:05 UTC"}
+{"name": "b", "n": 2, "x": 1.5, "tags": [], "addr": {"city": "d"}, "d": "2018-01-02", "nil": null}
+{"name": "c", "n": 3, "x": null, "addr": {"city": "e", "zip": 2}, "d": "2018-01-03", "mixed": true}
+{"name": "d", "n": 4, "mixed": "s", "t": "12:30:00", "dt": "2018-01-02T12:30:00", "ts": "2018-01-02T12:30:00Z"}
+`
+	b := NewSchemaBuilder()
+	if err := b.AddJSON(strings.NewReader(rows)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.Schema()
+	want := Schema{
+		{Name: "name", Type: StringFieldType, Required: true},
+		{Name: "n", Type: IntegerFieldType, Required: true},
+		{Name: "x", Type: FloatFieldType},
+		{Name: "tags", Type: StringFieldType, Repeated: true},
+		{Name: "addr", Type: RecordFieldType, Schema: Schema{
+			{Name: "city", Type: StringFieldType, Required: true},
+			{Name: "zip", Type: IntegerFieldType},
+		}},
+		{Name: "when", Type: TimestampFieldType},
+		{Name: "d", Type: DateFieldType},
+		{Name: "nil", Type: StringFieldType},
+		{Name: "mixed", Type: StringFieldType},
+		{Name: "t", Type: TimeFieldType},
+		{Name: "dt", Type: DateTimeFieldType},
+		{Name: "ts", Type: TimestampFieldType},
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", pretty.Value(got), pretty.Value(want))
+	}
+}
+
+func TestSchemaBuilderCSV(t *testing.T) {
+	const rows = `name,n,x,ok,d
+a,1,1,true,2018-01-02
+b,2,2.5,FALSE,
+c,,3,true,2018-01-04
+`
+	b := NewSchemaBuilder()
+	if err := b.AddCSV(strings.NewReader(rows)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.Schema()
+	want := Schema{
+		{Name: "name", Type: StringFieldType, Required: true},
+		{Name: "n", Type: IntegerFieldType},
+		{Name: "x", Type: FloatFieldType, Required: true},
+		{Name: "ok", Type: BooleanFieldType, Required: true},
+		{Name: "d", Type: DateFieldType},
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", pretty.Value(got), pretty.Value(want))
+	}
+}
+
+func TestSchemaBuilderErrors(t *testing.T) {
+	for _, rows := range []string{
+		`[1, 2]`,
+		`{"a": 1`,
+		`{"a": [1]} {"a": 2}`,
+		`{"a": 1} {"a": [2]}`,
+		`{"a": [[1]]}`,
+		`{"a": [1, null]}`,
+		`{"a": {"b": 1}} {"a": 2}`,
+		`{"a": 2} {"a": {"b": 1}}`,
+	} {
+		b := NewSchemaBuilder()
+		if err := b.AddJSON(strings.NewReader(rows)); err == nil {
+			t.Errorf("%s: got nil, want error", rows)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaChangeKind is the kind of a SchemaChange.
+type SchemaChangeKind int
+
+const (
+	// FieldAdded means that a field is in the new schema but not the old one.
+	FieldAdded SchemaChangeKind = iota
+	// FieldRemoved means that a field is in the old schema but not the new one.
+	FieldRemoved
+	// FieldTypeChanged means that the type of a field has changed.
+	FieldTypeChanged
+	// FieldModeChanged means that a field has changed between required,
+	// nullable and repeated.
+	FieldModeChanged
+	// FieldDescriptionChanged means that the description of a field has changed.
+	FieldDescriptionChanged
+)
+
+func (k SchemaChangeKind) String() string {
+	switch k {
+	case FieldAdded:
+		return "added"
+	case FieldRemoved:
+		return "removed"
+	case FieldTypeChanged:
+		return "type changed"
+	case FieldModeChanged:
+		return "mode changed"
+	case FieldDescriptionChanged:
+		return "description changed"
+	default:
+		return fmt.Sprintf("SchemaChangeKind(%d)", int(k))
+	}
+}
+
+// A SchemaChange is a difference between two schemas.
+type SchemaChange struct {
+	Kind SchemaChangeKind
+
+	// The name of the field, qualified by the names of the RECORD fields that
+	// contain it, separated by dots.
+	Path string
+
+	// The field in the old and new schemas. Old is nil if Kind is FieldAdded,
+	// and New is nil if Kind is FieldRemoved.
+	Old, New *FieldSchema
+
+	// Allowed reports whether the change can be made to the schema of an
+	// existing table. BigQuery allows adding fields that are not required, and
+	// making required fields nullable.
+	Allowed bool
+}
+
+func (c *SchemaChange) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Kind)
+}
+
+// A SchemaDiff holds the differences between two schemas.
+type SchemaDiff struct {
+	Changes []*SchemaChange
+
+	// The schema that results from applying the changes to the old schema.
+	merged Schema
+}
+
+// DiffSchemas compares the schema of a table, from, with the desired schema,
+// to. Fields are matched by name, ignoring case. The order of fields does not
+// matter.
+func DiffSchemas(from, to Schema) *SchemaDiff {
+	d := &SchemaDiff{}
+	d.merged = d.diff("", from, to)
+	return d
+}
+
+// Compatible reports whether all of the changes are allowed.
+func (d *SchemaDiff) Compatible() bool {
+	for _, c := range d.Changes {
+		if !c.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Update returns the update that changes a table's schema from the old schema
+// to the new one. New fields are added after the existing ones, since BigQuery
+// does not allow fields to be reordered. If there are no changes, the update is
+// empty.
+//
+// Update returns an error if any of the changes is not allowed.
+func (d *SchemaDiff) Update() (TableMetadataToUpdate, error) {
+	var bad []string
+	for _, c := range d.Changes {
+		if !c.Allowed {
+			bad = append(bad, c.String())
+		}
+	}
+	if len(bad) > 0 {
+		return TableMetadataToUpdate{}, fmt.Errorf("bigquery: incompatible schema changes: %s", strings.Join(bad, ", "))
+	}
+	if len(d.Changes) == 0 {
+		return TableMetadataToUpdate{}, nil
+	}
+	return TableMetadataToUpdate{Schema: d.merged}, nil
+}
+
+// diff records the changes from one list of fields to another, and returns
+// the merged list.
+func (d *SchemaDiff) diff(prefix string, from, to Schema) Schema {
+	toByName := make(map[string]*FieldSchema)
+	for _, f := range to {
+		toByName[strings.ToLower(f.Name)] = f
+	}
+	fromByName := make(map[string]bool)
+	var merged Schema
+	for _, old := range from {
+		fromByName[strings.ToLower(old.Name)] = true
+		path := prefix + old.Name
+		nu, ok := toByName[strings.ToLower(old.Name)]
+		if !ok {
+			d.add(FieldRemoved, path, old, nil, false)
+			merged = append(merged, old)
+			continue
+		}
+		m := *nu
+		m.Name = old.Name
+		if old.Type != nu.Type {
+			d.add(FieldTypeChanged, path, old, nu, false)
+			m.Schema = old.Schema
+		} else if old.Type == RecordFieldType {
+			m.Schema = d.diff(path+".", old.Schema, nu.Schema)
+		}
+		if om, nm := fieldMode(old), fieldMode(nu); om != nm {
+			d.add(FieldModeChanged, path, old, nu, om == "REQUIRED" && nm == "NULLABLE")
+		}
+		if old.Description != nu.Description {
+			d.add(FieldDescriptionChanged, path, old, nu, true)
+		}
+		merged = append(merged, &m)
+	}
+	for _, nu := range to {
+		if !fromByName[strings.ToLower(nu.Name)] {
+			d.add(FieldAdded, prefix+nu.Name, nil, nu, fieldMode(nu) != "REQUIRED")
+			merged = append(merged, nu)
+		}
+	}
+	return merged
+}
+
+func (d *SchemaDiff) add(kind SchemaChangeKind, path string, old, nu *FieldSchema, allowed bool) {
+	d.Changes = append(d.Changes, &SchemaChange{
+		Kind:    kind,
+		Path:    path,
+		Old:     old,
+		New:     nu,
+		Allowed: allowed,
+	})
+}
+
+func fieldMode(f *FieldSchema) string {
+	switch {
+	case f.Repeated:
+		return "REPEATED"
+	case f.Required:
+		return "REQUIRED"
+	default:
+		return "NULLABLE"
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"testing"
+
+	"cloud.google.com/go/internal/pretty"
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestDiffSchemasCompatible(t *testing.T) {
+	from := Schema{
+		{Name: "a", Type: StringFieldType, Required: true},
+		{Name: "rec", Type: RecordFieldType, Schema: Schema{
+			{Name: "x", Type: IntegerFieldType},
+		}},
+		{Name: "b", Type: IntegerFieldType},
+	}
+	to := Schema{
+		{Name: "c", Type: FloatFieldType},
+		{Name: "B", Type: IntegerFieldType, Description: "bee"},
+		{Name: "a", Type: StringFieldType},
+		{Name: "rec", Type: RecordFieldType, Schema: Schema{
+			{Name: "y", Type: StringFieldType, Repeated: true},
+			{Name: "x", Type: IntegerFieldType},
+		}},
+	}
+	d := DiffSchemas(from, to)
+	var got []string
+	for _, c := range d.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"a: mode changed",
+		"rec.y: added",
+		"b: description changed",
+		"c: added",
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !d.Compatible() {
+		t.Error("got incompatible, want compatible")
+	}
+	tm, err := d.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSchema := Schema{
+		{Name: "a", Type: StringFieldType},
+		{Name: "rec", Type: RecordFieldType, Schema: Schema{
+			{Name: "x", Type: IntegerFieldType},
+			{Name: "y", Type: StringFieldType, Repeated: true},
+		}},
+		{Name: "b", Type: IntegerFieldType, Description: "bee"},
+		{Name: "c", Type: FloatFieldType},
+	}
+	if !testutil.Equal(tm.Schema, wantSchema) {
+		t.Errorf("got\n%v\nwant\n%v", pretty.Value(tm.Schema), pretty.Value(wantSchema))
+	}
+
+	tm, err = DiffSchemas(from, from).Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.Schema != nil {
+		t.Errorf("no changes: got schema %v, want nil", tm.Schema)
+	}
+}
+
+func TestDiffSchemasIncompatible(t *testing.T) {
+	for _, test := range []struct {
+		from, to Schema
+		want     SchemaChangeKind
+	}{
+		{
+			Schema{{Name: "a", Type: StringFieldType}},
+			Schema{},
+			FieldRemoved,
+		},
+		{
+			Schema{},
+			Schema{{Name: "a", Type: StringFieldType, Required: true}},
+			FieldAdded,
+		},
+		{
+			Schema{{Name: "a", Type: IntegerFieldType}},
+			Schema{{Name: "a", Type: FloatFieldType}},
+			FieldTypeChanged,
+		},
+		{
+			Schema{{Name: "a", Type: StringFieldType}},
+			Schema{{Name: "a", Type: StringFieldType, Required: true}},
+			FieldModeChanged,
+		},
+		{
+			Schema{{Name: "a", Type: StringFieldType}},
+			Schema{{Name: "a", Type: StringFieldType, Repeated: true}},
+			FieldModeChanged,
+		},
+	} {
+		d := DiffSchemas(test.from, test.to)
+		if d.Compatible() {
+			t.Errorf("%v -> %v: got compatible, want incompatible", test.from, test.to)
+		}
+		if len(d.Changes) != 1 || d.Changes[0].Kind != test.want {
+			t.Errorf("%v -> %v: got %v, want one change of kind %v", test.from, test.to, d.Changes, test.want)
+		}
+		if _, err := d.Update(); err == nil {
+			t.Errorf("%v -> %v: Update: got nil, want error", test.from, test.to)
+		}
+	}
+}
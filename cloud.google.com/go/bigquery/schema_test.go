@@ -249,6 +249,7 @@ type allTime struct {
 	Time      civil.Time
 	Date      civil.Date
 	DateTime  civil.DateTime
+	CivilTS   civil.Timestamp
 }
 
 type allNumeric struct {
@@ -320,6 +321,7 @@ func TestSimpleInference(t *testing.T) {
 				reqField("Time", "TIME"),
 				reqField("Date", "DATE"),
 				reqField("DateTime", "DATETIME"),
+				reqField("CivilTS", "TIMESTAMP"),
 			},
 		},
 		{
@@ -338,6 +338,15 @@ func determineSetFunc(ftype reflect.Type, stype FieldType) setFunc {
 		if ftype == typeOfGoTime {
 			return setAny
 		}
+		if ftype == typeOfCivilTimestamp {
+			return func(v reflect.Value, x interface{}) error {
+				if x == nil {
+					return errNoNulls
+				}
+				v.Set(reflect.ValueOf(civil.TimestampOf(x.(time.Time))))
+				return nil
+			}
+		}
 		if ftype == typeOfNullTimestamp {
 			return func(v reflect.Value, x interface{}) error {
 				return setNull(v, x, func() interface{} {
@@ -649,6 +658,9 @@ func toUploadValue(val interface{}, fs *FieldSchema) interface{} {
 	if fs.Type == TimeFieldType || fs.Type == DateTimeFieldType || fs.Type == NumericFieldType {
 		return toUploadValueReflect(reflect.ValueOf(val), fs)
 	}
+	if fs.Type == TimestampFieldType && val != nil && isCivilTimestamp(reflect.TypeOf(val)) {
+		return toUploadValueReflect(reflect.ValueOf(val), fs)
+	}
 	return val
 }
 
@@ -668,6 +680,12 @@ func toUploadValueReflect(v reflect.Value, fs *FieldSchema) interface{} {
 		return formatUploadValue(v, fs, func(v reflect.Value) string {
 			return CivilDateTimeString(v.Interface().(civil.DateTime))
 		})
+	case TimestampFieldType:
+		if isCivilTimestamp(v.Type()) {
+			return formatUploadValue(v, fs, func(v reflect.Value) string {
+				return CivilTimestampString(v.Interface().(civil.Timestamp))
+			})
+		}
 	case NumericFieldType:
 		if r, ok := v.Interface().(*big.Rat); ok && r == nil {
 			return nil
@@ -675,14 +693,22 @@ func toUploadValueReflect(v reflect.Value, fs *FieldSchema) interface{} {
 		return formatUploadValue(v, fs, func(v reflect.Value) string {
 			return NumericString(v.Interface().(*big.Rat))
 		})
-	default:
-		if !fs.Repeated || v.Len() > 0 {
-			return v.Interface()
-		}
-		// The service treats a null repeated field as an error. Return
-		// nil to omit the field entirely.
-		return nil
 	}
+	if !fs.Repeated || v.Len() > 0 {
+		return v.Interface()
+	}
+	// The service treats a null repeated field as an error. Return
+	// nil to omit the field entirely.
+	return nil
+}
+
+// isCivilTimestamp reports whether t is civil.Timestamp or a slice or array of
+// it.
+func isCivilTimestamp(t reflect.Type) bool {
+	if k := t.Kind(); k == reflect.Slice || k == reflect.Array {
+		t = t.Elem()
+	}
+	return t == typeOfCivilTimestamp
 }
 
 func formatUploadValue(v reflect.Value, fs *FieldSchema, cvt func(reflect.Value) string) interface{} {
@@ -725,6 +751,25 @@ func CivilDateTimeString(dt civil.DateTime) string {
 	return dt.Date.String() + " " + CivilTimeString(dt.Time)
 }
 
+// CivilTimestampString returns a string representing a civil.Timestamp in a
+// format compatible with BigQuery SQL. It formats the date and time with
+// CivilDateTimeString, followed by the zone. Zone names are separated from the
+// time by a space.
+//
+// Use CivilTimestampString when using civil.Timestamp in DML, for example in
+// INSERT statements.
+func CivilTimestampString(ts civil.Timestamp) string {
+	s := CivilDateTimeString(ts.DateTime)
+	switch {
+	case ts.Zone == "":
+		return s
+	case ts.Zone[0] == '+' || ts.Zone[0] == '-':
+		return s + ts.Zone
+	default:
+		return s + " " + ts.Zone
+	}
+}
+
 // parseCivilDateTime parses a date-time represented in a BigQuery SQL
 // compatible format and returns a civil.DateTime.
 func parseCivilDateTime(s string) (civil.DateTime, error) {
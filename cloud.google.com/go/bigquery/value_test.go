@@ -627,6 +627,60 @@ func TestStructSaver(t *testing.T) {
 		})
 }
 
+func TestCivilTimestamp(t *testing.T) {
+	schema := Schema{
+		{Name: "ts", Type: TimestampFieldType},
+		{Name: "tsr", Type: TimestampFieldType, Repeated: true},
+	}
+	type T struct {
+		TS  civil.Timestamp
+		TSR []civil.Timestamp
+	}
+
+	// Loading.
+	var got T
+	mustLoad(t, &got, schema, []Value{testTimestamp, []Value{testTimestamp}})
+	want := T{
+		TS:  civil.Timestamp{DateTime: civil.DateTimeOf(testTimestamp), Zone: "UTC"},
+		TSR: []civil.Timestamp{{DateTime: civil.DateTimeOf(testTimestamp), Zone: "UTC"}},
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if err := load(&got, schema, []Value{nil, nil}); err == nil {
+		t.Error("loading NULL: got nil, want error")
+	}
+
+	// Saving.
+	ts := civil.Timestamp{DateTime: testDateTime, Zone: "America/Los_Angeles"}
+	wantMap := map[string]Value{
+		"ts":  "2016-11-05 07:50:22.000000 America/Los_Angeles",
+		"tsr": []string{"2016-11-05 07:50:22.000000 America/Los_Angeles"},
+	}
+	m, _, err := (&StructSaver{Schema: schema, Struct: T{TS: ts, TSR: []civil.Timestamp{ts}}}).Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := testutil.Diff(m, wantMap); diff != "" {
+		t.Errorf("StructSaver: %s", diff)
+	}
+	m, _, err = (&ValuesSaver{Schema: schema, Row: []Value{ts, []civil.Timestamp{ts}}}).Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := testutil.Diff(m, wantMap); diff != "" {
+		t.Errorf("ValuesSaver: %s", diff)
+	}
+	// time.Time values are unchanged.
+	m, _, err = (&ValuesSaver{Schema: schema, Row: []Value{testTimestamp, []time.Time{}}}).Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := testutil.Diff(m, map[string]Value{"ts": testTimestamp, "tsr": []time.Time{}}); diff != "" {
+		t.Errorf("ValuesSaver with time.Time: %s", diff)
+	}
+}
+
 func TestStructSaverErrors(t *testing.T) {
 	type (
 		badField struct {
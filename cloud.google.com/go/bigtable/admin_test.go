@@ -252,10 +252,6 @@ func TestAdminSnapshotIntegration(t *testing.T) {
 	}
 	defer testEnv.Close()
 
-	if !testEnv.Config().UseProd {
-		t.Skip("emulator doesn't support snapshots")
-	}
-
 	timeout := 2 * time.Second
 	if testEnv.Config().UseProd {
 		timeout = 5 * time.Minute
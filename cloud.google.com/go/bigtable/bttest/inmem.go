@@ -42,7 +42,11 @@ import (
 	"sync"
 	"time"
 
+	"cloud.google.com/go/internal/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	emptypb "github.com/golang/protobuf/ptypes/empty"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/btree"
 	"golang.org/x/net/context"
@@ -77,9 +81,11 @@ type Server struct {
 // It is a separate and unexported type so the API won't be cluttered with
 // methods that are only relevant to the fake's implementation.
 type server struct {
-	mu     sync.Mutex
-	tables map[string]*table // keyed by fully qualified name
-	gcc    chan int          // set when gcloop starts, closed when server shuts down
+	mu        sync.Mutex
+	tables    map[string]*table    // keyed by fully qualified name
+	snapshots map[string]*snapshot // keyed by fully qualified name
+	gcc       chan int             // set when gcloop starts, closed when server shuts down
+	persist   *persister           // nil unless the server persists its state
 
 	// Any unimplemented methods will cause a panic.
 	btapb.BigtableTableAdminServer
@@ -90,6 +96,13 @@ type server struct {
 // The Server will be listening for gRPC connections, without TLS,
 // on the provided address. The resolved address is named by the Addr field.
 func NewServer(laddr string, opt ...grpc.ServerOption) (*Server, error) {
+	return newServer(laddr, &server{
+		tables:    make(map[string]*table),
+		snapshots: make(map[string]*snapshot),
+	}, opt)
+}
+
+func newServer(laddr string, ss *server, opt []grpc.ServerOption) (*Server, error) {
 	l, err := net.Listen("tcp", laddr)
 	if err != nil {
 		return nil, err
@@ -99,9 +112,7 @@ func NewServer(laddr string, opt ...grpc.ServerOption) (*Server, error) {
 		Addr: l.Addr().String(),
 		l:    l,
 		srv:  grpc.NewServer(opt...),
-		s: &server{
-			tables: make(map[string]*table),
-		},
+		s:    ss,
 	}
 	btapb.RegisterBigtableTableAdminServer(s.srv, s.s)
 	btpb.RegisterBigtableServer(s.srv, s.s)
@@ -121,24 +132,54 @@ func (s *Server) Close() {
 
 	s.srv.Stop()
 	s.l.Close()
+	s.s.persist.close(s.s)
 }
 
 func (s *server) CreateTable(ctx context.Context, req *btapb.CreateTableRequest) (*btapb.Table, error) {
 	tbl := req.Parent + "/tables/" + req.TableId
 
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	if _, ok := s.tables[tbl]; ok {
-		s.mu.Unlock()
 		return nil, status.Errorf(codes.AlreadyExists, "table %q already exists", tbl)
 	}
-	s.tables[tbl] = newTable(req)
-	s.mu.Unlock()
+	t := newTable(req)
+	if err := s.persist.logTable(tbl, t); err != nil {
+		return nil, err
+	}
+	s.tables[tbl] = t
 
 	return &btapb.Table{Name: tbl}, nil
 }
 
-func (s *server) CreateTableFromSnapshot(context.Context, *btapb.CreateTableFromSnapshotRequest) (*longrunning.Operation, error) {
-	return nil, status.Errorf(codes.Unimplemented, "the emulator does not currently support snapshots")
+func (s *server) CreateTableFromSnapshot(ctx context.Context, req *btapb.CreateTableFromSnapshotRequest) (*longrunning.Operation, error) {
+	requestTime := time.Now()
+	tbl := req.Parent + "/tables/" + req.TableId
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.findSnapshot(req.SourceSnapshot, requestTime)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.tables[tbl]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "table %q already exists", tbl)
+	}
+	if err := s.persist.logRestore(tbl, req.SourceSnapshot); err != nil {
+		return nil, err
+	}
+	t := snap.table.copy(tbl)
+	s.tables[tbl] = t
+
+	return doneOperation(tbl, &btapb.Table{
+		Name:           tbl,
+		ColumnFamilies: toColumnFamilies(t.families),
+		Granularity:    btapb.Table_MILLIS,
+	}, &btapb.CreateTableFromSnapshotMetadata{
+		OriginalRequest: req,
+		RequestTime:     mustTimestampProto(requestTime),
+		FinishTime:      mustTimestampProto(time.Now()),
+	})
 }
 
 func (s *server) ListTables(ctx context.Context, req *btapb.ListTablesRequest) (*btapb.ListTablesResponse, error) {
@@ -178,6 +219,9 @@ func (s *server) DeleteTable(ctx context.Context, req *btapb.DeleteTableRequest)
 	if _, ok := s.tables[req.Name]; !ok {
 		return nil, status.Errorf(codes.NotFound, "table %q not found", req.Name)
 	}
+	if err := s.persist.logDeleteTable(req.Name); err != nil {
+		return nil, err
+	}
 	delete(s.tables, req.Name)
 	return &emptypb.Empty{}, nil
 }
@@ -223,6 +267,9 @@ func (s *server) ModifyColumnFamilies(ctx context.Context, req *btapb.ModifyColu
 			tbl.families[mod.Id] = newcf
 		}
 	}
+	if err := s.persist.logFamilies(req.Name, tbl); err != nil {
+		return nil, err
+	}
 
 	s.needGC()
 	return &btapb.Table{
@@ -234,39 +281,19 @@ func (s *server) ModifyColumnFamilies(ctx context.Context, req *btapb.ModifyColu
 
 func (s *server) DropRowRange(ctx context.Context, req *btapb.DropRowRangeRequest) (*emptypb.Empty, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	tbl, ok := s.tables[req.Name]
+	s.mu.Unlock()
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, "table %q not found", req.Name)
 	}
 
-	if req.GetDeleteAllDataFromTable() {
-		tbl.rows = btree.New(btreeDegree)
-	} else {
-		// Delete rows by prefix.
-		prefixBytes := req.GetRowKeyPrefix()
-		if prefixBytes == nil {
-			return nil, fmt.Errorf("missing row key prefix")
-		}
-		prefix := string(prefixBytes)
-
-		// The BTree does not specify what happens if rows are deleted during
-		// iteration, and it provides no "delete range" method.
-		// So we collect the rows first, then delete them one by one.
-		var rowsToDelete []*row
-		tbl.rows.AscendGreaterOrEqual(btreeKey(prefix), func(i btree.Item) bool {
-			r := i.(*row)
-			if strings.HasPrefix(r.key, prefix) {
-				rowsToDelete = append(rowsToDelete, r)
-				return true
-			} else {
-				return false // stop iteration
-			}
-		})
-		for _, r := range rowsToDelete {
-			tbl.rows.Delete(r)
-		}
+	if !req.GetDeleteAllDataFromTable() && req.GetRowKeyPrefix() == nil {
+		return nil, fmt.Errorf("missing row key prefix")
+	}
+	if err := s.persist.logDropRowRange(req.Name, req.GetRowKeyPrefix(), req.GetDeleteAllDataFromTable()); err != nil {
+		return nil, err
 	}
+	tbl.dropRowRange(string(req.GetRowKeyPrefix()), req.GetDeleteAllDataFromTable())
 	return &emptypb.Empty{}, nil
 }
 
@@ -300,18 +327,157 @@ func (s *server) CheckConsistency(ctx context.Context, req *btapb.CheckConsisten
 	}, nil
 }
 
-func (s *server) SnapshotTable(context.Context, *btapb.SnapshotTableRequest) (*longrunning.Operation, error) {
-	return nil, status.Errorf(codes.Unimplemented, "the emulator does not currently support snapshots")
+// maxSnapshotTTL is the longest, and default, lifetime of a snapshot.
+const maxSnapshotTTL = 365 * 24 * time.Hour
+
+func (s *server) SnapshotTable(ctx context.Context, req *btapb.SnapshotTableRequest) (*longrunning.Operation, error) {
+	requestTime := time.Now()
+	ttl := maxSnapshotTTL
+	if req.Ttl != nil {
+		d, err := ptypes.Duration(req.Ttl)
+		if err != nil || d <= 0 || d > maxSnapshotTTL {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid ttl %v", req.Ttl)
+		}
+		ttl = d
+	}
+	if req.SnapshotId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "missing snapshot ID")
+	}
+	name := req.Cluster + "/snapshots/" + req.SnapshotId
+
+	s.mu.Lock()
+	tbl, ok := s.tables[req.Name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "table %q not found", req.Name)
+	}
+	snap := &snapshot{
+		name:        name,
+		sourceTable: req.Name,
+		description: req.Description,
+		createTime:  requestTime,
+		deleteTime:  requestTime.Add(ttl),
+		table:       tbl.copy(req.Name),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSnapshots(requestTime)
+	if _, ok := s.snapshots[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "snapshot %q already exists", name)
+	}
+	if err := s.persist.logSnapshot(snap); err != nil {
+		return nil, err
+	}
+	if s.snapshots == nil {
+		s.snapshots = make(map[string]*snapshot)
+	}
+	s.snapshots[name] = snap
+
+	return doneOperation(name, snap.proto(), &btapb.SnapshotTableMetadata{
+		OriginalRequest: req,
+		RequestTime:     mustTimestampProto(requestTime),
+		FinishTime:      mustTimestampProto(time.Now()),
+	})
 }
 
-func (s *server) GetSnapshot(context.Context, *btapb.GetSnapshotRequest) (*btapb.Snapshot, error) {
-	return nil, status.Errorf(codes.Unimplemented, "the emulator does not currently support snapshots")
+func (s *server) GetSnapshot(ctx context.Context, req *btapb.GetSnapshotRequest) (*btapb.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.findSnapshot(req.Name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return snap.proto(), nil
 }
-func (s *server) ListSnapshots(context.Context, *btapb.ListSnapshotsRequest) (*btapb.ListSnapshotsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "the emulator does not currently support snapshots")
+
+func (s *server) ListSnapshots(ctx context.Context, req *btapb.ListSnapshotsRequest) (*btapb.ListSnapshotsResponse, error) {
+	// A cluster of "-" means all clusters in the instance.
+	prefix := req.Parent + "/snapshots/"
+	if strings.HasSuffix(req.Parent, "/clusters/-") {
+		prefix = strings.TrimSuffix(req.Parent, "-")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSnapshots(time.Now())
+	var names []string
+	for name := range s.snapshots {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	from, to, nextToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(names))
+	if err != nil {
+		return nil, err
+	}
+	res := &btapb.ListSnapshotsResponse{NextPageToken: nextToken}
+	for _, name := range names[from:to] {
+		res.Snapshots = append(res.Snapshots, s.snapshots[name].proto())
+	}
+	return res, nil
+}
+
+func (s *server) DeleteSnapshot(ctx context.Context, req *btapb.DeleteSnapshotRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.findSnapshot(req.Name, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.persist.logDeleteSnapshot(req.Name); err != nil {
+		return nil, err
+	}
+	delete(s.snapshots, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// findSnapshot returns the snapshot with the given name.
+// s.mu should be held.
+func (s *server) findSnapshot(name string, now time.Time) (*snapshot, error) {
+	s.expireSnapshots(now)
+	snap, ok := s.snapshots[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "snapshot %q not found", name)
+	}
+	return snap, nil
+}
+
+// expireSnapshots deletes snapshots whose delete time has passed.
+// s.mu should be held.
+func (s *server) expireSnapshots(now time.Time) {
+	for name, snap := range s.snapshots {
+		if now.After(snap.deleteTime) {
+			delete(s.snapshots, name)
+		}
+	}
 }
-func (s *server) DeleteSnapshot(context.Context, *btapb.DeleteSnapshotRequest) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "the emulator does not currently support snapshots")
+
+// doneOperation returns a completed long-running operation with the given
+// response and metadata.
+func doneOperation(name string, res, md proto.Message) (*longrunning.Operation, error) {
+	anyRes, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	anyMD, err := ptypes.MarshalAny(md)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &longrunning.Operation{
+		Name:     name + "/operations/done",
+		Metadata: anyMD,
+		Done:     true,
+		Result:   &longrunning.Operation_Response{Response: anyRes},
+	}, nil
+}
+
+func mustTimestampProto(t time.Time) *tspb.Timestamp {
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		panic(err)
+	}
+	return ts
 }
 
 func (s *server) ReadRows(req *btpb.ReadRowsRequest, stream btpb.Bigtable_ReadRowsServer) error {
@@ -726,6 +892,9 @@ func (s *server) MutateRow(ctx context.Context, req *btpb.MutateRowRequest) (*bt
 	if err := applyMutations(tbl, r, req.Mutations, fs); err != nil {
 		return nil, err
 	}
+	if err := s.persist.logRow(req.TableName, r); err != nil {
+		return nil, err
+	}
 	return &btpb.MutateRowResponse{}, nil
 }
 
@@ -747,6 +916,9 @@ func (s *server) MutateRows(req *btpb.MutateRowsRequest, stream btpb.Bigtable_Mu
 		if err := applyMutations(tbl, r, entry.Mutations, fs); err != nil {
 			code = int32(codes.Internal)
 			msg = err.Error()
+		} else if err := s.persist.logRow(req.TableName, r); err != nil {
+			code = int32(codes.Internal)
+			msg = err.Error()
 		}
 		res.Entries[i] = &btpb.MutateRowsResponse_Entry{
 			Index:  int64(i),
@@ -793,6 +965,9 @@ func (s *server) CheckAndMutateRow(ctx context.Context, req *btpb.CheckAndMutate
 	if err := applyMutations(tbl, r, muts, fs); err != nil {
 		return nil, err
 	}
+	if err := s.persist.logRow(req.TableName, r); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
@@ -983,6 +1158,9 @@ func (s *server) ReadModifyWriteRow(ctx context.Context, req *btpb.ReadModifyWri
 		resultFamily.cellsByColumn(col)           // create the column
 		resultFamily.cells[col] = []cell{newCell} // overwrite the cells
 	}
+	if err := s.persist.logRow(req.TableName, r); err != nil {
+		return nil, err
+	}
 
 	// Build the response using the result row
 	res := &btpb.Row{
@@ -1111,6 +1289,62 @@ func newTable(ctr *btapb.CreateTableRequest) *table {
 	}
 }
 
+// copy returns a deep copy of the table, named name.
+// Cell values are aliased.
+func (t *table) copy(name string) *table {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	nt := &table{
+		counter:  t.counter,
+		families: make(map[string]*columnFamily),
+		rows:     btree.New(btreeDegree),
+	}
+	for id, cf := range t.families {
+		nt.families[id] = &columnFamily{
+			name:   name + "/columnFamilies/" + id,
+			order:  cf.order,
+			gcRule: cf.gcRule,
+		}
+	}
+	t.rows.Ascend(func(i btree.Item) bool {
+		r := i.(*row)
+		r.mu.Lock()
+		nt.rows.ReplaceOrInsert(r.copy())
+		r.mu.Unlock()
+		return true
+	})
+	return nt
+}
+
+// dropRowRange deletes all rows whose keys start with prefix, or all rows
+// if all is true.
+func (t *table) dropRowRange(prefix string, all bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if all {
+		t.rows = btree.New(btreeDegree)
+		return
+	}
+	// The BTree does not specify what happens if rows are deleted during
+	// iteration, and it provides no "delete range" method.
+	// So we collect the rows first, then delete them one by one.
+	var rowsToDelete []*row
+	t.rows.AscendGreaterOrEqual(btreeKey(prefix), func(i btree.Item) bool {
+		r := i.(*row)
+		if strings.HasPrefix(r.key, prefix) {
+			rowsToDelete = append(rowsToDelete, r)
+			return true
+		} else {
+			return false // stop iteration
+		}
+	})
+	for _, r := range rowsToDelete {
+		t.rows.Delete(r)
+	}
+}
+
 func (t *table) validTimestamp(ts int64) bool {
 	if ts < minValidMilliSeconds || ts > maxValidMilliSeconds {
 		return false
@@ -1206,7 +1440,7 @@ func (r *row) copy() *row {
 		nr.families[fam.name] = &family{
 			name:     fam.name,
 			order:    fam.order,
-			colNames: fam.colNames,
+			colNames: append([]string(nil), fam.colNames...),
 			cells:    make(map[string][]cell),
 		}
 		for col, cs := range fam.cells {
@@ -1327,6 +1561,36 @@ func applyGC(cells []cell, rule *btapb.GcRule) []cell {
 	return cells
 }
 
+type snapshot struct {
+	name        string
+	sourceTable string
+	description string
+	createTime  time.Time
+	deleteTime  time.Time
+	table       *table // a copy of the source table when the snapshot was taken
+}
+
+func (s *snapshot) proto() *btapb.Snapshot {
+	var size int64
+	s.table.rows.Ascend(func(i btree.Item) bool {
+		size += int64(i.(*row).size())
+		return true
+	})
+	return &btapb.Snapshot{
+		Name: s.name,
+		SourceTable: &btapb.Table{
+			Name:           s.sourceTable,
+			ColumnFamilies: toColumnFamilies(s.table.families),
+			Granularity:    btapb.Table_MILLIS,
+		},
+		DataSizeBytes: size,
+		CreateTime:    mustTimestampProto(s.createTime),
+		DeleteTime:    mustTimestampProto(s.deleteTime),
+		State:         btapb.Snapshot_READY,
+		Description:   s.description,
+	}
+}
+
 type family struct {
 	name     string            // Column family name
 	order    uint64            // Creation order of column family
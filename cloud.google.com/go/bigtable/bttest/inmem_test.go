@@ -24,12 +24,16 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/btree"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/net/context"
 	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestConcurrentMutationsReadModifyAndGC(t *testing.T) {
@@ -1079,3 +1083,102 @@ func TestFilterRowWithBinaryColumnQualifier(t *testing.T) {
 		}
 	}
 }
+
+// tableRows returns the contents of tbl, for comparisons.
+func tableRows(tbl *table) []*btpb.Row {
+	tbl.mu.RLock()
+	defer tbl.mu.RUnlock()
+	var rows []*btpb.Row
+	tbl.rows.Ascend(func(i btree.Item) bool {
+		r := i.(*row)
+		r.mu.Lock()
+		rows = append(rows, r.proto())
+		r.mu.Unlock()
+		return true
+	})
+	return rows
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	s := &server{
+		tables: make(map[string]*table),
+	}
+	tblInfo, err := populateTable(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tableRows(s.tables[tblInfo.Name])
+
+	const cluster = "proj/instances/inst/clusters/c"
+	const snapName = cluster + "/snapshots/snap"
+	op, err := s.SnapshotTable(ctx, &btapb.SnapshotTableRequest{
+		Name:        tblInfo.Name,
+		Cluster:     cluster,
+		SnapshotId:  "snap",
+		Ttl:         ptypes.DurationProto(time.Hour),
+		Description: "desc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !op.Done {
+		t.Error("SnapshotTable: operation not done")
+	}
+	if _, err := s.SnapshotTable(ctx, &btapb.SnapshotTableRequest{Name: tblInfo.Name, Cluster: cluster, SnapshotId: "snap"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("SnapshotTable again: got %v, want AlreadyExists", err)
+	}
+
+	// Changes to the table after the snapshot don't affect it.
+	if _, err := s.DropRowRange(ctx, &btapb.DropRowRangeRequest{
+		Name:   tblInfo.Name,
+		Target: &btapb.DropRowRangeRequest_DeleteAllDataFromTable{DeleteAllDataFromTable: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := s.GetSnapshot(ctx, &btapb.GetSnapshotRequest{Name: snapName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.SourceTable.Name != tblInfo.Name || snap.Description != "desc" || snap.State != btapb.Snapshot_READY {
+		t.Errorf("GetSnapshot: got %+v", snap)
+	}
+	ct, _ := ptypes.Timestamp(snap.CreateTime)
+	dt, _ := ptypes.Timestamp(snap.DeleteTime)
+	if got := dt.Sub(ct); got != time.Hour {
+		t.Errorf("snapshot lifetime: got %v, want 1h", got)
+	}
+
+	for _, parent := range []string{cluster, "proj/instances/inst/clusters/-"} {
+		res, err := s.ListSnapshots(ctx, &btapb.ListSnapshotsRequest{Parent: parent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Snapshots) != 1 || res.Snapshots[0].Name != snapName {
+			t.Errorf("ListSnapshots(%q): got %v", parent, res.Snapshots)
+		}
+	}
+
+	if _, err := s.CreateTableFromSnapshot(ctx, &btapb.CreateTableFromSnapshotRequest{
+		Parent:         "cluster",
+		TableId:        "restored",
+		SourceSnapshot: snapName,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	restored := s.tables["cluster/tables/restored"]
+	if diff := cmp.Diff(tableRows(restored), want, cmp.Comparer(proto.Equal)); diff != "" {
+		t.Errorf("restored table: %s", diff)
+	}
+	if got, want := len(restored.families), 4; got != want {
+		t.Errorf("restored table has %d families, want %d", got, want)
+	}
+
+	if _, err := s.DeleteSnapshot(ctx, &btapb.DeleteSnapshotRequest{Name: snapName}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSnapshot(ctx, &btapb.GetSnapshotRequest{Name: snapName}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetSnapshot after delete: got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,674 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/btree"
+	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultCheckpointInterval is the checkpoint interval used by
+// NewPersistentServer when it is passed zero.
+const DefaultCheckpointInterval = time.Minute
+
+// NewPersistentServer is like NewServer, but the server keeps its tables and
+// snapshots in the directory dir so they survive restarts. Any state already
+// stored in dir is loaded before the server starts; dir is created if it does
+// not exist.
+//
+// Every change is appended to a write-ahead log. Every checkpointInterval the
+// server writes a checkpoint of its full state and discards the log written
+// before it. Close writes a final checkpoint. The log is not synced to disk
+// after each write, so changes made shortly before a machine crash (as opposed
+// to a process exit) may be lost.
+func NewPersistentServer(laddr, dir string, checkpointInterval time.Duration, opt ...grpc.ServerOption) (*Server, error) {
+	if checkpointInterval <= 0 {
+		checkpointInterval = DefaultCheckpointInterval
+	}
+	s := &server{
+		tables:    make(map[string]*table),
+		snapshots: make(map[string]*snapshot),
+	}
+	p, err := openPersister(dir, s)
+	if err != nil {
+		return nil, err
+	}
+	s.persist = p
+	srv, err := newServer(laddr, s, opt)
+	if err != nil {
+		p.close(s)
+		return nil, err
+	}
+	if len(s.tables) > 0 {
+		s.needGC()
+	}
+	p.start(s, checkpointInterval)
+	return srv, nil
+}
+
+// persister records a server's state in a directory: a checkpoint of the full
+// state, plus a write-ahead log of the changes made since. The log is split
+// into numbered segments; each checkpoint starts a new one.
+//
+// All methods are no-ops on a nil *persister.
+type persister struct {
+	dir  string
+	done chan struct{} // closed to stop the checkpoint loop
+	wg   sync.WaitGroup
+
+	ckmu sync.Mutex // held while writing a checkpoint
+
+	mu  sync.Mutex // guards the fields below
+	seg int        // number of the current log segment
+	f   *os.File   // current log segment
+	enc *json.Encoder
+}
+
+const checkpointFile = "checkpoint.json"
+
+func segmentFile(n int) string { return fmt.Sprintf("wal-%08d.log", n) }
+
+// A checkpoint is the full state of a server.
+type checkpoint struct {
+	Segment   int // first log segment to replay on top of this checkpoint
+	Tables    []*tableState
+	Snapshots []*snapshotState
+}
+
+// A logRecord is an entry in the write-ahead log. Exactly one field is set.
+type logRecord struct {
+	Table          *tableState    `json:",omitempty"` // create or replace a table
+	Families       *tableState    `json:",omitempty"` // replace a table's column families
+	DeleteTable    string         `json:",omitempty"`
+	DropRowRange   *dropRowRange  `json:",omitempty"`
+	Row            *rowState      `json:",omitempty"` // replace a row
+	Snapshot       *snapshotState `json:",omitempty"` // create or replace a snapshot
+	DeleteSnapshot string         `json:",omitempty"`
+	Restore        *restore       `json:",omitempty"` // create a table from a snapshot
+}
+
+type tableState struct {
+	Name     string
+	Counter  uint64
+	Families []familyState `json:",omitempty"`
+	Rows     []*btpb.Row   `json:",omitempty"`
+}
+
+type familyState struct {
+	ID     string
+	Order  uint64
+	GCRule []byte `json:",omitempty"` // encoded btapb.GcRule
+}
+
+type rowState struct {
+	Table string
+	Row   *btpb.Row
+}
+
+type dropRowRange struct {
+	Table  string
+	Prefix []byte `json:",omitempty"`
+	All    bool   `json:",omitempty"`
+}
+
+type snapshotState struct {
+	Name        string
+	SourceTable string
+	Description string `json:",omitempty"`
+	CreateTime  time.Time
+	DeleteTime  time.Time
+	Table       *tableState
+}
+
+type restore struct {
+	Table    string
+	Snapshot string
+}
+
+// openPersister loads the state stored in dir into s, and returns a persister
+// that logs to a new segment.
+func openPersister(dir string, s *server) (*persister, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	p := &persister{dir: dir, done: make(chan struct{})}
+	seg, err := p.load(s)
+	if err != nil {
+		return nil, fmt.Errorf("bttest: loading state from %s: %v", dir, err)
+	}
+	if err := p.openSegment(seg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load reads the checkpoint and log segments in p.dir into s. It returns the
+// number of the next log segment.
+func (p *persister) load(s *server) (int, error) {
+	var ck checkpoint
+	data, err := ioutil.ReadFile(filepath.Join(p.dir, checkpointFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return 0, err
+	default:
+		if err := json.Unmarshal(data, &ck); err != nil {
+			return 0, err
+		}
+	}
+	for _, ts := range ck.Tables {
+		t, err := ts.table()
+		if err != nil {
+			return 0, err
+		}
+		s.tables[ts.Name] = t
+	}
+	for _, ss := range ck.Snapshots {
+		snap, err := ss.snapshot()
+		if err != nil {
+			return 0, err
+		}
+		s.snapshots[ss.Name] = snap
+	}
+
+	segs, err := p.segments()
+	if err != nil {
+		return 0, err
+	}
+	next := ck.Segment
+	for _, n := range segs {
+		if n < ck.Segment {
+			continue
+		}
+		if err := p.replay(s, n); err != nil {
+			return 0, err
+		}
+		next = n + 1
+	}
+	return next, nil
+}
+
+// segments returns the numbers of the log segments in p.dir, in increasing order.
+func (p *persister) segments() ([]int, error) {
+	names, err := filepath.Glob(filepath.Join(p.dir, "wal-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var segs []int
+	for _, name := range names {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(name), "wal-%d.log", &n); err == nil {
+			segs = append(segs, n)
+		}
+	}
+	sort.Ints(segs)
+	return segs, nil
+}
+
+// replay applies the records in log segment n to s.
+func (p *persister) replay(s *server, n int) error {
+	f, err := os.Open(filepath.Join(p.dir, segmentFile(n)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("bttest: ignoring incomplete record at end of %s", segmentFile(n))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec logRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("%s: %v", segmentFile(n), err)
+		}
+		if err := s.apply(&rec); err != nil {
+			return fmt.Errorf("%s: %v", segmentFile(n), err)
+		}
+	}
+}
+
+// apply applies a log record to s. It is only used while loading, so it
+// does no locking.
+func (s *server) apply(rec *logRecord) error {
+	switch {
+	case rec.Table != nil:
+		t, err := rec.Table.table()
+		if err != nil {
+			return err
+		}
+		s.tables[rec.Table.Name] = t
+	case rec.Families != nil:
+		if t, ok := s.tables[rec.Families.Name]; ok {
+			fams, err := rec.Families.families()
+			if err != nil {
+				return err
+			}
+			t.families = fams
+			t.counter = rec.Families.Counter
+		}
+	case rec.DeleteTable != "":
+		delete(s.tables, rec.DeleteTable)
+	case rec.DropRowRange != nil:
+		if t, ok := s.tables[rec.DropRowRange.Table]; ok {
+			t.dropRowRange(string(rec.DropRowRange.Prefix), rec.DropRowRange.All)
+		}
+	case rec.Row != nil:
+		if t, ok := s.tables[rec.Row.Table]; ok {
+			t.rows.ReplaceOrInsert(rowFromProto(rec.Row.Row, t.families))
+		}
+	case rec.Snapshot != nil:
+		snap, err := rec.Snapshot.snapshot()
+		if err != nil {
+			return err
+		}
+		s.snapshots[rec.Snapshot.Name] = snap
+	case rec.DeleteSnapshot != "":
+		delete(s.snapshots, rec.DeleteSnapshot)
+	case rec.Restore != nil:
+		if snap, ok := s.snapshots[rec.Restore.Snapshot]; ok {
+			s.tables[rec.Restore.Table] = snap.table.copy(rec.Restore.Table)
+		}
+	default:
+		return fmt.Errorf("empty log record")
+	}
+	return nil
+}
+
+// openSegment starts writing to log segment n.
+// p.mu should be held, or p not yet shared.
+func (p *persister) openSegment(n int) error {
+	f, err := os.OpenFile(filepath.Join(p.dir, segmentFile(n)), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	p.seg = n
+	p.f = f
+	p.enc = json.NewEncoder(f)
+	return nil
+}
+
+// start begins writing a checkpoint of s every interval.
+func (p *persister) start(s *server, interval time.Duration) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(interval):
+				if err := p.checkpoint(s); err != nil {
+					log.Printf("bttest: writing checkpoint: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// close stops the checkpoint loop, writes a final checkpoint of s and closes
+// the log.
+func (p *persister) close(s *server) {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+	if err := p.checkpoint(s); err != nil {
+		log.Printf("bttest: writing checkpoint: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.f.Close(); err != nil {
+		log.Printf("bttest: closing log: %v", err)
+	}
+}
+
+// checkpoint writes the full state of s to disk, and removes the log segments
+// that the checkpoint makes obsolete.
+//
+// Changes made while the checkpoint is being written are logged to a new
+// segment, which is replayed on top of the checkpoint when loading. Replaying
+// a change that the checkpoint already reflects is harmless, because each log
+// record describes the resulting state, not the operation.
+func (p *persister) checkpoint(s *server) error {
+	p.ckmu.Lock()
+	defer p.ckmu.Unlock()
+
+	p.mu.Lock()
+	if err := p.f.Close(); err != nil {
+		log.Printf("bttest: closing log: %v", err)
+	}
+	err := p.openSegment(p.seg + 1)
+	seg := p.seg
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	tables := make(map[string]*table)
+	for name, t := range s.tables {
+		tables[name] = t
+	}
+	var snaps []*snapshot
+	for _, snap := range s.snapshots {
+		snaps = append(snaps, snap)
+	}
+	s.mu.Unlock()
+
+	ck := checkpoint{Segment: seg}
+	for name, t := range tables {
+		t.mu.RLock()
+		ts := newTableState(name, t, true)
+		t.mu.RUnlock()
+		ck.Tables = append(ck.Tables, ts)
+	}
+	for _, snap := range snaps {
+		ck.Snapshots = append(ck.Snapshots, newSnapshotState(snap))
+	}
+	data, err := json.Marshal(&ck)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(p.dir, checkpointFile), data); err != nil {
+		return err
+	}
+
+	segs, err := p.segments()
+	if err != nil {
+		return err
+	}
+	for _, n := range segs {
+		if n < seg {
+			if err := os.Remove(filepath.Join(p.dir, segmentFile(n))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to name, so
+// that name always holds either the old or the new contents.
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (p *persister) write(rec *logRecord) error {
+	if err := p.enc.Encode(rec); err != nil {
+		return status.Errorf(codes.Internal, "persisting change: %v", err)
+	}
+	return nil
+}
+
+// logTable records the creation of the table t, which has no rows.
+func (p *persister) logTable(name string, t *table) error {
+	if p == nil {
+		return nil
+	}
+	rec := &logRecord{Table: newTableState(name, t, false)}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(rec)
+}
+
+// logFamilies records the column families of t.
+// t.mu should be held.
+func (p *persister) logFamilies(name string, t *table) error {
+	if p == nil {
+		return nil
+	}
+	rec := &logRecord{Families: newTableState(name, t, false)}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(rec)
+}
+
+func (p *persister) logDeleteTable(name string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(&logRecord{DeleteTable: name})
+}
+
+func (p *persister) logDropRowRange(name string, prefix []byte, all bool) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(&logRecord{DropRowRange: &dropRowRange{Table: name, Prefix: prefix, All: all}})
+}
+
+// logRow records the contents of r.
+// r.mu should be held.
+func (p *persister) logRow(tableName string, r *row) error {
+	if p == nil {
+		return nil
+	}
+	rec := &logRecord{Row: &rowState{Table: tableName, Row: r.proto()}}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(rec)
+}
+
+func (p *persister) logSnapshot(snap *snapshot) error {
+	if p == nil {
+		return nil
+	}
+	rec := &logRecord{Snapshot: newSnapshotState(snap)}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(rec)
+}
+
+func (p *persister) logDeleteSnapshot(name string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(&logRecord{DeleteSnapshot: name})
+}
+
+func (p *persister) logRestore(tableName, snapshotName string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(&logRecord{Restore: &restore{Table: tableName, Snapshot: snapshotName}})
+}
+
+// newTableState returns the persistent form of t, including its rows if rows
+// is true.
+// t.mu should be held, unless t is not shared.
+func newTableState(name string, t *table, rows bool) *tableState {
+	ts := &tableState{Name: name, Counter: t.counter}
+	for id, cf := range t.families {
+		fs := familyState{ID: id, Order: cf.order}
+		if cf.gcRule != nil {
+			b, err := proto.Marshal(cf.gcRule)
+			if err != nil {
+				panic(err) // cannot happen for a valid message
+			}
+			fs.GCRule = b
+		}
+		ts.Families = append(ts.Families, fs)
+	}
+	sort.Sort(byFamilyOrder(ts.Families))
+	if rows {
+		t.rows.Ascend(func(i btree.Item) bool {
+			r := i.(*row)
+			r.mu.Lock()
+			ts.Rows = append(ts.Rows, r.proto())
+			r.mu.Unlock()
+			return true
+		})
+	}
+	return ts
+}
+
+type byFamilyOrder []familyState
+
+func (b byFamilyOrder) Len() int           { return len(b) }
+func (b byFamilyOrder) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byFamilyOrder) Less(i, j int) bool { return b[i].Order < b[j].Order }
+
+func (ts *tableState) families() (map[string]*columnFamily, error) {
+	fams := make(map[string]*columnFamily)
+	for _, fs := range ts.Families {
+		cf := &columnFamily{
+			name:  ts.Name + "/columnFamilies/" + fs.ID,
+			order: fs.Order,
+		}
+		if fs.GCRule != nil {
+			cf.gcRule = &btapb.GcRule{}
+			if err := proto.Unmarshal(fs.GCRule, cf.gcRule); err != nil {
+				return nil, err
+			}
+		}
+		fams[fs.ID] = cf
+	}
+	return fams, nil
+}
+
+func (ts *tableState) table() (*table, error) {
+	fams, err := ts.families()
+	if err != nil {
+		return nil, err
+	}
+	t := &table{
+		counter:  ts.Counter,
+		families: fams,
+		rows:     btree.New(btreeDegree),
+	}
+	for _, pr := range ts.Rows {
+		t.rows.ReplaceOrInsert(rowFromProto(pr, fams))
+	}
+	return t, nil
+}
+
+func newSnapshotState(snap *snapshot) *snapshotState {
+	// The snapshot's table is never modified, so it needs no locking.
+	return &snapshotState{
+		Name:        snap.name,
+		SourceTable: snap.sourceTable,
+		Description: snap.description,
+		CreateTime:  snap.createTime,
+		DeleteTime:  snap.deleteTime,
+		Table:       newTableState(snap.sourceTable, snap.table, true),
+	}
+}
+
+func (ss *snapshotState) snapshot() (*snapshot, error) {
+	t, err := ss.Table.table()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		name:        ss.Name,
+		sourceTable: ss.SourceTable,
+		description: ss.Description,
+		createTime:  ss.CreateTime,
+		deleteTime:  ss.DeleteTime,
+		table:       t,
+	}, nil
+}
+
+// proto returns all the cells of r, in the form of a btpb.Row.
+// r.mu should be held.
+func (r *row) proto() *btpb.Row {
+	pr := &btpb.Row{Key: []byte(r.key)}
+	for _, fam := range r.sortedFamilies() {
+		pf := &btpb.Family{Name: fam.name}
+		for _, col := range fam.colNames {
+			pc := &btpb.Column{Qualifier: []byte(col)}
+			for _, c := range fam.cells[col] {
+				pc.Cells = append(pc.Cells, &btpb.Cell{TimestampMicros: c.ts, Value: c.value})
+			}
+			pf.Columns = append(pf.Columns, pc)
+		}
+		pr.Families = append(pr.Families, pf)
+	}
+	return pr
+}
+
+// rowFromProto is the inverse of row.proto. Family creation order is taken
+// from fams.
+func rowFromProto(pr *btpb.Row, fams map[string]*columnFamily) *row {
+	r := newRow(string(pr.Key))
+	for _, pf := range pr.Families {
+		var order uint64
+		if cf, ok := fams[pf.Name]; ok {
+			order = cf.order
+		}
+		f := r.getOrCreateFamily(pf.Name, order)
+		for _, pc := range pf.Columns {
+			col := string(pc.Qualifier)
+			f.cellsByColumn(col)
+			cs := make([]cell, 0, len(pc.Cells))
+			for _, c := range pc.Cells {
+				cs = append(cs, cell{ts: c.TimestampMicros, value: c.Value})
+			}
+			f.cells[col] = cs
+		}
+	}
+	return r
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bttest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/context"
+	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
+)
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "bttest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Use a long interval so that only Close writes a checkpoint.
+	srv, err := NewPersistentServer("localhost:0", dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := srv.s
+	tblInfo, err := populateTable(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MutateRow(ctx, &btpb.MutateRowRequest{
+		TableName: tblInfo.Name,
+		RowKey:    []byte("row2"),
+		Mutations: []*btpb.Mutation{{
+			Mutation: &btpb.Mutation_SetCell_{SetCell: &btpb.Mutation_SetCell{
+				FamilyName:      "cf1",
+				ColumnQualifier: []byte("col"),
+				TimestampMicros: -1,
+				Value:           []byte("v"),
+			}},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SnapshotTable(ctx, &btapb.SnapshotTableRequest{
+		Name:       tblInfo.Name,
+		Cluster:    "cluster",
+		SnapshotId: "snap",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateTable(ctx, &btapb.CreateTableRequest{Parent: "cluster", TableId: "deleted"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteTable(ctx, &btapb.DeleteTableRequest{Name: "cluster/tables/deleted"}); err != nil {
+		t.Fatal(err)
+	}
+	// Garbage collection may happen at any time; do it now, and after loading,
+	// so the contents can be compared.
+	s.tables[tblInfo.Name].gc()
+	want := tableRows(s.tables[tblInfo.Name])
+
+	check := func(desc string, s *server) {
+		if got, want := len(s.tables), 1; got != want {
+			t.Fatalf("%s: got %d tables, want %d", desc, got, want)
+		}
+		tbl := s.tables[tblInfo.Name]
+		if tbl == nil {
+			t.Fatalf("%s: table %q missing", desc, tblInfo.Name)
+		}
+		tbl.gc()
+		if diff := cmp.Diff(tableRows(tbl), want, cmp.Comparer(proto.Equal)); diff != "" {
+			t.Errorf("%s: %s", desc, diff)
+		}
+		if got, want := tbl.families["cf0"].gcRule.GetMaxNumVersions(), int32(1); got != want {
+			t.Errorf("%s: cf0 MaxNumVersions: got %d, want %d", desc, got, want)
+		}
+		if got, want := tbl.families["cf3"].order, uint64(3); got != want {
+			t.Errorf("%s: cf3 order: got %d, want %d", desc, got, want)
+		}
+		if _, ok := s.snapshots["cluster/snapshots/snap"]; !ok {
+			t.Errorf("%s: snapshot missing", desc)
+		}
+	}
+
+	// Load a copy of the directory before the server is closed. Everything is
+	// in the log, not the checkpoint.
+	copyDir := filepath.Join(dir, "copy")
+	if err := os.Mkdir(copyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(copyDir, filepath.Base(f)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fromLog := &server{tables: make(map[string]*table), snapshots: make(map[string]*snapshot)}
+	if _, err := openPersister(copyDir, fromLog); err != nil {
+		t.Fatal(err)
+	}
+	check("from log", fromLog)
+
+	// After Close, the state is in the checkpoint.
+	srv.Close()
+	if files, _ := filepath.Glob(filepath.Join(dir, "wal-*.log")); len(files) != 1 {
+		t.Errorf("got log segments %v after checkpoint, want 1", files)
+	}
+	srv, err = NewPersistentServer("localhost:0", dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	check("from checkpoint", srv.s)
+}
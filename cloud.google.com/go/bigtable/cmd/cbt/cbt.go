@@ -20,6 +20,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"go/format"
@@ -317,6 +318,26 @@ var commands = []struct {
 		Usage:    "cbt doc",
 		Required: cbtconfig.NoneRequired,
 	},
+	{
+		Name: "export",
+		Desc: "Export rows to a file",
+		do:   doExport,
+		Usage: "cbt export <table> <file> [format=csv|binary] [start=<row>] [end=<row>] [prefix=<prefix>]" +
+			" [columns=[family]:[qualifier],...] [app-profile=<app profile id>]\n" +
+			"  file					The file to write, or - for standard output\n" +
+			"  format=csv|binary			The file format; the default is csv\n" +
+			"  start=<row>				Start reading at this row\n" +
+			"  end=<row>				Stop reading before this row\n" +
+			"  prefix=<prefix>			Read rows with this prefix\n" +
+			"  columns=[family]:[qualifier],...	Read only these columns, comma-separated\n" +
+			"  app-profile=<app profile id>		The app profile id to use for the request\n" +
+			"\n" +
+			"  All versions of each cell are exported with their timestamps. The csv format\n" +
+			"  has a header line and one line per cell with the fields row, family:column,\n" +
+			"  timestamp (in microseconds) and value. Use the binary format for values that\n" +
+			"  are not text.",
+		Required: cbtconfig.ProjectAndInstanceRequired,
+	},
 	{
 		Name:     "help",
 		Desc:     "Print help text",
@@ -324,6 +345,21 @@ var commands = []struct {
 		Usage:    "cbt help [command]",
 		Required: cbtconfig.NoneRequired,
 	},
+	{
+		Name: "import",
+		Desc: "Import rows from a file",
+		do:   doImport,
+		Usage: "cbt import <table> <file> [format=csv|binary] [batch-size=<n>] [workers=<n>]" +
+			" [app-profile=<app profile id>]\n" +
+			"  file					A file written by cbt export, or - for standard input\n" +
+			"  format=csv|binary			The file format; the default is csv\n" +
+			"  batch-size=<n>			Write this many rows in each request (default 500)\n" +
+			"  workers=<n>				Send this many requests concurrently (default 4)\n" +
+			"  app-profile=<app profile id>		The app profile id to use for the request\n" +
+			"\n" +
+			"  The table and its column families must already exist.",
+		Required: cbtconfig.ProjectAndInstanceRequired,
+	},
 	{
 		Name:     "listinstances",
 		Desc:     "List instances in a project",
@@ -808,7 +844,7 @@ var docTemplate = template.Must(template.New("doc").Funcs(template.FuncMap{
 
 // DO NOT EDIT. THIS IS AUTOMATICALLY GENERATED.
 // Run "go generate" to regenerate.
-//go:generate go run cbt.go gcpolicy.go -o cbtdoc.go doc
+//go:generate go run cbt.go gcpolicy.go transfer.go -o cbtdoc.go doc
 
 /*
 Cbt is a tool for doing basic interactions with Cloud Bigtable. To learn how to
@@ -1060,18 +1096,9 @@ func doRead(ctx context.Context, args ...string) {
 		// Be nicer; we used to support this, but renamed it to "end".
 		log.Fatal("Unknown arg key 'limit'; did you mean 'end'?")
 	}
-	if (parsed["start"] != "" || parsed["end"] != "") && parsed["prefix"] != "" {
-		log.Fatal(`"start"/"end" may not be mixed with "prefix"`)
-	}
-
-	var rr bigtable.RowRange
-	if start, end := parsed["start"], parsed["end"]; end != "" {
-		rr = bigtable.NewRange(start, end)
-	} else if start != "" {
-		rr = bigtable.InfiniteRange(start)
-	}
-	if prefix := parsed["prefix"]; prefix != "" {
-		rr = bigtable.PrefixRange(prefix)
+	rr, err := parseRowRange(parsed)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	var opts []bigtable.ReadOption
@@ -1119,6 +1146,24 @@ func doRead(ctx context.Context, args ...string) {
 	}
 }
 
+// parseRowRange returns the row range given by the "start", "end" and "prefix"
+// keys of parsed arguments.
+func parseRowRange(parsed map[string]string) (bigtable.RowRange, error) {
+	if (parsed["start"] != "" || parsed["end"] != "") && parsed["prefix"] != "" {
+		return bigtable.RowRange{}, errors.New(`"start"/"end" may not be mixed with "prefix"`)
+	}
+	var rr bigtable.RowRange
+	if start, end := parsed["start"], parsed["end"]; end != "" {
+		rr = bigtable.NewRange(start, end)
+	} else if start != "" {
+		rr = bigtable.InfiniteRange(start)
+	}
+	if prefix := parsed["prefix"]; prefix != "" {
+		rr = bigtable.PrefixRange(prefix)
+	}
+	return rr, nil
+}
+
 var setArg = regexp.MustCompile(`([^:]+):([^=]*)=(.*)`)
 
 func doSet(ctx context.Context, args ...string) {
@@ -14,7 +14,7 @@
 
 // DO NOT EDIT. THIS IS AUTOMATICALLY GENERATED.
 // Run "go generate" to regenerate.
-//go:generate go run cbt.go gcpolicy.go -o cbtdoc.go doc
+//go:generate go run cbt.go gcpolicy.go transfer.go -o cbtdoc.go doc
 
 /*
 Cbt is a tool for doing basic interactions with Cloud Bigtable. To learn how to
@@ -40,7 +40,9 @@ The commands are:
 	deleterow                 Delete a row
 	deletetable               Delete a table
 	doc                       Print godoc-suitable documentation for cbt
+	export                    Export rows to a file
 	help                      Print help text
+	import                    Import rows from a file
 	listinstances             List instances in a project
 	listclusters              List clusters in an instance
 	lookup                    Read from a single row
@@ -218,6 +220,26 @@ Usage:
 
 
 
+Export rows to a file
+
+Usage:
+	cbt export <table> <file> [format=csv|binary] [start=<row>] [end=<row>] [prefix=<prefix>] [columns=[family]:[qualifier],...] [app-profile=<app profile id>]
+	  file					The file to write, or - for standard output
+	  format=csv|binary			The file format; the default is csv
+	  start=<row>				Start reading at this row
+	  end=<row>				Stop reading before this row
+	  prefix=<prefix>			Read rows with this prefix
+	  columns=[family]:[qualifier],...	Read only these columns, comma-separated
+	  app-profile=<app profile id>		The app profile id to use for the request
+
+	  All versions of each cell are exported with their timestamps. The csv format
+	  has a header line and one line per cell with the fields row, family:column,
+	  timestamp (in microseconds) and value. Use the binary format for values that
+	  are not text.
+
+
+
+
 Print help text
 
 Usage:
@@ -226,6 +248,21 @@ Usage:
 
 
 
+Import rows from a file
+
+Usage:
+	cbt import <table> <file> [format=csv|binary] [batch-size=<n>] [workers=<n>] [app-profile=<app profile id>]
+	  file					A file written by cbt export, or - for standard input
+	  format=csv|binary			The file format; the default is csv
+	  batch-size=<n>			Write this many rows in each request (default 500)
+	  workers=<n>				Send this many requests concurrently (default 4)
+	  app-profile=<app profile id>		The app profile id to use for the request
+
+	  The table and its column families must already exist.
+
+
+
+
 List instances in a project
 
 Usage:
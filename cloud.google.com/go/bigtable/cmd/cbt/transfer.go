@@ -0,0 +1,507 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+// Bulk export and import of table data.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/bigtable"
+	"golang.org/x/net/context"
+)
+
+// A cell is a single version of a single column in a row.
+type cell struct {
+	Row    string
+	Column string // family:qualifier
+	Time   bigtable.Timestamp
+	Value  []byte
+}
+
+// A cellWriter writes the cells of rows to a file.
+type cellWriter interface {
+	Write(c cell) error
+	Flush() error
+}
+
+// A cellReader reads cells from a file. Read returns io.EOF after the last cell.
+type cellReader interface {
+	Read() (cell, error)
+}
+
+var csvHeader = []string{"row", "column", "timestamp", "value"}
+
+// csvCellWriter writes cells as CSV records of row key, family:qualifier,
+// timestamp in microseconds and value, after a header record.
+type csvCellWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVCellWriter(w io.Writer) *csvCellWriter {
+	return &csvCellWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvCellWriter) Write(c cell) error {
+	if !cw.wroteHeader {
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+	return cw.w.Write([]string{c.Row, c.Column, strconv.FormatInt(int64(c.Time), 10), string(c.Value)})
+}
+
+func (cw *csvCellWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type csvCellReader struct {
+	r    *csv.Reader
+	line int
+}
+
+func newCSVCellReader(r io.Reader) *csvCellReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	return &csvCellReader{r: cr}
+}
+
+func (cr *csvCellReader) Read() (cell, error) {
+	for {
+		rec, err := cr.r.Read()
+		if err != nil {
+			return cell{}, err
+		}
+		cr.line++
+		if cr.line == 1 && strings.Join(rec, ",") == strings.Join(csvHeader, ",") {
+			continue
+		}
+		if !strings.Contains(rec[1], ":") {
+			return cell{}, fmt.Errorf("record %d: column %q is not of the form family:qualifier", cr.line, rec[1])
+		}
+		ts, err := strconv.ParseInt(rec[2], 10, 64)
+		if err != nil {
+			return cell{}, fmt.Errorf("record %d: bad timestamp %q", cr.line, rec[2])
+		}
+		return cell{Row: rec[0], Column: rec[1], Time: bigtable.Timestamp(ts), Value: []byte(rec[3])}, nil
+	}
+}
+
+// binaryMagic begins files in the binary format. The rest of the file is a
+// sequence of cells, each encoded as the length-prefixed row key, the
+// length-prefixed column, the varint timestamp and the length-prefixed value.
+// Lengths are uvarints.
+//
+// Unlike CSV, the binary format preserves values that are not text exactly.
+const binaryMagic = "CBTDUMP1"
+
+type binaryCellWriter struct {
+	w           *bufio.Writer
+	wroteHeader bool
+	buf         [binary.MaxVarintLen64]byte
+}
+
+func newBinaryCellWriter(w io.Writer) *binaryCellWriter {
+	return &binaryCellWriter{w: bufio.NewWriter(w)}
+}
+
+func (bw *binaryCellWriter) Write(c cell) error {
+	if err := bw.writeHeader(); err != nil {
+		return err
+	}
+	bw.writeBytes([]byte(c.Row))
+	bw.writeBytes([]byte(c.Column))
+	n := binary.PutVarint(bw.buf[:], int64(c.Time))
+	bw.w.Write(bw.buf[:n])
+	// bufio.Writer remembers the first error, so checking the last write is enough.
+	return bw.writeBytes(c.Value)
+}
+
+func (bw *binaryCellWriter) writeBytes(b []byte) error {
+	n := binary.PutUvarint(bw.buf[:], uint64(len(b)))
+	bw.w.Write(bw.buf[:n])
+	_, err := bw.w.Write(b)
+	return err
+}
+
+// Flush writes the header, if no cells were written, and any buffered data.
+func (bw *binaryCellWriter) Flush() error {
+	if err := bw.writeHeader(); err != nil {
+		return err
+	}
+	return bw.w.Flush()
+}
+
+func (bw *binaryCellWriter) writeHeader() error {
+	if bw.wroteHeader {
+		return nil
+	}
+	bw.wroteHeader = true
+	_, err := bw.w.WriteString(binaryMagic)
+	return err
+}
+
+type binaryCellReader struct {
+	r         *bufio.Reader
+	readMagic bool
+	cell      int
+}
+
+func newBinaryCellReader(r io.Reader) *binaryCellReader {
+	return &binaryCellReader{r: bufio.NewReader(r)}
+}
+
+// maxBinaryField bounds the length of a field in the binary format, so that a
+// corrupt file does not cause a huge allocation. It is larger than the maximum
+// size of a Bigtable cell.
+const maxBinaryField = 256 << 20
+
+var errCorruptDump = errors.New("corrupt binary dump")
+
+func (br *binaryCellReader) Read() (cell, error) {
+	if !br.readMagic {
+		magic := make([]byte, len(binaryMagic))
+		if _, err := io.ReadFull(br.r, magic); err != nil || string(magic) != binaryMagic {
+			return cell{}, fmt.Errorf("not a binary dump: missing %q header", binaryMagic)
+		}
+		br.readMagic = true
+	}
+	row, err := br.readBytes()
+	if err == io.EOF {
+		return cell{}, io.EOF
+	}
+	if err != nil {
+		return cell{}, err
+	}
+	br.cell++
+	col, err := br.readBytes()
+	if err != nil {
+		return cell{}, unexpectedEOF(err)
+	}
+	if !bytes.Contains(col, []byte(":")) {
+		return cell{}, fmt.Errorf("cell %d: column %q is not of the form family:qualifier", br.cell, col)
+	}
+	ts, err := binary.ReadVarint(br.r)
+	if err != nil {
+		return cell{}, unexpectedEOF(err)
+	}
+	val, err := br.readBytes()
+	if err != nil {
+		return cell{}, unexpectedEOF(err)
+	}
+	return cell{Row: string(row), Column: string(col), Time: bigtable.Timestamp(ts), Value: val}, nil
+}
+
+func (br *binaryCellReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxBinaryField {
+		return nil, errCorruptDump
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br.r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+var transferFormats = []string{"csv", "binary"}
+
+func checkFormat(format string) {
+	if !stringInSlice(format, transferFormats) {
+		log.Fatalf("Bad format %q: must be one of %s", format, strings.Join(transferFormats, ", "))
+	}
+}
+
+// exportRows reads the rows of tbl in rr and writes all of their cells to w.
+// It returns the number of rows written.
+func exportRows(ctx context.Context, tbl *bigtable.Table, rr bigtable.RowSet, w cellWriter, opts ...bigtable.ReadOption) (int, error) {
+	var (
+		n    int
+		werr error
+	)
+	err := tbl.ReadRows(ctx, rr, func(r bigtable.Row) bool {
+		for _, fam := range sortedFamilies(r) {
+			for _, ri := range r[fam] {
+				if werr = w.Write(cell{Row: ri.Row, Column: ri.Column, Time: ri.Timestamp, Value: ri.Value}); werr != nil {
+					return false
+				}
+			}
+		}
+		n++
+		return true
+	}, opts...)
+	if werr != nil {
+		return n, werr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, w.Flush()
+}
+
+func sortedFamilies(r bigtable.Row) []string {
+	var fams []string
+	for fam := range r {
+		fams = append(fams, fam)
+	}
+	sort.Strings(fams)
+	return fams
+}
+
+// importSettings control how cells are written to a table.
+type importSettings struct {
+	BatchSize int // rows per ApplyBulk call
+	Workers   int // concurrent ApplyBulk calls
+}
+
+// importRows reads cells from r and writes them to tbl. Consecutive cells with
+// the same row key are applied as a single mutation. Batches of rows are written
+// with ApplyBulk by settings.Workers goroutines; reading stops while all of them
+// are busy. It returns the number of mutations applied.
+func importRows(ctx context.Context, tbl *bigtable.Table, r cellReader, settings importSettings) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type batch struct {
+		keys []string
+		muts []*bigtable.Mutation
+	}
+	batches := make(chan batch)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		applied  int
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	for i := 0; i < settings.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				errs, err := tbl.ApplyBulk(ctx, b.keys, b.muts)
+				if err != nil {
+					fail(fmt.Errorf("applying mutations: %v", err))
+					continue
+				}
+				for i, err := range errs {
+					if err != nil {
+						fail(fmt.Errorf("applying mutation to row %q: %v", b.keys[i], err))
+						break
+					}
+				}
+				mu.Lock()
+				applied += len(b.keys)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var (
+		cur     batch
+		lastRow string
+		mut     *bigtable.Mutation
+		readErr error
+	)
+	send := func() bool {
+		select {
+		case batches <- cur:
+			cur = batch{}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		c, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+		if mut == nil || c.Row != lastRow {
+			if len(cur.keys) == settings.BatchSize && !send() {
+				break
+			}
+			mut = bigtable.NewMutation()
+			lastRow = c.Row
+			cur.keys = append(cur.keys, c.Row)
+			cur.muts = append(cur.muts, mut)
+		}
+		i := strings.Index(c.Column, ":")
+		if i < 0 {
+			readErr = fmt.Errorf("row %q: column %q is not of the form family:qualifier", c.Row, c.Column)
+			break
+		}
+		mut.Set(c.Column[:i], c.Column[i+1:], c.Time, c.Value)
+	}
+	if readErr == nil && len(cur.keys) > 0 {
+		send()
+	}
+	close(batches)
+	wg.Wait()
+
+	if readErr != nil {
+		return applied, readErr
+	}
+	if firstErr != nil {
+		return applied, firstErr
+	}
+	return applied, ctx.Err()
+}
+
+func doExport(ctx context.Context, args ...string) {
+	if len(args) < 2 {
+		log.Fatal("usage: cbt export <table> <file> [args ...]")
+	}
+	parsed, err := parseArgs(args[2:], []string{
+		"format", "start", "end", "prefix", "columns", "app-profile",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	format := parsed["format"]
+	if format == "" {
+		format = "csv"
+	}
+	checkFormat(format)
+	rr, err := parseRowRange(parsed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var opts []bigtable.ReadOption
+	if columns := parsed["columns"]; columns != "" {
+		f, err := parseColumnsFilter(columns)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, bigtable.RowFilter(f))
+	}
+
+	var out io.Writer = os.Stdout
+	if name := args[1]; name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+		out = f
+	}
+	var w cellWriter
+	switch format {
+	case "csv":
+		w = newCSVCellWriter(out)
+	case "binary":
+		w = newBinaryCellWriter(out)
+	}
+
+	tbl := getClient(bigtable.ClientConfig{AppProfile: parsed["app-profile"]}).Open(args[0])
+	n, err := exportRows(ctx, tbl, rr, w, opts...)
+	if err != nil {
+		log.Fatalf("Exporting rows: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d rows\n", n)
+}
+
+func doImport(ctx context.Context, args ...string) {
+	if len(args) < 2 {
+		log.Fatal("usage: cbt import <table> <file> [args ...]")
+	}
+	parsed, err := parseArgs(args[2:], []string{
+		"format", "batch-size", "workers", "app-profile",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	format := parsed["format"]
+	if format == "" {
+		format = "csv"
+	}
+	checkFormat(format)
+	settings := importSettings{BatchSize: 500, Workers: 4}
+	for key, p := range map[string]*int{"batch-size": &settings.BatchSize, "workers": &settings.Workers} {
+		if s := parsed[key]; s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				log.Fatalf("Bad %s %q: must be a positive integer", key, s)
+			}
+			*p = n
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if name := args[1]; name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	var r cellReader
+	switch format {
+	case "csv":
+		r = newCSVCellReader(in)
+	case "binary":
+		r = newBinaryCellReader(in)
+	}
+
+	tbl := getClient(bigtable.ClientConfig{AppProfile: parsed["app-profile"]}).Open(args[0])
+	n, err := importRows(ctx, tbl, r, settings)
+	if err != nil {
+		log.Fatalf("Importing rows: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d rows\n", n)
+}
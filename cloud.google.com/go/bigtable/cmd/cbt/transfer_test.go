@@ -0,0 +1,215 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/bttest"
+	"cloud.google.com/go/internal/testutil"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func TestCellFormats(t *testing.T) {
+	cells := []cell{
+		{Row: "r1", Column: "f:a", Time: 2000, Value: []byte("v2")},
+		{Row: "r1", Column: "f:a", Time: 1000, Value: []byte("v1")},
+		{Row: "r,2", Column: "f:b", Time: 0, Value: []byte("line\nbreak, \"quoted\"")},
+		{Row: "r3", Column: "g:", Time: -1, Value: []byte{0, 0xff, 7}},
+	}
+	for _, test := range []struct {
+		name      string
+		newWriter func(io.Writer) cellWriter
+		newReader func(io.Reader) cellReader
+		cells     []cell
+	}{
+		{
+			"csv",
+			func(w io.Writer) cellWriter { return newCSVCellWriter(w) },
+			func(r io.Reader) cellReader { return newCSVCellReader(r) },
+			cells[:3], // not binary values
+		},
+		{
+			"binary",
+			func(w io.Writer) cellWriter { return newBinaryCellWriter(w) },
+			func(r io.Reader) cellReader { return newBinaryCellReader(r) },
+			cells,
+		},
+	} {
+		var buf bytes.Buffer
+		w := test.newWriter(&buf)
+		for _, c := range test.cells {
+			if err := w.Write(c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readAllCells(test.newReader(&buf))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !testutil.Equal(got, test.cells) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.cells)
+		}
+	}
+}
+
+func TestCellReaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		r    cellReader
+	}{
+		{"csv no family", newCSVCellReader(strings.NewReader("r,col,1,v\n"))},
+		{"csv bad timestamp", newCSVCellReader(strings.NewReader("r,f:c,x,v\n"))},
+		{"csv wrong field count", newCSVCellReader(strings.NewReader("r,f:c,1\n"))},
+		{"binary no magic", newBinaryCellReader(strings.NewReader("r,f:c,1,v\n"))},
+		{"binary truncated", newBinaryCellReader(strings.NewReader(binaryMagic + "\x02r1\x03f:"))},
+		{"binary no family", newBinaryCellReader(strings.NewReader(binaryMagic + "\x02r1\x03col\x02\x01v"))},
+	} {
+		if _, err := readAllCells(test.r); err == nil {
+			t.Errorf("%s: got nil, want error", test.name)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	adminClient, err := bigtable.NewAdminClient(ctx, "proj", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := bigtable.NewClient(ctx, "proj", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"src", "csv", "binary"} {
+		if err := adminClient.CreateTable(ctx, table); err != nil {
+			t.Fatal(err)
+		}
+		for _, fam := range []string{"f", "g"} {
+			if err := adminClient.CreateColumnFamily(ctx, table, fam); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	src := client.Open("src")
+	const nRows = 25
+	for i := 0; i < nRows; i++ {
+		mut := bigtable.NewMutation()
+		mut.Set("f", "a", 1000, []byte(fmt.Sprintf("a%d-old", i)))
+		mut.Set("f", "a", 2000, []byte(fmt.Sprintf("a%d", i)))
+		mut.Set("g", "b", 3000, []byte{byte(i), 0})
+		if err := src.Apply(ctx, fmt.Sprintf("row%02d", i), mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := readTable(t, src)
+
+	for _, format := range []string{"csv", "binary"} {
+		var buf bytes.Buffer
+		var w cellWriter
+		if format == "csv" {
+			w = newCSVCellWriter(&buf)
+		} else {
+			w = newBinaryCellWriter(&buf)
+		}
+		n, err := exportRows(ctx, src, bigtable.InfiniteRange(""), w)
+		if err != nil {
+			t.Fatalf("%s: export: %v", format, err)
+		}
+		if n != nRows {
+			t.Errorf("%s: exported %d rows, want %d", format, n, nRows)
+		}
+
+		var r cellReader
+		if format == "csv" {
+			r = newCSVCellReader(&buf)
+		} else {
+			r = newBinaryCellReader(&buf)
+		}
+		dst := client.Open(format)
+		n, err = importRows(ctx, dst, r, importSettings{BatchSize: 4, Workers: 3})
+		if err != nil {
+			t.Fatalf("%s: import: %v", format, err)
+		}
+		if n != nRows {
+			t.Errorf("%s: imported %d rows, want %d", format, n, nRows)
+		}
+		if got := readTable(t, dst); !testutil.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", format, got, want)
+		}
+	}
+
+	// A failed mutation, here to a missing column family, is reported.
+	r := newCSVCellReader(strings.NewReader("r,nofam:c,1000,v\n"))
+	if _, err := importRows(ctx, client.Open("csv"), r, importSettings{BatchSize: 1, Workers: 1}); err == nil {
+		t.Error("import to missing family: got nil, want error")
+	}
+
+	// A malformed binary dump, here with a column without family, is
+	// reported rather than causing a panic.
+	br := newBinaryCellReader(strings.NewReader(binaryMagic + "\x02r1\x03f:c\x02\x01v" + "\x02r2\x03col\x02\x01v"))
+	if _, err := importRows(ctx, client.Open("binary"), br, importSettings{BatchSize: 1, Workers: 1}); err == nil {
+		t.Error("import of malformed binary dump: got nil, want error")
+	}
+}
+
+func readAllCells(r cellReader) ([]cell, error) {
+	var cells []cell
+	for {
+		c, err := r.Read()
+		if err == io.EOF {
+			return cells, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+}
+
+func readTable(t *testing.T, tbl *bigtable.Table) []bigtable.Row {
+	var rows []bigtable.Row
+	err := tbl.ReadRows(context.Background(), bigtable.InfiniteRange(""), func(r bigtable.Row) bool {
+		rows = append(rows, r)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
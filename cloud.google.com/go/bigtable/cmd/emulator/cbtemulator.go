@@ -14,6 +14,9 @@
 
 /*
 cbtemulator launches the in-memory Cloud Bigtable server on the given address.
+
+If the -dir flag is set, the emulator keeps its tables and snapshots in that
+directory and reloads them when it is restarted.
 */
 package main
 
@@ -21,6 +24,9 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"cloud.google.com/go/bigtable/bttest"
 	"google.golang.org/grpc"
@@ -29,16 +35,32 @@ import (
 var (
 	host = flag.String("host", "localhost", "the address to bind to on the local machine")
 	port = flag.Int("port", 9000, "the port number to bind to on the local machine")
+	dir  = flag.String("dir", "", "if set, the directory in which to persist data across restarts")
+
+	checkpointInterval = flag.Duration("checkpoint_interval", bttest.DefaultCheckpointInterval, "how often to checkpoint data, if -dir is set")
 )
 
 func main() {
 	grpc.EnableTracing = false
 	flag.Parse()
-	srv, err := bttest.NewServer(fmt.Sprintf("%s:%d", *host, *port))
+	addr := fmt.Sprintf("%s:%d", *host, *port)
+	var (
+		srv *bttest.Server
+		err error
+	)
+	if *dir != "" {
+		srv, err = bttest.NewPersistentServer(addr, *dir, *checkpointInterval)
+	} else {
+		srv, err = bttest.NewServer(addr)
+	}
 	if err != nil {
 		log.Fatalf("failed to start emulator: %v", err)
 	}
 
 	fmt.Printf("Cloud Bigtable emulator running on %s\n", srv.Addr)
-	select {}
+	// Shut down cleanly on a signal, so that persisted data is checkpointed.
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+	srv.Close()
 }
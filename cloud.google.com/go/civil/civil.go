@@ -18,7 +18,8 @@
 // minutes.
 //
 // Because they lack location information, these types do not represent unique
-// moments or intervals of time. Use time.Time for that purpose.
+// moments or intervals of time. Use time.Time for that purpose. The exception
+// is Timestamp, which pairs a DateTime with the name of a time zone.
 package civil
 
 import (
@@ -82,6 +83,50 @@ func (d Date) AddDays(n int) Date {
 	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
 }
 
+// AddMonths returns the date that is n months in the future.
+// n can also be negative to go into the past.
+// If the day of the month does not exist in the resulting month, the last day
+// of that month is used. For example, one month after January 31 is February 28
+// or 29.
+func (d Date) AddMonths(n int) Date {
+	// Count months from year 0 so that the month arithmetic is zero-based.
+	m := d.Year*12 + int(d.Month) - 1 + n
+	y, mon := m/12, m%12
+	if mon < 0 {
+		y, mon = y-1, mon+12
+	}
+	r := Date{Year: y, Month: time.Month(mon + 1), Day: d.Day}
+	if last := daysIn(r.Year, r.Month); r.Day > last {
+		r.Day = last
+	}
+	return r
+}
+
+// AddYears returns the date that is n years in the future.
+// n can also be negative to go into the past.
+// February 29 is mapped to February 28 in years that are not leap years.
+func (d Date) AddYears(n int) Date {
+	return d.AddMonths(12 * n)
+}
+
+// daysIn returns the number of days in a month.
+func daysIn(year int, month time.Month) int {
+	// Day 0 of the next month is the last day of this month.
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Weekday returns the day of the week of the date.
+func (d Date) Weekday() time.Weekday {
+	return d.In(time.UTC).Weekday()
+}
+
+// ISOWeek returns the ISO 8601 year and week number in which the date occurs.
+// Week ranges from 1 to 53. Jan 01 to Jan 03 of year n might belong to week 52
+// or 53 of year n-1, and Dec 29 to Dec 31 might belong to week 1 of year n+1.
+func (d Date) ISOWeek() (year, week int) {
+	return d.In(time.UTC).ISOWeek()
+}
+
 // DaysSince returns the signed number of days between the date and s, not including the end day.
 // This is the inverse operation to AddDays.
 func (d Date) DaysSince(s Date) (days int) {
@@ -163,6 +163,55 @@ func TestDateArithmetic(t *testing.T) {
 	}
 }
 
+func TestDateAddMonths(t *testing.T) {
+	for _, test := range []struct {
+		start         Date
+		months, years int
+		want          Date
+	}{
+		{Date{2014, 5, 9}, 0, 0, Date{2014, 5, 9}},
+		{Date{2014, 5, 9}, 1, 0, Date{2014, 6, 9}},
+		{Date{2014, 11, 30}, 2, 0, Date{2015, 1, 30}},
+		{Date{2014, 1, 31}, 1, 0, Date{2014, 2, 28}},
+		{Date{2016, 1, 31}, 1, 0, Date{2016, 2, 29}},
+		{Date{2016, 3, 31}, -1, 0, Date{2016, 2, 29}},
+		{Date{2014, 1, 15}, -13, 0, Date{2012, 12, 15}},
+		{Date{0, 1, 1}, -1, 0, Date{-1, 12, 1}},
+		{Date{2016, 2, 29}, 0, 1, Date{2017, 2, 28}},
+		{Date{2016, 2, 29}, 0, 4, Date{2020, 2, 29}},
+		{Date{2016, 2, 29}, 0, -1, Date{2015, 2, 28}},
+	} {
+		var got Date
+		if test.years != 0 {
+			got = test.start.AddYears(test.years)
+		} else {
+			got = test.start.AddMonths(test.months)
+		}
+		if got != test.want {
+			t.Errorf("%v + %d months, %d years: got %v, want %v", test.start, test.months, test.years, got, test.want)
+		}
+	}
+}
+
+func TestDateWeek(t *testing.T) {
+	for _, test := range []struct {
+		date             Date
+		weekday          time.Weekday
+		isoYear, isoWeek int
+	}{
+		{Date{2018, 6, 1}, time.Friday, 2018, 22},
+		{Date{2021, 1, 3}, time.Sunday, 2020, 53},
+		{Date{2019, 12, 30}, time.Monday, 2020, 1},
+	} {
+		if got := test.date.Weekday(); got != test.weekday {
+			t.Errorf("%v.Weekday() = %v, want %v", test.date, got, test.weekday)
+		}
+		if y, w := test.date.ISOWeek(); y != test.isoYear || w != test.isoWeek {
+			t.Errorf("%v.ISOWeek() = %d, %d, want %d, %d", test.date, y, w, test.isoYear, test.isoWeek)
+		}
+	}
+}
+
 func TestDateBefore(t *testing.T) {
 	for _, test := range []struct {
 		d1, d2 Date
@@ -0,0 +1,114 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A DateRange represents the dates from Start to End, inclusive.
+//
+// The zero DateRange, like any range whose End is before its Start, is empty.
+type DateRange struct {
+	Start Date
+	End   Date
+}
+
+// ParseDateRange parses a string of the form "START/END", where START and END
+// are in the format accepted by ParseDate, and returns the DateRange it
+// represents.
+func ParseDateRange(s string) (DateRange, error) {
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return DateRange{}, fmt.Errorf("civil: date range %q has no '/'", s)
+	}
+	start, err := ParseDate(s[:i])
+	if err != nil {
+		return DateRange{}, err
+	}
+	end, err := ParseDate(s[i+1:])
+	if err != nil {
+		return DateRange{}, err
+	}
+	return DateRange{Start: start, End: end}, nil
+}
+
+// String returns the range in the format accepted by ParseDateRange, which is
+// the ISO 8601 format for time intervals.
+func (r DateRange) String() string {
+	return r.Start.String() + "/" + r.End.String()
+}
+
+// IsValid reports whether both dates of the range are valid.
+func (r DateRange) IsValid() bool {
+	return r.Start.IsValid() && r.End.IsValid()
+}
+
+// IsEmpty reports whether the range contains no dates.
+func (r DateRange) IsEmpty() bool {
+	return r.End.Before(r.Start)
+}
+
+// Days returns the number of dates in the range.
+func (r DateRange) Days() int {
+	if r.IsEmpty() {
+		return 0
+	}
+	return r.End.DaysSince(r.Start) + 1
+}
+
+// Contains reports whether d is in the range.
+func (r DateRange) Contains(d Date) bool {
+	return !d.Before(r.Start) && !d.After(r.End)
+}
+
+// Overlaps reports whether the ranges have a date in common.
+func (r DateRange) Overlaps(s DateRange) bool {
+	return !r.IsEmpty() && !s.IsEmpty() && !r.End.Before(s.Start) && !s.End.Before(r.Start)
+}
+
+// Dates returns the dates in the range, in order.
+func (r DateRange) Dates() []Date {
+	ds := make([]Date, 0, r.Days())
+	r.Each(func(d Date) bool {
+		ds = append(ds, d)
+		return true
+	})
+	return ds
+}
+
+// Each calls f for each date in the range, in order, until f returns false.
+func (r DateRange) Each(f func(Date) bool) {
+	for d := r.Start; !d.After(r.End); d = d.AddDays(1) {
+		if !f(d) {
+			return
+		}
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The output is the result of r.String().
+func (r DateRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// The range is expected to be a string in a format accepted by ParseDateRange.
+func (r *DateRange) UnmarshalText(data []byte) error {
+	var err error
+	*r, err = ParseDateRange(string(data))
+	return err
+}
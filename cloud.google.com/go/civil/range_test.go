@@ -0,0 +1,126 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDateRange(t *testing.T) {
+	for _, test := range []struct {
+		r         DateRange
+		wantDates []Date
+	}{
+		{
+			r:         DateRange{Date{2016, 2, 27}, Date{2016, 3, 1}},
+			wantDates: []Date{{2016, 2, 27}, {2016, 2, 28}, {2016, 2, 29}, {2016, 3, 1}},
+		},
+		{
+			r:         DateRange{Date{2016, 2, 27}, Date{2016, 2, 27}},
+			wantDates: []Date{{2016, 2, 27}},
+		},
+		{
+			r:         DateRange{Date{2016, 2, 27}, Date{2016, 2, 26}},
+			wantDates: []Date{},
+		},
+	} {
+		if got := test.r.Dates(); !cmp.Equal(got, test.wantDates) {
+			t.Errorf("%v.Dates() = %v, want %v", test.r, got, test.wantDates)
+		}
+		if got, want := test.r.Days(), len(test.wantDates); got != want {
+			t.Errorf("%v.Days() = %d, want %d", test.r, got, want)
+		}
+		if got, want := test.r.IsEmpty(), len(test.wantDates) == 0; got != want {
+			t.Errorf("%v.IsEmpty() = %t, want %t", test.r, got, want)
+		}
+		for _, d := range test.wantDates {
+			if !test.r.Contains(d) {
+				t.Errorf("%v.Contains(%v) = false, want true", test.r, d)
+			}
+		}
+		for _, d := range []Date{test.r.Start.AddDays(-1), test.r.End.AddDays(1)} {
+			if test.r.Contains(d) {
+				t.Errorf("%v.Contains(%v) = true, want false", test.r, d)
+			}
+		}
+	}
+
+	var n int
+	DateRange{Date{2016, 1, 1}, Date{2016, 12, 31}}.Each(func(Date) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("Each: got %d calls, want 3", n)
+	}
+}
+
+func TestDateRangeOverlaps(t *testing.T) {
+	jan := DateRange{Date{2016, 1, 1}, Date{2016, 1, 31}}
+	for _, test := range []struct {
+		r    DateRange
+		want bool
+	}{
+		{DateRange{Date{2015, 12, 1}, Date{2016, 1, 1}}, true},
+		{DateRange{Date{2016, 1, 31}, Date{2016, 2, 1}}, true},
+		{DateRange{Date{2016, 1, 10}, Date{2016, 1, 20}}, true},
+		{DateRange{Date{2016, 2, 1}, Date{2016, 2, 2}}, false},
+		{DateRange{Date{2016, 1, 20}, Date{2016, 1, 10}}, false}, // empty
+	} {
+		if got := jan.Overlaps(test.r); got != test.want {
+			t.Errorf("%v.Overlaps(%v) = %t, want %t", jan, test.r, got, test.want)
+		}
+		if got := test.r.Overlaps(jan); got != test.want {
+			t.Errorf("%v.Overlaps(%v) = %t, want %t", test.r, jan, got, test.want)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	want := DateRange{Date{2016, 1, 1}, Date{2016, 1, 31}}
+	got, err := ParseDateRange("2016-01-01/2016-01-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := want.String(), "2016-01-01/2016-01-31"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	for _, bad := range []string{"", "2016-01-01", "2016-01-01/", "/2016-01-31", "2016-01-01/2016-02-30"} {
+		if _, err := ParseDateRange(bad); err == nil {
+			t.Errorf("ParseDateRange(%q): got nil, want error", bad)
+		}
+	}
+
+	var r DateRange
+	if err := json.Unmarshal([]byte(`"2016-01-01/2016-01-31"`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r != want {
+		t.Errorf("json.Unmarshal: got %v, want %v", r, want)
+	}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `"2016-01-01/2016-01-31"`; got != want {
+		t.Errorf("json.Marshal: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Value implements the driver.Valuer interface.
+// The value is the result of d.String().
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements the sql.Scanner interface. It accepts a time.Time, whose
+// date in its location is used, or a string or []byte in a format accepted by
+// ParseDate.
+func (d *Date) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case time.Time:
+		*d = DateOf(src)
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(src))
+	case []byte:
+		return d.UnmarshalText(src)
+	default:
+		return scanError(src, d)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+// The value is the result of t.String().
+func (t Time) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan implements the sql.Scanner interface. It accepts a time.Time, whose
+// time of day in its location is used, or a string or []byte in a format
+// accepted by ParseTime.
+func (t *Time) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case time.Time:
+		*t = TimeOf(src)
+		return nil
+	case string:
+		return t.UnmarshalText([]byte(src))
+	case []byte:
+		return t.UnmarshalText(src)
+	default:
+		return scanError(src, t)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+// The value is the result of dt.String().
+func (dt DateTime) Value() (driver.Value, error) {
+	return dt.String(), nil
+}
+
+// Scan implements the sql.Scanner interface. It accepts a time.Time, whose
+// date and time in its location are used, or a string or []byte in a format
+// accepted by ParseDateTime. The separator between the date and the time may
+// also be a space, as is common in SQL databases.
+func (dt *DateTime) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case time.Time:
+		*dt = DateTimeOf(src)
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return scanError(src, dt)
+	}
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+	return dt.UnmarshalText([]byte(s))
+}
+
+// Value implements the driver.Valuer interface.
+// The value is the time.Time returned by ts.Time().
+func (ts Timestamp) Value() (driver.Value, error) {
+	return ts.Time()
+}
+
+// Scan implements the sql.Scanner interface. It accepts a time.Time, or a
+// string or []byte in a format accepted by ParseTimestamp.
+func (ts *Timestamp) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case time.Time:
+		*ts = TimestampOf(src)
+		return nil
+	case string:
+		return ts.UnmarshalText([]byte(src))
+	case []byte:
+		return ts.UnmarshalText(src)
+	default:
+		return scanError(src, ts)
+	}
+}
+
+func scanError(src, dest interface{}) error {
+	if src == nil {
+		return fmt.Errorf("civil: cannot scan NULL into %T", dest)
+	}
+	return fmt.Errorf("civil: cannot scan %T into %T", src, dest)
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	_ sql.Scanner   = (*Date)(nil)
+	_ driver.Valuer = Date{}
+	_ sql.Scanner   = (*Time)(nil)
+	_ driver.Valuer = Time{}
+	_ sql.Scanner   = (*DateTime)(nil)
+	_ driver.Valuer = DateTime{}
+	_ sql.Scanner   = (*Timestamp)(nil)
+	_ driver.Valuer = Timestamp{}
+)
+
+func TestValue(t *testing.T) {
+	for _, test := range []struct {
+		v    driver.Valuer
+		want driver.Value
+	}{
+		{Date{1987, 4, 15}, "1987-04-15"},
+		{Time{18, 54, 2, 0}, "18:54:02"},
+		{DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}, "1987-04-15T18:54:02"},
+		{Timestamp{DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}, "+01:00"}, time.Date(1987, 4, 15, 17, 54, 2, 0, time.UTC)},
+	} {
+		got, err := test.v.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tm, ok := got.(time.Time); ok {
+			if !tm.Equal(test.want.(time.Time)) {
+				t.Errorf("%#v: got %v, want %v", test.v, got, test.want)
+			}
+		} else if got != test.want {
+			t.Errorf("%#v: got %v, want %v", test.v, got, test.want)
+		}
+	}
+	if _, err := (Timestamp{Zone: "Not/A_Zone"}).Value(); err == nil {
+		t.Error("bad zone: got nil, want error")
+	}
+}
+
+func TestScan(t *testing.T) {
+	tm := time.Date(1987, 4, 15, 18, 54, 2, 0, time.UTC)
+	var (
+		d  Date
+		ct Time
+		dt DateTime
+		ts Timestamp
+	)
+	for _, test := range []struct {
+		src  interface{}
+		ptr  sql.Scanner
+		want interface{}
+	}{
+		{tm, &d, &Date{1987, 4, 15}},
+		{"1987-04-15", &d, &Date{1987, 4, 15}},
+		{[]byte("1987-04-15"), &d, &Date{1987, 4, 15}},
+		{tm, &ct, &Time{18, 54, 2, 0}},
+		{"18:54:02", &ct, &Time{18, 54, 2, 0}},
+		{tm, &dt, &DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}},
+		{"1987-04-15 18:54:02", &dt, &DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}},
+		{[]byte("1987-04-15T18:54:02"), &dt, &DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}},
+		{tm, &ts, &Timestamp{DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}, "UTC"}},
+		{"1987-04-15 18:54:02 +01:00", &ts, &Timestamp{DateTime{Date{1987, 4, 15}, Time{18, 54, 2, 0}}, "+01:00"}},
+	} {
+		if err := test.ptr.Scan(test.src); err != nil {
+			t.Errorf("%T.Scan(%v): %v", test.ptr, test.src, err)
+			continue
+		}
+		if !cmp.Equal(test.ptr, test.want) {
+			t.Errorf("%T.Scan(%v): got %v, want %v", test.ptr, test.src, test.ptr, test.want)
+		}
+	}
+	for _, ptr := range []sql.Scanner{&d, &ct, &dt, &ts} {
+		for _, bad := range []interface{}{nil, 42, "bad"} {
+			if err := ptr.Scan(bad); err == nil {
+				t.Errorf("%T.Scan(%v): got nil, want error", ptr, bad)
+			}
+		}
+	}
+}
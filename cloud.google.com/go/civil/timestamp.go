@@ -0,0 +1,159 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Timestamp represents a date and time in a time zone.
+//
+// Unlike the other types in this package, a Timestamp describes a unique moment
+// in time, unless the DateTime is missing or ambiguous in the zone. Unlike
+// time.Time, it keeps the name of the zone, not just its offset, when it is
+// converted to a string and back.
+//
+// This type exists to represent TIMESTAMP values with a time zone in
+// storage-based APIs like BigQuery and Spanner, which store them as moments in
+// UTC.
+type Timestamp struct {
+	DateTime DateTime
+
+	// Zone is a name from the IANA Time Zone Database, such as
+	// "America/New_York", or a UTC offset of the form "+hh:mm" or "-hh:mm".
+	// The empty string means UTC.
+	Zone string
+}
+
+// TimestampOf returns the Timestamp of a time in that time's location. If the
+// location is not a zone of the IANA Time Zone Database, as is the case for
+// time.Local and for zones created by time.FixedZone, the zone of the
+// Timestamp is the UTC offset of t.
+func TimestampOf(t time.Time) Timestamp {
+	zone := t.Location().String()
+	if !isZoneName(zone) {
+		zone = t.Format("-07:00")
+	}
+	return Timestamp{DateTime: DateTimeOf(t), Zone: zone}
+}
+
+// isZoneName reports whether zone is the name of a zone in the IANA Time Zone
+// Database.
+func isZoneName(zone string) bool {
+	if zone == "" || zone == "Local" || zone[0] == '+' || zone[0] == '-' {
+		return false
+	}
+	_, err := time.LoadLocation(zone)
+	return err == nil
+}
+
+// ParseTimestamp parses a string and returns the Timestamp it represents.
+// ParseTimestamp accepts a DateTime in the format accepted by ParseDateTime,
+// where the 'T' may also be a space, optionally followed by a space and a zone
+// in the format described by Timestamp.Zone. This includes the format of
+// BigQuery TIMESTAMP literals, for example
+//     2014-09-27 12:30:00.45 America/Los_Angeles
+func ParseTimestamp(s string) (Timestamp, error) {
+	fields := strings.Split(s, " ")
+	dt, zone := fields[0], ""
+	if len(fields) > 1 && !strings.ContainsAny(fields[0], "Tt") {
+		dt, fields = fields[0]+"T"+fields[1], fields[1:]
+	}
+	switch len(fields) {
+	case 1:
+	case 2:
+		zone = fields[1]
+	default:
+		return Timestamp{}, fmt.Errorf("civil: cannot parse %q as a timestamp", s)
+	}
+	d, err := ParseDateTime(dt)
+	if err != nil {
+		return Timestamp{}, err
+	}
+	if _, err := loadZone(zone); err != nil {
+		return Timestamp{}, err
+	}
+	return Timestamp{DateTime: d, Zone: zone}, nil
+}
+
+// String returns the Timestamp in the format described in ParseTimestamp, with
+// a 'T' between the date and the time. The zone is omitted if it is empty.
+func (ts Timestamp) String() string {
+	if ts.Zone == "" {
+		return ts.DateTime.String()
+	}
+	return ts.DateTime.String() + " " + ts.Zone
+}
+
+// IsValid reports whether the datetime and the zone are valid.
+func (ts Timestamp) IsValid() bool {
+	_, err := loadZone(ts.Zone)
+	return err == nil && ts.DateTime.IsValid()
+}
+
+// Time returns the time.Time corresponding to the Timestamp. It returns an
+// error if the zone cannot be loaded.
+func (ts Timestamp) Time() (time.Time, error) {
+	loc, err := loadZone(ts.Zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.DateTime.In(loc), nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The output is the result of ts.String().
+func (ts Timestamp) MarshalText() ([]byte, error) {
+	return []byte(ts.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// The timestamp is expected to be a string in a format accepted by
+// ParseTimestamp.
+func (ts *Timestamp) UnmarshalText(data []byte) error {
+	var err error
+	*ts, err = ParseTimestamp(string(data))
+	return err
+}
+
+// loadZone returns the location of a zone in the format described by
+// Timestamp.Zone.
+func loadZone(zone string) (*time.Location, error) {
+	if zone == "" {
+		return time.UTC, nil
+	}
+	if zone[0] != '+' && zone[0] != '-' {
+		if !isZoneName(zone) {
+			return nil, fmt.Errorf("civil: unknown time zone %q", zone)
+		}
+		return time.LoadLocation(zone)
+	}
+	if len(zone) != 6 || zone[3] != ':' {
+		return nil, fmt.Errorf("civil: bad UTC offset %q", zone)
+	}
+	h, err1 := strconv.Atoi(zone[1:3])
+	m, err2 := strconv.Atoi(zone[4:6])
+	if err1 != nil || err2 != nil || h > 23 || m > 59 {
+		return nil, fmt.Errorf("civil: bad UTC offset %q", zone)
+	}
+	offset := h*3600 + m*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(zone, offset), nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package civil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	dt := DateTime{Date{2014, 9, 27}, Time{12, 30, 0, 450000000}}
+	for _, test := range []struct {
+		str     string
+		want    Timestamp
+		wantStr string
+	}{
+		{"2014-09-27T12:30:00.45", Timestamp{dt, ""}, "2014-09-27T12:30:00.450000000"},
+		{"2014-09-27 12:30:00.45", Timestamp{dt, ""}, "2014-09-27T12:30:00.450000000"},
+		{"2014-09-27 12:30:00.45 UTC", Timestamp{dt, "UTC"}, "2014-09-27T12:30:00.450000000 UTC"},
+		{"2014-09-27t12:30:00.45 America/Los_Angeles", Timestamp{dt, "America/Los_Angeles"}, "2014-09-27T12:30:00.450000000 America/Los_Angeles"},
+		{"2014-09-27 12:30:00.45 -07:30", Timestamp{dt, "-07:30"}, "2014-09-27T12:30:00.450000000 -07:30"},
+	} {
+		got, err := ParseTimestamp(test.str)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", test.str, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseTimestamp(%q) = %+v, want %+v", test.str, got, test.want)
+		}
+		if s := got.String(); s != test.wantStr {
+			t.Errorf("%+v.String() = %q, want %q", got, s, test.wantStr)
+		}
+	}
+	for _, bad := range []string{
+		"",
+		"2014-09-27",
+		"2014-09-27 12:30:00 UTC extra",
+		"2014-09-27T12:30:00 Not/A_Zone",
+		"2014-09-27T12:30:00 +7:00",
+		"2014-09-27T12:30:00 +24:00",
+	} {
+		if _, err := ParseTimestamp(bad); err == nil {
+			t.Errorf("ParseTimestamp(%q): got nil, want error", bad)
+		}
+	}
+}
+
+func TestTimestampTime(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, tm := range []time.Time{
+		time.Date(2018, 6, 1, 12, 0, 0, 5, ny),
+		time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2018, 6, 1, 12, 0, 0, 0, time.FixedZone("", -(7*3600+1800))),
+		time.Date(2018, 6, 1, 12, 0, 0, 0, time.FixedZone("PDT", -7*3600)),
+		time.Now(),
+		time.Date(2018, 6, 1, 12, 0, 0, 0, time.Local),
+	} {
+		ts := TimestampOf(tm)
+		if !ts.IsValid() {
+			t.Errorf("TimestampOf(%v) = %+v, which is not valid", tm, ts)
+		}
+		got, err := ts.Time()
+		if err != nil {
+			t.Errorf("%+v.Time(): %v", ts, err)
+			continue
+		}
+		if !got.Equal(tm) {
+			t.Errorf("%+v.Time() = %v, want %v", ts, got, tm)
+		}
+	}
+	if got, want := TimestampOf(time.Date(2018, 6, 1, 12, 0, 0, 0, ny)).Zone, "America/New_York"; got != want {
+		t.Errorf("got zone %q, want %q", got, want)
+	}
+	if got, want := TimestampOf(time.Date(2018, 6, 1, 12, 0, 0, 0, time.FixedZone("PDT", -7*3600))).Zone, "-07:00"; got != want {
+		t.Errorf("got zone %q, want %q", got, want)
+	}
+	if got := TimestampOf(time.Now()).Zone; got == "Local" {
+		t.Errorf("TimestampOf(time.Now()): got zone %q, want a UTC offset", got)
+	}
+	if _, err := (Timestamp{Zone: "Local"}).Time(); err == nil {
+		t.Error("Local zone: got nil, want error")
+	}
+	if _, err := (Timestamp{Zone: "Not/A_Zone"}).Time(); err == nil {
+		t.Error("bad zone: got nil, want error")
+	}
+}
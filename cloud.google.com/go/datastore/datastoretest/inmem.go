@@ -0,0 +1,629 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package datastoretest contains an in-memory fake of Cloud Datastore, for
+testing code that uses the datastore package.
+
+The fake stores the entities of any number of projects and namespaces, keeping
+every version of each entity so that reads in a transaction see a consistent
+snapshot. Every commit creates a new version number, which is returned as the
+version of the entities it writes.
+
+Queries support a single kind (or none), equality and inequality filters,
+ancestor filters, sort orders, projections, distinct-on, keys-only queries,
+offsets, limits and cursors. Results are returned in batches, so clients page
+through large result sets with cursors just as they do against Cloud
+Datastore. Index definitions are not required.
+
+Lookups and ancestor queries are strongly consistent. By default, so are all
+other queries. Calling SetEventualConsistency(true) makes queries without an
+ancestor filter, and reads that ask for eventual consistency, see the data as
+of the last call to CatchUp, so that tests can check how code behaves when
+recent writes are not yet visible.
+
+Transactions use optimistic concurrency control: a read-write transaction
+reads a snapshot taken when it begins, and is aborted at commit if an entity
+it looked up, or an entity in the scope of a query it ran, was written since.
+The datastore client retries aborted transactions. Mutations with a base
+version are not applied, and report a conflict, if the entity's current
+version differs.
+
+GQL queries are not supported.
+
+To use a Server, create it and connect a client to it with no security:
+	srv, err := datastoretest.NewServer("localhost:0")
+	...
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	...
+	client, err := datastore.NewClient(ctx, "projectID", option.WithGRPCConn(conn))
+	...
+*/
+package datastoretest // import "cloud.google.com/go/datastore/datastoretest"
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/internal/btree"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/datastore/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// The maximum number of results in a batch of query results.
+	maxBatchSize = 300
+
+	// The maximum number of keys looked up at once. The rest are deferred.
+	maxLookupKeys = 1000
+)
+
+// Server is an in-memory Cloud Datastore fake.
+// It is unauthenticated, and only a rough approximation.
+type Server struct {
+	Addr string
+
+	l   net.Listener
+	srv *grpc.Server
+	s   *server
+}
+
+// server is the real implementation of the fake.
+// It is a separate and unexported type so the API won't be cluttered with
+// methods that are only relevant to the fake's implementation.
+type server struct {
+	mu       sync.Mutex
+	entities *btree.BTree // *entityHistory, keyed by *pb.Key
+	version  int64        // the version of the latest commit
+	nextID   int64        // the next ID to allocate
+	txns     map[string]*transaction
+	nextTxn  int
+
+	// If eventual is true, eventually consistent reads see the data as of
+	// version visible.
+	eventual bool
+	visible  int64
+
+	// Any unimplemented methods will cause a panic.
+	pb.DatastoreServer
+}
+
+// entityHistory holds the versions of an entity, oldest first.
+type entityHistory struct {
+	versions []entityVersion
+}
+
+type entityVersion struct {
+	version int64
+	entity  *pb.Entity // nil if the entity was deleted
+}
+
+// at returns the entity as of version v and the version it was written at,
+// or nil if it did not exist.
+func (h *entityHistory) at(v int64) (*pb.Entity, int64) {
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		if h.versions[i].version <= v {
+			return h.versions[i].entity, h.versions[i].version
+		}
+	}
+	return nil, 0
+}
+
+// updated returns the version of the latest write to the entity.
+func (h *entityHistory) updated() int64 {
+	return h.versions[len(h.versions)-1].version
+}
+
+type transaction struct {
+	id       []byte
+	project  string
+	readOnly bool
+	snapshot int64 // the version read by the transaction
+
+	// For read-write transactions, the keys looked up and the queries run.
+	keys    []*pb.Key
+	queries []*query
+}
+
+// NewServer creates a new Server.
+// The Server will be listening for gRPC connections, without TLS,
+// on the provided address. The resolved address is named by the Addr field.
+func NewServer(laddr string, opt ...grpc.ServerOption) (*Server, error) {
+	l, err := net.Listen("tcp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr: l.Addr().String(),
+		l:    l,
+		srv:  grpc.NewServer(opt...),
+		s: &server{
+			entities: btree.New(8, func(a, b interface{}) bool {
+				return compareKeys(a.(*pb.Key), b.(*pb.Key)) < 0
+			}),
+			nextID: 1,
+			txns:   make(map[string]*transaction),
+		},
+	}
+	pb.RegisterDatastoreServer(s.srv, s.s)
+
+	go s.srv.Serve(s.l)
+
+	return s, nil
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Stop()
+	s.l.Close()
+}
+
+// SetEventualConsistency sets whether eventually consistent reads may see
+// stale data. If on is true, queries without an ancestor filter that are not
+// part of a transaction, and lookups and queries that ask for eventual
+// consistency, see the data as of the last call to CatchUp or
+// SetEventualConsistency. If on is false, the default, all reads see the
+// latest data.
+func (s *Server) SetEventualConsistency(on bool) {
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	s.s.eventual = on
+	s.s.visible = s.s.version
+}
+
+// CatchUp makes all the writes committed so far visible to eventually
+// consistent reads.
+func (s *Server) CatchUp() {
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	s.s.visible = s.s.version
+}
+
+// get returns the entity with key k as of version v, and the version it was
+// written at, or nil. s.mu must be held.
+func (s *server) get(k *pb.Key, v int64) (*pb.Entity, int64) {
+	h, ok := s.entities.Get(k).(*entityHistory)
+	if !ok {
+		return nil, 0
+	}
+	return h.at(v)
+}
+
+// forEachInScope calls f with the key and history of each entity that may be
+// a result of q, in key order. Since keys are ordered by partition and then
+// path, the entities in scope are contiguous, starting at the ancestor if
+// there is one. s.mu must be held.
+func (s *server) forEachInScope(q *query, f func(*pb.Key, *entityHistory)) {
+	start := q.ancestor
+	if start == nil {
+		start = &pb.Key{PartitionId: q.partition}
+	}
+	it := s.entities.Before(start)
+	for it.Next() {
+		k := it.Key.(*pb.Key)
+		if k.PartitionId.ProjectId != q.partition.ProjectId || k.PartitionId.NamespaceId != q.partition.NamespaceId {
+			break
+		}
+		if q.ancestor != nil && !hasAncestor(k, q.ancestor) {
+			break
+		}
+		if q.inScope(k) {
+			f(k, it.Value.(*entityHistory))
+		}
+	}
+}
+
+// normalizeKey checks that k is a well-formed key in project, and returns a
+// copy of it whose partition names the project. If incompleteOK is true, the
+// last element of the key may lack an ID or name.
+func normalizeKey(project string, k *pb.Key, incompleteOK bool) (*pb.Key, error) {
+	if k == nil || len(k.Path) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "missing key path")
+	}
+	if p := k.PartitionId.GetProjectId(); p != "" && p != project {
+		return nil, status.Errorf(codes.InvalidArgument, "mismatched project: key in %q, request in %q", p, project)
+	}
+	for i, e := range k.Path {
+		if e.Kind == "" || isReserved(e.Kind) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid kind %q in key", e.Kind)
+		}
+		switch id := e.IdType.(type) {
+		case *pb.Key_PathElement_Id:
+			if id.Id <= 0 {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid ID %d in key", id.Id)
+			}
+		case *pb.Key_PathElement_Name:
+			if id.Name == "" || isReserved(id.Name) {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid name %q in key", id.Name)
+			}
+		default:
+			if !incompleteOK || i != len(k.Path)-1 {
+				return nil, status.Errorf(codes.InvalidArgument, "incomplete key path element")
+			}
+		}
+	}
+	nk := proto.Clone(k).(*pb.Key)
+	nk.PartitionId = &pb.PartitionId{ProjectId: project, NamespaceId: k.PartitionId.GetNamespaceId()}
+	return nk, nil
+}
+
+// isReserved reports whether s has the form __*__, which is reserved for
+// Cloud Datastore.
+func isReserved(s string) bool {
+	return len(s) >= 4 && strings.HasPrefix(s, "__") && strings.HasSuffix(s, "__")
+}
+
+func isComplete(k *pb.Key) bool {
+	return k.Path[len(k.Path)-1].IdType != nil
+}
+
+// Transactions.
+
+func (s *server) BeginTransaction(ctx context.Context, req *pb.BeginTransactionRequest) (*pb.BeginTransactionResponse, error) {
+	if req.ProjectId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "missing project ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rw := req.TransactionOptions.GetReadWrite(); rw != nil && rw.PreviousTransaction != nil {
+		// The previous transaction is being retried; it is over.
+		delete(s.txns, string(rw.PreviousTransaction))
+	}
+	s.nextTxn++
+	tx := &transaction{
+		id:       []byte(fmt.Sprintf("tx%d", s.nextTxn)),
+		project:  req.ProjectId,
+		readOnly: req.TransactionOptions.GetReadOnly() != nil,
+		snapshot: s.version,
+	}
+	s.txns[string(tx.id)] = tx
+	return &pb.BeginTransactionResponse{Transaction: tx.id}, nil
+}
+
+// lookupTxn returns the transaction with the given ID. s.mu must be held.
+func (s *server) lookupTxn(project string, id []byte) (*transaction, error) {
+	tx, ok := s.txns[string(id)]
+	if !ok || tx.project != project {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown transaction %q", id)
+	}
+	return tx, nil
+}
+
+// readVersion returns the version that a read with the given options
+// should see, and the transaction it is part of, if any. strong is whether
+// the read is strongly consistent by default. s.mu must be held.
+func (s *server) readVersion(project string, opts *pb.ReadOptions, strong bool) (int64, *transaction, error) {
+	switch ct := opts.GetConsistencyType().(type) {
+	case *pb.ReadOptions_Transaction:
+		tx, err := s.lookupTxn(project, ct.Transaction)
+		if err != nil {
+			return 0, nil, err
+		}
+		return tx.snapshot, tx, nil
+	case *pb.ReadOptions_ReadConsistency_:
+		switch ct.ReadConsistency {
+		case pb.ReadOptions_STRONG:
+			strong = true
+		case pb.ReadOptions_EVENTUAL:
+			strong = false
+		}
+	}
+	if s.eventual && !strong {
+		return s.visible, nil, nil
+	}
+	return s.version, nil, nil
+}
+
+// conflicts reports whether anything tx read has been written since its
+// snapshot. s.mu must be held.
+func (s *server) conflicts(tx *transaction) bool {
+	for _, k := range tx.keys {
+		if h, ok := s.entities.Get(k).(*entityHistory); ok && h.updated() > tx.snapshot {
+			return true
+		}
+	}
+	for _, q := range tx.queries {
+		conflict := false
+		s.forEachInScope(q, func(_ *pb.Key, h *entityHistory) {
+			conflict = conflict || h.updated() > tx.snapshot
+		})
+		if conflict {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) Rollback(ctx context.Context, req *pb.RollbackRequest) (*pb.RollbackResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.lookupTxn(req.ProjectId, req.Transaction); err != nil {
+		return nil, err
+	}
+	delete(s.txns, string(req.Transaction))
+	return &pb.RollbackResponse{}, nil
+}
+
+// Reads.
+
+func (s *server) Lookup(ctx context.Context, req *pb.LookupRequest) (*pb.LookupResponse, error) {
+	if len(req.Keys) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no keys")
+	}
+	var keys []*pb.Key
+	for _, k := range req.Keys {
+		nk, err := normalizeKey(req.ProjectId, k, false)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, nk)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, tx, err := s.readVersion(req.ProjectId, req.ReadOptions, true)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.LookupResponse{}
+	for i, k := range keys {
+		if i >= maxLookupKeys {
+			resp.Deferred = append(resp.Deferred, req.Keys[i])
+			continue
+		}
+		if tx != nil && !tx.readOnly {
+			tx.keys = append(tx.keys, k)
+		}
+		if e, ev := s.get(k, v); e != nil {
+			resp.Found = append(resp.Found, &pb.EntityResult{Entity: e, Version: ev})
+		} else {
+			resp.Missing = append(resp.Missing, &pb.EntityResult{Entity: &pb.Entity{Key: k}, Version: v})
+		}
+	}
+	return resp, nil
+}
+
+func (s *server) RunQuery(ctx context.Context, req *pb.RunQueryRequest) (*pb.RunQueryResponse, error) {
+	pq := req.GetQuery()
+	if pq == nil {
+		if req.GetGqlQuery() != nil {
+			return nil, status.Errorf(codes.Unimplemented, "GQL queries are not supported")
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "missing query")
+	}
+	if p := req.PartitionId.GetProjectId(); p != "" && p != req.ProjectId {
+		return nil, status.Errorf(codes.InvalidArgument, "mismatched project: partition in %q, request in %q", p, req.ProjectId)
+	}
+	partition := &pb.PartitionId{ProjectId: req.ProjectId, NamespaceId: req.PartitionId.GetNamespaceId()}
+	q, err := newQuery(partition, pq)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, tx, err := s.readVersion(req.ProjectId, req.ReadOptions, q.ancestor != nil)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil && !tx.readOnly {
+		tx.queries = append(tx.queries, q)
+	}
+	var entities []*pb.Entity
+	var versions []int64
+	s.forEachInScope(q, func(k *pb.Key, h *entityHistory) {
+		if e, ev := h.at(v); e != nil {
+			entities = append(entities, e)
+			versions = append(versions, ev)
+		}
+	})
+	res, cut := q.run(entities, versions)
+
+	batch := &pb.QueryResultBatch{
+		EntityResultType: q.resultType(),
+		EndCursor:        pq.StartCursor,
+		MoreResults:      pb.QueryResultBatch_NO_MORE_RESULTS,
+		SnapshotVersion:  v,
+	}
+	if cut {
+		batch.MoreResults = pb.QueryResultBatch_MORE_RESULTS_AFTER_CURSOR
+	}
+	for len(res) > 0 && int(batch.SkippedResults) < q.offset {
+		batch.SkippedResults++
+		batch.SkippedCursor = encodeCursor(res[0].pos)
+		batch.EndCursor = batch.SkippedCursor
+		res = res[1:]
+	}
+	for _, r := range res {
+		if len(batch.EntityResults) == q.limit {
+			batch.MoreResults = pb.QueryResultBatch_MORE_RESULTS_AFTER_LIMIT
+			break
+		}
+		if len(batch.EntityResults) == maxBatchSize {
+			batch.MoreResults = pb.QueryResultBatch_NOT_FINISHED
+			break
+		}
+		er := q.entityResult(r)
+		batch.EntityResults = append(batch.EntityResults, er)
+		batch.EndCursor = er.Cursor
+	}
+	return &pb.RunQueryResponse{Batch: batch, Query: pq}, nil
+}
+
+// Writes.
+
+func (s *server) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tx *transaction
+	switch req.Mode {
+	case pb.CommitRequest_TRANSACTIONAL:
+		var err error
+		tx, err = s.lookupTxn(req.ProjectId, req.GetTransaction())
+		if err != nil {
+			return nil, err
+		}
+		// Committing ends the transaction, whether or not it succeeds.
+		delete(s.txns, string(tx.id))
+		if tx.readOnly && len(req.Mutations) > 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "mutations in a read-only transaction")
+		}
+	case pb.CommitRequest_NON_TRANSACTIONAL:
+		if req.GetTransaction() != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "transaction in a non-transactional commit")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown mode %v", req.Mode)
+	}
+
+	// Validate all the mutations before applying any of them.
+	type write struct {
+		key       *pb.Key
+		entity    *pb.Entity // nil for a delete
+		allocated bool       // whether the key's ID was allocated
+		conflict  bool       // whether the base version differs
+	}
+	var writes []write
+	seen := map[string]bool{}
+	for _, m := range req.Mutations {
+		var w write
+		var k *pb.Key
+		var op string
+		switch mt := m.Operation.(type) {
+		case *pb.Mutation_Insert:
+			w.entity, k, op = mt.Insert, mt.Insert.GetKey(), "insert"
+		case *pb.Mutation_Update:
+			w.entity, k, op = mt.Update, mt.Update.GetKey(), "update"
+		case *pb.Mutation_Upsert:
+			w.entity, k, op = mt.Upsert, mt.Upsert.GetKey(), "upsert"
+		case *pb.Mutation_Delete:
+			k, op = mt.Delete, "delete"
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown mutation %T", mt)
+		}
+		var err error
+		w.key, err = normalizeKey(req.ProjectId, k, op == "insert" || op == "upsert")
+		if err != nil {
+			return nil, err
+		}
+		if !isComplete(w.key) {
+			w.key.Path[len(w.key.Path)-1].IdType = &pb.Key_PathElement_Id{Id: s.nextID}
+			s.nextID++
+			w.allocated = true
+		} else if id := w.key.Path[len(w.key.Path)-1].GetId(); id >= s.nextID {
+			// Never allocate an ID that is in use.
+			s.nextID = id + 1
+		}
+		ks := w.key.String()
+		if seen[ks] {
+			return nil, status.Errorf(codes.InvalidArgument, "more than one mutation for entity %v", w.key)
+		}
+		seen[ks] = true
+
+		current, cv := s.get(w.key, s.version)
+		if bv, ok := m.ConflictDetectionStrategy.(*pb.Mutation_BaseVersion); ok && bv.BaseVersion != cv {
+			w.conflict = true
+		} else if op == "insert" && current != nil {
+			return nil, status.Errorf(codes.AlreadyExists, "entity already exists: %v", w.key)
+		} else if op == "update" && current == nil {
+			return nil, status.Errorf(codes.NotFound, "no entity to update: %v", w.key)
+		}
+		if w.entity != nil {
+			w.entity = proto.Clone(w.entity).(*pb.Entity)
+			w.entity.Key = w.key
+		}
+		writes = append(writes, w)
+	}
+	if tx != nil && s.conflicts(tx) {
+		return nil, status.Errorf(codes.Aborted, "too much contention on these datastore entities; please try again")
+	}
+
+	v := s.version + 1
+	resp := &pb.CommitResponse{}
+	for _, w := range writes {
+		mr := &pb.MutationResult{Version: v, ConflictDetected: w.conflict}
+		if w.allocated {
+			mr.Key = w.key
+		}
+		if w.conflict {
+			_, mr.Version = s.get(w.key, s.version)
+		} else {
+			h, ok := s.entities.Get(w.key).(*entityHistory)
+			if !ok {
+				h = &entityHistory{}
+				s.entities.Set(w.key, h)
+			}
+			h.versions = append(h.versions, entityVersion{version: v, entity: w.entity})
+			resp.IndexUpdates++
+		}
+		resp.MutationResults = append(resp.MutationResults, mr)
+	}
+	s.version = v
+	return resp, nil
+}
+
+// Key allocation.
+
+func (s *server) AllocateIds(ctx context.Context, req *pb.AllocateIdsRequest) (*pb.AllocateIdsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []*pb.Key
+	for _, k := range req.Keys {
+		nk, err := normalizeKey(req.ProjectId, k, true)
+		if err != nil {
+			return nil, err
+		}
+		if isComplete(nk) {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot allocate an ID for complete key %v", k)
+		}
+		keys = append(keys, nk)
+	}
+	for _, k := range keys {
+		k.Path[len(k.Path)-1].IdType = &pb.Key_PathElement_Id{Id: s.nextID}
+		s.nextID++
+	}
+	return &pb.AllocateIdsResponse{Keys: keys}, nil
+}
+
+func (s *server) ReserveIds(ctx context.Context, req *pb.ReserveIdsRequest) (*pb.ReserveIdsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range req.Keys {
+		nk, err := normalizeKey(req.ProjectId, k, false)
+		if err != nil {
+			return nil, err
+		}
+		id := nk.Path[len(nk.Path)-1].GetId()
+		if id == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot reserve key %v without an ID", k)
+		}
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+	}
+	return &pb.ReserveIdsResponse{}, nil
+}
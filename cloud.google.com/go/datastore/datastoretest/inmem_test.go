@@ -0,0 +1,394 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastoretest
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type entity struct {
+	N    int
+	S    string
+	Tags []string
+}
+
+func newTestClient(t *testing.T) (*datastore.Client, *Server, func()) {
+	srv, err := NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial: %v", err)
+	}
+	client, err := datastore.NewClient(context.Background(), "P", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, srv, func() {
+		client.Close()
+		srv.Close()
+	}
+}
+
+func names(keys []*datastore.Key) []string {
+	var names []string
+	for _, k := range keys {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+func TestPutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+
+	k, err := client.Put(ctx, datastore.IncompleteKey("E", nil), &entity{N: 1})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if k.Incomplete() {
+		t.Fatalf("Put returned incomplete key %v", k)
+	}
+	var got entity
+	if err := client.Get(ctx, k, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.N != 1 {
+		t.Errorf("got %+v, want N=1", got)
+	}
+
+	// The same name in another namespace is a different entity.
+	k1 := datastore.NameKey("E", "a", nil)
+	k2 := datastore.NameKey("E", "a", nil)
+	k2.Namespace = "ns"
+	if _, err := client.PutMulti(ctx, []*datastore.Key{k1, k2}, []*entity{{N: 1}, {N: 2}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	ents := make([]entity, 2)
+	err = client.GetMulti(ctx, []*datastore.Key{k1, datastore.NameKey("E", "missing", nil)}, ents)
+	merr, ok := err.(datastore.MultiError)
+	if !ok || merr[0] != nil || merr[1] != datastore.ErrNoSuchEntity {
+		t.Fatalf("GetMulti: got %v, want ErrNoSuchEntity for the second key only", err)
+	}
+	if ents[0].N != 1 {
+		t.Errorf("GetMulti: got %+v, want N=1", ents[0])
+	}
+	if err := client.Get(ctx, k2, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.N != 2 {
+		t.Errorf("got %+v in namespace, want N=2", got)
+	}
+
+	if _, err := client.Mutate(ctx, datastore.NewInsert(k1, &entity{})); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("insert of existing entity: got %v, want AlreadyExists", err)
+	}
+	if _, err := client.Mutate(ctx, datastore.NewUpdate(datastore.NameKey("E", "missing", nil), &entity{})); status.Code(err) != codes.NotFound {
+		t.Errorf("update of missing entity: got %v, want NotFound", err)
+	}
+
+	if err := client.Delete(ctx, k1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := client.Get(ctx, k1, &got); err != datastore.ErrNoSuchEntity {
+		t.Errorf("Get after Delete: got %v, want ErrNoSuchEntity", err)
+	}
+
+	keys, err := client.AllocateIDs(ctx, []*datastore.Key{datastore.IncompleteKey("E", nil), datastore.IncompleteKey("E", nil)})
+	if err != nil {
+		t.Fatalf("AllocateIDs: %v", err)
+	}
+	if keys[0].ID == keys[1].ID || keys[0].ID == k.ID || keys[1].ID == k.ID {
+		t.Errorf("AllocateIDs returned duplicate IDs: %v, %v, %v", keys[0], keys[1], k)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+
+	parent := datastore.NameKey("P", "p", nil)
+	data := map[string]*entity{
+		"a": {N: 3, S: "x", Tags: []string{"red", "blue"}},
+		"b": {N: 1, S: "y", Tags: []string{"blue"}},
+		"c": {N: 2, S: "x"},
+		"d": {N: 5, S: "z", Tags: []string{"green"}},
+	}
+	for name, e := range data {
+		var p *datastore.Key
+		if name == "a" || name == "c" {
+			p = parent
+		}
+		if _, err := client.Put(ctx, datastore.NameKey("E", name, p), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Put(ctx, datastore.NameKey("Other", "o", nil), &entity{N: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		q    *datastore.Query
+		want []string
+	}{
+		{datastore.NewQuery("E"), []string{"b", "d", "a", "c"}}, // in key order
+		{datastore.NewQuery("E").Order("N"), []string{"b", "c", "a", "d"}},
+		{datastore.NewQuery("E").Order("-N"), []string{"d", "a", "c", "b"}},
+		{datastore.NewQuery("E").Filter("S =", "x").Order("N"), []string{"c", "a"}},
+		{datastore.NewQuery("E").Filter("N >", 1).Filter("N <=", 3), []string{"c", "a"}},
+		{datastore.NewQuery("E").Filter("Tags =", "blue").Order("N"), []string{"b", "a"}},
+		{datastore.NewQuery("E").Ancestor(parent), []string{"a", "c"}},
+		{datastore.NewQuery("E").Ancestor(parent).Filter("N >", 2), []string{"a"}},
+		{datastore.NewQuery("E").Filter("__key__ >", datastore.NameKey("E", "b", nil)), []string{"d", "a", "c"}},
+		{datastore.NewQuery("E").Order("S").Order("-N"), []string{"a", "c", "b", "d"}},
+		{datastore.NewQuery("E").Order("N").Offset(1).Limit(2), []string{"c", "a"}},
+		{datastore.NewQuery("E").Limit(0), nil},
+		{datastore.NewQuery("E").Filter("N >", "1"), nil}, // no entity has a string N
+	} {
+		keys, err := client.GetAll(ctx, test.q.KeysOnly(), nil)
+		if err != nil {
+			t.Fatalf("%+v: %v", test.q, err)
+		}
+		if got := names(keys); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: got %v, want %v", test.q, got, test.want)
+		}
+	}
+
+	// An inequality filter must be on the first sort order.
+	_, err := client.GetAll(ctx, datastore.NewQuery("E").Filter("N >", 1).Order("S").KeysOnly(), nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("inequality not on first order: got %v, want InvalidArgument", err)
+	}
+
+	// Projections return one result per value of a multi-valued property.
+	var projected []entity
+	keys, err := client.GetAll(ctx, datastore.NewQuery("E").Project("Tags").Order("Tags"), &projected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(keys), []string{"b", "a", "d", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projection keys: got %v, want %v", got, want)
+	}
+	var tags []string
+	for _, e := range projected {
+		tags = append(tags, e.Tags...)
+	}
+	if want := []string{"blue", "blue", "green", "red"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("projected tags: got %v, want %v", tags, want)
+	}
+	projected = nil
+	if _, err := client.GetAll(ctx, datastore.NewQuery("E").Project("S").Distinct().Order("S"), &projected); err != nil {
+		t.Fatal(err)
+	}
+	var ss []string
+	for _, e := range projected {
+		ss = append(ss, e.S)
+	}
+	if want := []string{"x", "y", "z"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("distinct: got %v, want %v", ss, want)
+	}
+
+	// Cursors.
+	it := client.Run(ctx, datastore.NewQuery("E").Order("N").KeysOnly())
+	for i := 0; i < 2; i++ {
+		if _, err := it.Next(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cursor, err := it.Cursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err = client.GetAll(ctx, datastore.NewQuery("E").Order("N").KeysOnly().Start(cursor), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(keys), []string{"a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after cursor: got %v, want %v", got, want)
+	}
+	keys, err = client.GetAll(ctx, datastore.NewQuery("E").Order("N").KeysOnly().End(cursor), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(keys), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("before cursor: got %v, want %v", got, want)
+	}
+}
+
+func TestQueryPaging(t *testing.T) {
+	ctx := context.Background()
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+
+	const n = 2*maxBatchSize + 10
+	keys := make([]*datastore.Key, n)
+	ents := make([]entity, n)
+	for i := range keys {
+		keys[i] = datastore.IDKey("E", int64(i+1), nil)
+		ents[i].N = i
+	}
+	for i := 0; i < n; i += 500 {
+		j := i + 500
+		if j > n {
+			j = n
+		}
+		if _, err := client.PutMulti(ctx, keys[i:j], ents[i:j]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		q    *datastore.Query
+		want int
+	}{
+		{datastore.NewQuery("E"), n},
+		{datastore.NewQuery("E").Offset(maxBatchSize + 5), n - maxBatchSize - 5},
+		{datastore.NewQuery("E").Limit(maxBatchSize + 5), maxBatchSize + 5},
+	} {
+		got, err := client.Count(ctx, test.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %d results, want %d", test.q, got, test.want)
+		}
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+
+	k := datastore.NameKey("E", "counter", nil)
+	if _, err := client.Put(ctx, k, &entity{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write by another client between the transaction's read and its commit
+	// aborts the commit, and the client retries.
+	attempts := 0
+	_, err := client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		attempts++
+		var e entity
+		if err := tx.Get(k, &e); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			if _, err := client.Put(ctx, k, &entity{N: 10}); err != nil {
+				return err
+			}
+		}
+		e.N++
+		_, err := tx.Put(k, &e)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+	var e entity
+	if err := client.Get(ctx, k, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.N != 11 {
+		t.Errorf("got N=%d, want 11", e.N)
+	}
+
+	// A query conflicts with new entities in its scope.
+	tx, err := client.NewTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAll(ctx, datastore.NewQuery("E").Ancestor(k).KeysOnly().Transaction(tx), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Put(ctx, datastore.NameKey("E", "child", k), &entity{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Put(datastore.NameKey("E", "other", nil), &entity{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Commit(); err != datastore.ErrConcurrentTransaction {
+		t.Errorf("commit after conflicting write: got %v, want ErrConcurrentTransaction", err)
+	}
+
+	// A read-only transaction reads a snapshot.
+	tx, err = client.NewTransaction(ctx, datastore.ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Put(ctx, k, &entity{N: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Get(k, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.N != 11 {
+		t.Errorf("read-only transaction: got N=%d, want 11", e.N)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventualConsistency(t *testing.T) {
+	ctx := context.Background()
+	client, srv, cleanup := newTestClient(t)
+	defer cleanup()
+
+	parent := datastore.NameKey("P", "p", nil)
+	srv.SetEventualConsistency(true)
+	k := datastore.NameKey("E", "a", parent)
+	if _, err := client.Put(ctx, k, &entity{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	count := func(q *datastore.Query) int {
+		n, err := client.Count(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(datastore.NewQuery("E")); n != 0 {
+		t.Errorf("global query before CatchUp: got %d results, want 0", n)
+	}
+	if n := count(datastore.NewQuery("E").Ancestor(parent)); n != 1 {
+		t.Errorf("ancestor query: got %d results, want 1", n)
+	}
+	if n := count(datastore.NewQuery("E").Ancestor(parent).EventualConsistency()); n != 0 {
+		t.Errorf("eventually consistent ancestor query: got %d results, want 0", n)
+	}
+	var e entity
+	if err := client.Get(ctx, k, &e); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+	srv.CatchUp()
+	if n := count(datastore.NewQuery("E")); n != 1 {
+		t.Errorf("global query after CatchUp: got %d results, want 1", n)
+	}
+}
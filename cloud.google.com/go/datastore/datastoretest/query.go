@@ -0,0 +1,452 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastoretest
+
+// This file contains the execution of queries.
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/genproto/googleapis/datastore/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const keyProperty = "__key__"
+
+// A query is a validated pb.Query.
+type query struct {
+	partition  *pb.PartitionId
+	kind       string  // empty for a kindless query
+	ancestor   *pb.Key // nil if there is no ancestor filter
+	filters    []*pb.PropertyFilter
+	orders     []queryOrder // always ending with an order on __key__
+	projection []string
+	keysOnly   bool
+	distinctOn []string
+	start, end []*pb.Value // decoded cursors, or nil
+	offset     int
+	limit      int // -1 for no limit
+}
+
+type queryOrder struct {
+	property string
+	desc     bool
+}
+
+// newQuery validates q, a query in the given partition.
+func newQuery(partition *pb.PartitionId, q *pb.Query) (*query, error) {
+	nq := &query{
+		partition: partition,
+		offset:    int(q.Offset),
+		limit:     -1,
+	}
+	switch len(q.Kind) {
+	case 0:
+	case 1:
+		nq.kind = q.Kind[0].Name
+		if nq.kind == "" || strings.HasPrefix(nq.kind, "__") {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported kind %q", nq.kind)
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "only one kind may be queried")
+	}
+	if err := nq.addFilter(q.Filter); err != nil {
+		return nil, err
+	}
+
+	// All inequality filters must be on the same property, which must also
+	// be the first sort order.
+	var ineq string
+	for _, f := range nq.filters {
+		if f.Op == pb.PropertyFilter_EQUAL {
+			continue
+		}
+		if ineq != "" && f.Property.Name != ineq {
+			return nil, status.Errorf(codes.InvalidArgument, "inequality filters on more than one property: %q and %q", ineq, f.Property.Name)
+		}
+		ineq = f.Property.Name
+	}
+	for _, o := range q.Order {
+		if o.Property == nil || o.Property.Name == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "order without a property")
+		}
+		nq.orders = append(nq.orders, queryOrder{o.Property.Name, o.Direction == pb.PropertyOrder_DESCENDING})
+	}
+	if ineq != "" {
+		if len(nq.orders) == 0 {
+			nq.orders = []queryOrder{{property: ineq}}
+		} else if nq.orders[0].property != ineq {
+			return nil, status.Errorf(codes.InvalidArgument, "the property %q with an inequality filter must be the first sort order", ineq)
+		}
+	}
+	hasKeyOrder := false
+	for _, o := range nq.orders {
+		hasKeyOrder = hasKeyOrder || o.property == keyProperty
+	}
+	if !hasKeyOrder {
+		nq.orders = append(nq.orders, queryOrder{property: keyProperty})
+	}
+
+	for _, p := range q.Projection {
+		if p.Property == nil || p.Property.Name == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "projection without a property")
+		}
+		if p.Property.Name != keyProperty {
+			nq.projection = append(nq.projection, p.Property.Name)
+		}
+	}
+	nq.keysOnly = len(q.Projection) > 0 && len(nq.projection) == 0
+	for _, p := range q.DistinctOn {
+		nq.distinctOn = append(nq.distinctOn, p.Name)
+	}
+
+	var err error
+	if nq.start, err = nq.decodeCursor(q.StartCursor); err != nil {
+		return nil, err
+	}
+	if nq.end, err = nq.decodeCursor(q.EndCursor); err != nil {
+		return nil, err
+	}
+	if q.Offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative offset %d", q.Offset)
+	}
+	if q.Limit != nil {
+		if q.Limit.Value < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "negative limit %d", q.Limit.Value)
+		}
+		nq.limit = int(q.Limit.Value)
+	}
+	return nq, nil
+}
+
+// addFilter adds the property filters of f, which may be a conjunction.
+func (q *query) addFilter(f *pb.Filter) error {
+	switch ft := f.GetFilterType().(type) {
+	case nil:
+		return nil
+	case *pb.Filter_CompositeFilter:
+		if ft.CompositeFilter.Op != pb.CompositeFilter_AND {
+			return status.Errorf(codes.InvalidArgument, "unsupported composite filter operator %v", ft.CompositeFilter.Op)
+		}
+		for _, sf := range ft.CompositeFilter.Filters {
+			if err := q.addFilter(sf); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *pb.Filter_PropertyFilter:
+		pf := ft.PropertyFilter
+		if pf.Property == nil || pf.Property.Name == "" || pf.Value == nil {
+			return status.Errorf(codes.InvalidArgument, "incomplete property filter")
+		}
+		isKey := pf.Property.Name == keyProperty
+		if isKey || pf.Op == pb.PropertyFilter_HAS_ANCESTOR {
+			k := pf.Value.GetKeyValue()
+			if !isKey || k == nil {
+				return status.Errorf(codes.InvalidArgument, "a filter on %s must compare it with a key", keyProperty)
+			}
+			if k.PartitionId.GetNamespaceId() != q.partition.NamespaceId {
+				return status.Errorf(codes.InvalidArgument, "key filter in namespace %q for query in namespace %q",
+					k.PartitionId.GetNamespaceId(), q.partition.NamespaceId)
+			}
+			k, err := normalizeKey(q.partition.ProjectId, k, false)
+			if err != nil {
+				return err
+			}
+			if pf.Op == pb.PropertyFilter_HAS_ANCESTOR {
+				if q.ancestor != nil {
+					return status.Errorf(codes.InvalidArgument, "more than one ancestor filter")
+				}
+				q.ancestor = k
+				return nil
+			}
+			pf = &pb.PropertyFilter{Property: pf.Property, Op: pf.Op, Value: keyValue(k)}
+		}
+		switch pf.Op {
+		case pb.PropertyFilter_LESS_THAN, pb.PropertyFilter_LESS_THAN_OR_EQUAL, pb.PropertyFilter_GREATER_THAN,
+			pb.PropertyFilter_GREATER_THAN_OR_EQUAL, pb.PropertyFilter_EQUAL:
+		default:
+			return status.Errorf(codes.InvalidArgument, "unsupported filter operator %v", pf.Op)
+		}
+		q.filters = append(q.filters, pf)
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown filter type %T", ft)
+	}
+}
+
+// resultType returns the type of the entities returned by q.
+func (q *query) resultType() pb.EntityResult_ResultType {
+	switch {
+	case q.keysOnly:
+		return pb.EntityResult_KEY_ONLY
+	case len(q.projection) > 0:
+		return pb.EntityResult_PROJECTION
+	default:
+		return pb.EntityResult_FULL
+	}
+}
+
+// inScope reports whether an entity with key k could be a result of q.
+func (q *query) inScope(k *pb.Key) bool {
+	if k.PartitionId.GetProjectId() != q.partition.ProjectId || k.PartitionId.GetNamespaceId() != q.partition.NamespaceId {
+		return false
+	}
+	if q.ancestor != nil && !hasAncestor(k, q.ancestor) {
+		return false
+	}
+	return q.kind == "" || k.Path[len(k.Path)-1].Kind == q.kind
+}
+
+// A result is one result of a query. A projection query can have several
+// results for one entity, one for each combination of the values of its
+// projected array properties.
+type result struct {
+	entity  *pb.Entity
+	version int64
+	values  []*pb.Value // the values of the projected properties
+	pos     []*pb.Value // the values of the sort orders, then values
+}
+
+// run returns the results of q over entities, which must be in scope, in
+// order. The results are those between the start and end cursors; run reports
+// whether the end cursor excluded any results.
+func (q *query) run(entities []*pb.Entity, versions []int64) (res []*result, cut bool) {
+	for i, e := range entities {
+		if !q.matches(e) {
+			continue
+		}
+		for _, vals := range q.projectedValues(e) {
+			r := &result{entity: e, version: versions[i], values: vals}
+			if q.setPosition(r) {
+				res = append(res, r)
+			}
+		}
+	}
+	sort.Sort(&byPosition{q, res})
+
+	if len(q.distinctOn) > 0 {
+		seen := map[string]bool{}
+		var distinct []*result
+		for _, r := range res {
+			var vals []*pb.Value
+			for _, p := range q.distinctOn {
+				vals = append(vals, q.value(r, p, false))
+			}
+			b, err := proto.Marshal(&pb.ArrayValue{Values: vals})
+			if err != nil {
+				panic(err)
+			}
+			if !seen[string(b)] {
+				seen[string(b)] = true
+				distinct = append(distinct, r)
+			}
+		}
+		res = distinct
+	}
+
+	if q.start != nil {
+		i := sort.Search(len(res), func(i int) bool { return q.comparePositions(res[i].pos, q.start) > 0 })
+		res = res[i:]
+	}
+	if q.end != nil {
+		i := sort.Search(len(res), func(i int) bool { return q.comparePositions(res[i].pos, q.end) > 0 })
+		cut = i < len(res)
+		res = res[:i]
+	}
+	return res, cut
+}
+
+// matches reports whether e satisfies all the filters of q. A filter on a
+// multi-valued property is satisfied if any of its values satisfies it.
+func (q *query) matches(e *pb.Entity) bool {
+	for _, f := range q.filters {
+		var vals []*pb.Value
+		if f.Property.Name == keyProperty {
+			vals = []*pb.Value{keyValue(e.Key)}
+		} else {
+			vals = propertyValues(e, f.Property.Name)
+		}
+		ok := false
+		for _, v := range vals {
+			if satisfies(v, f.Op, f.Value) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfies reports whether v op fv holds. Inequalities only hold between
+// values of the same type, and never for NaN.
+func satisfies(v *pb.Value, op pb.PropertyFilter_Operator, fv *pb.Value) bool {
+	if typeOrder(v) != typeOrder(fv) {
+		return false
+	}
+	c := compareValues(v, fv)
+	switch op {
+	case pb.PropertyFilter_EQUAL:
+		return c == 0
+	case pb.PropertyFilter_LESS_THAN:
+		return !isNaN(v) && !isNaN(fv) && c < 0
+	case pb.PropertyFilter_LESS_THAN_OR_EQUAL:
+		return !isNaN(v) && !isNaN(fv) && c <= 0
+	case pb.PropertyFilter_GREATER_THAN:
+		return !isNaN(v) && !isNaN(fv) && c > 0
+	case pb.PropertyFilter_GREATER_THAN_OR_EQUAL:
+		return !isNaN(v) && !isNaN(fv) && c >= 0
+	default:
+		return false
+	}
+}
+
+// projectedValues returns the combinations of the distinct values of the
+// projected properties of e. It returns a single empty combination if q is
+// not a projection, and none if e lacks an indexed value for a projected
+// property.
+func (q *query) projectedValues(e *pb.Entity) [][]*pb.Value {
+	combos := [][]*pb.Value{nil}
+	for _, p := range q.projection {
+		var distinct []*pb.Value
+	vals:
+		for _, v := range propertyValues(e, p) {
+			for _, d := range distinct {
+				if typeOrder(d) == typeOrder(v) && compareValues(d, v) == 0 {
+					continue vals
+				}
+			}
+			distinct = append(distinct, v)
+		}
+		var next [][]*pb.Value
+		for _, c := range combos {
+			for _, v := range distinct {
+				next = append(next, append(c[:len(c):len(c)], v))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// setPosition sets the position of r, and reports whether r has a value for
+// each sort order.
+func (q *query) setPosition(r *result) bool {
+	for _, o := range q.orders {
+		v := q.value(r, o.property, o.desc)
+		if v == nil {
+			return false
+		}
+		r.pos = append(r.pos, v)
+	}
+	r.pos = append(r.pos, r.values...)
+	return true
+}
+
+// value returns the value of the named property of r, or nil if it has none.
+// The value of a multi-valued property that is not projected is its
+// smallest value, or if max is true, its largest.
+func (q *query) value(r *result, property string, max bool) *pb.Value {
+	if property == keyProperty {
+		return keyValue(r.entity.Key)
+	}
+	for i, p := range q.projection {
+		if p == property {
+			return r.values[i]
+		}
+	}
+	var best *pb.Value
+	for _, v := range propertyValues(r.entity, property) {
+		if best == nil {
+			best = v
+		} else if c := compareValues(v, best); (c < 0 && !max) || (c > 0 && max) {
+			best = v
+		}
+	}
+	return best
+}
+
+// comparePositions compares two positions in the order of the results of q.
+func (q *query) comparePositions(a, b []*pb.Value) int {
+	for i := range a {
+		c := compareValues(a[i], b[i])
+		if i < len(q.orders) && q.orders[i].desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+type byPosition struct {
+	q   *query
+	res []*result
+}
+
+func (b *byPosition) Len() int      { return len(b.res) }
+func (b *byPosition) Swap(i, j int) { b.res[i], b.res[j] = b.res[j], b.res[i] }
+func (b *byPosition) Less(i, j int) bool {
+	return b.q.comparePositions(b.res[i].pos, b.res[j].pos) < 0
+}
+
+// A cursor is the encoded position of a result: the query continues after
+// the result with that position.
+func encodeCursor(pos []*pb.Value) []byte {
+	b, err := proto.Marshal(&pb.ArrayValue{Values: pos})
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// decodeCursor decodes a cursor for q. It returns nil for an empty cursor.
+func (q *query) decodeCursor(b []byte) ([]*pb.Value, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var av pb.ArrayValue
+	if err := proto.Unmarshal(b, &av); err != nil || len(av.Values) != len(q.orders)+len(q.projection) {
+		return nil, status.Errorf(codes.InvalidArgument, "cursor does not match query")
+	}
+	return av.Values, nil
+}
+
+// entityResult returns the result as returned by q.
+func (q *query) entityResult(r *result) *pb.EntityResult {
+	er := &pb.EntityResult{
+		Version: r.version,
+		Cursor:  encodeCursor(r.pos),
+	}
+	switch q.resultType() {
+	case pb.EntityResult_FULL:
+		er.Entity = r.entity
+	case pb.EntityResult_KEY_ONLY:
+		er.Entity = &pb.Entity{Key: r.entity.Key}
+	case pb.EntityResult_PROJECTION:
+		er.Entity = &pb.Entity{Key: r.entity.Key, Properties: map[string]*pb.Value{}}
+		for i, p := range q.projection {
+			er.Entity.Properties[p] = r.values[i]
+		}
+	}
+	return er
+}
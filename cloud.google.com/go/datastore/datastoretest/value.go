@@ -0,0 +1,231 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastoretest
+
+// This file contains the ordering of keys and values, and the lookup of
+// indexed property values.
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	pb "google.golang.org/genproto/googleapis/datastore/v1"
+)
+
+// compareKeys compares keys by partition and then path element by path
+// element. Within an element, kinds are compared first, and numeric IDs sort
+// before names. An ancestor sorts before its descendants, which immediately
+// follow it.
+func compareKeys(a, b *pb.Key) int {
+	if c := strings.Compare(a.PartitionId.GetProjectId(), b.PartitionId.GetProjectId()); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.PartitionId.GetNamespaceId(), b.PartitionId.GetNamespaceId()); c != 0 {
+		return c
+	}
+	for i := 0; i < len(a.Path) && i < len(b.Path); i++ {
+		if c := comparePathElements(a.Path[i], b.Path[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt64s(int64(len(a.Path)), int64(len(b.Path)))
+}
+
+func comparePathElements(a, b *pb.Key_PathElement) int {
+	if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+		return c
+	}
+	_, aName := a.IdType.(*pb.Key_PathElement_Name)
+	_, bName := b.IdType.(*pb.Key_PathElement_Name)
+	switch {
+	case aName && bName:
+		return strings.Compare(a.GetName(), b.GetName())
+	case aName:
+		return 1
+	case bName:
+		return -1
+	default:
+		return compareInt64s(a.GetId(), b.GetId())
+	}
+}
+
+// hasAncestor reports whether anc is k or one of its ancestors.
+func hasAncestor(k, anc *pb.Key) bool {
+	if k.PartitionId.GetProjectId() != anc.PartitionId.GetProjectId() ||
+		k.PartitionId.GetNamespaceId() != anc.PartitionId.GetNamespaceId() ||
+		len(anc.Path) > len(k.Path) {
+		return false
+	}
+	for i, e := range anc.Path {
+		if comparePathElements(k.Path[i], e) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// typeOrder returns the position of v's type in the order Cloud Datastore
+// uses for values of mixed types.
+func typeOrder(v *pb.Value) int {
+	switch v.ValueType.(type) {
+	case nil, *pb.Value_NullValue:
+		return 0
+	case *pb.Value_IntegerValue:
+		return 1
+	case *pb.Value_TimestampValue:
+		return 2
+	case *pb.Value_BooleanValue:
+		return 3
+	case *pb.Value_BlobValue:
+		return 4
+	case *pb.Value_StringValue:
+		return 5
+	case *pb.Value_KeyValue:
+		return 6
+	case *pb.Value_DoubleValue:
+		return 7
+	case *pb.Value_GeoPointValue:
+		return 8
+	case *pb.Value_ArrayValue:
+		return 9
+	case *pb.Value_EntityValue:
+		return 10
+	default:
+		panic("datastoretest: bad value type")
+	}
+}
+
+// compareValues compares a and b. Values of different types are ordered by
+// typeOrder. NaN sorts before all other doubles. Arrays and entities are not
+// indexed, so they are only ordered by type.
+func compareValues(a, b *pb.Value) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		return compareInt64s(int64(ta), int64(tb))
+	}
+	switch a.ValueType.(type) {
+	case *pb.Value_IntegerValue:
+		return compareInt64s(a.GetIntegerValue(), b.GetIntegerValue())
+	case *pb.Value_TimestampValue:
+		at, bt := a.GetTimestampValue(), b.GetTimestampValue()
+		if c := compareInt64s(at.Seconds, bt.Seconds); c != 0 {
+			return c
+		}
+		return compareInt64s(int64(at.Nanos), int64(bt.Nanos))
+	case *pb.Value_BooleanValue:
+		ab, bb := a.GetBooleanValue(), b.GetBooleanValue()
+		switch {
+		case ab == bb:
+			return 0
+		case ab:
+			return 1
+		default:
+			return -1
+		}
+	case *pb.Value_BlobValue:
+		return bytes.Compare(a.GetBlobValue(), b.GetBlobValue())
+	case *pb.Value_StringValue:
+		return strings.Compare(a.GetStringValue(), b.GetStringValue())
+	case *pb.Value_KeyValue:
+		return compareKeys(a.GetKeyValue(), b.GetKeyValue())
+	case *pb.Value_DoubleValue:
+		return compareFloat64s(a.GetDoubleValue(), b.GetDoubleValue())
+	case *pb.Value_GeoPointValue:
+		ag, bg := a.GetGeoPointValue(), b.GetGeoPointValue()
+		if c := compareFloat64s(ag.Latitude, bg.Latitude); c != 0 {
+			return c
+		}
+		return compareFloat64s(ag.Longitude, bg.Longitude)
+	default:
+		return 0
+	}
+}
+
+func compareInt64s(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64s(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isNaN(v *pb.Value) bool {
+	d, ok := v.ValueType.(*pb.Value_DoubleValue)
+	return ok && math.IsNaN(d.DoubleValue)
+}
+
+// propertyValues returns the indexed values of the named property of e: the
+// value itself, or the elements of an array. Values excluded from indexes,
+// and entity values, are omitted. A name that is not a property of e, but
+// contains dots, refers to a property of an entity value.
+func propertyValues(e *pb.Entity, name string) []*pb.Value {
+	if v, ok := e.Properties[name]; ok {
+		var vals []*pb.Value
+		for _, v := range elements(v) {
+			if _, ok := v.ValueType.(*pb.Value_EntityValue); !ok && !v.ExcludeFromIndexes {
+				vals = append(vals, v)
+			}
+		}
+		return vals
+	}
+	i := strings.IndexByte(name, '.')
+	if i < 0 {
+		return nil
+	}
+	v, ok := e.Properties[name[:i]]
+	if !ok {
+		return nil
+	}
+	var vals []*pb.Value
+	for _, v := range elements(v) {
+		if sub := v.GetEntityValue(); sub != nil {
+			vals = append(vals, propertyValues(sub, name[i+1:])...)
+		}
+	}
+	return vals
+}
+
+// elements returns the elements of v if it is an array, and v otherwise.
+func elements(v *pb.Value) []*pb.Value {
+	if a, ok := v.ValueType.(*pb.Value_ArrayValue); ok {
+		return a.ArrayValue.Values
+	}
+	return []*pb.Value{v}
+}
+
+func keyValue(k *pb.Key) *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_KeyValue{KeyValue: k}}
+}
@@ -55,6 +55,20 @@ type Config struct {
 	// OnError is the function to call if any background
 	// tasks errored. By default, errors are logged.
 	OnError func(err error)
+
+	// Grouping, if not nil, enables client-side grouping and rate limiting
+	// of the errors passed to Report. See GroupingConfig for details.
+	// Optional.
+	Grouping *GroupingConfig
+
+	// Sinks receive every error event sent by the client, in addition to the
+	// Error Reporting service. Optional.
+	Sinks []Sink
+
+	// LocalOnly disables sending error events to the Error Reporting
+	// service, so that they are only written to Sinks. NewClient does not
+	// connect to the service in this case.
+	LocalOnly bool
 }
 
 // Entry holds information about the reported error.
@@ -71,6 +85,13 @@ type Client struct {
 	apiClient      client
 	serviceContext *pb.ServiceContext
 	bundler        *bundler.Bundler
+	sinks          []Sink
+
+	// grouper is nil unless grouping is enabled. The summaries of the groups
+	// are sent every grouper.interval until stop is closed.
+	grouper *grouper
+	stop    chan struct{}
+	stopped chan struct{}
 
 	onErrorFn func(err error)
 }
@@ -91,9 +112,13 @@ func NewClient(ctx context.Context, projectID string, cfg Config, opts ...option
 	if cfg.ServiceName == "" {
 		cfg.ServiceName = "goapp"
 	}
-	c, err := newClient(ctx, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("creating client: %v", err)
+	var c client
+	if !cfg.LocalOnly {
+		var err error
+		c, err = newClient(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating client: %v", err)
+		}
 	}
 
 	client := &Client{
@@ -103,13 +128,13 @@ func NewClient(ctx context.Context, projectID string, cfg Config, opts ...option
 			Service: cfg.ServiceName,
 			Version: cfg.ServiceVersion,
 		},
+		sinks:     cfg.Sinks,
 		onErrorFn: cfg.OnError,
 	}
 	bundler := bundler.NewBundler((*pb.ReportErrorEventRequest)(nil), func(bundle interface{}) {
 		reqs := bundle.([]*pb.ReportErrorEventRequest)
 		for _, req := range reqs {
-			_, err = client.apiClient.ReportErrorEvent(ctx, req)
-			if err != nil {
+			if err := client.send(ctx, req); err != nil {
 				client.onError(err)
 			}
 		}
@@ -121,9 +146,55 @@ func NewClient(ctx context.Context, projectID string, cfg Config, opts ...option
 	bundler.BundleByteLimit = 1000
 	bundler.BufferedByteLimit = 10000
 	client.bundler = bundler
+	if cfg.Grouping != nil {
+		client.grouper = newGrouper(*cfg.Grouping)
+		client.stop = make(chan struct{})
+		client.stopped = make(chan struct{})
+		go client.sendSummaries()
+	}
 	return client, nil
 }
 
+// send writes an error event to the Error Reporting service and the sinks.
+// It returns the first error encountered.
+func (c *Client) send(ctx context.Context, req *pb.ReportErrorEventRequest) error {
+	var firstErr error
+	if c.apiClient != nil {
+		if _, err := c.apiClient.ReportErrorEvent(ctx, req); err != nil {
+			firstErr = err
+		}
+	}
+	for _, s := range c.sinks {
+		if err := s.WriteEvent(ctx, req); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sendSummaries adds the summaries of the groups to the bundler at the end of
+// each grouping interval, and once more when c.stop is closed.
+func (c *Client) sendSummaries() {
+	defer close(c.stopped)
+	t := time.NewTicker(c.grouper.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-c.stop:
+			c.addSummaries()
+			return
+		}
+		c.addSummaries()
+	}
+}
+
+func (c *Client) addSummaries() {
+	for _, req := range c.grouper.summaries() {
+		c.bundler.Add(req, 1)
+	}
+}
+
 func (c *Client) onError(err error) {
 	if c.onErrorFn != nil {
 		c.onErrorFn(err)
@@ -134,21 +205,38 @@ func (c *Client) onError(err error) {
 
 // Close calls Flush, then closes any resources held by the client.
 // Close should be called when the client is no longer needed.
+// If grouping is enabled, the summaries of the current interval are sent
+// before the buffered reports are flushed.
 func (c *Client) Close() error {
+	if c.grouper != nil {
+		close(c.stop)
+		<-c.stopped
+	}
 	c.Flush()
+	if c.apiClient == nil {
+		return nil
+	}
 	return c.apiClient.Close()
 }
 
 // Report writes an error report. It doesn't block. Errors in
 // writing the error report can be handled via Config.OnError.
+//
+// If grouping is enabled, errors similar to those already reported in the
+// current interval may be counted instead of sent. See GroupingConfig.
 func (c *Client) Report(e Entry) {
-	c.bundler.Add(c.newRequest(e), 1)
+	stack := entryStack(e)
+	req := c.newRequest(e, stack)
+	if c.grouper != nil && !c.grouper.admit(e.Error.Error(), stack, req) {
+		return
+	}
+	c.bundler.Add(req, 1)
 }
 
 // ReportSync writes an error report. It blocks until the entry is written.
+// Errors reported with ReportSync are not grouped.
 func (c *Client) ReportSync(ctx context.Context, e Entry) error {
-	_, err := c.apiClient.ReportErrorEvent(ctx, c.newRequest(e))
-	return err
+	return c.send(ctx, c.newRequest(e, entryStack(e)))
 }
 
 // Flush blocks until all currently buffered error reports are sent.
@@ -160,15 +248,18 @@ func (c *Client) Flush() {
 	c.bundler.Flush()
 }
 
-func (c *Client) newRequest(e Entry) *pb.ReportErrorEventRequest {
-	var stack string
+// entryStack returns the stack trace of an entry, or the stack trace of the
+// caller of Report or ReportSync if the entry has none.
+func entryStack(e Entry) string {
 	if e.Stack != nil {
-		stack = string(e.Stack)
-	} else {
-		// limit the stack trace to 16k.
-		var buf [16 * 1024]byte
-		stack = chopStack(buf[0:runtime.Stack(buf[:], false)])
+		return string(e.Stack)
 	}
+	// limit the stack trace to 16k.
+	var buf [16 * 1024]byte
+	return chopStack(buf[0:runtime.Stack(buf[:], false)])
+}
+
+func (c *Client) newRequest(e Entry, stack string) *pb.ReportErrorEventRequest {
 	message := e.Error.Error() + "\n" + stack
 
 	var errorContext *pb.ErrorContext
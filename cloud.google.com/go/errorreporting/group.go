@@ -0,0 +1,185 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorreporting
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	pb "google.golang.org/genproto/googleapis/devtools/clouderrorreporting/v1beta1"
+)
+
+const (
+	defaultGroupingInterval  = time.Minute
+	defaultGroupingLimit     = 1
+	defaultGroupingMaxGroups = 1000
+)
+
+// GroupingConfig configures client-side grouping of the errors passed to
+// Client.Report.
+//
+// Errors are grouped by a fingerprint of their message and stack trace, in
+// which numbers, addresses and goroutine IDs are ignored. In each interval,
+// only the first Limit errors of a group are sent. At the end of the interval,
+// a summary event with the number of errors suppressed is sent for each group
+// that exceeded the limit. The summary has the message and stack trace of the
+// first error of the group, so the Error Reporting service counts it in the
+// same group.
+type GroupingConfig struct {
+	// Interval is the length of the rate limiting window. It defaults to one
+	// minute.
+	Interval time.Duration
+
+	// Limit is the number of errors of each group that are sent in an
+	// interval. It defaults to 1.
+	Limit int
+
+	// MaxGroups is the number of groups tracked in an interval. Errors of
+	// other groups are sent without rate limiting. It defaults to 1000.
+	MaxGroups int
+}
+
+// grouper rate limits error reports by fingerprint.
+type grouper struct {
+	interval  time.Duration
+	limit     int
+	maxGroups int
+
+	mu     sync.Mutex
+	start  time.Time
+	groups map[string]*group
+}
+
+// group holds the errors with the same fingerprint reported in the current
+// interval.
+type group struct {
+	first      *pb.ReportErrorEventRequest
+	message    string // the message of the first error
+	stack      string // the stack trace of the first error
+	count      int
+	suppressed int
+}
+
+func newGrouper(cfg GroupingConfig) *grouper {
+	g := &grouper{
+		interval:  cfg.Interval,
+		limit:     cfg.Limit,
+		maxGroups: cfg.MaxGroups,
+		start:     time.Now(),
+		groups:    map[string]*group{},
+	}
+	if g.interval <= 0 {
+		g.interval = defaultGroupingInterval
+	}
+	if g.limit <= 0 {
+		g.limit = defaultGroupingLimit
+	}
+	if g.maxGroups <= 0 {
+		g.maxGroups = defaultGroupingMaxGroups
+	}
+	return g
+}
+
+// admit records an error and reports whether it should be sent.
+func (g *grouper) admit(message, stack string, req *pb.ReportErrorEventRequest) bool {
+	fp := fingerprint(message, stack)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	gr := g.groups[fp]
+	if gr == nil {
+		if len(g.groups) >= g.maxGroups {
+			return true
+		}
+		gr = &group{first: req, message: message, stack: stack}
+		g.groups[fp] = gr
+	}
+	gr.count++
+	if gr.count > g.limit {
+		gr.suppressed++
+		return false
+	}
+	return true
+}
+
+// summaries ends the current interval, and returns a summary event for each
+// group with suppressed errors.
+func (g *grouper) summaries() []*pb.ReportErrorEventRequest {
+	g.mu.Lock()
+	groups, start := g.groups, g.start
+	g.groups, g.start = map[string]*group{}, time.Now()
+	g.mu.Unlock()
+
+	var reqs []*pb.ReportErrorEventRequest
+	for _, gr := range groups {
+		if gr.suppressed == 0 {
+			continue
+		}
+		req := proto.Clone(gr.first).(*pb.ReportErrorEventRequest)
+		req.Event.EventTime = ptypes.TimestampNow()
+		req.Event.Message = fmt.Sprintf("%s (%d similar errors suppressed since %s)\n%s",
+			gr.message, gr.suppressed, start.UTC().Format(time.RFC3339), gr.stack)
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+var (
+	numberRE = regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9]+`)
+	// Function arguments and program counter offsets in stack traces, as in
+	// "main.f(0xc420010000, 0x1)" and "/src/main.go:12 +0x2a".
+	stackArgsRE   = regexp.MustCompile(`\([^()]*\)$`)
+	stackOffsetRE = regexp.MustCompile(` \+0x[0-9a-f]+$`)
+	// The creator of a goroutine, as in "created by main.f in goroutine 7".
+	stackCreatorRE = regexp.MustCompile(` in goroutine [0-9]+$`)
+)
+
+// fingerprint returns a key identifying errors with the same message and
+// stack trace, up to numbers in the message and the goroutine-specific parts
+// of the stack trace.
+func fingerprint(message, stack string) string {
+	h := sha1.New()
+	h.Write([]byte(numberRE.ReplaceAllString(message, "#")))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeStack(stack)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeStack removes the goroutine header, function arguments and
+// program counter offsets from a stack trace produced by runtime.Stack.
+func normalizeStack(stack string) string {
+	lines := strings.Split(stack, "\n")
+	var out []string
+	for _, l := range lines {
+		if t := strings.TrimSpace(l); t == "" || strings.HasPrefix(t, "goroutine ") {
+			continue
+		}
+		if strings.HasPrefix(l, "\t") {
+			l = stackOffsetRE.ReplaceAllString(l, "")
+		} else if strings.HasPrefix(l, "created by ") {
+			l = stackCreatorRE.ReplaceAllString(l, "")
+		} else {
+			l = stackArgsRE.ReplaceAllString(l, "()")
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
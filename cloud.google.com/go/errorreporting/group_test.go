@@ -0,0 +1,184 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorreporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/devtools/clouderrorreporting/v1beta1"
+)
+
+const (
+	testStack1 = `goroutine 39 [running]:
+main.fetch(0xc420010000, 0x1)
+	/src/main.go:12 +0x2a
+created by main.main in goroutine 1
+	/src/main.go:30 +0x4f
+`
+	testStack2 = `goroutine 7 [running]:
+main.fetch(0xc420090000, 0x3)
+	/src/main.go:12 +0x2b
+created by main.main in goroutine 1
+	/src/main.go:30 +0x4f
+`
+	testStack3 = `goroutine 7 [running]:
+main.store(0xc420090000)
+	/src/main.go:20 +0x2b
+`
+)
+
+func TestFingerprint(t *testing.T) {
+	base := fingerprint("dial tcp 10.0.0.1:443: connection refused", testStack1)
+	for _, test := range []struct {
+		message, stack string
+		same           bool
+	}{
+		{"dial tcp 10.0.0.2:8443: connection refused", testStack2, true},
+		{"dial tcp 10.0.0.1:443: connection reset", testStack1, false},
+		{"dial tcp 10.0.0.1:443: connection refused", testStack3, false},
+	} {
+		if got := fingerprint(test.message, test.stack) == base; got != test.same {
+			t.Errorf("fingerprint(%q, %q) equal to base: got %t, want %t", test.message, test.stack, got, test.same)
+		}
+	}
+}
+
+func TestNormalizeStack(t *testing.T) {
+	want := `main.fetch()
+	/src/main.go:12
+created by main.main
+	/src/main.go:30`
+	if got := normalizeStack(testStack1); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func testRequest(msg string) *pb.ReportErrorEventRequest {
+	return &pb.ReportErrorEventRequest{Event: &pb.ReportedErrorEvent{Message: msg}}
+}
+
+func TestGrouper(t *testing.T) {
+	g := newGrouper(GroupingConfig{Limit: 2, MaxGroups: 2})
+	if g.interval != defaultGroupingInterval {
+		t.Errorf("got interval %v, want %v", g.interval, defaultGroupingInterval)
+	}
+	var admitted []string
+	for i, msg := range []string{"a 1", "a 2", "a 3", "b", "a 4", "c", "c"} {
+		stack := testStack1
+		if i%2 == 1 {
+			stack = testStack2 // equivalent to testStack1
+		}
+		if g.admit(msg, stack, testRequest(msg)) {
+			admitted = append(admitted, msg)
+		}
+	}
+	// Group "c" is not tracked, since there are already two groups.
+	if got, want := strings.Join(admitted, ","), "a 1,a 2,b,c,c"; got != want {
+		t.Errorf("got admitted %s, want %s", got, want)
+	}
+
+	sums := g.summaries()
+	if len(sums) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(sums))
+	}
+	re := regexp.MustCompile(`^a 1 \(2 similar errors suppressed since \S+\)\ngoroutine 39`)
+	if msg := sums[0].Event.Message; !re.MatchString(msg) {
+		t.Errorf("got summary %q, want match for %s", msg, re)
+	}
+	if sums[0].Event.EventTime == nil {
+		t.Error("summary has no event time")
+	}
+
+	// A new interval starts after the summaries.
+	if !g.admit("a 5", testStack1, testRequest("a 5")) {
+		t.Error("error not admitted in new interval")
+	}
+	if sums := g.summaries(); len(sums) != 0 {
+		t.Errorf("got %d summaries, want 0", len(sums))
+	}
+}
+
+func TestGroupingClient(t *testing.T) {
+	var sink MemorySink
+	c, err := NewClient(context.Background(), "projectID", Config{
+		ServiceName: "myservice",
+		LocalOnly:   true,
+		Sinks:       []Sink{&sink},
+		Grouping:    &GroupingConfig{Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		c.Report(Entry{Error: fmt.Errorf("request %d failed", i)})
+	}
+	c.Flush()
+	events := sink.Events()
+	if len(events) != 1 || !strings.HasPrefix(events[0].Event.Message, "request 0 failed\n") {
+		t.Fatalf("got %v, want one event for request 0", events)
+	}
+
+	// Close sends the summary of the current interval.
+	sink.Reset()
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	events = sink.Events()
+	if len(events) != 1 || !strings.HasPrefix(events[0].Event.Message, "request 0 failed (4 similar errors suppressed") {
+		t.Fatalf("got %v, want one summary event", events)
+	}
+	if got, want := events[0].ProjectName, "projects/projectID"; got != want {
+		t.Errorf("got project %q, want %q", got, want)
+	}
+}
+
+func TestSinks(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	var buf bytes.Buffer
+	var mem MemorySink
+	cfg := defaultConfig
+	cfg.Sinks = []Sink{NewFileSink(&buf), &mem}
+	c := newTestClient(fc, cfg)
+	if err := c.ReportSync(context.Background(), Entry{Error: errors.New("error"), User: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	<-fc.doneCh
+	if fc.req == nil {
+		t.Error("service got no error report")
+	}
+	if events := mem.Events(); len(events) != 1 || events[0] != fc.req {
+		t.Errorf("memory sink got %v, want the report sent to the service", events)
+	}
+	var got struct {
+		ProjectName string
+		Event       struct {
+			Message string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%s: %v", buf.Bytes(), err)
+	}
+	if !strings.HasPrefix(got.Event.Message, "error\n") || got.ProjectName != fc.req.ProjectName {
+		t.Errorf("file sink got %s", buf.Bytes())
+	}
+}
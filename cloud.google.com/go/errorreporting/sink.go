@@ -0,0 +1,80 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorreporting
+
+import (
+	"io"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/devtools/clouderrorreporting/v1beta1"
+)
+
+// A Sink receives the error events sent by a Client, in addition to or
+// instead of the Error Reporting service. See Config.Sinks.
+type Sink interface {
+	// WriteEvent writes an error event. It may be called concurrently.
+	WriteEvent(ctx context.Context, req *pb.ReportErrorEventRequest) error
+}
+
+// NewFileSink returns a Sink that writes each event to w as a JSON object
+// followed by a newline.
+func NewFileSink(w io.Writer) Sink {
+	return &fileSink{w: w}
+}
+
+type fileSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *fileSink) WriteEvent(_ context.Context, req *pb.ReportErrorEventRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := (&jsonpb.Marshaler{}).Marshal(s.w, req); err != nil {
+		return err
+	}
+	_, err := io.WriteString(s.w, "\n")
+	return err
+}
+
+// MemorySink is a Sink that keeps events in memory. It is intended for tests.
+type MemorySink struct {
+	mu   sync.Mutex
+	reqs []*pb.ReportErrorEventRequest
+}
+
+// WriteEvent implements Sink.
+func (s *MemorySink) WriteEvent(_ context.Context, req *pb.ReportErrorEventRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reqs = append(s.reqs, req)
+	return nil
+}
+
+// Events returns the events written so far.
+func (s *MemorySink) Events() []*pb.ReportErrorEventRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pb.ReportErrorEventRequest(nil), s.reqs...)
+}
+
+// Reset discards the events written so far.
+func (s *MemorySink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reqs = nil
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestore
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	gax "github.com/googleapis/gax-go"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// The maximum number of writes in one Commit request of a BulkWriter.
+	bulkWriterMaxBatchSize = 20
+
+	// A BulkWriter starts at bulkWriterInitialOpsPerSecond writes per second,
+	// and increases the rate by bulkWriterRampFactor every
+	// bulkWriterRampInterval, up to bulkWriterMaxOpsPerSecond. This is the
+	// "500/50/5" rule recommended for ramping up traffic to Firestore.
+	bulkWriterInitialOpsPerSecond = 500
+	bulkWriterMaxOpsPerSecond     = 10000
+	bulkWriterRampFactor          = 1.5
+	bulkWriterRampInterval        = 5 * time.Minute
+
+	// The maximum number of attempts to commit a batch.
+	bulkWriterMaxAttempts = 10
+)
+
+var errBulkWriterEnded = errors.New("firestore: BulkWriter has been ended")
+
+// A BulkWriter writes large numbers of documents. Unlike a WriteBatch, it
+// accepts any number of writes and applies them in batches that are not atomic:
+// each write succeeds or fails on its own, and its outcome is reported by the
+// BulkWriterJob returned when it was added.
+//
+// A BulkWriter sends writes in batches of up to 20, and limits its rate to 500
+// writes per second at first, increasing it by 50% every 5 minutes. A batch that
+// fails with a transient error is retried with exponential backoff. If a batch
+// fails with another error, its writes are retried one at a time, so that one
+// bad write does not fail the others.
+//
+// Writes are sent when a batch is full, or when Flush or End is called. The
+// order in which writes are applied is not guaranteed, even for writes to the
+// same document.
+//
+// The methods of BulkWriter are safe for use by multiple goroutines.
+type BulkWriter struct {
+	c        *Client
+	ctx      context.Context
+	err      error // returned by every write method, if non-nil
+	start    time.Time
+	limiter  *rate.Limiter
+	backoff  gax.Backoff // copied for each batch
+	maxBatch int
+
+	mu          sync.Mutex
+	batch       []*BulkWriterJob
+	docs        map[string]bool // documents in batch
+	ended       bool
+	outstanding map[*BulkWriterJob]bool // jobs added and not yet done
+}
+
+// BulkWriter returns a BulkWriter that uses ctx for all of its RPCs. Call End
+// when done with it.
+func (c *Client) BulkWriter(ctx context.Context) *BulkWriter {
+	return &BulkWriter{
+		c:           c,
+		ctx:         ctx,
+		err:         checkTransaction(ctx),
+		start:       time.Now(),
+		limiter:     rate.NewLimiter(bulkWriterInitialOpsPerSecond, bulkWriterMaxBatchSize),
+		backoff:     defaultBackoff,
+		maxBatch:    bulkWriterMaxBatchSize,
+		docs:        map[string]bool{},
+		outstanding: map[*BulkWriterJob]bool{},
+	}
+}
+
+// A BulkWriterJob is the result of a write added to a BulkWriter.
+type BulkWriterJob struct {
+	doc    string // path of the document written
+	writes []*pb.Write
+	done   chan struct{}
+	result *WriteResult
+	err    error
+}
+
+// Results blocks until the write has been applied or has failed, and returns its
+// WriteResult or error.
+func (j *BulkWriterJob) Results() (*WriteResult, error) {
+	<-j.done
+	return j.result, j.err
+}
+
+// Done returns a channel that is closed when the write has been applied or has
+// failed. After that, Results does not block.
+func (j *BulkWriterJob) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *BulkWriterJob) finish(wr *WriteResult, err error) {
+	j.result = wr
+	j.err = err
+	close(j.done)
+}
+
+// Create adds a Create operation to the BulkWriter.
+// See DocumentRef.Create for details.
+func (bw *BulkWriter) Create(dr *DocumentRef, data interface{}) (*BulkWriterJob, error) {
+	ws, err := dr.newCreateWrites(data)
+	return bw.add(dr, ws, err)
+}
+
+// Set adds a Set operation to the BulkWriter.
+// See DocumentRef.Set for details.
+func (bw *BulkWriter) Set(dr *DocumentRef, data interface{}, opts ...SetOption) (*BulkWriterJob, error) {
+	ws, err := dr.newSetWrites(data, opts)
+	return bw.add(dr, ws, err)
+}
+
+// Delete adds a Delete operation to the BulkWriter.
+// See DocumentRef.Delete for details.
+func (bw *BulkWriter) Delete(dr *DocumentRef, opts ...Precondition) (*BulkWriterJob, error) {
+	ws, err := dr.newDeleteWrites(opts)
+	return bw.add(dr, ws, err)
+}
+
+// Update adds an Update operation to the BulkWriter.
+// See DocumentRef.Update for details.
+func (bw *BulkWriter) Update(dr *DocumentRef, data []Update, opts ...Precondition) (*BulkWriterJob, error) {
+	ws, err := dr.newUpdatePathWrites(data, opts)
+	return bw.add(dr, ws, err)
+}
+
+func (bw *BulkWriter) add(dr *DocumentRef, ws []*pb.Write, err error) (*BulkWriterJob, error) {
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.ended {
+		return nil, errBulkWriterEnded
+	}
+	// Keep writes to the same document in separate batches: a Commit with
+	// several writes to one document is applied atomically, so a failure of
+	// one would fail the others.
+	if bw.docs[dr.Path] {
+		bw.sendLocked()
+	}
+	j := &BulkWriterJob{doc: dr.Path, writes: ws, done: make(chan struct{})}
+	bw.outstanding[j] = true
+	bw.batch = append(bw.batch, j)
+	bw.docs[dr.Path] = true
+	if len(bw.batch) >= bw.maxBatch {
+		bw.sendLocked()
+	}
+	return j, nil
+}
+
+// Flush sends all pending writes, and blocks until every write added to the
+// BulkWriter so far has been applied or has failed.
+func (bw *BulkWriter) Flush() {
+	bw.mu.Lock()
+	bw.sendLocked()
+	jobs := bw.outstandingLocked()
+	bw.mu.Unlock()
+	waitJobs(jobs)
+}
+
+// End flushes the BulkWriter and closes it. Writes added after End fail.
+func (bw *BulkWriter) End() {
+	bw.mu.Lock()
+	bw.ended = true
+	bw.sendLocked()
+	jobs := bw.outstandingLocked()
+	bw.mu.Unlock()
+	waitJobs(jobs)
+}
+
+// outstandingLocked returns the jobs that are not done yet. Flush waits for
+// these only: jobs added by other goroutines while it waits may sit in a batch
+// that nobody sends.
+//
+// Must be called with bw.mu held.
+func (bw *BulkWriter) outstandingLocked() []*BulkWriterJob {
+	jobs := make([]*BulkWriterJob, 0, len(bw.outstanding))
+	for j := range bw.outstanding {
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// waitJobs blocks until all of jobs are done.
+func waitJobs(jobs []*BulkWriterJob) {
+	for _, j := range jobs {
+		<-j.done
+	}
+}
+
+// sendLocked starts sending the current batch, if it is not empty.
+//
+// Must be called with bw.mu held.
+func (bw *BulkWriter) sendLocked() {
+	if len(bw.batch) == 0 {
+		return
+	}
+	jobs := bw.batch
+	bw.batch = nil
+	bw.docs = map[string]bool{}
+	go bw.send(jobs)
+}
+
+// send commits jobs, retrying transient failures, and finishes each job.
+func (bw *BulkWriter) send(jobs []*BulkWriterJob) {
+	backoff := bw.backoff
+	var ws []*pb.Write
+	for _, j := range jobs {
+		ws = append(ws, j.writes...)
+	}
+	req := &pb.CommitRequest{Database: bw.c.path(), Writes: ws}
+	for attempt := 1; ; attempt++ {
+		bw.limiter.SetLimit(bulkWriterRate(time.Since(bw.start)))
+		if err := bw.limiter.WaitN(bw.ctx, len(jobs)); err != nil {
+			bw.finishAll(jobs, nil, err)
+			return
+		}
+		res, err := bw.c.c.Commit(withResourceHeader(bw.ctx, req.Database), req)
+		if err == nil {
+			bw.finishCommitted(jobs, res)
+			return
+		}
+		if !isRetryableBulkError(err) {
+			if len(jobs) == 1 {
+				bw.finishAll(jobs, nil, err)
+				return
+			}
+			// Find out which writes failed by retrying them one at a time.
+			for _, j := range jobs {
+				go bw.send([]*BulkWriterJob{j})
+			}
+			return
+		}
+		if attempt >= bulkWriterMaxAttempts {
+			bw.finishAll(jobs, nil, err)
+			return
+		}
+		if err := sleep(bw.ctx, backoff.Pause()); err != nil {
+			bw.finishAll(jobs, nil, err)
+			return
+		}
+	}
+}
+
+// finishCommitted finishes jobs with the results of a successful commit. A job
+// with several writes, like a Set with a server timestamp, gets the result of its
+// first write.
+func (bw *BulkWriter) finishCommitted(jobs []*BulkWriterJob, res *pb.CommitResponse) {
+	i := 0
+	for _, j := range jobs {
+		if i >= len(res.WriteResults) {
+			bw.finishAll([]*BulkWriterJob{j}, nil, errors.New("firestore: missing WriteResult"))
+		} else {
+			wr, err := writeResultFromProto(res.WriteResults[i])
+			bw.finish(j, wr, err)
+		}
+		i += len(j.writes)
+	}
+}
+
+// finish finishes j and stops tracking it.
+func (bw *BulkWriter) finish(j *BulkWriterJob, wr *WriteResult, err error) {
+	bw.mu.Lock()
+	delete(bw.outstanding, j)
+	bw.mu.Unlock()
+	j.finish(wr, err)
+}
+
+func (bw *BulkWriter) finishAll(jobs []*BulkWriterJob, wr *WriteResult, err error) {
+	for _, j := range jobs {
+		bw.finish(j, wr, err)
+	}
+}
+
+// bulkWriterRate returns the maximum rate of writes, in writes per second, of a
+// BulkWriter that was created elapsed ago.
+func bulkWriterRate(elapsed time.Duration) rate.Limit {
+	steps := float64(elapsed / bulkWriterRampInterval)
+	r := bulkWriterInitialOpsPerSecond * math.Pow(bulkWriterRampFactor, steps)
+	if r > bulkWriterMaxOpsPerSecond {
+		r = bulkWriterMaxOpsPerSecond
+	}
+	return rate.Limit(r)
+}
+
+// isRetryableBulkError reports whether err is a transient error after which a
+// BulkWriter retries a batch.
+func isRetryableBulkError(err error) bool {
+	switch status.Code(err) {
+	case codes.Aborted, codes.Unavailable, codes.ResourceExhausted, codes.Internal, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
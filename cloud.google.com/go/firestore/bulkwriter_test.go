@@ -0,0 +1,301 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+	"github.com/golang/protobuf/ptypes"
+	gax "github.com/googleapis/gax-go"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/option"
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// commitServer is a fake Firestore server that handles Commit with a function,
+// and records the requests it receives.
+type commitServer struct {
+	pb.FirestoreServer
+
+	mu     sync.Mutex
+	reqs   []*pb.CommitRequest
+	commit func(*pb.CommitRequest, int) error // called with the request and its index
+}
+
+func (s *commitServer) Commit(_ context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	s.mu.Lock()
+	s.reqs = append(s.reqs, req)
+	n := len(s.reqs) - 1
+	s.mu.Unlock()
+	if s.commit != nil {
+		if err := s.commit(req, n); err != nil {
+			return nil, err
+		}
+	}
+	// Each write's update time encodes its position in the request.
+	res := &pb.CommitResponse{}
+	for i := range req.Writes {
+		ts, err := ptypes.TimestampProto(time.Unix(int64(i), 0))
+		if err != nil {
+			return nil, err
+		}
+		res.WriteResults = append(res.WriteResults, &pb.WriteResult{UpdateTime: ts})
+	}
+	return res, nil
+}
+
+func (s *commitServer) requests() []*pb.CommitRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pb.CommitRequest(nil), s.reqs...)
+}
+
+func newBulkWriterTest(t *testing.T, commit func(*pb.CommitRequest, int) error) (*BulkWriter, *commitServer) {
+	srv, err := testutil.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := &commitServer{commit: commit}
+	pb.RegisterFirestoreServer(srv.Gsrv, cs)
+	srv.Start()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(context.Background(), "projectID", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bw := c.BulkWriter(context.Background())
+	bw.backoff = gax.Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+	bw.limiter = rate.NewLimiter(rate.Inf, bulkWriterMaxBatchSize)
+	return bw, cs
+}
+
+func TestBulkWriterBatches(t *testing.T) {
+	bw, srv := newBulkWriterTest(t, nil)
+	coll := bw.c.Collection("C")
+	const n = 45
+	var jobs []*BulkWriterJob
+	for i := 0; i < n; i++ {
+		j, err := bw.Set(coll.Doc(fmt.Sprintf("d%d", i)), map[string]interface{}{"a": i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, j)
+	}
+	// Writes to the same document go to separate batches.
+	j, err := bw.Delete(coll.Doc("d44"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs = append(jobs, j)
+	bw.End()
+
+	// Batches are sent concurrently, so they may arrive in any order.
+	var sizes []int
+	for _, req := range srv.requests() {
+		sizes = append(sizes, len(req.Writes))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	if got, want := sizes, []int{20, 20, 5, 1}; !testutil.Equal(got, want) {
+		t.Errorf("batch sizes: got %v, want %v", got, want)
+	}
+	for i, j := range jobs {
+		wr, err := j.Results()
+		if err != nil {
+			t.Fatalf("job %d: %v", i, err)
+		}
+		// The update time is the position of the write in its batch.
+		if got, want := wr.UpdateTime.Unix(), int64(i%20); i < n && got != want {
+			t.Errorf("job %d: got position %d, want %d", i, got, want)
+		}
+	}
+	if _, err := bw.Create(coll.Doc("late"), map[string]interface{}{}); err != errBulkWriterEnded {
+		t.Errorf("after End: got %v, want errBulkWriterEnded", err)
+	}
+	bw.End() // ending twice is a no-op
+}
+
+func TestBulkWriterRetry(t *testing.T) {
+	bw, srv := newBulkWriterTest(t, func(req *pb.CommitRequest, n int) error {
+		if n < 2 {
+			return status.Error(codes.Unavailable, "try again")
+		}
+		return nil
+	})
+	coll := bw.c.Collection("C")
+	j1, err := bw.Create(coll.Doc("a"), map[string]interface{}{"x": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	j2, err := bw.Update(coll.Doc("b"), []Update{{Path: "x", Value: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bw.Flush()
+	for _, j := range []*BulkWriterJob{j1, j2} {
+		if _, err := j.Results(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := len(srv.requests()), 3; got != want {
+		t.Errorf("got %d commits, want %d", got, want)
+	}
+	bw.End()
+}
+
+func TestBulkWriterPartialFailure(t *testing.T) {
+	bw, srv := newBulkWriterTest(t, func(req *pb.CommitRequest, _ int) error {
+		for _, w := range req.Writes {
+			if u := w.GetUpdate(); u != nil && strings.HasSuffix(u.Name, "/bad") {
+				return status.Error(codes.AlreadyExists, "exists")
+			}
+		}
+		return nil
+	})
+	coll := bw.c.Collection("C")
+	jobs := map[string]*BulkWriterJob{}
+	for _, id := range []string{"a", "bad", "c"} {
+		j, err := bw.Create(coll.Doc(id), map[string]interface{}{"x": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs[id] = j
+	}
+	bw.End()
+	for id, j := range jobs {
+		_, err := j.Results()
+		if id == "bad" {
+			if status.Code(err) != codes.AlreadyExists {
+				t.Errorf("%s: got %v, want AlreadyExists", id, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+	// One batch, then one commit per write.
+	if got, want := len(srv.requests()), 4; got != want {
+		t.Errorf("got %d commits, want %d", got, want)
+	}
+}
+
+func TestBulkWriterGiveUp(t *testing.T) {
+	bw, srv := newBulkWriterTest(t, func(*pb.CommitRequest, int) error {
+		return status.Error(codes.ResourceExhausted, "slow down")
+	})
+	j, err := bw.Delete(bw.c.Doc("C/a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bw.End()
+	if _, err := j.Results(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got %v, want ResourceExhausted", err)
+	}
+	if got, want := len(srv.requests()), bulkWriterMaxAttempts; got != want {
+		t.Errorf("got %d commits, want %d", got, want)
+	}
+}
+
+func TestBulkWriterErrors(t *testing.T) {
+	bw, srv := newBulkWriterTest(t, nil)
+	if _, err := bw.Create(nil, map[string]interface{}{}); err != errNilDocRef {
+		t.Errorf("got %v, want errNilDocRef", err)
+	}
+	if _, err := bw.Create(bw.c.Doc("C/a"), 7); err == nil {
+		t.Error("got nil, want error for bad data")
+	}
+	bw.End()
+	if len(srv.requests()) != 0 {
+		t.Errorf("got %d commits, want none", len(srv.requests()))
+	}
+
+	ctx := context.WithValue(context.Background(), transactionInProgressKey{}, 1)
+	if _, err := bw.c.BulkWriter(ctx).Delete(bw.c.Doc("C/a")); err != errNonTransactionalOp {
+		t.Errorf("in transaction: got %v, want errNonTransactionalOp", err)
+	}
+}
+
+func TestBulkWriterConcurrentFlush(t *testing.T) {
+	// Run with -race: Flush must not race with writes added concurrently.
+	bw, srv := newBulkWriterTest(t, nil)
+	coll := bw.c.Collection("C")
+	const goroutines, writes = 4, 30
+	var wg sync.WaitGroup
+	errc := make(chan error, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				j, err := bw.Set(coll.Doc(fmt.Sprintf("g%d-%d", g, i)), map[string]interface{}{"a": i})
+				if err != nil {
+					errc <- err
+					return
+				}
+				if i%7 == 0 {
+					bw.Flush()
+					// Flush waits for the writes added before it.
+					select {
+					case <-j.Done():
+					default:
+						errc <- fmt.Errorf("g%d-%d: not done after Flush", g, i)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	bw.End()
+	close(errc)
+	for err := range errc {
+		t.Error(err)
+	}
+	n := 0
+	for _, req := range srv.requests() {
+		n += len(req.Writes)
+	}
+	if want := goroutines * writes; n != want {
+		t.Errorf("got %d writes, want %d", n, want)
+	}
+}
+
+func TestBulkWriterRate(t *testing.T) {
+	for _, test := range []struct {
+		elapsed time.Duration
+		want    rate.Limit
+	}{
+		{0, 500},
+		{4 * time.Minute, 500},
+		{5 * time.Minute, 750},
+		{11 * time.Minute, 1125},
+		{10 * time.Hour, bulkWriterMaxOpsPerSecond},
+	} {
+		if got := bulkWriterRate(test.elapsed); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.elapsed, got, test.want)
+		}
+	}
+}
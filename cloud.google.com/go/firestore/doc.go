@@ -162,6 +162,19 @@ atomically.
 		Delete(client.Doc("States/WestDakota")).
 		Commit(ctx)
 
+A WriteBatch holds at most 500 writes. To write more documents, such as in a
+backfill, use a BulkWriter. It sends writes in batches that are not atomic,
+limits and gradually increases its rate of writes, and retries failed writes.
+Each write returns a BulkWriterJob that reports its result.
+
+	bw := client.BulkWriter(ctx)
+	for _, s := range states {
+		if _, err := bw.Set(client.Collection("States").Doc(s.ID), s); err != nil {
+			// TODO: Handle error.
+		}
+	}
+	bw.End() // wait for all writes to finish
+
 Queries
 
 You can use SQL to select documents from a collection. Begin with the collection, and
@@ -91,6 +91,31 @@ func ExampleClient_Batch() {
 	_ = b // TODO: Use batch.
 }
 
+func ExampleBulkWriter() {
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, "project-id")
+	if err != nil {
+		// TODO: Handle error.
+	}
+	defer client.Close()
+
+	bw := client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for i := 0; i < 10000; i++ {
+		job, err := bw.Set(client.Collection("Counters").NewDoc(), map[string]int{"n": i})
+		if err != nil {
+			// TODO: Handle error.
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			// TODO: Handle error.
+		}
+	}
+}
+
 func ExampleWriteBatch_Commit() {
 	ctx := context.Background()
 	client, err := firestore.NewClient(ctx, "project-id")
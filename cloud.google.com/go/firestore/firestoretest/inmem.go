@@ -0,0 +1,956 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package firestoretest contains an in-memory fake of Cloud Firestore, for
+testing code that uses the firestore package.
+
+The fake stores documents of any number of databases, keeping every version of
+each document so that reads at a past time, such as those of read-only
+transactions, see a consistent snapshot. It executes structured queries with
+filters, orders, cursors, offsets, limits and projections; applies writes with
+update masks, preconditions and transforms (server timestamps, ArrayUnion and
+ArrayRemove); and supports read-write transactions using optimistic
+concurrency control: a transaction is aborted at commit if a document it read,
+or a document in a collection it queried, was written after its first read.
+The firestore client retries aborted transactions.
+
+The Listen stream is driven by commits. After a target is added the fake sends
+its initial results, then after every commit that changes the results of a
+target, it sends the changed documents in name order followed by a global
+NO_CHANGE carrying the commit time. This makes the sequence of snapshots and
+document changes seen by the client deterministic. A target that is resumed
+with a resume token or read time is reset and resent in full.
+
+The streaming Write RPC is not supported.
+
+To use a Server, create it and connect a client to it with no security:
+	srv, err := firestoretest.NewServer("localhost:0")
+	...
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	...
+	client, err := firestore.NewClient(ctx, "projectID", option.WithGRPCConn(conn))
+	...
+*/
+package firestoretest // import "cloud.google.com/go/firestore/firestoretest"
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/internal/btree"
+	"cloud.google.com/go/internal/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	emptypb "github.com/golang/protobuf/ptypes/empty"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server is an in-memory Cloud Firestore fake.
+// It is unauthenticated, and only a rough approximation.
+type Server struct {
+	Addr string
+
+	l   net.Listener
+	srv *grpc.Server
+	s   *server
+}
+
+// server is the real implementation of the fake.
+// It is a separate and unexported type so the API won't be cluttered with
+// methods that are only relevant to the fake's implementation.
+type server struct {
+	mu        sync.Mutex
+	docs      *btree.BTree // *docHistory, keyed by document name
+	txns      map[string]*transaction
+	nextTxn   int
+	lastTS    time.Time // the latest timestamp used for a read or commit
+	listeners map[*listener]bool
+
+	// Any unimplemented methods will cause a panic.
+	pb.FirestoreServer
+}
+
+// docHistory holds the versions of a document, oldest first.
+type docHistory struct {
+	versions []docVersion
+}
+
+type docVersion struct {
+	t   time.Time
+	doc *pb.Document // nil if the document was deleted
+}
+
+// at returns the document as of t, or nil if it did not exist.
+func (h *docHistory) at(t time.Time) *pb.Document {
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		if !h.versions[i].t.After(t) {
+			return h.versions[i].doc
+		}
+	}
+	return nil
+}
+
+// updated returns the time of the latest write to the document.
+func (h *docHistory) updated() time.Time {
+	return h.versions[len(h.versions)-1].t
+}
+
+type transaction struct {
+	id       []byte
+	database string
+	readOnly bool
+
+	// For read-only transactions, the time of the snapshot read.
+	readTime time.Time
+
+	// For read-write transactions, the time of the first read, or zero, and
+	// the documents and queries read since.
+	firstRead time.Time
+	docs      map[string]bool
+	queries   []*query
+}
+
+// NewServer creates a new Server.
+// The Server will be listening for gRPC connections, without TLS,
+// on the provided address. The resolved address is named by the Addr field.
+func NewServer(laddr string, opt ...grpc.ServerOption) (*Server, error) {
+	l, err := net.Listen("tcp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr: l.Addr().String(),
+		l:    l,
+		srv:  grpc.NewServer(opt...),
+		s: &server{
+			docs: btree.New(8, func(a, b interface{}) bool {
+				return compareNames(a.(string), b.(string)) < 0
+			}),
+			txns:      make(map[string]*transaction),
+			listeners: make(map[*listener]bool),
+		},
+	}
+	pb.RegisterFirestoreServer(s.srv, s.s)
+
+	go s.srv.Serve(s.l)
+
+	return s, nil
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Stop()
+	s.l.Close()
+}
+
+// now returns a timestamp later than any the server has used.
+// s.mu must be held.
+func (s *server) now() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(s.lastTS) {
+		t = s.lastTS.Add(time.Microsecond)
+	}
+	s.lastTS = t
+	return t
+}
+
+// fence ensures that later commits have timestamps after t, so that a
+// snapshot read at t stays consistent. s.mu must be held.
+func (s *server) fence(t time.Time) {
+	if t.After(s.lastTS) {
+		s.lastTS = t
+	}
+}
+
+// get returns the named document as of t, or nil. s.mu must be held.
+func (s *server) get(name string, t time.Time) *pb.Document {
+	h, ok := s.docs.Get(name).(*docHistory)
+	if !ok {
+		return nil
+	}
+	return h.at(t)
+}
+
+// docsBelow returns the documents below parent as of t, in name order.
+// s.mu must be held.
+func (s *server) docsBelow(parent string, t time.Time) []*pb.Document {
+	var docs []*pb.Document
+	s.forEachBelow(parent, func(name string, h *docHistory) {
+		if doc := h.at(t); doc != nil {
+			docs = append(docs, doc)
+		}
+	})
+	return docs
+}
+
+// forEachBelow calls f with each document history below parent, in name order.
+// Since names are ordered component by component, the documents below
+// parent immediately follow it. s.mu must be held.
+func (s *server) forEachBelow(parent string, f func(string, *docHistory)) {
+	it := s.docs.Before(parent)
+	for it.Next() {
+		name := it.Key.(string)
+		if name == parent {
+			continue
+		}
+		if !strings.HasPrefix(name, parent+"/") {
+			break
+		}
+		f(name, it.Value.(*docHistory))
+	}
+}
+
+// Resource names.
+
+// splitName splits a resource name of the form
+// projects/P/databases/D/documents[/C/D...] into its components, and reports
+// whether it is well formed.
+func splitName(name string) ([]string, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) < 4 || parts[0] != "projects" || parts[2] != "databases" {
+		return nil, false
+	}
+	for _, p := range parts {
+		if p == "" {
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+func validDatabase(name string) bool {
+	parts, ok := splitName(name)
+	return ok && len(parts) == 4
+}
+
+// validParent reports whether name is the document root of a database or the
+// name of a document, either of which may contain collections.
+func validParent(name string) bool {
+	parts, ok := splitName(name)
+	return ok && len(parts) >= 5 && parts[4] == "documents" && len(parts)%2 == 1
+}
+
+func validDocName(name string) bool {
+	return validParent(name) && strings.Count(name, "/") >= 6
+}
+
+// normalizeParent returns the parent of a query or listing. The firestore
+// client names the document root of a database by the database name alone.
+func normalizeParent(name string) string {
+	if validDatabase(name) {
+		return name + "/documents"
+	}
+	return name
+}
+
+// databaseOf returns the database of a valid parent or document name.
+func databaseOf(name string) string {
+	parts := strings.SplitN(name, "/", 5)
+	return strings.Join(parts[:4], "/")
+}
+
+func mustTimestampProto(t time.Time) *tspb.Timestamp {
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+// applyMask returns doc restricted to the fields of mask, if it is set.
+func applyMask(doc *pb.Document, mask *pb.DocumentMask) (*pb.Document, error) {
+	if mask == nil {
+		return doc, nil
+	}
+	var fields [][]string
+	for _, p := range mask.FieldPaths {
+		fp, err := parseFieldPath(p)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		fields = append(fields, fp)
+	}
+	return projectDocument(doc, fields), nil
+}
+
+// Transactions.
+
+func (s *server) BeginTransaction(ctx context.Context, req *pb.BeginTransactionRequest) (*pb.BeginTransactionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.begin(req.Database, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BeginTransactionResponse{Transaction: tx.id}, nil
+}
+
+// begin starts a new transaction. s.mu must be held.
+func (s *server) begin(database string, opts *pb.TransactionOptions) (*transaction, error) {
+	if !validDatabase(database) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid database %q", database)
+	}
+	s.nextTxn++
+	tx := &transaction{
+		id:       []byte(fmt.Sprintf("txn%d", s.nextTxn)),
+		database: database,
+	}
+	switch m := opts.GetMode().(type) {
+	case *pb.TransactionOptions_ReadOnly_:
+		tx.readOnly = true
+		if rt := m.ReadOnly.GetReadTime(); rt != nil {
+			t, err := ptypes.Timestamp(rt)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+			s.fence(t)
+			tx.readTime = t
+		} else {
+			tx.readTime = s.now()
+		}
+	case *pb.TransactionOptions_ReadWrite_:
+		// A retried transaction is superseded by the new one.
+		delete(s.txns, string(m.ReadWrite.RetryTransaction))
+	}
+	s.txns[string(tx.id)] = tx
+	return tx, nil
+}
+
+// lookupTxn returns the transaction with the given ID. s.mu must be held.
+func (s *server) lookupTxn(database string, id []byte) (*transaction, error) {
+	tx, ok := s.txns[string(id)]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "transaction %q not found", id)
+	}
+	if tx.database != database {
+		return nil, status.Errorf(codes.InvalidArgument, "transaction %q belongs to database %s, not %s", id, tx.database, database)
+	}
+	return tx, nil
+}
+
+// readContext resolves the consistency selector of a read against database:
+// an existing transaction, a new transaction, a read time, or none. It
+// returns the time to read at and the transaction, if any. s.mu must be held.
+func (s *server) readContext(database string, tid []byte, newTxn *pb.TransactionOptions, readTime *tspb.Timestamp) (time.Time, *transaction, error) {
+	var tx *transaction
+	var err error
+	switch {
+	case tid != nil:
+		tx, err = s.lookupTxn(database, tid)
+	case newTxn != nil:
+		tx, err = s.begin(database, newTxn)
+	case readTime != nil:
+		t, err := ptypes.Timestamp(readTime)
+		if err != nil {
+			return time.Time{}, nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		s.fence(t)
+		return t, nil, nil
+	default:
+		return s.now(), nil, nil
+	}
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if tx.readOnly {
+		return tx.readTime, tx, nil
+	}
+	t := s.now()
+	if tx.firstRead.IsZero() {
+		tx.firstRead = t
+		tx.docs = make(map[string]bool)
+	}
+	return t, tx, nil
+}
+
+// conflicts reports whether anything read by the read-write transaction tx
+// has been written since its first read. s.mu must be held.
+func (s *server) conflicts(tx *transaction) bool {
+	if tx.firstRead.IsZero() {
+		return false
+	}
+	for name := range tx.docs {
+		if h, ok := s.docs.Get(name).(*docHistory); ok && h.updated().After(tx.firstRead) {
+			return true
+		}
+	}
+	for _, q := range tx.queries {
+		conflict := false
+		s.forEachBelow(q.parent, func(name string, h *docHistory) {
+			if q.inScope(name) && h.updated().After(tx.firstRead) {
+				conflict = true
+			}
+		})
+		if conflict {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) Rollback(ctx context.Context, req *pb.RollbackRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.lookupTxn(req.Database, req.Transaction); err != nil {
+		return nil, err
+	}
+	delete(s.txns, string(req.Transaction))
+	return &emptypb.Empty{}, nil
+}
+
+// Reads.
+
+func (s *server) GetDocument(ctx context.Context, req *pb.GetDocumentRequest) (*pb.Document, error) {
+	if !validDocName(req.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", req.Name)
+	}
+	var tid []byte
+	var rt *tspb.Timestamp
+	switch c := req.ConsistencySelector.(type) {
+	case *pb.GetDocumentRequest_Transaction:
+		tid = c.Transaction
+	case *pb.GetDocumentRequest_ReadTime:
+		rt = c.ReadTime
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, tx, err := s.readContext(databaseOf(req.Name), tid, nil, rt)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil && !tx.readOnly {
+		tx.docs[req.Name] = true
+	}
+	doc := s.get(req.Name, t)
+	if doc == nil {
+		return nil, status.Errorf(codes.NotFound, "document %s not found", req.Name)
+	}
+	return applyMask(doc, req.Mask)
+}
+
+func (s *server) BatchGetDocuments(req *pb.BatchGetDocumentsRequest, stream pb.Firestore_BatchGetDocumentsServer) error {
+	resps, err := s.batchGet(req)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) batchGet(req *pb.BatchGetDocumentsRequest) ([]*pb.BatchGetDocumentsResponse, error) {
+	if !validDatabase(req.Database) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid database %q", req.Database)
+	}
+	for _, name := range req.Documents {
+		if !validDocName(name) || databaseOf(name) != req.Database {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", name)
+		}
+	}
+	var tid []byte
+	var newTxn *pb.TransactionOptions
+	var rt *tspb.Timestamp
+	switch c := req.ConsistencySelector.(type) {
+	case *pb.BatchGetDocumentsRequest_Transaction:
+		tid = c.Transaction
+	case *pb.BatchGetDocumentsRequest_NewTransaction:
+		newTxn = c.NewTransaction
+	case *pb.BatchGetDocumentsRequest_ReadTime:
+		rt = c.ReadTime
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, tx, err := s.readContext(req.Database, tid, newTxn, rt)
+	if err != nil {
+		return nil, err
+	}
+	readTime := mustTimestampProto(t)
+	var resps []*pb.BatchGetDocumentsResponse
+	for _, name := range req.Documents {
+		if tx != nil && !tx.readOnly {
+			tx.docs[name] = true
+		}
+		resp := &pb.BatchGetDocumentsResponse{ReadTime: readTime}
+		if doc := s.get(name, t); doc != nil {
+			doc, err := applyMask(doc, req.Mask)
+			if err != nil {
+				return nil, err
+			}
+			resp.Result = &pb.BatchGetDocumentsResponse_Found{Found: doc}
+		} else {
+			resp.Result = &pb.BatchGetDocumentsResponse_Missing{Missing: name}
+		}
+		resps = append(resps, resp)
+	}
+	if newTxn != nil && len(resps) > 0 {
+		resps[0].Transaction = tx.id
+	}
+	return resps, nil
+}
+
+func (s *server) ListDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	parent := normalizeParent(req.Parent)
+	if !validParent(parent) || req.CollectionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent %q or collection ID %q", req.Parent, req.CollectionId)
+	}
+	if req.OrderBy != "" && req.OrderBy != docNameField {
+		return nil, status.Errorf(codes.Unimplemented, "order by %q is not supported", req.OrderBy)
+	}
+	var tid []byte
+	var rt *tspb.Timestamp
+	switch c := req.ConsistencySelector.(type) {
+	case *pb.ListDocumentsRequest_Transaction:
+		tid = c.Transaction
+	case *pb.ListDocumentsRequest_ReadTime:
+		rt = c.ReadTime
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, tx, err := s.readContext(databaseOf(parent), tid, nil, rt)
+	if err != nil {
+		return nil, err
+	}
+	coll := parent + "/" + req.CollectionId
+	if tx != nil && !tx.readOnly {
+		tx.queries = append(tx.queries, &query{parent: parent, collectionID: req.CollectionId})
+	}
+	// A missing document is one that does not exist but has documents below it.
+	var docs []*pb.Document
+	seen := map[string]bool{}
+	for _, doc := range s.docsBelow(coll, t) {
+		rest := strings.Split(doc.Name[len(coll)+1:], "/")
+		if len(rest) == 1 {
+			docs = append(docs, doc)
+			seen[doc.Name] = true
+		} else if name := coll + "/" + rest[0]; req.ShowMissing && !seen[name] && s.get(name, t) == nil {
+			docs = append(docs, &pb.Document{Name: name})
+			seen[name] = true
+		}
+	}
+	from, to, nextToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(docs))
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListDocumentsResponse{NextPageToken: nextToken}
+	for _, doc := range docs[from:to] {
+		doc, err := applyMask(doc, req.Mask)
+		if err != nil {
+			return nil, err
+		}
+		resp.Documents = append(resp.Documents, doc)
+	}
+	return resp, nil
+}
+
+func (s *server) ListCollectionIds(ctx context.Context, req *pb.ListCollectionIdsRequest) (*pb.ListCollectionIdsResponse, error) {
+	parent := normalizeParent(req.Parent)
+	if !validParent(parent) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent %q", req.Parent)
+	}
+	s.mu.Lock()
+	seen := map[string]bool{}
+	var ids []string
+	for _, doc := range s.docsBelow(parent, s.now()) {
+		id := strings.SplitN(doc.Name[len(parent)+1:], "/", 2)[0]
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(ids)
+	from, to, nextToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListCollectionIdsResponse{CollectionIds: ids[from:to], NextPageToken: nextToken}, nil
+}
+
+func (s *server) RunQuery(req *pb.RunQueryRequest, stream pb.Firestore_RunQueryServer) error {
+	resps, err := s.runQuery(req)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) runQuery(req *pb.RunQueryRequest) ([]*pb.RunQueryResponse, error) {
+	sq, ok := req.QueryType.(*pb.RunQueryRequest_StructuredQuery)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "no structured query")
+	}
+	q, err := newQuery(req.Parent, sq.StructuredQuery)
+	if err != nil {
+		return nil, err
+	}
+	var tid []byte
+	var newTxn *pb.TransactionOptions
+	var rt *tspb.Timestamp
+	switch c := req.ConsistencySelector.(type) {
+	case *pb.RunQueryRequest_Transaction:
+		tid = c.Transaction
+	case *pb.RunQueryRequest_NewTransaction:
+		newTxn = c.NewTransaction
+	case *pb.RunQueryRequest_ReadTime:
+		rt = c.ReadTime
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, tx, err := s.readContext(databaseOf(q.parent), tid, newTxn, rt)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil && !tx.readOnly {
+		tx.queries = append(tx.queries, q)
+	}
+	docs, skipped := q.run(s.docsBelow(q.parent, t))
+	readTime := mustTimestampProto(t)
+	var resps []*pb.RunQueryResponse
+	for _, doc := range docs {
+		resps = append(resps, &pb.RunQueryResponse{Document: doc, ReadTime: readTime})
+	}
+	if len(resps) == 0 {
+		resps = append(resps, &pb.RunQueryResponse{ReadTime: readTime})
+	}
+	resps[0].SkippedResults = int32(skipped)
+	if newTxn != nil {
+		resps[0].Transaction = tx.id
+	}
+	return resps, nil
+}
+
+// Writes.
+
+func (s *server) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	if !validDatabase(req.Database) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid database %q", req.Database)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(req.Transaction) > 0 {
+		tx, err := s.lookupTxn(req.Database, req.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		delete(s.txns, string(req.Transaction))
+		if tx.readOnly && len(req.Writes) > 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot write in read-only transaction %q", tx.id)
+		}
+		if s.conflicts(tx) {
+			return nil, status.Errorf(codes.Aborted, "transaction %q aborted: data it read has since been written", tx.id)
+		}
+	}
+	return s.commit(req.Database, req.Writes)
+}
+
+func (s *server) CreateDocument(ctx context.Context, req *pb.CreateDocumentRequest) (*pb.Document, error) {
+	id := req.DocumentId
+	if id == "" {
+		id = newDocumentID()
+	}
+	name := req.Parent + "/" + req.CollectionId + "/" + id
+	if !validDocName(name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", name)
+	}
+	doc := &pb.Document{Name: name}
+	if req.Document != nil {
+		doc.Fields = req.Document.Fields
+	}
+	return s.writeDocument(name, &pb.Write{
+		Operation:       &pb.Write_Update{Update: doc},
+		CurrentDocument: &pb.Precondition{ConditionType: &pb.Precondition_Exists{Exists: false}},
+	}, req.Mask)
+}
+
+func (s *server) UpdateDocument(ctx context.Context, req *pb.UpdateDocumentRequest) (*pb.Document, error) {
+	if req.Document == nil || !validDocName(req.Document.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid document")
+	}
+	return s.writeDocument(req.Document.Name, &pb.Write{
+		Operation:       &pb.Write_Update{Update: req.Document},
+		UpdateMask:      req.UpdateMask,
+		CurrentDocument: req.CurrentDocument,
+	}, req.Mask)
+}
+
+func (s *server) DeleteDocument(ctx context.Context, req *pb.DeleteDocumentRequest) (*emptypb.Empty, error) {
+	if !validDocName(req.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", req.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.commit(databaseOf(req.Name), []*pb.Write{{
+		Operation:       &pb.Write_Delete{Delete: req.Name},
+		CurrentDocument: req.CurrentDocument,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// writeDocument commits a single write to the named document and returns
+// the document written.
+func (s *server) writeDocument(name string, w *pb.Write, mask *pb.DocumentMask) (*pb.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp, err := s.commit(databaseOf(name), []*pb.Write{w})
+	if err != nil {
+		return nil, err
+	}
+	t, _ := ptypes.Timestamp(resp.CommitTime)
+	return applyMask(s.get(name, t), mask)
+}
+
+// newDocumentID returns a random document ID.
+func newDocumentID() string {
+	const alphanum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 20)
+	for i := range b {
+		b[i] = alphanum[rand.Intn(len(alphanum))]
+	}
+	return string(b)
+}
+
+// commit applies writes to database atomically, and notifies listeners.
+// s.mu must be held.
+func (s *server) commit(database string, writes []*pb.Write) (*pb.CommitResponse, error) {
+	b := &batch{
+		s:        s,
+		database: database,
+		ts:       s.now(),
+		docs:     make(map[string]*pb.Document),
+	}
+	resp := &pb.CommitResponse{CommitTime: mustTimestampProto(b.ts)}
+	for _, w := range writes {
+		wr, err := b.apply(w)
+		if err != nil {
+			return nil, err
+		}
+		resp.WriteResults = append(resp.WriteResults, wr)
+	}
+	if len(b.names) == 0 {
+		return resp, nil
+	}
+	for _, name := range b.names {
+		v := docVersion{t: b.ts, doc: b.docs[name]}
+		if h, ok := s.docs.Get(name).(*docHistory); ok {
+			h.versions = append(h.versions, v)
+		} else {
+			s.docs.Set(name, &docHistory{versions: []docVersion{v}})
+		}
+	}
+	s.notifyListeners(b.ts)
+	return resp, nil
+}
+
+// A batch accumulates the effects of the writes of a commit.
+type batch struct {
+	s        *server
+	database string
+	ts       time.Time
+	docs     map[string]*pb.Document // new state of the documents written; nil if deleted
+	names    []string                // documents written, in order of first write
+}
+
+// current returns the named document as written so far in the batch.
+func (b *batch) current(name string) *pb.Document {
+	if doc, ok := b.docs[name]; ok {
+		return doc
+	}
+	return b.s.get(name, b.ts)
+}
+
+func (b *batch) put(name string, fields map[string]*pb.Value, exists bool) {
+	if _, ok := b.docs[name]; !ok {
+		b.names = append(b.names, name)
+	}
+	if !exists {
+		b.docs[name] = nil
+		return
+	}
+	doc := &pb.Document{
+		Name:       name,
+		Fields:     fields,
+		CreateTime: mustTimestampProto(b.ts),
+		UpdateTime: mustTimestampProto(b.ts),
+	}
+	if cur := b.current(name); cur != nil {
+		doc.CreateTime = cur.CreateTime
+	}
+	b.docs[name] = doc
+}
+
+func (b *batch) apply(w *pb.Write) (*pb.WriteResult, error) {
+	var name string
+	switch op := w.Operation.(type) {
+	case *pb.Write_Update:
+		name = op.Update.Name
+	case *pb.Write_Delete:
+		name = op.Delete
+	case *pb.Write_Transform:
+		name = op.Transform.Document
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "write has no operation")
+	}
+	if !validDocName(name) || databaseOf(name) != b.database {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", name)
+	}
+	cur := b.current(name)
+	if err := checkPrecondition(name, w.CurrentDocument, cur); err != nil {
+		return nil, err
+	}
+	var fields map[string]*pb.Value
+	if cur != nil {
+		fields = cloneFields(cur.Fields)
+	}
+	res := &pb.WriteResult{UpdateTime: mustTimestampProto(b.ts)}
+	switch op := w.Operation.(type) {
+	case *pb.Write_Update:
+		if w.UpdateMask == nil {
+			fields = cloneFields(op.Update.Fields)
+		} else {
+			if fields == nil {
+				fields = make(map[string]*pb.Value)
+			}
+			for _, p := range w.UpdateMask.FieldPaths {
+				fp, err := parseFieldPath(p)
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+				}
+				if v, ok := getField(op.Update.Fields, fp); ok {
+					setField(fields, fp, proto.Clone(v).(*pb.Value))
+				} else {
+					deleteField(fields, fp)
+				}
+			}
+		}
+		b.put(name, fields, true)
+	case *pb.Write_Delete:
+		b.put(name, nil, false)
+	case *pb.Write_Transform:
+		if w.UpdateMask != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "update mask on a transform")
+		}
+		if fields == nil {
+			fields = make(map[string]*pb.Value)
+		}
+		for _, ft := range op.Transform.FieldTransforms {
+			v, err := applyTransform(fields, ft, b.ts)
+			if err != nil {
+				return nil, err
+			}
+			res.TransformResults = append(res.TransformResults, v)
+		}
+		b.put(name, fields, true)
+	}
+	return res, nil
+}
+
+func checkPrecondition(name string, pc *pb.Precondition, cur *pb.Document) error {
+	switch c := pc.GetConditionType().(type) {
+	case *pb.Precondition_Exists:
+		if c.Exists && cur == nil {
+			return status.Errorf(codes.NotFound, "document %s does not exist", name)
+		}
+		if !c.Exists && cur != nil {
+			return status.Errorf(codes.AlreadyExists, "document %s already exists", name)
+		}
+	case *pb.Precondition_UpdateTime:
+		if cur == nil || !proto.Equal(cur.UpdateTime, c.UpdateTime) {
+			return status.Errorf(codes.FailedPrecondition, "document %s was not last updated at %v", name, c.UpdateTime)
+		}
+	}
+	return nil
+}
+
+// applyTransform applies ft to fields, and returns the transform result.
+func applyTransform(fields map[string]*pb.Value, ft *pb.DocumentTransform_FieldTransform, ts time.Time) (*pb.Value, error) {
+	fp, err := parseFieldPath(ft.FieldPath)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	old, _ := getField(fields, fp)
+	switch t := ft.TransformType.(type) {
+	case *pb.DocumentTransform_FieldTransform_SetToServerValue:
+		if t.SetToServerValue != pb.DocumentTransform_FieldTransform_REQUEST_TIME {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported server value %v", t.SetToServerValue)
+		}
+		v := &pb.Value{ValueType: &pb.Value_TimestampValue{TimestampValue: mustTimestampProto(ts)}}
+		setField(fields, fp, v)
+		return v, nil
+	case *pb.DocumentTransform_FieldTransform_AppendMissingElements:
+		vs := append([]*pb.Value(nil), old.GetArrayValue().GetValues()...)
+		for _, e := range t.AppendMissingElements.GetValues() {
+			if !containsValue(vs, e) {
+				vs = append(vs, e)
+			}
+		}
+		setField(fields, fp, arrayValue(vs))
+		return nullValue(), nil
+	case *pb.DocumentTransform_FieldTransform_RemoveAllFromArray:
+		var vs []*pb.Value
+		for _, e := range old.GetArrayValue().GetValues() {
+			if !containsValue(t.RemoveAllFromArray.GetValues(), e) {
+				vs = append(vs, e)
+			}
+		}
+		setField(fields, fp, arrayValue(vs))
+		return nullValue(), nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unsupported transform for field %q", ft.FieldPath)
+}
+
+func containsValue(vs []*pb.Value, v *pb.Value) bool {
+	for _, e := range vs {
+		if valuesEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFields returns a deep copy of fields, so that the stored versions of
+// documents are never modified.
+func cloneFields(fields map[string]*pb.Value) map[string]*pb.Value {
+	c := make(map[string]*pb.Value, len(fields))
+	for k, v := range fields {
+		c[k] = proto.Clone(v).(*pb.Value)
+	}
+	return c
+}
@@ -0,0 +1,407 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestoretest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestClient(t *testing.T) (*firestore.Client, func()) {
+	srv, err := NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial: %v", err)
+	}
+	client, err := firestore.NewClient(context.Background(), "P", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, func() {
+		client.Close()
+		srv.Close()
+	}
+}
+
+func ids(docs []*firestore.DocumentSnapshot) []string {
+	var ids []string
+	for _, d := range docs {
+		ids = append(ids, d.Ref.ID)
+	}
+	return ids
+}
+
+func TestWrites(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	doc := client.Doc("C/a")
+	wr, err := doc.Create(ctx, map[string]interface{}{"x": 1, "m": map[string]interface{}{"y": 2}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := doc.Create(ctx, map[string]interface{}{"x": 2}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("second Create: got %v, want AlreadyExists", err)
+	}
+	_, err = doc.Update(ctx, []firestore.Update{
+		{Path: "m.z", Value: 3},
+		{Path: "ts", Value: firestore.ServerTimestamp},
+		{Path: "arr", Value: firestore.ArrayUnion(1, 2, 2)},
+	}, firestore.LastUpdateTime(wr.UpdateTime))
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	snap, err := doc.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data := snap.Data()
+	if got, want := data["m"], map[string]interface{}{"y": int64(2), "z": int64(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("m = %v, want %v", got, want)
+	}
+	if got, want := data["arr"], []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("arr = %v, want %v", got, want)
+	}
+	if ts, ok := data["ts"].(time.Time); !ok || !ts.Equal(snap.UpdateTime) {
+		t.Errorf("ts = %v, want the update time %v", data["ts"], snap.UpdateTime)
+	}
+	if !snap.CreateTime.Equal(wr.UpdateTime) {
+		t.Errorf("CreateTime = %v, want %v", snap.CreateTime, wr.UpdateTime)
+	}
+
+	// The update time of the first write is stale now.
+	if _, err := doc.Delete(ctx, firestore.LastUpdateTime(wr.UpdateTime)); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Delete with stale update time: got %v, want FailedPrecondition", err)
+	}
+	if _, err := doc.Set(ctx, map[string]interface{}{"x": 5}, firestore.Merge([]string{"x"})); err != nil {
+		t.Fatalf("Set with merge: %v", err)
+	}
+	snap, err = doc.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got, want := snap.Data()["x"], int64(5); got != want {
+		t.Errorf("x = %v, want %v", got, want)
+	}
+	if _, ok := snap.Data()["m"]; !ok {
+		t.Error("merge removed field m")
+	}
+	if _, err := doc.Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := doc.Get(ctx); status.Code(err) != codes.NotFound {
+		t.Errorf("Get after Delete: got %v, want NotFound", err)
+	}
+	if _, err := doc.Update(ctx, []firestore.Update{{Path: "x", Value: 1}}); status.Code(err) != codes.NotFound {
+		t.Errorf("Update of missing document: got %v, want NotFound", err)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	coll := client.Collection("C")
+	for id, data := range map[string]map[string]interface{}{
+		"a": {"n": 3, "s": "x", "tags": []interface{}{"red"}},
+		"b": {"n": 1, "s": "y", "tags": []interface{}{"red", "blue"}},
+		"c": {"n": 2, "s": "x"},
+		"d": {"n": 2.5, "s": "z"},
+		"e": {"s": "x"},
+		"f": {"n": "str"},
+	} {
+		if _, err := coll.Doc(id).Set(ctx, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A document in another collection with the same ID.
+	if _, err := client.Doc("C/a/Sub/c").Set(ctx, map[string]interface{}{"n": 0, "s": "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		desc string
+		q    firestore.Query
+		want []string
+	}{
+		{"all", coll.Query, []string{"a", "b", "c", "d", "e", "f"}},
+		{"equality", coll.Where("s", "==", "x"), []string{"a", "c", "e"}},
+		{"inequality implies order", coll.Where("n", ">", 1), []string{"c", "d", "a"}},
+		{"inequality only matches numbers", coll.Where("n", ">=", 0), []string{"b", "c", "d", "a"}},
+		{"array-contains", coll.Where("tags", "array-contains", "red"), []string{"a", "b"}},
+		{"order desc", coll.OrderBy("n", firestore.Desc), []string{"f", "a", "d", "c", "b"}},
+		{"order and limit", coll.Where("s", "==", "x").OrderBy("n", firestore.Asc).Limit(1), []string{"c"}},
+		{"offset", coll.OrderBy("n", firestore.Asc).Offset(2).Limit(2), []string{"d", "a"}},
+		{"start at", coll.OrderBy("n", firestore.Asc).StartAt(2), []string{"c", "d", "a", "f"}},
+		{"start after", coll.OrderBy("n", firestore.Asc).StartAfter(2), []string{"d", "a", "f"}},
+		{"end before", coll.OrderBy("n", firestore.Asc).EndBefore(2.5), []string{"b", "c"}},
+		{"end at", coll.OrderBy("n", firestore.Asc).EndAt(2.5), []string{"b", "c", "d"}},
+		{"document ID", coll.Where(firestore.DocumentID, ">", coll.Doc("d")), []string{"e", "f"}},
+		{"subcollection", client.Collection("C").Doc("a").Collection("Sub").Query, []string{"c"}},
+	} {
+		docs, err := test.q.Documents(ctx).GetAll()
+		if err != nil {
+			t.Errorf("%s: %v", test.desc, err)
+			continue
+		}
+		if got := ids(docs); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+
+	// Cursors from a document snapshot order by name after the query's orders.
+	c, err := coll.Doc("c").Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := coll.OrderBy("s", firestore.Asc).StartAfter(c).Documents(ctx).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(docs), []string{"e", "b", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("start after snapshot: got %v, want %v", got, want)
+	}
+
+	// Projection.
+	docs, err = coll.Select("s").Where("n", "==", 1).Documents(ctx).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || !reflect.DeepEqual(docs[0].Data(), map[string]interface{}{"s": "y"}) {
+		t.Errorf("select: got %v", docs)
+	}
+
+	// Inequalities on two fields are rejected.
+	_, err = coll.Where("n", ">", 1).Where("s", "<", "z").Documents(ctx).GetAll()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("two inequality fields: got %v, want InvalidArgument", err)
+	}
+
+	colls, err := client.Doc("C/a").Collections(ctx).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colls) != 1 || colls[0].ID != "Sub" {
+		t.Errorf("Collections: got %v, want [Sub]", colls)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	doc := client.Doc("C/counter")
+	if _, err := doc.Set(ctx, map[string]interface{}{"n": 0}); err != nil {
+		t.Fatal(err)
+	}
+	// Write to the document during the first attempt, so that it is aborted and retried.
+	attempts := 0
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		attempts++
+		snap, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		n := snap.Data()["n"].(int64)
+		if attempts == 1 {
+			if _, err := doc.Set(context.Background(), map[string]interface{}{"n": 10}); err != nil {
+				return err
+			}
+		}
+		return tx.Set(doc, map[string]interface{}{"n": n + 1})
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+	snap, err := doc.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snap.Data()["n"], int64(11); got != want {
+		t.Errorf("n = %v, want %v", got, want)
+	}
+
+	// A query in a transaction conflicts with a later insert into the collection.
+	attempts = 0
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		attempts++
+		docs, err := tx.Documents(client.Collection("D")).GetAll()
+		if err != nil {
+			return err
+		}
+		if attempts == 1 {
+			if _, err := client.Doc("D/x").Set(context.Background(), map[string]interface{}{}); err != nil {
+				return err
+			}
+		}
+		return tx.Set(client.Doc("D/count"), map[string]interface{}{"n": len(docs)})
+	}, firestore.MaxAttempts(3))
+	if err != nil {
+		t.Fatalf("RunTransaction: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+
+	// A read-only transaction reads a consistent snapshot.
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := doc.Set(context.Background(), map[string]interface{}{"n": 100}); err != nil {
+			return err
+		}
+		snap, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		if got, want := snap.Data()["n"], int64(11); got != want {
+			t.Errorf("read-only transaction: n = %v, want %v", got, want)
+		}
+		return nil
+	}, firestore.ReadOnly)
+	if err != nil {
+		t.Fatalf("read-only RunTransaction: %v", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	coll := client.Collection("C")
+	if _, err := coll.Doc("a").Set(ctx, map[string]interface{}{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	it := coll.Where("n", ">", 0).OrderBy("n", firestore.Asc).Snapshots(ctx)
+	defer it.Stop()
+
+	type change struct {
+		kind     firestore.DocumentChangeKind
+		id       string
+		old, new int
+	}
+	next := func() ([]string, []change) {
+		qs, err := it.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		docs, err := qs.Documents.GetAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var changes []change
+		for _, c := range qs.Changes {
+			changes = append(changes, change{c.Kind, c.Doc.Ref.ID, c.OldIndex, c.NewIndex})
+		}
+		return ids(docs), changes
+	}
+	check := func(desc string, wantIDs []string, wantChanges []change) {
+		gotIDs, gotChanges := next()
+		if !reflect.DeepEqual(gotIDs, wantIDs) {
+			t.Errorf("%s: got docs %v, want %v", desc, gotIDs, wantIDs)
+		}
+		if !reflect.DeepEqual(gotChanges, wantChanges) {
+			t.Errorf("%s: got changes %v, want %v", desc, gotChanges, wantChanges)
+		}
+	}
+
+	check("initial", []string{"a"}, []change{{firestore.DocumentAdded, "a", -1, 0}})
+
+	if _, err := coll.Doc("b").Set(ctx, map[string]interface{}{"n": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coll.Doc("c").Set(ctx, map[string]interface{}{"n": 3}); err != nil {
+		t.Fatal(err)
+	}
+	check("add", []string{"a", "c"}, []change{{firestore.DocumentAdded, "c", -1, 1}})
+
+	if _, err := coll.Doc("a").Set(ctx, map[string]interface{}{"n": 5}); err != nil {
+		t.Fatal(err)
+	}
+	check("modify", []string{"c", "a"}, []change{{firestore.DocumentModified, "a", 0, 1}})
+
+	batch := client.Batch()
+	batch.Set(coll.Doc("b"), map[string]interface{}{"n": 2})
+	batch.Set(coll.Doc("c"), map[string]interface{}{"n": 0})
+	batch.Delete(coll.Doc("a"))
+	if _, err := batch.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	check("batch", []string{"b"}, []change{
+		{firestore.DocumentRemoved, "c", 0, -1},
+		{firestore.DocumentRemoved, "a", 0, -1},
+		{firestore.DocumentAdded, "b", -1, 0},
+	})
+}
+
+func TestListenDocument(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	doc := client.Doc("C/a")
+	it := doc.Snapshots(ctx)
+	defer it.Stop()
+	snap, err := it.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Exists() {
+		t.Fatal("document exists before it is created")
+	}
+	if _, err := doc.Set(ctx, map[string]interface{}{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	snap, err = it.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snap.Data()["n"], int64(1); got != want {
+		t.Errorf("n = %v, want %v", got, want)
+	}
+	if _, err := doc.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snap, err = it.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Exists() {
+		t.Error("document exists after it is deleted")
+	}
+	cancel()
+	if _, err := it.Next(); err == nil || err == iterator.Done {
+		t.Errorf("after cancel: got %v, want an error", err)
+	}
+}
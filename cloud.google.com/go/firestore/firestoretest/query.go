@@ -0,0 +1,415 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestoretest
+
+// This file contains the execution of structured queries.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// docNameField is the field path that refers to a document's name.
+const docNameField = "__name__"
+
+// A query is a validated structured query, ready to be run against the
+// documents below its parent.
+type query struct {
+	parent         string // a database's document root, or a document
+	collectionID   string
+	allDescendants bool
+
+	where   *pb.StructuredQuery_Filter
+	orders  []queryOrder // always ends with the document name
+	startAt *pb.Cursor
+	endAt   *pb.Cursor
+	offset  int
+	limit   int        // -1 for no limit
+	fields  [][]string // the projection; nil for whole documents
+	project bool
+}
+
+type queryOrder struct {
+	path []string // nil for the document name
+	desc bool
+}
+
+// newQuery validates sq, to be run below parent.
+func newQuery(parent string, sq *pb.StructuredQuery) (*query, error) {
+	parent = normalizeParent(parent)
+	if !validParent(parent) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent %q", parent)
+	}
+	if len(sq.From) != 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "query must select from exactly one collection, got %d", len(sq.From))
+	}
+	q := &query{
+		parent:         parent,
+		collectionID:   sq.From[0].CollectionId,
+		allDescendants: sq.From[0].AllDescendants,
+		where:          sq.Where,
+		startAt:        sq.StartAt,
+		endAt:          sq.EndAt,
+		offset:         int(sq.Offset),
+		limit:          -1,
+	}
+	if q.collectionID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "query has no collection ID")
+	}
+	if sq.Limit != nil {
+		if sq.Limit.Value < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "negative limit %d", sq.Limit.Value)
+		}
+		q.limit = int(sq.Limit.Value)
+	}
+	if q.offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative offset %d", q.offset)
+	}
+
+	var ineq string
+	if sq.Where != nil {
+		var err error
+		if ineq, err = checkFilter(sq.Where, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, o := range sq.OrderBy {
+		if o.Field == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "order has no field")
+		}
+		qo := queryOrder{desc: o.Direction == pb.StructuredQuery_DESCENDING}
+		if o.Field.FieldPath != docNameField {
+			fp, err := parseFieldPath(o.Field.FieldPath)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+			qo.path = fp
+		}
+		q.orders = append(q.orders, qo)
+	}
+	// An inequality filter implies an ordering on its field, which must come first.
+	if ineq != "" {
+		if len(sq.OrderBy) == 0 {
+			fp, _ := parseFieldPath(ineq)
+			if ineq == docNameField {
+				fp = nil
+			}
+			q.orders = append(q.orders, queryOrder{path: fp})
+		} else if sq.OrderBy[0].Field.FieldPath != ineq {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"the first order must be on the inequality field %q, not %q", ineq, sq.OrderBy[0].Field.FieldPath)
+		}
+	}
+	// Documents are finally ordered by name, in the direction of the last order.
+	if n := len(q.orders); n == 0 || q.orders[n-1].path != nil {
+		desc := n > 0 && q.orders[n-1].desc
+		q.orders = append(q.orders, queryOrder{desc: desc})
+	}
+	for _, c := range []*pb.Cursor{sq.StartAt, sq.EndAt} {
+		if c != nil && len(c.Values) > len(q.orders) {
+			return nil, status.Errorf(codes.InvalidArgument, "cursor has more values than the query has orders")
+		}
+	}
+	if sq.Select != nil {
+		q.project = true
+		for _, f := range sq.Select.Fields {
+			if f.FieldPath == docNameField {
+				continue
+			}
+			fp, err := parseFieldPath(f.FieldPath)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+			q.fields = append(q.fields, fp)
+		}
+	}
+	return q, nil
+}
+
+// checkFilter validates f, and returns the field of its inequality filter, if
+// any. ineq is the inequality field seen so far.
+func checkFilter(f *pb.StructuredQuery_Filter, ineq string) (string, error) {
+	switch f := f.FilterType.(type) {
+	case *pb.StructuredQuery_Filter_CompositeFilter:
+		if f.CompositeFilter.Op != pb.StructuredQuery_CompositeFilter_AND {
+			return "", status.Errorf(codes.InvalidArgument, "unsupported composite operator %v", f.CompositeFilter.Op)
+		}
+		for _, sub := range f.CompositeFilter.Filters {
+			var err error
+			if ineq, err = checkFilter(sub, ineq); err != nil {
+				return "", err
+			}
+		}
+		return ineq, nil
+	case *pb.StructuredQuery_Filter_FieldFilter:
+		ff := f.FieldFilter
+		if ff.Field == nil || ff.Value == nil {
+			return "", status.Errorf(codes.InvalidArgument, "field filter needs a field and a value")
+		}
+		if ff.Field.FieldPath != docNameField {
+			if _, err := parseFieldPath(ff.Field.FieldPath); err != nil {
+				return "", status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+		}
+		switch ff.Op {
+		case pb.StructuredQuery_FieldFilter_EQUAL, pb.StructuredQuery_FieldFilter_ARRAY_CONTAINS:
+			return ineq, nil
+		case pb.StructuredQuery_FieldFilter_LESS_THAN, pb.StructuredQuery_FieldFilter_LESS_THAN_OR_EQUAL,
+			pb.StructuredQuery_FieldFilter_GREATER_THAN, pb.StructuredQuery_FieldFilter_GREATER_THAN_OR_EQUAL:
+			if ineq != "" && ineq != ff.Field.FieldPath {
+				return "", status.Errorf(codes.InvalidArgument,
+					"inequality filters on more than one field: %q and %q", ineq, ff.Field.FieldPath)
+			}
+			return ff.Field.FieldPath, nil
+		}
+		return "", status.Errorf(codes.InvalidArgument, "unsupported field filter operator %v", ff.Op)
+	case *pb.StructuredQuery_Filter_UnaryFilter:
+		uf := f.UnaryFilter
+		if uf.GetField() == nil {
+			return "", status.Errorf(codes.InvalidArgument, "unary filter needs a field")
+		}
+		if _, err := parseFieldPath(uf.GetField().FieldPath); err != nil {
+			return "", status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		switch uf.Op {
+		case pb.StructuredQuery_UnaryFilter_IS_NAN, pb.StructuredQuery_UnaryFilter_IS_NULL:
+			return ineq, nil
+		}
+		return "", status.Errorf(codes.InvalidArgument, "unsupported unary filter operator %v", uf.Op)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "empty filter")
+}
+
+// inScope reports whether the document named name is in one of the
+// collections the query selects from.
+func (q *query) inScope(name string) bool {
+	if !strings.HasPrefix(name, q.parent+"/") {
+		return false
+	}
+	rest := strings.Split(name[len(q.parent)+1:], "/")
+	if len(rest)%2 != 0 {
+		return false
+	}
+	if !q.allDescendants && len(rest) != 2 {
+		return false
+	}
+	return rest[len(rest)-2] == q.collectionID
+}
+
+// run returns the results of the query over docs, which must be sorted by
+// name. It returns the number of results skipped because of the offset.
+func (q *query) run(docs []*pb.Document) ([]*pb.Document, int) {
+	var res []*pb.Document
+	for _, doc := range docs {
+		if q.inScope(doc.Name) && q.hasOrderFields(doc) && (q.where == nil || matches(q.where, doc)) {
+			res = append(res, doc)
+		}
+	}
+	sort.Stable(docsByOrder{res, q})
+	i := 0
+	for i < len(res) && q.startAt != nil && !q.afterStart(res[i]) {
+		i++
+	}
+	j := i
+	for j < len(res) && (q.endAt == nil || q.beforeEnd(res[j])) {
+		j++
+	}
+	res = res[i:j]
+	skipped := q.offset
+	if skipped > len(res) {
+		skipped = len(res)
+	}
+	res = res[skipped:]
+	if q.limit >= 0 && len(res) > q.limit {
+		res = res[:q.limit]
+	}
+	if q.project {
+		for i, doc := range res {
+			res[i] = projectDocument(doc, q.fields)
+		}
+	}
+	return res, skipped
+}
+
+// hasOrderFields reports whether doc has a value for every field the query
+// is ordered by. Documents without one are never returned.
+func (q *query) hasOrderFields(doc *pb.Document) bool {
+	for _, o := range q.orders {
+		if o.path != nil {
+			if _, ok := getField(doc.Fields, o.path); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (q *query) compareDocs(a, b *pb.Document) int {
+	for _, o := range q.orders {
+		var c int
+		if o.path == nil {
+			c = compareNames(a.Name, b.Name)
+		} else {
+			av, _ := getField(a.Fields, o.path)
+			bv, _ := getField(b.Fields, o.path)
+			c = compareValues(av, bv)
+		}
+		if o.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareCursor compares doc to the position described by the values of c,
+// in the order of the query.
+func (q *query) compareCursor(doc *pb.Document, c *pb.Cursor) int {
+	for i, cv := range c.Values {
+		o := q.orders[i]
+		var v *pb.Value
+		if o.path == nil {
+			v = &pb.Value{ValueType: &pb.Value_ReferenceValue{ReferenceValue: doc.Name}}
+		} else {
+			v, _ = getField(doc.Fields, o.path)
+		}
+		c := compareValues(v, cv)
+		if o.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (q *query) afterStart(doc *pb.Document) bool {
+	c := q.compareCursor(doc, q.startAt)
+	if q.startAt.Before {
+		return c >= 0
+	}
+	return c > 0
+}
+
+func (q *query) beforeEnd(doc *pb.Document) bool {
+	c := q.compareCursor(doc, q.endAt)
+	if q.endAt.Before {
+		return c < 0
+	}
+	return c <= 0
+}
+
+type docsByOrder struct {
+	docs []*pb.Document
+	q    *query
+}
+
+func (b docsByOrder) Len() int           { return len(b.docs) }
+func (b docsByOrder) Swap(i, j int)      { b.docs[i], b.docs[j] = b.docs[j], b.docs[i] }
+func (b docsByOrder) Less(i, j int) bool { return b.q.compareDocs(b.docs[i], b.docs[j]) < 0 }
+
+// matches reports whether doc satisfies the filter f, which has been checked.
+func matches(f *pb.StructuredQuery_Filter, doc *pb.Document) bool {
+	switch f := f.FilterType.(type) {
+	case *pb.StructuredQuery_Filter_CompositeFilter:
+		for _, sub := range f.CompositeFilter.Filters {
+			if !matches(sub, doc) {
+				return false
+			}
+		}
+		return true
+	case *pb.StructuredQuery_Filter_FieldFilter:
+		ff := f.FieldFilter
+		var v *pb.Value
+		if ff.Field.FieldPath == docNameField {
+			v = &pb.Value{ValueType: &pb.Value_ReferenceValue{ReferenceValue: doc.Name}}
+		} else {
+			fp, _ := parseFieldPath(ff.Field.FieldPath)
+			var ok bool
+			if v, ok = getField(doc.Fields, fp); !ok {
+				return false
+			}
+		}
+		if ff.Op == pb.StructuredQuery_FieldFilter_ARRAY_CONTAINS {
+			arr, ok := v.ValueType.(*pb.Value_ArrayValue)
+			if !ok {
+				return false
+			}
+			for _, e := range arr.ArrayValue.Values {
+				if valuesEqual(e, ff.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		// Comparisons only match values of the same type, and never NaN.
+		if typeOrder(v) != typeOrder(ff.Value) || isNaN(v) || isNaN(ff.Value) {
+			return false
+		}
+		c := compareValues(v, ff.Value)
+		switch ff.Op {
+		case pb.StructuredQuery_FieldFilter_LESS_THAN:
+			return c < 0
+		case pb.StructuredQuery_FieldFilter_LESS_THAN_OR_EQUAL:
+			return c <= 0
+		case pb.StructuredQuery_FieldFilter_GREATER_THAN:
+			return c > 0
+		case pb.StructuredQuery_FieldFilter_GREATER_THAN_OR_EQUAL:
+			return c >= 0
+		case pb.StructuredQuery_FieldFilter_EQUAL:
+			return c == 0
+		}
+	case *pb.StructuredQuery_Filter_UnaryFilter:
+		fp, _ := parseFieldPath(f.UnaryFilter.GetField().FieldPath)
+		v, ok := getField(doc.Fields, fp)
+		if !ok {
+			return false
+		}
+		switch f.UnaryFilter.Op {
+		case pb.StructuredQuery_UnaryFilter_IS_NAN:
+			return isNaN(v)
+		case pb.StructuredQuery_UnaryFilter_IS_NULL:
+			_, ok := v.ValueType.(*pb.Value_NullValue)
+			return ok
+		}
+	}
+	panic(fmt.Sprintf("firestoretest: unchecked filter %v", f))
+}
+
+// projectDocument returns a copy of doc with only the given fields.
+func projectDocument(doc *pb.Document, fields [][]string) *pb.Document {
+	d := &pb.Document{
+		Name:       doc.Name,
+		CreateTime: doc.CreateTime,
+		UpdateTime: doc.UpdateTime,
+	}
+	for _, fp := range fields {
+		if v, ok := getField(doc.Fields, fp); ok {
+			if d.Fields == nil {
+				d.Fields = map[string]*pb.Value{}
+			}
+			setField(d.Fields, fp, v)
+		}
+	}
+	return d
+}
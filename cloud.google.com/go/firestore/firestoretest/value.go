@@ -0,0 +1,306 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestoretest
+
+// This file contains the ordering of Firestore values, and helpers for
+// reading and writing fields of documents by field path.
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+)
+
+// compareValues returns a negative number, zero, or a positive number
+// depending on whether a is less than, equal to, or greater than b according
+// to Firestore's ordering of values.
+func compareValues(a, b *pb.Value) int {
+	ta := typeOrder(a)
+	tb := typeOrder(b)
+	if ta != tb {
+		return compareInt64s(int64(ta), int64(tb))
+	}
+	switch a := a.ValueType.(type) {
+	case *pb.Value_NullValue:
+		return 0
+	case *pb.Value_BooleanValue:
+		av := a.BooleanValue
+		bv := b.GetBooleanValue()
+		switch {
+		case av && !bv:
+			return 1
+		case bv && !av:
+			return -1
+		}
+		return 0
+	case *pb.Value_IntegerValue:
+		if bi, ok := b.ValueType.(*pb.Value_IntegerValue); ok {
+			return compareInt64s(a.IntegerValue, bi.IntegerValue)
+		}
+		return compareNumbers(float64(a.IntegerValue), toFloat(b))
+	case *pb.Value_DoubleValue:
+		return compareNumbers(a.DoubleValue, toFloat(b))
+	case *pb.Value_TimestampValue:
+		return compareTimestamps(a.TimestampValue, b.GetTimestampValue())
+	case *pb.Value_StringValue:
+		return strings.Compare(a.StringValue, b.GetStringValue())
+	case *pb.Value_BytesValue:
+		return bytes.Compare(a.BytesValue, b.GetBytesValue())
+	case *pb.Value_ReferenceValue:
+		return compareNames(a.ReferenceValue, b.GetReferenceValue())
+	case *pb.Value_GeoPointValue:
+		ag := a.GeoPointValue
+		bg := b.GetGeoPointValue()
+		if ag.Latitude != bg.Latitude {
+			return compareNumbers(ag.Latitude, bg.Latitude)
+		}
+		return compareNumbers(ag.Longitude, bg.Longitude)
+	case *pb.Value_ArrayValue:
+		av := a.ArrayValue.Values
+		bv := b.GetArrayValue().Values
+		return compareSequences(len(av), len(bv), func(i int) int {
+			return compareValues(av[i], bv[i])
+		})
+	case *pb.Value_MapValue:
+		return compareMaps(a.MapValue.Fields, b.GetMapValue().Fields)
+	}
+	panic(fmt.Sprintf("firestoretest: bad value type: %v", a))
+}
+
+// typeOrder returns an integer corresponding to the type of value stored in
+// v, such that comparing the resulting integers gives the Firestore ordering
+// for types. Integers and doubles have the same type order.
+func typeOrder(v *pb.Value) int {
+	switch v.ValueType.(type) {
+	case *pb.Value_NullValue:
+		return 0
+	case *pb.Value_BooleanValue:
+		return 1
+	case *pb.Value_IntegerValue, *pb.Value_DoubleValue:
+		return 2
+	case *pb.Value_TimestampValue:
+		return 3
+	case *pb.Value_StringValue:
+		return 4
+	case *pb.Value_BytesValue:
+		return 5
+	case *pb.Value_ReferenceValue:
+		return 6
+	case *pb.Value_GeoPointValue:
+		return 7
+	case *pb.Value_ArrayValue:
+		return 8
+	case *pb.Value_MapValue:
+		return 9
+	}
+	panic(fmt.Sprintf("firestoretest: bad value type: %v", v))
+}
+
+// compareNumbers treats NaN as less than any other number.
+func compareNumbers(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v *pb.Value) float64 {
+	if x, ok := v.ValueType.(*pb.Value_IntegerValue); ok {
+		return float64(x.IntegerValue)
+	}
+	return v.GetDoubleValue()
+}
+
+func isNaN(v *pb.Value) bool {
+	x, ok := v.ValueType.(*pb.Value_DoubleValue)
+	return ok && math.IsNaN(x.DoubleValue)
+}
+
+func compareTimestamps(a, b *tspb.Timestamp) int {
+	if c := compareInt64s(a.Seconds, b.Seconds); c != 0 {
+		return c
+	}
+	return compareInt64s(int64(a.Nanos), int64(b.Nanos))
+}
+
+// compareNames orders resource names component by component, so that all
+// the documents below a path sort together.
+func compareNames(a, b string) int {
+	pa := strings.Split(a, "/")
+	pb := strings.Split(b, "/")
+	return compareSequences(len(pa), len(pb), func(i int) int {
+		return strings.Compare(pa[i], pb[i])
+	})
+}
+
+func compareMaps(a, b map[string]*pb.Value) int {
+	aks := sortedKeys(a)
+	bks := sortedKeys(b)
+	return compareSequences(len(aks), len(bks), func(i int) int {
+		if c := strings.Compare(aks[i], bks[i]); c != 0 {
+			return c
+		}
+		return compareValues(a[aks[i]], b[bks[i]])
+	})
+}
+
+func sortedKeys(m map[string]*pb.Value) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func compareSequences(len1, len2 int, compare func(int) int) int {
+	for i := 0; i < len1 && i < len2; i++ {
+		if c := compare(i); c != 0 {
+			return c
+		}
+	}
+	return compareInt64s(int64(len1), int64(len2))
+}
+
+func compareInt64s(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// valuesEqual reports whether a and b are equal for the purposes of
+// equality filters and array transforms.
+func valuesEqual(a, b *pb.Value) bool {
+	return typeOrder(a) == typeOrder(b) && compareValues(a, b) == 0
+}
+
+// parseFieldPath splits a field path in the service's syntax into its
+// components. Components are separated by dots; a component that is not a
+// simple identifier is enclosed in backquotes, with backquotes and
+// backslashes escaped by a backslash.
+func parseFieldPath(s string) ([]string, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty field path")
+	}
+	var fp []string
+	for {
+		var part string
+		if strings.HasPrefix(s, "`") {
+			var buf bytes.Buffer
+			i := 1
+			for ; i < len(s) && s[i] != '`'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				buf.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated backquote in field path %q", s)
+			}
+			part, s = buf.String(), s[i+1:]
+		} else {
+			i := strings.IndexByte(s, '.')
+			if i < 0 {
+				i = len(s)
+			}
+			part, s = s[:i], s[i:]
+		}
+		if part == "" {
+			return nil, fmt.Errorf("empty component in field path")
+		}
+		fp = append(fp, part)
+		if s == "" {
+			return fp, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("bad field path")
+		}
+		s = s[1:]
+	}
+}
+
+// getField returns the value at fp in fields.
+func getField(fields map[string]*pb.Value, fp []string) (*pb.Value, bool) {
+	for i, p := range fp {
+		v, ok := fields[p]
+		if !ok {
+			return nil, false
+		}
+		if i == len(fp)-1 {
+			return v, true
+		}
+		m, ok := v.ValueType.(*pb.Value_MapValue)
+		if !ok {
+			return nil, false
+		}
+		fields = m.MapValue.Fields
+	}
+	return nil, false
+}
+
+// setField stores v at fp in fields, creating or replacing intermediate maps
+// as needed.
+func setField(fields map[string]*pb.Value, fp []string, v *pb.Value) {
+	for _, p := range fp[:len(fp)-1] {
+		m, ok := fields[p].GetValueType().(*pb.Value_MapValue)
+		if !ok {
+			m = &pb.Value_MapValue{MapValue: &pb.MapValue{}}
+			fields[p] = &pb.Value{ValueType: m}
+		}
+		if m.MapValue.Fields == nil {
+			m.MapValue.Fields = map[string]*pb.Value{}
+		}
+		fields = m.MapValue.Fields
+	}
+	fields[fp[len(fp)-1]] = v
+}
+
+// deleteField removes the value at fp from fields, if it is present.
+func deleteField(fields map[string]*pb.Value, fp []string) {
+	for _, p := range fp[:len(fp)-1] {
+		m, ok := fields[p].GetValueType().(*pb.Value_MapValue)
+		if !ok {
+			return
+		}
+		fields = m.MapValue.Fields
+	}
+	delete(fields, fp[len(fp)-1])
+}
+
+func nullValue() *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_NullValue{}}
+}
+
+func arrayValue(vs []*pb.Value) *pb.Value {
+	return &pb.Value{ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: vs}}}
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestoretest
+
+// This file contains the implementation of the Listen RPC.
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A listener is the state of one Listen stream.
+// Its fields are protected by server.mu.
+type listener struct {
+	database string
+	targets  map[int32]*target
+	queue    []*pb.ListenResponse // responses not yet sent
+	ready    chan struct{}        // signaled when the queue becomes non-empty
+}
+
+// A target is a query or set of documents being listened to.
+type target struct {
+	id    int32
+	q     *query   // nil for a documents target
+	names []string // for a documents target
+	docs  map[string]*pb.Document
+}
+
+// results returns the documents of t as of ts, keyed by name.
+// s.mu must be held.
+func (s *server) results(t *target, ts time.Time) map[string]*pb.Document {
+	res := map[string]*pb.Document{}
+	if t.q != nil {
+		docs, _ := t.q.run(s.docsBelow(t.q.parent, ts))
+		for _, doc := range docs {
+			res[doc.Name] = doc
+		}
+		return res
+	}
+	for _, name := range t.names {
+		if doc := s.get(name, ts); doc != nil {
+			res[name] = doc
+		}
+	}
+	return res
+}
+
+func (s *server) Listen(stream pb.Firestore_ListenServer) error {
+	l := &listener{
+		targets: make(map[int32]*target),
+		ready:   make(chan struct{}, 1),
+	}
+	s.mu.Lock()
+	s.listeners[l] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			s.mu.Lock()
+			err = s.handleListenRequest(l, req)
+			s.mu.Unlock()
+			if err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case err := <-errc:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-l.ready:
+		}
+		s.mu.Lock()
+		resps := l.queue
+		l.queue = nil
+		s.mu.Unlock()
+		for _, resp := range resps {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleListenRequest adds or removes a target. s.mu must be held.
+func (s *server) handleListenRequest(l *listener, req *pb.ListenRequest) error {
+	if l.database == "" {
+		if !validDatabase(req.Database) {
+			return status.Errorf(codes.InvalidArgument, "invalid database %q", req.Database)
+		}
+		l.database = req.Database
+	} else if req.Database != l.database {
+		return status.Errorf(codes.InvalidArgument, "stream is for database %s, not %s", l.database, req.Database)
+	}
+	switch tc := req.TargetChange.(type) {
+	case *pb.ListenRequest_AddTarget:
+		s.addTarget(l, tc.AddTarget)
+	case *pb.ListenRequest_RemoveTarget:
+		if _, ok := l.targets[tc.RemoveTarget]; !ok {
+			return status.Errorf(codes.InvalidArgument, "no target with ID %d", tc.RemoveTarget)
+		}
+		delete(l.targets, tc.RemoveTarget)
+		l.send(targetChange(pb.TargetChange_REMOVE, tc.RemoveTarget))
+	default:
+		return status.Errorf(codes.InvalidArgument, "no target change")
+	}
+	return nil
+}
+
+// addTarget starts listening to pt, and sends its current results.
+// An invalid target is removed with the error as its cause.
+// s.mu must be held.
+func (s *server) addTarget(l *listener, pt *pb.Target) {
+	t := &target{id: pt.TargetId}
+	var err error
+	switch tt := pt.TargetType.(type) {
+	case *pb.Target_Query:
+		sq, ok := tt.Query.QueryType.(*pb.Target_QueryTarget_StructuredQuery)
+		if !ok {
+			err = status.Errorf(codes.InvalidArgument, "no structured query")
+			break
+		}
+		if databaseOf(normalizeParent(tt.Query.Parent)) != l.database {
+			err = status.Errorf(codes.InvalidArgument, "query parent %q is not in database %s", tt.Query.Parent, l.database)
+			break
+		}
+		t.q, err = newQuery(tt.Query.Parent, sq.StructuredQuery)
+	case *pb.Target_Documents:
+		for _, name := range tt.Documents.Documents {
+			if !validDocName(name) || databaseOf(name) != l.database {
+				err = status.Errorf(codes.InvalidArgument, "invalid document name %q", name)
+			}
+		}
+		t.names = tt.Documents.Documents
+	default:
+		err = status.Errorf(codes.InvalidArgument, "no target type")
+	}
+	if err == nil {
+		if _, ok := l.targets[t.id]; ok {
+			err = status.Errorf(codes.InvalidArgument, "target ID %d already in use", t.id)
+		}
+	}
+	if err != nil {
+		tc := targetChange(pb.TargetChange_REMOVE, t.id)
+		st, _ := status.FromError(err)
+		tc.GetTargetChange().Cause = st.Proto()
+		l.send(tc)
+		return
+	}
+
+	ts := s.now()
+	t.docs = s.results(t, ts)
+	l.targets[t.id] = t
+	l.send(targetChange(pb.TargetChange_ADD, t.id))
+	if pt.ResumeType != nil {
+		// The fake does not compute changes since the resume point; it
+		// discards the client's state and sends everything again.
+		l.send(targetChange(pb.TargetChange_RESET, t.id))
+	}
+	for _, name := range sortedNames(t.docs) {
+		l.send(&pb.ListenResponse{
+			ResponseType: &pb.ListenResponse_DocumentChange{DocumentChange: &pb.DocumentChange{
+				Document:  t.docs[name],
+				TargetIds: []int32{t.id},
+			}},
+		})
+	}
+	l.send(targetChange(pb.TargetChange_CURRENT, t.id))
+	l.send(noChange(ts))
+}
+
+// notifyListeners sends the changes to the results of every target caused
+// by the commit at ts. s.mu must be held.
+func (s *server) notifyListeners(ts time.Time) {
+	for l := range s.listeners {
+		changed := false
+		for _, id := range l.targetIDs() {
+			t := l.targets[int32(id)]
+			docs := s.results(t, ts)
+			for _, resp := range s.diffResults(t, docs, ts) {
+				l.send(resp)
+				changed = true
+			}
+			t.docs = docs
+		}
+		if changed {
+			l.send(noChange(ts))
+		}
+	}
+}
+
+// diffResults returns the responses that move the results of t to docs, in
+// name order. s.mu must be held.
+func (s *server) diffResults(t *target, docs map[string]*pb.Document, ts time.Time) []*pb.ListenResponse {
+	all := map[string]*pb.Document{}
+	for name, doc := range t.docs {
+		all[name] = doc
+	}
+	for name, doc := range docs {
+		all[name] = doc
+	}
+	var resps []*pb.ListenResponse
+	for _, name := range sortedNames(all) {
+		old, wasIn := t.docs[name]
+		doc, isIn := docs[name]
+		switch {
+		case isIn && (!wasIn || !proto.Equal(old.UpdateTime, doc.UpdateTime)):
+			resps = append(resps, &pb.ListenResponse{
+				ResponseType: &pb.ListenResponse_DocumentChange{DocumentChange: &pb.DocumentChange{
+					Document:  doc,
+					TargetIds: []int32{t.id},
+				}},
+			})
+		case wasIn && !isIn && s.get(name, ts) == nil:
+			resps = append(resps, &pb.ListenResponse{
+				ResponseType: &pb.ListenResponse_DocumentDelete{DocumentDelete: &pb.DocumentDelete{
+					Document:         name,
+					RemovedTargetIds: []int32{t.id},
+					ReadTime:         mustTimestampProto(ts),
+				}},
+			})
+		case wasIn && !isIn:
+			resps = append(resps, &pb.ListenResponse{
+				ResponseType: &pb.ListenResponse_DocumentRemove{DocumentRemove: &pb.DocumentRemove{
+					Document:         name,
+					RemovedTargetIds: []int32{t.id},
+					ReadTime:         mustTimestampProto(ts),
+				}},
+			})
+		}
+	}
+	return resps
+}
+
+// targetIDs returns the IDs of l's targets in increasing order.
+func (l *listener) targetIDs() []int {
+	var ids []int
+	for id := range l.targets {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// send queues resp for the stream. s.mu must be held.
+func (l *listener) send(resp *pb.ListenResponse) {
+	l.queue = append(l.queue, resp)
+	select {
+	case l.ready <- struct{}{}:
+	default:
+	}
+}
+
+func targetChange(typ pb.TargetChange_TargetChangeType, id int32) *pb.ListenResponse {
+	return &pb.ListenResponse{
+		ResponseType: &pb.ListenResponse_TargetChange{TargetChange: &pb.TargetChange{
+			TargetChangeType: typ,
+			TargetIds:        []int32{id},
+		}},
+	}
+}
+
+// noChange returns the global target change that marks the results sent so
+// far as consistent as of ts.
+func noChange(ts time.Time) *pb.ListenResponse {
+	return &pb.ListenResponse{
+		ResponseType: &pb.ListenResponse_TargetChange{TargetChange: &pb.TargetChange{
+			TargetChangeType: pb.TargetChange_NO_CHANGE,
+			ReadTime:         mustTimestampProto(ts),
+			ResumeToken:      []byte(strconv.FormatInt(ts.UnixNano(), 10)),
+		}},
+	}
+}
+
+func sortedNames(docs map[string]*pb.Document) []string {
+	var names []string
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Sort(byName(names))
+	return names
+}
+
+type byName []string
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return compareNames(b[i], b[j]) < 0 }
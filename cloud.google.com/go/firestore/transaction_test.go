@@ -149,6 +149,50 @@ func TestRunTransaction(t *testing.T) {
 	}
 }
 
+func TestRunTransactionRetryDiscardsWrites(t *testing.T) {
+	ctx := context.Background()
+	const db = "projects/projectID/databases/(default)"
+	tid := []byte{1}
+	c, srv := newMock(t)
+	deleteWrite := func(id string) *pb.Write {
+		return &pb.Write{Operation: &pb.Write_Delete{db + "/documents/C/" + id}}
+	}
+	srv.addRPC(&pb.BeginTransactionRequest{Database: db}, &pb.BeginTransactionResponse{Transaction: tid})
+	srv.addRPC(
+		&pb.CommitRequest{Database: db, Transaction: tid, Writes: []*pb.Write{deleteWrite("a")}},
+		status.Errorf(codes.Aborted, ""),
+	)
+	srv.addRPC(
+		&pb.BeginTransactionRequest{
+			Database: db,
+			Options: &pb.TransactionOptions{
+				Mode: &pb.TransactionOptions_ReadWrite_{
+					&pb.TransactionOptions_ReadWrite{RetryTransaction: tid},
+				},
+			},
+		},
+		&pb.BeginTransactionResponse{Transaction: tid},
+	)
+	// The retried commit has only the writes of the second attempt.
+	srv.addRPC(
+		&pb.CommitRequest{Database: db, Transaction: tid, Writes: []*pb.Write{deleteWrite("b")}},
+		&pb.CommitResponse{CommitTime: aTimestamp},
+	)
+	ids := []string{"a", "b"}
+	attempt := 0
+	err := c.RunTransaction(ctx, func(_ context.Context, tx *Transaction) error {
+		id := ids[attempt]
+		attempt++
+		return tx.Delete(c.Collection("C").Doc(id))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt != 2 {
+		t.Errorf("got %d attempts, want 2", attempt)
+	}
+}
+
 func TestTransactionErrors(t *testing.T) {
 	ctx := context.Background()
 	const db = "projects/projectID/databases/(default)"
@@ -31,6 +31,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 
 	"cloud.google.com/go/httpreplay/internal/proxy"
 	"github.com/google/martian/martianhttp"
@@ -42,8 +43,32 @@ var (
 	record       = flag.String("record", "", "record traffic and save to filename")
 	replay       = flag.String("replay", "", "read filename and replay traffic")
 	debugHeaders = flag.Bool("debug-headers", false, "log header mismatches")
+
+	ignoreHeaders, ignoreParams, ignoreURLPatterns, ignoreJSONFields stringList
+	redactHeaders, redactParams, redactJSONFields, redactPatterns    stringList
 )
 
+func init() {
+	flag.Var(&ignoreHeaders, "ignore-header", "header to ignore when matching (repeatable)")
+	flag.Var(&ignoreParams, "ignore-param", "URL query parameter to ignore when matching (repeatable)")
+	flag.Var(&ignoreURLPatterns, "ignore-url-pattern", "regexp for parts of URLs to ignore when matching (repeatable)")
+	flag.Var(&ignoreJSONFields, "ignore-json-field", "dotted path of a JSON body field to ignore when matching (repeatable)")
+	flag.Var(&redactHeaders, "redact-header", "header to redact when recording (repeatable)")
+	flag.Var(&redactParams, "redact-param", "URL query parameter to redact when recording (repeatable)")
+	flag.Var(&redactJSONFields, "redact-json-field", "dotted path of a JSON body field to redact when recording (repeatable)")
+	flag.Var(&redactPatterns, "redact-pattern", "regexp for text to redact when recording (repeatable)")
+}
+
+// A stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
 func main() {
 	flag.Parse()
 	if *record == "" && *replay == "" {
@@ -65,6 +90,9 @@ func main() {
 		log.Fatal(err)
 	}
 	proxy.DebugHeaders = *debugHeaders
+	if err := addRules(pr); err != nil {
+		log.Fatal(err)
+	}
 
 	// Expose handlers on the control port.
 	mux := http.NewServeMux()
@@ -87,6 +115,39 @@ func main() {
 	}
 }
 
+// addRules configures pr with the matching and redaction rules from the flags.
+func addRules(pr *proxy.Proxy) error {
+	for _, h := range ignoreHeaders {
+		pr.IgnoreHeader(h)
+	}
+	for _, p := range ignoreParams {
+		pr.IgnoreParam(p)
+	}
+	for _, p := range ignoreURLPatterns {
+		if err := pr.IgnoreURLPattern(p); err != nil {
+			return err
+		}
+	}
+	for _, f := range ignoreJSONFields {
+		pr.IgnoreJSONField(f)
+	}
+	for _, h := range redactHeaders {
+		pr.RedactHeader(h)
+	}
+	for _, p := range redactParams {
+		pr.RedactParam(p)
+	}
+	for _, f := range redactJSONFields {
+		pr.RedactJSONField(f)
+	}
+	for _, p := range redactPatterns {
+		if err := pr.RedactPattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func handleInitial(pr *proxy.Proxy) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		switch req.Method {
@@ -29,6 +29,15 @@
 //     You will get back the recorded responses.
 // 3.  Close the Replayer when you're done.
 //
+// Recordings may contain private data. Values of headers that usually hold
+// credentials are always replaced in the log; use the Redact methods of Recorder
+// to replace other data. Redaction rules are saved in the log, and redacted data
+// is ignored when matching requests on replay.
+//
+// Requests are matched on replay by method, URL, body and headers. Use the Ignore
+// methods of Replayer to make matching less strict, for example when a request
+// contains a timestamp.
+//
 // This package is EXPERIMENTAL and is subject to change or removal without notice.
 // It requires Go version 1.8 or higher.
 package httpreplay
@@ -78,6 +87,31 @@ func proxyClient(ctx context.Context, p *proxy.Proxy, opts ...option.ClientOptio
 	return &http.Client{Transport: trans}, nil
 }
 
+// RedactHeader replaces the values of the header h in the log.
+func (r *Recorder) RedactHeader(h string) {
+	r.proxy.RedactHeader(h)
+}
+
+// RedactParam replaces the values of the URL query parameter name in the log.
+func (r *Recorder) RedactParam(name string) {
+	r.proxy.RedactParam(name)
+}
+
+// RedactJSONField replaces the value of a field of JSON request and response
+// bodies in the log. The path is the dotted sequence of names leading to the
+// field, like "owner.email". A path that reaches an array applies to each of its
+// elements.
+func (r *Recorder) RedactJSONField(path string) {
+	r.proxy.RedactJSONField(path)
+}
+
+// RedactPattern replaces the text matching the regular expression pattern in
+// the log, in URLs and in request and response bodies. Compressed bodies are not
+// redacted.
+func (r *Recorder) RedactPattern(pattern string) error {
+	return r.proxy.RedactPattern(pattern)
+}
+
 // Close closes the Recorder and saves the log file.
 func (r *Recorder) Close() error {
 	return r.proxy.Close()
@@ -114,6 +148,26 @@ func (r *Replayer) IgnoreHeader(h string) {
 	r.proxy.IgnoreHeader(h)
 }
 
+// IgnoreParam will not use the URL query parameter name when matching requests.
+// The order of query parameters is never used.
+func (r *Replayer) IgnoreParam(name string) {
+	r.proxy.IgnoreParam(name)
+}
+
+// IgnoreURLPattern will not use the parts of URLs that match the regular
+// expression pattern when matching requests.
+func (r *Replayer) IgnoreURLPattern(pattern string) error {
+	return r.proxy.IgnoreURLPattern(pattern)
+}
+
+// IgnoreJSONField will not use a field of JSON request bodies when matching
+// requests. The path is the dotted sequence of names leading to the field, like
+// "metadata.updated". A path that reaches an array applies to each of its
+// elements. Apart from ignored fields, JSON bodies are compared by value.
+func (r *Replayer) IgnoreJSONField(path string) {
+	r.proxy.IgnoreJSONField(path)
+}
+
 // Close closes the replayer.
 func (r *Replayer) Close() error {
 	return r.proxy.Close()
@@ -40,12 +40,19 @@ func NewRecorder(string, []byte) (*Recorder, error) { return nil, nil }
 func (*Recorder) Client(context.Context, ...option.ClientOption) (*http.Client, error) {
 	return nil, nil
 }
-func (*Recorder) Close() error { return nil }
+func (*Recorder) RedactHeader(string)        {}
+func (*Recorder) RedactParam(string)         {}
+func (*Recorder) RedactJSONField(string)     {}
+func (*Recorder) RedactPattern(string) error { return nil }
+func (*Recorder) Close() error               { return nil }
 
 func NewReplayer(string) (*Replayer, error) { return nil, nil }
 
 func (*Replayer) Initial() []byte                              { return nil }
 func (*Replayer) IgnoreHeader(string)                          {}
+func (*Replayer) IgnoreParam(string)                           {}
+func (*Replayer) IgnoreURLPattern(string) error                { return nil }
+func (*Replayer) IgnoreJSONField(string)                       {}
 func (*Replayer) Client(context.Context) (*http.Client, error) { return nil, nil }
 func (*Replayer) Close() error                                 { return nil }
 
@@ -39,6 +39,7 @@ const LogVersion = "0.1"
 type Log struct {
 	Initial []byte // initial data for replay
 	Version string // version of this log format
+	Rules   *Rules `json:",omitempty"` // matching and redaction rules used when recording
 	Entries []*Entry
 }
 
@@ -75,6 +76,7 @@ type Logger struct {
 	mu      sync.Mutex
 	entries map[string]*Entry // from ID
 	log     *Log
+	rules   *Rules // redaction rules; may be nil
 }
 
 // NewLogger creates a new logger.
@@ -98,6 +100,9 @@ func (l *Logger) ModifyRequest(req *http.Request) error {
 	if err != nil {
 		return err
 	}
+	if l.rules != nil {
+		l.rules.redactRequest(lreq)
+	}
 	id := ctx.ID()
 	entry := &Entry{ID: id, Request: lreq}
 
@@ -123,6 +128,9 @@ func (l *Logger) ModifyResponse(res *http.Response) error {
 	if err != nil {
 		return err
 	}
+	if l.rules != nil {
+		l.rules.redactResponse(lres)
+	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -30,6 +30,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -51,10 +52,10 @@ type Proxy struct {
 	// Initial state of the client.
 	Initial []byte
 
-	mproxy        *martian.Proxy
-	filename      string          // for log
-	logger        *Logger         // for recording only
-	ignoreHeaders map[string]bool // headers the user has asked to ignore
+	mproxy   *martian.Proxy
+	filename string  // for log
+	logger   *Logger // for recording only
+	rules    *Rules  // matching and redaction rules
 }
 
 // ForRecording returns a Proxy configured to record.
@@ -76,6 +77,7 @@ func ForRecording(filename string, port int) (*Proxy, error) {
 	logGroup.AddRequestModifier(skipAuth)
 	logGroup.AddResponseModifier(skipAuth)
 	p.logger = NewLogger()
+	p.logger.rules = p.rules
 	logGroup.AddRequestModifier(p.logger)
 	logGroup.AddResponseModifier(p.logger)
 
@@ -119,15 +121,11 @@ func newProxy(filename string) (*Proxy, error) {
 		return nil, err
 	}
 	mproxy.SetMITM(mc)
-	ih := map[string]bool{}
-	for k, v := range ignoreHeaders {
-		ih[k] = v
-	}
 	return &Proxy{
-		mproxy:        mproxy,
-		CACert:        x509c,
-		filename:      filename,
-		ignoreHeaders: ih,
+		mproxy:   mproxy,
+		CACert:   x509c,
+		filename: filename,
+		rules:    &Rules{},
 	}, nil
 }
 
@@ -153,7 +151,56 @@ func (p *Proxy) Transport() *http.Transport {
 
 // IgnoreHeader will cause h to be ignored during matching on replay.
 func (p *Proxy) IgnoreHeader(h string) {
-	p.ignoreHeaders[http.CanonicalHeaderKey(h)] = true
+	p.rules.IgnoreHeaders = append(p.rules.IgnoreHeaders, http.CanonicalHeaderKey(h))
+}
+
+// IgnoreParam will cause the URL query parameter name to be ignored during
+// matching on replay.
+func (p *Proxy) IgnoreParam(name string) {
+	p.rules.IgnoreParams = append(p.rules.IgnoreParams, name)
+}
+
+// IgnoreURLPattern will cause the parts of URLs that match the regular
+// expression pattern to be ignored during matching on replay.
+func (p *Proxy) IgnoreURLPattern(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return err
+	}
+	p.rules.IgnoreURLPatterns = append(p.rules.IgnoreURLPatterns, pattern)
+	return p.rules.compile()
+}
+
+// IgnoreJSONField will cause the field of JSON request bodies with the given
+// dotted path to be ignored during matching on replay.
+func (p *Proxy) IgnoreJSONField(path string) {
+	p.rules.IgnoreJSONFields = append(p.rules.IgnoreJSONFields, path)
+}
+
+// RedactHeader will cause the values of h to be replaced in the log.
+func (p *Proxy) RedactHeader(h string) {
+	p.rules.RedactHeaders = append(p.rules.RedactHeaders, http.CanonicalHeaderKey(h))
+}
+
+// RedactParam will cause the values of the URL query parameter name to be
+// replaced in the log.
+func (p *Proxy) RedactParam(name string) {
+	p.rules.RedactParams = append(p.rules.RedactParams, name)
+}
+
+// RedactJSONField will cause the value of the field of JSON request and
+// response bodies with the given dotted path to be replaced in the log.
+func (p *Proxy) RedactJSONField(path string) {
+	p.rules.RedactJSONFields = append(p.rules.RedactJSONFields, path)
+}
+
+// RedactPattern will cause the matches of the regular expression pattern in
+// URLs and bodies to be replaced in the log.
+func (p *Proxy) RedactPattern(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return err
+	}
+	p.rules.RedactPatterns = append(p.rules.RedactPatterns, pattern)
+	return p.rules.compile()
 }
 
 // Close closes the proxy. If the proxy is recording, it also writes the log.
@@ -168,6 +215,9 @@ func (p *Proxy) Close() error {
 func (p *Proxy) writeLog() error {
 	lg := p.logger.Extract()
 	lg.Initial = p.Initial
+	if !p.rules.isEmpty() {
+		lg.Rules = p.rules
+	}
 	bytes, err := json.MarshalIndent(lg, "", "  ")
 	if err != nil {
 		return err
@@ -40,15 +40,20 @@ func ForReplaying(filename string, port int) (*Proxy, error) {
 	if err != nil {
 		return nil, err
 	}
-	calls, initial, err := readLog(filename)
+	calls, lg, err := readLog(filename)
 	if err != nil {
 		return nil, err
 	}
+	if lg.Rules != nil {
+		if err := p.rules.merge(lg.Rules); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
 	p.mproxy.SetRoundTripper(&replayRoundTripper{
-		calls:         calls,
-		ignoreHeaders: p.ignoreHeaders,
+		calls: calls,
+		rules: p.rules,
 	})
-	p.Initial = initial
+	p.Initial = lg.Initial
 
 	// Debug logging.
 	// TODO(jba): factor out from here and ForRecording.
@@ -70,7 +75,7 @@ type call struct {
 	res     *Response
 }
 
-func readLog(filename string) ([]*call, []byte, error) {
+func readLog(filename string) ([]*call, *Log, error) {
 	bytes, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, nil, err
@@ -124,27 +129,37 @@ func readLog(filename string) ([]*call, []byte, error) {
 			return nil, nil, fmt.Errorf("missing request or response: %+v", c)
 		}
 	}
-	return calls, lg.Initial, nil
+	return calls, &lg, nil
 }
 
 type replayRoundTripper struct {
-	mu            sync.Mutex
-	calls         []*call
-	ignoreHeaders map[string]bool
+	mu    sync.Mutex
+	calls []*call
+	rules *Rules
 }
 
 func (r *replayRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	reqBody, err := newRequestBodyFromHTTP(req)
+	// Redact the request as it would have been when recording, so that it
+	// matches the log.
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = r.rules.redactBody(body, req.Header.Get("Content-Type"), req.Header.Get("Content-Encoding"))
+	reqBody, err := newRequestBody(req.Header.Get("Content-Type"), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	inURL := r.rules.redactURL(req.URL.String())
+	ignoreHeaders := r.rules.ignoredHeaders()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	for i, call := range r.calls {
 		if call == nil {
 			continue
 		}
-		if requestsMatch(req, reqBody, call.req, call.reqBody, r.ignoreHeaders) {
+		if requestsMatch(req.Method, inURL, req.Header, reqBody, call.req, call.reqBody, r.rules, ignoreHeaders) {
 			r.calls[i] = nil // nil out this call so we don't reuse it
 			return toHTTPResponse(call.res, req), nil
 		}
@@ -179,19 +194,20 @@ func init() {
 	}
 }
 
-// Report whether the incoming request in matches the candidate request cand.
-func requestsMatch(in *http.Request, inBody *requestBody, cand *Request, candBody *requestBody, ignoreHeaders map[string]bool) bool {
-	if in.Method != cand.Method {
+// Report whether the incoming request, with the given method, URL, header and body,
+// matches the candidate request cand.
+func requestsMatch(method, url string, header http.Header, inBody *requestBody, cand *Request, candBody *requestBody, rules *Rules, ignoreHeaders map[string]bool) bool {
+	if method != cand.Method {
 		return false
 	}
-	if in.URL.String() != cand.URL {
+	if rules.matchURL(url) != rules.matchURL(cand.URL) {
 		return false
 	}
-	if !inBody.equal(candBody) {
+	if !inBody.matches(candBody, rules) {
 		return false
 	}
 	// Check headers last. See DebugHeaders.
-	return headersMatch(in.Header, cand.Header, ignoreHeaders)
+	return headersMatch(header, cand.Header, ignoreHeaders)
 }
 
 // A requestBody represents the body of a request. If the content type is multipart, the
@@ -259,6 +275,12 @@ func newRequestBody(contentType string, body io.Reader) (*requestBody, error) {
 }
 
 func (r1 *requestBody) equal(r2 *requestBody) bool {
+	return r1.matches(r2, &Rules{})
+}
+
+// matches reports whether r1 and r2 are equal according to rules. Parts that
+// are both JSON are compared by value, ignoring the fields that rules ignore.
+func (r1 *requestBody) matches(r2 *requestBody, rules *Rules) bool {
 	if r1 == nil || r2 == nil {
 		return r1 == r2
 	}
@@ -269,7 +291,13 @@ func (r1 *requestBody) equal(r2 *requestBody) bool {
 		return false
 	}
 	for i, p1 := range r1.parts {
-		if !bytes.Equal(p1, r2.parts[i]) {
+		p2 := r2.parts[i]
+		if bytes.Equal(p1, p2) {
+			continue
+		}
+		v1, ok1 := rules.matchJSON(p1)
+		v2, ok2 := rules.matchJSON(p2)
+		if !ok1 || !ok2 || !reflect.DeepEqual(v1, v2) {
 			return false
 		}
 	}
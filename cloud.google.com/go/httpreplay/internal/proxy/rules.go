@@ -0,0 +1,265 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.8
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// The value that replaces redacted data in the log.
+const redacted = "REDACTED"
+
+// Rules control how requests are matched on replay, and what data is redacted
+// when recording.
+//
+// The rules in effect when recording are saved in the log and used again on
+// replay, along with any added by the replayer. Redacted data is also ignored
+// when matching, so that a redacted recording can still be replayed.
+type Rules struct {
+	// Headers that are not compared when matching requests.
+	IgnoreHeaders []string `json:",omitempty"`
+
+	// URL query parameters that are not compared when matching requests. The
+	// order of query parameters never matters.
+	IgnoreParams []string `json:",omitempty"`
+
+	// Regular expressions for parts of request URLs that are not compared
+	// when matching requests, such as timestamps or random IDs.
+	IgnoreURLPatterns []string `json:",omitempty"`
+
+	// Dotted paths of the fields of JSON request bodies that are not compared
+	// when matching requests. A path that reaches an array applies to each
+	// of its elements. Other than these fields, JSON bodies are compared by
+	// value, so their formatting and the order of object members do not matter.
+	IgnoreJSONFields []string `json:",omitempty"`
+
+	// Headers whose values are replaced in the log, in addition to the ones
+	// that usually hold credentials.
+	RedactHeaders []string `json:",omitempty"`
+
+	// URL query parameters whose values are replaced in the log.
+	RedactParams []string `json:",omitempty"`
+
+	// Dotted paths of the fields of JSON request and response bodies whose
+	// values are replaced in the log.
+	RedactJSONFields []string `json:",omitempty"`
+
+	// Regular expressions whose matches are replaced in the log, in URLs and
+	// in request and response bodies. Compressed bodies are not redacted.
+	RedactPatterns []string `json:",omitempty"`
+
+	ignoreURLRes []*regexp.Regexp
+	redactRes    []*regexp.Regexp
+}
+
+// compile compiles the regular expressions of r.
+func (r *Rules) compile() error {
+	var err error
+	if r.ignoreURLRes, err = compileAll(r.IgnoreURLPatterns); err != nil {
+		return err
+	}
+	r.redactRes, err = compileAll(r.RedactPatterns)
+	return err
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// merge adds the rules of r2 to r.
+func (r *Rules) merge(r2 *Rules) error {
+	r.IgnoreHeaders = append(r.IgnoreHeaders, r2.IgnoreHeaders...)
+	r.IgnoreParams = append(r.IgnoreParams, r2.IgnoreParams...)
+	r.IgnoreURLPatterns = append(r.IgnoreURLPatterns, r2.IgnoreURLPatterns...)
+	r.IgnoreJSONFields = append(r.IgnoreJSONFields, r2.IgnoreJSONFields...)
+	r.RedactHeaders = append(r.RedactHeaders, r2.RedactHeaders...)
+	r.RedactParams = append(r.RedactParams, r2.RedactParams...)
+	r.RedactJSONFields = append(r.RedactJSONFields, r2.RedactJSONFields...)
+	r.RedactPatterns = append(r.RedactPatterns, r2.RedactPatterns...)
+	return r.compile()
+}
+
+func (r *Rules) isEmpty() bool {
+	return len(r.IgnoreHeaders)+len(r.IgnoreParams)+len(r.IgnoreURLPatterns)+len(r.IgnoreJSONFields)+
+		len(r.RedactHeaders)+len(r.RedactParams)+len(r.RedactJSONFields)+len(r.RedactPatterns) == 0
+}
+
+// ignoredHeaders returns the headers that are not compared when matching:
+// the default ones, and those that r ignores or redacts.
+func (r *Rules) ignoredHeaders() map[string]bool {
+	ih := map[string]bool{}
+	for k, v := range ignoreHeaders {
+		ih[k] = v
+	}
+	for _, h := range r.IgnoreHeaders {
+		ih[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, h := range r.RedactHeaders {
+		ih[http.CanonicalHeaderKey(h)] = true
+	}
+	return ih
+}
+
+// redactHeaders copies hs, redacting sensitive headers and those in r.
+func (r *Rules) redactHeaders(hs http.Header) http.Header {
+	rh := redactHeaders(hs)
+	for _, h := range r.RedactHeaders {
+		h = http.CanonicalHeaderKey(h)
+		if _, ok := rh[h]; ok {
+			rh.Set(h, redacted)
+		}
+	}
+	return rh
+}
+
+// redactURL returns u with its redacted parameters and patterns replaced.
+func (r *Rules) redactURL(u string) string {
+	for _, re := range r.redactRes {
+		u = re.ReplaceAllLiteralString(u, redacted)
+	}
+	if len(r.RedactParams) == 0 {
+		return u
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	q := pu.Query()
+	changed := false
+	for _, p := range r.RedactParams {
+		if vs, ok := q[p]; ok {
+			for i := range vs {
+				vs[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return u
+	}
+	pu.RawQuery = q.Encode()
+	return pu.String()
+}
+
+// redactBody returns body with its redacted JSON fields and patterns replaced.
+// contentType and contentEncoding are the values of the body's headers.
+func (r *Rules) redactBody(body []byte, contentType, contentEncoding string) []byte {
+	if len(body) == 0 || (contentEncoding != "" && contentEncoding != "identity") {
+		return body
+	}
+	if len(r.RedactJSONFields) > 0 && strings.HasPrefix(contentType, "application/json") {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			for _, f := range r.RedactJSONFields {
+				v = editJSON(v, strings.Split(f, "."), func(interface{}) interface{} { return redacted })
+			}
+			if b, err := json.Marshal(v); err == nil {
+				body = b
+			}
+		}
+	}
+	for _, re := range r.redactRes {
+		body = re.ReplaceAllLiteral(body, []byte(redacted))
+	}
+	return body
+}
+
+// matchURL returns the form of u that is compared when matching: ignored
+// patterns are replaced, ignored parameters are removed, and the remaining
+// parameters are sorted.
+func (r *Rules) matchURL(u string) string {
+	for _, re := range r.ignoreURLRes {
+		u = re.ReplaceAllLiteralString(u, "*")
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	q := pu.Query()
+	for _, p := range r.IgnoreParams {
+		q.Del(p)
+	}
+	pu.RawQuery = q.Encode()
+	return pu.String()
+}
+
+// matchJSON returns the JSON value of part with ignored fields removed, and
+// reports whether part is JSON.
+func (r *Rules) matchJSON(part []byte) (interface{}, bool) {
+	b := bytes.TrimSpace(part)
+	if len(b) == 0 || (b[0] != '{' && b[0] != '[') {
+		return nil, false
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+	for _, f := range r.IgnoreJSONFields {
+		v = editJSON(v, strings.Split(f, "."), nil)
+	}
+	return v, true
+}
+
+// editJSON replaces the value at path in v with the result of calling edit on
+// it, or removes it if edit is nil. Arrays along the path are edited
+// element-wise.
+func editJSON(v interface{}, path []string, edit func(interface{}) interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, e := range v {
+			v[i] = editJSON(e, path, edit)
+		}
+	case map[string]interface{}:
+		x, ok := v[path[0]]
+		switch {
+		case !ok:
+		case len(path) > 1:
+			v[path[0]] = editJSON(x, path[1:], edit)
+		case edit == nil:
+			delete(v, path[0])
+		default:
+			v[path[0]] = edit(x)
+		}
+	}
+	return v
+}
+
+// redactRequest redacts a logged request.
+func (r *Rules) redactRequest(req *Request) {
+	req.URL = r.redactURL(req.URL)
+	req.Header = r.redactHeaders(req.Header)
+	req.Body = r.redactBody(req.Body, req.Header.Get("Content-Type"), req.Header.Get("Content-Encoding"))
+}
+
+// redactResponse redacts a logged response.
+func (r *Rules) redactResponse(res *Response) {
+	res.Header = r.redactHeaders(res.Header)
+	res.Body = r.redactBody(res.Body, res.Header.Get("Content-Type"), res.Header.Get("Content-Encoding"))
+}
@@ -0,0 +1,190 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.8
+
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+	"github.com/google/martian"
+)
+
+func newRules(t *testing.T, r *Rules) *Rules {
+	if err := r.compile(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMatchURL(t *testing.T) {
+	r := newRules(t, &Rules{
+		IgnoreParams:      []string{"t"},
+		IgnoreURLPatterns: []string{`obj-[0-9]+`},
+	})
+	for _, test := range []struct {
+		u1, u2 string
+		want   bool
+	}{
+		{"https://h/p?a=1&b=2", "https://h/p?b=2&a=1", true},
+		{"https://h/p?a=1&t=10", "https://h/p?a=1&t=20", true},
+		{"https://h/p?a=1&t=10", "https://h/p?a=1", true},
+		{"https://h/p?a=1", "https://h/p?a=2", false},
+		{"https://h/b/obj-123/x", "https://h/b/obj-456/x", true},
+		{"https://h/b/obj-123/x", "https://h/b/obj-456/y", false},
+	} {
+		got := r.matchURL(test.u1) == r.matchURL(test.u2)
+		if got != test.want {
+			t.Errorf("%s, %s: got %t, want %t", test.u1, test.u2, got, test.want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r := newRules(t, &Rules{
+		RedactHeaders:    []string{"x-secret"},
+		RedactParams:     []string{"key"},
+		RedactJSONFields: []string{"owner.email", "items.token"},
+		RedactPatterns:   []string{`sk-[a-z]+`},
+	})
+	req := &Request{
+		URL:    "https://h/p/sk-abc?key=k1&a=1",
+		Header: http.Header{"X-Secret": {"s"}, "Authorization": {"tok"}, "Content-Type": {"application/json"}},
+		Body:   []byte(`{"owner": {"email": "e@x.com", "name": "n"}, "items": [{"token": 1}, {"token": 2}], "note": "sk-xyz"}`),
+	}
+	r.redactRequest(req)
+	want := &Request{
+		URL: "https://h/p/REDACTED?a=1&key=REDACTED",
+		Header: http.Header{
+			"X-Secret":      {"REDACTED"},
+			"Authorization": {"REDACTED"},
+			"Content-Type":  {"application/json"},
+		},
+		Body: []byte(`{"items":[{"token":"REDACTED"},{"token":"REDACTED"}],"note":"REDACTED","owner":{"email":"REDACTED","name":"n"}}`),
+	}
+	if diff := testutil.Diff(req, want); diff != "" {
+		t.Error(diff)
+	}
+
+	// Compressed bodies are left alone.
+	res := &Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}, "Content-Type": {"application/json"}},
+		Body:   []byte("sk-abc"),
+	}
+	r.redactResponse(res)
+	if got := string(res.Body); got != "sk-abc" {
+		t.Errorf("compressed body: got %q, want it unchanged", got)
+	}
+}
+
+func TestBodyMatches(t *testing.T) {
+	r := newRules(t, &Rules{IgnoreJSONFields: []string{"updated", "list.id"}})
+	body := func(s string) *requestBody {
+		return &requestBody{mediaType: "application/json", parts: [][]byte{[]byte(s)}}
+	}
+	for _, test := range []struct {
+		b1, b2 string
+		want   bool
+	}{
+		{`{"a": 1, "b": 2}`, `{"b":2,"a":1}`, true},
+		{`{"a": 1, "updated": "t1"}`, `{"a": 1, "updated": "t2"}`, true},
+		{`{"a": 1, "updated": "t1"}`, `{"a": 1}`, true},
+		{`{"a": 1}`, `{"a": 2}`, false},
+		{`{"list": [{"id": 1, "x": 1}]}`, `{"list": [{"id": 2, "x": 1}]}`, true},
+		{`{"list": [{"id": 1, "x": 1}]}`, `{"list": [{"id": 1, "x": 2}]}`, false},
+		{`not json`, `not json`, true},
+		{`not json`, `not json!`, false},
+	} {
+		if got := body(test.b1).matches(body(test.b2), r); got != test.want {
+			t.Errorf("%s, %s: got %t, want %t", test.b1, test.b2, got, test.want)
+		}
+	}
+}
+
+func TestReplayWithRules(t *testing.T) {
+	rules := newRules(t, &Rules{
+		IgnoreParams:   []string{"t"},
+		RedactParams:   []string{"key"},
+		RedactPatterns: []string{`sk-[a-z]+`},
+	})
+
+	// Record a request.
+	req := &http.Request{
+		Method: "POST",
+		URL:    mustParseURL(t, "https://h/p?key=secret&t=1"),
+		Header: http.Header{"Content-Type": {"text/plain"}},
+		Body:   ioutil.NopCloser(strings.NewReader("token sk-abc")),
+	}
+	res := &http.Response{
+		Request:    req,
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("ok")),
+	}
+	l := NewLogger()
+	l.rules = rules
+	_, remove, err := martian.TestContext(req, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remove()
+	if err := l.ModifyRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ModifyResponse(res); err != nil {
+		t.Fatal(err)
+	}
+	lg := l.Extract()
+	lreq := lg.Entries[0].Request
+	if strings.Contains(lreq.URL, "secret") || strings.Contains(string(lreq.Body), "sk-abc") {
+		t.Fatalf("request not redacted: %+v", lreq)
+	}
+	reqBody, err := newRequestBodyFromLog(lreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay a request that differs in redacted and ignored data.
+	rt := &replayRoundTripper{
+		calls: []*call{{lreq, reqBody, lg.Entries[0].Response}},
+		rules: rules,
+	}
+	req2 := &http.Request{
+		Method: "POST",
+		URL:    mustParseURL(t, "https://h/p?t=2&key=other"),
+		Header: http.Header{"Content-Type": {"text/plain"}},
+		Body:   ioutil.NopCloser(strings.NewReader("token sk-xyz")),
+	}
+	res2, err := rt.RoundTrip(req2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res2.StatusCode != 200 {
+		t.Errorf("got status %d, want 200", res2.StatusCode)
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
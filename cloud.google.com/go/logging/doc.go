@@ -94,6 +94,21 @@ You may want use a standard log.Logger in your program.
 	stdlg.Println("some info")
 
 
+Structured Logging Packages
+
+Records from structured logging packages can be converted to entries with
+Logger.RecordEntry, which maps their fields to a JSON payload, and HTTP
+requests and trace spans among the fields to the corresponding parts of the
+entry. With Go 1.21 and later, Logger.SlogHandler returns a handler for the
+log/slog package:
+
+	slg := slog.New(lg.SlogHandler(nil))
+	slg.InfoContext(ctx, "request served", "path", r.URL.Path)
+
+The package cloud.google.com/go/logging/logrushook provides a hook for
+github.com/sirupsen/logrus.
+
+
 Log Levels
 
 An Entry may have one of a number of severity levels associated with it.
@@ -0,0 +1,154 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrushook provides a hook that sends the entries of a
+// github.com/sirupsen/logrus logger to Stackdriver Logging.
+//
+// Add the hook to a logrus logger:
+//
+//	lg := client.Logger("my-log")
+//	logrus.AddHook(logrushook.New(lg))
+//
+// Entries are converted as described for logging.Logger.RecordEntry: their
+// fields become the JSON payload, except that an *http.Request or
+// *logging.HTTPRequest field sets the HTTP request of the entry and a
+// *trace.Span or context.Context field associates the entry with a trace.
+//
+//	logrus.WithFields(logrus.Fields{"request": r, "span": span}).Info("served")
+//
+// Entries are buffered as for logging.Logger.Log. The hook flushes the
+// logger on Fatal and Panic entries, since logrus exits or panics after
+// them.
+package logrushook // import "cloud.google.com/go/logging/logrushook"
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"cloud.google.com/go/logging"
+	"github.com/sirupsen/logrus"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+// Hook is a logrus.Hook that logs entries to a logging.Logger.
+type Hook struct {
+	logger *logging.Logger
+
+	// LogLevels are the levels of the entries that are logged. If empty, all
+	// levels are logged.
+	LogLevels []logrus.Level
+
+	// LabelKeys are the keys of fields whose values are added to the labels
+	// of entries instead of their payloads.
+	LabelKeys []string
+
+	// AddSource sets the SourceLocation of entries to the location of the
+	// logrus call. It adds some overhead to each entry.
+	AddSource bool
+
+	log   func(logging.Entry) // for testing
+	flush func() error
+}
+
+// New returns a Hook that logs to l.
+func New(l *logging.Logger) *Hook {
+	return &Hook{logger: l, log: l.Log, flush: l.Flush}
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	if len(h.LogLevels) == 0 {
+		return logrus.AllLevels
+	}
+	return h.LogLevels
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	r := logging.Record{
+		Time:     e.Time,
+		Severity: severity(e.Level),
+		Message:  e.Message,
+		Fields:   map[string]interface{}{},
+	}
+	for k, v := range e.Data {
+		if stringInSlice(k, h.LabelKeys) {
+			if r.Labels == nil {
+				r.Labels = map[string]string{}
+			}
+			r.Labels[k] = fmt.Sprint(v)
+			continue
+		}
+		r.Fields[k] = v
+	}
+	if h.AddSource {
+		r.SourceLocation = caller()
+	}
+	h.log(h.logger.RecordEntry(r))
+	if e.Level <= logrus.FatalLevel {
+		return h.flush()
+	}
+	return nil
+}
+
+func severity(l logrus.Level) logging.Severity {
+	switch l {
+	case logrus.PanicLevel:
+		return logging.Alert
+	case logrus.FatalLevel:
+		return logging.Critical
+	case logrus.ErrorLevel:
+		return logging.Error
+	case logrus.WarnLevel:
+		return logging.Warning
+	case logrus.InfoLevel:
+		return logging.Info
+	case logrus.DebugLevel:
+		return logging.Debug
+	default:
+		return logging.Default
+	}
+}
+
+// caller returns the location of the first caller of logrus.
+func caller() *logpb.LogEntrySourceLocation {
+	inLogrus := false
+	for i := 2; ; i++ {
+		pc, file, line, ok := runtime.Caller(i)
+		if !ok {
+			return nil
+		}
+		var name string
+		if f := runtime.FuncForPC(pc); f != nil {
+			name = f.Name()
+		}
+		if strings.Contains(name, "sirupsen/logrus.") {
+			inLogrus = true
+			continue
+		}
+		if inLogrus {
+			return &logpb.LogEntrySourceLocation{File: file, Line: int64(line), Function: name}
+		}
+	}
+}
+
+func stringInSlice(s string, list []string) bool {
+	for _, e := range list {
+		if s == e {
+			return true
+		}
+	}
+	return false
+}
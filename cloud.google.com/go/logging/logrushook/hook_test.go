@@ -0,0 +1,123 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrushook
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+	"cloud.google.com/go/logging"
+	ltesting "cloud.google.com/go/logging/internal/testing"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func newTestHook(t *testing.T) (*Hook, *[]logging.Entry, *int) {
+	addr, err := ltesting.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := logging.NewClient(context.Background(), "projects/P", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(client.Logger("test"))
+	var (
+		entries []logging.Entry
+		flushes int
+	)
+	h.log = func(e logging.Entry) { entries = append(entries, e) }
+	h.flush = func() error { flushes++; return nil }
+	return h, &entries, &flushes
+}
+
+func newTestLogger(h *Hook) *logrus.Logger {
+	lg := logrus.New()
+	lg.Out = ioutil.Discard
+	lg.Level = logrus.DebugLevel
+	lg.Hooks.Add(h)
+	return lg
+}
+
+func TestHook(t *testing.T) {
+	h, entries, flushes := newTestHook(t)
+	h.LabelKeys = []string{"service"}
+	lg := newTestLogger(h)
+
+	lg.Debug("plain")
+	lg.WithFields(logrus.Fields{"service": "api", "n": 1}).Warn("fields")
+	lg.WithError(errors.New("boom")).Error("failed")
+
+	want := []logging.Entry{
+		{Severity: logging.Debug, Payload: "plain"},
+		{
+			Severity: logging.Warning,
+			Payload:  map[string]interface{}{"message": "fields", "n": 1},
+			Labels:   map[string]string{"service": "api"},
+		},
+		{
+			Severity: logging.Error,
+			Payload:  map[string]interface{}{"message": "failed", "error": "boom"},
+		},
+	}
+	got := *entries
+	for i := range got {
+		got[i].Timestamp = want[i].Timestamp
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if *flushes != 0 {
+		t.Errorf("got %d flushes, want 0", *flushes)
+	}
+
+	// Panic entries are flushed.
+	func() {
+		defer func() { recover() }()
+		lg.Panic("panic")
+	}()
+	if n := len(*entries); n != 4 || (*entries)[3].Severity != logging.Alert {
+		t.Errorf("panic: got entries %v", *entries)
+	}
+	if *flushes != 1 {
+		t.Errorf("got %d flushes, want 1", *flushes)
+	}
+}
+
+func TestHookLevelsAndSource(t *testing.T) {
+	h, entries, _ := newTestHook(t)
+	h.LogLevels = []logrus.Level{logrus.ErrorLevel}
+	h.AddSource = true
+	lg := newTestLogger(h)
+
+	lg.Info("not logged")
+	lg.Error("logged")
+	if len(*entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(*entries))
+	}
+	loc := (*entries)[0].SourceLocation
+	if loc == nil || !strings.HasSuffix(loc.File, "hook_test.go") || !strings.HasSuffix(loc.Function, "TestHookLevelsAndSource") {
+		t.Errorf("got source location %v, want this function", loc)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/trace"
+	"golang.org/x/net/context"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+// A Record is a leveled, structured log record, such as those produced by
+// structured logging packages. Adapters for those packages convert their
+// records to Records, and Records to Entries with Logger.RecordEntry.
+type Record struct {
+	// Time is the time of the record. If zero, the current time is used.
+	Time time.Time
+
+	// Severity is the record's severity.
+	Severity Severity
+
+	// Message is the record's message.
+	Message string
+
+	// Fields are the record's structured data. See Logger.RecordEntry for how
+	// they are converted.
+	Fields map[string]interface{}
+
+	// Labels are added to the entry's labels.
+	Labels map[string]string
+
+	// SourceLocation is the location in the source code that produced the
+	// record, if known.
+	SourceLocation *logpb.LogEntrySourceLocation
+
+	// Context is the context in which the record was produced, if any. If it
+	// contains a trace.Span, the entry is associated with the span's trace.
+	Context context.Context
+}
+
+// MessageKey is the key of the message in the JSON payload of entries made
+// from Records with fields. The Logs Viewer displays this field as the
+// summary of the entry.
+const MessageKey = "message"
+
+// RecordEntry converts a Record to an Entry suitable for Log.
+//
+// If the Record has no fields, the payload of the Entry is its message.
+// Otherwise, the payload is a JSON object with the message under MessageKey
+// and the fields under their keys. Field values are converted as follows:
+//   - A *HTTPRequest or *http.Request becomes the HTTPRequest of the Entry.
+//   - A *trace.Span or context.Context containing one sets the Trace of the Entry.
+//   - An error is replaced by its message.
+//   - A value that cannot be marshaled to JSON is formatted with fmt.Sprint.
+func (l *Logger) RecordEntry(r Record) Entry {
+	e := Entry{
+		Timestamp:      r.Time,
+		Severity:       r.Severity,
+		SourceLocation: r.SourceLocation,
+	}
+	if len(r.Labels) > 0 {
+		e.Labels = make(map[string]string, len(r.Labels))
+		for k, v := range r.Labels {
+			e.Labels[k] = v
+		}
+	}
+	var span *trace.Span
+	if r.Context != nil {
+		span = trace.FromContext(r.Context)
+	}
+	payload := map[string]interface{}{}
+	for k, v := range r.Fields {
+		switch v := v.(type) {
+		case *HTTPRequest:
+			if v != nil && v.Request != nil {
+				e.HTTPRequest = v
+			}
+		case *http.Request:
+			if v != nil {
+				e.HTTPRequest = &HTTPRequest{Request: v}
+			}
+		case *trace.Span:
+			span = v
+		case context.Context:
+			if s := trace.FromContext(v); s != nil {
+				span = s
+			}
+		case error:
+			payload[k] = v.Error()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				payload[k] = fmt.Sprint(v)
+			} else {
+				payload[k] = v
+			}
+		}
+	}
+	if id := span.TraceID(); id != "" {
+		e.Trace = fmt.Sprintf("%s/traces/%s", l.client.parent, id)
+	}
+	if len(payload) == 0 {
+		e.Payload = r.Message
+	} else {
+		payload[MessageKey] = r.Message
+		e.Payload = payload
+	}
+	return e
+}
+
+// LogRecord converts r to an Entry with RecordEntry and logs it with Log.
+func (l *Logger) LogRecord(r Record) {
+	l.Log(l.RecordEntry(r))
+}
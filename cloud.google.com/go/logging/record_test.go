@@ -0,0 +1,105 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+	"cloud.google.com/go/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+func TestRecordEntry(t *testing.T) {
+	logger := &Logger{client: &Client{parent: "projects/P"}}
+	tc, err := trace.NewClient(context.Background(), "P", option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := tc.NewSpan("span")
+	traceName := "projects/P/traces/" + span.TraceID()
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	loc := &logpb.LogEntrySourceLocation{File: "f.go", Line: 3}
+
+	for _, test := range []struct {
+		in   Record
+		want Entry
+	}{
+		{
+			in:   Record{Time: now, Severity: Info, Message: "hello"},
+			want: Entry{Timestamp: now, Severity: Info, Payload: "hello"},
+		},
+		{
+			in: Record{
+				Severity:       Error,
+				Message:        "failed",
+				Fields:         map[string]interface{}{"err": errors.New("boom"), "n": 1, "inf": math.Inf(1)},
+				Labels:         map[string]string{"l": "v"},
+				SourceLocation: loc,
+			},
+			want: Entry{
+				Severity:       Error,
+				Payload:        map[string]interface{}{"message": "failed", "err": "boom", "n": 1, "inf": "+Inf"},
+				Labels:         map[string]string{"l": "v"},
+				SourceLocation: loc,
+			},
+		},
+		{
+			in: Record{
+				Message: "served",
+				Fields:  map[string]interface{}{"req": req},
+				Context: trace.NewContext(context.Background(), span),
+			},
+			want: Entry{
+				Payload:     "served",
+				HTTPRequest: &HTTPRequest{Request: req},
+				Trace:       traceName,
+			},
+		},
+		{
+			in: Record{
+				Message: "span field",
+				Fields:  map[string]interface{}{"span": span, "req": &HTTPRequest{Request: req, Status: 200}},
+			},
+			want: Entry{
+				Payload:     "span field",
+				HTTPRequest: &HTTPRequest{Request: req, Status: 200},
+				Trace:       traceName,
+			},
+		},
+	} {
+		got := logger.RecordEntry(test.in)
+		// Compare HTTP requests separately, since http.Request has unexported fields.
+		if g, w := got.HTTPRequest, test.want.HTTPRequest; g != nil && w != nil {
+			if g.Request != w.Request || g.Status != w.Status {
+				t.Errorf("%+v: got HTTP request %+v, want %+v", test.in, g, w)
+			}
+			got.HTTPRequest, test.want.HTTPRequest = nil, nil
+		}
+		if diff := testutil.Diff(got, test.want); diff != "" {
+			t.Errorf("%+v: %s", test.in, diff)
+		}
+	}
+}
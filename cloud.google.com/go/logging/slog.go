@@ -0,0 +1,217 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+// SlogHandlerOptions are options for Logger.SlogHandler.
+type SlogHandlerOptions struct {
+	// Level is the minimum level of records to log. If nil, records at
+	// slog.LevelInfo and above are logged.
+	Level slog.Leveler
+
+	// AddSource sets the SourceLocation of entries to the location of the
+	// logging call.
+	AddSource bool
+
+	// LabelKeys are the keys of top-level attributes whose values are added
+	// to the labels of entries instead of their payloads.
+	LabelKeys []string
+}
+
+// SlogHandler returns a slog.Handler that logs records to l. Records are
+// converted as described for RecordEntry; attribute groups become nested JSON
+// objects. Entries are buffered as for Log.
+//
+// Levels map to severities as follows: below slog.LevelInfo is Debug, below
+// slog.LevelWarn is Info, below slog.LevelError is Warning, below
+// slog.LevelError+4 is Error and anything higher is Critical.
+func (l *Logger) SlogHandler(opts *SlogHandlerOptions) slog.Handler {
+	h := &slogHandler{log: l.Log, entry: l.RecordEntry, fields: map[string]interface{}{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+type slogHandler struct {
+	opts   SlogHandlerOptions
+	log    func(Entry)
+	entry  func(Record) Entry
+	fields map[string]interface{} // from WithAttrs
+	labels map[string]string      // from WithAttrs
+	groups []string               // from WithGroup
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h2 := h.clone()
+	r.Attrs(func(a slog.Attr) bool {
+		h2.addAttr(a)
+		return true
+	})
+	rec := Record{
+		Time:     r.Time,
+		Severity: slogSeverity(r.Level),
+		Message:  r.Message,
+		Fields:   h2.fields,
+		Labels:   h2.labels,
+		Context:  ctx,
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		rec.SourceLocation = &logpb.LogEntrySourceLocation{
+			File:     f.File,
+			Line:     int64(f.Line),
+			Function: f.Function,
+		}
+	}
+	h.log(h.entry(rec))
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := h.clone()
+	for _, a := range attrs {
+		h2.addAttr(a)
+	}
+	return h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups[:len(h2.groups):len(h2.groups)], name)
+	return h2
+}
+
+func (h *slogHandler) clone() *slogHandler {
+	h2 := *h
+	h2.fields = copyFields(h.fields)
+	if h.labels != nil {
+		h2.labels = make(map[string]string, len(h.labels))
+		for k, v := range h.labels {
+			h2.labels[k] = v
+		}
+	}
+	return &h2
+}
+
+// addAttr adds a to the fields in the current group, or to the labels.
+func (h *slogHandler) addAttr(a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if len(h.groups) == 0 && stringInSlice(a.Key, h.opts.LabelKeys) {
+		if h.labels == nil {
+			h.labels = map[string]string{}
+		}
+		h.labels[a.Key] = a.Value.String()
+		return
+	}
+	m := h.fields
+	for _, g := range h.groups {
+		sub, ok := m[g].(map[string]interface{})
+		if !ok {
+			sub = map[string]interface{}{}
+			m[g] = sub
+		}
+		m = sub
+	}
+	addSlogAttr(m, a, len(h.groups) > 0)
+}
+
+// addSlogAttr adds a to m. Errors in nested groups are replaced by their
+// messages; at top level, RecordEntry does that.
+func addSlogAttr(m map[string]interface{}, a slog.Attr, nested bool) {
+	if a.Value.Kind() != slog.KindGroup {
+		v := a.Value.Any()
+		if err, ok := v.(error); ok && nested {
+			v = err.Error()
+		}
+		m[a.Key] = v
+		return
+	}
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+	// As slog.Handler requires, groups with empty keys are inlined.
+	sub := m
+	if a.Key != "" {
+		sub = map[string]interface{}{}
+		m[a.Key] = sub
+		nested = true
+	}
+	for _, ga := range attrs {
+		ga.Value = ga.Value.Resolve()
+		if !ga.Equal(slog.Attr{}) {
+			addSlogAttr(sub, ga, nested)
+		}
+	}
+}
+
+func copyFields(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[string]interface{}); ok {
+			v = copyFields(sub)
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func slogSeverity(level slog.Level) Severity {
+	switch {
+	case level < slog.LevelInfo:
+		return Debug
+	case level < slog.LevelWarn:
+		return Info
+	case level < slog.LevelError:
+		return Warning
+	case level < slog.LevelError+4:
+		return Error
+	default:
+		return Critical
+	}
+}
+
+func stringInSlice(s string, list []string) bool {
+	for _, e := range list {
+		if s == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestSlogHandler(t *testing.T) {
+	logger := &Logger{client: &Client{parent: "projects/P"}}
+	var got []Entry
+	newLogger := func(opts *SlogHandlerOptions) *slog.Logger {
+		h := logger.SlogHandler(opts).(*slogHandler)
+		h.log = func(e Entry) { got = append(got, e) }
+		return slog.New(h)
+	}
+
+	lg := newLogger(&SlogHandlerOptions{Level: slog.LevelDebug, LabelKeys: []string{"service"}})
+	lg = lg.With("service", "api", "version", 2)
+	lg.Debug("plain")
+	lg.WithGroup("req").With("id", "r1").Error("failed",
+		"err", errors.New("boom"),
+		slog.Group("db", "table", "t"),
+		slog.Group("", "inlined", true))
+	lg.Log(context.Background(), slog.LevelError+4, "critical")
+
+	want := []Entry{
+		{
+			Severity: Debug,
+			Payload:  map[string]interface{}{"message": "plain", "version": int64(2)},
+			Labels:   map[string]string{"service": "api"},
+		},
+		{
+			Severity: Error,
+			Payload: map[string]interface{}{
+				"message": "failed",
+				"version": int64(2),
+				"req": map[string]interface{}{
+					"id":      "r1",
+					"err":     "boom",
+					"db":      map[string]interface{}{"table": "t"},
+					"inlined": true,
+				},
+			},
+			Labels: map[string]string{"service": "api"},
+		},
+		{
+			Severity: Critical,
+			Payload:  map[string]interface{}{"message": "critical", "version": int64(2)},
+			Labels:   map[string]string{"service": "api"},
+		},
+	}
+	for i := range got {
+		got[i].Timestamp = want[i].Timestamp
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+
+	// The default level is Info, and AddSource sets the source location.
+	got = nil
+	lg = newLogger(&SlogHandlerOptions{AddSource: true})
+	lg.Debug("dropped")
+	lg.Warn("kept")
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if got[0].Severity != Warning || got[0].Payload != "kept" {
+		t.Errorf("got %+v, want a Warning entry with payload %q", got[0], "kept")
+	}
+	if loc := got[0].SourceLocation; loc == nil || !strings.HasSuffix(loc.File, "slog_test.go") || !strings.HasSuffix(loc.Function, "TestSlogHandler") {
+		t.Errorf("got source location %v, want this function", loc)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/pprof/profile"
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/devtools/cloudprofiler/v2"
+)
+
+const (
+	defaultLocalInterval = time.Minute
+	defaultLocalDuration = 10 * time.Second
+	serviceLabel         = "service"
+	projectLabel         = "project"
+)
+
+// LocalConfig configures local capture mode. In this mode the agent does not
+// talk to the profiler service. Instead, each round of collection captures one
+// profile of every enabled type and writes it to Dir or Bucket as a gzipped
+// pprof file. The deployment and instance labels that would be sent to the
+// service are recorded as comments in the profile, of the form "key=value".
+//
+// A round of collection starts every Interval, when the process receives one of
+// Signals, or when the handler returned by TriggerHandler is called. A trigger
+// received during a round starts another round after it.
+type LocalConfig struct {
+	// Dir is the directory the profiles are written to. It is created if it
+	// does not exist. Exactly one of Dir and Bucket must be set.
+	Dir string
+
+	// Bucket is the Cloud Storage bucket the profiles are written to. The
+	// labels of a profile are also set as the metadata of its object.
+	Bucket *storage.BucketHandle
+
+	// Prefix is prepended to the name of each file or object, for example
+	// "profiles/".
+	Prefix string
+
+	// Interval is the time between scheduled rounds of collection. It
+	// defaults to one minute. If negative, profiles are only collected when
+	// triggered.
+	Interval time.Duration
+
+	// Duration is the time over which CPU, allocation and contention
+	// profiles are collected. It defaults to 10 seconds.
+	Duration time.Duration
+
+	// Signals trigger a round of collection when received by the process,
+	// for example syscall.SIGUSR1.
+	Signals []os.Signal
+}
+
+func initializeLocalConfig(lc *LocalConfig) error {
+	if (lc.Dir == "") == (lc.Bucket == nil) {
+		return errors.New("exactly one of Dir and Bucket must be set for local capture")
+	}
+	if lc.Interval == 0 {
+		lc.Interval = defaultLocalInterval
+	}
+	if lc.Duration <= 0 {
+		lc.Duration = defaultLocalDuration
+	}
+	return nil
+}
+
+// localWriter stores the profiles captured in local mode.
+type localWriter interface {
+	writeProfile(ctx context.Context, name string, labels map[string]string, data []byte) error
+}
+
+func newLocalWriter(lc *LocalConfig) (localWriter, error) {
+	if lc.Bucket != nil {
+		return bucketWriter{lc.Bucket}, nil
+	}
+	if err := os.MkdirAll(lc.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return dirWriter(lc.Dir), nil
+}
+
+// dirWriter writes profiles to files in a directory.
+type dirWriter string
+
+func (d dirWriter) writeProfile(_ context.Context, name string, _ map[string]string, data []byte) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that partially written profiles are
+	// never seen under the final name.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// bucketWriter writes profiles to objects in a Cloud Storage bucket.
+type bucketWriter struct {
+	bucket *storage.BucketHandle
+}
+
+func (b bucketWriter) writeProfile(ctx context.Context, name string, labels map[string]string, data []byte) error {
+	w := b.bucket.Object(name).NewWriter(ctx)
+	w.ContentType = "application/octet-stream"
+	w.Metadata = labels
+	if _, err := w.Write(data); err != nil {
+		w.CloseWithError(err)
+		return err
+	}
+	return w.Close()
+}
+
+var (
+	triggerMu sync.Mutex
+	// trigger requests a round of collection. It is nil unless local capture
+	// mode is running.
+	trigger chan struct{}
+)
+
+// TriggerHandler returns a handler that starts a round of profile collection
+// in local capture mode when it receives a POST request. It responds with
+// status 202 (Accepted) without waiting for the profiles, and with status 503
+// (Service Unavailable) if local capture mode is not running.
+func TriggerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		triggerMu.Lock()
+		c := trigger
+		triggerMu.Unlock()
+		if c == nil {
+			http.Error(w, "local profile capture is not running", http.StatusServiceUnavailable)
+			return
+		}
+		select {
+		case c <- struct{}{}:
+		default:
+			// A round is already pending.
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// pollLocal runs rounds of profile collection in local capture mode until ctx
+// is done.
+func pollLocal(ctx context.Context, a *agent, lc *LocalConfig, w localWriter) {
+	c := make(chan struct{}, 1)
+	triggerMu.Lock()
+	trigger = c
+	triggerMu.Unlock()
+	defer func() {
+		triggerMu.Lock()
+		trigger = nil
+		triggerMu.Unlock()
+	}()
+
+	var tick <-chan time.Time
+	if lc.Interval > 0 {
+		t := time.NewTicker(lc.Interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	sigs := make(chan os.Signal, 1)
+	if len(lc.Signals) > 0 {
+		signal.Notify(sigs, lc.Signals...)
+		defer signal.Stop(sigs)
+	}
+
+	debugLog("profiler has started in local capture mode")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-sigs:
+		case <-c:
+		}
+		a.profileLocally(ctx, lc, w)
+	}
+}
+
+// profileLocally collects one profile of each enabled type and writes it
+// with w.
+func (a *agent) profileLocally(ctx context.Context, lc *LocalConfig, w localWriter) {
+	labels := a.localLabels()
+	for _, pt := range a.profileTypes {
+		prof, err := collectProfile(ctx, pt, lc.Duration)
+		if err != nil {
+			debugLog("failed to collect %v profile: %v", pt, err)
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		data, err := labelProfile(prof, labels)
+		if err != nil {
+			debugLog("failed to label %v profile: %v", pt, err)
+			continue
+		}
+		name := localProfileName(lc.Prefix, labels, pt, time.Now())
+		debugLog("start writing profile %s", name)
+		if err := w.writeProfile(ctx, name, labels, data); err != nil {
+			debugLog("failed to write profile %s: %v", name, err)
+		}
+	}
+}
+
+// localLabels returns the deployment and profile labels of the agent, with the
+// service name and project ID.
+func (a *agent) localLabels() map[string]string {
+	labels := map[string]string{serviceLabel: a.deployment.Target}
+	if a.deployment.ProjectId != "" {
+		labels[projectLabel] = a.deployment.ProjectId
+	}
+	for k, v := range a.deployment.Labels {
+		labels[k] = v
+	}
+	for k, v := range a.profileLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// labelProfile adds the labels to the comments of a serialized profile.
+func labelProfile(prof []byte, labels map[string]string) ([]byte, error) {
+	p, err := profile.Parse(bytes.NewReader(prof))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p.Comments = append(p.Comments, k+"="+labels[k])
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// localProfileName returns the name of the file or object for a profile, of
+// the form "<prefix><service>[-<version>][-<instance>]-<type>-<time>.pb.gz".
+func localProfileName(prefix string, labels map[string]string, pt pb.ProfileType, t time.Time) string {
+	parts := []string{labels[serviceLabel]}
+	for _, k := range []string{versionLabel, instanceLabel} {
+		if v := labels[k]; v != "" {
+			parts = append(parts, strings.Replace(v, "/", "_", -1))
+		}
+	}
+	parts = append(parts, strings.ToLower(pt.String()), t.UTC().Format("20060102T150405.000Z"))
+	return fmt.Sprintf("%s%s.pb.gz", prefix, strings.Join(parts, "-"))
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/pprof/profile"
+	"golang.org/x/net/context"
+	pb "google.golang.org/genproto/googleapis/devtools/cloudprofiler/v2"
+)
+
+func TestInitializeLocalConfig(t *testing.T) {
+	oldConfig, oldOnGCE := config, onGCE
+	defer func() {
+		config, onGCE = oldConfig, oldOnGCE
+	}()
+	onGCE = func() bool { return false }
+
+	for _, tt := range []struct {
+		desc            string
+		local           LocalConfig
+		wantLocal       LocalConfig
+		wantErrorString string
+	}{
+		{
+			desc:      "sets defaults",
+			local:     LocalConfig{Dir: "profiles"},
+			wantLocal: LocalConfig{Dir: "profiles", Interval: defaultLocalInterval, Duration: defaultLocalDuration},
+		},
+		{
+			desc:      "keeps negative interval",
+			local:     LocalConfig{Dir: "profiles", Interval: -1, Duration: time.Second},
+			wantLocal: LocalConfig{Dir: "profiles", Interval: -1, Duration: time.Second},
+		},
+		{
+			desc:            "requires a destination",
+			local:           LocalConfig{},
+			wantErrorString: "exactly one of Dir and Bucket must be set",
+		},
+		{
+			desc:            "rejects two destinations",
+			local:           LocalConfig{Dir: "profiles", Bucket: &storage.BucketHandle{}},
+			wantErrorString: "exactly one of Dir and Bucket must be set",
+		},
+	} {
+		// No project ID is needed outside of GCP.
+		cfg := Config{Service: testService, Local: &tt.local}
+		err := initializeConfig(cfg)
+		if tt.wantErrorString != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrorString) {
+				t.Errorf("%s: got error %v, want error containing %q", tt.desc, err, tt.wantErrorString)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: got error %v", tt.desc, err)
+			continue
+		}
+		if config.Local == &tt.local {
+			t.Errorf("%s: initializeConfig did not copy the local configuration", tt.desc)
+		}
+		if !reflect.DeepEqual(*config.Local, tt.wantLocal) {
+			t.Errorf("%s: got %+v, want %+v", tt.desc, *config.Local, tt.wantLocal)
+		}
+	}
+}
+
+func TestLocalProfileName(t *testing.T) {
+	ts := time.Date(2018, 6, 1, 12, 30, 45, 123456789, time.UTC)
+	for _, tt := range []struct {
+		prefix string
+		labels map[string]string
+		pt     pb.ProfileType
+		want   string
+	}{
+		{
+			labels: map[string]string{serviceLabel: testService},
+			pt:     pb.ProfileType_CPU,
+			want:   testService + "-cpu-20180601T123045.123Z.pb.gz",
+		},
+		{
+			prefix: "profiles/",
+			labels: map[string]string{serviceLabel: testService, versionLabel: "v1/beta", instanceLabel: testInstance, zoneNameLabel: testZone},
+			pt:     pb.ProfileType_HEAP_ALLOC,
+			want:   "profiles/" + testService + "-v1_beta-" + testInstance + "-heap_alloc-20180601T123045.123Z.pb.gz",
+		},
+	} {
+		if got := localProfileName(tt.prefix, tt.labels, tt.pt, ts); got != tt.want {
+			t.Errorf("localProfileName(%q, %v, %v) = %q, want %q", tt.prefix, tt.labels, tt.pt, got, tt.want)
+		}
+	}
+}
+
+type fakeLocalWriter struct {
+	names chan string
+	data  map[string][]byte
+}
+
+func (w *fakeLocalWriter) writeProfile(_ context.Context, name string, labels map[string]string, data []byte) error {
+	w.data[name] = data
+	w.names <- name
+	return nil
+}
+
+func TestPollLocal(t *testing.T) {
+	a := createTestAgent(nil)
+	lc := &LocalConfig{Interval: -1, Duration: 10 * time.Millisecond}
+	w := &fakeLocalWriter{names: make(chan string, len(a.profileTypes)), data: map[string][]byte{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		pollLocal(ctx, a, lc, w)
+		close(done)
+	}()
+
+	srv := httptest.NewServer(TriggerHandler())
+	defer srv.Close()
+	// Wait until pollLocal has registered the trigger.
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = http.Post(srv.URL, "text/plain", nil); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			break
+		}
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	var names []string
+	for range a.profileTypes {
+		select {
+		case name := <-w.names:
+			names = append(names, name)
+		case <-time.After(testServerTimeout):
+			t.Fatalf("timed out waiting for profiles, got %v", names)
+		}
+	}
+	cancel()
+	<-done
+
+	sort.Strings(names)
+	nameRE := regexp.MustCompile(fmt.Sprintf(`^%s-%s-%s-(cpu|heap|threads)-\d{8}T\d{6}\.\d{3}Z\.pb\.gz$`, testService, testSvcVersion, testInstance))
+	wantComments := []string{
+		instanceLabel + "=" + testInstance,
+		projectLabel + "=" + testProjectID,
+		serviceLabel + "=" + testService,
+		versionLabel + "=" + testSvcVersion,
+		zoneNameLabel + "=" + testZone,
+	}
+	for _, name := range names {
+		if !nameRE.MatchString(name) {
+			t.Errorf("got profile name %q, want match for %s", name, nameRE)
+		}
+		p, err := profile.ParseData(w.data[name])
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(p.Comments, wantComments) {
+			t.Errorf("%s: got comments %v, want %v", name, p.Comments, wantComments)
+		}
+	}
+
+	// The trigger is unregistered when pollLocal returns.
+	resp, err = http.Post(srv.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("after stop: got status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestDirWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := newLocalWriter(&LocalConfig{Dir: filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.writeProfile(context.Background(), "sub/p.pb.gz", nil, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dir, "out", "sub", "p.pb.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "data" {
+		t.Errorf("got %q, want %q", got, "data")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "sub", "p.pb.gz.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary file was not removed: %v", err)
+	}
+}
@@ -27,6 +27,19 @@
 // Calling Start will start a goroutine to collect profiles and upload to
 // the profiler server, at the rhythm specified by the server.
 //
+// Alternatively, profiles can be captured locally, on a fixed schedule or when
+// triggered by a signal or an HTTP request, and written to a directory or a
+// Cloud Storage bucket:
+//
+//   err := profiler.Start(profiler.Config{
+//       Service: "my-service",
+//       Local: &profiler.LocalConfig{
+//           Dir:     "/var/tmp/profiles",
+//           Signals: []os.Signal{syscall.SIGUSR1},
+//       },
+//   })
+//   http.Handle("/debug/profiler/trigger", profiler.TriggerHandler())
+//
 // The caller must provide the service string in the config, and may provide
 // other information as well. See Config for details.
 //
@@ -156,6 +169,12 @@ type Config struct {
 	// for testing.
 	APIAddr string
 
+	// Local, if not nil, enables local capture mode: profiles are collected
+	// on a local schedule or when triggered, and written to a directory or a
+	// Cloud Storage bucket instead of being uploaded to the profiler
+	// service. See LocalConfig for details.
+	Local *LocalConfig
+
 	instance string
 	zone     string
 }
@@ -188,6 +207,16 @@ func start(cfg Config, options ...option.ClientOption) error {
 
 	ctx := context.Background()
 
+	if config.Local != nil {
+		w, err := newLocalWriter(config.Local)
+		if err != nil {
+			debugLog("failed to initialize local capture: %v", err)
+			return err
+		}
+		go pollLocal(ctx, initializeAgent(nil), config.Local, w)
+		return nil
+	}
+
 	opts := []option.ClientOption{
 		option.WithEndpoint(config.APIAddr),
 		option.WithScopes(scope),
@@ -294,55 +323,62 @@ func (a *agent) createProfile(ctx context.Context) *pb.Profile {
 }
 
 func (a *agent) profileAndUpload(ctx context.Context, p *pb.Profile) {
-	var prof bytes.Buffer
 	pt := p.GetProfileType()
+	var duration time.Duration
+	if pt == pb.ProfileType_CPU || pt == pb.ProfileType_HEAP_ALLOC || pt == pb.ProfileType_CONTENTION {
+		var err error
+		if duration, err = ptypes.Duration(p.Duration); err != nil {
+			debugLog("failed to get profile duration for %v profile: %v", pt, err)
+			return
+		}
+	}
+	prof, err := collectProfile(ctx, pt, duration)
+	if err != nil {
+		debugLog("failed to collect %v profile: %v", pt, err)
+		return
+	}
+
+	p.ProfileBytes = prof
+	p.Labels = a.profileLabels
+	req := pb.UpdateProfileRequest{Profile: p}
+
+	// Upload profile, discard profile in case of error.
+	debugLog("start uploading profile")
+	if _, err := a.client.UpdateProfile(ctx, &req); err != nil {
+		debugLog("failed to upload profile: %v", err)
+	}
+}
+
+// collectProfile collects a profile of type pt. CPU, allocation and contention
+// profiles are collected over the given duration.
+func collectProfile(ctx context.Context, pt pb.ProfileType, duration time.Duration) ([]byte, error) {
+	var prof bytes.Buffer
 
 	switch pt {
 	case pb.ProfileType_CPU:
-		duration, err := ptypes.Duration(p.Duration)
-		if err != nil {
-			debugLog("failed to get profile duration for CPU profile: %v", err)
-			return
-		}
 		if err := startCPUProfile(&prof); err != nil {
-			debugLog("failed to start CPU profile: %v", err)
-			return
+			return nil, fmt.Errorf("failed to start CPU profile: %v", err)
 		}
 		sleep(ctx, duration)
 		stopCPUProfile()
 	case pb.ProfileType_HEAP:
 		if err := heapProfile(&prof); err != nil {
-			debugLog("failed to write heap profile: %v", err)
-			return
+			return nil, fmt.Errorf("failed to write heap profile: %v", err)
 		}
 	case pb.ProfileType_HEAP_ALLOC:
-		duration, err := ptypes.Duration(p.Duration)
-		if err != nil {
-			debugLog("failed to get profile duration for allocation profile: %v", err)
-			return
-		}
 		if err := deltaAllocProfile(ctx, duration, config.AllocForceGC, &prof); err != nil {
-			debugLog("failed to collect allocation profile: %v", err)
-			return
+			return nil, fmt.Errorf("failed to collect allocation profile: %v", err)
 		}
 	case pb.ProfileType_THREADS:
 		if err := pprof.Lookup("goroutine").WriteTo(&prof, 0); err != nil {
-			debugLog("failed to collect goroutine profile: %v", err)
-			return
+			return nil, fmt.Errorf("failed to collect goroutine profile: %v", err)
 		}
 	case pb.ProfileType_CONTENTION:
-		duration, err := ptypes.Duration(p.Duration)
-		if err != nil {
-			debugLog("failed to get profile duration: %v", err)
-			return
-		}
 		if err := deltaMutexProfile(ctx, duration, &prof); err != nil {
-			debugLog("failed to collect mutex profile: %v", err)
-			return
+			return nil, fmt.Errorf("failed to collect mutex profile: %v", err)
 		}
 	default:
-		debugLog("unexpected profile type: %v", pt)
-		return
+		return nil, fmt.Errorf("unexpected profile type: %v", pt)
 	}
 
 	// Starting Go 1.9 the profiles are symbolized by runtime/pprof.
@@ -353,16 +389,7 @@ func (a *agent) profileAndUpload(ctx context.Context, p *pb.Profile) {
 			debugLog("failed to symbolize profile: %v", err)
 		}
 	}
-
-	p.ProfileBytes = prof.Bytes()
-	p.Labels = a.profileLabels
-	req := pb.UpdateProfileRequest{Profile: p}
-
-	// Upload profile, discard profile in case of error.
-	debugLog("start uploading profile")
-	if _, err := a.client.UpdateProfile(ctx, &req); err != nil {
-		debugLog("failed to upload profile: %v", err)
-	}
+	return prof.Bytes(), nil
 }
 
 // deltaMutexProfile writes mutex profile changes over a time period specified
@@ -506,11 +533,19 @@ func initializeConfig(cfg Config) error {
 		}
 
 	} else {
-		if config.ProjectID == "" {
+		if config.ProjectID == "" && config.Local == nil {
 			return fmt.Errorf("project ID must be specified in the configuration if running outside of GCP")
 		}
 	}
 
+	if config.Local != nil {
+		lc := *config.Local
+		if err := initializeLocalConfig(&lc); err != nil {
+			return err
+		}
+		config.Local = &lc
+	}
+
 	if config.APIAddr == "" {
 		config.APIAddr = apiAddress
 	}
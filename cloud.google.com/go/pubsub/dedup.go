@@ -0,0 +1,171 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultDedupTTL is the time for which a DedupStore remembers a message key if
+// DedupSettings.TTL is zero.
+const DefaultDedupTTL = 10 * time.Minute
+
+// DefaultDedupInProgressTTL is the time for which a DedupStore holds the key
+// of a message that is being processed if DedupSettings.InProgressTTL is zero.
+// It is the default ack deadline of a subscription.
+const DefaultDedupInProgressTTL = 10 * time.Second
+
+// DedupSettings configure the deduplication of messages by Receive.
+//
+// Before calling its callback for a message, Receive adds the message's key to
+// the Store as in progress for InProgressTTL. When the callback acks the
+// message, the key is marked done and kept for TTL; when it nacks the message,
+// the key is removed so that the redelivered message is processed. If the
+// message is neither acked nor nacked, for example because the process
+// crashed, the key expires after InProgressTTL, so that the redelivered
+// message is processed too.
+//
+// If the key is already there, the message is a duplicate and the callback is
+// not called. A duplicate of a message that is done is acked. A duplicate of a
+// message that is still in progress is nacked, as the message may yet be
+// nacked itself; it is redelivered, and then skipped or processed depending on
+// the outcome.
+//
+// If the Store returns an error when adding a key, the message is processed
+// anyway, so a failing Store weakens deduplication but does not stop delivery.
+type DedupSettings struct {
+	// Store records the keys of processed messages. It must be non-nil.
+	Store DedupStore
+
+	// Attribute is the name of the message attribute holding the key. If it is
+	// empty, the message ID is the key; this detects messages redelivered by
+	// the service. Setting it detects messages that were published more than
+	// once. Messages without the attribute are not deduplicated.
+	Attribute string
+
+	// TTL is how long the key of an acked message is remembered. If zero,
+	// DefaultDedupTTL is used.
+	TTL time.Duration
+
+	// InProgressTTL is how long the key of a message is held while the
+	// message is being processed. It should be about the ack deadline of the
+	// subscription, after which an abandoned message is redelivered. If zero,
+	// DefaultDedupInProgressTTL is used.
+	InProgressTTL time.Duration
+}
+
+// A DedupStore records the keys of processed messages. Its methods must be safe
+// for concurrent use.
+//
+// A store shared by several processes can be built on a key-value database.
+// For example, with Redis, Add is "SET key 0 NX PX ttl" followed by "GET key"
+// if the key was not set, MarkDone is "SET key 1 PX ttl" and Remove is
+// "DEL key".
+type DedupStore interface {
+	// Add records key as in progress for the duration ttl. It reports
+	// whether key was added, which is false if key was already recorded and
+	// has not expired. In that case, done reports whether the recorded key
+	// was marked done.
+	Add(ctx context.Context, key string, ttl time.Duration) (added, done bool, err error)
+
+	// MarkDone records key as done for the duration ttl, replacing the
+	// record of a key that is already recorded.
+	MarkDone(ctx context.Context, key string, ttl time.Duration) error
+
+	// Remove forgets key.
+	Remove(ctx context.Context, key string) error
+}
+
+// NewMemoryDedupStore returns a DedupStore that keeps up to size keys in
+// memory. When it is full, adding a key evicts the least recently added one.
+func NewMemoryDedupStore(size int) DedupStore {
+	if size < 1 {
+		size = 1
+	}
+	return &memoryDedupStore{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+		now:   time.Now,
+	}
+}
+
+type memoryDedupStore struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List // of *dedupEntry, most recently added first
+	items map[string]*list.Element
+	now   func() time.Time // for testing
+}
+
+type dedupEntry struct {
+	key     string
+	done    bool
+	expires time.Time
+}
+
+func (s *memoryDedupStore) Add(_ context.Context, key string, ttl time.Duration) (added, done bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		if de := e.Value.(*dedupEntry); s.now().Before(de.expires) {
+			return false, de.done, nil
+		}
+	}
+	s.setLocked(key, false, ttl)
+	return true, false, nil
+}
+
+func (s *memoryDedupStore) MarkDone(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(key, true, ttl)
+	return nil
+}
+
+// setLocked records key for ttl, evicting the least recently added key if the
+// store is full.
+//
+// Must be called with s.mu held.
+func (s *memoryDedupStore) setLocked(key string, done bool, ttl time.Duration) {
+	expires := s.now().Add(ttl)
+	if e, ok := s.items[key]; ok {
+		de := e.Value.(*dedupEntry)
+		de.done = done
+		de.expires = expires
+		s.ll.MoveToFront(e)
+		return
+	}
+	s.items[key] = s.ll.PushFront(&dedupEntry{key: key, done: done, expires: expires})
+	if s.ll.Len() > s.size {
+		e := s.ll.Back()
+		s.ll.Remove(e)
+		delete(s.items, e.Value.(*dedupEntry).key)
+	}
+}
+
+func (s *memoryDedupStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.ll.Remove(e)
+		delete(s.items, key)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+	"golang.org/x/net/context"
+)
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryDedupStore(2).(*memoryDedupStore)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	add := func(key string, wantAdded, wantDone bool) {
+		added, done, err := s.Add(ctx, key, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != wantAdded || done != wantDone {
+			t.Errorf("Add(%q) = %t, %t, want %t, %t", key, added, done, wantAdded, wantDone)
+		}
+	}
+	add("a", true, false)
+	add("a", false, false)
+	add("b", true, false)
+	// Adding a third key evicts the oldest, "a".
+	add("c", true, false)
+	add("a", true, false)
+	add("c", false, false)
+	if err := s.Remove(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	add("c", true, false)
+	// Keys expire after their TTL.
+	now = now.Add(2 * time.Minute)
+	add("a", true, false)
+	// MarkDone marks a key done and extends its TTL.
+	if err := s.MarkDone(ctx, "a", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	add("a", false, true)
+	// MarkDone records a missing key too.
+	if err := s.MarkDone(ctx, "d", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	add("d", false, true)
+}
+
+type failingDedupStore struct{}
+
+func (failingDedupStore) Add(context.Context, string, time.Duration) (bool, bool, error) {
+	return false, false, errors.New("unavailable")
+}
+
+func (failingDedupStore) MarkDone(context.Context, string, time.Duration) error {
+	return errors.New("unavailable")
+}
+
+func (failingDedupStore) Remove(context.Context, string) error { return errors.New("unavailable") }
+
+func TestReceiveFilterAndDedup(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newFake(t)
+	defer client.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, attrs := range []map[string]string{
+		{"id": "1", "type": "uplink"},
+		{"id": "1", "type": "uplink"},
+		{"id": "2", "type": "downlink"},
+		{"id": "3", "type": "uplink"},
+		{"type": "uplink"},
+	} {
+		ids = append(ids, srv.Publish(topic.name, nil, attrs))
+	}
+	sub.ReceiveSettings.Filter, err = ParseFilter(`attributes.type = "uplink"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.ReceiveSettings.Dedup = &DedupSettings{Store: NewMemoryDedupStore(10), Attribute: "id"}
+
+	cctx, cancel := context.WithCancel(ctx)
+	go func() {
+		// Stop receiving once every message has been acked.
+		defer cancel()
+		for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+			done := true
+			for _, id := range ids {
+				if srv.Message(id).Acks == 0 {
+					done = false
+				}
+			}
+			if done {
+				return
+			}
+		}
+	}()
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	nacked := false
+	err = sub.Receive(cctx, func(_ context.Context, m *Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, m.Attributes["id"])
+		// Nack the first delivery of "3". Its key must be forgotten, so the
+		// redelivery is processed.
+		if m.Attributes["id"] == "3" && !nacked {
+			nacked = true
+			m.Nack()
+			return
+		}
+		m.Ack()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, id := range got {
+		counts[id]++
+	}
+	if want := map[string]int{"1": 1, "3": 2, "": 1}; !testutil.Equal(counts, want) {
+		t.Errorf("callback calls: got %v, want %v", counts, want)
+	}
+	for _, id := range ids {
+		if srv.Message(id).Acks != 1 {
+			t.Errorf("message %s: got %d acks, want 1", id, srv.Message(id).Acks)
+		}
+	}
+}
+
+func TestReceiveDedupStoreError(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newFake(t)
+	defer client.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		srv.Publish(topic.name, []byte{byte(i)}, map[string]string{"id": "1"})
+	}
+	sub.ReceiveSettings.Dedup = &DedupSettings{Store: failingDedupStore{}, Attribute: "id"}
+	// Messages are processed even though the store fails.
+	msgs, err := pullN(ctx, sub, 2, func(_ context.Context, m *Message) { m.Ack() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("got %d messages, want 2", len(msgs))
+	}
+}
+
+func TestReceiveDedupAbandoned(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryDedupStore(10).(*memoryDedupStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	sub := &Subscription{name: "projects/p/subscriptions/s"}
+	sub.ReceiveSettings.Dedup = &DedupSettings{Store: store, TTL: time.Minute, InProgressTTL: 10 * time.Second}
+
+	calls := 0
+	var last *Message
+	f := sub.receiveCallback(ctx, func(_ context.Context, m *Message) {
+		calls++
+		last = m
+	})
+	var acks []bool
+	deliver := func() {
+		f(ctx, &Message{ID: "1", doneFunc: func(_ string, ack bool, _ time.Time) {
+			acks = append(acks, ack)
+		}})
+	}
+
+	// The first attempt is abandoned, for example because the process
+	// crashed, and the message is redelivered after the ack deadline.
+	deliver()
+	now = now.Add(11 * time.Second)
+	deliver()
+	if calls != 2 {
+		t.Fatalf("after redelivery of an abandoned message: got %d calls, want 2", calls)
+	}
+	last.Ack()
+
+	// Once acked, the key is remembered for the TTL.
+	now = now.Add(30 * time.Second)
+	deliver()
+	if calls != 2 {
+		t.Errorf("after ack: got %d calls, want 2", calls)
+	}
+	if want := []bool{true, true}; !testutil.Equal(acks, want) {
+		t.Errorf("got acks %v, want %v", acks, want)
+	}
+
+	// After the TTL, the message is processed again.
+	now = now.Add(time.Minute)
+	deliver()
+	if calls != 3 {
+		t.Errorf("after TTL: got %d calls, want 3", calls)
+	}
+}
+
+func TestReceiveDedupDuplicateInProgress(t *testing.T) {
+	ctx := context.Background()
+	sub := &Subscription{name: "projects/p/subscriptions/s"}
+	sub.ReceiveSettings.Dedup = &DedupSettings{Store: NewMemoryDedupStore(10)}
+
+	var held []*Message
+	f := sub.receiveCallback(ctx, func(_ context.Context, m *Message) {
+		held = append(held, m)
+	})
+	var acks []bool
+	deliver := func() {
+		f(ctx, &Message{ID: "1", doneFunc: func(_ string, ack bool, _ time.Time) {
+			acks = append(acks, ack)
+		}})
+	}
+
+	// A duplicate that arrives while the first copy is in progress is
+	// nacked, not acked, and the callback is not called.
+	deliver()
+	deliver()
+	if len(held) != 1 {
+		t.Fatalf("duplicate in progress: got %d calls, want 1", len(held))
+	}
+	if want := []bool{false}; !testutil.Equal(acks, want) {
+		t.Fatalf("duplicate in progress: got acks %v, want %v", acks, want)
+	}
+
+	// The first copy is nacked, so the redelivered message is processed.
+	held[0].Nack()
+	deliver()
+	if len(held) != 2 {
+		t.Fatalf("after nack: got %d calls, want 2", len(held))
+	}
+
+	// Once the message is acked, a duplicate is acked.
+	held[1].Ack()
+	deliver()
+	if len(held) != 2 {
+		t.Errorf("after ack: got %d calls, want 2", len(held))
+	}
+	if want := []bool{false, false, true, true}; !testutil.Equal(acks, want) {
+		t.Errorf("got acks %v, want %v", acks, want)
+	}
+}
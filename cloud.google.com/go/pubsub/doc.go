@@ -86,6 +86,44 @@ may be surprising. Please take a look at https://cloud.google.com/pubsub/docs/pu
 for more details on how streaming pull behaves compared to the synchronous
 pull method.
 
+Filtering and Deduplication
+
+Receive can skip messages before they reach the callback. Set
+ReceiveSettings.Filter to a Filter made by ParseFilter to process only messages
+whose attributes match it, and ReceiveSettings.Dedup to skip messages whose ID,
+or the value of a chosen attribute, was processed recently:
+
+ sub.ReceiveSettings.Filter, err = pubsub.ParseFilter(`attributes.type = "uplink"`)
+ sub.ReceiveSettings.Dedup = &pubsub.DedupSettings{
+	Store:     pubsub.NewMemoryDedupStore(100000),
+	Attribute: "event-id",
+ }
+
+Skipped messages are acked, and counted by the FilteredCount and DuplicateCount
+measures. A message's key is remembered for DedupSettings.TTL once the message
+is acked; until then it is held only for DedupSettings.InProgressTTL, so that a
+message whose processing was abandoned is processed again when it is
+redelivered. A duplicate that arrives while the first copy is still being
+processed is nacked rather than acked, since the first copy may yet be nacked.
+To deduplicate across processes, implement DedupStore with a shared database.
+
+Ordering
+
+Messages with the same ordering key can be delivered in the order they were
+published. To publish them, set EnableMessageOrdering on the topic and
+OrderingKey on each message:
+
+ topic.EnableMessageOrdering = true
+ res := topic.Publish(ctx, &pubsub.Message{Data: []byte("payload"), OrderingKey: "device-1"})
+
+Messages with the same key are sent one batch at a time. If a batch fails, later
+messages with that key fail with ErrPublishingPaused until Topic.ResumePublish
+is called for the key.
+
+To receive them in order, create the subscription with EnableMessageOrdering
+set in its SubscriptionConfig. Receive calls its callback for a message only
+after the callback for the previous message with the same key has returned.
+
 Deadlines
 
 The default pubsub deadlines are suitable for most use cases, but may be
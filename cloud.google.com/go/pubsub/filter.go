@@ -0,0 +1,330 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Filter selects messages by their attributes. See ParseFilter for the
+// syntax of filter expressions.
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// ParseFilter parses a filter expression. An expression is made of the
+// following conditions, where key is an attribute name and value is a
+// double-quoted string:
+//
+//   attributes:key                        the message has the attribute
+//   attributes.key = "value"              the attribute has the value
+//   attributes.key != "value"             the attribute is missing or has another value
+//   hasPrefix(attributes.key, "prefix")   the attribute value starts with prefix
+//
+// Conditions can be combined with NOT, AND and OR, in decreasing order of
+// precedence, and grouped with parentheses. For example:
+//
+//   attributes.type = "uplink" AND NOT (attributes:test OR hasPrefix(attributes.dev, "sim-"))
+//
+// An attribute name that is not made of letters, digits, '_' and '-' must be
+// double-quoted, as in attributes."my key".
+func ParseFilter(expr string) (*Filter, error) {
+	p := &filterParser{expr: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.toks) == 0 {
+		return nil, fmt.Errorf("pubsub: empty filter expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match reports whether a message with the given attributes satisfies f.
+func (f *Filter) Match(attrs map[string]string) bool {
+	return f.root.match(attrs)
+}
+
+// String returns the expression f was parsed from.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+type filterNode interface {
+	match(attrs map[string]string) bool
+}
+
+type (
+	andNode    struct{ left, right filterNode }
+	orNode     struct{ left, right filterNode }
+	notNode    struct{ node filterNode }
+	hasNode    struct{ key string }
+	equalNode  struct{ key, value string }
+	prefixNode struct{ key, prefix string }
+)
+
+func (n andNode) match(a map[string]string) bool { return n.left.match(a) && n.right.match(a) }
+func (n orNode) match(a map[string]string) bool  { return n.left.match(a) || n.right.match(a) }
+func (n notNode) match(a map[string]string) bool { return !n.node.match(a) }
+
+func (n hasNode) match(a map[string]string) bool {
+	_, ok := a[n.key]
+	return ok
+}
+
+func (n equalNode) match(a map[string]string) bool {
+	v, ok := a[n.key]
+	return ok && v == n.value
+}
+
+func (n prefixNode) match(a map[string]string) bool {
+	v, ok := a[n.key]
+	return ok && strings.HasPrefix(v, n.prefix)
+}
+
+type tokenKind int
+
+const (
+	tokIdent  tokenKind = iota // attributes, AND, hasPrefix, attribute names
+	tokString                  // a double-quoted string, unquoted in text
+	tokPunct                   // ( ) , : . = !=
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the expression
+}
+
+type filterParser struct {
+	expr string
+	toks []filterToken
+	pos  int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	off := len(p.expr)
+	if p.pos < len(p.toks) {
+		off = p.toks[p.pos].pos
+	}
+	return fmt.Errorf("pubsub: bad filter %q at offset %d: %s", p.expr, off, fmt.Sprintf(format, args...))
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (p *filterParser) tokenize() error {
+	s := p.expr
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("(),:.=", c) >= 0:
+			p.toks = append(p.toks, filterToken{tokPunct, s[i : i+1], i})
+			i++
+		case c == '!' && i+1 < len(s) && s[i+1] == '=':
+			p.toks = append(p.toks, filterToken{tokPunct, "!=", i})
+			i += 2
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return fmt.Errorf("pubsub: bad filter %q: unterminated string at offset %d", p.expr, i)
+			}
+			str, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return fmt.Errorf("pubsub: bad filter %q: bad string at offset %d: %v", p.expr, i, err)
+			}
+			p.toks = append(p.toks, filterToken{tokString, str, i})
+			i = j + 1
+		default:
+			j := strings.IndexFunc(s[i:], func(r rune) bool { return !isIdentRune(r) })
+			if j == 0 {
+				return fmt.Errorf("pubsub: bad filter %q: unexpected %q at offset %d", p.expr, s[i], i)
+			}
+			if j < 0 {
+				j = len(s) - i
+			}
+			p.toks = append(p.toks, filterToken{tokIdent, s[i : i+j], i})
+			i += j
+		}
+	}
+	return nil
+}
+
+// peek reports whether the next token has the given kind and text.
+func (p *filterParser) peek(kind tokenKind, text string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == kind && p.toks[p.pos].text == text
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	if !p.peek(kind, text) {
+		if p.pos >= len(p.toks) {
+			return p.errorf("missing %q", text)
+		}
+		return p.errorf("got %q, want %q", p.toks[p.pos].text, text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek(tokIdent, "OR") {
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		n = orNode{n, r}
+	}
+	return n, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek(tokIdent, "AND") {
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		n = andNode{n, r}
+	}
+	return n, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch {
+	case p.peek(tokIdent, "NOT"):
+		p.pos++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case p.peek(tokPunct, "("):
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case p.peek(tokIdent, "hasPrefix"):
+		p.pos++
+		if err := p.expect(tokPunct, "("); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokIdent, "attributes"); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, "."); err != nil {
+			return nil, err
+		}
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ","); err != nil {
+			return nil, err
+		}
+		prefix, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		return prefixNode{key, prefix}, nil
+	case p.peek(tokIdent, "attributes"):
+		p.pos++
+		if p.peek(tokPunct, ":") {
+			p.pos++
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			return hasNode{key}, nil
+		}
+		if err := p.expect(tokPunct, "."); err != nil {
+			return nil, err
+		}
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		negate := p.peek(tokPunct, "!=")
+		if negate {
+			p.pos++
+		} else if err := p.expect(tokPunct, "="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			return notNode{equalNode{key, value}}, nil
+		}
+		return equalNode{key, value}, nil
+	case p.pos >= len(p.toks):
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q", p.toks[p.pos].text)
+	}
+}
+
+// parseKey parses an attribute name, which is an identifier or a string.
+func (p *filterParser) parseKey() (string, error) {
+	if p.pos < len(p.toks) && (p.toks[p.pos].kind == tokIdent || p.toks[p.pos].kind == tokString) {
+		p.pos++
+		return p.toks[p.pos-1].text, nil
+	}
+	return "", p.errorf("missing attribute name")
+}
+
+func (p *filterParser) parseString() (string, error) {
+	if p.pos < len(p.toks) && p.toks[p.pos].kind == tokString {
+		p.pos++
+		return p.toks[p.pos-1].text, nil
+	}
+	return "", p.errorf("missing quoted string")
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import "testing"
+
+func TestFilterMatch(t *testing.T) {
+	attrs := map[string]string{
+		"type":   "uplink",
+		"dev":    "sim-42",
+		"my key": "x",
+		"empty":  "",
+	}
+	for _, test := range []struct {
+		expr string
+		want bool
+	}{
+		{`attributes:type`, true},
+		{`attributes:missing`, false},
+		{`attributes:empty`, true},
+		{`attributes.type = "uplink"`, true},
+		{`attributes.type = "downlink"`, false},
+		{`attributes.missing = ""`, false},
+		{`attributes.type != "downlink"`, true},
+		{`attributes.missing != "x"`, true},
+		{`attributes."my key" = "x"`, true},
+		{`hasPrefix(attributes.dev, "sim-")`, true},
+		{`hasPrefix(attributes.missing, "")`, false},
+		{`NOT attributes:type`, false},
+		{`NOT NOT attributes:type`, true},
+		{`attributes:type AND attributes:missing`, false},
+		{`attributes:type OR attributes:missing`, true},
+		// AND binds more tightly than OR.
+		{`attributes:missing AND attributes:type OR attributes:dev`, true},
+		{`attributes:missing AND (attributes:type OR attributes:dev)`, false},
+		{`attributes.type = "uplink" AND NOT (attributes:test OR hasPrefix(attributes.dev, "sim-"))`, false},
+		{`attributes.type="uplink"AND attributes.dev="sim-42"`, true},
+	} {
+		f, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", test.expr, err)
+			continue
+		}
+		if got := f.Match(attrs); got != test.want {
+			t.Errorf("%q: got %t, want %t", test.expr, got, test.want)
+		}
+		if got := f.String(); got != test.expr {
+			t.Errorf("String() = %q, want %q", got, test.expr)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`   `,
+		`type = "uplink"`,
+		`attributes`,
+		`attributes.`,
+		`attributes.type`,
+		`attributes.type = uplink`,
+		`attributes.type == "uplink"`,
+		`attributes.type = "uplink`,
+		`attributes.type = "uplink" AND`,
+		`attributes:type attributes:dev`,
+		`(attributes:type`,
+		`attributes:type)`,
+		`hasPrefix(attributes.dev "sim-")`,
+		`hasPrefix(dev, "sim-")`,
+		`attributes:type and attributes:dev`,
+		`attributes:type & attributes:dev`,
+	} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q): got nil, want error", expr)
+		}
+	}
+}
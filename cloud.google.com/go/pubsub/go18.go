@@ -83,6 +83,21 @@ var (
 	// It is EXPERIMENTAL and subject to change or removal without notice.
 	StreamResponseCount = stats.Int64(statsPrefix+"stream_response_count", "Number of gRPC StreamingPull response messages received", stats.UnitDimensionless)
 
+	// FilteredCount is a measure of the number of messages acked by Receive
+	// because they did not match ReceiveSettings.Filter.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	FilteredCount = stats.Int64(statsPrefix+"filtered_count", "Number of PubSub messages skipped by a receive filter", stats.UnitDimensionless)
+
+	// DuplicateCount is a measure of the number of messages acked by Receive
+	// because ReceiveSettings.Dedup found them to be duplicates.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	DuplicateCount = stats.Int64(statsPrefix+"duplicate_count", "Number of duplicate PubSub messages skipped", stats.UnitDimensionless)
+
+	// DedupErrorCount is a measure of the number of errors returned by the
+	// DedupStore of ReceiveSettings.Dedup.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	DedupErrorCount = stats.Int64(statsPrefix+"dedup_error_count", "Number of deduplication store errors", stats.UnitDimensionless)
+
 	// PullCountView is a cumulative sum of PullCount.
 	// It is EXPERIMENTAL and subject to change or removal without notice.
 	PullCountView *view.View
@@ -118,6 +133,18 @@ var (
 	// StreamResponseCountView is a cumulative sum of StreamResponseCount.
 	// It is EXPERIMENTAL and subject to change or removal without notice.
 	StreamResponseCountView *view.View
+
+	// FilteredCountView is a cumulative sum of FilteredCount.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	FilteredCountView *view.View
+
+	// DuplicateCountView is a cumulative sum of DuplicateCount.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	DuplicateCountView *view.View
+
+	// DedupErrorCountView is a cumulative sum of DedupErrorCount.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	DedupErrorCountView *view.View
 )
 
 func init() {
@@ -130,6 +157,9 @@ func init() {
 	StreamRetryCountView = countView(StreamRetryCount)
 	StreamRequestCountView = countView(StreamRequestCount)
 	StreamResponseCountView = countView(StreamResponseCount)
+	FilteredCountView = countView(FilteredCount)
+	DuplicateCountView = countView(DuplicateCount)
+	DedupErrorCountView = countView(DedupErrorCount)
 }
 
 func countView(m *stats.Int64Measure) *view.View {
@@ -35,6 +35,13 @@ type Message struct {
 	// is labelled with.
 	Attributes map[string]string
 
+	// OrderingKey identifies related messages for which publish order should
+	// be respected. Messages with the same non-empty OrderingKey that are
+	// published to a Topic with EnableMessageOrdering set are delivered in the
+	// order they were published to subscriptions whose SubscriptionConfig has
+	// EnableMessageOrdering set.
+	OrderingKey string
+
 	// ackID is the identifier to acknowledge this message.
 	ackID string
 
@@ -70,6 +77,7 @@ func toMessage(resp *pb.ReceivedMessage) (*Message, error) {
 		Attributes:  resp.Message.Attributes,
 		ID:          resp.Message.MessageId,
 		PublishTime: pubTime,
+		OrderingKey: resp.Message.OrderingKey,
 	}, nil
 }
 
@@ -50,6 +50,12 @@ var (
 	StreamRequestCount dummy
 	// Not supported below Go 1.8.
 	StreamResponseCount dummy
+	// Not supported below Go 1.8.
+	FilteredCount dummy
+	// Not supported below Go 1.8.
+	DuplicateCount dummy
+	// Not supported below Go 1.8.
+	DedupErrorCount dummy
 )
 
 func recordStat(context.Context, dummy, int64) {
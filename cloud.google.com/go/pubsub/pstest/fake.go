@@ -17,16 +17,27 @@
 // differently from the actual service in ways in which the service is
 // non-deterministic or unspecified: timing, delivery order, etc.
 //
+// Messages are delivered to each subscription in the order they were published.
+// If a subscription has message ordering enabled, a message with an ordering key
+// is not delivered until all earlier messages with the same key have been
+// acked. The fake also supports snapshots, push subscriptions (which POST to an HTTP
+// endpoint), dead-letter topics (see Server.SetDeadLetterPolicy) and hooks for
+// injecting errors into publish and pull operations.
+//
 // This package is EXPERIMENTAL and is subject to change without notice.
 //
 // See the example for usage.
 package pstest
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -69,11 +80,14 @@ type gServer struct {
 	mu            sync.Mutex
 	topics        map[string]*topic
 	subs          map[string]*subscription
+	snaps         map[string]*snapshot
 	msgs          []*Message // all messages ever published
 	msgsByID      map[string]*Message
 	wg            sync.WaitGroup
 	nextID        int
 	streamTimeout time.Duration
+	publishErr    func(*pb.PublishRequest) error
+	pullErr       func(subscription string) error
 }
 
 // NewServer creates a new fake server running in the current process.
@@ -87,6 +101,7 @@ func NewServer() *Server {
 		gServer: gServer{
 			topics:   map[string]*topic{},
 			subs:     map[string]*subscription{},
+			snaps:    map[string]*snapshot{},
 			msgsByID: map[string]*Message{},
 		},
 	}
@@ -102,6 +117,11 @@ func NewServer() *Server {
 //
 // Publish panics if there is an error, which is appropriate for testing.
 func (s *Server) Publish(topic string, data []byte, attrs map[string]string) string {
+	return s.PublishOrdered(topic, data, attrs, "")
+}
+
+// PublishOrdered is like Publish, but the message has the given ordering key.
+func (s *Server) PublishOrdered(topic string, data []byte, attrs map[string]string, orderingKey string) string {
 	const topicPattern = "projects/*/topics/*"
 	ok, err := path.Match(topicPattern, topic)
 	if err != nil {
@@ -113,9 +133,12 @@ func (s *Server) Publish(topic string, data []byte, attrs map[string]string) str
 	_, _ = s.gServer.CreateTopic(nil, &pb.Topic{Name: topic})
 	req := &pb.PublishRequest{
 		Topic:    topic,
-		Messages: []*pb.PubsubMessage{{Data: data, Attributes: attrs}},
+		Messages: []*pb.PubsubMessage{{Data: data, Attributes: attrs, OrderingKey: orderingKey}},
 	}
-	res, err := s.gServer.Publish(nil, req)
+	// Bypass the publish error hook, which applies only to the Publish RPC.
+	s.gServer.mu.Lock()
+	res, err := s.gServer.publishMessages(req)
+	s.gServer.mu.Unlock()
 	if err != nil {
 		panic(fmt.Sprintf("pstest.Server.Publish: %v", err))
 	}
@@ -132,11 +155,75 @@ func (s *Server) SetStreamTimeout(d time.Duration) {
 	s.gServer.streamTimeout = d
 }
 
+// SetPublishErrorHook arranges for f to be called with each Publish RPC request
+// before it is processed. If f returns a non-nil error, the RPC fails with that
+// error and none of the request's messages are published. Use this to test how
+// publishers handle failures. Pass nil to remove the hook.
+//
+// f is called with the server's lock held, so it must not call methods of s.
+func (s *Server) SetPublishErrorHook(f func(*pb.PublishRequest) error) {
+	s.gServer.mu.Lock()
+	defer s.gServer.mu.Unlock()
+	s.gServer.publishErr = f
+}
+
+// SetPullErrorHook arranges for f to be called with the subscription name each
+// time a Pull RPC is about to return messages, when a StreamingPull RPC starts,
+// and before each message is sent on a stream. If f returns a non-nil error, the
+// RPC fails with that error. A message that was about to be sent on a stream is
+// made available for redelivery. Pass nil to remove the hook.
+//
+// f is called with the server's lock held, so it must not call methods of s.
+func (s *Server) SetPullErrorHook(f func(subscription string) error) {
+	s.gServer.mu.Lock()
+	defer s.gServer.mu.Unlock()
+	s.gServer.pullErr = f
+}
+
+// Attributes added to messages forwarded to a dead-letter topic.
+const (
+	deadLetterDeliveryCountAttr       = "CloudPubSubDeadLetterSourceDeliveryCount"
+	deadLetterSubscriptionAttr        = "CloudPubSubDeadLetterSourceSubscription"
+	deadLetterSubscriptionProjectAttr = "CloudPubSubDeadLetterSourceSubscriptionProject"
+	deadLetterTopicPublishTimeAttr    = "CloudPubSubDeadLetterSourceTopicPublishTime"
+)
+
+// SetDeadLetterPolicy makes the subscription sub forward a message to
+// deadLetterTopic, instead of delivering it again, once delivery of the message
+// to sub has been attempted maxDeliveryAttempts times. The message is then
+// removed from sub. As in the real service, the forwarded message carries
+// attributes describing where it came from, such as
+// CloudPubSubDeadLetterSourceDeliveryCount.
+//
+// A maxDeliveryAttempts of zero removes the policy.
+func (s *Server) SetDeadLetterPolicy(sub, deadLetterTopic string, maxDeliveryAttempts int) error {
+	s.gServer.mu.Lock()
+	defer s.gServer.mu.Unlock()
+
+	su, err := s.gServer.findSubscription(sub)
+	if err != nil {
+		return err
+	}
+	if maxDeliveryAttempts < 0 {
+		return status.Errorf(codes.InvalidArgument, "bad max delivery attempts: %d", maxDeliveryAttempts)
+	}
+	if maxDeliveryAttempts == 0 {
+		su.deadLetter = nil
+		return nil
+	}
+	if s.gServer.topics[deadLetterTopic] == nil {
+		return status.Errorf(codes.NotFound, "topic %q", deadLetterTopic)
+	}
+	su.deadLetter = &deadLetterPolicy{topic: deadLetterTopic, maxAttempts: maxDeliveryAttempts}
+	return nil
+}
+
 // A Message is a message that was published to the server.
 type Message struct {
 	ID          string
 	Data        []byte
 	Attributes  map[string]string
+	OrderingKey string
 	PublishTime time.Time
 	Deliveries  int // number of times delivery of the message was attempted
 	Acks        int // number of acks received from clients
@@ -146,6 +233,7 @@ type Message struct {
 	acks       int
 	Modacks    []Modack // modacks received by server for this message
 
+	seq int // position in publish order
 }
 
 type Modack struct {
@@ -273,6 +361,27 @@ func (s *gServer) ListTopicSubscriptions(_ context.Context, req *pb.ListTopicSub
 	}, nil
 }
 
+func (s *gServer) ListTopicSnapshots(_ context.Context, req *pb.ListTopicSnapshotsRequest) (*pb.ListTopicSnapshotsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name, snap := range s.snaps {
+		if snap.proto.Topic == req.Topic {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	from, to, nextToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(names))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListTopicSnapshotsResponse{
+		Snapshots:     names[from:to],
+		NextPageToken: nextToken,
+	}, nil
+}
+
 func (s *gServer) DeleteTopic(_ context.Context, req *pb.DeleteTopicRequest) (*emptypb.Empty, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -317,6 +426,7 @@ func (s *gServer) CreateSubscription(_ context.Context, ps *pb.Subscription) (*p
 	}
 
 	sub := newSubscription(top, &s.mu, ps)
+	sub.srv = s
 	top.subs[ps.Name] = sub
 	s.subs[ps.Name] = sub
 	sub.start(&s.wg)
@@ -401,6 +511,9 @@ func (s *gServer) UpdateSubscription(_ context.Context, req *pb.UpdateSubscripti
 
 		case "retain_acked_messages":
 			sub.proto.RetainAckedMessages = req.Subscription.RetainAckedMessages
+			if !sub.proto.RetainAckedMessages {
+				sub.acked = map[string]*message{}
+			}
 
 		case "message_retention_duration":
 			if err := checkMRD(req.Subscription.MessageRetentionDuration); err != nil {
@@ -457,6 +570,16 @@ func (s *gServer) Publish(_ context.Context, req *pb.PublishRequest) (*pb.Publis
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.publishErr != nil {
+		if err := s.publishErr(req); err != nil {
+			return nil, err
+		}
+	}
+	return s.publishMessages(req)
+}
+
+// Must be called with the lock held.
+func (s *gServer) publishMessages(req *pb.PublishRequest) (*pb.PublishResponse, error) {
 	if req.Topic == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "missing topic")
 	}
@@ -464,40 +587,62 @@ func (s *gServer) Publish(_ context.Context, req *pb.PublishRequest) (*pb.Publis
 	if top == nil {
 		return nil, status.Errorf(codes.NotFound, "topic %q", req.Topic)
 	}
+	for _, pm := range req.Messages[1:] {
+		if pm.OrderingKey != req.Messages[0].OrderingKey {
+			return nil, status.Errorf(codes.InvalidArgument, "all messages in a publish request must have the same ordering key")
+		}
+	}
 	var ids []string
 	for _, pm := range req.Messages {
-		id := fmt.Sprintf("m%d", s.nextID)
-		s.nextID++
-		pm.MessageId = id
-		pubTime := timeNow()
-		tsPubTime, err := ptypes.TimestampProto(pubTime)
+		id, err := s.publish(top, pm)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, err.Error())
+			return nil, err
 		}
-		pm.PublishTime = tsPubTime
-		m := &Message{
-			ID:          id,
-			Data:        pm.Data,
-			Attributes:  pm.Attributes,
-			PublishTime: pubTime,
-		}
-		top.publish(pm, m)
 		ids = append(ids, id)
-		s.msgs = append(s.msgs, m)
-		s.msgsByID[id] = m
 	}
 	return &pb.PublishResponse{MessageIds: ids}, nil
 }
 
+// publish assigns an ID and publish time to pm, and delivers it to top's
+// subscriptions. It returns the ID.
+//
+// Must be called with the lock held.
+func (s *gServer) publish(top *topic, pm *pb.PubsubMessage) (string, error) {
+	id := fmt.Sprintf("m%d", s.nextID)
+	seq := s.nextID
+	s.nextID++
+	pm.MessageId = id
+	pubTime := timeNow()
+	tsPubTime, err := ptypes.TimestampProto(pubTime)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "%v", err)
+	}
+	pm.PublishTime = tsPubTime
+	m := &Message{
+		ID:          id,
+		Data:        pm.Data,
+		Attributes:  pm.Attributes,
+		OrderingKey: pm.OrderingKey,
+		PublishTime: pubTime,
+		seq:         seq,
+	}
+	top.publish(pm, m)
+	s.msgs = append(s.msgs, m)
+	s.msgsByID[id] = m
+	return id, nil
+}
+
 type topic struct {
 	proto *pb.Topic
 	subs  map[string]*subscription
+	snaps map[string]*snapshot
 }
 
 func newTopic(pt *pb.Topic) *topic {
 	return &topic{
 		proto: pt,
 		subs:  map[string]*subscription{},
+		snaps: map[string]*snapshot{},
 	}
 }
 
@@ -506,6 +651,9 @@ func (t *topic) stop() {
 		sub.proto.Topic = "_deleted-topic_"
 		sub.stop()
 	}
+	for _, snap := range t.snaps {
+		snap.proto.Topic = "_deleted-topic_"
+	}
 }
 
 func (t *topic) deleteSub(sub *subscription) {
@@ -514,29 +662,32 @@ func (t *topic) deleteSub(sub *subscription) {
 
 func (t *topic) publish(pm *pb.PubsubMessage, m *Message) {
 	for _, s := range t.subs {
-		s.msgs[pm.MessageId] = &message{
-			publishTime: m.PublishTime,
-			proto: &pb.ReceivedMessage{
-				AckId:   pm.MessageId,
-				Message: pm,
-			},
-			deliveries:  &m.deliveries,
-			acks:        &m.acks,
-			streamIndex: -1,
-		}
+		s.msgs[pm.MessageId] = newMessage(pm, m)
+	}
+	// A snapshot holds messages published after its creation, too.
+	for _, snap := range t.snaps {
+		snap.msgs[pm.MessageId] = newMessage(pm, m)
 	}
 }
 
 type subscription struct {
 	topic      *topic
 	mu         *sync.Mutex // the server mutex, here for convenience
+	srv        *gServer
 	proto      *pb.Subscription
 	ackTimeout time.Duration
 	msgs       map[string]*message // unacked messages by message ID
+	acked      map[string]*message // acked messages kept for seeking, by message ID
+	deadLetter *deadLetterPolicy
 	streams    []*stream
 	done       chan struct{}
 }
 
+type deadLetterPolicy struct {
+	topic       string
+	maxAttempts int
+}
+
 func newSubscription(t *topic, mu *sync.Mutex, ps *pb.Subscription) *subscription {
 	at := time.Duration(ps.AckDeadlineSeconds) * time.Second
 	if at == 0 {
@@ -548,6 +699,7 @@ func newSubscription(t *topic, mu *sync.Mutex, ps *pb.Subscription) *subscriptio
 		proto:      ps,
 		ackTimeout: at,
 		msgs:       map[string]*message{},
+		acked:      map[string]*message{},
 		done:       make(chan struct{}),
 	}
 }
@@ -562,6 +714,7 @@ func (s *subscription) start(wg *sync.WaitGroup) {
 				return
 			case <-time.After(10 * time.Millisecond):
 				s.deliver()
+				s.push()
 			}
 		}
 	}()
@@ -618,6 +771,10 @@ func (s *gServer) Pull(ctx context.Context, req *pb.PullRequest) (*pb.PullRespon
 	if max == 0 { // MaxMessages not specified; use a default.
 		max = 1000
 	}
+	if err := s.pullError(req.Subscription); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
 	msgs := sub.pull(max)
 	s.mu.Unlock()
 	// Implement the spec from the pubsub proto:
@@ -648,6 +805,9 @@ func (s *gServer) StreamingPull(sps pb.Subscriber_StreamingPullServer) error {
 	}
 	s.mu.Lock()
 	sub, err := s.findSubscription(req.Subscription)
+	if err == nil {
+		err = s.pullError(req.Subscription)
+	}
 	s.mu.Unlock()
 	if err != nil {
 		return err
@@ -660,9 +820,10 @@ func (s *gServer) StreamingPull(sps pb.Subscriber_StreamingPullServer) error {
 }
 
 func (s *gServer) Seek(ctx context.Context, req *pb.SeekRequest) (*pb.SeekResponse, error) {
-	// Only handle time-based seeking for now.
-	// This fake doesn't deal with snapshots.
-	var target time.Time
+	var (
+		target   time.Time
+		snapName string
+	)
 	switch v := req.Target.(type) {
 	case nil:
 		return nil, status.Errorf(codes.InvalidArgument, "missing Seek target type")
@@ -672,6 +833,8 @@ func (s *gServer) Seek(ctx context.Context, req *pb.SeekRequest) (*pb.SeekRespon
 		if err != nil {
 			return nil, status.Errorf(codes.InvalidArgument, "bad Time target: %v", err)
 		}
+	case *pb.SeekRequest_Snapshot:
+		snapName = v.Snapshot
 	default:
 		return nil, status.Errorf(codes.Unimplemented, "unhandled Seek target type %T", v)
 	}
@@ -684,34 +847,188 @@ func (s *gServer) Seek(ctx context.Context, req *pb.SeekRequest) (*pb.SeekRespon
 	if err != nil {
 		return nil, err
 	}
-	// Drop all messages from sub that were published before the target time.
+	sub.maintainMessages(timeNow())
+	if _, ok := req.Target.(*pb.SeekRequest_Snapshot); ok {
+		snap, err := s.findSnapshot(snapName)
+		if err != nil {
+			return nil, err
+		}
+		if snap.topic != sub.topic {
+			return nil, status.Errorf(codes.InvalidArgument, "snapshot %q is for topic %q, but subscription %q is for topic %q",
+				snapName, snap.proto.Topic, sub.proto.Name, sub.proto.Topic)
+		}
+		// Ack every message not in the snapshot, and make the snapshot's
+		// messages available for delivery.
+		for id := range sub.msgs {
+			if snap.msgs[id] == nil {
+				sub.ack(id)
+			}
+		}
+		for id, m := range snap.msgs {
+			delete(sub.acked, id)
+			sub.msgs[id] = m.reset()
+		}
+		return &pb.SeekResponse{}, nil
+	}
+	// Ack all messages that were published before the target time, and make
+	// the rest available for delivery.
 	for id, m := range sub.msgs {
 		if m.publishTime.Before(target) {
-			delete(sub.msgs, id)
-			(*m.acks)++
+			sub.ack(id)
+		} else {
+			sub.msgs[id] = m.reset()
 		}
 	}
-	// Un-ack any already-acked messages after this time;
-	// redelivering them to the subscription is the closest analogue here.
-	for _, m := range s.msgs {
-		if m.PublishTime.Before(target) {
-			continue
-		}
-		sub.msgs[m.ID] = &message{
-			publishTime: m.PublishTime,
-			proto: &pb.ReceivedMessage{
-				AckId: m.ID,
-				// This was not preserved!
-				//Message: pm,
-			},
-			deliveries:  &m.deliveries,
-			acks:        &m.acks,
-			streamIndex: -1,
+	// Redeliver acked messages published at or after the target time. Only
+	// messages retained by the subscription can be redelivered.
+	for id, m := range sub.acked {
+		if !m.publishTime.Before(target) {
+			delete(sub.acked, id)
+			sub.msgs[id] = m.reset()
 		}
 	}
 	return &pb.SeekResponse{}, nil
 }
 
+type snapshot struct {
+	proto *pb.Snapshot
+	topic *topic
+	msgs  map[string]*message // unacked messages by message ID
+}
+
+func (s *gServer) CreateSnapshot(_ context.Context, req *pb.CreateSnapshotRequest) (*pb.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "missing name")
+	}
+	if s.snaps[req.Name] != nil {
+		return nil, status.Errorf(codes.AlreadyExists, "snapshot %q", req.Name)
+	}
+	sub, err := s.findSubscription(req.Subscription)
+	if err != nil {
+		return nil, err
+	}
+	if s.topics[sub.proto.Topic] != sub.topic {
+		return nil, status.Errorf(codes.FailedPrecondition, "topic of subscription %q was deleted", req.Subscription)
+	}
+	now := timeNow()
+	sub.maintainMessages(now)
+	snap := &snapshot{
+		proto: &pb.Snapshot{
+			Name:   req.Name,
+			Topic:  sub.proto.Topic,
+			Labels: req.Labels,
+		},
+		topic: sub.topic,
+		msgs:  map[string]*message{},
+	}
+	// The snapshot expires when its oldest unacked message would.
+	oldest := now
+	for id, m := range sub.msgs {
+		snap.msgs[id] = m.reset()
+		if m.publishTime.Before(oldest) {
+			oldest = m.publishTime
+		}
+	}
+	snap.proto.ExpireTime, err = ptypes.TimestampProto(oldest.Add(maxMessageRetentionDuration))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	s.snaps[req.Name] = snap
+	sub.topic.snaps[req.Name] = snap
+	return snap.proto, nil
+}
+
+func (s *gServer) GetSnapshot(_ context.Context, req *pb.GetSnapshotRequest) (*pb.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.findSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snap.proto, nil
+}
+
+func (s *gServer) UpdateSnapshot(_ context.Context, req *pb.UpdateSnapshotRequest) (*pb.Snapshot, error) {
+	if req.Snapshot == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "missing snapshot")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.findSnapshot(req.Snapshot.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case "labels":
+			snap.proto.Labels = req.Snapshot.Labels
+		case "expire_time":
+			snap.proto.ExpireTime = req.Snapshot.ExpireTime
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown field name %q", path)
+		}
+	}
+	return snap.proto, nil
+}
+
+func (s *gServer) ListSnapshots(_ context.Context, req *pb.ListSnapshotsRequest) (*pb.ListSnapshotsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name := range s.snaps {
+		if strings.HasPrefix(name, req.Project) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	from, to, nextToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(names))
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.ListSnapshotsResponse{NextPageToken: nextToken}
+	for i := from; i < to; i++ {
+		res.Snapshots = append(res.Snapshots, s.snaps[names[i]].proto)
+	}
+	return res, nil
+}
+
+func (s *gServer) DeleteSnapshot(_ context.Context, req *pb.DeleteSnapshotRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.findSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.snaps, req.Snapshot)
+	delete(snap.topic.snaps, req.Snapshot)
+	return &emptypb.Empty{}, nil
+}
+
+// Gets a snapshot that must exist.
+// Must be called with the lock held.
+func (s *gServer) findSnapshot(name string) (*snapshot, error) {
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "missing snapshot")
+	}
+	snap := s.snaps[name]
+	if snap == nil {
+		return nil, status.Errorf(codes.NotFound, "snapshot %s", name)
+	}
+	return snap, nil
+}
+
+// Must be called with the lock held.
+func (s *gServer) pullError(sub string) error {
+	if s.pullErr == nil {
+		return nil
+	}
+	return s.pullErr(sub)
+}
+
 // Gets a subscription that must exist.
 // Must be called with the lock held.
 func (s *gServer) findSubscription(name string) (*subscription, error) {
@@ -730,11 +1047,9 @@ func (s *subscription) pull(max int) []*pb.ReceivedMessage {
 	now := timeNow()
 	s.maintainMessages(now)
 	var msgs []*pb.ReceivedMessage
-	for _, m := range s.msgs {
-		if m.outstanding() {
-			continue
-		}
+	for _, m := range s.deliverableMsgs() {
 		(*m.deliveries)++
+		m.attempts++
 		m.ackDeadline = now.Add(s.ackTimeout)
 		msgs = append(msgs, m.proto)
 		if len(msgs) >= max {
@@ -748,14 +1063,14 @@ func (s *subscription) deliver() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.pushEndpoint() != "" {
+		return
+	}
 	now := timeNow()
 	s.maintainMessages(now)
 	// Try to deliver each remaining message.
 	curIndex := 0
-	for _, m := range s.msgs {
-		if m.outstanding() {
-			continue
-		}
+	for _, m := range s.deliverableMsgs() {
 		// If the message was never delivered before, start with the stream at
 		// curIndex. If it was delivered before, start with the stream after the one
 		// that owned it.
@@ -796,6 +1111,7 @@ func (s *subscription) tryDeliverMessage(m *message, start int, now time.Time) (
 
 		case st.msgc <- m.proto:
 			(*m.deliveries)++
+			m.attempts++
 			m.ackDeadline = now.Add(st.ackTimeout)
 			return idx, true
 
@@ -805,24 +1121,192 @@ func (s *subscription) tryDeliverMessage(m *message, start int, now time.Time) (
 	return 0, false
 }
 
-var retentionDuration = 10 * time.Minute
+// orderedMsgs returns the subscription's unacked messages in publish order.
+//
+// Must be called with the lock held.
+func (s *subscription) orderedMsgs() []*message {
+	msgs := make([]*message, 0, len(s.msgs))
+	for _, m := range s.msgs {
+		msgs = append(msgs, m)
+	}
+	sort.Sort(bySeq(msgs))
+	return msgs
+}
+
+// deliverableMsgs returns the subscription's messages that are not outstanding,
+// in publish order. If the subscription has message ordering enabled, a message
+// with an ordering key is included only if it is the earliest unacked message
+// with that key.
+//
+// Must be called with the lock held.
+func (s *subscription) deliverableMsgs() []*message {
+	var msgs []*message
+	blocked := map[string]bool{} // ordering keys with an earlier unacked message
+	for _, m := range s.orderedMsgs() {
+		if key := m.proto.Message.GetOrderingKey(); key != "" && s.proto.EnableMessageOrdering {
+			if blocked[key] {
+				continue
+			}
+			blocked[key] = true
+		}
+		if !m.outstanding() {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+type bySeq []*message
+
+func (b bySeq) Len() int           { return len(b) }
+func (b bySeq) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bySeq) Less(i, j int) bool { return b[i].seq < b[j].seq }
+
+// Must be called with the lock held.
+func (s *subscription) pushEndpoint() string {
+	if s.proto.PushConfig == nil {
+		return ""
+	}
+	return s.proto.PushConfig.PushEndpoint
+}
+
+// push sends the available messages of a push subscription to its endpoint, one
+// at a time. A message is acked if the endpoint responds with a 2xx status, and
+// is made available for redelivery otherwise.
+func (s *subscription) push() {
+	s.mu.Lock()
+	endpoint := s.pushEndpoint()
+	if endpoint == "" {
+		s.mu.Unlock()
+		return
+	}
+	now := timeNow()
+	s.maintainMessages(now)
+	var msgs []*message
+	for _, m := range s.deliverableMsgs() {
+		(*m.deliveries)++
+		m.attempts++
+		m.ackDeadline = now.Add(s.ackTimeout)
+		msgs = append(msgs, m)
+	}
+	name := s.proto.Name
+	client := &http.Client{Timeout: s.ackTimeout}
+	s.mu.Unlock()
+
+	for _, m := range msgs {
+		err := pushMessage(client, endpoint, name, m.proto.Message)
+		s.mu.Lock()
+		if err == nil {
+			s.ack(m.proto.AckId)
+		} else {
+			s.modifyAckDeadline(m.proto.AckId, 0)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// pushRequest is the JSON body of a push delivery.
+type pushRequest struct {
+	Message struct {
+		Data        []byte            `json:"data,omitempty"`
+		Attributes  map[string]string `json:"attributes,omitempty"`
+		MessageID   string            `json:"messageId"`
+		PublishTime time.Time         `json:"publishTime"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+func pushMessage(client *http.Client, endpoint, sub string, pm *pb.PubsubMessage) error {
+	var preq pushRequest
+	preq.Message.Data = pm.Data
+	preq.Message.Attributes = pm.Attributes
+	preq.Message.MessageID = pm.MessageId
+	pubTime, err := ptypes.Timestamp(pm.PublishTime)
+	if err != nil {
+		return err
+	}
+	preq.Message.PublishTime = pubTime
+	preq.Subscription = sub
+	body, err := json.Marshal(&preq)
+	if err != nil {
+		return err
+	}
+	res, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("push to %s: %s", endpoint, res.Status)
+	}
+	return nil
+}
 
 // Must be called with the lock held.
 func (s *subscription) maintainMessages(now time.Time) {
+	retention, err := ptypes.Duration(s.proto.MessageRetentionDuration)
+	if err != nil {
+		retention = maxMessageRetentionDuration
+	}
 	for id, m := range s.msgs {
 		// Mark a message as re-deliverable if its ack deadline has expired.
 		if m.outstanding() && now.After(m.ackDeadline) {
 			m.makeAvailable()
 		}
+		// Forward messages that have run out of delivery attempts.
+		if !m.outstanding() && s.deadLetter != nil && m.attempts >= s.deadLetter.maxAttempts {
+			s.forwardToDeadLetter(m)
+			delete(s.msgs, id)
+			continue
+		}
 		pubTime, err := ptypes.Timestamp(m.proto.Message.PublishTime)
 		if err != nil {
 			panic(err)
 		}
 		// Remove messages that have been undelivered for a long time.
-		if !m.outstanding() && now.Sub(pubTime) > retentionDuration {
+		if !m.outstanding() && now.Sub(pubTime) > retention {
 			delete(s.msgs, id)
 		}
 	}
+	// Acked messages are kept for the same duration.
+	for id, m := range s.acked {
+		if now.Sub(m.publishTime) > retention {
+			delete(s.acked, id)
+		}
+	}
+}
+
+// forwardToDeadLetter publishes a copy of m to the subscription's dead-letter
+// topic. If that topic no longer exists, the message is dropped.
+//
+// Must be called with the lock held.
+func (s *subscription) forwardToDeadLetter(m *message) {
+	top := s.srv.topics[s.deadLetter.topic]
+	if top == nil {
+		return
+	}
+	pm := m.proto.Message
+	attrs := map[string]string{}
+	for k, v := range pm.Attributes {
+		attrs[k] = v
+	}
+	attrs[deadLetterDeliveryCountAttr] = strconv.Itoa(m.attempts)
+	attrs[deadLetterSubscriptionAttr] = path.Base(s.proto.Name)
+	attrs[deadLetterSubscriptionProjectAttr] = projectOf(s.proto.Name)
+	attrs[deadLetterTopicPublishTimeAttr] = m.publishTime.UTC().Format(time.RFC3339Nano)
+	if _, err := s.srv.publish(top, &pb.PubsubMessage{Data: pm.Data, Attributes: attrs}); err != nil {
+		panic(err)
+	}
+}
+
+// projectOf returns the project ID from a resource name of the form
+// "projects/P/...".
+func projectOf(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 2 || parts[0] != "projects" {
+		return ""
+	}
+	return parts[1]
 }
 
 func (s *subscription) newStream(gs pb.Subscriber_StreamingPullServer, timeout time.Duration) *stream {
@@ -861,12 +1345,40 @@ func deleteStreamAt(s []*stream, i int) []*stream {
 type message struct {
 	proto       *pb.ReceivedMessage
 	publishTime time.Time
+	seq         int // position in publish order
 	ackDeadline time.Time
 	deliveries  *int
 	acks        *int
+	attempts    int // delivery attempts to this subscription
 	streamIndex int // index of stream that currently owns msg, for round-robin delivery
 }
 
+func newMessage(pm *pb.PubsubMessage, m *Message) *message {
+	return &message{
+		publishTime: m.PublishTime,
+		seq:         m.seq,
+		proto: &pb.ReceivedMessage{
+			AckId:   pm.MessageId,
+			Message: pm,
+		},
+		deliveries:  &m.deliveries,
+		acks:        &m.acks,
+		streamIndex: -1,
+	}
+}
+
+// reset returns a copy of m that has not been delivered.
+func (m *message) reset() *message {
+	return &message{
+		proto:       m.proto,
+		publishTime: m.publishTime,
+		seq:         m.seq,
+		deliveries:  m.deliveries,
+		acks:        m.acks,
+		streamIndex: -1,
+	}
+}
+
 // A message is outstanding if it is owned by some stream.
 func (m *message) outstanding() bool {
 	return !m.ackDeadline.IsZero()
@@ -920,6 +1432,16 @@ func (st *stream) sendLoop() error {
 		case <-st.done:
 			return nil
 		case rm := <-st.msgc:
+			st.sub.mu.Lock()
+			err := st.sub.srv.pullError(st.sub.proto.Name)
+			if err != nil {
+				// The message was never sent; make it available again.
+				st.sub.modifyAckDeadline(rm.AckId, 0)
+			}
+			st.sub.mu.Unlock()
+			if err != nil {
+				return err
+			}
 			res := &pb.StreamingPullResponse{ReceivedMessages: []*pb.ReceivedMessage{rm}}
 			if err := st.gstream.Send(res); err != nil {
 				return err
@@ -960,6 +1482,9 @@ func (s *subscription) ack(id string) {
 	if m != nil {
 		(*m.acks)++
 		delete(s.msgs, id)
+		if s.proto.RetainAckedMessages {
+			s.acked[id] = m
+		}
 	}
 }
 
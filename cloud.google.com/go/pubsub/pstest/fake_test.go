@@ -15,8 +15,11 @@
 package pstest
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +29,7 @@ import (
 	"cloud.google.com/go/internal/testutil"
 	"golang.org/x/net/context"
 	pb "google.golang.org/genproto/googleapis/pubsub/v1"
+	fmpb "google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -541,6 +545,444 @@ func TestSeek(t *testing.T) {
 	}
 }
 
+func TestSeekReplay(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	retain := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:                "projects/P/subscriptions/retain",
+		Topic:               top.Name,
+		AckDeadlineSeconds:  10,
+		RetainAckedMessages: true,
+	})
+	noRetain := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/noretain",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+	})
+
+	// Publish two messages at distinct times.
+	t0 := time.Now()
+	now.Store(func() time.Time { return t0 })
+	defer func() { now.Store(time.Now) }()
+	id1 := srv.Publish(top.Name, []byte("1"), nil)
+	t1 := t0.Add(time.Minute)
+	now.Store(func() time.Time { return t1 })
+	id2 := srv.Publish(top.Name, []byte("2"), nil)
+
+	seek := func(sub *pb.Subscription, tm time.Time) {
+		ts, err := ptypes.TimestampProto(tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sclient.Seek(ctx, &pb.SeekRequest{
+			Subscription: sub.Name,
+			Target:       &pb.SeekRequest_Time{Time: ts},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sub := range []*pb.Subscription{retain, noRetain} {
+		got := pullN(t, 2, sclient, sub)
+		var ackIDs []string
+		for _, m := range got {
+			ackIDs = append(ackIDs, m.AckId)
+		}
+		if _, err := sclient.Acknowledge(ctx, &pb.AcknowledgeRequest{Subscription: sub.Name, AckIds: ackIDs}); err != nil {
+			t.Fatal(err)
+		}
+		// Seeking to t1 replays the second message, if it was retained.
+		seek(sub, t1)
+	}
+	if got := pullAll(t, sclient, retain); !testutil.Equal(got, []string{id2}) {
+		t.Errorf("retain: got %v, want [%s]", got, id2)
+	}
+	if got := pullAll(t, sclient, noRetain); len(got) != 0 {
+		t.Errorf("no retain: got %v, want none", got)
+	}
+
+	// Seeking back to t0 replays the first message as well, but messages older
+	// than the retention duration are gone.
+	seek(retain, t0)
+	if got := pullAll(t, sclient, retain); !testutil.Equal(got, []string{id1, id2}) {
+		t.Errorf("got %v, want [%s %s]", got, id1, id2)
+	}
+	later := t1.Add(maxMessageRetentionDuration)
+	now.Store(func() time.Time { return later })
+	seek(retain, t0)
+	if got := pullAll(t, sclient, retain); !testutil.Equal(got, []string{id2}) {
+		t.Errorf("after retention: got %v, want [%s]", got, id2)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+	})
+	id1 := srv.Publish(top.Name, []byte("1"), nil)
+
+	checkCode := func(err error, want codes.Code) {
+		if status.Code(err) != want {
+			t.Errorf("got %v, want code %s", err, want)
+		}
+	}
+	_, err := sclient.CreateSnapshot(ctx, &pb.CreateSnapshotRequest{Subscription: sub.Name})
+	checkCode(err, codes.InvalidArgument)
+	_, err = sclient.CreateSnapshot(ctx, &pb.CreateSnapshotRequest{Name: "projects/P/snapshots/X", Subscription: "projects/P/subscriptions/none"})
+	checkCode(err, codes.NotFound)
+
+	snap, err := sclient.CreateSnapshot(ctx, &pb.CreateSnapshotRequest{
+		Name:         "projects/P/snapshots/snap",
+		Subscription: sub.Name,
+		Labels:       map[string]string{"a": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Topic != top.Name || snap.ExpireTime == nil {
+		t.Errorf("got %+v", snap)
+	}
+	_, err = sclient.CreateSnapshot(ctx, &pb.CreateSnapshotRequest{Name: snap.Name, Subscription: sub.Name})
+	checkCode(err, codes.AlreadyExists)
+
+	got, err := sclient.GetSnapshot(ctx, &pb.GetSnapshotRequest{Snapshot: snap.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.Equal(got, snap) {
+		t.Errorf("got %+v, want %+v", got, snap)
+	}
+	got, err = sclient.UpdateSnapshot(ctx, &pb.UpdateSnapshotRequest{
+		Snapshot:   &pb.Snapshot{Name: snap.Name, Labels: map[string]string{"b": "2"}},
+		UpdateMask: &fmpb.FieldMask{Paths: []string{"labels"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.Equal(got.Labels, map[string]string{"b": "2"}) {
+		t.Errorf("got labels %v", got.Labels)
+	}
+	lres, err := sclient.ListSnapshots(ctx, &pb.ListSnapshotsRequest{Project: "projects/P"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lres.Snapshots) != 1 || lres.Snapshots[0].Name != snap.Name {
+		t.Errorf("ListSnapshots: got %v", lres.Snapshots)
+	}
+	tres, err := pclient.ListTopicSnapshots(ctx, &pb.ListTopicSnapshotsRequest{Topic: top.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.Equal(tres.Snapshots, []string{snap.Name}) {
+		t.Errorf("ListTopicSnapshots: got %v", tres.Snapshots)
+	}
+
+	// Consume everything, including a message published after the snapshot.
+	id2 := srv.Publish(top.Name, []byte("2"), nil)
+	rms := pullN(t, 2, sclient, sub)
+	var ackIDs []string
+	for _, m := range rms {
+		ackIDs = append(ackIDs, m.AckId)
+	}
+	if _, err := sclient.Acknowledge(ctx, &pb.AcknowledgeRequest{Subscription: sub.Name, AckIds: ackIDs}); err != nil {
+		t.Fatal(err)
+	}
+	// Seeking to the snapshot restores both.
+	if _, err := sclient.Seek(ctx, &pb.SeekRequest{
+		Subscription: sub.Name,
+		Target:       &pb.SeekRequest_Snapshot{Snapshot: snap.Name},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := pullAll(t, sclient, sub); !testutil.Equal(got, []string{id1, id2}) {
+		t.Errorf("got %v, want [%s %s]", got, id1, id2)
+	}
+
+	// A snapshot can only be used with a subscription to the same topic.
+	top2 := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T2"})
+	sub2 := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S2",
+		Topic:              top2.Name,
+		AckDeadlineSeconds: 10,
+	})
+	_, err = sclient.Seek(ctx, &pb.SeekRequest{
+		Subscription: sub2.Name,
+		Target:       &pb.SeekRequest_Snapshot{Snapshot: snap.Name},
+	})
+	checkCode(err, codes.InvalidArgument)
+
+	if _, err := sclient.DeleteSnapshot(ctx, &pb.DeleteSnapshotRequest{Snapshot: snap.Name}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = sclient.GetSnapshot(ctx, &pb.GetSnapshotRequest{Snapshot: snap.Name})
+	checkCode(err, codes.NotFound)
+	_, err = sclient.Seek(ctx, &pb.SeekRequest{
+		Subscription: sub.Name,
+		Target:       &pb.SeekRequest_Snapshot{Snapshot: snap.Name},
+	})
+	checkCode(err, codes.NotFound)
+}
+
+func TestOrderedDelivery(t *testing.T) {
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+	})
+	var want []string
+	for i := 0; i < 20; i++ {
+		want = append(want, srv.Publish(top.Name, []byte{byte(i)}, nil))
+	}
+	spc := mustStartStreamingPull(t, sclient, sub)
+	var got []string
+	for len(got) < len(want) {
+		res, err := spc.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range res.ReceivedMessages {
+			got = append(got, m.Message.MessageId)
+		}
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOrderingKeys(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:                  "projects/P/subscriptions/S",
+		Topic:                 top.Name,
+		AckDeadlineSeconds:    10,
+		EnableMessageOrdering: true,
+	})
+	_, err := pclient.Publish(ctx, &pb.PublishRequest{
+		Topic: top.Name,
+		Messages: []*pb.PubsubMessage{
+			{Data: []byte("a"), OrderingKey: "a"},
+			{Data: []byte("b"), OrderingKey: "b"},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("mixed ordering keys: got %v, want InvalidArgument", err)
+	}
+
+	a1 := srv.PublishOrdered(top.Name, []byte("a1"), nil, "a")
+	a2 := srv.PublishOrdered(top.Name, []byte("a2"), nil, "a")
+	b1 := srv.PublishOrdered(top.Name, []byte("b1"), nil, "b")
+	n1 := srv.Publish(top.Name, []byte("n1"), nil)
+	if got := srv.Message(a1).OrderingKey; got != "a" {
+		t.Errorf("OrderingKey: got %q, want %q", got, "a")
+	}
+
+	// Only the first message of each key is delivered until it is acked.
+	got := pullAll(t, sclient, sub)
+	if want := []string{a1, b1, n1}; !testutil.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := pullAll(t, sclient, sub); len(got) != 0 {
+		t.Fatalf("got %v, want none", got)
+	}
+	// A nacked message is redelivered before the later messages with its key.
+	if _, err := sclient.ModifyAckDeadline(ctx, &pb.ModifyAckDeadlineRequest{
+		Subscription:       sub.Name,
+		AckIds:             []string{a1},
+		AckDeadlineSeconds: 0,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pullAll(t, sclient, sub), []string{a1}; !testutil.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := sclient.Acknowledge(ctx, &pb.AcknowledgeRequest{Subscription: sub.Name, AckIds: []string{a1}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pullAll(t, sclient, sub), []string{a2}; !testutil.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPushDelivery(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []pushRequest
+		fail     = true
+	)
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var preq pushRequest
+		if err := json.NewDecoder(r.Body).Decode(&preq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, preq)
+		if fail {
+			// Fail the first attempt, to check for redelivery.
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer hs.Close()
+
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+		PushConfig:         &pb.PushConfig{PushEndpoint: hs.URL},
+	})
+	id := srv.Publish(top.Name, []byte("hello"), map[string]string{"k": "v"})
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Message(id).Acks == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for push delivery")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("got %d pushes, want 2", len(received))
+	}
+	got := received[1]
+	if got.Subscription != sub.Name || got.Message.MessageID != id ||
+		string(got.Message.Data) != "hello" || got.Message.Attributes["k"] != "v" || got.Message.PublishTime.IsZero() {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	dlt := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/DL"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+	})
+	dlSub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/DL",
+		Topic:              dlt.Name,
+		AckDeadlineSeconds: 10,
+	})
+	if err := srv.SetDeadLetterPolicy(sub.Name, "projects/P/topics/none", 2); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+	if err := srv.SetDeadLetterPolicy(sub.Name, dlt.Name, 2); err != nil {
+		t.Fatal(err)
+	}
+	srv.Publish(top.Name, []byte("poison"), map[string]string{"k": "v"})
+
+	// Nack the message twice; then it should move to the dead-letter topic.
+	for i := 0; i < 2; i++ {
+		rms := pullN(t, 1, sclient, sub)
+		for _, m := range rms {
+			if _, err := sclient.ModifyAckDeadline(ctx, &pb.ModifyAckDeadlineRequest{
+				Subscription:       sub.Name,
+				AckIds:             []string{m.AckId},
+				AckDeadlineSeconds: 0,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if got := pullAll(t, sclient, sub); len(got) != 0 {
+		t.Errorf("source subscription: got %v, want none", got)
+	}
+	var dm *pb.PubsubMessage
+	for _, m := range pullN(t, 1, sclient, dlSub) {
+		dm = m.Message
+	}
+	if string(dm.Data) != "poison" {
+		t.Errorf("got data %q", dm.Data)
+	}
+	for k, want := range map[string]string{
+		"k":                               "v",
+		deadLetterDeliveryCountAttr:       "2",
+		deadLetterSubscriptionAttr:        "S",
+		deadLetterSubscriptionProjectAttr: "P",
+	} {
+		if got := dm.Attributes[k]; got != want {
+			t.Errorf("%s: got %q, want %q", k, got, want)
+		}
+	}
+	if dm.Attributes[deadLetterTopicPublishTimeAttr] == "" {
+		t.Errorf("missing %s", deadLetterTopicPublishTimeAttr)
+	}
+}
+
+func TestErrorHooks(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv := newFake(t)
+	top := mustCreateTopic(t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+	})
+
+	unavailable := status.Errorf(codes.Unavailable, "injected")
+	srv.SetPublishErrorHook(func(*pb.PublishRequest) error { return unavailable })
+	_, err := pclient.Publish(ctx, &pb.PublishRequest{
+		Topic:    top.Name,
+		Messages: []*pb.PubsubMessage{{Data: []byte("x")}},
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Publish: got %v, want Unavailable", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("got %d messages published, want 0", n)
+	}
+	srv.SetPublishErrorHook(nil)
+	id := srv.Publish(top.Name, []byte("x"), nil)
+
+	var pulls int
+	srv.SetPullErrorHook(func(s string) error {
+		if s != sub.Name {
+			t.Errorf("got subscription %q, want %q", s, sub.Name)
+		}
+		pulls++
+		return unavailable
+	})
+	_, err = sclient.Pull(ctx, &pb.PullRequest{Subscription: sub.Name, ReturnImmediately: true})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Pull: got %v, want Unavailable", err)
+	}
+	spc := mustStartStreamingPull(t, sclient, sub)
+	if _, err := spc.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("StreamingPull: got %v, want Unavailable", err)
+	}
+	if pulls != 2 {
+		t.Errorf("hook called %d times, want 2", pulls)
+	}
+
+	// Fail a stream just before it sends a message. The message should be
+	// available again afterwards.
+	srv.SetPullErrorHook(nil)
+	spc = mustStartStreamingPull(t, sclient, sub)
+	srv.SetPullErrorHook(func(string) error { return unavailable })
+	if _, err := spc.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("StreamingPull send: got %v, want Unavailable", err)
+	}
+	srv.SetPullErrorHook(nil)
+	if got := pullAll(t, sclient, sub); !testutil.Equal(got, []string{id}) {
+		t.Errorf("got %v, want [%s]", got, id)
+	}
+}
+
 func TestTryDeliverMessage(t *testing.T) {
 	for _, test := range []struct {
 		desc           string
@@ -626,6 +1068,20 @@ func streamingPullN(t *testing.T, n int, sc pb.SubscriberClient, sub *pb.Subscri
 	return got
 }
 
+// pullAll pulls the available messages from sub without waiting, and returns
+// their IDs in the order received.
+func pullAll(t *testing.T, sc pb.SubscriberClient, sub *pb.Subscription) []string {
+	res, err := sc.Pull(context.Background(), &pb.PullRequest{Subscription: sub.Name, ReturnImmediately: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range res.ReceivedMessages {
+		ids = append(ids, m.Message.MessageId)
+	}
+	return ids
+}
+
 func pubsubMessages(rms map[string]*pb.ReceivedMessage) map[string]*pb.PubsubMessage {
 	ms := map[string]*pb.PubsubMessage{}
 	for k, rm := range rms {
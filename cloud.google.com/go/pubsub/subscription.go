@@ -148,6 +148,12 @@ type SubscriptionConfig struct {
 
 	// The set of labels for the subscription.
 	Labels map[string]string
+
+	// When true, messages with the same OrderingKey are delivered in the order
+	// they were published, and Receive calls its callback for a message only
+	// after the callback for the previous message with that key has returned.
+	// It can only be set when the subscription is created.
+	EnableMessageOrdering bool
 }
 
 func (cfg *SubscriptionConfig) toProto(name string) *pb.Subscription {
@@ -170,6 +176,7 @@ func (cfg *SubscriptionConfig) toProto(name string) *pb.Subscription {
 		RetainAckedMessages:      cfg.RetainAckedMessages,
 		MessageRetentionDuration: retentionDuration,
 		Labels:                   cfg.Labels,
+		EnableMessageOrdering:    cfg.EnableMessageOrdering,
 	}
 }
 
@@ -189,9 +196,10 @@ func protoToSubscriptionConfig(pbSub *pb.Subscription, c *Client) (SubscriptionC
 			Endpoint:   pbSub.PushConfig.PushEndpoint,
 			Attributes: pbSub.PushConfig.Attributes,
 		},
-		RetainAckedMessages: pbSub.RetainAckedMessages,
-		RetentionDuration:   rd,
-		Labels:              pbSub.Labels,
+		RetainAckedMessages:   pbSub.RetainAckedMessages,
+		RetentionDuration:     rd,
+		Labels:                pbSub.Labels,
+		EnableMessageOrdering: pbSub.EnableMessageOrdering,
 	}, nil
 }
 
@@ -238,6 +246,16 @@ type ReceiveSettings struct {
 	// processed, rather than in memory. NumGoroutines is ignored.
 	// The default is false.
 	Synchronous bool
+
+	// Filter, if non-nil, selects the messages passed to the callback by their
+	// attributes. Messages that do not match are acked without calling the
+	// callback, and counted by FilteredCount.
+	Filter *Filter
+
+	// Dedup, if non-nil, makes Receive skip duplicate messages. Duplicates
+	// of processed messages are acked without calling the callback, and
+	// counted by DuplicateCount. See DedupSettings for details.
+	Dedup *DedupSettings
 }
 
 // For synchronous receive, the time to wait if we are already processing
@@ -432,6 +450,13 @@ var errReceiveInProgress = errors.New("pubsub: Receive already in progress for t
 // time-consuming; Receive will spawn new goroutines for incoming messages,
 // limited by MaxOutstandingMessages and MaxOutstandingBytes in ReceiveSettings.
 //
+// Messages with the same non-empty OrderingKey are an exception: Receive calls
+// f for such a message only after the call of f for the previous message with
+// that key has returned, so they are processed one at a time in the order they
+// were received. Use this with a subscription whose SubscriptionConfig has
+// EnableMessageOrdering set to process messages in the order they were
+// published.
+//
 // The context passed to f will be canceled when ctx is Done or there is a
 // fatal service error.
 //
@@ -480,19 +505,21 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 		synchronous:  s.ReceiveSettings.Synchronous,
 	}
 	fc := newFlowController(maxCount, maxBytes)
+	ks := newKeyScheduler()
+	f = s.receiveCallback(ctx, f)
 
 	// Wait for all goroutines started by Receive to return, so instead of an
 	// obscure goroutine leak we have an obvious blocked call to Receive.
 	group, gctx := errgroup.WithContext(ctx)
 	for i := 0; i < numGoroutines; i++ {
 		group.Go(func() error {
-			return s.receive(gctx, po, fc, f)
+			return s.receive(gctx, po, fc, ks, f)
 		})
 	}
 	return group.Wait()
 }
 
-func (s *Subscription) receive(ctx context.Context, po *pullOptions, fc *flowController, f func(context.Context, *Message)) error {
+func (s *Subscription) receive(ctx context.Context, po *pullOptions, fc *flowController, ks *keyScheduler, f func(context.Context, *Message)) error {
 	// Cancel a sub-context when we return, to kick the context-aware callbacks
 	// and the goroutine below.
 	ctx2, cancel := context.WithCancel(ctx)
@@ -567,12 +594,131 @@ func (s *Subscription) receive(ctx context.Context, po *pullOptions, fc *flowCon
 				old(ackID, ack, receiveTime)
 			}
 			wg.Add(1)
-			go func() {
+			ks.schedule(msg.OrderingKey, func() {
 				defer wg.Done()
 				f(ctx2, msg)
-			}()
+			})
+		}
+	}
+}
+
+// receiveCallback returns a callback that applies the Filter and Dedup
+// ReceiveSettings to each message before calling f.
+func (s *Subscription) receiveCallback(ctx context.Context, f func(context.Context, *Message)) func(context.Context, *Message) {
+	filter, dedup := s.ReceiveSettings.Filter, s.ReceiveSettings.Dedup
+	if dedup != nil && dedup.Store == nil {
+		dedup = nil
+	}
+	if filter == nil && dedup == nil {
+		return f
+	}
+	ttl, inProgressTTL := DefaultDedupTTL, DefaultDedupInProgressTTL
+	if dedup != nil && dedup.TTL > 0 {
+		ttl = dedup.TTL
+	}
+	if dedup != nil && dedup.InProgressTTL > 0 {
+		inProgressTTL = dedup.InProgressTTL
+	}
+	statsCtx := withSubscriptionKey(ctx, s.name)
+	return func(ctx context.Context, m *Message) {
+		if filter != nil && !filter.Match(m.Attributes) {
+			recordStat(statsCtx, FilteredCount, 1)
+			m.Ack()
+			return
+		}
+		key := m.ID
+		if dedup != nil && dedup.Attribute != "" {
+			key = m.Attributes[dedup.Attribute]
+		}
+		if dedup == nil || key == "" {
+			f(ctx, m)
+			return
+		}
+		// Hold the key only briefly until the message is acked, so that a
+		// message whose processing is abandoned is processed when it is
+		// redelivered.
+		added, processed, err := dedup.Store.Add(ctx, key, inProgressTTL)
+		if err != nil {
+			recordStat(statsCtx, DedupErrorCount, 1)
+		} else if !added && processed {
+			recordStat(statsCtx, DuplicateCount, 1)
+			m.Ack()
+			return
+		} else if !added {
+			// The first copy is still being processed and may yet be
+			// nacked. Acking this copy could lose the message, so leave it
+			// to be redelivered.
+			m.Nack()
+			return
+		} else {
+			done := m.doneFunc
+			m.doneFunc = func(ackID string, ack bool, receiveTime time.Time) {
+				// Use a fresh context: the message is often acked or nacked
+				// while ctx is being canceled.
+				var err error
+				if ack {
+					err = dedup.Store.MarkDone(context.Background(), key, ttl)
+				} else {
+					// Failing to remove the key would make the redelivered
+					// message look like a duplicate.
+					err = dedup.Store.Remove(context.Background(), key)
+				}
+				if err != nil {
+					recordStat(statsCtx, DedupErrorCount, 1)
+				}
+				done(ackID, ack, receiveTime)
+			}
 		}
+		f(ctx, m)
+	}
+}
+
+// A keyScheduler runs functions in new goroutines, except that functions
+// scheduled with the same non-empty key run one at a time, in the order they
+// were scheduled.
+type keyScheduler struct {
+	mu sync.Mutex
+	// pending holds, for each key with a running function, the functions
+	// waiting to run after it.
+	pending map[string][]func()
+}
+
+func newKeyScheduler() *keyScheduler {
+	return &keyScheduler{pending: map[string][]func(){}}
+}
+
+func (ks *keyScheduler) schedule(key string, f func()) {
+	if key == "" {
+		go f()
+		return
+	}
+	ks.mu.Lock()
+	if q, ok := ks.pending[key]; ok {
+		ks.pending[key] = append(q, f)
+		ks.mu.Unlock()
+		return
 	}
+	ks.pending[key] = nil
+	ks.mu.Unlock()
+	go func() {
+		for f != nil {
+			f()
+			f = ks.next(key)
+		}
+	}()
+}
+
+// next returns the next function to run for key, or nil if there is none.
+func (ks *keyScheduler) next(key string) func() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	q := ks.pending[key]
+	if len(q) == 0 {
+		delete(ks.pending, key)
+		return nil
+	}
+	ks.pending[key] = q[1:]
+	return q[0]
 }
 
 type pullOptions struct {
@@ -16,6 +16,7 @@ package pubsub
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -214,6 +215,92 @@ func testReceive(t *testing.T, synchronous bool) {
 	}
 }
 
+func TestReceiveOrdered(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFake(t)
+	defer client.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	topic.EnableMessageOrdering = true
+	topic.PublishSettings.CountThreshold = 3
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic, EnableMessageOrdering: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := sub.Config(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.EnableMessageOrdering {
+		t.Error("EnableMessageOrdering: got false, want true")
+	}
+	keys := []string{"a", "b", "c"}
+	const n = 30
+	var rs []*PublishResult
+	for i := 0; i < n; i++ {
+		rs = append(rs, topic.Publish(ctx, &Message{Data: []byte{byte(i)}, OrderingKey: keys[i%len(keys)]}))
+	}
+	for _, r := range rs {
+		if _, err := r.Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	topic.Stop()
+
+	msgs, err := pullN(ctx, sub, n, func(_ context.Context, m *Message) { m.Ack() })
+	if c := status.Convert(err); err != nil && c.Code() != codes.Canceled {
+		t.Fatalf("Pull: %v", err)
+	}
+	last := map[string]int{}
+	for _, m := range msgs {
+		i := int(m.Data[0])
+		if m.OrderingKey != keys[i%len(keys)] {
+			t.Errorf("message %d: got key %q", i, m.OrderingKey)
+		}
+		if prev, ok := last[m.OrderingKey]; ok && prev > i {
+			t.Errorf("key %q: got message %d after %d", m.OrderingKey, i, prev)
+		}
+		last[m.OrderingKey] = i
+	}
+}
+
+func TestKeyScheduler(t *testing.T) {
+	ks := newKeyScheduler()
+	var (
+		mu      sync.Mutex
+		got     []int
+		running = map[string]bool{}
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 100; i++ {
+		i := i
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		ks.schedule(key, func() {
+			defer wg.Done()
+			mu.Lock()
+			if running[key] {
+				t.Errorf("key %q: concurrent calls", key)
+			}
+			running[key] = true
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running[key] = false
+			if key == "a" {
+				got = append(got, i)
+			}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	for j := 1; j < len(got); j++ {
+		if got[j] < got[j-1] {
+			t.Fatalf("out of order: %v", got)
+		}
+	}
+}
+
 func (t1 *Topic) Equal(t2 *Topic) bool {
 	if t1 == nil && t2 == nil {
 		return true
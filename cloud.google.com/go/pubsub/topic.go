@@ -59,10 +59,23 @@ type Topic struct {
 	// first call to Publish. The default is DefaultPublishSettings.
 	PublishSettings PublishSettings
 
+	// EnableMessageOrdering enables publishing messages with an OrderingKey.
+	// Messages with the same key are sent in separate requests, one at a
+	// time, in the order they were passed to Publish. It must be set before
+	// the first call to Publish.
+	EnableMessageOrdering bool
+
 	mu      sync.RWMutex
 	stopped bool
 	bundler *bundler.Bundler
 
+	// orderMu guards keys.
+	orderMu sync.Mutex
+	// keys holds the state of each ordering key that has messages buffered or
+	// in flight, or whose publishing is paused. Other keys are removed, so
+	// that the state does not grow with the number of keys ever published.
+	keys map[string]*orderingKeyState
+
 	wg sync.WaitGroup
 }
 
@@ -303,7 +316,22 @@ func (t *Topic) Subscriptions(ctx context.Context) *SubscriptionIterator {
 	}
 }
 
-var errTopicStopped = errors.New("pubsub: Stop has been called for this topic")
+var (
+	errTopicStopped            = errors.New("pubsub: Stop has been called for this topic")
+	errTopicOrderingNotEnabled = errors.New("pubsub: Topic.EnableMessageOrdering must be set to publish messages with an OrderingKey")
+)
+
+// ErrPublishingPaused is the error of a PublishResult for a message whose
+// OrderingKey is paused. Publishing for a key is paused when a message with
+// that key fails to be published, so that later messages are not published
+// out of order. Call Topic.ResumePublish to resume publishing for the key.
+type ErrPublishingPaused struct {
+	OrderingKey string
+}
+
+func (e ErrPublishingPaused) Error() string {
+	return fmt.Sprintf("pubsub: publishing for ordering key %q is paused; call Topic.ResumePublish to resume it", e.OrderingKey)
+}
 
 // Publish publishes msg to the topic asynchronously. Messages are batched and
 // sent according to the topic's PublishSettings. Publish never blocks.
@@ -314,14 +342,24 @@ var errTopicStopped = errors.New("pubsub: Stop has been called for this topic")
 // Publish creates goroutines for batching and sending messages. These goroutines
 // need to be stopped by calling t.Stop(). Once stopped, future calls to Publish
 // will immediately return a PublishResult with an error.
+//
+// If msg has an OrderingKey, t.EnableMessageOrdering must be set. The message
+// is sent after all earlier messages with the same key have been sent. If the
+// key's publishing is paused, the PublishResult has an ErrPublishingPaused
+// error.
 func (t *Topic) Publish(ctx context.Context, msg *Message) *PublishResult {
 	// TODO(jba): if this turns out to take significant time, try to approximate it.
 	// Or, convert the messages to protos in Publish, instead of in the service.
 	msg.size = proto.Size(&pb.PubsubMessage{
-		Data:       msg.Data,
-		Attributes: msg.Attributes,
+		Data:        msg.Data,
+		Attributes:  msg.Attributes,
+		OrderingKey: msg.OrderingKey,
 	})
 	r := &PublishResult{ready: make(chan struct{})}
+	if msg.OrderingKey != "" && !t.EnableMessageOrdering {
+		r.set("", errTopicOrderingNotEnabled)
+		return r
+	}
 	t.initBundler()
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -331,14 +369,26 @@ func (t *Topic) Publish(ctx context.Context, msg *Message) *PublishResult {
 		return r
 	}
 
+	b := t.bundler
+	if msg.OrderingKey != "" {
+		var err error
+		b, err = t.keyBundler(msg.OrderingKey)
+		if err != nil {
+			r.set("", err)
+			return r
+		}
+	}
 	// TODO(jba) [from bcmills] consider using a shared channel per bundle
 	// (requires Bundler API changes; would reduce allocations)
 	// The call to Add should never return an error because the bundler's
 	// BufferedByteLimit is set to maxInt; we do not perform any flow
 	// control in the client.
-	err := t.bundler.Add(&bundledMessage{msg, r}, msg.size)
+	err := b.Add(&bundledMessage{msg, r}, msg.size)
 	if err != nil {
 		r.set("", err)
+		if msg.OrderingKey != "" {
+			t.keyDone(msg.OrderingKey, 1)
+		}
 	}
 	return r
 }
@@ -355,6 +405,106 @@ func (t *Topic) Stop() {
 		return
 	}
 	t.bundler.Flush()
+	t.orderMu.Lock()
+	bs := make([]*bundler.Bundler, 0, len(t.keys))
+	for _, ks := range t.keys {
+		if ks.bundler != nil {
+			bs = append(bs, ks.bundler)
+		}
+	}
+	t.orderMu.Unlock()
+	for _, b := range bs {
+		b.Flush()
+	}
+}
+
+// ResumePublish resumes publishing for an ordering key whose publishing was
+// paused because a message with the key failed to be published. Messages with
+// the key that are published afterwards are sent as usual; messages whose
+// PublishResult had an ErrPublishingPaused error are not resent.
+func (t *Topic) ResumePublish(orderingKey string) {
+	t.orderMu.Lock()
+	defer t.orderMu.Unlock()
+	if ks, ok := t.keys[orderingKey]; ok {
+		ks.paused = false
+		t.pruneKeyLocked(orderingKey, ks)
+	}
+}
+
+// orderingKeyState is the publishing state of an ordering key.
+type orderingKeyState struct {
+	bundler *bundler.Bundler // nil if the key has nothing buffered or in flight
+	pending int              // messages added to bundler and not yet handled
+	paused  bool
+}
+
+// keyBundler returns the bundler for a message with an ordering key, creating
+// it if necessary, and counts the message as pending. It returns an
+// ErrPublishingPaused if the key is paused. The caller must call keyDone once
+// the message has been handled, or could not be added to the bundler.
+func (t *Topic) keyBundler(key string) (*bundler.Bundler, error) {
+	t.orderMu.Lock()
+	defer t.orderMu.Unlock()
+	ks := t.keys[key]
+	if ks == nil {
+		if t.keys == nil {
+			t.keys = map[string]*orderingKeyState{}
+		}
+		ks = &orderingKeyState{}
+		t.keys[key] = ks
+	}
+	if ks.paused {
+		return nil, ErrPublishingPaused{OrderingKey: key}
+	}
+	if ks.bundler == nil {
+		// A HandlerLimit of 1 makes the bundler send one request for the key
+		// at a time, in order.
+		ks.bundler = t.newBundler(1, func(ctx context.Context, bms []*bundledMessage) {
+			defer t.keyDone(key, len(bms))
+			t.orderMu.Lock()
+			paused := ks.paused
+			t.orderMu.Unlock()
+			if paused {
+				for _, bm := range bms {
+					bm.res.set("", ErrPublishingPaused{OrderingKey: key})
+				}
+				return
+			}
+			if err := t.publishMessageBundle(ctx, bms); err != nil {
+				t.orderMu.Lock()
+				ks.paused = true
+				t.orderMu.Unlock()
+			}
+		})
+	}
+	ks.pending++
+	return ks.bundler, nil
+}
+
+// keyDone records that n pending messages with an ordering key have been
+// handled, and removes the state of the key if it is no longer needed.
+func (t *Topic) keyDone(key string, n int) {
+	t.orderMu.Lock()
+	defer t.orderMu.Unlock()
+	ks := t.keys[key]
+	ks.pending -= n
+	t.pruneKeyLocked(key, ks)
+}
+
+// pruneKeyLocked drops the bundler of an ordering key that has no pending
+// messages, and removes the key if it is not paused either. The next message
+// with the key gets a new bundler, which is safe because all earlier messages
+// have been handled.
+//
+// Must be called with t.orderMu held.
+func (t *Topic) pruneKeyLocked(key string, ks *orderingKeyState) {
+	if ks.pending > 0 {
+		return
+	}
+	ks.bundler = nil
+	if !ks.paused {
+		delete(t.keys, key)
+	}
 }
 
 // A PublishResult holds the result from a call to Publish.
@@ -410,8 +560,22 @@ func (t *Topic) initBundler() {
 		return
 	}
 
+	// Unless overridden, allow many goroutines per CPU to call the Publish RPC concurrently.
+	// The default value was determined via extensive load testing (see the loadtest subdirectory).
+	handlerLimit := 25 * runtime.GOMAXPROCS(0)
+	if t.PublishSettings.NumGoroutines > 0 {
+		handlerLimit = t.PublishSettings.NumGoroutines
+	}
+	t.bundler = t.newBundler(handlerLimit, func(ctx context.Context, bms []*bundledMessage) {
+		t.publishMessageBundle(ctx, bms)
+	})
+}
+
+// newBundler returns a bundler configured by t.PublishSettings that calls
+// publish with each bundle.
+func (t *Topic) newBundler(handlerLimit int, publish func(context.Context, []*bundledMessage)) *bundler.Bundler {
 	timeout := t.PublishSettings.Timeout
-	t.bundler = bundler.NewBundler(&bundledMessage{}, func(items interface{}) {
+	b := bundler.NewBundler(&bundledMessage{}, func(items interface{}) {
 		// TODO(jba): use a context detached from the one passed to NewClient.
 		ctx := context.TODO()
 		if timeout != 0 {
@@ -419,31 +583,29 @@ func (t *Topic) initBundler() {
 			ctx, cancel = context.WithTimeout(ctx, timeout)
 			defer cancel()
 		}
-		t.publishMessageBundle(ctx, items.([]*bundledMessage))
+		publish(ctx, items.([]*bundledMessage))
 	})
-	t.bundler.DelayThreshold = t.PublishSettings.DelayThreshold
-	t.bundler.BundleCountThreshold = t.PublishSettings.CountThreshold
-	if t.bundler.BundleCountThreshold > MaxPublishRequestCount {
-		t.bundler.BundleCountThreshold = MaxPublishRequestCount
-	}
-	t.bundler.BundleByteThreshold = t.PublishSettings.ByteThreshold
-	t.bundler.BufferedByteLimit = maxInt
-	t.bundler.BundleByteLimit = MaxPublishRequestBytes
-	// Unless overridden, allow many goroutines per CPU to call the Publish RPC concurrently.
-	// The default value was determined via extensive load testing (see the loadtest subdirectory).
-	if t.PublishSettings.NumGoroutines > 0 {
-		t.bundler.HandlerLimit = t.PublishSettings.NumGoroutines
-	} else {
-		t.bundler.HandlerLimit = 25 * runtime.GOMAXPROCS(0)
+	b.DelayThreshold = t.PublishSettings.DelayThreshold
+	b.BundleCountThreshold = t.PublishSettings.CountThreshold
+	if b.BundleCountThreshold > MaxPublishRequestCount {
+		b.BundleCountThreshold = MaxPublishRequestCount
 	}
+	b.BundleByteThreshold = t.PublishSettings.ByteThreshold
+	b.BufferedByteLimit = maxInt
+	b.BundleByteLimit = MaxPublishRequestBytes
+	b.HandlerLimit = handlerLimit
+	return b
 }
 
-func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage) {
+// publishMessageBundle publishes bms in one request, sets their results, and
+// returns the error of the request.
+func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage) error {
 	pbMsgs := make([]*pb.PubsubMessage, len(bms))
 	for i, bm := range bms {
 		pbMsgs[i] = &pb.PubsubMessage{
-			Data:       bm.msg.Data,
-			Attributes: bm.msg.Attributes,
+			Data:        bm.msg.Data,
+			Attributes:  bm.msg.Attributes,
+			OrderingKey: bm.msg.OrderingKey,
 		}
 		bm.msg = nil // release bm.msg for GC
 	}
@@ -458,4 +620,5 @@ func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage)
 			bm.res.set(res.MessageIds[i], nil)
 		}
 	}
+	return err
 }
@@ -128,6 +128,118 @@ func TestPublishTimeout(t *testing.T) {
 	}
 }
 
+func TestPublishOrderingNotEnabled(t *testing.T) {
+	ctx := context.Background()
+	c := &Client{projectID: "projid"}
+	topic := c.Topic("t")
+	r := topic.Publish(ctx, &Message{OrderingKey: "k"})
+	if _, err := r.Get(ctx); err != errTopicOrderingNotEnabled {
+		t.Errorf("got %v, want errTopicOrderingNotEnabled", err)
+	}
+}
+
+func TestPublishOrderingPause(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newFake(t)
+	defer client.Close()
+
+	failed := false
+	srv.SetPublishErrorHook(func(req *pubsubpb.PublishRequest) error {
+		if req.Messages[0].OrderingKey == "bad" && !failed {
+			failed = true
+			return status.Errorf(codes.InvalidArgument, "bad message")
+		}
+		return nil
+	})
+	topic := mustCreateTopic(t, client, "t")
+	topic.EnableMessageOrdering = true
+	defer topic.Stop()
+
+	if _, err := topic.Publish(ctx, &Message{Data: []byte("1"), OrderingKey: "bad"}).Get(ctx); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	_, err := topic.Publish(ctx, &Message{Data: []byte("2"), OrderingKey: "bad"}).Get(ctx)
+	if want := (ErrPublishingPaused{OrderingKey: "bad"}); err != want {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+	// Other keys are unaffected.
+	if _, err := topic.Publish(ctx, &Message{Data: []byte("3"), OrderingKey: "good"}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	topic.ResumePublish("bad")
+	id, err := topic.Publish(ctx, &Message{Data: []byte("4"), OrderingKey: "bad"}).Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Message(id).OrderingKey; got != "bad" {
+		t.Errorf("OrderingKey: got %q, want %q", got, "bad")
+	}
+}
+
+func TestPublishOrderingPrunesKeys(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newFake(t)
+	defer client.Close()
+
+	failed := false
+	srv.SetPublishErrorHook(func(req *pubsubpb.PublishRequest) error {
+		if req.Messages[0].OrderingKey == "bad" && !failed {
+			failed = true
+			return status.Errorf(codes.InvalidArgument, "bad message")
+		}
+		return nil
+	})
+	topic := mustCreateTopic(t, client, "t")
+	topic.EnableMessageOrdering = true
+	defer topic.Stop()
+
+	// keys returns the ordering keys the topic keeps state for, once the
+	// handlers of the published messages have finished.
+	keys := func() []string {
+		var ks []string
+		for i := 0; i < 100; i++ {
+			topic.orderMu.Lock()
+			ks = ks[:0]
+			idle := true
+			for k, s := range topic.keys {
+				ks = append(ks, k)
+				idle = idle && s.pending == 0
+			}
+			topic.orderMu.Unlock()
+			if idle && (len(ks) == 0 || (len(ks) == 1 && ks[0] == "bad")) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return ks
+	}
+
+	var rs []*PublishResult
+	for i := 0; i < 50; i++ {
+		rs = append(rs, topic.Publish(ctx, &Message{Data: []byte{byte(i)}, OrderingKey: fmt.Sprintf("k%d", i%10)}))
+	}
+	for _, r := range rs {
+		if _, err := r.Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := keys(); len(got) != 0 {
+		t.Errorf("after publishing: got state for keys %v, want none", got)
+	}
+
+	// A paused key is kept until publishing is resumed.
+	if _, err := topic.Publish(ctx, &Message{Data: []byte("1"), OrderingKey: "bad"}).Get(ctx); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	if got, want := keys(), []string{"bad"}; !testutil.Equal(got, want) {
+		t.Errorf("after failure: got state for keys %v, want %v", got, want)
+	}
+	topic.ResumePublish("bad")
+	if got := keys(); len(got) != 0 {
+		t.Errorf("after resuming: got state for keys %v, want none", got)
+	}
+}
+
 func TestUpdateTopic(t *testing.T) {
 	ctx := context.Background()
 	client, _ := newFake(t)
@@ -0,0 +1,64 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// replaydump writes the contents of an rpcreplay file as text or JSON.
+//
+// Usage:
+//
+//	replaydump [-json] FILE
+//
+// Messages are decoded if their types are linked into replaydump, which
+// includes the Google Cloud APIs that the rpcreplay package is commonly used
+// with. Other messages are written as the name of their type and their encoded
+// bytes. To decode them, write a program that imports their Go packages and
+// calls rpcreplay.Fprint or rpcreplay.FprintJSON.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"cloud.google.com/go/rpcreplay"
+
+	// Register the message types of commonly replayed services.
+	_ "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	_ "google.golang.org/genproto/googleapis/bigtable/v2"
+	_ "google.golang.org/genproto/googleapis/datastore/v1"
+	_ "google.golang.org/genproto/googleapis/firestore/v1beta1"
+	_ "google.golang.org/genproto/googleapis/pubsub/v1"
+	_ "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+var jsonOutput = flag.Bool("json", false, "write JSON instead of text")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: replaydump [-json] FILE\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fprint := rpcreplay.Fprint
+	if *jsonOutput {
+		fprint = rpcreplay.FprintJSON
+	}
+	if err := fprint(os.Stdout, flag.Arg(0)); err != nil {
+		log.Fatal(err)
+	}
+}
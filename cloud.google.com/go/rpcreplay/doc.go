@@ -88,6 +88,13 @@ before RPCs are written to the replay file. If requests are modified by the call
 it is important to perform the same modifications to the requests when replaying, or RPC
 matching on replay will fail.
 
+For streaming RPCs, the Recorder's BeforeFunc is called on each message sent
+or received, and the Replayer's BeforeFunc on a copy of each message sent.
+
+RedactFields returns a BeforeFunc that clears the named fields of every message.
+Using it for both recording and replay keeps data such as credentials out of the
+replay file while still matching requests on replay.
+
 A common way to analyze and modify the various messages is to use a type switch.
 
 	// Assume these types implement proto.Message.
@@ -125,6 +132,22 @@ depending on the order of such RPCs probably has a race condition: since both th
 recorded sequence of RPCs and the sequence during replay are valid orderings, the
 program should behave the same under both.
 
+Requests that differ on each run, for example because they contain timestamps,
+can be matched by setting the Replayer's Matcher. A request that is equal to a
+recorded request is always matched to it first; otherwise, the Matcher decides
+whether the request matches a recorded one. IgnoreFields returns a Matcher that
+ignores some fields of requests, and ByMethod combines Matchers for different
+methods:
+
+    rep.Matcher = rpcreplay.ByMethod(map[string]rpcreplay.Matcher{
+        "/google.pubsub.v1.Publisher/Publish": rpcreplay.IgnoreFields("messages.publish_time"),
+    })
+
+Streams for the same method may also be created in a different order on
+replay. A stream is matched to a recorded one when its first message is sent:
+the Replayer chooses the first recorded stream for the method whose first sent
+message matches, or failing that, one with any matching sent message.
+
 
 Other Replayer Differences
 
@@ -136,8 +159,15 @@ incoming RPCs. This can violate causality. For example, in a Pub/Sub program whe
 one goroutine publishes and another subscribes, during replay the Subscribe call may
 finish before the Publish call begins.
 
-For streaming RPCs, the Replayer delivers the result of Send and Recv calls in
-the order they were recorded. No attempt is made to match message contents.
+For streaming RPCs, the Replayer delivers the result of Recv calls in the
+order they were recorded. Each Send is matched to the first recorded send on
+the stream with the same contents, or the next one if there is none.
+
+
+Inspecting Replay Files
+
+The command cloud.google.com/go/rpcreplay/cmd/replaydump writes a replay file
+as text or JSON. Programs can do the same with Fprint and FprintJSON.
 
 At present, this package does not record or replay stream headers and trailers, or
 the result of the CloseSend method.
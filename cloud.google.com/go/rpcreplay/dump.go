@@ -0,0 +1,193 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcreplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	pb "cloud.google.com/go/rpcreplay/proto/rpcreplay"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Fprint reads the entries from filename and writes them to w in human-readable form.
+// It is intended for debugging.
+func Fprint(w io.Writer, filename string) error {
+	return fprintFile(w, filename, FprintReader)
+}
+
+// FprintJSON reads the entries from filename and writes them to w as JSON.
+// It is intended for debugging.
+func FprintJSON(w io.Writer, filename string) error {
+	return fprintFile(w, filename, FprintReaderJSON)
+}
+
+func fprintFile(w io.Writer, filename string, fprint func(io.Writer, io.Reader) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fprint(w, f)
+}
+
+// FprintReader reads the entries from r and writes them to w in human-readable form.
+// It is intended for debugging.
+//
+// Messages whose types are not linked into the program are written as the
+// name of their type and their encoded bytes.
+func FprintReader(w io.Writer, r io.Reader) error {
+	initial, err := readHeader(r)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "initial state: %q\n", string(initial))
+	for i := 1; ; i++ {
+		pe, err := readPBEntry(r)
+		if err != nil {
+			return err
+		}
+		if pe == nil {
+			return nil
+		}
+		d := decodeEntry(pe)
+		s := "message"
+		if pe.IsError {
+			s = "error"
+		}
+		fmt.Fprintf(w, "#%d: kind: %s, method: %s, ref index: %d, %s:\n",
+			i, pe.Kind, pe.Method, pe.RefIndex, s)
+		switch {
+		case d.err != nil:
+			fmt.Fprintf(w, "%v\n", d.err)
+		case d.msg != nil:
+			if err := proto.MarshalText(w, d.msg); err != nil {
+				return err
+			}
+		case pe.Message != nil:
+			fmt.Fprintf(w, "unknown message of type %s: %x\n", pe.Message.TypeUrl, pe.Message.Value)
+		default:
+			fmt.Fprintln(w, "<nil>")
+		}
+	}
+}
+
+// FprintReaderJSON reads the entries from r and writes them to w as a JSON
+// object with the initial state and a list of entries. It is intended for
+// debugging.
+//
+// Messages whose types are not linked into the program are written as the
+// name of their type and their encoded bytes.
+func FprintReaderJSON(w io.Writer, r io.Reader) error {
+	initial, err := readHeader(r)
+	if err != nil {
+		return err
+	}
+	out := jsonFile{Initial: initial, Entries: []*jsonEntry{}}
+	m := jsonpb.Marshaler{OrigName: true}
+	for i := 1; ; i++ {
+		pe, err := readPBEntry(r)
+		if err != nil {
+			return err
+		}
+		if pe == nil {
+			break
+		}
+		d := decodeEntry(pe)
+		je := &jsonEntry{
+			Index:    i,
+			Kind:     pe.Kind.String(),
+			Method:   pe.Method,
+			RefIndex: int(pe.RefIndex),
+		}
+		switch {
+		case d.err == io.EOF:
+			je.EOF = true
+		case d.err != nil:
+			s, _ := status.FromError(d.err)
+			je.Error = &jsonError{Code: s.Code().String(), Message: s.Message()}
+		case d.msg != nil:
+			js, err := m.MarshalToString(d.msg)
+			if err != nil {
+				return err
+			}
+			je.Type = proto.MessageName(d.msg)
+			je.Message = json.RawMessage(js)
+		case pe.Message != nil:
+			je.Type = pe.Message.TypeUrl
+			je.RawMessage = pe.Message.Value
+		}
+		out.Entries = append(out.Entries, je)
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+type jsonFile struct {
+	Initial []byte       `json:"initial"`
+	Entries []*jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Index      int             `json:"index"`
+	Kind       string          `json:"kind"`
+	Method     string          `json:"method,omitempty"`
+	RefIndex   int             `json:"ref_index,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Message    json.RawMessage `json:"message,omitempty"`
+	RawMessage []byte          `json:"raw_message,omitempty"` // for unknown types
+	Error      *jsonError      `json:"error,omitempty"`
+	EOF        bool            `json:"eof,omitempty"`
+}
+
+type jsonError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// decodeEntry decodes the message of pe. Unlike readEntry, it does not fail
+// if the type of the message is unknown; it leaves the message nil instead.
+func decodeEntry(pe *pb.Entry) message {
+	var msg message
+	switch {
+	case pe.Message != nil:
+		var any ptypes.DynamicAny
+		if err := ptypes.UnmarshalAny(pe.Message, &any); err != nil {
+			if pe.IsError {
+				msg.err = status.Errorf(codes.Unknown, "undecodable status: %v", err)
+			}
+			return msg
+		}
+		if s, ok := any.Message.(*spb.Status); ok && pe.IsError {
+			msg.err = status.ErrorProto(s)
+		} else {
+			msg.msg = any.Message
+		}
+	case pe.IsError:
+		msg.err = io.EOF
+	}
+	return msg
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	ipb "cloud.google.com/go/rpcreplay/proto/intstore"
+	rpb "cloud.google.com/go/rpcreplay/proto/rpcreplay"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeTestFile writes a replay file with a request, an error response and an
+// entry whose message type is unknown.
+func writeTestFile(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, []byte("init")); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*entry{
+		{kind: rpb.Entry_REQUEST, method: "/intstore.IntStore/Get", msg: message{msg: &ipb.GetRequest{Name: "a"}}},
+		{kind: rpb.Entry_RESPONSE, msg: message{err: status.Error(codes.NotFound, "no a")}, refIndex: 1},
+	} {
+		if err := writeEntry(&buf, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := proto.Marshal(&rpb.Entry{
+		Kind:    rpb.Entry_REQUEST,
+		Method:  "/unknown.Service/Method",
+		Message: &any.Any{TypeUrl: "type.googleapis.com/unknown.Message", Value: []byte{1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRecord(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFprintReader(t *testing.T) {
+	var out bytes.Buffer
+	if err := FprintReader(&out, bytes.NewReader(writeTestFile(t))); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		`initial state: "init"`,
+		`#1: kind: REQUEST, method: /intstore.IntStore/Get, ref index: 0, message:`,
+		`name: "a"`,
+		`#2: kind: RESPONSE, method: , ref index: 1, error:`,
+		`no a`,
+		`unknown message of type type.googleapis.com/unknown.Message: 0102`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFprintReaderJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := FprintReaderJSON(&out, bytes.NewReader(writeTestFile(t))); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Initial []byte
+		Entries []struct {
+			Index      int
+			Kind       string
+			Method     string
+			RefIndex   int `json:"ref_index"`
+			Type       string
+			Message    map[string]interface{}
+			RawMessage []byte `json:"raw_message"`
+			Error      *struct{ Code, Message string }
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if string(got.Initial) != "init" || len(got.Entries) != 3 {
+		t.Fatalf("got %+v", got)
+	}
+	e := got.Entries[0]
+	if e.Kind != "REQUEST" || e.Type != "intstore.GetRequest" || e.Message["name"] != "a" {
+		t.Errorf("entry 1: got %+v", e)
+	}
+	e = got.Entries[1]
+	if e.RefIndex != 1 || e.Error == nil || e.Error.Code != "NotFound" || e.Error.Message != "no a" {
+		t.Errorf("entry 2: got %+v", e)
+	}
+	e = got.Entries[2]
+	if e.Type != "type.googleapis.com/unknown.Message" || !bytes.Equal(e.RawMessage, []byte{1, 2}) {
+		t.Errorf("entry 3: got %+v", e)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcreplay
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// A Matcher reports whether a request made during replay matches a recorded
+// request for the same method.
+type Matcher func(method string, req, recorded proto.Message) bool
+
+// IgnoreFields returns a Matcher that compares requests for equality, ignoring
+// the given fields. A field is named by its name in the .proto file or in the
+// generated Go struct, and the fields of nested messages by a dotted path, like
+// "item.update_time". A path through a repeated message field applies to each
+// element. Fields that a message does not have are ignored.
+func IgnoreFields(fields ...string) Matcher {
+	return func(_ string, req, recorded proto.Message) bool {
+		return proto.Equal(clearFields(req, fields), clearFields(recorded, fields))
+	}
+}
+
+// ByMethod returns a Matcher that uses the Matcher for the request's method in
+// matchers, and compares requests for equality if there is none.
+func ByMethod(matchers map[string]Matcher) Matcher {
+	return func(method string, req, recorded proto.Message) bool {
+		if m, ok := matchers[method]; ok {
+			return m(method, req, recorded)
+		}
+		return proto.Equal(req, recorded)
+	}
+}
+
+// RedactFields returns a function that can be used as a Recorder's BeforeFunc.
+// It clears the given fields, named as for IgnoreFields, in recorded messages.
+//
+// Requests that were redacted when recording will not be equal to requests
+// during replay. Use the same function as the Replayer's BeforeFunc, or
+// IgnoreFields as its Matcher, so that they still match.
+func RedactFields(fields ...string) func(method string, msg proto.Message) error {
+	return func(_ string, msg proto.Message) error {
+		for _, f := range fields {
+			clearField(reflect.ValueOf(msg), strings.Split(f, "."))
+		}
+		return nil
+	}
+}
+
+// clearFields returns a copy of msg with the fields cleared.
+func clearFields(msg proto.Message, fields []string) proto.Message {
+	if msg == nil || len(fields) == 0 {
+		return msg
+	}
+	msg = proto.Clone(msg)
+	for _, f := range fields {
+		clearField(reflect.ValueOf(msg), strings.Split(f, "."))
+	}
+	return msg
+}
+
+// clearField sets the field at path in v, a pointer to a generated message
+// struct, to its zero value.
+func clearField(v reflect.Value, path []string) {
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !fieldHasName(t.Field(i), path[0]) {
+			continue
+		}
+		fv := v.Field(i)
+		if len(path) == 1 {
+			fv.Set(reflect.Zero(fv.Type()))
+			return
+		}
+		switch fv.Kind() {
+		case reflect.Ptr:
+			clearField(fv, path[1:])
+		case reflect.Slice:
+			for j := 0; j < fv.Len(); j++ {
+				clearField(fv.Index(j), path[1:])
+			}
+		}
+		return
+	}
+}
+
+// fieldHasName reports whether the struct field of a generated message has the
+// given Go or proto name.
+func fieldHasName(f reflect.StructField, name string) bool {
+	if f.Name == name {
+		return true
+	}
+	if n := f.Tag.Get("protobuf_oneof"); n != "" {
+		return n == name
+	}
+	for _, p := range strings.Split(f.Tag.Get("protobuf"), ",") {
+		if p == "name="+name {
+			return true
+		}
+	}
+	return false
+}
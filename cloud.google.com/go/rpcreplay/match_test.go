@@ -0,0 +1,220 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcreplay
+
+import (
+	"bytes"
+	"testing"
+
+	ipb "cloud.google.com/go/rpcreplay/proto/intstore"
+	rpb "cloud.google.com/go/rpcreplay/proto/rpcreplay"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestIgnoreFields(t *testing.T) {
+	a1 := &ipb.Item{Name: "a", Value: 1}
+	a2 := &ipb.Item{Name: "a", Value: 2}
+	b1 := &ipb.Item{Name: "b", Value: 1}
+	for _, test := range []struct {
+		m    Matcher
+		x, y proto.Message
+		want bool
+	}{
+		{IgnoreFields("value"), a1, a2, true},
+		{IgnoreFields("Value"), a1, a2, true},
+		{IgnoreFields("value"), a1, b1, false},
+		{IgnoreFields("name", "value"), a1, &ipb.Item{}, true},
+		{IgnoreFields("no_such_field"), a1, a2, false},
+		{ByMethod(map[string]Matcher{"m": IgnoreFields("value")}), a1, a2, true},
+		{ByMethod(map[string]Matcher{"other": IgnoreFields("value")}), a1, a2, false},
+	} {
+		if got := test.m("m", test.x, test.y); got != test.want {
+			t.Errorf("%v, %v: got %t, want %t", test.x, test.y, got, test.want)
+		}
+	}
+	// The arguments are not modified.
+	if a1.Value != 1 || a2.Value != 2 {
+		t.Error("IgnoreFields modified its arguments")
+	}
+
+	// Nested fields.
+	e1 := &rpb.Entry{Method: "m", Message: &any.Any{TypeUrl: "x", Value: []byte{1}}}
+	e2 := &rpb.Entry{Method: "m", Message: &any.Any{TypeUrl: "x", Value: []byte{2}}}
+	if !IgnoreFields("message.value")("", e1, e2) {
+		t.Error("message.value: got false, want true")
+	}
+	if IgnoreFields("message.type_url")("", e1, e2) {
+		t.Error("message.type_url: got true, want false")
+	}
+}
+
+func TestReplayMatcher(t *testing.T) {
+	srv := newIntStoreServer()
+	defer srv.stop()
+
+	var buf bytes.Buffer
+	rec, err := NewRecorderWriter(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, close := newTestClient(t, srv.Addr, rec.DialOptions())
+	ctx := context.Background()
+	for _, item := range []*ipb.Item{{Name: "a", Value: 1}, {Name: "b", Value: 2}} {
+		if _, err := client.Set(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recorded := buf.Bytes()
+
+	replay := func(m Matcher) error {
+		rep, err := NewReplayerReader(bytes.NewReader(recorded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep.Matcher = m
+		client, close := newTestClient(t, srv.Addr, rep.DialOptions())
+		defer close()
+		// The values differ from the recording, and the calls are out of order.
+		for _, item := range []*ipb.Item{{Name: "b", Value: 20}, {Name: "a", Value: 10}} {
+			if _, err := client.Set(ctx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := replay(nil); err == nil {
+		t.Error("without a Matcher: got nil, want error")
+	}
+	if err := replay(IgnoreFields("value")); err != nil {
+		t.Errorf("with a Matcher: %v", err)
+	}
+}
+
+func TestReplayStreamsOutOfOrder(t *testing.T) {
+	srv := newIntStoreServer()
+	defer srv.stop()
+
+	setStream := func(client ipb.IntStoreClient, names ...string) int32 {
+		ssc, err := client.SetStream(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range names {
+			if err := ssc.Send(&ipb.Item{Name: n}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		summary, err := ssc.CloseAndRecv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return summary.Count
+	}
+
+	var buf bytes.Buffer
+	rec, err := NewRecorderWriter(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, close := newTestClient(t, srv.Addr, rec.DialOptions())
+	setStream(client, "a")
+	setStream(client, "b", "c")
+	close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := NewReplayerReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, close = newTestClient(t, srv.Addr, rep.DialOptions())
+	defer close()
+	// Replay the streams in the opposite order, with the sends of the second
+	// reordered.
+	if got := setStream(client, "c", "b"); got != 2 {
+		t.Errorf("got count %d, want 2", got)
+	}
+	if got := setStream(client, "a"); got != 1 {
+		t.Errorf("got count %d, want 1", got)
+	}
+}
+
+func TestRecorderRedactFields(t *testing.T) {
+	srv := newIntStoreServer()
+	defer srv.stop()
+
+	var buf bytes.Buffer
+	rec, err := NewRecorderWriter(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.BeforeFunc = RedactFields("name")
+	client, close := newTestClient(t, srv.Addr, rec.DialOptions())
+	ctx := context.Background()
+	if _, err := client.Set(ctx, &ipb.Item{Name: "secret", Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	chatc, err := client.StreamChat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := &ipb.Item{Name: "secret", Value: 2}
+	if err := chatc.Send(item); err != nil {
+		t.Fatal(err)
+	}
+	got, err := chatc.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The messages seen by the client are not redacted.
+	if item.Name != "secret" || got.Name != "secret" {
+		t.Errorf("client messages were redacted: sent %v, received %v", item, got)
+	}
+	close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Error("replay file contains redacted data")
+	}
+
+	// Redacted requests can be matched by redacting on replay, too.
+	rep, err := NewReplayerReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.BeforeFunc = RedactFields("name")
+	client, close = newTestClient(t, srv.Addr, rep.DialOptions())
+	defer close()
+	if _, err := client.Set(ctx, &ipb.Item{Name: "other", Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestClient(t *testing.T, addr string, opts []grpc.DialOption) (ipb.IntStoreClient, func()) {
+	conn, err := grpc.Dial(addr, append([]grpc.DialOption{grpc.WithInsecure()}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ipb.NewIntStoreClient(conn), func() { conn.Close() }
+}
@@ -50,7 +50,10 @@ type Recorder struct {
 	// is written to the replay file.
 	// The function is called with the method name and the message that triggered the callback.
 	// If the function returns an error, the error will be returned to the client.
-	// This is only executed for unary RPCs; streaming RPCs are not supported.
+	// For streaming RPCs, it is run before each sent or received message is written
+	// to the replay file.
+	//
+	// RedactFields returns a BeforeFunc that clears fields that should not be saved.
 	BeforeFunc func(string, proto.Message) error
 }
 
@@ -184,6 +187,7 @@ func (r *Recorder) interceptStream(ctx context.Context, desc *grpc.StreamDesc, c
 		ctx:      ctx,
 		rec:      r,
 		cstream:  cstream,
+		method:   method,
 		refIndex: refIndex,
 	}, serr
 }
@@ -195,6 +199,7 @@ type recClientStream struct {
 	ctx      context.Context
 	rec      *Recorder
 	cstream  grpc.ClientStream
+	method   string
 	refIndex int
 }
 
@@ -206,7 +211,9 @@ func (rcs *recClientStream) SendMsg(m interface{}) error {
 		kind:     pb.Entry_SEND,
 		refIndex: rcs.refIndex,
 	}
-	e.msg.set(m, serr)
+	if err := rcs.setMsg(e, m, serr); err != nil {
+		return err
+	}
 	if _, err := rcs.rec.writeEntry(e); err != nil {
 		return err
 	}
@@ -219,13 +226,30 @@ func (rcs *recClientStream) RecvMsg(m interface{}) error {
 		kind:     pb.Entry_RECV,
 		refIndex: rcs.refIndex,
 	}
-	e.msg.set(m, serr)
+	if err := rcs.setMsg(e, m, serr); err != nil {
+		return err
+	}
 	if _, err := rcs.rec.writeEntry(e); err != nil {
 		return err
 	}
 	return serr
 }
 
+// setMsg sets the message of e, calling the Recorder's BeforeFunc on a copy of
+// m if there is one.
+func (rcs *recClientStream) setMsg(e *entry, m interface{}, serr error) error {
+	if rcs.rec.BeforeFunc == nil || serr != nil || m == nil {
+		e.msg.set(m, serr)
+		return nil
+	}
+	msg := proto.Clone(m.(proto.Message))
+	if err := rcs.rec.BeforeFunc(rcs.method, msg); err != nil {
+		return err
+	}
+	e.msg.set(msg, serr)
+	return nil
+}
+
 func (rcs *recClientStream) Header() (metadata.MD, error) {
 	// TODO(jba): record.
 	return rcs.cstream.Header()
@@ -253,8 +277,16 @@ type Replayer struct {
 	// are matched for responses from the replay file.
 	// The function is called with the method name and the message that triggered the callback.
 	// If the function returns an error, the error will be returned to the client.
-	// This is only executed for unary RPCs; streaming RPCs are not supported.
+	// For streaming RPCs, it is run on a copy of each sent message.
 	BeforeFunc func(string, proto.Message) error
+
+	// Matcher, if non-nil, is used to match requests to recorded requests for
+	// the same method when no recorded request is equal. It is also used for the
+	// messages sent on streams.
+	//
+	// IgnoreFields returns a Matcher for requests that contain timestamps or
+	// other values that differ from run to run.
+	Matcher Matcher
 }
 
 // A call represents a unary RPC, with a request and response (or error).
@@ -397,7 +429,7 @@ func (r *Replayer) interceptUnary(_ context.Context, method string, req, res int
 		}
 	}
 	r.log("request %s (%s)", method, req)
-	call := r.extractCall(method, mreq)
+	call := r.extractCall(method, mreq, r.Matcher)
 	if call == nil {
 		return fmt.Errorf("replayer: request not found: %s", mreq)
 	}
@@ -411,35 +443,75 @@ func (r *Replayer) interceptUnary(_ context.Context, method string, req, res int
 
 func (r *Replayer) interceptStream(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ grpc.Streamer, _ ...grpc.CallOption) (grpc.ClientStream, error) {
 	r.log("create-stream %s", method)
-	str := r.extractStream(method)
-	if str == nil {
-		return nil, fmt.Errorf("replayer: stream not found for method %s", method)
-	}
-	if str.createErr != nil {
-		return nil, str.createErr
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Concurrent streams for the same method may be created in a different
+	// order than when recording. Unless the next recorded stream for the method
+	// failed to be created, we wait until the first message is sent to decide
+	// which recorded stream the new one corresponds to.
+	for i, str := range r.streams {
+		if str == nil || str.method != method {
+			continue
+		}
+		if str.createErr != nil {
+			r.streams[i] = nil
+			return nil, str.createErr
+		}
+		return &repClientStream{ctx: ctx, rep: r, method: method}, nil
 	}
-	return &repClientStream{ctx: ctx, str: str}, nil
+	return nil, fmt.Errorf("replayer: stream not found for method %s", method)
 }
 
 type repClientStream struct {
-	ctx context.Context
-	str *stream
+	ctx    context.Context
+	rep    *Replayer
+	method string
+
+	mu  sync.Mutex
+	str *stream // nil until the first message is sent or received
 }
 
 func (rcs *repClientStream) Context() context.Context { return rcs.ctx }
 
 func (rcs *repClientStream) SendMsg(m interface{}) error {
+	msg := proto.Clone(m.(proto.Message))
+	if rcs.rep.BeforeFunc != nil {
+		if err := rcs.rep.BeforeFunc(rcs.method, msg); err != nil {
+			return err
+		}
+	}
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+	if rcs.str == nil {
+		rcs.str = rcs.rep.extractStream(rcs.method, msg)
+		if rcs.str == nil {
+			return fmt.Errorf("replayer: stream not found for method %s", rcs.method)
+		}
+	}
 	if len(rcs.str.sends) == 0 {
 		return fmt.Errorf("replayer: no more sends for stream %s, created at index %d",
 			rcs.str.method, rcs.str.createIndex)
 	}
-	// TODO(jba): Do not assume that the sends happen in the same order on replay.
-	msg := rcs.str.sends[0]
-	rcs.str.sends = rcs.str.sends[1:]
-	return msg.err
+	// Use the first recorded send that matches, in case sends happen in a
+	// different order on replay. If none match, use the next one.
+	i := indexOfMatch(rcs.str.sends, rcs.method, msg, rcs.rep.Matcher)
+	if i < 0 {
+		i = 0
+	}
+	sent := rcs.str.sends[i]
+	rcs.str.sends = append(rcs.str.sends[:i:i], rcs.str.sends[i+1:]...)
+	return sent.err
 }
 
 func (rcs *repClientStream) RecvMsg(m interface{}) error {
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+	if rcs.str == nil {
+		rcs.str = rcs.rep.extractStream(rcs.method, nil)
+		if rcs.str == nil {
+			return fmt.Errorf("replayer: stream not found for method %s", rcs.method)
+		}
+	}
 	if len(rcs.str.recvs) == 0 {
 		return fmt.Errorf("replayer: no more receives for stream %s, created at index %d",
 			rcs.str.method, rcs.str.createIndex)
@@ -453,6 +525,25 @@ func (rcs *repClientStream) RecvMsg(m interface{}) error {
 	return nil
 }
 
+// indexOfMatch returns the index of the first message in msgs that is equal to
+// msg or, failing that, that matches it according to matcher. It returns -1 if
+// there is none.
+func indexOfMatch(msgs []message, method string, msg proto.Message, matcher Matcher) int {
+	for i, m := range msgs {
+		if m.msg != nil && proto.Equal(m.msg, msg) {
+			return i
+		}
+	}
+	if matcher != nil {
+		for i, m := range msgs {
+			if m.msg != nil && matcher(method, msg, m.msg) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (rcs *repClientStream) Header() (metadata.MD, error) {
 	log.Printf("replay: stream metadata not supported")
 	return nil, nil
@@ -467,80 +558,66 @@ func (rcs *repClientStream) CloseSend() error {
 	return nil
 }
 
-// extractCall finds the first call in the list with the same method
-// and request. It returns nil if it can't find such a call.
-func (r *Replayer) extractCall(method string, req proto.Message) *call {
+// extractCall finds the first call in the list with the same method and an
+// equal request or, failing that, a request that matches according to matcher.
+// It returns nil if it can't find such a call.
+func (r *Replayer) extractCall(method string, req proto.Message, matcher Matcher) *call {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	for i, call := range r.calls {
-		if call == nil {
-			continue
-		}
-		if method == call.method && proto.Equal(req, call.request) {
-			r.calls[i] = nil // nil out this call so we don't reuse it
-			return call
+	match := func(eq func(req, recorded proto.Message) bool) *call {
+		for i, call := range r.calls {
+			if call == nil {
+				continue
+			}
+			if method == call.method && eq(req, call.request) {
+				r.calls[i] = nil // nil out this call so we don't reuse it
+				return call
+			}
 		}
+		return nil
 	}
-	return nil
+	if c := match(proto.Equal); c != nil || matcher == nil {
+		return c
+	}
+	return match(func(req, recorded proto.Message) bool { return matcher(method, req, recorded) })
 }
 
-func (r *Replayer) extractStream(method string) *stream {
+// extractStream finds the first successfully created stream for method whose
+// first send matches firstSend. Since sends may be reordered on replay, it next
+// tries streams with any matching send. If firstSend is nil or there is no
+// match, it uses the first stream for method. It returns nil if there is no
+// stream for method.
+func (r *Replayer) extractStream(method string, firstSend proto.Message) *stream {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	var candidates []int
 	for i, stream := range r.streams {
-		if stream == nil {
-			continue
-		}
-		if method == stream.method {
-			r.streams[i] = nil
-			return stream
+		if stream != nil && stream.method == method && stream.createErr == nil {
+			candidates = append(candidates, i)
 		}
 	}
-	return nil
-}
-
-// Fprint reads the entries from filename and writes them to w in human-readable form.
-// It is intended for debugging.
-func Fprint(w io.Writer, filename string) error {
-	f, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	return FprintReader(w, f)
-}
-
-// FprintReader reads the entries from r and writes them to w in human-readable form.
-// It is intended for debugging.
-func FprintReader(w io.Writer, r io.Reader) error {
-	initial, err := readHeader(r)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintf(w, "initial state: %q\n", string(initial))
-	for i := 1; ; i++ {
-		e, err := readEntry(r)
-		if err != nil {
-			return err
-		}
-		if e == nil {
-			return nil
-		}
-
-		s := "message"
-		if e.msg.err != nil {
-			s = "error"
-		}
-		fmt.Fprintf(w, "#%d: kind: %s, method: %s, ref index: %d, %s:\n",
-			i, e.kind, e.method, e.refIndex, s)
-		if e.msg.err == nil {
-			if err := proto.MarshalText(w, e.msg.msg); err != nil {
-				return err
+	if len(candidates) == 0 {
+		return nil
+	}
+	i := candidates[0]
+	if firstSend != nil {
+	Loop:
+		for _, firstOnly := range []bool{true, false} {
+			for _, c := range candidates {
+				sends := r.streams[c].sends
+				if firstOnly && len(sends) > 1 {
+					sends = sends[:1]
+				}
+				if indexOfMatch(sends, method, firstSend, r.Matcher) >= 0 {
+					i = c
+					break Loop
+				}
 			}
-		} else {
-			fmt.Fprintf(w, "%v\n", e.msg.err)
 		}
 	}
+	str := r.streams[i]
+	r.streams[i] = nil
+	return str
 }
 
 // An entry holds one gRPC action (request, response, etc.).
@@ -659,15 +736,8 @@ func writeEntry(w io.Writer, e *entry) error {
 }
 
 func readEntry(r io.Reader) (*entry, error) {
-	buf, err := readRecord(r)
-	if err == io.EOF {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	var pe pb.Entry
-	if err := proto.Unmarshal(buf, &pe); err != nil {
+	pe, err := readPBEntry(r)
+	if pe == nil || err != nil {
 		return nil, err
 	}
 	var msg message
@@ -694,6 +764,23 @@ func readEntry(r io.Reader) (*entry, error) {
 	}, nil
 }
 
+// readPBEntry reads an Entry proto. It returns nil, nil at the end of the
+// stream.
+func readPBEntry(r io.Reader) (*pb.Entry, error) {
+	buf, err := readRecord(r)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pe pb.Entry
+	if err := proto.Unmarshal(buf, &pe); err != nil {
+		return nil, err
+	}
+	return &pe, nil
+}
+
 // A record consists of an unsigned 32-bit little-endian length L followed by L
 // bytes.
 
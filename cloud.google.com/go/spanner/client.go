@@ -199,6 +199,14 @@ func (c *Client) Close() {
 	}
 }
 
+// Stats returns statistics about the client's session pool.
+func (c *Client) Stats() SessionPoolStats {
+	if c.idleSessions == nil {
+		return SessionPoolStats{}
+	}
+	return c.idleSessions.stats()
+}
+
 // Single provides a read-only snapshot transaction optimized for the case
 // where only a single read or query is needed.  This is more efficient than
 // using ReadOnlyTransaction() for a single read or query.
@@ -55,7 +55,7 @@ import (
 //     - bool and NullBool are mapped to Cloud Spanner's BOOL type.
 //     - []byte is mapped to Cloud Spanner's BYTES type.
 //     - string and NullString are mapped to Cloud Spanner's STRING type.
-//     - time.Time and NullTime are mapped to Cloud Spanner's TIMESTAMP type.
+//     - time.Time, NullTime and civil.Timestamp are mapped to Cloud Spanner's TIMESTAMP type.
 //     - civil.Date and NullDate are mapped to Cloud Spanner's DATE type.
 type Key []interface{}
 
@@ -84,7 +84,7 @@ func keyPartValue(part interface{}) (pb *proto3.Value, err error) {
 		pb, _, err = encodeValue(int64(v))
 	case float32:
 		pb, _, err = encodeValue(float64(v))
-	case int64, float64, NullInt64, NullFloat64, bool, NullBool, []byte, string, NullString, time.Time, civil.Date, NullTime, NullDate:
+	case int64, float64, NullInt64, NullFloat64, bool, NullBool, []byte, string, NullString, time.Time, civil.Timestamp, civil.Date, NullTime, NullDate:
 		pb, _, err = encodeValue(v)
 	default:
 		return nil, errInvdKeyPartType(v)
@@ -140,7 +140,7 @@ func (key Key) String() string {
 		case NullInt64, NullFloat64, NullBool, NullString, NullTime, NullDate:
 			// The above types implement fmt.Stringer.
 			fmt.Fprintf(b, "%s", v)
-		case civil.Date:
+		case civil.Date, civil.Timestamp:
 			fmt.Fprintf(b, "%q", v)
 		case time.Time:
 			fmt.Fprintf(b, "%q", v.Format(time.RFC3339Nano))
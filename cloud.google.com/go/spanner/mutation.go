@@ -116,8 +116,8 @@ const (
 //     []bool, []NullBool - BOOL ARRAY
 //     float64, NullFloat64 - FLOAT64
 //     []float64, []NullFloat64 - FLOAT64 ARRAY
-//     time.Time, NullTime - TIMESTAMP
-//     []time.Time, []NullTime - TIMESTAMP ARRAY
+//     time.Time, NullTime, civil.Timestamp - TIMESTAMP
+//     []time.Time, []NullTime, []civil.Timestamp - TIMESTAMP ARRAY
 //     Date, NullDate - DATE
 //     []Date, []NullDate - DATE ARRAY
 //
@@ -61,12 +61,15 @@ import (
 //	*[]float64, *[]NullFloat64 - FLOAT64 ARRAY
 //	*time.Time(not NULL), *NullTime - TIMESTAMP
 //	*[]time.Time, *[]NullTime - TIMESTAMP ARRAY
+//	*civil.Timestamp(not NULL) - TIMESTAMP
+//	*[]civil.Timestamp - TIMESTAMP ARRAY
 //	*Date(not NULL), *NullDate - DATE
 //	*[]civil.Date, *[]NullDate - DATE ARRAY
 //	*[]*some_go_struct, *[]NullRow - STRUCT ARRAY
 //	*GenericColumnValue - any Cloud Spanner type
 //
-// For TIMESTAMP columns, the returned time.Time object will be in UTC.
+// For TIMESTAMP columns, the returned time.Time object will be in UTC, and the
+// returned civil.Timestamp will have the zone "UTC".
 //
 // To fetch an array of BYTES, pass a *[][]byte. To fetch an array of (sub)rows, pass
 // a *[]spanner.NullRow or a *[]*some_go_struct where some_go_struct holds all
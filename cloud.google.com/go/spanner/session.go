@@ -314,10 +314,43 @@ type SessionPoolConfig struct {
 	HealthCheckInterval time.Duration
 	// healthCheckSampleInterval is how often the health checker samples live session (for use in maintaining session pool size). Defaults to 1 min.
 	healthCheckSampleInterval time.Duration
+	// TakeWaitThreshold enables adaptive sizing of the session pool when positive. In each sample interval,
+	// the health checker counts the session requests that found no idle session and waited longer than
+	// TakeWaitThreshold, and keeps that many more sessions idle (up to MaxOpened), creating them in batches
+	// of up to MaxBurst. After a sample interval with no such waits, the extra idle sessions are halved.
+	// Defaults to 0, which sizes the pool from MinOpened, MaxIdle and the sessions in use only.
+	TakeWaitThreshold time.Duration
 	// sessionLabels for the sessions created in the session pool.
 	sessionLabels map[string]string
 }
 
+// SessionPoolStats holds statistics about a session pool.
+type SessionPoolStats struct {
+	// Opened is the number of open sessions, including those being created.
+	Opened uint64
+	// InUse is the number of sessions in use by transactions.
+	InUse uint64
+	// Idle is the number of idle sessions that are not prepared for write.
+	Idle uint64
+	// WritePrepared is the number of idle sessions that are prepared for write.
+	WritePrepared uint64
+	// Creating is the number of sessions being created.
+	Creating uint64
+	// Waiters is the number of session requests waiting for a session to become available.
+	Waiters uint64
+	// WaitCount is the total number of session requests that found no idle session.
+	WaitCount uint64
+	// WaitDuration is the total time those requests waited for a session.
+	WaitDuration time.Duration
+	// CreateCount is the total number of sessions created.
+	CreateCount uint64
+	// CreateLatency is the average time taken to create a session.
+	CreateLatency time.Duration
+	// ExtraIdle is the number of idle sessions kept in addition to MaxIdle by adaptive sizing.
+	// See SessionPoolConfig.TakeWaitThreshold.
+	ExtraIdle uint64
+}
+
 // errNoRPCGetter returns error for SessionPoolConfig missing getRPCClient method.
 func errNoRPCGetter() error {
 	return spannerErrorf(codes.InvalidArgument, "require SessionPoolConfig.getRPCClient != nil, got nil")
@@ -360,6 +393,18 @@ type sessionPool struct {
 	createReqs uint64
 	// prepareReqs is the number of ongoing session preparation request.
 	prepareReqs uint64
+	// waiters is the number of session requests blocked waiting for a session.
+	waiters uint64
+	// waitCount and waitDuration are the total number of session requests that found no idle session and the total time they waited.
+	waitCount    uint64
+	waitDuration time.Duration
+	// slowTakes is the number of session requests that waited longer than TakeWaitThreshold since the maintainer last sampled the pool.
+	slowTakes uint64
+	// extraIdle is the number of idle sessions kept in addition to MaxIdle by adaptive sizing.
+	extraIdle uint64
+	// createCount and createDuration are the total number of sessions created and the total time taken to create them.
+	createCount    uint64
+	createDuration time.Duration
 	// configuration of the session pool.
 	SessionPoolConfig
 	// Metadata to be sent with each request
@@ -433,6 +478,56 @@ func (p *sessionPool) close() {
 	}
 }
 
+// stats returns the current statistics of the session pool.
+func (p *sessionPool) stats() SessionPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := SessionPoolStats{
+		Opened:        p.numOpened,
+		Idle:          uint64(p.idleList.Len()),
+		WritePrepared: uint64(p.idleWriteList.Len()),
+		Creating:      p.createReqs,
+		Waiters:       p.waiters,
+		WaitCount:     p.waitCount,
+		WaitDuration:  p.waitDuration,
+		CreateCount:   p.createCount,
+		ExtraIdle:     p.extraIdle,
+	}
+	// Sessions being prepared for write by the health checker are neither idle nor in use.
+	if notInUse := st.Idle + st.WritePrepared + st.Creating + p.prepareReqs; st.Opened > notInUse {
+		st.InUse = st.Opened - notInUse
+	}
+	if p.createCount > 0 {
+		st.CreateLatency = p.createDuration / time.Duration(p.createCount)
+	}
+	return st
+}
+
+// recordWait records that a session request found no idle session and waited d for one.
+func (p *sessionPool) recordWait(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waitCount++
+	p.waitDuration += d
+	if p.TakeWaitThreshold > 0 && d > p.TakeWaitThreshold {
+		p.slowTakes++
+	}
+}
+
+// adaptIdle updates the number of extra idle sessions from the session requests that waited too long since the
+// last call. p.mu must be held.
+func (p *sessionPool) adaptIdle() {
+	if p.slowTakes > 0 {
+		p.extraIdle += p.slowTakes
+		if p.MaxOpened > 0 && p.extraIdle > p.MaxOpened {
+			p.extraIdle = p.MaxOpened
+		}
+	} else {
+		p.extraIdle /= 2
+	}
+	p.slowTakes = 0
+}
+
 // errInvalidSessionPool returns error for using an invalid session pool.
 func errInvalidSessionPool() error {
 	return spannerErrorf(codes.InvalidArgument, "invalid session pool")
@@ -450,12 +545,16 @@ func (p *sessionPool) shouldPrepareWrite() bool {
 
 func (p *sessionPool) createSession(ctx context.Context) (*session, error) {
 	tracePrintf(ctx, nil, "Creating a new session")
+	start := time.Now()
 	doneCreate := func(done bool) {
 		p.mu.Lock()
 		if !done {
 			// Session creation failed, give budget back.
 			p.numOpened--
 			recordStat(ctx, OpenSessionCount, int64(p.numOpened))
+		} else {
+			p.createCount++
+			p.createDuration += time.Since(start)
 		}
 		p.createReqs--
 		// Notify other waiters blocking on session creation.
@@ -500,6 +599,67 @@ func createSession(ctx context.Context, sc sppb.SpannerClient, db string, labels
 	return s, nil
 }
 
+// batchCreateSessions creates up to n sessions concurrently, at most MaxBurst at a time, and puts them in the idle lists,
+// preparing them for write as needed to maintain WriteSessions. It stops early if ctx is done, the session pool is closed
+// or the session pool reaches MaxOpened.
+func (p *sessionPool) batchCreateSessions(ctx context.Context, n uint64) {
+	burst := p.MaxBurst
+	if burst == 0 || burst > n {
+		burst = n
+	}
+	if burst == 0 {
+		return
+	}
+	ctx = contextWithOutgoingMetadata(ctx, p.md)
+	sem := make(chan struct{}, burst)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i := uint64(0); i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		p.mu.Lock()
+		if !p.valid || (p.MaxOpened > 0 && p.numOpened >= p.MaxOpened) {
+			p.mu.Unlock()
+			return
+		}
+		// Take budget before the actual session creation.
+		p.numOpened++
+		recordStat(ctx, OpenSessionCount, int64(p.numOpened))
+		p.createReqs++
+		prepare := p.shouldPrepareWrite()
+		if prepare {
+			p.prepareReqs++
+		}
+		p.mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if prepare {
+					p.mu.Lock()
+					p.prepareReqs--
+					p.mu.Unlock()
+				}
+				<-sem
+				wg.Done()
+			}()
+			s, err := p.createSession(ctx)
+			if err != nil {
+				log.Printf("Failed to create session, error: %v", toSpannerError(err))
+				return
+			}
+			if prepare {
+				if err := s.prepareForWrite(ctx); err != nil {
+					log.Printf("Failed to prepare session, error: %v", toSpannerError(err))
+				}
+			}
+			p.recycle(s)
+		}()
+	}
+}
+
 func (p *sessionPool) isHealthy(s *session) bool {
 	if s.getNextCheck().Add(2 * p.hc.getInterval()).Before(time.Now()) {
 		// TODO: figure out if we need to schedule a new healthcheck worker here.
@@ -518,6 +678,9 @@ func (p *sessionPool) isHealthy(s *session) bool {
 func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 	tracePrintf(ctx, nil, "Acquiring a read-only session")
 	ctx = contextWithOutgoingMetadata(ctx, p.md)
+	start := time.Now()
+	// waited is true if no idle session was available at the first attempt.
+	waited := false
 	for {
 		var (
 			s   *session
@@ -548,18 +711,20 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 			if !p.isHealthy(s) {
 				continue
 			}
+			if waited {
+				p.recordWait(time.Since(start))
+			}
 			return &sessionHandle{session: s}, nil
 		}
 		// Idle list is empty, block if session pool has reached max session creation concurrency or max number of open sessions.
+		waited = true
 		if (p.MaxOpened > 0 && p.numOpened >= p.MaxOpened) || (p.MaxBurst > 0 && p.createReqs >= p.MaxBurst) {
 			mayGetSession := p.mayGetSession
+			p.waiters++
 			p.mu.Unlock()
 			tracePrintf(ctx, nil, "Waiting for read-only session to become available")
-			select {
-			case <-ctx.Done():
-				tracePrintf(ctx, nil, "Context done waiting for session")
-				return nil, errGetSessionTimeout()
-			case <-mayGetSession:
+			if err := p.waitForSession(ctx, mayGetSession); err != nil {
+				return nil, err
 			}
 			continue
 		}
@@ -572,6 +737,7 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 			tracePrintf(ctx, nil, "Error creating session: %v", err)
 			return nil, toSpannerError(err)
 		}
+		p.recordWait(time.Since(start))
 		tracePrintf(ctx, map[string]interface{}{"sessionID": s.getID()},
 			"Created session")
 		return &sessionHandle{session: s}, nil
@@ -583,6 +749,9 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 func (p *sessionPool) takeWriteSession(ctx context.Context) (*sessionHandle, error) {
 	tracePrintf(ctx, nil, "Acquiring a read-write session")
 	ctx = contextWithOutgoingMetadata(ctx, p.md)
+	start := time.Now()
+	// waited is true if no idle session was available at the first attempt.
+	waited := false
 	for {
 		var (
 			s   *session
@@ -611,17 +780,19 @@ func (p *sessionPool) takeWriteSession(ctx context.Context) (*sessionHandle, err
 			if !p.isHealthy(s) {
 				continue
 			}
+			if waited {
+				p.recordWait(time.Since(start))
+			}
 		} else {
 			// Idle list is empty, block if session pool has reached max session creation concurrency or max number of open sessions.
+			waited = true
 			if (p.MaxOpened > 0 && p.numOpened >= p.MaxOpened) || (p.MaxBurst > 0 && p.createReqs >= p.MaxBurst) {
 				mayGetSession := p.mayGetSession
+				p.waiters++
 				p.mu.Unlock()
 				tracePrintf(ctx, nil, "Waiting for read-write session to become available")
-				select {
-				case <-ctx.Done():
-					tracePrintf(ctx, nil, "Context done waiting for session")
-					return nil, errGetSessionTimeout()
-				case <-mayGetSession:
+				if err := p.waitForSession(ctx, mayGetSession); err != nil {
+					return nil, err
 				}
 				continue
 			}
@@ -635,6 +806,7 @@ func (p *sessionPool) takeWriteSession(ctx context.Context) (*sessionHandle, err
 				tracePrintf(ctx, nil, "Error creating session: %v", err)
 				return nil, toSpannerError(err)
 			}
+			p.recordWait(time.Since(start))
 			tracePrintf(ctx, map[string]interface{}{"sessionID": s.getID()},
 				"Created session")
 		}
@@ -650,6 +822,22 @@ func (p *sessionPool) takeWriteSession(ctx context.Context) (*sessionHandle, err
 	}
 }
 
+// waitForSession blocks until mayGetSession is closed or ctx is done. The caller must have counted itself in p.waiters.
+func (p *sessionPool) waitForSession(ctx context.Context, mayGetSession chan struct{}) error {
+	defer func() {
+		p.mu.Lock()
+		p.waiters--
+		p.mu.Unlock()
+	}()
+	select {
+	case <-ctx.Done():
+		tracePrintf(ctx, nil, "Context done waiting for session")
+		return errGetSessionTimeout()
+	case <-mayGetSession:
+		return nil
+	}
+}
+
 // recycle puts session s back to the session pool's idle list, it returns true if the session pool successfully recycles session s.
 func (p *sessionPool) recycle(s *session) bool {
 	p.mu.Lock()
@@ -965,45 +1153,18 @@ func (hc *healthChecker) maintainer() {
 	)
 
 	// replenishPool is run if numOpened is less than sessionsToKeep, timeouts on sampleInterval.
+	// It creates the missing sessions in batches.
 	replenishPool := func(sessionsToKeep uint64) {
-		ctx, _ := context.WithTimeout(context.Background(), hc.sampleInterval)
-		for {
-			select {
-			case <-timeout:
-				return
-			default:
-				break
-			}
-
-			p := hc.pool
-			p.mu.Lock()
-			// Take budget before the actual session creation.
-			if sessionsToKeep <= p.numOpened {
-				p.mu.Unlock()
-				break
-			}
-			p.numOpened++
-			recordStat(ctx, OpenSessionCount, int64(p.numOpened))
-			p.createReqs++
-			shouldPrepareWrite := p.shouldPrepareWrite()
-			p.mu.Unlock()
-			var (
-				s   *session
-				err error
-			)
-			if s, err = p.createSession(ctx); err != nil {
-				log.Printf("Failed to create session, error: %v", toSpannerError(err))
-				continue
-			}
-			if shouldPrepareWrite {
-				if err = s.prepareForWrite(ctx); err != nil {
-					p.recycle(s)
-					log.Printf("Failed to prepare session, error: %v", toSpannerError(err))
-					continue
-				}
-			}
-			p.recycle(s)
+		ctx, cancel := context.WithTimeout(context.Background(), hc.sampleInterval)
+		defer cancel()
+		p := hc.pool
+		p.mu.Lock()
+		var n uint64
+		if sessionsToKeep > p.numOpened {
+			n = sessionsToKeep - p.numOpened
 		}
+		p.mu.Unlock()
+		p.batchCreateSessions(ctx, n)
 	}
 
 	// shrinkPool, scales down the session pool.
@@ -1053,6 +1214,10 @@ func (hc *healthChecker) maintainer() {
 		hc.pool.mu.Lock()
 		currSessionsInUse := hc.pool.numOpened - uint64(hc.pool.idleList.Len()) - uint64(hc.pool.idleWriteList.Len())
 		currSessionsOpened := hc.pool.numOpened
+		if hc.pool.TakeWaitThreshold > 0 {
+			hc.pool.adaptIdle()
+		}
+		extraIdle := hc.pool.extraIdle
 		hc.pool.mu.Unlock()
 
 		hc.mu.Lock()
@@ -1061,6 +1226,14 @@ func (hc *healthChecker) maintainer() {
 		}
 		sessionsToKeep := maxUint64(hc.pool.MinOpened,
 			minUint64(currSessionsOpened, hc.pool.MaxIdle+maxSessionsInUse))
+		if extraIdle > 0 {
+			// Grow the pool ahead of demand, since recent session requests had to wait.
+			target := currSessionsInUse + hc.pool.MaxIdle + extraIdle
+			if hc.pool.MaxOpened > 0 {
+				target = minUint64(target, hc.pool.MaxOpened)
+			}
+			sessionsToKeep = maxUint64(sessionsToKeep, target)
+		}
 		hc.mu.Unlock()
 
 		timeout = time.After(hc.sampleInterval)
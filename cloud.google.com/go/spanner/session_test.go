@@ -871,6 +871,108 @@ func TestMaintainer(t *testing.T) {
 	sp.mu.Unlock()
 }
 
+// TestSessionPoolStats tests the statistics reported by the session pool.
+func TestSessionPoolStats(t *testing.T) {
+	t.Parallel()
+	sp, _, cancel := setup(t, SessionPoolConfig{MaxOpened: 2})
+	defer cancel()
+	sh1, err := sp.take(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh2, err := sp.take(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := sp.stats()
+	if st.Opened != 2 || st.InUse != 2 || st.Idle != 0 || st.CreateCount != 2 || st.WaitCount != 2 {
+		t.Errorf("got %+v, want 2 opened, in use, created and waited", st)
+	}
+
+	// A third request waits until a session is recycled.
+	errc := make(chan error, 1)
+	go func() {
+		sh, err := sp.take(context.Background())
+		if err == nil {
+			sh.recycle()
+		}
+		errc <- err
+	}()
+	for {
+		if sp.stats().Waiters == 1 {
+			break
+		}
+		<-time.After(time.Millisecond)
+	}
+	sh1.recycle()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	sh2.recycle()
+	st = sp.stats()
+	if st.Opened != 2 || st.InUse != 0 || st.Idle+st.WritePrepared != 2 || st.Waiters != 0 || st.WaitCount != 3 {
+		t.Errorf("got %+v, want 2 opened and idle, 3 waited", st)
+	}
+}
+
+// TestBatchCreateSessions tests that the session pool creates sessions concurrently, within MaxBurst.
+func TestBatchCreateSessions(t *testing.T) {
+	t.Parallel()
+	// MaxIdle keeps the maintainer from expiring the idle sessions.
+	sp, _, cancel := setup(t, SessionPoolConfig{MaxOpened: 8, MaxBurst: 3, MaxIdle: 10})
+	defer cancel()
+	sp.batchCreateSessions(context.Background(), 10)
+	st := sp.stats()
+	// The pool stops at MaxOpened.
+	if st.Opened != 8 || st.Idle+st.WritePrepared != 8 || st.CreateCount != 8 || st.Creating != 0 {
+		t.Errorf("got %+v, want 8 idle sessions", st)
+	}
+}
+
+// TestAdaptiveIdle tests that the maintainer keeps more sessions idle after session requests wait too long.
+func TestAdaptiveIdle(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.SkipNow()
+	}
+	sp, _, cancel := setup(t, SessionPoolConfig{MaxOpened: 20, TakeWaitThreshold: time.Nanosecond})
+	defer cancel()
+	// Every take creates a session, which is slower than TakeWaitThreshold.
+	var shs []*sessionHandle
+	for i := 0; i < 5; i++ {
+		sh, err := sp.take(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		shs = append(shs, sh)
+	}
+	// The maintainer creates 5 extra idle sessions in addition to the 5 in use.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := sp.stats()
+		if st.CreateCount >= 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool did not grow: %+v", st)
+		}
+		<-time.After(sp.healthCheckSampleInterval)
+	}
+	for _, sh := range shs {
+		sh.recycle()
+	}
+	// With no more waits, the extra idle sessions decay.
+	for {
+		if st := sp.stats(); st.ExtraIdle == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("extra idle sessions did not decay: %+v", sp.stats())
+		}
+		<-time.After(sp.healthCheckSampleInterval)
+	}
+}
+
 func (s1 *session) Equal(s2 *session) bool {
 	return s1.client == s2.client &&
 		s1.id == s2.id &&
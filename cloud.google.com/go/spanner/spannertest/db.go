@@ -0,0 +1,959 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spannertest
+
+// This file contains the storage of the fake: the schema of a database,
+// its multi-version data, and the application of DDL and mutations.
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// versionRetention is how long old versions of the data are kept for
+// stale reads. It matches the version GC period of Cloud Spanner.
+const versionRetention = time.Hour
+
+type column struct {
+	name                 string
+	typ                  *sppb.Type
+	size                 int64 // maximum length of a STRING or BYTES value; 0 for MAX
+	notNull              bool
+	allowCommitTimestamp bool
+}
+
+type keyPart struct {
+	col  string
+	desc bool
+}
+
+// table is the schema and contents of a table at one version of a database.
+// Tables in a committed version are never modified; writers work on copies.
+type table struct {
+	seq     int // creation order, used to order the DDL
+	name    string
+	cols    []*column
+	pk      []keyPart
+	parent  string // the table this one is interleaved in, if any
+	cascade bool   // ON DELETE CASCADE
+	rows    []row  // sorted by primary key
+}
+
+// row holds the values of a table's columns, in column order.
+type row []interface{}
+
+type index struct {
+	seq          int
+	name         string
+	table        string
+	key          []keyPart
+	storing      []string
+	unique       bool
+	nullFiltered bool
+	interleave   string
+}
+
+// version is an immutable snapshot of a database's schema and data.
+type version struct {
+	ts      time.Time
+	tables  map[string]*table // keyed by lower-case name
+	indexes map[string]*index // keyed by lower-case name
+	seq     int               // the next schema object sequence number
+}
+
+func (v *version) table(name string) (*table, error) {
+	t, ok := v.tables[strings.ToLower(name)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "table not found: %s", name)
+	}
+	return t, nil
+}
+
+func (v *version) index(name string) (*index, error) {
+	idx, ok := v.indexes[strings.ToLower(name)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "index not found: %s", name)
+	}
+	return idx, nil
+}
+
+// database holds the versions of a database. It is guarded by server.mu.
+type database struct {
+	name     string
+	created  time.Time
+	versions []*version     // ordered by timestamp; the last is the current one
+	modified map[string]int // table name to the count of the last commit that wrote it
+	commits  int            // the number of commits so far
+	lastTS   time.Time      // the latest timestamp handed out
+}
+
+func newDatabase(name string, now time.Time) *database {
+	return &database{
+		name:     name,
+		created:  now,
+		versions: []*version{{ts: now, tables: map[string]*table{}, indexes: map[string]*index{}}},
+		modified: map[string]int{},
+		lastTS:   now,
+	}
+}
+
+func (d *database) current() *version { return d.versions[len(d.versions)-1] }
+
+// nextTimestamp returns a timestamp for a commit. Commit timestamps strictly
+// increase, and are later than any read timestamp already handed out.
+func (d *database) nextTimestamp(now time.Time) time.Time {
+	if !now.After(d.lastTS) {
+		now = d.lastTS.Add(time.Microsecond)
+	}
+	// Cloud Spanner timestamps have microsecond precision.
+	now = now.Truncate(time.Microsecond)
+	if !now.After(d.lastTS) {
+		now = now.Add(time.Microsecond)
+	}
+	d.lastTS = now
+	return now
+}
+
+// strongTimestamp returns a read timestamp that observes all commits so far.
+func (d *database) strongTimestamp(now time.Time) time.Time {
+	if now.After(d.lastTS) {
+		d.lastTS = now
+	}
+	return d.lastTS
+}
+
+// versionAt returns the version that was current at ts.
+func (d *database) versionAt(ts time.Time) (*version, error) {
+	for i := len(d.versions) - 1; i >= 0; i-- {
+		if !d.versions[i].ts.After(ts) {
+			return d.versions[i], nil
+		}
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "read timestamp %v is earlier than the oldest available version", ts)
+}
+
+// addVersion makes v current, and discards versions that can no longer be read.
+func (d *database) addVersion(v *version, now time.Time) {
+	d.versions = append(d.versions, v)
+	for len(d.versions) > 1 && d.versions[1].ts.Before(now.Add(-versionRetention)) {
+		d.versions = d.versions[1:]
+	}
+}
+
+func (t *table) colIndex(name string) int {
+	for i, c := range t.cols {
+		if strings.EqualFold(c.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// keyIndexes returns the column indexes of the given key parts, and whether
+// each is descending.
+func (t *table) keyIndexes(kps []keyPart) ([]int, []bool) {
+	idxs := make([]int, len(kps))
+	desc := make([]bool, len(kps))
+	for i, kp := range kps {
+		idxs[i] = t.colIndex(kp.col)
+		desc[i] = kp.desc
+	}
+	return idxs, desc
+}
+
+func (t *table) key(r row) []interface{} {
+	k := make([]interface{}, len(t.pk))
+	for i, kp := range t.pk {
+		k[i] = r[t.colIndex(kp.col)]
+	}
+	return k
+}
+
+func (t *table) pkDesc() []bool {
+	_, desc := t.keyIndexes(t.pk)
+	return desc
+}
+
+// find returns the position of the row with the given primary key, and
+// whether it exists. A partial key finds the first row with that prefix.
+func (t *table) find(key []interface{}) (int, bool) {
+	desc := t.pkDesc()
+	i := sort.Search(len(t.rows), func(i int) bool {
+		return compareKeys(t.key(t.rows[i]), key, desc) >= 0
+	})
+	return i, i < len(t.rows) && len(key) == len(t.pk) && compareKeys(t.key(t.rows[i]), key, desc) == 0
+}
+
+// copy returns a copy of t that can be modified without affecting t.
+// Rows are shared, so they must be replaced rather than modified.
+func (t *table) copy() *table {
+	t2 := *t
+	t2.rows = append([]row(nil), t.rows...)
+	t2.cols = append([]*column(nil), t.cols...)
+	return &t2
+}
+
+func (t *table) ddl() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE TABLE %s (\n", t.name)
+	for _, c := range t.cols {
+		fmt.Fprintf(&buf, "  %s %s,\n", c.name, c.ddl())
+	}
+	buf.WriteString(") PRIMARY KEY(")
+	writeKeyParts(&buf, t.pk)
+	buf.WriteString(")")
+	if t.parent != "" {
+		fmt.Fprintf(&buf, ",\n  INTERLEAVE IN PARENT %s", t.parent)
+		if t.cascade {
+			buf.WriteString(" ON DELETE CASCADE")
+		}
+	}
+	return buf.String()
+}
+
+func (c *column) ddl() string {
+	s := c.typeDDL(c.typ)
+	if c.notNull {
+		s += " NOT NULL"
+	}
+	if c.allowCommitTimestamp {
+		s += " OPTIONS (allow_commit_timestamp=true)"
+	}
+	return s
+}
+
+func (c *column) typeDDL(t *sppb.Type) string {
+	switch t.Code {
+	case sppb.TypeCode_ARRAY:
+		return "ARRAY<" + c.typeDDL(t.ArrayElementType) + ">"
+	case sppb.TypeCode_STRING, sppb.TypeCode_BYTES:
+		if c.size == 0 {
+			return t.Code.String() + "(MAX)"
+		}
+		return fmt.Sprintf("%s(%d)", t.Code, c.size)
+	}
+	return t.Code.String()
+}
+
+func (idx *index) ddl() string {
+	var buf bytes.Buffer
+	buf.WriteString("CREATE ")
+	if idx.unique {
+		buf.WriteString("UNIQUE ")
+	}
+	if idx.nullFiltered {
+		buf.WriteString("NULL_FILTERED ")
+	}
+	fmt.Fprintf(&buf, "INDEX %s ON %s(", idx.name, idx.table)
+	writeKeyParts(&buf, idx.key)
+	buf.WriteString(")")
+	if len(idx.storing) > 0 {
+		fmt.Fprintf(&buf, " STORING (%s)", strings.Join(idx.storing, ", "))
+	}
+	if idx.interleave != "" {
+		fmt.Fprintf(&buf, ", INTERLEAVE IN %s", idx.interleave)
+	}
+	return buf.String()
+}
+
+func writeKeyParts(buf *bytes.Buffer, kps []keyPart) {
+	for i, kp := range kps {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(kp.col)
+		if kp.desc {
+			buf.WriteString(" DESC")
+		}
+	}
+}
+
+// ddl returns the DDL statements that create v's schema.
+func (v *version) ddl() []string {
+	var ts []*table
+	for _, t := range v.tables {
+		ts = append(ts, t)
+	}
+	var idxs []*index
+	for _, idx := range v.indexes {
+		idxs = append(idxs, idx)
+	}
+	sort.Sort(tablesBySeq(ts))
+	sort.Sort(indexesBySeq(idxs))
+	var stmts []string
+	for _, t := range ts {
+		stmts = append(stmts, t.ddl())
+	}
+	for _, idx := range idxs {
+		stmts = append(stmts, idx.ddl())
+	}
+	return stmts
+}
+
+type tablesBySeq []*table
+
+func (s tablesBySeq) Len() int           { return len(s) }
+func (s tablesBySeq) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s tablesBySeq) Less(i, j int) bool { return s[i].seq < s[j].seq }
+
+type indexesBySeq []*index
+
+func (s indexesBySeq) Len() int           { return len(s) }
+func (s indexesBySeq) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s indexesBySeq) Less(i, j int) bool { return s[i].seq < s[j].seq }
+
+// writer accumulates changes to a version. Tables are copied when they are
+// first changed, so the original version is unaffected until the writer's
+// result is installed.
+type writer struct {
+	v       *version
+	copied  map[string]bool // tables that have been copied
+	commit  time.Time       // the commit timestamp, for commit timestamp placeholders
+	changed map[string]bool // tables whose data changed
+}
+
+func newWriter(v *version, ts time.Time) *writer {
+	v2 := &version{
+		ts:      ts,
+		tables:  make(map[string]*table, len(v.tables)),
+		indexes: make(map[string]*index, len(v.indexes)),
+		seq:     v.seq,
+	}
+	for k, t := range v.tables {
+		v2.tables[k] = t
+	}
+	for k, idx := range v.indexes {
+		v2.indexes[k] = idx
+	}
+	return &writer{v: v2, copied: map[string]bool{}, commit: ts, changed: map[string]bool{}}
+}
+
+// table returns a modifiable copy of the named table.
+func (w *writer) table(name string) (*table, error) {
+	t, err := w.v.table(name)
+	if err != nil {
+		return nil, err
+	}
+	k := strings.ToLower(t.name)
+	if !w.copied[k] {
+		t = t.copy()
+		w.v.tables[k] = t
+		w.copied[k] = true
+	}
+	return t, nil
+}
+
+// applyDDL applies a DDL statement to the writer's version.
+func (w *writer) applyDDL(stmt string) error {
+	s, err := parseDDL(stmt)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v: %s", err, stmt)
+	}
+	v := w.v
+	switch s := s.(type) {
+	case createDatabase:
+		return status.Errorf(codes.InvalidArgument, "CREATE DATABASE is only valid in CreateDatabase")
+	case createTable:
+		t := s.tbl
+		if err := v.checkNewName(t.name); err != nil {
+			return err
+		}
+		for _, kp := range t.pk {
+			i := t.colIndex(kp.col)
+			if i < 0 {
+				return status.Errorf(codes.InvalidArgument, "primary key column %s not found in table %s", kp.col, t.name)
+			}
+			if t.cols[i].typ.Code == sppb.TypeCode_ARRAY {
+				return status.Errorf(codes.InvalidArgument, "column %s has type ARRAY and cannot be part of a key", kp.col)
+			}
+		}
+		if t.parent != "" {
+			p, err := v.table(t.parent)
+			if err != nil {
+				return err
+			}
+			t.parent = p.name
+			if len(t.pk) <= len(p.pk) {
+				return status.Errorf(codes.InvalidArgument, "table %s must have more key columns than its parent %s", t.name, p.name)
+			}
+			for i, kp := range p.pk {
+				pc := p.cols[p.colIndex(kp.col)]
+				c := t.cols[t.colIndex(t.pk[i].col)]
+				if !strings.EqualFold(kp.col, t.pk[i].col) || !sameType(pc.typ, c.typ) || kp.desc != t.pk[i].desc {
+					return status.Errorf(codes.InvalidArgument, "the key of table %s must start with the key of its parent %s", t.name, p.name)
+				}
+			}
+		}
+		t.seq = v.seq
+		v.seq++
+		v.tables[strings.ToLower(t.name)] = t
+		w.copied[strings.ToLower(t.name)] = true
+		return nil
+	case createIndex:
+		idx := s.idx
+		if err := v.checkNewName(idx.name); err != nil {
+			return err
+		}
+		t, err := v.table(idx.table)
+		if err != nil {
+			return err
+		}
+		idx.table = t.name
+		for _, kp := range idx.key {
+			i := t.colIndex(kp.col)
+			if i < 0 {
+				return status.Errorf(codes.InvalidArgument, "index %s refers to unknown column %s", idx.name, kp.col)
+			}
+			if t.cols[i].typ.Code == sppb.TypeCode_ARRAY {
+				return status.Errorf(codes.InvalidArgument, "column %s has type ARRAY and cannot be part of a key", kp.col)
+			}
+		}
+		for _, c := range idx.storing {
+			if t.colIndex(c) < 0 {
+				return status.Errorf(codes.InvalidArgument, "index %s refers to unknown column %s", idx.name, c)
+			}
+			if idx.keyIndex(c) >= 0 || t.pkIndex(c) >= 0 {
+				return status.Errorf(codes.InvalidArgument, "index %s cannot store key column %s", idx.name, c)
+			}
+		}
+		if idx.interleave != "" {
+			p, err := v.table(idx.interleave)
+			if err != nil {
+				return err
+			}
+			idx.interleave = p.name
+		}
+		if err := idx.checkUnique(t); err != nil {
+			return err
+		}
+		idx.seq = v.seq
+		v.seq++
+		v.indexes[strings.ToLower(idx.name)] = idx
+		return nil
+	case dropTable:
+		t, err := v.table(s.name)
+		if err != nil {
+			return err
+		}
+		for _, idx := range v.indexes {
+			if strings.EqualFold(idx.table, t.name) || strings.EqualFold(idx.interleave, t.name) {
+				return status.Errorf(codes.FailedPrecondition, "cannot drop table %s with indexes: %s", t.name, idx.name)
+			}
+		}
+		for _, c := range v.tables {
+			if strings.EqualFold(c.parent, t.name) {
+				return status.Errorf(codes.FailedPrecondition, "cannot drop table %s with interleaved tables: %s", t.name, c.name)
+			}
+		}
+		delete(v.tables, strings.ToLower(t.name))
+		w.changed[strings.ToLower(t.name)] = true
+		return nil
+	case dropIndex:
+		idx, err := v.index(s.name)
+		if err != nil {
+			return err
+		}
+		delete(v.indexes, strings.ToLower(idx.name))
+		return nil
+	case addColumn:
+		t, err := w.table(s.table)
+		if err != nil {
+			return err
+		}
+		if t.colIndex(s.col.name) >= 0 {
+			return status.Errorf(codes.FailedPrecondition, "duplicate column %s in table %s", s.col.name, t.name)
+		}
+		if s.col.notNull && len(t.rows) > 0 {
+			return status.Errorf(codes.FailedPrecondition, "cannot add NOT NULL column %s to existing table %s", s.col.name, t.name)
+		}
+		t.cols = append(t.cols, s.col)
+		for i, r := range t.rows {
+			t.rows[i] = append(append(row(nil), r...), nil)
+		}
+		w.changed[strings.ToLower(t.name)] = true
+		return nil
+	case dropColumn:
+		t, err := w.table(s.table)
+		if err != nil {
+			return err
+		}
+		ci := t.colIndex(s.col)
+		if ci < 0 {
+			return status.Errorf(codes.NotFound, "column %s not found in table %s", s.col, t.name)
+		}
+		if t.pkIndex(s.col) >= 0 {
+			return status.Errorf(codes.FailedPrecondition, "cannot drop key column %s", s.col)
+		}
+		for _, idx := range v.indexes {
+			if strings.EqualFold(idx.table, t.name) && idx.refers(s.col) {
+				return status.Errorf(codes.FailedPrecondition, "cannot drop column %s used by index %s", s.col, idx.name)
+			}
+		}
+		t.cols = append(t.cols[:ci:ci], t.cols[ci+1:]...)
+		for i, r := range t.rows {
+			t.rows[i] = append(append(row(nil), r[:ci]...), r[ci+1:]...)
+		}
+		w.changed[strings.ToLower(t.name)] = true
+		return nil
+	}
+	panic("unreachable")
+}
+
+func (v *version) checkNewName(name string) error {
+	k := strings.ToLower(name)
+	if _, ok := v.tables[k]; ok {
+		return status.Errorf(codes.FailedPrecondition, "duplicate name in schema: %s", name)
+	}
+	if _, ok := v.indexes[k]; ok {
+		return status.Errorf(codes.FailedPrecondition, "duplicate name in schema: %s", name)
+	}
+	return nil
+}
+
+func (t *table) pkIndex(col string) int {
+	for i, kp := range t.pk {
+		if strings.EqualFold(kp.col, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (idx *index) keyIndex(col string) int {
+	for i, kp := range idx.key {
+		if strings.EqualFold(kp.col, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (idx *index) refers(col string) bool {
+	if idx.keyIndex(col) >= 0 {
+		return true
+	}
+	for _, c := range idx.storing {
+		if strings.EqualFold(c, col) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether col can be read from idx.
+func (idx *index) covers(t *table, col string) bool {
+	return idx.refers(col) || t.pkIndex(col) >= 0
+}
+
+// indexEntry is a row of a table as seen through an index.
+type indexEntry struct {
+	key []interface{} // the index key followed by the table's primary key
+	r   row
+}
+
+// entries returns the entries of idx over t, in index order.
+func (idx *index) entries(t *table) ([]indexEntry, []bool) {
+	kps := append(append([]keyPart(nil), idx.key...), t.pk...)
+	cols, desc := t.keyIndexes(kps)
+	var es []indexEntry
+rows:
+	for _, r := range t.rows {
+		k := make([]interface{}, len(cols))
+		for i, c := range cols {
+			k[i] = r[c]
+			if k[i] == nil && idx.nullFiltered && i < len(idx.key) {
+				continue rows
+			}
+		}
+		es = append(es, indexEntry{k, r})
+	}
+	sort.Stable(byIndexKey{es, desc})
+	return es, desc
+}
+
+type byIndexKey struct {
+	es   []indexEntry
+	desc []bool
+}
+
+func (b byIndexKey) Len() int      { return len(b.es) }
+func (b byIndexKey) Swap(i, j int) { b.es[i], b.es[j] = b.es[j], b.es[i] }
+func (b byIndexKey) Less(i, j int) bool {
+	return compareKeys(b.es[i].key, b.es[j].key, b.desc) < 0
+}
+
+// checkUnique returns an error if idx is unique and t has two rows with the
+// same index key.
+func (idx *index) checkUnique(t *table) error {
+	if !idx.unique {
+		return nil
+	}
+	es, desc := idx.entries(t)
+	n := len(idx.key)
+	for i := 1; i < len(es); i++ {
+		if compareKeys(es[i-1].key[:n], es[i].key[:n], desc) == 0 {
+			return status.Errorf(codes.AlreadyExists, "UNIQUE violation on index %s", idx.name)
+		}
+	}
+	return nil
+}
+
+// applyMutation applies a single mutation.
+func (w *writer) applyMutation(m *sppb.Mutation) error {
+	var (
+		wr      *sppb.Mutation_Write
+		replace bool
+		op      string
+	)
+	switch m := m.Operation.(type) {
+	case *sppb.Mutation_Insert:
+		wr, op = m.Insert, "insert"
+	case *sppb.Mutation_Update:
+		wr, op = m.Update, "update"
+	case *sppb.Mutation_InsertOrUpdate:
+		wr, op = m.InsertOrUpdate, "insert_or_update"
+	case *sppb.Mutation_Replace:
+		wr, op, replace = m.Replace, "replace", true
+	case *sppb.Mutation_Delete_:
+		return w.delete(m.Delete.Table, m.Delete.KeySet)
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown mutation %T", m)
+	}
+	t, err := w.table(wr.Table)
+	if err != nil {
+		return err
+	}
+	cols := make([]int, len(wr.Columns))
+	for i, name := range wr.Columns {
+		if cols[i] = t.colIndex(name); cols[i] < 0 {
+			return status.Errorf(codes.NotFound, "column %s not found in table %s", name, t.name)
+		}
+		for j := 0; j < i; j++ {
+			if cols[j] == cols[i] {
+				return status.Errorf(codes.InvalidArgument, "duplicate column %s", name)
+			}
+		}
+	}
+	for _, kp := range t.pk {
+		found := false
+		for _, c := range cols {
+			found = found || c == t.colIndex(kp.col)
+		}
+		if !found {
+			return status.Errorf(codes.FailedPrecondition, "%s on table %s does not specify key column %s", op, t.name, kp.col)
+		}
+	}
+	for _, lv := range wr.Values {
+		if len(lv.Values) != len(cols) {
+			return status.Errorf(codes.InvalidArgument, "%s on table %s has %d columns but %d values", op, t.name, len(cols), len(lv.Values))
+		}
+		nr := make(row, len(t.cols))
+		for i, v := range lv.Values {
+			c := t.cols[cols[i]]
+			x, err := w.columnValue(c, v)
+			if err != nil {
+				return err
+			}
+			nr[cols[i]] = x
+		}
+		pos, exists := t.find(t.key(nr))
+		switch {
+		case op == "insert" && exists:
+			return status.Errorf(codes.AlreadyExists, "row %v already exists in table %s", t.key(nr), t.name)
+		case op == "update" && !exists:
+			return status.Errorf(codes.NotFound, "row %v not found in table %s", t.key(nr), t.name)
+		}
+		if exists && !replace {
+			// Keep the values of the columns not being written.
+			old := t.rows[pos]
+			written := make([]bool, len(t.cols))
+			for _, c := range cols {
+				written[c] = true
+			}
+			for i := range nr {
+				if !written[i] {
+					nr[i] = old[i]
+				}
+			}
+		}
+		for i, c := range t.cols {
+			if c.notNull && nr[i] == nil {
+				return status.Errorf(codes.FailedPrecondition, "%s on table %s: column %s is NOT NULL", op, t.name, c.name)
+			}
+		}
+		if !exists {
+			if err := w.checkParent(t, nr); err != nil {
+				return err
+			}
+			t.rows = append(t.rows, nil)
+			copy(t.rows[pos+1:], t.rows[pos:])
+		}
+		t.rows[pos] = nr
+	}
+	w.changed[strings.ToLower(t.name)] = true
+	return nil
+}
+
+// columnValue decodes v as a value for column c.
+func (w *writer) columnValue(c *column, v *proto3.Value) (interface{}, error) {
+	x, err := decodeValue(v, c.typ)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "column %s: %v", c.name, err)
+	}
+	if x == commitTimestampPlaceholder {
+		if !c.allowCommitTimestamp {
+			return nil, status.Errorf(codes.FailedPrecondition, "column %s does not have allow_commit_timestamp=true", c.name)
+		}
+		return w.commit, nil
+	}
+	if err := checkSize(c, x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func checkSize(c *column, x interface{}) error {
+	if c.size == 0 {
+		return nil
+	}
+	var n int
+	switch x := x.(type) {
+	case string:
+		n = utf8.RuneCountInString(x)
+	case []byte:
+		n = len(x)
+	case []interface{}:
+		for _, e := range x {
+			if err := checkSize(c, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+	if int64(n) > c.size {
+		return status.Errorf(codes.FailedPrecondition, "value of length %d is too long for column %s", n, c.name)
+	}
+	return nil
+}
+
+// checkParent returns an error if t is interleaved and the parent of r does
+// not exist.
+func (w *writer) checkParent(t *table, r row) error {
+	if t.parent == "" {
+		return nil
+	}
+	p, err := w.v.table(t.parent)
+	if err != nil {
+		return err
+	}
+	pk := t.key(r)[:len(p.pk)]
+	if _, ok := p.find(pk); !ok {
+		return status.Errorf(codes.NotFound, "parent row %v of %s not found in table %s", pk, t.name, p.name)
+	}
+	return nil
+}
+
+func (w *writer) delete(tname string, ks *sppb.KeySet) error {
+	t, err := w.table(tname)
+	if err != nil {
+		return err
+	}
+	m, err := newKeyMatcher(t.pkTypes(), t.pkDesc(), ks)
+	if err != nil {
+		return err
+	}
+	var kept []row
+	for _, r := range t.rows {
+		k := t.key(r)
+		if !m.match(k) {
+			kept = append(kept, r)
+			continue
+		}
+		if err := w.deleteChildren(t, k); err != nil {
+			return err
+		}
+	}
+	t.rows = kept
+	w.changed[strings.ToLower(t.name)] = true
+	return nil
+}
+
+// deleteChildren deletes the rows interleaved in the row of t with primary
+// key pk, or returns an error if there are any and deletes do not cascade.
+func (w *writer) deleteChildren(t *table, pk []interface{}) error {
+	for _, c := range w.v.tables {
+		if !strings.EqualFold(c.parent, t.name) {
+			continue
+		}
+		pos, _ := c.find(pk)
+		end := pos
+		for end < len(c.rows) && compareKeys(c.key(c.rows[end]), pk, c.pkDesc()) == 0 {
+			end++
+		}
+		if end == pos {
+			continue
+		}
+		if !c.cascade {
+			return status.Errorf(codes.FailedPrecondition, "cannot delete row %v of table %s: it has rows in interleaved table %s", pk, t.name, c.name)
+		}
+		c, err := w.table(c.name)
+		if err != nil {
+			return err
+		}
+		for _, r := range c.rows[pos:end] {
+			if err := w.deleteChildren(c, c.key(r)); err != nil {
+				return err
+			}
+		}
+		c.rows = append(c.rows[:pos], c.rows[end:]...)
+		w.changed[strings.ToLower(c.name)] = true
+	}
+	return nil
+}
+
+// checkIndexes verifies the unique indexes of the changed tables.
+func (w *writer) checkIndexes() error {
+	for _, idx := range w.v.indexes {
+		if !w.changed[strings.ToLower(idx.table)] {
+			continue
+		}
+		t, err := w.v.table(idx.table)
+		if err != nil {
+			return err
+		}
+		if err := idx.checkUnique(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *table) pkTypes() []*sppb.Type {
+	ts := make([]*sppb.Type, len(t.pk))
+	for i, kp := range t.pk {
+		ts[i] = t.cols[t.colIndex(kp.col)].typ
+	}
+	return ts
+}
+
+// keyMatcher matches keys against a KeySet.
+type keyMatcher struct {
+	desc   []bool
+	all    bool
+	keys   [][]interface{}
+	ranges []keyRange
+}
+
+type keyRange struct {
+	start, end             []interface{}
+	startClosed, endClosed bool
+}
+
+func newKeyMatcher(types []*sppb.Type, desc []bool, ks *sppb.KeySet) (*keyMatcher, error) {
+	m := &keyMatcher{desc: desc}
+	if ks == nil {
+		return m, nil
+	}
+	m.all = ks.All
+	for _, lv := range ks.Keys {
+		k, err := decodeKey(types, lv)
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, k)
+	}
+	for _, r := range ks.Ranges {
+		var kr keyRange
+		var err error
+		switch s := r.StartKeyType.(type) {
+		case *sppb.KeyRange_StartClosed:
+			kr.start, err = decodeKey(types, s.StartClosed)
+			kr.startClosed = true
+		case *sppb.KeyRange_StartOpen:
+			kr.start, err = decodeKey(types, s.StartOpen)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := r.EndKeyType.(type) {
+		case *sppb.KeyRange_EndClosed:
+			kr.end, err = decodeKey(types, e.EndClosed)
+			kr.endClosed = true
+		case *sppb.KeyRange_EndOpen:
+			kr.end, err = decodeKey(types, e.EndOpen)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.ranges = append(m.ranges, kr)
+	}
+	return m, nil
+}
+
+func decodeKey(types []*sppb.Type, lv *proto3.ListValue) ([]interface{}, error) {
+	if lv == nil {
+		return nil, nil
+	}
+	if len(lv.Values) > len(types) {
+		return nil, status.Errorf(codes.InvalidArgument, "key %v has too many columns", lv)
+	}
+	k := make([]interface{}, len(lv.Values))
+	for i, v := range lv.Values {
+		x, err := decodeValue(v, types[i])
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "bad key: %v", err)
+		}
+		k[i] = x
+	}
+	return k, nil
+}
+
+// match reports whether key k is in the key set. Keys and range bounds
+// with fewer columns than k match every key with that prefix.
+func (m *keyMatcher) match(k []interface{}) bool {
+	if m.all {
+		return true
+	}
+	for _, mk := range m.keys {
+		if compareKeys(k, mk, m.desc) == 0 {
+			return true
+		}
+	}
+	for _, r := range m.ranges {
+		c := compareKeys(k, r.start, m.desc)
+		if c < 0 || c == 0 && !r.startClosed && len(r.start) > 0 {
+			continue
+		}
+		c = compareKeys(k, r.end, m.desc)
+		if c > 0 || c == 0 && !r.endClosed {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,1129 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spannertest
+
+// This file contains the evaluation of queries and reads against a version
+// of a database.
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resultSet is the result of a query or read.
+type resultSet struct {
+	fields []*sppb.StructType_Field
+	rows   [][]interface{}
+}
+
+// typedValue is the value of a query parameter.
+type typedValue struct {
+	v interface{}
+	t *sppb.Type // nil for an untyped NULL
+}
+
+// decodeParams decodes the parameters of a query.
+func decodeParams(params *proto3.Struct, types map[string]*sppb.Type) (map[string]typedValue, error) {
+	m := map[string]typedValue{}
+	if params == nil {
+		return m, nil
+	}
+	for name, v := range params.Fields {
+		t := types[name]
+		if t == nil {
+			// Infer the type from the JSON encoding.
+			switch v.Kind.(type) {
+			case *proto3.Value_BoolValue:
+				t = boolType
+			case *proto3.Value_NumberValue:
+				t = float64Type
+			case *proto3.Value_StringValue:
+				t = stringType
+			case *proto3.Value_NullValue:
+				m[name] = typedValue{}
+				continue
+			default:
+				return nil, status.Errorf(codes.InvalidArgument, "parameter @%s has no type", name)
+			}
+		}
+		x, err := decodeValue(v, t)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "parameter @%s: %v", name, err)
+		}
+		m[name] = typedValue{x, t}
+	}
+	return m, nil
+}
+
+// source is a table in the FROM clause of a query.
+type source struct {
+	name string // the alias, or the table name
+	t    *table
+}
+
+// scope is the context in which expressions are evaluated.
+type scope struct {
+	params  map[string]typedValue
+	sources []source
+	rows    []row // the current row of each source; nil for no row in an outer join
+}
+
+// resolve finds the source and column index a column reference refers to.
+func (sc *scope) resolve(c colRef) (int, int, error) {
+	si, ci := -1, -1
+	for i, s := range sc.sources {
+		if c.table != "" && !strings.EqualFold(c.table, s.name) {
+			continue
+		}
+		if j := s.t.colIndex(c.name); j >= 0 {
+			if si >= 0 {
+				return 0, 0, fmt.Errorf("column name %s is ambiguous", c.name)
+			}
+			si, ci = i, j
+		}
+	}
+	if si < 0 {
+		if c.table != "" {
+			return 0, 0, fmt.Errorf("unrecognized name: %s.%s", c.table, c.name)
+		}
+		return 0, 0, fmt.Errorf("unrecognized name: %s", c.name)
+	}
+	return si, ci, nil
+}
+
+// typeOf returns the type of e, or nil if e is an untyped NULL.
+func (sc *scope) typeOf(e expr) (*sppb.Type, error) {
+	switch e := e.(type) {
+	case literal:
+		return e.t, nil
+	case paramRef:
+		p, ok := sc.params[e.name]
+		if !ok {
+			return nil, fmt.Errorf("no value for parameter @%s", e.name)
+		}
+		return p.t, nil
+	case colRef:
+		si, ci, err := sc.resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		return sc.sources[si].t.cols[ci].typ, nil
+	case unaryOp:
+		if e.op == "NOT" {
+			return boolType, nil
+		}
+		return sc.typeOf(e.x)
+	case binaryOp:
+		switch e.op {
+		case "+", "-", "*":
+			lt, err := sc.typeOf(e.l)
+			if err != nil {
+				return nil, err
+			}
+			rt, err := sc.typeOf(e.r)
+			if err != nil {
+				return nil, err
+			}
+			if lt != nil && lt.Code == sppb.TypeCode_FLOAT64 || rt != nil && rt.Code == sppb.TypeCode_FLOAT64 {
+				return float64Type, nil
+			}
+			return int64Type, nil
+		case "/":
+			return float64Type, nil
+		case "||":
+			lt, err := sc.typeOf(e.l)
+			if err != nil || lt != nil {
+				return lt, err
+			}
+			return sc.typeOf(e.r)
+		}
+		return boolType, nil
+	case isNull, inList, between, like:
+		return boolType, nil
+	case funcCall:
+		return sc.funcType(e)
+	}
+	return nil, fmt.Errorf("unknown expression %T", e)
+}
+
+func (sc *scope) funcType(f funcCall) (*sppb.Type, error) {
+	switch f.name {
+	case "LOWER", "UPPER", "CONCAT":
+		if len(f.args) == 0 {
+			return stringType, nil
+		}
+		t, err := sc.typeOf(f.args[0])
+		if t == nil && err == nil {
+			t = stringType
+		}
+		return t, err
+	case "LENGTH", "ARRAY_LENGTH":
+		return int64Type, nil
+	case "STARTS_WITH", "ENDS_WITH":
+		return boolType, nil
+	case "ABS":
+		if len(f.args) != 1 {
+			return nil, fmt.Errorf("ABS takes one argument")
+		}
+		return sc.typeOf(f.args[0])
+	case "COALESCE", "IFNULL":
+		for _, a := range f.args {
+			t, err := sc.typeOf(a)
+			if err != nil || t != nil {
+				return t, err
+			}
+		}
+		return nil, nil
+	case "CAST":
+		return f.args[1].(literal).t, nil
+	}
+	return nil, fmt.Errorf("unsupported function %s", f.name)
+}
+
+// eval evaluates e in the current row.
+func (sc *scope) eval(e expr) (interface{}, error) {
+	switch e := e.(type) {
+	case literal:
+		return e.v, nil
+	case paramRef:
+		p, ok := sc.params[e.name]
+		if !ok {
+			return nil, fmt.Errorf("no value for parameter @%s", e.name)
+		}
+		return p.v, nil
+	case colRef:
+		si, ci, err := sc.resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		if sc.rows[si] == nil {
+			return nil, nil
+		}
+		return sc.rows[si][ci], nil
+	case unaryOp:
+		x, err := sc.eval(e.x)
+		if err != nil || x == nil {
+			return nil, err
+		}
+		if e.op == "NOT" {
+			b, ok := x.(bool)
+			if !ok {
+				return nil, fmt.Errorf("NOT requires a BOOL operand")
+			}
+			return !b, nil
+		}
+		switch x := x.(type) {
+		case int64:
+			if x == math.MinInt64 {
+				return nil, fmt.Errorf("int64 overflow: -%d", x)
+			}
+			return -x, nil
+		case float64:
+			return -x, nil
+		}
+		return nil, fmt.Errorf("cannot negate %T", x)
+	case binaryOp:
+		return sc.evalBinary(e)
+	case isNull:
+		x, err := sc.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		return (x == nil) != e.not, nil
+	case inList:
+		x, err := sc.eval(e.x)
+		if err != nil || x == nil {
+			return nil, err
+		}
+		sawNull := false
+		for _, le := range e.list {
+			y, err := sc.eval(le)
+			if err != nil {
+				return nil, err
+			}
+			if y == nil {
+				sawNull = true
+				continue
+			}
+			c, err := compare(x, y)
+			if err != nil {
+				return nil, err
+			}
+			if c == 0 {
+				return !e.not, nil
+			}
+		}
+		if sawNull {
+			return nil, nil
+		}
+		return e.not, nil
+	case between:
+		v, err := sc.eval(binaryOp{"AND", binaryOp{">=", e.x, e.lo}, binaryOp{"<=", e.x, e.hi}})
+		if err != nil || v == nil || !e.not {
+			return v, err
+		}
+		return !v.(bool), nil
+	case like:
+		x, err := sc.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		pat, err := sc.eval(e.pattern)
+		if err != nil || x == nil || pat == nil {
+			return nil, err
+		}
+		matched, err := evalLike(x, pat)
+		if err != nil {
+			return nil, err
+		}
+		return matched != e.not, nil
+	case funcCall:
+		return sc.evalFunc(e)
+	}
+	return nil, fmt.Errorf("unknown expression %T", e)
+}
+
+// evalBool evaluates a condition. NULL is treated as false.
+func (sc *scope) evalBool(e expr) (bool, error) {
+	x, err := sc.eval(e)
+	if err != nil || x == nil {
+		return false, err
+	}
+	b, ok := x.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition has type %T, not BOOL", x)
+	}
+	return b, nil
+}
+
+func (sc *scope) evalBinary(e binaryOp) (interface{}, error) {
+	l, err := sc.eval(e.l)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND", "OR":
+		// Three-valued logic: FALSE AND NULL is FALSE; TRUE OR NULL is TRUE.
+		short := e.op == "OR"
+		lb, ok := l.(bool)
+		if l != nil && !ok {
+			return nil, fmt.Errorf("%s requires BOOL operands", e.op)
+		}
+		if l != nil && lb == short {
+			return short, nil
+		}
+		r, err := sc.eval(e.r)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if r != nil && !ok {
+			return nil, fmt.Errorf("%s requires BOOL operands", e.op)
+		}
+		if r != nil && rb == short {
+			return short, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return !short, nil
+	}
+	r, err := sc.eval(e.r)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "||":
+		switch l := l.(type) {
+		case string:
+			if r, ok := r.(string); ok {
+				return l + r, nil
+			}
+		case []byte:
+			if r, ok := r.([]byte); ok {
+				return append(append([]byte(nil), l...), r...), nil
+			}
+		}
+		return nil, fmt.Errorf("cannot concatenate %T and %T", l, r)
+	}
+	return arith(e.op, l, r)
+}
+
+func arith(op string, l, r interface{}) (interface{}, error) {
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	if lok && rok && op != "/" {
+		var n int64
+		switch op {
+		case "+":
+			n = li + ri
+			if (n > li) != (ri > 0) {
+				return nil, fmt.Errorf("int64 overflow: %d + %d", li, ri)
+			}
+		case "-":
+			n = li - ri
+			if (n < li) != (ri > 0) {
+				return nil, fmt.Errorf("int64 overflow: %d - %d", li, ri)
+			}
+		case "*":
+			n = li * ri
+			if li != 0 && (n/li != ri || li == -1 && ri == math.MinInt64) {
+				return nil, fmt.Errorf("int64 overflow: %d * %d", li, ri)
+			}
+		}
+		return n, nil
+	}
+	lf, ok1 := toFloat(l)
+	rf, ok2 := toFloat(r)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("cannot apply %s to %T and %T", op, l, r)
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, fmt.Errorf("division by zero: %v / %v", lf, rf)
+	}
+	return lf / rf, nil
+}
+
+func toFloat(x interface{}) (float64, bool) {
+	switch x := x.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// compare compares two non-NULL values, coercing string literals to DATE
+// or TIMESTAMP where needed.
+func compare(a, b interface{}) (int, error) {
+	a, b, err := coerce(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return compareValues(a, b), nil
+}
+
+func coerce(a, b interface{}) (interface{}, interface{}, error) {
+	switch a.(type) {
+	case int64, float64:
+		switch b.(type) {
+		case int64, float64:
+			return a, b, nil
+		}
+	case string:
+		switch bt := b.(type) {
+		case civil.Date:
+			d, err := civil.ParseDate(a.(string))
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not cast %q to DATE", a)
+			}
+			return d, b, nil
+		case time.Time:
+			t, err := parseTimestamp(a.(string))
+			if err != nil {
+				return nil, nil, err
+			}
+			return t, bt, nil
+		}
+	case civil.Date, time.Time:
+		if _, ok := b.(string); ok {
+			b, a, err := coerce(b, a)
+			return a, b, err
+		}
+	}
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) {
+		return nil, nil, fmt.Errorf("cannot compare %T and %T", a, b)
+	}
+	return a, b, nil
+}
+
+var timestampFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, f := range timestampFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not cast %q to TIMESTAMP", s)
+}
+
+// evalLike matches x against a LIKE pattern.
+func evalLike(x, pat interface{}) (bool, error) {
+	var s, p string
+	switch x := x.(type) {
+	case string:
+		ps, ok := pat.(string)
+		if !ok {
+			return false, fmt.Errorf("LIKE pattern must be a STRING")
+		}
+		s, p = x, ps
+	case []byte:
+		pb, ok := pat.([]byte)
+		if !ok {
+			return false, fmt.Errorf("LIKE pattern must be BYTES")
+		}
+		s, p = string(x), string(pb)
+	default:
+		return false, fmt.Errorf("LIKE requires STRING or BYTES operands")
+	}
+	var re bytes.Buffer
+	re.WriteString(`(?s)^`)
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+			}
+		default:
+			re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String()).MatchString(s), nil
+}
+
+func (sc *scope) evalFunc(f funcCall) (interface{}, error) {
+	args := make([]interface{}, len(f.args))
+	for i, a := range f.args {
+		if f.name == "CAST" && i == 1 {
+			break
+		}
+		x, err := sc.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = x
+	}
+	nargs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s takes %d arguments", f.name, n)
+		}
+		return nil
+	}
+	switch f.name {
+	case "COALESCE", "IFNULL":
+		if f.name == "IFNULL" {
+			if err := nargs(2); err != nil {
+				return nil, err
+			}
+		}
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	case "CAST":
+		if args[0] == nil {
+			return nil, nil
+		}
+		from, err := sc.typeOf(f.args[0])
+		if err != nil {
+			return nil, err
+		}
+		return castValue(args[0], from, f.args[1].(literal).t)
+	}
+	for _, a := range args {
+		if a == nil {
+			return nil, nil
+		}
+	}
+	switch f.name {
+	case "LOWER", "UPPER":
+		if err := nargs(1); err != nil {
+			return nil, err
+		}
+		conv := strings.ToLower
+		if f.name == "UPPER" {
+			conv = strings.ToUpper
+		}
+		switch a := args[0].(type) {
+		case string:
+			return conv(a), nil
+		case []byte:
+			return []byte(conv(string(a))), nil
+		}
+	case "LENGTH":
+		if err := nargs(1); err != nil {
+			return nil, err
+		}
+		switch a := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(a)), nil
+		case []byte:
+			return int64(len(a)), nil
+		}
+	case "ARRAY_LENGTH":
+		if err := nargs(1); err != nil {
+			return nil, err
+		}
+		if a, ok := args[0].([]interface{}); ok {
+			return int64(len(a)), nil
+		}
+	case "STARTS_WITH", "ENDS_WITH":
+		if err := nargs(2); err != nil {
+			return nil, err
+		}
+		test := strings.HasPrefix
+		if f.name == "ENDS_WITH" {
+			test = strings.HasSuffix
+		}
+		switch a := args[0].(type) {
+		case string:
+			if b, ok := args[1].(string); ok {
+				return test(a, b), nil
+			}
+		case []byte:
+			if b, ok := args[1].([]byte); ok {
+				return test(string(a), string(b)), nil
+			}
+		}
+	case "CONCAT":
+		var res interface{}
+		for _, a := range args {
+			if res == nil {
+				res = a
+				continue
+			}
+			var err error
+			if res, err = sc.evalBinary(binaryOp{"||", literal{v: res}, literal{v: a}}); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case "ABS":
+		if err := nargs(1); err != nil {
+			return nil, err
+		}
+		switch a := args[0].(type) {
+		case int64:
+			if a == math.MinInt64 {
+				return nil, fmt.Errorf("int64 overflow: ABS(%d)", a)
+			}
+			if a < 0 {
+				return -a, nil
+			}
+			return a, nil
+		case float64:
+			return math.Abs(a), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported function %s", f.name)
+	}
+	return nil, fmt.Errorf("no matching signature for function %s", f.name)
+}
+
+// castValue converts x, of type from, to type to.
+func castValue(x interface{}, from, to *sppb.Type) (interface{}, error) {
+	if x == nil || from != nil && sameType(from, to) {
+		return x, nil
+	}
+	fail := func() (interface{}, error) {
+		return nil, fmt.Errorf("could not cast %v to %s", x, typeString(to))
+	}
+	switch to.Code {
+	case sppb.TypeCode_STRING:
+		switch x := x.(type) {
+		case string:
+			return x, nil
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case float64:
+			return strconv.FormatFloat(x, 'g', -1, 64), nil
+		case bool:
+			if x {
+				return "TRUE", nil
+			}
+			return "FALSE", nil
+		case []byte:
+			if !utf8.Valid(x) {
+				return fail()
+			}
+			return string(x), nil
+		case civil.Date:
+			return x.String(), nil
+		case time.Time:
+			return x.UTC().Format("2006-01-02 15:04:05.999999999-07"), nil
+		}
+	case sppb.TypeCode_INT64:
+		switch x := x.(type) {
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(x), 0, 64)
+			if err != nil {
+				return fail()
+			}
+			return n, nil
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) || x >= math.MaxInt64 || x < math.MinInt64 {
+				return fail()
+			}
+			// Round half away from zero.
+			if x < 0 {
+				return int64(x - 0.5), nil
+			}
+			return int64(x + 0.5), nil
+		case bool:
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case sppb.TypeCode_FLOAT64:
+		switch x := x.(type) {
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return fail()
+			}
+			return f, nil
+		case int64:
+			return float64(x), nil
+		}
+	case sppb.TypeCode_BOOL:
+		switch x := x.(type) {
+		case string:
+			switch strings.ToUpper(x) {
+			case "TRUE":
+				return true, nil
+			case "FALSE":
+				return false, nil
+			}
+		case int64:
+			return x != 0, nil
+		}
+	case sppb.TypeCode_BYTES:
+		if s, ok := x.(string); ok {
+			return []byte(s), nil
+		}
+	case sppb.TypeCode_DATE:
+		switch x := x.(type) {
+		case string:
+			d, err := civil.ParseDate(strings.TrimSpace(x))
+			if err != nil {
+				return fail()
+			}
+			return d, nil
+		case time.Time:
+			return civil.DateOf(x.UTC()), nil
+		}
+	case sppb.TypeCode_TIMESTAMP:
+		switch x := x.(type) {
+		case string:
+			return parseTimestamp(strings.TrimSpace(x))
+		case civil.Date:
+			return x.In(time.UTC), nil
+		}
+	}
+	return fail()
+}
+
+// execute runs q against version v.
+func execute(v *version, q *query, params map[string]typedValue) (*resultSet, error) {
+	sc := &scope{params: params}
+	for _, fi := range q.from {
+		t, err := v.table(fi.table)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "table not found: %s", fi.table)
+		}
+		name := fi.alias
+		if name == "" {
+			name = t.name
+		}
+		for _, s := range sc.sources {
+			if strings.EqualFold(s.name, name) {
+				return nil, status.Errorf(codes.InvalidArgument, "duplicate alias %s", name)
+			}
+		}
+		sc.sources = append(sc.sources, source{name, t})
+	}
+	res, err := sc.run(q)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return res, nil
+}
+
+func (sc *scope) run(q *query) (*resultSet, error) {
+	// Produce the rows of the FROM clause.
+	combos := [][]row{nil}
+	if len(sc.sources) > 0 {
+		combos = nil
+		if err := sc.join(q, 0, make([]row, len(sc.sources)), &combos); err != nil {
+			return nil, err
+		}
+	}
+
+	// Determine the output columns.
+	type outCol struct {
+		e     expr
+		field *sppb.StructType_Field
+	}
+	var outs []outCol
+	sc.rows = make([]row, len(sc.sources))
+	for _, item := range q.items {
+		if item.star {
+			found := false
+			for _, s := range sc.sources {
+				if item.starTable != "" && !strings.EqualFold(item.starTable, s.name) {
+					continue
+				}
+				found = true
+				for _, c := range s.t.cols {
+					outs = append(outs, outCol{colRef{s.name, c.name}, &sppb.StructType_Field{Name: c.name, Type: c.typ}})
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("SELECT * requires a FROM clause with a matching table")
+			}
+			continue
+		}
+		t, err := sc.typeOf(item.e)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			t = int64Type
+		}
+		name := item.alias
+		if c, ok := item.e.(colRef); ok && name == "" {
+			name = c.name
+		}
+		outs = append(outs, outCol{item.e, &sppb.StructType_Field{Name: name, Type: t}})
+	}
+	res := &resultSet{}
+	for _, o := range outs {
+		res.fields = append(res.fields, o.field)
+	}
+
+	// Resolve the ORDER BY clause: an item may name an output column by
+	// alias or by position.
+	orderOut := make([]int, len(q.order))
+	for i, o := range q.order {
+		orderOut[i] = -1
+		switch e := o.e.(type) {
+		case colRef:
+			if e.table != "" {
+				break
+			}
+			for j, item := range q.items {
+				if item.alias != "" && strings.EqualFold(item.alias, e.name) {
+					orderOut[i] = j
+				}
+			}
+		case literal:
+			n, ok := e.v.(int64)
+			if !ok || n < 1 || int(n) > len(outs) {
+				return nil, fmt.Errorf("ORDER BY position %v is out of range", e.v)
+			}
+			orderOut[i] = int(n - 1)
+		}
+	}
+
+	var sorted []sortRow
+	for _, combo := range combos {
+		sc.rows = combo
+		if q.where != nil {
+			ok, err := sc.evalBool(q.where)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		sr := sortRow{vals: make([]interface{}, len(outs)), keys: make([]interface{}, len(q.order))}
+		for i, o := range outs {
+			x, err := sc.eval(o.e)
+			if err != nil {
+				return nil, err
+			}
+			sr.vals[i] = x
+		}
+		for i, o := range q.order {
+			if orderOut[i] >= 0 {
+				sr.keys[i] = sr.vals[orderOut[i]]
+				continue
+			}
+			x, err := sc.eval(o.e)
+			if err != nil {
+				return nil, err
+			}
+			sr.keys[i] = x
+		}
+		sorted = append(sorted, sr)
+	}
+	if len(q.order) > 0 {
+		desc := make([]bool, len(q.order))
+		for i, o := range q.order {
+			desc[i] = o.desc
+		}
+		var err error
+		sort.Stable(bySortKey{sorted, desc, &err})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, sr := range sorted {
+		res.rows = append(res.rows, sr.vals)
+	}
+	if q.distinct {
+		var rows [][]interface{}
+	outer:
+		for _, r := range res.rows {
+			for _, seen := range rows {
+				if compareValues([]interface{}(seen), []interface{}(r)) == 0 {
+					continue outer
+				}
+			}
+			rows = append(rows, r)
+		}
+		res.rows = rows
+	}
+
+	sc.rows = make([]row, len(sc.sources))
+	if q.offset != nil {
+		n, err := sc.evalCount(q.offset, "OFFSET")
+		if err != nil {
+			return nil, err
+		}
+		if n > len(res.rows) {
+			n = len(res.rows)
+		}
+		res.rows = res.rows[n:]
+	}
+	if q.limit != nil {
+		n, err := sc.evalCount(q.limit, "LIMIT")
+		if err != nil {
+			return nil, err
+		}
+		if n < len(res.rows) {
+			res.rows = res.rows[:n]
+		}
+	}
+	return res, nil
+}
+
+// join appends to out the combinations of rows of sources i and after that
+// satisfy the join conditions.
+func (sc *scope) join(q *query, i int, cur []row, out *[][]row) error {
+	if i == len(sc.sources) {
+		*out = append(*out, append([]row(nil), cur...))
+		return nil
+	}
+	fi := q.from[i]
+	matched := false
+	for _, r := range sc.sources[i].t.rows {
+		cur[i] = r
+		if fi.on != nil {
+			sc.rows = cur
+			ok, err := sc.evalBool(fi.on)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		matched = true
+		if err := sc.join(q, i+1, cur, out); err != nil {
+			return err
+		}
+	}
+	cur[i] = nil
+	if !matched && fi.join == "LEFT" {
+		return sc.join(q, i+1, cur, out)
+	}
+	return nil
+}
+
+func (sc *scope) evalCount(e expr, clause string) (int, error) {
+	x, err := sc.eval(e)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := x.(int64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("%s requires a non-negative INT64, not %v", clause, x)
+	}
+	return int(n), nil
+}
+
+type sortRow struct {
+	vals []interface{}
+	keys []interface{}
+}
+
+type bySortKey struct {
+	rows []sortRow
+	desc []bool
+	err  *error
+}
+
+func (b bySortKey) Len() int      { return len(b.rows) }
+func (b bySortKey) Swap(i, j int) { b.rows[i], b.rows[j] = b.rows[j], b.rows[i] }
+func (b bySortKey) Less(i, j int) bool {
+	for k, d := range b.desc {
+		x, y := b.rows[i].keys[k], b.rows[j].keys[k]
+		var c int
+		if x == nil || y == nil {
+			c = compareValues(x, y)
+		} else {
+			var err error
+			if c, err = compare(x, y); err != nil {
+				*b.err = err
+				return false
+			}
+		}
+		if d {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+// read performs a read of a table or index.
+func read(v *version, req *sppb.ReadRequest) (*resultSet, error) {
+	t, err := v.table(req.Table)
+	if err != nil {
+		return nil, err
+	}
+	var idx *index
+	if req.Index != "" {
+		if idx, err = v.index(req.Index); err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(idx.table, t.name) {
+			return nil, status.Errorf(codes.InvalidArgument, "index %s is not on table %s", idx.name, t.name)
+		}
+	}
+	res := &resultSet{}
+	cols := make([]int, len(req.Columns))
+	for i, name := range req.Columns {
+		if cols[i] = t.colIndex(name); cols[i] < 0 {
+			return nil, status.Errorf(codes.NotFound, "column %s not found in table %s", name, t.name)
+		}
+		if idx != nil && !idx.covers(t, name) {
+			return nil, status.Errorf(codes.InvalidArgument, "column %s is not stored in index %s", name, idx.name)
+		}
+		c := t.cols[cols[i]]
+		res.fields = append(res.fields, &sppb.StructType_Field{Name: c.name, Type: c.typ})
+	}
+
+	var (
+		keys [][]interface{}
+		rows []row
+		desc []bool
+	)
+	if idx == nil {
+		desc = t.pkDesc()
+		for _, r := range t.rows {
+			keys = append(keys, t.key(r))
+			rows = append(rows, r)
+		}
+	} else {
+		var es []indexEntry
+		es, desc = idx.entries(t)
+		for _, e := range es {
+			keys = append(keys, e.key[:len(idx.key)])
+			rows = append(rows, e.r)
+		}
+	}
+	var types []*sppb.Type
+	if idx == nil {
+		types = t.pkTypes()
+	} else {
+		for _, kp := range idx.key {
+			types = append(types, t.cols[t.colIndex(kp.col)].typ)
+		}
+	}
+	m, err := newKeyMatcher(types, desc, req.KeySet)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range rows {
+		if !m.match(keys[i]) {
+			continue
+		}
+		out := make([]interface{}, len(cols))
+		for j, c := range cols {
+			out[j] = r[c]
+		}
+		res.rows = append(res.rows, out)
+		if req.Limit > 0 && int64(len(res.rows)) == req.Limit {
+			break
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,787 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package spannertest contains an in-memory fake of Cloud Spanner, for testing
+code that uses the spanner package.
+
+The fake applies DDL, executes a subset of Cloud Spanner SQL, reads tables and
+indexes by key, and applies mutations atomically at commit. Read-write
+transactions use optimistic concurrency control: a transaction is aborted at
+commit if a table it read was written by another transaction after its first
+read. Old versions of the data are kept for an hour, so read-only transactions
+may use any timestamp bound.
+
+The supported SQL consists of SELECT statements with inner, left and cross
+joins, WHERE, ORDER BY, LIMIT and OFFSET, query parameters, and common
+operators and scalar functions. Aggregation, subqueries and DML are not
+supported.
+
+To use a Server, create it, create a database, and connect a client to it
+with no security:
+	srv, err := spannertest.NewServer("localhost:0")
+	...
+	db := "projects/p/instances/i/databases/d"
+	err = srv.UpdateDDL(db, `CREATE TABLE Singers (
+		SingerId INT64 NOT NULL,
+		Name     STRING(MAX),
+	) PRIMARY KEY (SingerId)`)
+	...
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	...
+	client, err := spanner.NewClient(ctx, db, option.WithGRPCConn(conn))
+	...
+*/
+package spannertest // import "cloud.google.com/go/spanner/spannertest"
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+	spantestutil "cloud.google.com/go/spanner/internal/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	emptypb "github.com/golang/protobuf/ptypes/empty"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/longrunning"
+	adminpb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server is an in-memory Cloud Spanner fake.
+// It is unauthenticated, and only a rough approximation.
+type Server struct {
+	Addr string
+
+	l   net.Listener
+	srv *grpc.Server
+	s   *server
+}
+
+// server is the real implementation of the fake.
+// It is a separate and unexported type so the API won't be cluttered with
+// methods that are only relevant to the fake's implementation.
+type server struct {
+	mu          sync.Mutex
+	dbs         map[string]*database // keyed by fully qualified name
+	sessions    map[string]*session  // keyed by fully qualified name
+	nextSession int
+	nextTxn     int
+
+	// Any unimplemented methods will cause a panic.
+	sppb.SpannerServer
+	adminpb.DatabaseAdminServer
+}
+
+type session struct {
+	name    string
+	db      *database
+	created time.Time
+	labels  map[string]string
+	txns    map[string]*transaction // keyed by ID
+}
+
+type transaction struct {
+	id       []byte
+	readOnly bool
+
+	// For read-only transactions, the version read and its timestamp.
+	snap   *version
+	readTS time.Time
+
+	// For read-write transactions, the number of commits to the database at
+	// the transaction's first read, or -1, and the tables it has read.
+	readSeq int
+	read    map[string]bool
+}
+
+// NewServer creates a new Server.
+// The Server will be listening for gRPC connections, without TLS,
+// on the provided address. The resolved address is named by the Addr field.
+func NewServer(laddr string, opt ...grpc.ServerOption) (*Server, error) {
+	l, err := net.Listen("tcp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr: l.Addr().String(),
+		l:    l,
+		srv:  grpc.NewServer(opt...),
+		s: &server{
+			dbs:      make(map[string]*database),
+			sessions: make(map[string]*session),
+		},
+	}
+	sppb.RegisterSpannerServer(s.srv, s.s)
+	adminpb.RegisterDatabaseAdminServer(s.srv, s.s)
+
+	go s.srv.Serve(s.l)
+
+	return s, nil
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Stop()
+	s.l.Close()
+}
+
+// UpdateDDL applies DDL statements to the named database, creating the
+// database if it does not exist. The database name has the form
+// projects/P/instances/I/databases/D. The statements are applied
+// atomically: if one fails, none take effect.
+func (s *Server) UpdateDDL(database string, statements ...string) error {
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	db, ok := s.s.dbs[database]
+	if !ok {
+		db = newDatabase(database, time.Now())
+		s.s.dbs[database] = db
+	}
+	_, err := s.s.applyDDL(db, statements)
+	return err
+}
+
+// applyDDL applies DDL statements atomically, and returns the commit timestamp.
+// s.mu must be held.
+func (s *server) applyDDL(db *database, statements []string) (time.Time, error) {
+	now := time.Now()
+	ts := db.nextTimestamp(now)
+	w := newWriter(db.current(), ts)
+	for _, stmt := range statements {
+		if err := w.applyDDL(stmt); err != nil {
+			return time.Time{}, err
+		}
+	}
+	db.install(w, now)
+	return ts, nil
+}
+
+// install makes the writer's version current.
+func (d *database) install(w *writer, now time.Time) {
+	d.addVersion(w.v, now)
+	d.commits++
+	for t := range w.copied {
+		d.modified[t] = d.commits
+	}
+	for t := range w.changed {
+		d.modified[t] = d.commits
+	}
+}
+
+// Database admin.
+
+func (s *server) CreateDatabase(ctx context.Context, req *adminpb.CreateDatabaseRequest) (*longrunning.Operation, error) {
+	stmt, err := parseDDL(req.CreateStatement)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v: %s", err, req.CreateStatement)
+	}
+	cd, ok := stmt.(createDatabase)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "not a CREATE DATABASE statement: %s", req.CreateStatement)
+	}
+	name := req.Parent + "/databases/" + cd.name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.dbs[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "database %s already exists", name)
+	}
+	db := newDatabase(name, time.Now())
+	if _, err := s.applyDDL(db, req.ExtraStatements); err != nil {
+		return nil, err
+	}
+	s.dbs[name] = db
+	return doneOperation(name,
+		&adminpb.Database{Name: name, State: adminpb.Database_READY},
+		&adminpb.CreateDatabaseMetadata{Database: name})
+}
+
+func (s *server) GetDatabase(ctx context.Context, req *adminpb.GetDatabaseRequest) (*adminpb.Database, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.findDatabase(req.Name); err != nil {
+		return nil, err
+	}
+	return &adminpb.Database{Name: req.Name, State: adminpb.Database_READY}, nil
+}
+
+func (s *server) ListDatabases(ctx context.Context, req *adminpb.ListDatabasesRequest) (*adminpb.ListDatabasesResponse, error) {
+	s.mu.Lock()
+	var names []string
+	for name := range s.dbs {
+		if strings.HasPrefix(name, req.Parent+"/databases/") {
+			names = append(names, name)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+	from, to, next, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(names))
+	if err != nil {
+		return nil, err
+	}
+	res := &adminpb.ListDatabasesResponse{NextPageToken: next}
+	for _, name := range names[from:to] {
+		res.Databases = append(res.Databases, &adminpb.Database{Name: name, State: adminpb.Database_READY})
+	}
+	return res, nil
+}
+
+func (s *server) DropDatabase(ctx context.Context, req *adminpb.DropDatabaseRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db, err := s.findDatabase(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.dbs, req.Database)
+	for name, sess := range s.sessions {
+		if sess.db == db {
+			delete(s.sessions, name)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) UpdateDatabaseDdl(ctx context.Context, req *adminpb.UpdateDatabaseDdlRequest) (*longrunning.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db, err := s.findDatabase(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.applyDDL(db, req.Statements)
+	if err != nil {
+		return nil, err
+	}
+	md := &adminpb.UpdateDatabaseDdlMetadata{Database: req.Database, Statements: req.Statements}
+	for range req.Statements {
+		md.CommitTimestamps = append(md.CommitTimestamps, mustTimestampProto(ts))
+	}
+	return doneOperation(req.Database, &emptypb.Empty{}, md)
+}
+
+func (s *server) GetDatabaseDdl(ctx context.Context, req *adminpb.GetDatabaseDdlRequest) (*adminpb.GetDatabaseDdlResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db, err := s.findDatabase(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	return &adminpb.GetDatabaseDdlResponse{Statements: db.current().ddl()}, nil
+}
+
+// findDatabase returns the named database. s.mu must be held.
+func (s *server) findDatabase(name string) (*database, error) {
+	db, ok := s.dbs[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "database not found: %s", name)
+	}
+	return db, nil
+}
+
+func doneOperation(name string, res, md proto.Message) (*longrunning.Operation, error) {
+	anyRes, err := ptypes.MarshalAny(res)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	anyMD, err := ptypes.MarshalAny(md)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &longrunning.Operation{
+		Name:     name + "/operations/done",
+		Metadata: anyMD,
+		Done:     true,
+		Result:   &longrunning.Operation_Response{Response: anyRes},
+	}, nil
+}
+
+func mustTimestampProto(t time.Time) *tspb.Timestamp {
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+// Sessions.
+
+func (s *server) CreateSession(ctx context.Context, req *sppb.CreateSessionRequest) (*sppb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db, err := s.findDatabase(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	s.nextSession++
+	sess := &session{
+		name:    fmt.Sprintf("%s/sessions/%d", req.Database, s.nextSession),
+		db:      db,
+		created: time.Now(),
+		txns:    make(map[string]*transaction),
+	}
+	if req.Session != nil {
+		sess.labels = req.Session.Labels
+	}
+	s.sessions[sess.name] = sess
+	return sess.proto(), nil
+}
+
+func (sess *session) proto() *sppb.Session {
+	return &sppb.Session{
+		Name:       sess.name,
+		Labels:     sess.labels,
+		CreateTime: mustTimestampProto(sess.created),
+	}
+}
+
+func (s *server) GetSession(ctx context.Context, req *sppb.GetSessionRequest) (*sppb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.findSession(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return sess.proto(), nil
+}
+
+func (s *server) ListSessions(ctx context.Context, req *sppb.ListSessionsRequest) (*sppb.ListSessionsResponse, error) {
+	s.mu.Lock()
+	var names []string
+	for name, sess := range s.sessions {
+		if sess.db.name == req.Database {
+			names = append(names, name)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+	from, to, next, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(names))
+	if err != nil {
+		return nil, err
+	}
+	res := &sppb.ListSessionsResponse{NextPageToken: next}
+	s.mu.Lock()
+	for _, name := range names[from:to] {
+		if sess, ok := s.sessions[name]; ok {
+			res.Sessions = append(res.Sessions, sess.proto())
+		}
+	}
+	s.mu.Unlock()
+	return res, nil
+}
+
+func (s *server) DeleteSession(ctx context.Context, req *sppb.DeleteSessionRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.findSession(req.Name); err != nil {
+		return nil, err
+	}
+	delete(s.sessions, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// findSession returns the named session. s.mu must be held.
+func (s *server) findSession(name string) (*session, error) {
+	sess, ok := s.sessions[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session not found: %s", name)
+	}
+	return sess, nil
+}
+
+// Transactions.
+
+func (s *server) BeginTransaction(ctx context.Context, req *sppb.BeginTransactionRequest) (*sppb.Transaction, error) {
+	if err := waitForTimestamp(ctx, req.Options); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.findSession(req.Session)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(sess, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	return tx.proto(), nil
+}
+
+func (tx *transaction) proto() *sppb.Transaction {
+	pt := &sppb.Transaction{Id: tx.id}
+	if tx.readOnly {
+		pt.ReadTimestamp = mustTimestampProto(tx.readTS)
+	}
+	return pt
+}
+
+// begin starts a transaction in sess. s.mu must be held.
+func (s *server) begin(sess *session, opts *sppb.TransactionOptions) (*transaction, error) {
+	s.nextTxn++
+	tx := &transaction{id: []byte(strconv.Itoa(s.nextTxn)), readSeq: -1}
+	switch mode := opts.GetMode().(type) {
+	case *sppb.TransactionOptions_ReadWrite_:
+		// Starting a read-write transaction invalidates the session's previous one.
+		for id, old := range sess.txns {
+			if !old.readOnly {
+				delete(sess.txns, id)
+			}
+		}
+		tx.read = make(map[string]bool)
+	case *sppb.TransactionOptions_ReadOnly_:
+		v, ts, err := sess.db.snapshot(mode.ReadOnly, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		tx.readOnly, tx.snap, tx.readTS = true, v, ts
+	case *sppb.TransactionOptions_PartitionedDml_:
+		return nil, status.Errorf(codes.Unimplemented, "partitioned DML is not supported")
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "transaction options must specify a mode")
+	}
+	sess.txns[string(tx.id)] = tx
+	return tx, nil
+}
+
+// snapshot returns the version to read for a read-only transaction, and its
+// timestamp.
+func (d *database) snapshot(ro *sppb.TransactionOptions_ReadOnly, now time.Time) (*version, time.Time, error) {
+	var ts time.Time
+	switch b := ro.GetTimestampBound().(type) {
+	case *sppb.TransactionOptions_ReadOnly_ReadTimestamp:
+		t, err := ptypes.Timestamp(b.ReadTimestamp)
+		if err != nil {
+			return nil, time.Time{}, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		ts = t
+	case *sppb.TransactionOptions_ReadOnly_ExactStaleness:
+		d, err := ptypes.Duration(b.ExactStaleness)
+		if err != nil {
+			return nil, time.Time{}, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		ts = now.Add(-d)
+	default:
+		// Strong reads, and bounded staleness reads, which may as well be strong.
+		ts = d.strongTimestamp(now)
+	}
+	if ts.Before(d.created) {
+		return nil, time.Time{}, status.Errorf(codes.FailedPrecondition, "read timestamp %v is before the database was created", ts)
+	}
+	if ts.Before(now.Add(-versionRetention)) {
+		return nil, time.Time{}, status.Errorf(codes.FailedPrecondition, "read timestamp %v is too old", ts)
+	}
+	if ts.After(d.lastTS) {
+		d.lastTS = ts
+	}
+	v, err := d.versionAt(ts)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return v, ts, nil
+}
+
+// waitForTimestamp waits until a read timestamp in the future, as Cloud Spanner does.
+func waitForTimestamp(ctx context.Context, opts *sppb.TransactionOptions) error {
+	b, ok := opts.GetReadOnly().GetTimestampBound().(*sppb.TransactionOptions_ReadOnly_ReadTimestamp)
+	if !ok {
+		return nil
+	}
+	ts, err := ptypes.Timestamp(b.ReadTimestamp)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if d := ts.Sub(time.Now()); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return nil
+}
+
+// readVersion returns the version to read for a read or query in the given
+// transaction, which reads the given tables. If the selector begins a
+// transaction, the new transaction is returned for the result metadata.
+func (s *server) readVersion(ctx context.Context, sessName string, sel *sppb.TransactionSelector, tables []string) (*version, *sppb.Transaction, error) {
+	var opts *sppb.TransactionOptions
+	switch sel := sel.GetSelector().(type) {
+	case *sppb.TransactionSelector_SingleUse:
+		opts = sel.SingleUse
+	case *sppb.TransactionSelector_Begin:
+		opts = sel.Begin
+	}
+	if err := waitForTimestamp(ctx, opts); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.findSession(sessName)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := sess.db
+	var tx *transaction
+	switch sel := sel.GetSelector().(type) {
+	case nil:
+		// A strong, single-use, read-only transaction.
+		v, _, err := db.snapshot(nil, time.Now())
+		return v, nil, err
+	case *sppb.TransactionSelector_SingleUse:
+		ro := sel.SingleUse.GetReadOnly()
+		if ro == nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "single-use transactions for reads must be read-only")
+		}
+		v, ts, err := db.snapshot(ro, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		var md *sppb.Transaction
+		if ro.ReturnReadTimestamp {
+			md = &sppb.Transaction{ReadTimestamp: mustTimestampProto(ts)}
+		}
+		return v, md, nil
+	case *sppb.TransactionSelector_Begin:
+		if tx, err = s.begin(sess, sel.Begin); err != nil {
+			return nil, nil, err
+		}
+	case *sppb.TransactionSelector_Id:
+		var ok bool
+		if tx, ok = sess.txns[string(sel.Id)]; !ok {
+			return nil, nil, status.Errorf(codes.FailedPrecondition, "transaction %q not found in session %s", sel.Id, sessName)
+		}
+	}
+	var md *sppb.Transaction
+	if _, ok := sel.GetSelector().(*sppb.TransactionSelector_Begin); ok {
+		md = tx.proto()
+	}
+	if tx.readOnly {
+		return tx.snap, md, nil
+	}
+	if tx.readSeq < 0 {
+		tx.readSeq = db.commits
+	}
+	for _, t := range tables {
+		tx.read[strings.ToLower(t)] = true
+	}
+	return db.current(), md, nil
+}
+
+func (s *server) Commit(ctx context.Context, req *sppb.CommitRequest) (*sppb.CommitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.findSession(req.Session)
+	if err != nil {
+		return nil, err
+	}
+	db := sess.db
+	switch t := req.Transaction.(type) {
+	case *sppb.CommitRequest_TransactionId:
+		tx, ok := sess.txns[string(t.TransactionId)]
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "transaction %q not found in session %s", t.TransactionId, req.Session)
+		}
+		delete(sess.txns, string(t.TransactionId))
+		if tx.readOnly {
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot commit a read-only transaction")
+		}
+		for name := range tx.read {
+			if db.modified[name] > tx.readSeq {
+				return nil, status.Errorf(codes.Aborted, "transaction aborted: table %s was modified by a concurrent transaction", name)
+			}
+		}
+	case *sppb.CommitRequest_SingleUseTransaction:
+		if t.SingleUseTransaction.GetReadWrite() == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "single-use transactions for commits must be read-write")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "commit request must specify a transaction")
+	}
+
+	now := time.Now()
+	ts := db.nextTimestamp(now)
+	w := newWriter(db.current(), ts)
+	for _, m := range req.Mutations {
+		if err := w.applyMutation(m); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.checkIndexes(); err != nil {
+		return nil, err
+	}
+	// Copying a table is not a schema change, so only the tables whose data
+	// changed count as modified.
+	w.copied = nil
+	db.install(w, now)
+	return &sppb.CommitResponse{CommitTimestamp: mustTimestampProto(ts)}, nil
+}
+
+func (s *server) Rollback(ctx context.Context, req *sppb.RollbackRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.findSession(req.Session)
+	if err != nil {
+		return nil, err
+	}
+	delete(sess.txns, string(req.TransactionId))
+	return &emptypb.Empty{}, nil
+}
+
+// Reads and queries.
+
+func (s *server) query(ctx context.Context, req *sppb.ExecuteSqlRequest) (*resultSet, *sppb.Transaction, error) {
+	if req.QueryMode != sppb.ExecuteSqlRequest_NORMAL {
+		return nil, nil, status.Errorf(codes.Unimplemented, "query mode %v is not supported", req.QueryMode)
+	}
+	q, err := parseQuery(req.Sql)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%v: %s", err, req.Sql)
+	}
+	params, err := decodeParams(req.Params, req.ParamTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tables []string
+	for _, fi := range q.from {
+		tables = append(tables, fi.table)
+	}
+	v, md, err := s.readVersion(ctx, req.Session, req.Transaction, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs, err := execute(v, q, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, md, nil
+}
+
+func (s *server) ExecuteSql(ctx context.Context, req *sppb.ExecuteSqlRequest) (*sppb.ResultSet, error) {
+	rs, md, err := s.query(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return rs.proto(md), nil
+}
+
+func (s *server) ExecuteStreamingSql(req *sppb.ExecuteSqlRequest, stream sppb.Spanner_ExecuteStreamingSqlServer) error {
+	rs, md, err := s.query(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	return rs.stream(md, req.ResumeToken, stream.Send)
+}
+
+func (s *server) doRead(ctx context.Context, req *sppb.ReadRequest) (*resultSet, *sppb.Transaction, error) {
+	v, md, err := s.readVersion(ctx, req.Session, req.Transaction, []string{req.Table})
+	if err != nil {
+		return nil, nil, err
+	}
+	rs, err := read(v, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, md, nil
+}
+
+func (s *server) Read(ctx context.Context, req *sppb.ReadRequest) (*sppb.ResultSet, error) {
+	rs, md, err := s.doRead(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return rs.proto(md), nil
+}
+
+func (s *server) StreamingRead(req *sppb.ReadRequest, stream sppb.Spanner_StreamingReadServer) error {
+	rs, md, err := s.doRead(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	return rs.stream(md, req.ResumeToken, stream.Send)
+}
+
+func (rs *resultSet) metadata(tx *sppb.Transaction) *sppb.ResultSetMetadata {
+	return &sppb.ResultSetMetadata{
+		RowType:     &sppb.StructType{Fields: rs.fields},
+		Transaction: tx,
+	}
+}
+
+func encodeRow(r []interface{}) []*proto3.Value {
+	vs := make([]*proto3.Value, len(r))
+	for i, x := range r {
+		vs[i] = encodeValue(x)
+	}
+	return vs
+}
+
+func (rs *resultSet) proto(tx *sppb.Transaction) *sppb.ResultSet {
+	res := &sppb.ResultSet{Metadata: rs.metadata(tx)}
+	for _, r := range rs.rows {
+		res.Rows = append(res.Rows, &proto3.ListValue{Values: encodeRow(r)})
+	}
+	return res
+}
+
+// stream sends the result set as a sequence of PartialResultSets, one row
+// at a time, each with a resume token. A non-empty resumeToken resumes the
+// stream after the row it was sent with.
+func (rs *resultSet) stream(tx *sppb.Transaction, resumeToken []byte, send func(*sppb.PartialResultSet) error) error {
+	start := 0
+	if len(resumeToken) > 0 {
+		n, err := spantestutil.DecodeResumeToken(resumeToken)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		start = int(n)
+	}
+	var md *sppb.ResultSetMetadata
+	if start == 0 {
+		md = rs.metadata(tx)
+	}
+	if start >= len(rs.rows) {
+		if md == nil {
+			return nil
+		}
+		return send(&sppb.PartialResultSet{Metadata: md})
+	}
+	for i := start; i < len(rs.rows); i++ {
+		err := send(&sppb.PartialResultSet{
+			Metadata:    md,
+			Values:      encodeRow(rs.rows[i]),
+			ResumeToken: spantestutil.EncodeResumeToken(uint64(i + 1)),
+		})
+		if err != nil {
+			return err
+		}
+		md = nil
+	}
+	return nil
+}
@@ -0,0 +1,522 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spannertest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	dbadmin "cloud.google.com/go/spanner/admin/database/apiv1"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	adminpb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const testDB = "projects/p/instances/i/databases/d"
+
+var testDDL = []string{
+	`CREATE TABLE Singers (
+		SingerId  INT64 NOT NULL,
+		FirstName STRING(1024),
+		LastName  STRING(1024),
+		BirthDate DATE,
+	) PRIMARY KEY (SingerId)`,
+	`CREATE TABLE Albums (
+		SingerId    INT64 NOT NULL,
+		AlbumId     INT64 NOT NULL,
+		AlbumTitle  STRING(MAX),
+		Price       FLOAT64,
+		Tags        ARRAY<STRING(MAX)>,
+		LastUpdated TIMESTAMP OPTIONS (allow_commit_timestamp=true),
+	) PRIMARY KEY (SingerId, AlbumId),
+	  INTERLEAVE IN PARENT Singers ON DELETE CASCADE`,
+	`CREATE INDEX AlbumsByAlbumTitle ON Albums(AlbumTitle) STORING (Price)`,
+	`CREATE UNIQUE NULL_FILTERED INDEX SingersByLastName ON Singers(LastName DESC)`,
+}
+
+func newTestClient(t *testing.T) (*spanner.Client, *Server, func()) {
+	srv, err := NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.UpdateDDL(testDB, testDDL...); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := spanner.NewClientWithConfig(ctx, testDB,
+		spanner.ClientConfig{NumChannels: 1, SessionPoolConfig: spanner.SessionPoolConfig{MinOpened: 0}},
+		option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Singers", []string{"SingerId", "FirstName", "LastName", "BirthDate"}, []interface{}{1, "Marc", "Richards", civil.Date{Year: 1970, Month: 9, Day: 3}}),
+		spanner.Insert("Singers", []string{"SingerId", "FirstName", "LastName"}, []interface{}{2, "Catalina", "Smith"}),
+		spanner.Insert("Singers", []string{"SingerId", "FirstName", "LastName"}, []interface{}{3, "Alice", "Trentor"}),
+		spanner.Insert("Albums", []string{"SingerId", "AlbumId", "AlbumTitle", "Price", "Tags"}, []interface{}{1, 1, "Total Junk", 10.5, []string{"rock"}}),
+		spanner.Insert("Albums", []string{"SingerId", "AlbumId", "AlbumTitle", "Price"}, []interface{}{1, 2, "Go, Go, Go", 7.0}),
+		spanner.Insert("Albums", []string{"SingerId", "AlbumId", "AlbumTitle", "Price"}, []interface{}{2, 1, "Green", 12.0}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, srv, func() {
+		client.Close()
+		conn.Close()
+		srv.Close()
+	}
+}
+
+// runQuery runs a query and returns its rows, with each row's columns decoded
+// into the types of the zero values in proto.
+func runQuery(t *testing.T, ro *spanner.ReadOnlyTransaction, stmt spanner.Statement, proto []interface{}) ([][]interface{}, error) {
+	iter := ro.Query(context.Background(), stmt)
+	return collect(t, iter, proto)
+}
+
+func collect(t *testing.T, iter *spanner.RowIterator, proto []interface{}) ([][]interface{}, error) {
+	defer iter.Stop()
+	var rows [][]interface{}
+	for {
+		r, err := iter.Next()
+		if err == iterator.Done {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ptrs := make([]interface{}, len(proto))
+		for i, p := range proto {
+			ptrs[i] = reflect.New(reflect.TypeOf(p)).Interface()
+		}
+		if err := r.Columns(ptrs...); err != nil {
+			t.Fatal(err)
+		}
+		row := make([]interface{}, len(ptrs))
+		for i, p := range ptrs {
+			row[i] = reflect.ValueOf(p).Elem().Interface()
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+
+	for _, test := range []struct {
+		sql    string
+		params map[string]interface{}
+		proto  []interface{}
+		want   [][]interface{}
+	}{
+		{
+			sql:   `SELECT FirstName, LastName FROM Singers ORDER BY SingerId`,
+			proto: []interface{}{"", ""},
+			want:  [][]interface{}{{"Marc", "Richards"}, {"Catalina", "Smith"}, {"Alice", "Trentor"}},
+		},
+		{
+			sql:    `SELECT SingerId FROM Singers WHERE LastName = @last OR SingerId > @id ORDER BY SingerId DESC`,
+			params: map[string]interface{}{"last": "Richards", "id": 2},
+			proto:  []interface{}{int64(0)},
+			want:   [][]interface{}{{int64(3)}, {int64(1)}},
+		},
+		{
+			sql:   `SELECT s.FirstName, a.AlbumTitle FROM Singers s JOIN Albums a ON s.SingerId = a.SingerId WHERE a.Price >= 10 ORDER BY a.AlbumTitle`,
+			proto: []interface{}{"", ""},
+			want:  [][]interface{}{{"Catalina", "Green"}, {"Marc", "Total Junk"}},
+		},
+		{
+			sql:   `SELECT s.FirstName, a.AlbumId FROM Singers AS s LEFT JOIN Albums AS a ON s.SingerId = a.SingerId ORDER BY s.SingerId, a.AlbumId LIMIT 3 OFFSET 1`,
+			proto: []interface{}{"", spanner.NullInt64{}},
+			want: [][]interface{}{
+				{"Marc", spanner.NullInt64{Int64: 2, Valid: true}},
+				{"Catalina", spanner.NullInt64{Int64: 1, Valid: true}},
+				{"Alice", spanner.NullInt64{}},
+			},
+		},
+		{
+			sql:   `SELECT UPPER(FirstName) AS n, BirthDate IS NULL FROM Singers WHERE FirstName LIKE '%a%' AND SingerId IN (1, 2, 4) ORDER BY n`,
+			proto: []interface{}{"", false},
+			want:  [][]interface{}{{"CATALINA", true}, {"MARC", false}},
+		},
+		{
+			sql:   `SELECT SingerId FROM Singers WHERE BirthDate < '1980-01-01'`,
+			proto: []interface{}{int64(0)},
+			want:  [][]interface{}{{int64(1)}},
+		},
+		{
+			sql:   `SELECT DISTINCT SingerId FROM Albums@{FORCE_INDEX=AlbumsByAlbumTitle} ORDER BY 1`,
+			proto: []interface{}{int64(0)},
+			want:  [][]interface{}{{int64(1)}, {int64(2)}},
+		},
+		{
+			sql:   `SELECT 1 + 2 * 3, 7 / 2, CAST('12' AS INT64)`,
+			proto: []interface{}{int64(0), float64(0), int64(0)},
+			want:  [][]interface{}{{int64(7), 3.5, int64(12)}},
+		},
+		{
+			sql:   `SELECT * FROM Singers WHERE SingerId = 2`,
+			proto: []interface{}{int64(0), "", "", spanner.NullDate{}},
+			want:  [][]interface{}{{int64(2), "Catalina", "Smith", spanner.NullDate{}}},
+		},
+	} {
+		stmt := spanner.Statement{SQL: test.sql, Params: test.params}
+		got, err := runQuery(t, client.Single(), stmt, test.proto)
+		if err != nil {
+			t.Errorf("%s: %v", test.sql, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %v\nwant %v", test.sql, got, test.want)
+		}
+	}
+
+	for _, sql := range []string{
+		`SELECT Nope FROM Singers`,
+		`SELECT * FROM Nope`,
+		`SELECT SingerId FROM Singers s, Albums a`,
+		`SELECT FROM Singers`,
+		`SELECT COUNT(*) FROM Singers`,
+	} {
+		_, err := runQuery(t, client.Single(), spanner.NewStatement(sql), nil)
+		if spanner.ErrCode(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", sql, err)
+		}
+	}
+}
+
+func TestReads(t *testing.T) {
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	r, err := client.Single().ReadRow(ctx, "Singers", spanner.Key{2}, []string{"FirstName"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := r.Column(0, &name); err != nil || name != "Catalina" {
+		t.Errorf("got %q, %v; want Catalina", name, err)
+	}
+	if _, err := client.Single().ReadRow(ctx, "Singers", spanner.Key{9}, []string{"FirstName"}); spanner.ErrCode(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+
+	for _, test := range []struct {
+		table, index string
+		keys         spanner.KeySet
+		cols         []string
+		proto        []interface{}
+		want         [][]interface{}
+	}{
+		{
+			table: "Albums",
+			keys:  spanner.Key{1}.AsPrefix(),
+			cols:  []string{"AlbumId", "AlbumTitle"},
+			proto: []interface{}{int64(0), ""},
+			want:  [][]interface{}{{int64(1), "Total Junk"}, {int64(2), "Go, Go, Go"}},
+		},
+		{
+			table: "Albums",
+			keys:  spanner.KeyRange{Start: spanner.Key{1, 2}, End: spanner.Key{2}, Kind: spanner.ClosedClosed},
+			cols:  []string{"SingerId", "AlbumId"},
+			proto: []interface{}{int64(0), int64(0)},
+			want:  [][]interface{}{{int64(1), int64(2)}, {int64(2), int64(1)}},
+		},
+		{
+			table: "Albums",
+			index: "AlbumsByAlbumTitle",
+			keys:  spanner.AllKeys(),
+			cols:  []string{"AlbumTitle", "Price"},
+			proto: []interface{}{"", float64(0)},
+			want:  [][]interface{}{{"Go, Go, Go", 7.0}, {"Green", 12.0}, {"Total Junk", 10.5}},
+		},
+		{
+			table: "Singers",
+			index: "SingersByLastName",
+			keys:  spanner.KeySets(spanner.Key{"Richards"}, spanner.Key{"Trentor"}),
+			cols:  []string{"SingerId"},
+			proto: []interface{}{int64(0)},
+			want:  [][]interface{}{{int64(3)}, {int64(1)}},
+		},
+	} {
+		var iter *spanner.RowIterator
+		if test.index == "" {
+			iter = client.Single().Read(ctx, test.table, test.keys, test.cols)
+		} else {
+			iter = client.Single().ReadUsingIndex(ctx, test.table, test.index, test.keys, test.cols)
+		}
+		got, err := collect(t, iter, test.proto)
+		if err != nil {
+			t.Errorf("%s/%s: %v", test.table, test.index, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s/%s %v:\ngot  %v\nwant %v", test.table, test.index, test.keys, got, test.want)
+		}
+	}
+
+	// Columns not stored in an index cannot be read through it.
+	iter := client.Single().ReadUsingIndex(ctx, "Albums", "AlbumsByAlbumTitle", spanner.AllKeys(), []string{"Tags"})
+	if _, err := collect(t, iter, []interface{}{[]string(nil)}); spanner.ErrCode(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	ts, err := client.Apply(ctx, []*spanner.Mutation{
+		spanner.Update("Albums", []string{"SingerId", "AlbumId", "LastUpdated"}, []interface{}{1, 1, spanner.CommitTimestamp}),
+		spanner.InsertOrUpdate("Singers", []string{"SingerId", "FirstName"}, []interface{}{2, "Cat"}),
+		spanner.Replace("Singers", []string{"SingerId", "FirstName"}, []interface{}{3, "Al"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := client.Single().ReadRow(ctx, "Albums", spanner.Key{1, 1}, []string{"LastUpdated", "AlbumTitle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		got   time.Time
+		title string
+	)
+	if err := r.Columns(&got, &title); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(ts) || title != "Total Junk" {
+		t.Errorf("got (%v, %q), want (%v, Total Junk)", got, title, ts)
+	}
+	rows, err := runQuery(t, client.Single(), spanner.NewStatement(`SELECT FirstName, LastName FROM Singers WHERE SingerId >= 2 ORDER BY SingerId`),
+		[]interface{}{"", spanner.NullString{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"Cat", spanner.NullString{StringVal: "Smith", Valid: true}}, {"Al", spanner.NullString{}}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+
+	// A failed mutation leaves no trace of the others in its commit.
+	for _, test := range []struct {
+		ms   []*spanner.Mutation
+		code codes.Code
+	}{
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId"}, []interface{}{10}),
+			spanner.Insert("Singers", []string{"SingerId"}, []interface{}{1}),
+		}, codes.AlreadyExists},
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId"}, []interface{}{10}),
+			spanner.Update("Singers", []string{"SingerId"}, []interface{}{11}),
+		}, codes.NotFound},
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId"}, []interface{}{10}),
+			spanner.Insert("Albums", []string{"SingerId", "AlbumId"}, []interface{}{12, 1}),
+		}, codes.NotFound},
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId", "LastName"}, []interface{}{10, "Smith"}),
+		}, codes.AlreadyExists},
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"FirstName"}, []interface{}{"x"}),
+		}, codes.FailedPrecondition},
+		{[]*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId", "FirstName"}, []interface{}{10, strings.Repeat("x", 1025)}),
+		}, codes.FailedPrecondition},
+	} {
+		_, err := client.Apply(ctx, test.ms)
+		if got := spanner.ErrCode(err); got != test.code {
+			t.Errorf("%v: got %v, want code %v", test.ms, err, test.code)
+		}
+	}
+	if _, err := client.Single().ReadRow(ctx, "Singers", spanner.Key{10}, []string{"SingerId"}); spanner.ErrCode(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+
+	// Deleting a singer deletes their albums.
+	if _, err := client.Apply(ctx, []*spanner.Mutation{spanner.Delete("Singers", spanner.Key{1})}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = collect(t, client.Single().Read(ctx, "Albums", spanner.AllKeys(), []string{"SingerId"}), []interface{}{int64(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{int64(2)}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	client, _, cleanup := newTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	readPrice := func(ro *spanner.ReadOnlyTransaction) float64 {
+		r, err := ro.ReadRow(ctx, "Albums", spanner.Key{2, 1}, []string{"Price"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var p float64
+		if err := r.Column(0, &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+
+	// A multi-use read-only transaction sees a consistent snapshot.
+	ro := client.ReadOnlyTransaction()
+	defer ro.Close()
+	if got := readPrice(ro); got != 12 {
+		t.Fatalf("got %v, want 12", got)
+	}
+
+	// Concurrent read-write transactions that read the same row conflict;
+	// the client retries the loser.
+	attempts := 0
+	_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+		attempts++
+		r, err := tx.ReadRow(ctx, "Albums", spanner.Key{2, 1}, []string{"Price"})
+		if err != nil {
+			return err
+		}
+		var p float64
+		if err := r.Column(0, &p); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			// Another transaction commits first.
+			if _, err := client.Apply(context.Background(), []*spanner.Mutation{
+				spanner.Update("Albums", []string{"SingerId", "AlbumId", "Price"}, []interface{}{2, 1, p + 1}),
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.BufferWrite([]*spanner.Mutation{
+			spanner.Update("Albums", []string{"SingerId", "AlbumId", "Price"}, []interface{}{2, 1, p * 2}),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+
+	if got := readPrice(ro); got != 12 {
+		t.Errorf("snapshot read: got %v, want 12", got)
+	}
+	if got := readPrice(client.Single()); got != 26 {
+		t.Errorf("strong read: got %v, want 26", got)
+	}
+	if got := readPrice(client.Single().WithTimestampBound(spanner.ReadTimestamp(before))); got != 12 {
+		t.Errorf("read at %v: got %v, want 12", before, got)
+	}
+	if got := readPrice(client.Single().WithTimestampBound(spanner.ExactStaleness(time.Since(before)))); got != 12 {
+		t.Errorf("stale read: got %v, want 12", got)
+	}
+	if got := readPrice(client.Single().WithTimestampBound(spanner.MaxStaleness(time.Minute))); got != 26 {
+		t.Errorf("bounded stale read: got %v, want 26", got)
+	}
+	_, err = client.Single().WithTimestampBound(spanner.ExactStaleness(2*time.Hour)).ReadRow(ctx, "Albums", spanner.Key{2, 1}, []string{"Price"})
+	if spanner.ErrCode(err) != codes.FailedPrecondition {
+		t.Errorf("got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	srv, err := NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	ctx := context.Background()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	admin, err := dbadmin.NewDatabaseAdminClient(ctx, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := admin.CreateDatabase(ctx, &adminpb.CreateDatabaseRequest{
+		Parent:          "projects/p/instances/i",
+		CreateStatement: "CREATE DATABASE `d`",
+		ExtraStatements: testDDL[:1],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := op.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Name != testDB {
+		t.Errorf("got %q, want %q", db.Name, testDB)
+	}
+	uop, err := admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   testDB,
+		Statements: []string{"ALTER TABLE Singers ADD COLUMN Age INT64", "CREATE INDEX SingersByAge ON Singers(Age DESC)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uop.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   testDB,
+		Statements: []string{"ALTER TABLE Singers DROP COLUMN Age"},
+	})
+	if grpc.Code(err) != codes.FailedPrecondition {
+		t.Errorf("dropping indexed column: got %v, want FailedPrecondition", err)
+	}
+	res, err := admin.GetDatabaseDdl(ctx, &adminpb.GetDatabaseDdlRequest{Database: testDB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE Singers (\n  SingerId INT64 NOT NULL,\n  FirstName STRING(1024),\n  LastName STRING(1024),\n  BirthDate DATE,\n  Age INT64,\n) PRIMARY KEY(SingerId)",
+		"CREATE INDEX SingersByAge ON Singers(Age DESC)",
+	}
+	if !reflect.DeepEqual(res.Statements, want) {
+		t.Errorf("got %q\nwant %q", res.Statements, want)
+	}
+	// The generated DDL can be parsed again.
+	for _, stmt := range res.Statements {
+		if _, err := parseDDL(stmt); err != nil {
+			t.Errorf("%s: %v", stmt, err)
+		}
+	}
+}
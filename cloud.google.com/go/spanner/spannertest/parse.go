@@ -0,0 +1,1228 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spannertest
+
+// This file contains the lexer and the parsers for the subsets of Cloud
+// Spanner's DDL and query language that the fake understands.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokInt
+	tokFloat
+	tokString
+	tokBytes
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string // unquoted value for strings, bytes and quoted identifiers
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of statement"
+	case tokString, tokBytes:
+		return strconv.Quote(t.text)
+	case tokQuotedIdent:
+		return "`" + t.text + "`"
+	case tokParam:
+		return "@" + t.text
+	}
+	return t.text
+}
+
+var symbols = []string{"<=", ">=", "<>", "!=", "||", "@{", "(", ")", ",", ".", "*", "=", "<", ">", "+", "-", "/", "}", ";"}
+
+// lex splits s into tokens.
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '#' || strings.HasPrefix(s[i:], "--"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+			continue
+		case c == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted identifier")
+			}
+			toks = append(toks, token{tokQuotedIdent, s[i+1 : i+1+end]})
+			i += end + 2
+			continue
+		case c == '\'' || c == '"':
+			str, n, err := lexString(s[i:], false)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokString, str})
+			i += n
+			continue
+		case (c == 'b' || c == 'B' || c == 'r' || c == 'R') && i+1 < len(s):
+			// Bytes and raw string literals.
+			var kind tokenKind
+			raw, j := false, i
+			for k := 0; k < 2 && j < len(s); k++ {
+				switch s[j] {
+				case 'b', 'B':
+					kind = tokBytes
+					j++
+					continue
+				case 'r', 'R':
+					raw = true
+					j++
+					continue
+				}
+				break
+			}
+			if j < len(s) && (s[j] == '\'' || s[j] == '"') {
+				str, n, err := lexString(s[j:], raw)
+				if err != nil {
+					return nil, err
+				}
+				if kind != tokBytes {
+					kind = tokString
+				}
+				toks = append(toks, token{kind, str})
+				i = j + n
+				continue
+			}
+		case c == '@' && i+1 < len(s) && isIdentStart(rune(s[i+1])):
+			j := i + 1
+			for j < len(s) && isIdentPart(rune(s[j])) {
+				j++
+			}
+			toks = append(toks, token{tokParam, s[i+1 : j]})
+			i = j
+			continue
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j, kind := i, tokInt
+			if strings.HasPrefix(strings.ToLower(s[i:]), "0x") {
+				j += 2
+				for j < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+					j++
+				}
+			} else {
+				for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+					if s[j] == '.' {
+						kind = tokFloat
+					}
+					j++
+				}
+				if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+					kind = tokFloat
+					j++
+					if j < len(s) && (s[j] == '+' || s[j] == '-') {
+						j++
+					}
+					for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+						j++
+					}
+				}
+			}
+			toks = append(toks, token{kind, s[i:j]})
+			i = j
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		if isIdentStart(r) {
+			j := i
+			for j < len(s) {
+				r, n := utf8.DecodeRuneInString(s[j:])
+				if !isIdentPart(r) {
+					break
+				}
+				j += n
+			}
+			toks = append(toks, token{tokIdent, s[i:j]})
+			i = j
+			continue
+		}
+		found := false
+		for _, sym := range symbols {
+			if strings.HasPrefix(s[i:], sym) {
+				toks = append(toks, token{tokSymbol, sym})
+				i += len(sym)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+func isIdentStart(r rune) bool { return r == '_' || unicode.IsLetter(r) }
+
+func isIdentPart(r rune) bool { return isIdentStart(r) || unicode.IsDigit(r) }
+
+// lexString reads the quoted string at the start of s, returning its
+// unescaped value and the number of bytes consumed. Triple-quoted strings
+// are supported.
+func lexString(s string, raw bool) (string, int, error) {
+	q := s[:1]
+	if strings.HasPrefix(s, strings.Repeat(q, 3)) {
+		q = s[:3]
+	}
+	var buf []byte
+	i := len(q)
+	for {
+		if i >= len(s) {
+			return "", 0, fmt.Errorf("unterminated string literal")
+		}
+		if strings.HasPrefix(s[i:], q) {
+			return string(buf), i + len(q), nil
+		}
+		c := s[i]
+		if len(q) == 1 && c == '\n' {
+			return "", 0, fmt.Errorf("unterminated string literal")
+		}
+		if c != '\\' || raw {
+			buf = append(buf, c)
+			i++
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", 0, fmt.Errorf("unterminated string literal")
+		}
+		i++
+		switch e := s[i]; e {
+		case 'a':
+			buf = append(buf, '\a')
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'v':
+			buf = append(buf, '\v')
+		case 'x', 'X':
+			if i+2 >= len(s) {
+				return "", 0, fmt.Errorf("bad escape sequence")
+			}
+			n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", 0, fmt.Errorf("bad escape sequence")
+			}
+			buf = append(buf, byte(n))
+			i += 2
+		default:
+			buf = append(buf, e)
+		}
+		i++
+	}
+}
+
+// parser is a recursive-descent parser over a token stream.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func newParser(s string) (*parser, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{toks: toks}, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword reports whether the next token is one of the given keywords.
+func (p *parser) isKeyword(kws ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, kw := range kws {
+		if strings.EqualFold(t.text, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// eat consumes the given sequence of keywords if it comes next.
+func (p *parser) eat(kws ...string) bool {
+	for i, kw := range kws {
+		t := p.toks[p.pos+i]
+		if t.kind != tokIdent || !strings.EqualFold(t.text, kw) {
+			return false
+		}
+		if t.kind == tokEOF {
+			return false
+		}
+	}
+	p.pos += len(kws)
+	return true
+}
+
+func (p *parser) expect(kws ...string) error {
+	if !p.eat(kws...) {
+		return p.errorf("expected %s", strings.Join(kws, " "))
+	}
+	return nil
+}
+
+func (p *parser) eatSymbol(sym string) bool {
+	if t := p.peek(); t.kind == tokSymbol && t.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if !p.eatSymbol(sym) {
+		return p.errorf("expected %q", sym)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s, found %v", fmt.Sprintf(format, args...), p.peek())
+}
+
+// reserved holds the keywords that cannot be used as unquoted identifiers.
+var reserved = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CROSS": true, "DESC": true, "DISTINCT": true, "FALSE": true, "FROM": true,
+	"FULL": true, "INNER": true, "IN": true, "IS": true, "JOIN": true, "LEFT": true,
+	"LIKE": true, "LIMIT": true, "NOT": true, "NULL": true, "OFFSET": true,
+	"ON": true, "OR": true, "ORDER": true, "RIGHT": true, "SELECT": true,
+	"TRUE": true, "WHERE": true,
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokQuotedIdent:
+	case t.kind == tokIdent && !reserved[strings.ToUpper(t.text)]:
+	default:
+		return "", p.errorf("expected identifier")
+	}
+	p.next()
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for !p.eatSymbol(")") {
+		if len(names) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (p *parser) end() error {
+	p.eatSymbol(";")
+	if p.peek().kind != tokEOF {
+		return p.errorf("unexpected input")
+	}
+	return nil
+}
+
+// DDL
+
+type createDatabase struct{ name string }
+
+type createTable struct{ tbl *table }
+
+type createIndex struct{ idx *index }
+
+type dropTable struct{ name string }
+
+type dropIndex struct{ name string }
+
+type addColumn struct {
+	table string
+	col   *column
+}
+
+type dropColumn struct{ table, col string }
+
+// parseDDL parses a single DDL statement.
+func parseDDL(s string) (interface{}, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return nil, err
+	}
+	var stmt interface{}
+	switch {
+	case p.eat("CREATE", "DATABASE"):
+		var name string
+		name, err = p.ident()
+		stmt = createDatabase{name}
+	case p.eat("CREATE", "TABLE"):
+		var t *table
+		t, err = p.createTable()
+		stmt = createTable{t}
+	case p.isKeyword("CREATE"):
+		var idx *index
+		idx, err = p.createIndex()
+		stmt = createIndex{idx}
+	case p.eat("DROP", "TABLE"):
+		var name string
+		name, err = p.ident()
+		stmt = dropTable{name}
+	case p.eat("DROP", "INDEX"):
+		var name string
+		name, err = p.ident()
+		stmt = dropIndex{name}
+	case p.eat("ALTER", "TABLE"):
+		stmt, err = p.alterTable()
+	default:
+		return nil, p.errorf("unsupported DDL statement")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (p *parser) createTable() (*table, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	t := &table{name: name}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.eatSymbol(")") {
+		if len(t.cols) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+			// A trailing comma is allowed.
+			if p.eatSymbol(")") {
+				break
+			}
+		}
+		c, err := p.columnDef()
+		if err != nil {
+			return nil, err
+		}
+		if t.colIndex(c.name) >= 0 {
+			return nil, fmt.Errorf("duplicate column %s", c.name)
+		}
+		t.cols = append(t.cols, c)
+	}
+	if err := p.expect("PRIMARY", "KEY"); err != nil {
+		return nil, err
+	}
+	t.pk, err = p.keyParts()
+	if err != nil {
+		return nil, err
+	}
+	if p.eatSymbol(",") {
+		if err := p.expect("INTERLEAVE", "IN", "PARENT"); err != nil {
+			return nil, err
+		}
+		if t.parent, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if p.eat("ON", "DELETE") {
+			switch {
+			case p.eat("CASCADE"):
+				t.cascade = true
+			case p.eat("NO", "ACTION"):
+			default:
+				return nil, p.errorf("expected CASCADE or NO ACTION")
+			}
+		}
+	}
+	return t, nil
+}
+
+func (p *parser) columnDef() (*column, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	c := &column{name: name}
+	if c.typ, c.size, err = p.columnType(); err != nil {
+		return nil, err
+	}
+	if p.eat("NOT", "NULL") {
+		c.notNull = true
+	}
+	if p.eat("OPTIONS") {
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		if err := p.expect("allow_commit_timestamp"); err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, err
+		}
+		switch {
+		case p.eat("TRUE"):
+			c.allowCommitTimestamp = true
+		case p.eat("NULL"), p.eat("FALSE"):
+		default:
+			return nil, p.errorf("expected true or null")
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		if c.allowCommitTimestamp && c.typ.Code != sppb.TypeCode_TIMESTAMP {
+			return nil, fmt.Errorf("allow_commit_timestamp is only valid for TIMESTAMP columns")
+		}
+	}
+	return c, nil
+}
+
+// columnType parses a column type. The returned size is the declared
+// length of a STRING or BYTES type, or zero for MAX.
+func (p *parser) columnType() (*sppb.Type, int64, error) {
+	if p.eat("ARRAY") {
+		if err := p.expectSymbol("<"); err != nil {
+			return nil, 0, err
+		}
+		et, size, err := p.columnType()
+		if err != nil {
+			return nil, 0, err
+		}
+		if et.Code == sppb.TypeCode_ARRAY {
+			return nil, 0, fmt.Errorf("nested arrays are not supported")
+		}
+		if err := p.expectSymbol(">"); err != nil {
+			return nil, 0, err
+		}
+		return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: et}, size, nil
+	}
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, 0, fmt.Errorf("expected type, found %v", t)
+	}
+	switch strings.ToUpper(t.text) {
+	case "BOOL":
+		return boolType, 0, nil
+	case "INT64":
+		return int64Type, 0, nil
+	case "FLOAT64":
+		return float64Type, 0, nil
+	case "DATE":
+		return dateType, 0, nil
+	case "TIMESTAMP":
+		return timeType, 0, nil
+	case "STRING", "BYTES":
+		typ := stringType
+		if strings.ToUpper(t.text) == "BYTES" {
+			typ = bytesType
+		}
+		if err := p.expectSymbol("("); err != nil {
+			return nil, 0, err
+		}
+		var size int64
+		if !p.eat("MAX") {
+			n := p.next()
+			if n.kind != tokInt {
+				return nil, 0, fmt.Errorf("expected length, found %v", n)
+			}
+			var err error
+			if size, err = strconv.ParseInt(n.text, 0, 64); err != nil || size <= 0 {
+				return nil, 0, fmt.Errorf("bad length %s", n.text)
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, 0, err
+		}
+		return typ, size, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported type %s", t.text)
+}
+
+func (p *parser) keyParts() ([]keyPart, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var kps []keyPart
+	for !p.eatSymbol(")") {
+		if len(kps) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		kp := keyPart{col: name}
+		if p.eat("DESC") {
+			kp.desc = true
+		} else {
+			p.eat("ASC")
+		}
+		kps = append(kps, kp)
+	}
+	return kps, nil
+}
+
+func (p *parser) createIndex() (*index, error) {
+	p.eat("CREATE")
+	idx := &index{}
+	if p.eat("UNIQUE") {
+		idx.unique = true
+	}
+	if p.eat("NULL_FILTERED") {
+		idx.nullFiltered = true
+	}
+	if err := p.expect("INDEX"); err != nil {
+		return nil, err
+	}
+	var err error
+	if idx.name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("ON"); err != nil {
+		return nil, err
+	}
+	if idx.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if idx.key, err = p.keyParts(); err != nil {
+		return nil, err
+	}
+	if p.eat("STORING") {
+		if idx.storing, err = p.identList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.eatSymbol(",") {
+		if err := p.expect("INTERLEAVE", "IN"); err != nil {
+			return nil, err
+		}
+		if idx.interleave, err = p.ident(); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func (p *parser) alterTable() (interface{}, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.eat("ADD", "COLUMN"):
+		c, err := p.columnDef()
+		if err != nil {
+			return nil, err
+		}
+		return addColumn{name, c}, nil
+	case p.eat("DROP", "COLUMN"):
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		return dropColumn{name, col}, nil
+	}
+	return nil, p.errorf("unsupported ALTER TABLE statement")
+}
+
+// Queries
+
+type query struct {
+	distinct bool
+	items    []selectItem
+	from     []fromItem // empty for a query without a FROM clause
+	where    expr
+	order    []orderItem
+	limit    expr
+	offset   expr
+}
+
+type selectItem struct {
+	star      bool   // SELECT * or SELECT t.*
+	starTable string // the qualifier of t.*
+	e         expr
+	alias     string
+}
+
+type fromItem struct {
+	table string
+	alias string
+	join  string // "", "INNER", "LEFT" or "CROSS"; empty for the first item
+	on    expr
+}
+
+type orderItem struct {
+	e    expr
+	desc bool
+}
+
+type expr interface{}
+
+type (
+	literal struct {
+		v interface{}
+		t *sppb.Type // nil for NULL
+	}
+	paramRef struct{ name string }
+	colRef   struct{ table, name string }
+	unaryOp  struct {
+		op string // "NOT" or "-"
+		x  expr
+	}
+	binaryOp struct {
+		op   string // "AND", "OR", a comparison operator, "+", "-", "*", "/" or "||"
+		l, r expr
+	}
+	isNull struct {
+		x   expr
+		not bool
+	}
+	inList struct {
+		x    expr
+		list []expr
+		not  bool
+	}
+	between struct {
+		x, lo, hi expr
+		not       bool
+	}
+	like struct {
+		x, pattern expr
+		not        bool
+	}
+	funcCall struct {
+		name string // upper case
+		args []expr
+	}
+)
+
+// parseQuery parses a SELECT statement.
+func parseQuery(s string) (*query, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (p *parser) query() (*query, error) {
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	q := &query{}
+	if p.eat("DISTINCT") {
+		q.distinct = true
+	} else {
+		p.eat("ALL")
+	}
+	for {
+		item, err := p.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		q.items = append(q.items, item)
+		if !p.eatSymbol(",") {
+			break
+		}
+	}
+	if p.eat("FROM") {
+		for {
+			var fi fromItem
+			switch {
+			case len(q.from) == 0:
+			case p.eatSymbol(","), p.eat("CROSS", "JOIN"):
+				fi.join = "CROSS"
+			case p.eat("JOIN"), p.eat("INNER", "JOIN"):
+				fi.join = "INNER"
+			case p.eat("LEFT", "JOIN"), p.eat("LEFT", "OUTER", "JOIN"):
+				fi.join = "LEFT"
+			default:
+				goto fromDone
+			}
+			var err error
+			if fi.table, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err := p.skipHint(); err != nil {
+				return nil, err
+			}
+			if p.eat("AS") {
+				if fi.alias, err = p.ident(); err != nil {
+					return nil, err
+				}
+			} else if t := p.peek(); t.kind == tokQuotedIdent || t.kind == tokIdent && !reserved[strings.ToUpper(t.text)] {
+				fi.alias, _ = p.ident()
+			}
+			if fi.join == "INNER" || fi.join == "LEFT" {
+				if err := p.expect("ON"); err != nil {
+					return nil, err
+				}
+				if fi.on, err = p.expr(); err != nil {
+					return nil, err
+				}
+			}
+			q.from = append(q.from, fi)
+		}
+	}
+fromDone:
+	var err error
+	if p.eat("WHERE") {
+		if q.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.eat("ORDER", "BY") {
+		for {
+			var oi orderItem
+			if oi.e, err = p.expr(); err != nil {
+				return nil, err
+			}
+			if p.eat("DESC") {
+				oi.desc = true
+			} else {
+				p.eat("ASC")
+			}
+			q.order = append(q.order, oi)
+			if !p.eatSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.eat("LIMIT") {
+		if q.limit, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if p.eat("OFFSET") {
+			if q.offset, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return q, nil
+}
+
+// skipHint skips a table hint such as @{FORCE_INDEX=Idx}. Hints only
+// affect performance, so the fake ignores them.
+func (p *parser) skipHint() error {
+	if !p.eatSymbol("@{") {
+		return nil
+	}
+	for !p.eatSymbol("}") {
+		if p.next().kind == tokEOF {
+			return fmt.Errorf("unterminated hint")
+		}
+	}
+	return nil
+}
+
+func (p *parser) selectItem() (selectItem, error) {
+	if p.eatSymbol("*") {
+		return selectItem{star: true}, nil
+	}
+	// t.*
+	if t := p.peek(); (t.kind == tokIdent || t.kind == tokQuotedIdent) && p.toks[p.pos+1].text == "." && p.toks[p.pos+2].text == "*" {
+		p.pos += 3
+		return selectItem{star: true, starTable: t.text}, nil
+	}
+	e, err := p.expr()
+	if err != nil {
+		return selectItem{}, err
+	}
+	item := selectItem{e: e}
+	if p.eat("AS") {
+		if item.alias, err = p.ident(); err != nil {
+			return selectItem{}, err
+		}
+	} else if t := p.peek(); t.kind == tokQuotedIdent || t.kind == tokIdent && !reserved[strings.ToUpper(t.text)] {
+		item.alias, _ = p.ident()
+	}
+	return item, nil
+}
+
+func (p *parser) expr() (expr, error) {
+	l, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.eat("OR") {
+		r, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryOp{"OR", l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) andExpr() (expr, error) {
+	l, err := p.notExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.eat("AND") {
+		r, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryOp{"AND", l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) notExpr() (expr, error) {
+	if p.eat("NOT") {
+		x, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp{"NOT", x}, nil
+	}
+	return p.comparison()
+}
+
+var comparisonOps = map[string]bool{"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *parser) comparison() (expr, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokSymbol && comparisonOps[t.text] {
+		p.next()
+		r, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		op := t.text
+		if op == "<>" {
+			op = "!="
+		}
+		return binaryOp{op, l, r}, nil
+	}
+	if p.eat("IS") {
+		not := p.eat("NOT")
+		switch {
+		case p.eat("NULL"):
+			return isNull{l, not}, nil
+		case p.eat("TRUE"):
+			e := expr(binaryOp{"=", l, literal{true, boolType}})
+			if not {
+				// x IS NOT TRUE is true for NULL.
+				e = binaryOp{"OR", isNull{l, false}, unaryOp{"NOT", e}}
+			} else {
+				e = binaryOp{"AND", isNull{l, true}, e}
+			}
+			return e, nil
+		case p.eat("FALSE"):
+			e := expr(binaryOp{"=", l, literal{false, boolType}})
+			if not {
+				e = binaryOp{"OR", isNull{l, false}, unaryOp{"NOT", e}}
+			} else {
+				e = binaryOp{"AND", isNull{l, true}, e}
+			}
+			return e, nil
+		}
+		return nil, p.errorf("expected NULL, TRUE or FALSE")
+	}
+	not := p.eat("NOT")
+	switch {
+	case p.eat("IN"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		in := inList{x: l, not: not}
+		for !p.eatSymbol(")") {
+			if len(in.list) > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return nil, err
+				}
+			}
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, e)
+		}
+		return in, nil
+	case p.eat("BETWEEN"):
+		lo, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return between{l, lo, hi, not}, nil
+	case p.eat("LIKE"):
+		pat, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return like{l, pat, not}, nil
+	}
+	if not {
+		return nil, p.errorf("expected IN, BETWEEN or LIKE")
+	}
+	return l, nil
+}
+
+func (p *parser) additive() (expr, error) {
+	l, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokSymbol || t.text != "+" && t.text != "-" && t.text != "||" {
+			return l, nil
+		}
+		p.next()
+		r, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryOp{t.text, l, r}
+	}
+}
+
+func (p *parser) multiplicative() (expr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokSymbol || t.text != "*" && t.text != "/" {
+			return l, nil
+		}
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryOp{t.text, l, r}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.eatSymbol("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		// Fold negative numeric literals so that the minimum INT64 is expressible.
+		if lit, ok := x.(literal); ok {
+			switch v := lit.v.(type) {
+			case int64:
+				return literal{-v, lit.t}, nil
+			case float64:
+				return literal{-v, lit.t}, nil
+			}
+		}
+		return unaryOp{"-", x}, nil
+	}
+	if p.eatSymbol("+") {
+		return p.unary()
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokInt:
+		p.next()
+		if n, err := strconv.ParseInt(t.text, 0, 64); err == nil {
+			return literal{n, int64Type}, nil
+		}
+		// Only the negation of this literal is a valid INT64.
+		if t.text == "9223372036854775808" && p.toks[p.pos-2].text == "-" {
+			return literal{int64(-1 << 63), int64Type}, nil
+		}
+		return nil, fmt.Errorf("bad integer literal %s", t.text)
+	case tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad floating point literal %s", t.text)
+		}
+		return literal{f, float64Type}, nil
+	case tokString:
+		p.next()
+		return literal{t.text, stringType}, nil
+	case tokBytes:
+		p.next()
+		return literal{[]byte(t.text), bytesType}, nil
+	case tokParam:
+		p.next()
+		return paramRef{t.text}, nil
+	case tokSymbol:
+		if p.eatSymbol("(") {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	case tokIdent:
+		switch {
+		case p.eat("NULL"):
+			return literal{}, nil
+		case p.eat("TRUE"):
+			return literal{true, boolType}, nil
+		case p.eat("FALSE"):
+			return literal{false, boolType}, nil
+		}
+		if p.toks[p.pos+1].text == "(" && p.toks[p.pos+1].kind == tokSymbol {
+			return p.funcCall()
+		}
+		// Typed literals such as DATE '2018-01-02'.
+		if next := p.toks[p.pos+1]; next.kind == tokString && (strings.EqualFold(t.text, "DATE") || strings.EqualFold(t.text, "TIMESTAMP")) {
+			p.pos += 2
+			typ := dateType
+			if strings.EqualFold(t.text, "TIMESTAMP") {
+				typ = timeType
+			}
+			v, err := castValue(next.text, stringType, typ)
+			if err != nil {
+				return nil, err
+			}
+			return literal{v, typ}, nil
+		}
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, p.errorf("expected expression")
+	}
+	if p.eatSymbol(".") {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		return colRef{name, col}, nil
+	}
+	return colRef{"", name}, nil
+}
+
+func (p *parser) funcCall() (expr, error) {
+	name := strings.ToUpper(p.next().text)
+	p.next() // (
+	fc := funcCall{name: name}
+	if name == "CAST" {
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AS"); err != nil {
+			return nil, err
+		}
+		typ, _, err := p.columnType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return funcCall{name: name, args: []expr{x, literal{nil, typ}}}, nil
+	}
+	for !p.eatSymbol(")") {
+		if len(fc.args) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		fc.args = append(fc.args, e)
+	}
+	return fc, nil
+}
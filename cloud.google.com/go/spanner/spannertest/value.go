@@ -0,0 +1,376 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spannertest
+
+// This file contains the conversions between the wire encoding of Cloud Spanner
+// values and the Go values the fake stores.
+//
+// Values are held as follows:
+//	BOOL       bool
+//	INT64      int64
+//	FLOAT64    float64
+//	STRING     string
+//	BYTES      []byte
+//	DATE       civil.Date
+//	TIMESTAMP  time.Time
+//	ARRAY      []interface{}
+//	NULL       nil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	proto3 "github.com/golang/protobuf/ptypes/struct"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// commitTimestampPlaceholder may be written to a TIMESTAMP column in place of
+// a value; it is replaced by the commit timestamp of the transaction.
+const commitTimestampPlaceholder = "spanner.commit_timestamp()"
+
+var (
+	boolType    = &sppb.Type{Code: sppb.TypeCode_BOOL}
+	int64Type   = &sppb.Type{Code: sppb.TypeCode_INT64}
+	float64Type = &sppb.Type{Code: sppb.TypeCode_FLOAT64}
+	stringType  = &sppb.Type{Code: sppb.TypeCode_STRING}
+	bytesType   = &sppb.Type{Code: sppb.TypeCode_BYTES}
+	dateType    = &sppb.Type{Code: sppb.TypeCode_DATE}
+	timeType    = &sppb.Type{Code: sppb.TypeCode_TIMESTAMP}
+)
+
+// typeString returns the DDL spelling of t.
+func typeString(t *sppb.Type) string {
+	if t.Code == sppb.TypeCode_ARRAY {
+		return "ARRAY<" + typeString(t.ArrayElementType) + ">"
+	}
+	return t.Code.String()
+}
+
+func sameType(a, b *sppb.Type) bool {
+	if a.Code != b.Code {
+		return false
+	}
+	if a.Code == sppb.TypeCode_ARRAY {
+		return sameType(a.ArrayElementType, b.ArrayElementType)
+	}
+	return true
+}
+
+// decodeValue converts v, encoded as a value of type t, to its Go representation.
+func decodeValue(v *proto3.Value, t *sppb.Type) (interface{}, error) {
+	if _, ok := v.Kind.(*proto3.Value_NullValue); ok {
+		return nil, nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("cannot decode %v as %s", v, typeString(t))
+	}
+	switch t.Code {
+	case sppb.TypeCode_BOOL:
+		x, ok := v.Kind.(*proto3.Value_BoolValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		return x.BoolValue, nil
+	case sppb.TypeCode_INT64:
+		switch x := v.Kind.(type) {
+		case *proto3.Value_StringValue:
+			n, err := strconv.ParseInt(x.StringValue, 10, 64)
+			if err != nil {
+				return nil, mismatch()
+			}
+			return n, nil
+		case *proto3.Value_NumberValue:
+			// Be lenient: JSON numbers are accepted if they are integral.
+			if x.NumberValue != math.Trunc(x.NumberValue) {
+				return nil, mismatch()
+			}
+			return int64(x.NumberValue), nil
+		}
+		return nil, mismatch()
+	case sppb.TypeCode_FLOAT64:
+		switch x := v.Kind.(type) {
+		case *proto3.Value_NumberValue:
+			return x.NumberValue, nil
+		case *proto3.Value_StringValue:
+			switch x.StringValue {
+			case "NaN":
+				return math.NaN(), nil
+			case "Infinity":
+				return math.Inf(1), nil
+			case "-Infinity":
+				return math.Inf(-1), nil
+			}
+		}
+		return nil, mismatch()
+	case sppb.TypeCode_STRING:
+		x, ok := v.Kind.(*proto3.Value_StringValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		return x.StringValue, nil
+	case sppb.TypeCode_BYTES:
+		x, ok := v.Kind.(*proto3.Value_StringValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		b, err := base64.StdEncoding.DecodeString(x.StringValue)
+		if err != nil {
+			return nil, mismatch()
+		}
+		return b, nil
+	case sppb.TypeCode_DATE:
+		x, ok := v.Kind.(*proto3.Value_StringValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		d, err := civil.ParseDate(x.StringValue)
+		if err != nil {
+			return nil, mismatch()
+		}
+		return d, nil
+	case sppb.TypeCode_TIMESTAMP:
+		x, ok := v.Kind.(*proto3.Value_StringValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		if x.StringValue == commitTimestampPlaceholder {
+			return commitTimestampPlaceholder, nil
+		}
+		ts, err := time.Parse(time.RFC3339Nano, x.StringValue)
+		if err != nil {
+			return nil, mismatch()
+		}
+		return ts.UTC(), nil
+	case sppb.TypeCode_ARRAY:
+		x, ok := v.Kind.(*proto3.Value_ListValue)
+		if !ok {
+			return nil, mismatch()
+		}
+		arr := make([]interface{}, len(x.ListValue.Values))
+		for i, ev := range x.ListValue.Values {
+			e, err := decodeValue(ev, t.ArrayElementType)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = e
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", typeString(t))
+}
+
+// encodeValue converts a Go value held by the fake to its wire encoding.
+func encodeValue(x interface{}) *proto3.Value {
+	switch x := x.(type) {
+	case nil:
+		return &proto3.Value{Kind: &proto3.Value_NullValue{}}
+	case bool:
+		return &proto3.Value{Kind: &proto3.Value_BoolValue{BoolValue: x}}
+	case int64:
+		return stringValue(strconv.FormatInt(x, 10))
+	case float64:
+		switch {
+		case math.IsNaN(x):
+			return stringValue("NaN")
+		case math.IsInf(x, 1):
+			return stringValue("Infinity")
+		case math.IsInf(x, -1):
+			return stringValue("-Infinity")
+		}
+		return &proto3.Value{Kind: &proto3.Value_NumberValue{NumberValue: x}}
+	case string:
+		return stringValue(x)
+	case []byte:
+		return stringValue(base64.StdEncoding.EncodeToString(x))
+	case civil.Date:
+		return stringValue(x.String())
+	case time.Time:
+		return stringValue(x.UTC().Format(time.RFC3339Nano))
+	case []interface{}:
+		vs := make([]*proto3.Value, len(x))
+		for i, e := range x {
+			vs[i] = encodeValue(e)
+		}
+		return &proto3.Value{Kind: &proto3.Value_ListValue{ListValue: &proto3.ListValue{Values: vs}}}
+	}
+	panic(fmt.Sprintf("spannertest: cannot encode %T", x))
+}
+
+func stringValue(s string) *proto3.Value {
+	return &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: s}}
+}
+
+// compareValues orders two values of the same type. NULL sorts before any
+// other value, and NaN before any other FLOAT64, as in Cloud Spanner.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareInts(a, b)
+		case float64:
+			return compareFloats(float64(a), b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return compareFloats(a, float64(b))
+		case float64:
+			return compareFloats(a, b)
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case []byte:
+		return bytes.Compare(a, b.([]byte))
+	case civil.Date:
+		b := b.(civil.Date)
+		switch {
+		case a.Before(b):
+			return -1
+		case b.Before(a):
+			return 1
+		}
+		return 0
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case b.Before(a):
+			return 1
+		}
+		return 0
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compareValues(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(int64(len(a)), int64(len(b)))
+	}
+	panic(fmt.Sprintf("spannertest: cannot compare %T and %T", a, b))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareKeys compares two keys column by column; desc reports which
+// columns sort in descending order. If one key is a prefix of the other,
+// only the common prefix is compared.
+func compareKeys(a, b []interface{}, desc []bool) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		c := compareValues(a[i], b[i])
+		if desc[i] {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// assignable reports whether x, a decoded value, may be stored in a
+// column of type t.
+func assignable(x interface{}, t *sppb.Type) bool {
+	if x == nil {
+		return true
+	}
+	switch t.Code {
+	case sppb.TypeCode_BOOL:
+		_, ok := x.(bool)
+		return ok
+	case sppb.TypeCode_INT64:
+		_, ok := x.(int64)
+		return ok
+	case sppb.TypeCode_FLOAT64:
+		_, ok := x.(float64)
+		return ok
+	case sppb.TypeCode_STRING:
+		_, ok := x.(string)
+		return ok
+	case sppb.TypeCode_BYTES:
+		_, ok := x.([]byte)
+		return ok
+	case sppb.TypeCode_DATE:
+		_, ok := x.(civil.Date)
+		return ok
+	case sppb.TypeCode_TIMESTAMP:
+		_, ok := x.(time.Time)
+		return ok
+	case sppb.TypeCode_ARRAY:
+		arr, ok := x.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, e := range arr {
+			if !assignable(e, t.ArrayElementType) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -670,6 +670,42 @@ func decodeValue(v *proto3.Value, t *sppb.Type, ptr interface{}) error {
 			return err
 		}
 		*p = y
+	case *civil.Timestamp:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if isNull {
+			return errDstNotForNull(ptr)
+		}
+		var nt NullTime
+		if err := parseNullTime(v, &nt, code, isNull); err != nil {
+			return err
+		}
+		*p = civil.TimestampOf(nt.Time)
+	case *[]civil.Timestamp:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if acode != sppb.TypeCode_TIMESTAMP {
+			return errTypeMismatch(code, acode, ptr)
+		}
+		if isNull {
+			*p = nil
+			break
+		}
+		x, err := getListValue(v)
+		if err != nil {
+			return err
+		}
+		y, err := decodeTimeArray(x)
+		if err != nil {
+			return err
+		}
+		a := make([]civil.Timestamp, len(y))
+		for i, t := range y {
+			a[i] = civil.TimestampOf(t)
+		}
+		*p = a
 	case *civil.Date:
 		if p == nil {
 			return errNilDst(p)
@@ -1392,6 +1428,20 @@ func encodeValue(v interface{}) (*proto3.Value, *sppb.Type, error) {
 			}
 		}
 		pt = listType(timeType())
+	case civil.Timestamp:
+		t, err := v.Time()
+		if err != nil {
+			return nil, nil, spannerErrorf(codes.InvalidArgument, "cannot encode %v: %v", v, err)
+		}
+		return encodeValue(t)
+	case []civil.Timestamp:
+		if v != nil {
+			pb, err = encodeArray(len(v), func(i int) interface{} { return v[i] })
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		pt = listType(timeType())
 	case civil.Date:
 		pb.Kind = stringKind(v.String())
 		pt = dateType()
@@ -1557,6 +1607,7 @@ func isSupportedMutationType(v interface{}) bool {
 		bool, []bool, NullBool, []NullBool,
 		float64, []float64, NullFloat64, []NullFloat64,
 		time.Time, []time.Time, NullTime, []NullTime,
+		civil.Timestamp, []civil.Timestamp,
 		civil.Date, []civil.Date, NullDate, []NullDate,
 		GenericColumnValue:
 		return true
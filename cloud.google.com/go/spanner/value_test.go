@@ -36,6 +36,8 @@ var (
 	t4 = time.Now()
 	d1 = mustParseDate("2016-11-15")
 	d2 = mustParseDate("1678-01-01")
+	// The same moment as t1, in another zone.
+	ts1 = civil.Timestamp{DateTime: civil.DateTimeOf(t1.Add(time.Hour)), Zone: "+01:00"}
 )
 
 func mustParseTime(s string) time.Time {
@@ -113,6 +115,9 @@ func TestEncodeValue(t *testing.T) {
 		{[]time.Time(nil), nullProto(), listType(tTime)},
 		{[]time.Time{t1, t2, t3, t4}, listProto(timeProto(t1), timeProto(t2), timeProto(t3), timeProto(t4)), listType(tTime)},
 		{[]NullTime{{t1, true}, {t1, false}}, listProto(timeProto(t1), nullProto()), listType(tTime)},
+		{ts1, timeProto(t1), tTime},
+		{[]civil.Timestamp(nil), nullProto(), listType(tTime)},
+		{[]civil.Timestamp{ts1, civil.TimestampOf(t2)}, listProto(timeProto(t1), timeProto(t2)), listType(tTime)},
 		// DATE / DATE ARRAY
 		{d1, dateProto(d1), tDate},
 		{NullDate{d1, true}, dateProto(d1), tDate},
@@ -777,6 +782,11 @@ func TestDecodeValue(t *testing.T) {
 		{nullProto(), listType(timeType()), []NullTime(nil), false},
 		// TIMESTAMP ARRAY with []time.Time
 		{listProto(timeProto(t1), timeProto(t2), timeProto(t3)), listType(timeType()), []time.Time{t1, t2, t3}, false},
+		// TIMESTAMP with civil.Timestamp
+		{timeProto(t1), timeType(), civil.TimestampOf(t1), false},
+		{nullProto(), timeType(), civil.Timestamp{}, true},
+		{listProto(timeProto(t1), timeProto(t2)), listType(timeType()), []civil.Timestamp{civil.TimestampOf(t1), civil.TimestampOf(t2)}, false},
+		{nullProto(), listType(timeType()), []civil.Timestamp(nil), false},
 		// DATE
 		{dateProto(d1), dateType(), d1, false},
 		{dateProto(d1), dateType(), NullDate{d1, true}, false},
@@ -0,0 +1,37 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest_test
+
+import (
+	"cloud.google.com/go/storage"
+	"cloud.google.com/go/storage/storagetest"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+)
+
+func ExampleNewServer() {
+	ctx := context.Background()
+	// Start a fake server running locally.
+	srv := storagetest.NewServer()
+	defer srv.Close()
+	// Use the server's HTTP client, which sends all requests to the fake,
+	// when creating a storage client.
+	client, err := storage.NewClient(ctx, option.WithHTTPClient(srv.HTTPClient()))
+	if err != nil {
+		// TODO: Handle error.
+	}
+	defer client.Close()
+	_ = client // TODO: Use the client.
+}
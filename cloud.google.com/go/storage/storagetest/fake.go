@@ -0,0 +1,1986 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storagetest provides a fake Cloud Storage service for testing. It
+// implements a simplified form of the JSON and upload APIs used by the
+// cloud.google.com/go/storage package, suitable for unit tests: buckets,
+// object generations and preconditions, media, multipart and resumable
+// uploads, range reads, rewrites, composition, ACLs, IAM policies and
+// notification configurations.
+//
+// The fake does not model project membership, billing, encryption keys,
+// lifecycle rules, retention or holds. Attributes that control those
+// features are stored and returned, but they have no effect.
+//
+// This package is EXPERIMENTAL and is subject to change without notice.
+//
+// See the example for usage.
+package storagetest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	raw "google.golang.org/api/storage/v1"
+)
+
+const (
+	jsonPrefix     = "/storage/v1/"
+	uploadPrefix   = "/upload/storage/v1/"
+	downloadPrefix = "/download/storage/v1/"
+
+	// readHost is the host the storage package reads object contents from,
+	// using paths of the form /bucket/object.
+	readHost = "storage.googleapis.com"
+
+	// linkBase is the base URL of the self and media links of resources.
+	linkBase = "https://www.googleapis.com"
+
+	defaultMaxResults  = 1000
+	maxComposeSources  = 32
+	maxComponentCount  = 1024
+	defaultStorageType = "STANDARD"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Server is a fake Cloud Storage server running in the current process.
+type Server struct {
+	URL string // The base URL of the server.
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	uploads map[string]*upload
+	lastGen int64
+	nextID  int
+}
+
+type bucket struct {
+	attrs         *raw.Bucket
+	project       string
+	objects       map[string][]*object // all generations of an object, oldest first
+	notifications map[string]*raw.Notification
+	policy        *raw.Policy
+	policyVersion int
+}
+
+type object struct {
+	attrs *raw.Object
+	data  []byte
+}
+
+// upload is an in-progress resumable upload.
+type upload struct {
+	bucket        string
+	attrs         *raw.Object
+	conds         conditions
+	predefinedACL string
+	full          bool
+	data          []byte
+}
+
+// NewServer creates a new fake server running in the current process.
+func NewServer() *Server {
+	s := &Server{
+		buckets: map[string]*bucket{},
+		uploads: map[string]*upload{},
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// HTTPClient returns an HTTP client that sends every request to the server,
+// regardless of the host in the request URL. Pass it to storage.NewClient
+// using option.WithHTTPClient.
+func (s *Server) HTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &transport{
+			base: &http.Transport{},
+			host: s.srv.Listener.Addr().String(),
+		},
+	}
+}
+
+// transport redirects requests to the fake server. The Host header of the
+// original request is retained, so the server can tell object reads apart
+// from JSON API calls.
+type transport struct {
+	base http.RoundTripper
+	host string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	u := *req.URL
+	u.Scheme = "http"
+	u.Host = t.host
+	r.URL = &u
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+	return t.base.RoundTrip(r)
+}
+
+// call holds the state of a single request.
+type call struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	body []byte
+	args []string // values of the wildcards in the route pattern
+}
+
+func (c *call) query() url.Values {
+	return c.r.URL.Query()
+}
+
+// full reports whether the full projection, including ACLs, was requested.
+func (c *call) full() bool {
+	return c.query().Get("projection") == "full"
+}
+
+type route struct {
+	method  string
+	pattern string
+	handler func(*Server, *call) error
+}
+
+var jsonRoutes = []route{
+	{"GET", "b", (*Server).listBuckets},
+	{"POST", "b", (*Server).insertBucket},
+	{"GET", "b/*", (*Server).getBucket},
+	{"PATCH", "b/*", (*Server).patchBucket},
+	{"DELETE", "b/*", (*Server).deleteBucket},
+	{"GET", "b/*/acl", (*Server).listBucketACL},
+	{"PUT", "b/*/acl/*", (*Server).updateBucketACL},
+	{"PATCH", "b/*/acl/*", (*Server).updateBucketACL},
+	{"DELETE", "b/*/acl/*", (*Server).deleteBucketACL},
+	{"GET", "b/*/defaultObjectAcl", (*Server).listDefaultObjectACL},
+	{"PUT", "b/*/defaultObjectAcl/*", (*Server).updateDefaultObjectACL},
+	{"PATCH", "b/*/defaultObjectAcl/*", (*Server).updateDefaultObjectACL},
+	{"DELETE", "b/*/defaultObjectAcl/*", (*Server).deleteDefaultObjectACL},
+	{"GET", "b/*/iam", (*Server).getBucketPolicy},
+	{"PUT", "b/*/iam", (*Server).setBucketPolicy},
+	{"GET", "b/*/iam/testPermissions", (*Server).testBucketPermissions},
+	{"POST", "b/*/lockRetentionPolicy", (*Server).lockRetentionPolicy},
+	{"GET", "b/*/notificationConfigs", (*Server).listNotifications},
+	{"POST", "b/*/notificationConfigs", (*Server).insertNotification},
+	{"GET", "b/*/notificationConfigs/*", (*Server).getNotification},
+	{"DELETE", "b/*/notificationConfigs/*", (*Server).deleteNotification},
+	{"GET", "b/*/o", (*Server).listObjects},
+	{"GET", "b/*/o/*", (*Server).getObject},
+	{"PATCH", "b/*/o/*", (*Server).patchObject},
+	{"DELETE", "b/*/o/*", (*Server).deleteObject},
+	{"GET", "b/*/o/*/acl", (*Server).listObjectACL},
+	{"PUT", "b/*/o/*/acl/*", (*Server).updateObjectACL},
+	{"PATCH", "b/*/o/*/acl/*", (*Server).updateObjectACL},
+	{"DELETE", "b/*/o/*/acl/*", (*Server).deleteObjectACL},
+	{"POST", "b/*/o/*/compose", (*Server).composeObject},
+	{"POST", "b/*/o/*/rewriteTo/b/*/o/*", (*Server).rewriteObject},
+}
+
+var uploadRoutes = []route{
+	{"POST", "b/*/o", (*Server).uploadObject},
+	{"PUT", "b/*/o", (*Server).uploadObject},
+	{"DELETE", "b/*/o", (*Server).uploadObject},
+}
+
+var downloadRoutes = []route{
+	{"GET", "b/*/o/*", (*Server).downloadObject},
+	{"HEAD", "b/*/o/*", (*Server).downloadObject},
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if err := s.serve(w, r); err != nil {
+		writeError(w, err)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) error {
+	// Read the body before taking the lock, as a client may stream it while
+	// making other calls.
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errorf(http.StatusBadRequest, "reading request body: %v", err)
+	}
+	c := &call{w: w, r: r, body: body}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.URL.EscapedPath()
+	var routes []route
+	switch {
+	case r.Host == readHost:
+		return s.readObject(c)
+	case strings.HasPrefix(path, jsonPrefix):
+		routes, path = jsonRoutes, path[len(jsonPrefix):]
+	case strings.HasPrefix(path, uploadPrefix):
+		routes, path = uploadRoutes, path[len(uploadPrefix):]
+	case strings.HasPrefix(path, downloadPrefix):
+		routes, path = downloadRoutes, path[len(downloadPrefix):]
+	default:
+		return s.readObject(c)
+	}
+	var segs []string
+	for _, seg := range strings.Split(path, "/") {
+		// url.PathUnescape requires Go 1.8; unlike in a query, a "+" in a
+		// path segment is not a space.
+		seg, err := url.QueryUnescape(strings.Replace(seg, "+", "%2B", -1))
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid path %q", r.URL.EscapedPath())
+		}
+		segs = append(segs, seg)
+	}
+	found := false
+	for _, rt := range routes {
+		args, ok := matchPattern(rt.pattern, segs)
+		if !ok {
+			continue
+		}
+		found = true
+		if rt.method == r.Method {
+			c.args = args
+			return rt.handler(s, c)
+		}
+	}
+	if found {
+		return errorf(http.StatusMethodNotAllowed, "method %s is not allowed for %q", r.Method, r.URL.Path)
+	}
+	return errorf(http.StatusNotFound, "no API at %q", r.URL.Path)
+}
+
+// matchPattern matches the path segments against pattern, in which a "*"
+// matches any single segment. It returns the values of the wildcards.
+func matchPattern(pattern string, segs []string) ([]string, bool) {
+	ps := strings.Split(pattern, "/")
+	if len(ps) != len(segs) {
+		return nil, false
+	}
+	var args []string
+	for i, p := range ps {
+		switch {
+		case p == "*":
+			args = append(args, segs[i])
+		case p != segs[i]:
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// httpError is an error that is reported to the client with the given
+// HTTP status code.
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func errorf(code int, format string, args ...interface{}) error {
+	return &httpError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func errPreconditionFailed() error {
+	return errorf(http.StatusPreconditionFailed, "At least one of the pre-conditions you specified did not hold.")
+}
+
+var errorReasons = map[int]string{
+	http.StatusBadRequest:                   "invalid",
+	http.StatusNotFound:                     "notFound",
+	http.StatusMethodNotAllowed:             "methodNotAllowed",
+	http.StatusConflict:                     "conflict",
+	http.StatusPreconditionFailed:           "conditionNotMet",
+	http.StatusRequestedRangeNotSatisfiable: "requestedRangeNotSatisfiable",
+}
+
+// writeError writes err in the format of the JSON API, which the client
+// library decodes into a *googleapi.Error.
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*httpError)
+	if !ok {
+		e = &httpError{code: http.StatusInternalServerError, msg: err.Error()}
+	}
+	if e.code == http.StatusNotModified {
+		w.WriteHeader(e.code)
+		return
+	}
+	reason, ok := errorReasons[e.code]
+	if !ok {
+		reason = "backendError"
+	}
+	var reply struct {
+		Error struct {
+			Code    int                   `json:"code"`
+			Message string                `json:"message"`
+			Errors  []googleapi.ErrorItem `json:"errors"`
+		} `json:"error"`
+	}
+	reply.Error.Code = e.code
+	reply.Error.Message = e.msg
+	reply.Error.Errors = []googleapi.ErrorItem{{Reason: reason, Message: e.msg}}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(e.code)
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return json.NewEncoder(w).Encode(v)
+}
+
+// pathEscape escapes s for use as a single URL path segment.
+// (url.PathEscape requires Go 1.8.)
+func pathEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func decodeJSON(body []byte, v interface{}) error {
+	if len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// conditions holds the generation and the preconditions of a request.
+// Nil fields were not set.
+type conditions struct {
+	generation               *int64
+	ifGenerationMatch        *int64
+	ifGenerationNotMatch     *int64
+	ifMetagenerationMatch    *int64
+	ifMetagenerationNotMatch *int64
+}
+
+// parseConditions parses the generation and precondition parameters of a
+// request. The qualifier selects between the parameters of the object
+// itself ("") and those of the source object of a rewrite ("Source").
+func parseConditions(q url.Values, qualifier string) (conditions, error) {
+	var c conditions
+	genKey := "generation"
+	if qualifier != "" {
+		genKey = strings.ToLower(qualifier) + "Generation"
+	}
+	params := []struct {
+		key string
+		dst **int64
+	}{
+		{genKey, &c.generation},
+		{"if" + qualifier + "GenerationMatch", &c.ifGenerationMatch},
+		{"if" + qualifier + "GenerationNotMatch", &c.ifGenerationNotMatch},
+		{"if" + qualifier + "MetagenerationMatch", &c.ifMetagenerationMatch},
+		{"if" + qualifier + "MetagenerationNotMatch", &c.ifMetagenerationNotMatch},
+	}
+	for _, p := range params {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return c, errorf(http.StatusBadRequest, "invalid value %q for %s", v, p.key)
+		}
+		*p.dst = &n
+	}
+	return c, nil
+}
+
+// check evaluates the preconditions against a resource with the given
+// generation and metageneration. For reads, a failed "not match" condition
+// results in 304 Not Modified instead of 412 Precondition Failed.
+func (c conditions) check(exists bool, gen, metagen int64, read bool) error {
+	notModified := errPreconditionFailed()
+	if read {
+		notModified = errorf(http.StatusNotModified, "not modified")
+	}
+	if c.ifGenerationMatch != nil {
+		// A generation of 0 requires that the object doesn't exist.
+		if exists != (*c.ifGenerationMatch != 0) || exists && gen != *c.ifGenerationMatch {
+			return errPreconditionFailed()
+		}
+	}
+	if c.ifGenerationNotMatch != nil && exists && gen == *c.ifGenerationNotMatch {
+		return notModified
+	}
+	if c.ifMetagenerationMatch != nil && (!exists || metagen != *c.ifMetagenerationMatch) {
+		return errPreconditionFailed()
+	}
+	if c.ifMetagenerationNotMatch != nil && exists && metagen == *c.ifMetagenerationNotMatch {
+		return notModified
+	}
+	return nil
+}
+
+func (c conditions) checkObject(o *object, read bool) error {
+	if o == nil {
+		return c.check(false, 0, 0, read)
+	}
+	return c.check(true, o.attrs.Generation, o.attrs.Metageneration, read)
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func encodeUint32(u uint32) string {
+	b := []byte{byte(u >> 24), byte(u >> 16), byte(u >> 8), byte(u)}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func etag(parts ...int64) string {
+	var b []byte
+	for _, p := range parts {
+		b = strconv.AppendInt(b, p, 10)
+		b = append(b, '/')
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// newGeneration returns a new generation number. Like the service, it uses
+// the time in microseconds, but it ensures the numbers are increasing.
+func (s *Server) newGeneration() int64 {
+	g := time.Now().UnixNano() / 1000
+	if g <= s.lastGen {
+		g = s.lastGen + 1
+	}
+	s.lastGen = g
+	return g
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// mergePatch applies the JSON merge patch (RFC 7386) in patch to the
+// resource v, which must be a pointer to a struct. Only the top-level fields
+// in allowed are applied; the others are ignored.
+func mergePatch(v interface{}, patch []byte, allowed map[string]bool) error {
+	var p map[string]interface{}
+	if err := decodeJSON(patch, &p); err != nil {
+		return err
+	}
+	orig, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(orig, &doc); err != nil {
+		return err
+	}
+	for k, pv := range p {
+		if !allowed[k] {
+			continue
+		}
+		if pv == nil {
+			delete(doc, k)
+		} else {
+			doc[k] = mergeValues(doc[k], pv)
+		}
+	}
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// Decode into the zero value, so that deleted fields are cleared.
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	return json.Unmarshal(merged, v)
+}
+
+func mergeValues(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergeValues(t[k], v)
+		}
+	}
+	return t
+}
+
+// The fields of buckets and objects that can be changed by a patch.
+var (
+	bucketPatchFields = map[string]bool{
+		"acl":                   true,
+		"billing":               true,
+		"cors":                  true,
+		"defaultEventBasedHold": true,
+		"defaultObjectAcl":      true,
+		"encryption":            true,
+		"labels":                true,
+		"lifecycle":             true,
+		"logging":               true,
+		"retentionPolicy":       true,
+		"storageClass":          true,
+		"versioning":            true,
+		"website":               true,
+	}
+	objectPatchFields = map[string]bool{
+		"acl":                true,
+		"cacheControl":       true,
+		"contentDisposition": true,
+		"contentEncoding":    true,
+		"contentLanguage":    true,
+		"contentType":        true,
+		"eventBasedHold":     true,
+		"metadata":           true,
+		"storageClass":       true,
+		"temporaryHold":      true,
+	}
+)
+
+// predefinedACLs maps the names of the predefined ACLs to the roles they
+// grant. Grants to project teams and bucket owners are left out, as the fake
+// does not model project membership.
+var predefinedACLs = map[string]map[string]string{
+	"private":                {},
+	"projectPrivate":         {},
+	"bucketOwnerRead":        {},
+	"bucketOwnerFullControl": {},
+	"authenticatedRead":      {"allAuthenticatedUsers": "READER"},
+	"publicRead":             {"allUsers": "READER"},
+	"publicReadWrite":        {"allUsers": "WRITER"},
+}
+
+func predefinedEntities(name string) ([]string, map[string]string, error) {
+	roles, ok := predefinedACLs[name]
+	if !ok {
+		return nil, nil, errorf(http.StatusBadRequest, "invalid predefined ACL %q", name)
+	}
+	var entities []string
+	for e := range roles {
+		entities = append(entities, e)
+	}
+	sort.Strings(entities)
+	return entities, roles, nil
+}
+
+func newBucketACL(bucket, entity, role string) *raw.BucketAccessControl {
+	return &raw.BucketAccessControl{
+		Kind:   "storage#bucketAccessControl",
+		Id:     bucket + "/" + entity,
+		Bucket: bucket,
+		Entity: entity,
+		Role:   role,
+	}
+}
+
+func newObjectACL(bucket, object string, gen int64, entity, role string) *raw.ObjectAccessControl {
+	id := bucket + "/" + entity
+	if object != "" {
+		id = fmt.Sprintf("%s/%s/%d/%s", bucket, object, gen, entity)
+	}
+	return &raw.ObjectAccessControl{
+		Kind:       "storage#objectAccessControl",
+		Id:         id,
+		Bucket:     bucket,
+		Object:     object,
+		Generation: gen,
+		Entity:     entity,
+		Role:       role,
+	}
+}
+
+func predefinedBucketACL(name, bucket string) ([]*raw.BucketAccessControl, error) {
+	entities, roles, err := predefinedEntities(name)
+	if err != nil {
+		return nil, err
+	}
+	acl := []*raw.BucketAccessControl{}
+	for _, e := range entities {
+		acl = append(acl, newBucketACL(bucket, e, roles[e]))
+	}
+	return acl, nil
+}
+
+func predefinedObjectACL(name, bucket, object string, gen int64) ([]*raw.ObjectAccessControl, error) {
+	entities, roles, err := predefinedEntities(name)
+	if err != nil {
+		return nil, err
+	}
+	acl := []*raw.ObjectAccessControl{}
+	for _, e := range entities {
+		acl = append(acl, newObjectACL(bucket, object, gen, e, roles[e]))
+	}
+	return acl, nil
+}
+
+func setBucketACL(acl []*raw.BucketAccessControl, rule *raw.BucketAccessControl) []*raw.BucketAccessControl {
+	for i, r := range acl {
+		if r.Entity == rule.Entity {
+			acl[i] = rule
+			return acl
+		}
+	}
+	return append(acl, rule)
+}
+
+func deleteBucketACL(acl []*raw.BucketAccessControl, entity string) ([]*raw.BucketAccessControl, bool) {
+	for i, r := range acl {
+		if r.Entity == entity {
+			return append(acl[:i:i], acl[i+1:]...), true
+		}
+	}
+	return acl, false
+}
+
+func setObjectACL(acl []*raw.ObjectAccessControl, rule *raw.ObjectAccessControl) []*raw.ObjectAccessControl {
+	for i, r := range acl {
+		if r.Entity == rule.Entity {
+			acl[i] = rule
+			return acl
+		}
+	}
+	return append(acl, rule)
+}
+
+func deleteObjectACL(acl []*raw.ObjectAccessControl, entity string) ([]*raw.ObjectAccessControl, bool) {
+	for i, r := range acl {
+		if r.Entity == entity {
+			return append(acl[:i:i], acl[i+1:]...), true
+		}
+	}
+	return acl, false
+}
+
+// pageToken encodes the position of the next result of a list call.
+func pageToken(key string, gen int64) string {
+	return base64.URLEncoding.EncodeToString([]byte(key + "\x00" + strconv.FormatInt(gen, 10)))
+}
+
+func parsePageToken(tok string) (string, int64, error) {
+	b, err := base64.URLEncoding.DecodeString(tok)
+	if err == nil {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			gen, err := strconv.ParseInt(string(b[i+1:]), 10, 64)
+			if err == nil {
+				return string(b[:i]), gen, nil
+			}
+		}
+	}
+	return "", 0, errorf(http.StatusBadRequest, "invalid page token %q", tok)
+}
+
+func maxResults(q url.Values) (int, error) {
+	v := q.Get("maxResults")
+	if v == "" {
+		return defaultMaxResults, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errorf(http.StatusBadRequest, "invalid value %q for maxResults", v)
+	}
+	if n == 0 || n > defaultMaxResults {
+		n = defaultMaxResults
+	}
+	return n, nil
+}
+
+// Buckets.
+
+func (b *bucket) versioning() bool {
+	return b.attrs.Versioning != nil && b.attrs.Versioning.Enabled
+}
+
+func (b *bucket) resource(full bool) *raw.Bucket {
+	r := *b.attrs
+	if !full {
+		r.Acl = nil
+		r.DefaultObjectAcl = nil
+		r.Owner = nil
+	}
+	return &r
+}
+
+// touch increments the metageneration of the bucket.
+func (b *bucket) touch() {
+	b.attrs.Metageneration++
+	b.attrs.Updated = timestamp(time.Now())
+	b.attrs.Etag = etag(b.attrs.Metageneration)
+}
+
+func (s *Server) bucket(name string) (*bucket, error) {
+	b, ok := s.buckets[name]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "bucket %q not found", name)
+	}
+	return b, nil
+}
+
+// lookupBucket returns the named bucket, after checking the preconditions of
+// the request.
+func (s *Server) lookupBucket(c *call, read bool) (*bucket, error) {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return nil, err
+	}
+	conds, err := parseConditions(c.query(), "")
+	if err != nil {
+		return nil, err
+	}
+	if err := conds.check(true, 0, b.attrs.Metageneration, read); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *Server) listBuckets(c *call) error {
+	q := c.query()
+	project := q.Get("project")
+	if project == "" {
+		return errorf(http.StatusBadRequest, "project is required")
+	}
+	max, err := maxResults(q)
+	if err != nil {
+		return err
+	}
+	var start string
+	if tok := q.Get("pageToken"); tok != "" {
+		if start, _, err = parsePageToken(tok); err != nil {
+			return err
+		}
+	}
+	var names []string
+	for name, b := range s.buckets {
+		if b.project == project && strings.HasPrefix(name, q.Get("prefix")) && name >= start {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	res := &raw.Buckets{Kind: "storage#buckets"}
+	for i, name := range names {
+		if i == max {
+			res.NextPageToken = pageToken(name, 0)
+			break
+		}
+		res.Items = append(res.Items, s.buckets[name].resource(c.full()))
+	}
+	return writeJSON(c.w, res)
+}
+
+func (s *Server) insertBucket(c *call) error {
+	q := c.query()
+	project := q.Get("project")
+	if project == "" {
+		return errorf(http.StatusBadRequest, "project is required")
+	}
+	attrs := &raw.Bucket{}
+	if err := decodeJSON(c.body, attrs); err != nil {
+		return err
+	}
+	name := attrs.Name
+	if name == "" {
+		return errorf(http.StatusBadRequest, "bucket name is required")
+	}
+	if _, ok := s.buckets[name]; ok {
+		return errorf(http.StatusConflict, "bucket %q already exists", name)
+	}
+	now := timestamp(time.Now())
+	attrs.Kind = "storage#bucket"
+	attrs.Id = name
+	attrs.SelfLink = linkBase + jsonPrefix + "b/" + pathEscape(name)
+	attrs.Metageneration = 1
+	attrs.Etag = etag(attrs.Metageneration)
+	attrs.TimeCreated = now
+	attrs.Updated = now
+	if attrs.Location == "" {
+		attrs.Location = "US"
+	}
+	if attrs.StorageClass == "" {
+		attrs.StorageClass = defaultStorageType
+	}
+	if rp := attrs.RetentionPolicy; rp != nil {
+		rp.EffectiveTime = now
+		rp.IsLocked = false
+	}
+	if p := q.Get("predefinedAcl"); p != "" {
+		acl, err := predefinedBucketACL(p, name)
+		if err != nil {
+			return err
+		}
+		attrs.Acl = acl
+	}
+	for i, r := range attrs.Acl {
+		attrs.Acl[i] = newBucketACL(name, r.Entity, r.Role)
+	}
+	if p := q.Get("predefinedDefaultObjectAcl"); p != "" {
+		acl, err := predefinedObjectACL(p, name, "", 0)
+		if err != nil {
+			return err
+		}
+		attrs.DefaultObjectAcl = acl
+	}
+	for i, r := range attrs.DefaultObjectAcl {
+		attrs.DefaultObjectAcl[i] = newObjectACL(name, "", 0, r.Entity, r.Role)
+	}
+	b := &bucket{
+		attrs:         attrs,
+		project:       project,
+		objects:       map[string][]*object{},
+		notifications: map[string]*raw.Notification{},
+	}
+	s.buckets[name] = b
+	return writeJSON(c.w, b.resource(c.full()))
+}
+
+func (s *Server) getBucket(c *call) error {
+	b, err := s.lookupBucket(c, true)
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, b.resource(c.full()))
+}
+
+func (s *Server) patchBucket(c *call) error {
+	b, err := s.lookupBucket(c, false)
+	if err != nil {
+		return err
+	}
+	hadPolicy := b.attrs.RetentionPolicy
+	if hadPolicy != nil && hadPolicy.IsLocked && bytes.Contains(c.body, []byte(`"retentionPolicy"`)) {
+		return errorf(http.StatusForbidden, "the retention policy of bucket %q is locked", b.attrs.Name)
+	}
+	if err := mergePatch(b.attrs, c.body, bucketPatchFields); err != nil {
+		return err
+	}
+	if rp := b.attrs.RetentionPolicy; rp != nil && hadPolicy == nil {
+		rp.EffectiveTime = timestamp(time.Now())
+	}
+	q := c.query()
+	if p := q.Get("predefinedAcl"); p != "" {
+		if b.attrs.Acl, err = predefinedBucketACL(p, b.attrs.Name); err != nil {
+			return err
+		}
+	}
+	if p := q.Get("predefinedDefaultObjectAcl"); p != "" {
+		if b.attrs.DefaultObjectAcl, err = predefinedObjectACL(p, b.attrs.Name, "", 0); err != nil {
+			return err
+		}
+	}
+	b.touch()
+	return writeJSON(c.w, b.resource(c.full()))
+}
+
+func (s *Server) deleteBucket(c *call) error {
+	b, err := s.lookupBucket(c, false)
+	if err != nil {
+		return err
+	}
+	if len(b.objects) > 0 {
+		return errorf(http.StatusConflict, "bucket %q is not empty", b.attrs.Name)
+	}
+	delete(s.buckets, b.attrs.Name)
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) lockRetentionPolicy(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	conds, err := parseConditions(c.query(), "")
+	if err != nil {
+		return err
+	}
+	if conds.ifMetagenerationMatch == nil {
+		return errorf(http.StatusBadRequest, "ifMetagenerationMatch is required")
+	}
+	if err := conds.check(true, 0, b.attrs.Metageneration, false); err != nil {
+		return err
+	}
+	if b.attrs.RetentionPolicy == nil {
+		return errorf(http.StatusBadRequest, "bucket %q has no retention policy", b.attrs.Name)
+	}
+	b.attrs.RetentionPolicy.IsLocked = true
+	b.touch()
+	return writeJSON(c.w, b.resource(true))
+}
+
+func (s *Server) listBucketACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, &raw.BucketAccessControls{
+		Kind:  "storage#bucketAccessControls",
+		Items: b.attrs.Acl,
+	})
+}
+
+func (s *Server) updateBucketACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	var rule raw.BucketAccessControl
+	if err := decodeJSON(c.body, &rule); err != nil {
+		return err
+	}
+	if rule.Role == "" {
+		return errorf(http.StatusBadRequest, "role is required")
+	}
+	r := newBucketACL(b.attrs.Name, c.args[1], rule.Role)
+	b.attrs.Acl = setBucketACL(b.attrs.Acl, r)
+	b.touch()
+	return writeJSON(c.w, r)
+}
+
+func (s *Server) deleteBucketACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	acl, ok := deleteBucketACL(b.attrs.Acl, c.args[1])
+	if !ok {
+		return errorf(http.StatusNotFound, "no ACL entry for %q", c.args[1])
+	}
+	b.attrs.Acl = acl
+	b.touch()
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) listDefaultObjectACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, &raw.ObjectAccessControls{
+		Kind:  "storage#objectAccessControls",
+		Items: b.attrs.DefaultObjectAcl,
+	})
+}
+
+func (s *Server) updateDefaultObjectACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	var rule raw.ObjectAccessControl
+	if err := decodeJSON(c.body, &rule); err != nil {
+		return err
+	}
+	if rule.Role == "" {
+		return errorf(http.StatusBadRequest, "role is required")
+	}
+	r := newObjectACL(b.attrs.Name, "", 0, c.args[1], rule.Role)
+	b.attrs.DefaultObjectAcl = setObjectACL(b.attrs.DefaultObjectAcl, r)
+	b.touch()
+	return writeJSON(c.w, r)
+}
+
+func (s *Server) deleteDefaultObjectACL(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	acl, ok := deleteObjectACL(b.attrs.DefaultObjectAcl, c.args[1])
+	if !ok {
+		return errorf(http.StatusNotFound, "no ACL entry for %q", c.args[1])
+	}
+	b.attrs.DefaultObjectAcl = acl
+	b.touch()
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) getBucketPolicy(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	p := b.policy
+	if p == nil {
+		p = &raw.Policy{Etag: etag(0)}
+	}
+	p.Kind = "storage#policy"
+	p.ResourceId = "projects/_/buckets/" + b.attrs.Name
+	return writeJSON(c.w, p)
+}
+
+func (s *Server) setBucketPolicy(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	p := &raw.Policy{}
+	if err := decodeJSON(c.body, p); err != nil {
+		return err
+	}
+	if cur := b.policy; p.Etag != "" && cur != nil && p.Etag != cur.Etag {
+		return errPreconditionFailed()
+	}
+	b.policyVersion++
+	p.Etag = etag(int64(b.policyVersion))
+	p.Kind = "storage#policy"
+	p.ResourceId = "projects/_/buckets/" + b.attrs.Name
+	b.policy = p
+	return writeJSON(c.w, p)
+}
+
+// testBucketPermissions grants every requested permission.
+func (s *Server) testBucketPermissions(c *call) error {
+	if _, err := s.bucket(c.args[0]); err != nil {
+		return err
+	}
+	return writeJSON(c.w, &raw.TestIamPermissionsResponse{
+		Kind:        "storage#testIamPermissionsResponse",
+		Permissions: c.query()["permissions"],
+	})
+}
+
+func (s *Server) listNotifications(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	res := &raw.Notifications{Kind: "storage#notifications"}
+	for _, n := range b.notifications {
+		res.Items = append(res.Items, n)
+	}
+	sort.Sort(byNotificationID(res.Items))
+	return writeJSON(c.w, res)
+}
+
+type byNotificationID []*raw.Notification
+
+func (b byNotificationID) Len() int      { return len(b) }
+func (b byNotificationID) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byNotificationID) Less(i, j int) bool {
+	x, _ := strconv.Atoi(b[i].Id)
+	y, _ := strconv.Atoi(b[j].Id)
+	return x < y
+}
+
+func (s *Server) insertNotification(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	n := &raw.Notification{}
+	if err := decodeJSON(c.body, n); err != nil {
+		return err
+	}
+	if n.Topic == "" {
+		return errorf(http.StatusBadRequest, "topic is required")
+	}
+	if n.PayloadFormat == "" {
+		return errorf(http.StatusBadRequest, "payload format is required")
+	}
+	n.Id = s.newID()
+	n.Kind = "storage#notification"
+	n.Etag = n.Id
+	n.SelfLink = linkBase + jsonPrefix + "b/" + pathEscape(b.attrs.Name) + "/notificationConfigs/" + n.Id
+	b.notifications[n.Id] = n
+	return writeJSON(c.w, n)
+}
+
+func (s *Server) notification(c *call) (*bucket, *raw.Notification, error) {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	n, ok := b.notifications[c.args[1]]
+	if !ok {
+		return nil, nil, errorf(http.StatusNotFound, "notification %q not found", c.args[1])
+	}
+	return b, n, nil
+}
+
+func (s *Server) getNotification(c *call) error {
+	_, n, err := s.notification(c)
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, n)
+}
+
+func (s *Server) deleteNotification(c *call) error {
+	b, n, err := s.notification(c)
+	if err != nil {
+		return err
+	}
+	delete(b.notifications, n.Id)
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Objects.
+
+func (o *object) live() bool {
+	return o.attrs.TimeDeleted == ""
+}
+
+func (o *object) resource(full bool) *raw.Object {
+	r := *o.attrs
+	if !full {
+		r.Acl = nil
+		r.Owner = nil
+	}
+	return &r
+}
+
+// touch increments the metageneration of the object.
+func (o *object) touch() {
+	o.attrs.Metageneration++
+	o.attrs.Updated = timestamp(time.Now())
+	o.attrs.Etag = etag(o.attrs.Generation, o.attrs.Metageneration)
+}
+
+// object returns the given generation of the named object, or the live
+// generation if gen is nil. It returns nil if there is no such object.
+func (b *bucket) object(name string, gen *int64) *object {
+	versions := b.objects[name]
+	if gen == nil {
+		if n := len(versions); n > 0 && versions[n-1].live() {
+			return versions[n-1]
+		}
+		return nil
+	}
+	for _, o := range versions {
+		if o.attrs.Generation == *gen {
+			return o
+		}
+	}
+	return nil
+}
+
+// lookup returns an object, after checking the preconditions against it.
+func (b *bucket) lookup(name string, conds conditions, read bool) (*object, error) {
+	o := b.object(name, conds.generation)
+	if o == nil {
+		return nil, errorf(http.StatusNotFound, "object %q not found in bucket %q", name, b.attrs.Name)
+	}
+	if err := conds.checkObject(o, read); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// put stores o as the live generation of its object. The previous live
+// generation is kept as a noncurrent version if versioning is enabled.
+func (b *bucket) put(o *object) {
+	name := o.attrs.Name
+	if live := b.object(name, nil); live != nil {
+		b.remove(live, b.versioning())
+	}
+	b.objects[name] = append(b.objects[name], o)
+}
+
+// remove deletes the given generation of an object. When archive is set, a
+// live generation is kept as a noncurrent version instead.
+func (b *bucket) remove(o *object, archive bool) {
+	if archive && o.live() {
+		o.attrs.TimeDeleted = timestamp(time.Now())
+		return
+	}
+	name := o.attrs.Name
+	versions := b.objects[name]
+	for i, v := range versions {
+		if v == o {
+			versions = append(versions[:i:i], versions[i+1:]...)
+			break
+		}
+	}
+	if len(versions) == 0 {
+		delete(b.objects, name)
+	} else {
+		b.objects[name] = versions
+	}
+}
+
+// insertObject stores data as a new generation of the object described by
+// attrs, after checking the preconditions against the live generation. Only
+// the attributes that can be set by a client are taken from attrs.
+func (s *Server) insertObject(b *bucket, attrs *raw.Object, data []byte, conds conditions, predefinedACL string) (*object, error) {
+	name := attrs.Name
+	if name == "" {
+		return nil, errorf(http.StatusBadRequest, "object name is required")
+	}
+	if err := conds.checkObject(b.object(name, nil), false); err != nil {
+		return nil, err
+	}
+	crc := encodeUint32(crc32.Checksum(data, crc32cTable))
+	if attrs.Crc32c != "" && attrs.Crc32c != crc {
+		return nil, errorf(http.StatusBadRequest, "provided CRC32C %q doesn't match calculated CRC32C %q", attrs.Crc32c, crc)
+	}
+	sum := md5.Sum(data)
+	md5Hash := base64.StdEncoding.EncodeToString(sum[:])
+	if attrs.Md5Hash != "" && attrs.Md5Hash != md5Hash {
+		return nil, errorf(http.StatusBadRequest, "provided MD5 hash %q doesn't match calculated MD5 hash %q", attrs.Md5Hash, md5Hash)
+	}
+
+	gen := s.newGeneration()
+	now := timestamp(time.Now())
+	escaped := pathEscape(name)
+	o := &object{
+		attrs: &raw.Object{
+			Kind:               "storage#object",
+			Id:                 fmt.Sprintf("%s/%s/%d", b.attrs.Name, name, gen),
+			SelfLink:           fmt.Sprintf("%s%sb/%s/o/%s", linkBase, jsonPrefix, pathEscape(b.attrs.Name), escaped),
+			MediaLink:          fmt.Sprintf("%s%sb/%s/o/%s?generation=%d&alt=media", linkBase, downloadPrefix, pathEscape(b.attrs.Name), escaped, gen),
+			Bucket:             b.attrs.Name,
+			Name:               name,
+			Generation:         gen,
+			Metageneration:     1,
+			Etag:               etag(gen, 1),
+			Size:               uint64(len(data)),
+			Crc32c:             crc,
+			Md5Hash:            md5Hash,
+			TimeCreated:        now,
+			Updated:            now,
+			CacheControl:       attrs.CacheControl,
+			ContentDisposition: attrs.ContentDisposition,
+			ContentEncoding:    attrs.ContentEncoding,
+			ContentLanguage:    attrs.ContentLanguage,
+			ContentType:        attrs.ContentType,
+			EventBasedHold:     attrs.EventBasedHold,
+			TemporaryHold:      attrs.TemporaryHold,
+			KmsKeyName:         attrs.KmsKeyName,
+			StorageClass:       attrs.StorageClass,
+			Metadata:           attrs.Metadata,
+		},
+		data: data,
+	}
+	if o.attrs.ContentType == "" {
+		o.attrs.ContentType = "application/octet-stream"
+	}
+	if o.attrs.StorageClass == "" {
+		o.attrs.StorageClass = b.attrs.StorageClass
+	}
+	switch {
+	case predefinedACL != "":
+		acl, err := predefinedObjectACL(predefinedACL, b.attrs.Name, name, gen)
+		if err != nil {
+			return nil, err
+		}
+		o.attrs.Acl = acl
+	case attrs.Acl != nil:
+		for _, r := range attrs.Acl {
+			o.attrs.Acl = append(o.attrs.Acl, newObjectACL(b.attrs.Name, name, gen, r.Entity, r.Role))
+		}
+	default:
+		for _, r := range b.attrs.DefaultObjectAcl {
+			o.attrs.Acl = append(o.attrs.Acl, newObjectACL(b.attrs.Name, name, gen, r.Entity, r.Role))
+		}
+	}
+	b.put(o)
+	return o, nil
+}
+
+// lookupObject returns the object named in the request, after checking the
+// preconditions of the request against it.
+func (s *Server) lookupObject(c *call, read bool) (*bucket, *object, error) {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	conds, err := parseConditions(c.query(), "")
+	if err != nil {
+		return nil, nil, err
+	}
+	o, err := b.lookup(c.args[1], conds, read)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, o, nil
+}
+
+type listEntry struct {
+	key string
+	obj *object // nil for prefixes
+}
+
+func (e listEntry) gen() int64 {
+	if e.obj == nil {
+		return 0
+	}
+	return e.obj.attrs.Generation
+}
+
+type byKeyAndGeneration []listEntry
+
+func (b byKeyAndGeneration) Len() int      { return len(b) }
+func (b byKeyAndGeneration) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byKeyAndGeneration) Less(i, j int) bool {
+	if b[i].key != b[j].key {
+		return b[i].key < b[j].key
+	}
+	return b[i].gen() < b[j].gen()
+}
+
+func (s *Server) listObjects(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	q := c.query()
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+	versions := q.Get("versions") == "true"
+	max, err := maxResults(q)
+	if err != nil {
+		return err
+	}
+	var (
+		startKey string
+		startGen int64
+	)
+	if tok := q.Get("pageToken"); tok != "" {
+		if startKey, startGen, err = parsePageToken(tok); err != nil {
+			return err
+		}
+	}
+
+	var entries []listEntry
+	seen := map[string]bool{}
+	for name, objs := range b.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if delim != "" {
+			if i := strings.Index(name[len(prefix):], delim); i >= 0 {
+				p := name[:len(prefix)+i+len(delim)]
+				if !seen[p] {
+					seen[p] = true
+					entries = append(entries, listEntry{key: p})
+				}
+				continue
+			}
+		}
+		for _, o := range objs {
+			if versions || o.live() {
+				entries = append(entries, listEntry{key: name, obj: o})
+			}
+		}
+	}
+	sort.Sort(byKeyAndGeneration(entries))
+
+	res := &raw.Objects{Kind: "storage#objects"}
+	n := 0
+	for _, e := range entries {
+		if e.key < startKey || e.key == startKey && e.gen() < startGen {
+			continue
+		}
+		if n == max {
+			res.NextPageToken = pageToken(e.key, e.gen())
+			break
+		}
+		n++
+		if e.obj == nil {
+			res.Prefixes = append(res.Prefixes, e.key)
+		} else {
+			res.Items = append(res.Items, e.obj.resource(c.full()))
+		}
+	}
+	return writeJSON(c.w, res)
+}
+
+func (s *Server) getObject(c *call) error {
+	if c.query().Get("alt") == "media" {
+		return s.downloadObject(c)
+	}
+	_, o, err := s.lookupObject(c, true)
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, o.resource(c.full()))
+}
+
+func (s *Server) patchObject(c *call) error {
+	b, o, err := s.lookupObject(c, false)
+	if err != nil {
+		return err
+	}
+	if err := mergePatch(o.attrs, c.body, objectPatchFields); err != nil {
+		return err
+	}
+	if p := c.query().Get("predefinedAcl"); p != "" {
+		if o.attrs.Acl, err = predefinedObjectACL(p, b.attrs.Name, o.attrs.Name, o.attrs.Generation); err != nil {
+			return err
+		}
+	}
+	for i, r := range o.attrs.Acl {
+		o.attrs.Acl[i] = newObjectACL(b.attrs.Name, o.attrs.Name, o.attrs.Generation, r.Entity, r.Role)
+	}
+	o.touch()
+	return writeJSON(c.w, o.resource(c.full()))
+}
+
+func (s *Server) deleteObject(c *call) error {
+	b, o, err := s.lookupObject(c, false)
+	if err != nil {
+		return err
+	}
+	// Deleting a specific generation removes it permanently.
+	b.remove(o, c.query().Get("generation") == "" && b.versioning())
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) listObjectACL(c *call) error {
+	_, o, err := s.lookupObject(c, true)
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, &raw.ObjectAccessControls{
+		Kind:  "storage#objectAccessControls",
+		Items: o.attrs.Acl,
+	})
+}
+
+func (s *Server) updateObjectACL(c *call) error {
+	b, o, err := s.lookupObject(c, false)
+	if err != nil {
+		return err
+	}
+	var rule raw.ObjectAccessControl
+	if err := decodeJSON(c.body, &rule); err != nil {
+		return err
+	}
+	if rule.Role == "" {
+		return errorf(http.StatusBadRequest, "role is required")
+	}
+	r := newObjectACL(b.attrs.Name, o.attrs.Name, o.attrs.Generation, c.args[2], rule.Role)
+	o.attrs.Acl = setObjectACL(o.attrs.Acl, r)
+	o.touch()
+	return writeJSON(c.w, r)
+}
+
+func (s *Server) deleteObjectACL(c *call) error {
+	_, o, err := s.lookupObject(c, false)
+	if err != nil {
+		return err
+	}
+	acl, ok := deleteObjectACL(o.attrs.Acl, c.args[2])
+	if !ok {
+		return errorf(http.StatusNotFound, "no ACL entry for %q", c.args[2])
+	}
+	o.attrs.Acl = acl
+	o.touch()
+	c.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) composeObject(c *call) error {
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	var req raw.ComposeRequest
+	if err := decodeJSON(c.body, &req); err != nil {
+		return err
+	}
+	if len(req.SourceObjects) == 0 {
+		return errorf(http.StatusBadRequest, "source objects are required")
+	}
+	if len(req.SourceObjects) > maxComposeSources {
+		return errorf(http.StatusBadRequest, "at most %d source objects can be composed", maxComposeSources)
+	}
+	var (
+		data  []byte
+		count int64
+	)
+	for _, src := range req.SourceObjects {
+		var gen *int64
+		if src.Generation != 0 {
+			gen = &src.Generation
+		}
+		o := b.object(src.Name, gen)
+		if o == nil {
+			return errorf(http.StatusNotFound, "object %q not found in bucket %q", src.Name, b.attrs.Name)
+		}
+		if p := src.ObjectPreconditions; p != nil && p.IfGenerationMatch != 0 && p.IfGenerationMatch != o.attrs.Generation {
+			return errPreconditionFailed()
+		}
+		data = append(data, o.data...)
+		if o.attrs.ComponentCount > 0 {
+			count += o.attrs.ComponentCount
+		} else {
+			count++
+		}
+	}
+	if count > maxComponentCount {
+		return errorf(http.StatusBadRequest, "the composite object would have more than %d components", maxComponentCount)
+	}
+	attrs := &raw.Object{}
+	if req.Destination != nil {
+		attrs = req.Destination
+	}
+	attrs.Name = c.args[1]
+	conds, err := parseConditions(c.query(), "")
+	if err != nil {
+		return err
+	}
+	o, err := s.insertObject(b, attrs, data, conds, c.query().Get("destinationPredefinedAcl"))
+	if err != nil {
+		return err
+	}
+	o.attrs.ComponentCount = count
+	return writeJSON(c.w, o.resource(c.full()))
+}
+
+// rewriteToken encodes the progress of a rewrite: the source generation and
+// the number of bytes rewritten so far.
+func rewriteToken(gen, done int64) string {
+	return pageToken(strconv.FormatInt(gen, 10), done)
+}
+
+func (s *Server) rewriteObject(c *call) error {
+	q := c.query()
+	srcBucket, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	dstBucket, err := s.bucket(c.args[2])
+	if err != nil {
+		return err
+	}
+	srcConds, err := parseConditions(q, "Source")
+	if err != nil {
+		return err
+	}
+	dstConds, err := parseConditions(q, "")
+	if err != nil {
+		return err
+	}
+	var done int64
+	if tok := q.Get("rewriteToken"); tok != "" {
+		// Continue copying the generation the rewrite started with.
+		g, n, err := parsePageToken(tok)
+		if err != nil {
+			return err
+		}
+		gen, err := strconv.ParseInt(g, 10, 64)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid rewrite token %q", tok)
+		}
+		srcConds.generation = &gen
+		done = n
+	}
+	src, err := srcBucket.lookup(c.args[1], srcConds, false)
+	if err != nil {
+		return err
+	}
+	size := int64(len(src.data))
+	if v := q.Get("maxBytesRewrittenPerCall"); v != "" {
+		max, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || max <= 0 {
+			return errorf(http.StatusBadRequest, "invalid value %q for maxBytesRewrittenPerCall", v)
+		}
+		if done += max; done < size {
+			return writeJSON(c.w, &raw.RewriteResponse{
+				Kind:                "storage#rewriteResponse",
+				ObjectSize:          size,
+				TotalBytesRewritten: done,
+				RewriteToken:        rewriteToken(src.attrs.Generation, done),
+			})
+		}
+	}
+
+	// Attributes given in the request override those of the source.
+	attrs := &raw.Object{
+		CacheControl:       src.attrs.CacheControl,
+		ContentDisposition: src.attrs.ContentDisposition,
+		ContentEncoding:    src.attrs.ContentEncoding,
+		ContentLanguage:    src.attrs.ContentLanguage,
+		ContentType:        src.attrs.ContentType,
+		Metadata:           src.attrs.Metadata,
+		StorageClass:       src.attrs.StorageClass,
+	}
+	if err := mergePatch(attrs, c.body, objectPatchFields); err != nil {
+		return err
+	}
+	attrs.Name = c.args[3]
+	attrs.KmsKeyName = q.Get("destinationKmsKeyName")
+	o, err := s.insertObject(dstBucket, attrs, src.data, dstConds, q.Get("destinationPredefinedAcl"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, &raw.RewriteResponse{
+		Kind:                "storage#rewriteResponse",
+		Done:                true,
+		ObjectSize:          size,
+		TotalBytesRewritten: size,
+		Resource:            o.resource(c.full()),
+	})
+}
+
+// Uploads.
+
+func (s *Server) uploadObject(c *call) error {
+	q := c.query()
+	if id := q.Get("upload_id"); id != "" {
+		return s.resumeUpload(c, id)
+	}
+	if c.r.Method != "POST" {
+		return errorf(http.StatusBadRequest, "upload_id is required")
+	}
+	b, err := s.bucket(c.args[0])
+	if err != nil {
+		return err
+	}
+	conds, err := parseConditions(q, "")
+	if err != nil {
+		return err
+	}
+	attrs := &raw.Object{}
+	var data []byte
+	switch t := q.Get("uploadType"); t {
+	case "media":
+		attrs.ContentType = c.r.Header.Get("Content-Type")
+		data = c.body
+	case "multipart":
+		if attrs, data, err = parseMultipart(c.r.Header.Get("Content-Type"), c.body); err != nil {
+			return err
+		}
+	case "resumable":
+		if err := decodeJSON(c.body, attrs); err != nil {
+			return err
+		}
+		if attrs.ContentType == "" {
+			attrs.ContentType = c.r.Header.Get("X-Upload-Content-Type")
+		}
+	default:
+		return errorf(http.StatusBadRequest, "unsupported upload type %q", t)
+	}
+	if attrs.Name == "" {
+		attrs.Name = q.Get("name")
+	}
+	if v := q.Get("contentEncoding"); v != "" {
+		attrs.ContentEncoding = v
+	}
+	if v := q.Get("kmsKeyName"); v != "" {
+		attrs.KmsKeyName = v
+	}
+
+	if q.Get("uploadType") == "resumable" {
+		if attrs.Name == "" {
+			return errorf(http.StatusBadRequest, "object name is required")
+		}
+		id := s.newID()
+		s.uploads[id] = &upload{
+			bucket:        b.attrs.Name,
+			attrs:         attrs,
+			conds:         conds,
+			predefinedACL: q.Get("predefinedAcl"),
+			full:          c.full(),
+		}
+		scheme := "http"
+		if c.r.TLS != nil {
+			scheme = "https"
+		}
+		loc := url.URL{
+			Scheme:   scheme,
+			Host:     c.r.Host,
+			Path:     c.r.URL.Path,
+			RawQuery: url.Values{"uploadType": {"resumable"}, "upload_id": {id}}.Encode(),
+		}
+		c.w.Header().Set("Location", loc.String())
+		c.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	o, err := s.insertObject(b, attrs, data, conds, q.Get("predefinedAcl"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(c.w, o.resource(c.full()))
+}
+
+// parseMultipart parses a multipart/related upload, which consists of the
+// object metadata followed by the object contents.
+func parseMultipart(contentType string, body []byte) (*raw.Object, []byte, error) {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mt != "multipart/related" {
+		return nil, nil, errorf(http.StatusBadRequest, "invalid content type %q for multipart upload", contentType)
+	}
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var parts [][]byte
+	var mediaType string
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		b, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, nil, errorf(http.StatusBadRequest, "reading multipart body: %v", err)
+		}
+		parts = append(parts, b)
+		mediaType = p.Header.Get("Content-Type")
+	}
+	if len(parts) != 2 {
+		return nil, nil, errorf(http.StatusBadRequest, "multipart upload must have 2 parts, got %d", len(parts))
+	}
+	attrs := &raw.Object{}
+	if err := decodeJSON(parts[0], attrs); err != nil {
+		return nil, nil, err
+	}
+	if attrs.ContentType == "" {
+		attrs.ContentType = mediaType
+	}
+	return attrs, parts[1], nil
+}
+
+// parseContentRange parses the Content-Range header of a resumable upload
+// request. It returns -1 for a missing start ("*/total") or an unknown total
+// size ("start-end/*").
+func parseContentRange(h string) (start, total int64, err error) {
+	bad := errorf(http.StatusBadRequest, "invalid Content-Range %q", h)
+	if h == "" {
+		return -1, -1, nil
+	}
+	if !strings.HasPrefix(h, "bytes ") {
+		return 0, 0, bad
+	}
+	parts := strings.SplitN(h[len("bytes "):], "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, bad
+	}
+	start, total = -1, -1
+	if parts[0] != "*" {
+		r := strings.SplitN(parts[0], "-", 2)
+		if len(r) != 2 {
+			return 0, 0, bad
+		}
+		if start, err = strconv.ParseInt(r[0], 10, 64); err != nil {
+			return 0, 0, bad
+		}
+	}
+	if parts[1] != "*" {
+		if total, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return 0, 0, bad
+		}
+	}
+	return start, total, nil
+}
+
+func (s *Server) resumeUpload(c *call, id string) error {
+	u, ok := s.uploads[id]
+	if !ok {
+		return errorf(http.StatusNotFound, "upload %q not found", id)
+	}
+	if c.r.Method == "DELETE" {
+		delete(s.uploads, id)
+		// The service responds to a cancelled upload with this status.
+		c.w.WriteHeader(499)
+		return nil
+	}
+	start, total, err := parseContentRange(c.r.Header.Get("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if start >= 0 {
+		n := int64(len(u.data))
+		if start > n {
+			return errorf(http.StatusBadRequest, "upload offset %d is ahead of the %d bytes received", start, n)
+		}
+		// Skip the bytes that were already received, e.g. when a chunk is
+		// retried.
+		if skip := n - start; skip < int64(len(c.body)) {
+			u.data = append(u.data, c.body[skip:]...)
+		}
+	}
+	if total >= 0 {
+		switch n := int64(len(u.data)); {
+		case n > total:
+			return errorf(http.StatusBadRequest, "received %d bytes, more than the total size %d", n, total)
+		case n == total:
+			delete(s.uploads, id)
+			b, err := s.bucket(u.bucket)
+			if err != nil {
+				return err
+			}
+			o, err := s.insertObject(b, u.attrs, u.data, u.conds, u.predefinedACL)
+			if err != nil {
+				return err
+			}
+			return writeJSON(c.w, o.resource(u.full))
+		}
+	}
+
+	// The upload is incomplete.
+	if n := len(u.data); n > 0 {
+		c.w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	}
+	if c.r.Header.Get("X-GUploader-No-308") == "yes" {
+		c.w.Header().Set("X-Http-Status-Code-Override", "308")
+		c.w.WriteHeader(http.StatusOK)
+	} else {
+		c.w.WriteHeader(http.StatusPermanentRedirect)
+	}
+	return nil
+}
+
+// Downloads.
+
+// readObject serves a read of the form /bucket/object, which is how the
+// storage package reads object contents.
+func (s *Server) readObject(c *call) error {
+	if c.r.Method != "GET" && c.r.Method != "HEAD" {
+		return errorf(http.StatusMethodNotAllowed, "method %s is not allowed for %q", c.r.Method, c.r.URL.Path)
+	}
+	p := strings.TrimPrefix(c.r.URL.Path, "/")
+	i := strings.Index(p, "/")
+	if i < 0 {
+		return errorf(http.StatusNotFound, "no object at %q", c.r.URL.Path)
+	}
+	c.args = []string{p[:i], p[i+1:]}
+	return s.downloadObject(c)
+}
+
+func (s *Server) downloadObject(c *call) error {
+	_, o, err := s.lookupObject(c, true)
+	if err != nil {
+		return err
+	}
+	data := o.data
+	h := c.w.Header()
+	enc := o.attrs.ContentEncoding
+	transcode := enc == "gzip" && !strings.Contains(c.r.Header.Get("Accept-Encoding"), "gzip")
+	if transcode {
+		// Decompressive transcoding: the service serves the decompressed
+		// contents and ignores the Range header.
+		if zr, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+			if d, err := ioutil.ReadAll(zr); err == nil {
+				data = d
+			}
+		}
+	}
+
+	size := int64(len(data))
+	status := http.StatusOK
+	if rh := c.r.Header.Get("Range"); rh != "" && !transcode {
+		start, end, ok, err := parseRange(rh, size)
+		if err != nil {
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			return err
+		}
+		if ok {
+			h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+			data = data[start : end+1]
+			status = http.StatusPartialContent
+		}
+	}
+
+	h.Set("Content-Type", o.attrs.ContentType)
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	h.Set("X-Goog-Generation", strconv.FormatInt(o.attrs.Generation, 10))
+	h.Set("X-Goog-Metageneration", strconv.FormatInt(o.attrs.Metageneration, 10))
+	h.Set("X-Goog-Stored-Content-Length", strconv.Itoa(len(o.data)))
+	h.Add("X-Goog-Hash", "crc32c="+o.attrs.Crc32c)
+	h.Add("X-Goog-Hash", "md5="+o.attrs.Md5Hash)
+	if t, err := time.Parse(time.RFC3339Nano, o.attrs.Updated); err == nil {
+		h.Set("Last-Modified", t.Format(http.TimeFormat))
+	}
+	if o.attrs.CacheControl != "" {
+		h.Set("Cache-Control", o.attrs.CacheControl)
+	}
+	if enc != "" {
+		h.Set("X-Goog-Stored-Content-Encoding", enc)
+		if !transcode {
+			h.Set("Content-Encoding", enc)
+		}
+	}
+	c.w.WriteHeader(status)
+	if c.r.Method != "HEAD" {
+		_, _ = c.w.Write(data)
+	}
+	return nil
+}
+
+// parseRange parses a Range header with a single byte range. It returns
+// false if the header should be ignored, and an error if the range can't be
+// satisfied.
+func parseRange(h string, size int64) (start, end int64, ok bool, err error) {
+	if !strings.HasPrefix(h, "bytes=") || strings.Contains(h, ",") {
+		return 0, 0, false, nil
+	}
+	r := strings.SplitN(h[len("bytes="):], "-", 2)
+	if len(r) != 2 {
+		return 0, 0, false, nil
+	}
+	unsatisfiable := errorf(http.StatusRequestedRangeNotSatisfiable, "range %q is not satisfiable for an object of %d bytes", h, size)
+	if r[0] == "" {
+		// A suffix range: the last n bytes.
+		n, err := strconv.ParseInt(r[1], 10, 64)
+		if err != nil {
+			return 0, 0, false, nil
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, false, unsatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true, nil
+	}
+	start, err = strconv.ParseInt(r[0], 10, 64)
+	if err != nil {
+		return 0, 0, false, nil
+	}
+	end = size - 1
+	if r[1] != "" {
+		if end, err = strconv.ParseInt(r[1], 10, 64); err != nil || end < start {
+			return 0, 0, false, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false, unsatisfiable
+	}
+	return start, end, true, nil
+}
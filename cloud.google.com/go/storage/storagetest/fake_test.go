@@ -0,0 +1,751 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	raw "google.golang.org/api/storage/v1"
+)
+
+func newFake(t *testing.T) (*storage.Client, *Server) {
+	srv := NewServer()
+	client, err := storage.NewClient(context.Background(), option.WithHTTPClient(srv.HTTPClient()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, srv
+}
+
+func mustCreateBucket(t *testing.T, client *storage.Client, name string, attrs *storage.BucketAttrs) *storage.BucketHandle {
+	b := client.Bucket(name)
+	if err := b.Create(context.Background(), "P", attrs); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func mustWrite(t *testing.T, o *storage.ObjectHandle, data []byte) *storage.ObjectAttrs {
+	w := o.NewWriter(context.Background())
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return w.Attrs()
+}
+
+func mustRead(t *testing.T, o *storage.ObjectHandle) []byte {
+	r, err := o.NewReader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func errCode(err error) int {
+	if e, ok := err.(*googleapi.Error); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func TestBuckets(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	var want []string
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("bucket-%d", i)
+		mustCreateBucket(t, client, name, nil)
+		want = append(want, name)
+	}
+	if err := client.Bucket("other").Create(ctx, "Q", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Bucket("bucket-0").Create(ctx, "P", nil); errCode(err) != http.StatusConflict {
+		t.Errorf("creating existing bucket: got %v, want 409", err)
+	}
+
+	var got []string
+	it := client.Buckets(ctx, "P")
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, attrs.Name)
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	b := client.Bucket("bucket-0")
+	attrs, err := b.Attrs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := attrs.MetaGeneration, int64(1); got != want {
+		t.Errorf("got metageneration %d, want %d", got, want)
+	}
+
+	ua := storage.BucketAttrsToUpdate{VersioningEnabled: true}
+	ua.SetLabel("k1", "v1")
+	ua.SetLabel("k2", "v2")
+	if _, err := b.If(storage.BucketConditions{MetagenerationMatch: 2}).Update(ctx, ua); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("update with wrong metageneration: got %v, want 412", err)
+	}
+	attrs, err = b.If(storage.BucketConditions{MetagenerationMatch: 1}).Update(ctx, ua)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !attrs.VersioningEnabled || attrs.MetaGeneration != 2 {
+		t.Errorf("got %+v, want versioning and metageneration 2", attrs)
+	}
+	ua = storage.BucketAttrsToUpdate{}
+	ua.DeleteLabel("k1")
+	attrs, err = b.Update(ctx, ua)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := attrs.Labels, map[string]string{"k2": "v2"}; !testutil.Equal(got, want) {
+		t.Errorf("got labels %v, want %v", got, want)
+	}
+
+	mustWrite(t, b.Object("obj"), []byte("data"))
+	if err := b.Delete(ctx); errCode(err) != http.StatusConflict {
+		t.Errorf("deleting non-empty bucket: got %v, want 409", err)
+	}
+	if err := b.Object("obj").Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting the live version leaves a noncurrent one in a versioned bucket.
+	if err := b.Delete(ctx); errCode(err) != http.StatusConflict {
+		t.Errorf("deleting bucket with noncurrent objects: got %v, want 409", err)
+	}
+	if err := client.Bucket("bucket-1").Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Bucket("bucket-1").Attrs(ctx); err != storage.ErrBucketNotExist {
+		t.Errorf("got %v, want ErrBucketNotExist", err)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 40000)
+	for _, test := range []struct {
+		desc      string
+		chunkSize int
+	}{
+		{"single request", 0},
+		{"resumable", 256 * 1024},
+		{"default chunk size", -1},
+	} {
+		o := b.Object(test.desc)
+		w := o.NewWriter(ctx)
+		if test.chunkSize >= 0 {
+			w.ChunkSize = test.chunkSize
+		}
+		w.ContentType = "text/plain"
+		w.Metadata = map[string]string{"k": "v"}
+		var progress int64
+		w.ProgressFunc = func(n int64) { progress = n }
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		attrs := w.Attrs()
+		if got, want := attrs.Size, int64(len(data)); got != want {
+			t.Errorf("%s: got size %d, want %d", test.desc, got, want)
+		}
+		if got, want := attrs.CRC32C, crc32.Checksum(data, crc32cTable); got != want {
+			t.Errorf("%s: got CRC32C %d, want %d", test.desc, got, want)
+		}
+		if attrs.ContentType != "text/plain" || attrs.Metadata["k"] != "v" {
+			t.Errorf("%s: got %+v, want content type and metadata", test.desc, attrs)
+		}
+		if test.chunkSize > 0 && progress != int64(len(data)) {
+			t.Errorf("%s: got progress %d, want %d", test.desc, progress, len(data))
+		}
+
+		r, err := o.NewReader(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: read data differs from written data", test.desc)
+		}
+		if r.Attrs.Generation != attrs.Generation || r.Attrs.ContentType != "text/plain" {
+			t.Errorf("%s: got reader attrs %+v", test.desc, r.Attrs)
+		}
+	}
+
+	w := b.Object("bad-crc").NewWriter(ctx)
+	w.CRC32C = crc32.Checksum(data, crc32cTable) + 1
+	w.SendCRC32C = true
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); errCode(err) != http.StatusBadRequest {
+		t.Errorf("writing with wrong CRC32C: got %v, want 400", err)
+	}
+	if _, err := b.Object("bad-crc").Attrs(ctx); err != storage.ErrObjectNotExist {
+		t.Errorf("got %v, want ErrObjectNotExist", err)
+	}
+}
+
+func TestObjectNames(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	for _, name := range []string{"a+b", "a b", "a%2Bb", "dir/a+b c"} {
+		o := b.Object(name)
+		mustWrite(t, o, []byte(name))
+		attrs, err := o.Attrs(ctx)
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if attrs.Name != name {
+			t.Errorf("got name %q, want %q", attrs.Name, name)
+		}
+		if got := string(mustRead(t, o)); got != name {
+			t.Errorf("%q: got data %q", name, got)
+		}
+		if err := o.Delete(ctx); err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+	}
+
+	// A "+" in a path segment is not a space.
+	mustWrite(t, b.Object("a+b"), []byte("x"))
+	res, err := srv.HTTPClient().Get("https://www.googleapis.com/storage/v1/b/bucket/o/a+b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("getting object a+b with an unescaped path: got status %d, want 200", res.StatusCode)
+	}
+}
+
+func TestRangeRead(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+	o := b.Object("obj")
+	data := []byte("0123456789")
+	mustWrite(t, o, data)
+
+	for _, test := range []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, -1, "0123456789"},
+		{3, -1, "3456789"},
+		{0, 4, "0123"},
+		{2, 3, "234"},
+		{8, 10, "89"},
+		{5, 0, ""},
+	} {
+		r, err := o.NewRangeReader(ctx, test.offset, test.length)
+		if err != nil {
+			t.Fatalf("%d, %d: %v", test.offset, test.length, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%d, %d: %v", test.offset, test.length, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%d, %d: got %q, want %q", test.offset, test.length, got, test.want)
+		}
+		if r.Attrs.Size != int64(len(data)) {
+			t.Errorf("%d, %d: got size %d, want %d", test.offset, test.length, r.Attrs.Size, len(data))
+		}
+	}
+	if _, err := o.NewRangeReader(ctx, 10, -1); errCode(err) != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("reading past the end: got %v, want 416", err)
+	}
+	if _, err := b.Object("missing").NewReader(ctx); err != storage.ErrObjectNotExist {
+		t.Errorf("got %v, want ErrObjectNotExist", err)
+	}
+}
+
+func TestGzipTranscoding(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte("hello, world")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	o := b.Object("obj")
+	w := o.NewWriter(ctx)
+	w.ContentEncoding = "gzip"
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(mustRead(t, o)), "hello, world"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := mustRead(t, o.ReadCompressed(true)); !bytes.Equal(got, buf.Bytes()) {
+		t.Errorf("got %q, want compressed contents", got)
+	}
+}
+
+func TestPreconditions(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", &storage.BucketAttrs{VersioningEnabled: true})
+	o := b.Object("obj")
+
+	attrs1 := mustWrite(t, o.If(storage.Conditions{DoesNotExist: true}), []byte("v1"))
+	w := o.If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	if _, err := w.Write([]byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("overwriting with DoesNotExist: got %v, want 412", err)
+	}
+	attrs2 := mustWrite(t, o.If(storage.Conditions{GenerationMatch: attrs1.Generation}), []byte("v2"))
+	if attrs2.Generation <= attrs1.Generation {
+		t.Errorf("got generation %d, want more than %d", attrs2.Generation, attrs1.Generation)
+	}
+
+	// The previous generation can still be read.
+	if got, want := string(mustRead(t, o.Generation(attrs1.Generation))), "v1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := string(mustRead(t, o)), "v2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := o.If(storage.Conditions{GenerationMatch: attrs1.Generation}).NewReader(ctx); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("reading with old generation condition: got %v, want 412", err)
+	}
+	if _, err := o.If(storage.Conditions{GenerationNotMatch: attrs2.Generation}).Attrs(ctx); errCode(err) != http.StatusNotModified {
+		t.Errorf("getting with GenerationNotMatch: got %v, want 304", err)
+	}
+
+	uattrs := storage.ObjectAttrsToUpdate{ContentType: "text/plain"}
+	if _, err := o.If(storage.Conditions{MetagenerationMatch: 2}).Update(ctx, uattrs); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("update with wrong metageneration: got %v, want 412", err)
+	}
+	got, err := o.If(storage.Conditions{MetagenerationMatch: 1}).Update(ctx, uattrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ContentType != "text/plain" || got.Metageneration != 2 || got.Generation != attrs2.Generation {
+		t.Errorf("got %+v, want updated content type and metageneration", got)
+	}
+
+	var gens []int64
+	it := b.Objects(ctx, &storage.Query{Versions: true})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		gens = append(gens, attrs.Generation)
+	}
+	if want := []int64{attrs1.Generation, attrs2.Generation}; !testutil.Equal(gens, want) {
+		t.Errorf("got generations %v, want %v", gens, want)
+	}
+
+	if err := o.Generation(attrs1.Generation).Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Generation(attrs1.Generation).Attrs(ctx); err != storage.ErrObjectNotExist {
+		t.Errorf("got %v, want ErrObjectNotExist", err)
+	}
+	if err := o.If(storage.Conditions{GenerationMatch: attrs1.Generation}).Delete(ctx); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("delete with wrong generation: got %v, want 412", err)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+	for _, name := range []string{"a", "b/c", "b/d", "b/e/f", "b/e/g", "c"} {
+		mustWrite(t, b.Object(name), []byte(name))
+	}
+
+	list := func(q *storage.Query, pageSize int) (names []string) {
+		it := b.Objects(ctx, q)
+		p := iterator.NewPager(it, pageSize, "")
+		for {
+			var page []*storage.ObjectAttrs
+			tok, err := p.NextPage(&page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, attrs := range page {
+				if attrs.Prefix != "" {
+					names = append(names, attrs.Prefix)
+				} else {
+					names = append(names, attrs.Name)
+				}
+			}
+			if tok == "" {
+				return names
+			}
+		}
+	}
+	for _, test := range []struct {
+		query    *storage.Query
+		pageSize int
+		want     []string
+	}{
+		{nil, 100, []string{"a", "b/c", "b/d", "b/e/f", "b/e/g", "c"}},
+		{nil, 2, []string{"a", "b/c", "b/d", "b/e/f", "b/e/g", "c"}},
+		{&storage.Query{Prefix: "b/"}, 100, []string{"b/c", "b/d", "b/e/f", "b/e/g"}},
+		// The iterator returns the prefixes of a page after its objects.
+		{&storage.Query{Delimiter: "/"}, 100, []string{"a", "c", "b/"}},
+		{&storage.Query{Delimiter: "/"}, 1, []string{"a", "b/", "c"}},
+		{&storage.Query{Prefix: "b/", Delimiter: "/"}, 100, []string{"b/c", "b/d", "b/e/"}},
+		{&storage.Query{Prefix: "b/", Delimiter: "/"}, 1, []string{"b/c", "b/d", "b/e/"}},
+		{&storage.Query{Prefix: "x"}, 100, nil},
+	} {
+		if got := list(test.query, test.pageSize); !testutil.Equal(got, test.want) {
+			t.Errorf("%+v, page size %d: got %v, want %v", test.query, test.pageSize, got, test.want)
+		}
+	}
+}
+
+func TestCopyCompose(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+	b2 := mustCreateBucket(t, client, "bucket2", nil)
+	mustWrite(t, b.Object("a"), []byte("abc"))
+	mustWrite(t, b.Object("b"), []byte("def"))
+
+	c := b2.Object("copy").CopierFrom(b.Object("a"))
+	c.ContentType = "text/plain"
+	attrs, err := c.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.Bucket != "bucket2" || attrs.ContentType != "text/plain" {
+		t.Errorf("got %+v, want copy in bucket2 with content type", attrs)
+	}
+	if got, want := string(mustRead(t, b2.Object("copy"))), "abc"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	c = b2.Object("copy").If(storage.Conditions{DoesNotExist: true}).CopierFrom(b.Object("a"))
+	if _, err := c.Run(ctx); errCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("copy with DoesNotExist: got %v, want 412", err)
+	}
+
+	comp := b.Object("ab").ComposerFrom(b.Object("a"), b.Object("b"))
+	attrs, err = comp.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := attrs.CRC32C, crc32.Checksum([]byte("abcdef"), crc32cTable); got != want {
+		t.Errorf("got CRC32C %d, want %d", got, want)
+	}
+	attrs, err = b.Object("abab").ComposerFrom(b.Object("ab"), b.Object("ab")).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(mustRead(t, b.Object("abab"))), "abcdefabcdef"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := b.Object("x").ComposerFrom(b.Object("missing")).Run(ctx); errCode(err) != http.StatusNotFound {
+		t.Errorf("composing missing object: got %v, want 404", err)
+	}
+}
+
+func TestRewriteInSteps(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+	mustWrite(t, b.Object("src"), []byte("0123456789"))
+
+	rs, err := raw.New(srv.HTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		res   *raw.RewriteResponse
+		steps int
+		token string
+	)
+	for res == nil || !res.Done {
+		call := rs.Objects.Rewrite("bucket", "src", "bucket", "dst", &raw.Object{}).MaxBytesRewrittenPerCall(4)
+		if token != "" {
+			call.RewriteToken(token)
+		}
+		if res, err = call.Context(ctx).Do(); err != nil {
+			t.Fatal(err)
+		}
+		token = res.RewriteToken
+		steps++
+	}
+	if steps != 3 {
+		t.Errorf("got %d steps, want 3", steps)
+	}
+	if got, want := string(mustRead(t, b.Object("dst"))), "0123456789"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResumableUploadProtocol(t *testing.T) {
+	_, srv := newFake(t)
+	defer srv.Close()
+	hc := srv.HTTPClient()
+	rs, err := raw.New(hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.Buckets.Insert("P", &raw.Bucket{Name: "bucket"}).Do(); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, url, contentRange, body string) *http.Response {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentRange != "" {
+			req.Header.Set("Content-Range", contentRange)
+		}
+		res, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	res := do("POST", srv.URL+"/upload/storage/v1/b/bucket/o?uploadType=resumable&name=obj", "", `{"contentType": "text/plain"}`)
+	loc := res.Header.Get("Location")
+	if res.StatusCode != http.StatusOK || loc == "" {
+		t.Fatalf("got status %d and location %q", res.StatusCode, loc)
+	}
+	for _, step := range []struct {
+		contentRange, body string
+		wantStatus         int
+		wantRange          string
+	}{
+		{"bytes 0-3/*", "0123", http.StatusPermanentRedirect, "bytes=0-3"},
+		// A retried chunk overlapping the received bytes.
+		{"bytes 2-5/*", "2345", http.StatusPermanentRedirect, "bytes=0-5"},
+		{"bytes */*", "", http.StatusPermanentRedirect, "bytes=0-5"},
+		{"bytes 9-9/*", "9", http.StatusBadRequest, ""},
+		{"bytes 6-9/10", "6789", http.StatusOK, ""},
+	} {
+		res := do("PUT", loc, step.contentRange, step.body)
+		if res.StatusCode != step.wantStatus || res.Header.Get("Range") != step.wantRange {
+			t.Errorf("%s: got status %d and range %q, want %d and %q", step.contentRange,
+				res.StatusCode, res.Header.Get("Range"), step.wantStatus, step.wantRange)
+		}
+	}
+	obj, err := rs.Objects.Get("bucket", "obj").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Size != 10 || obj.ContentType != "text/plain" {
+		t.Errorf("got %+v, want 10 bytes of text/plain", obj)
+	}
+
+	res = do("POST", srv.URL+"/upload/storage/v1/b/bucket/o?uploadType=resumable&name=obj2", "", "")
+	loc = res.Header.Get("Location")
+	if res := do("DELETE", loc, "", ""); res.StatusCode != 499 {
+		t.Errorf("cancelling upload: got status %d, want 499", res.StatusCode)
+	}
+	if res := do("PUT", loc, "bytes */0", ""); res.StatusCode != http.StatusNotFound {
+		t.Errorf("resuming cancelled upload: got status %d, want 404", res.StatusCode)
+	}
+}
+
+func TestACLs(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	if err := b.ACL().Set(ctx, storage.AllAuthenticatedUsers, storage.RoleReader); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := b.ACL().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []storage.ACLRule{{Entity: storage.AllAuthenticatedUsers, Role: storage.RoleReader}}; !testutil.Equal(rules, want) {
+		t.Errorf("got %+v, want %+v", rules, want)
+	}
+	if err := b.ACL().Delete(ctx, storage.AllAuthenticatedUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ACL().Delete(ctx, storage.AllAuthenticatedUsers); errCode(err) != http.StatusNotFound {
+		t.Errorf("deleting missing entry: got %v, want 404", err)
+	}
+
+	// New objects get the default object ACL of the bucket.
+	if err := b.DefaultObjectACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
+		t.Fatal(err)
+	}
+	o := b.Object("obj")
+	mustWrite(t, o, []byte("data"))
+	if err := o.ACL().Set(ctx, "user-a@example.com", storage.RoleOwner); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = o.ACL().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []storage.ACLRule{
+		{Entity: storage.AllUsers, Role: storage.RoleReader},
+		{Entity: "user-a@example.com", Role: storage.RoleOwner},
+	}
+	if !testutil.Equal(rules, want) {
+		t.Errorf("got %+v, want %+v", rules, want)
+	}
+	attrs, err := o.Attrs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.Equal(attrs.ACL, want) || attrs.Metageneration != 2 {
+		t.Errorf("got %+v, want ACL and metageneration 2", attrs)
+	}
+
+	w := b.Object("public").NewWriter(ctx)
+	w.PredefinedACL = "authenticatedRead"
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Attrs().ACL, []storage.ACLRule{{Entity: storage.AllAuthenticatedUsers, Role: storage.RoleReader}}; !testutil.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	n, err := b.AddNotification(ctx, &storage.Notification{
+		TopicProjectID: "P",
+		TopicID:        "T",
+		PayloadFormat:  storage.JSONPayload,
+		EventTypes:     []string{storage.ObjectFinalizeEvent},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ID == "" {
+		t.Fatal("got empty notification ID")
+	}
+	ns, err := b.Notifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ns[n.ID]; len(ns) != 1 || !testutil.Equal(got, n) {
+		t.Errorf("got %+v, want %+v", ns, n)
+	}
+	if err := b.DeleteNotification(ctx, n.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeleteNotification(ctx, n.ID); errCode(err) != http.StatusNotFound {
+		t.Errorf("deleting missing notification: got %v, want 404", err)
+	}
+}
+
+func TestIAM(t *testing.T) {
+	client, srv := newFake(t)
+	defer srv.Close()
+	ctx := context.Background()
+	b := mustCreateBucket(t, client, "bucket", nil)
+
+	p, err := b.IAM().Policy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Add("user:a@example.com", "roles/storage.objectViewer")
+	if err := b.IAM().SetPolicy(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	p, err = b.IAM().Policy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.HasRole("user:a@example.com", "roles/storage.objectViewer") {
+		t.Errorf("got %+v, want role binding", p)
+	}
+	perms, err := b.IAM().TestPermissions(ctx, []string{"storage.objects.get"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"storage.objects.get"}; !testutil.Equal(perms, want) {
+		t.Errorf("got %v, want %v", perms, want)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"golang.org/x/net/context"
+	api "google.golang.org/api/cloudtrace/v1"
+)
+
+// An Exporter receives the traces recorded by a Client. A trace is exported
+// when its root span finishes, together with the descendant spans that have
+// finished by then.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+type Exporter interface {
+	// ExportTraces exports a batch of traces. It may be called concurrently.
+	ExportTraces(ctx context.Context, traces []*api.Trace) error
+}
+
+// serviceExporter uploads traces to the Stackdriver Trace service.
+type serviceExporter struct {
+	service   *api.Service
+	projectID string
+}
+
+func (e *serviceExporter) ExportTraces(ctx context.Context, traces []*api.Trace) error {
+	_, err := e.service.Projects.PatchTraces(e.projectID, &api.Traces{Traces: traces}).Context(ctx).Do()
+	return err
+}
+
+// NewJSONExporter returns an Exporter that writes each trace to w as a JSON
+// object followed by a newline.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func NewJSONExporter(w io.Writer) Exporter {
+	return &jsonExporter{enc: json.NewEncoder(w)}
+}
+
+type jsonExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (e *jsonExporter) ExportTraces(_ context.Context, traces []*api.Trace) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range traces {
+		if err := e.enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryExporter is an Exporter that keeps traces in memory. It is intended
+// for tests.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+type MemoryExporter struct {
+	mu     sync.Mutex
+	traces []*api.Trace
+}
+
+// ExportTraces implements Exporter.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func (e *MemoryExporter) ExportTraces(_ context.Context, traces []*api.Trace) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.traces = append(e.traces, traces...)
+	return nil
+}
+
+// Traces returns the traces exported so far.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func (e *MemoryExporter) Traces() []*api.Trace {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*api.Trace(nil), e.traces...)
+}
+
+// Reset discards the exported traces.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.traces = nil
+}
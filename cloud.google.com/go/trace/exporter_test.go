@@ -0,0 +1,80 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	api "google.golang.org/api/cloudtrace/v1"
+)
+
+func TestMemoryExporter(t *testing.T) {
+	var e MemoryExporter
+	tc := NewClientWithExporter(testProjectID, &e)
+	root := tc.NewSpan("root")
+	root.NewChild("child").Finish()
+	if err := root.FinishWait(); err != nil {
+		t.Fatal(err)
+	}
+	traces := e.Traces()
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1", len(traces))
+	}
+	tr := traces[0]
+	if tr.ProjectId != testProjectID || tr.TraceId != root.TraceID() {
+		t.Errorf("got project %q, trace %q; want %q, %q", tr.ProjectId, tr.TraceId, testProjectID, root.TraceID())
+	}
+	var names []string
+	for _, s := range tr.Spans {
+		names = append(names, s.Name)
+	}
+	if len(names) != 2 || names[0] != "child" || names[1] != "root" {
+		t.Errorf("got spans %v, want [child root]", names)
+	}
+
+	// Finish exports asynchronously.
+	e.Reset()
+	tc.NewSpan("async").Finish()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(e.Traces()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := e.Traces(); len(got) != 1 || got[0].Spans[0].Name != "async" {
+		t.Errorf("got %v, want one trace with span async", got)
+	}
+}
+
+func TestJSONExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tc := NewClientWithExporter(testProjectID, NewJSONExporter(&buf))
+	for _, name := range []string{"a", "b"} {
+		if err := tc.NewSpan(name).FinishWait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dec := json.NewDecoder(&buf)
+	for _, want := range []string{"a", "b"} {
+		var tr api.Trace
+		if err := dec.Decode(&tr); err != nil {
+			t.Fatal(err)
+		}
+		if len(tr.Spans) != 1 || tr.Spans[0].Name != want {
+			t.Errorf("got %+v, want a trace with span %q", tr, want)
+		}
+	}
+}
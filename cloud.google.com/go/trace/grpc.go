@@ -17,6 +17,7 @@ package trace
 import (
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"cloud.google.com/go/internal/tracecontext"
 	"golang.org/x/net/context"
@@ -30,7 +31,8 @@ const grpcMetadataKey = "grpc-trace-bin"
 // The calling context should already have a *trace.Span; a child span will be
 // created for the outgoing gRPC call. If the calling context doesn't have a span,
 // the call will not be traced. If the client is nil, then the interceptor just
-// passes through the request.
+// passes through the request. The trace context is sent in grpc-trace-bin
+// metadata, and in W3C traceparent and tracestate metadata.
 //
 // The functionality in gRPC that this feature relies on is currently experimental.
 // Deprecated: see https://cloud.google.com/trace/docs/setup/go.
@@ -67,6 +69,12 @@ func (c *Client) grpcUnaryInterceptor(ctx context.Context, method string, req, r
 		md = md.Copy() // metadata is immutable, copy.
 		md[grpcMetadataKey] = []string{string(traceContext)}
 	}
+	if tp := span.Traceparent(); tp != "" {
+		md[traceparentHeader] = []string{tp}
+		if ts := span.trace.tracestate; ts != "" {
+			md[tracestateHeader] = []string{ts}
+		}
+	}
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
 	err = invoker(ctx, method, req, reply, cc, opts...)
@@ -78,7 +86,8 @@ func (c *Client) grpcUnaryInterceptor(ctx context.Context, method string, req, r
 }
 
 // GRPCServerInterceptor returns a grpc.UnaryServerInterceptor that enables the tracing of the incoming
-// gRPC calls. Incoming call's context can be used to extract the span on servers that enabled this option:
+// gRPC calls. The trace context is read from grpc-trace-bin metadata or, if
+// that is missing, from W3C traceparent and tracestate metadata. Incoming call's context can be used to extract the span on servers that enabled this option:
 //
 //	span := trace.FromContext(ctx)
 //
@@ -94,18 +103,35 @@ func (c *Client) GRPCServerInterceptor() grpc.UnaryServerInterceptor {
 		}
 	}
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		md, _ := metadata.FromIncomingContext(ctx)
-		var traceHeader string
-		if header, ok := md[grpcMetadataKey]; ok {
-			traceID, spanID, opts, ok := tracecontext.Decode([]byte(header[0]))
-			if ok {
-				// TODO(jbd): Generate a span directly from string(traceID), spanID and opts.
-				traceHeader = fmt.Sprintf("%x/%d;o=%d", traceID, spanID, opts)
-			}
-		}
-		span := c.SpanFromHeader(info.FullMethod, traceHeader)
+		p, ok := parentFromMetadata(ctx)
+		span := c.newServerSpan(info.FullMethod, nil, p, ok)
 		defer span.Finish()
 		ctx = NewContext(ctx, span)
 		return handler(ctx, req)
 	}
 }
+
+// parentFromMetadata reads the trace context from the metadata of an incoming
+// gRPC call.
+func parentFromMetadata(ctx context.Context) (remoteParent, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if header, ok := md[grpcMetadataKey]; ok {
+		traceID, spanID, opts, ok := tracecontext.Decode([]byte(header[0]))
+		if ok {
+			return remoteParent{
+				traceID:   fmt.Sprintf("%x", traceID),
+				spanID:    spanID,
+				options:   optionFlags(opts),
+				optionsOk: true,
+			}, true
+		}
+	}
+	if header, ok := md[traceparentHeader]; ok {
+		p, ok := parentFromTraceparent(header[0])
+		if ok {
+			p.tracestate = strings.Join(md[tracestateHeader], ",")
+			return p, true
+		}
+	}
+	return remoteParent{}, false
+}
@@ -90,27 +90,15 @@ type handler struct {
 
 // Deprecated: see https://cloud.google.com/trace/docs/setup/go.
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	traceID, parentSpanID, options, optionsOk, ok := traceInfoFromHeader(r.Header.Get(httpHeader))
-	if !ok {
-		traceID = nextTraceID()
-	}
-	t := &trace{
-		traceID:       traceID,
-		client:        h.traceClient,
-		globalOptions: options,
-		localOptions:  options,
-	}
-	span := startNewChildWithRequest(r, t, parentSpanID)
-	span.span.Kind = spanKindServer
-	span.rootSpan = true
-	configureSpanFromPolicy(span, h.traceClient.policy, ok)
+	p, ok := parentFromHTTPHeader(r.Header)
+	span := h.traceClient.newServerSpan("", r, p, ok)
 	defer span.Finish()
 
 	r = r.WithContext(NewContext(r.Context(), span))
-	if ok && !optionsOk {
+	if ok && !p.optionsOk {
 		// Inject the trace context back to the response with the sampling options.
 		// TODO(jbd): Remove when there is a better way to report the client's sampling.
-		w.Header().Set(httpHeader, spanHeader(traceID, parentSpanID, span.trace.localOptions))
+		w.Header().Set(httpHeader, spanHeader(p.traceID, p.spanID, span.trace.localOptions))
 	}
 	h.handler.ServeHTTP(w, r)
 }
@@ -153,7 +153,7 @@ func nextTraceID() string {
 //
 // Deprecated: see https://cloud.google.com/trace/docs/setup/go.
 type Client struct {
-	service   *api.Service
+	exporter  Exporter
 	projectID string
 	policy    SamplingPolicy
 	bundler   *bundler.Bundler
@@ -180,15 +180,28 @@ func NewClient(ctx context.Context, projectID string, opts ...option.ClientOptio
 		// An option set a basepath, so override api.New's default.
 		apiService.BasePath = basePath
 	}
+	return newClient(projectID, &serviceExporter{service: apiService, projectID: projectID}), nil
+}
+
+// NewClientWithExporter creates a client that sends traces to e instead of
+// the Google Stackdriver Trace service. The project ID is recorded in the
+// traces.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func NewClientWithExporter(projectID string, e Exporter) *Client {
+	return newClient(projectID, e)
+}
+
+func newClient(projectID string, e Exporter) *Client {
 	c := &Client{
-		service:   apiService,
+		exporter:  e,
 		projectID: projectID,
 	}
 	bundler := bundler.NewBundler((*api.Trace)(nil), func(bundle interface{}) {
 		traces := bundle.([]*api.Trace)
-		err := c.upload(traces)
+		err := c.export(traces)
 		if err != nil {
-			log.Printf("failed to upload %d traces to the Cloud Trace server: %v", len(traces), err)
+			log.Printf("failed to export %d traces: %v", len(traces), err)
 		}
 	})
 	bundler.DelayThreshold = 2 * time.Second
@@ -198,7 +211,7 @@ func NewClient(ctx context.Context, projectID string, opts ...option.ClientOptio
 	bundler.BundleByteLimit = 1000
 	bundler.BufferedByteLimit = 10000
 	c.bundler = bundler
-	return c, nil
+	return c
 }
 
 // SetSamplingPolicy sets the SamplingPolicy that determines how often traces
@@ -216,8 +229,10 @@ func (c *Client) SetSamplingPolicy(p SamplingPolicy) {
 //
 // The trace information and identifiers will be read from the header value.
 // Otherwise, a new trace ID is made and the parent span ID is zero.
-// For the exact format of the header value, see
-// https://cloud.google.com/trace/docs/troubleshooting#how_do_i_force_a_request_to_be_traced
+// The header value may be that of an X-Cloud-Trace-Context header, described at
+// https://cloud.google.com/trace/docs/troubleshooting#how_do_i_force_a_request_to_be_traced,
+// or of a W3C traceparent header, described at
+// https://www.w3.org/TR/trace-context/.
 //
 // The name of the new span is provided as an argument.
 //
@@ -238,29 +253,24 @@ func (c *Client) SpanFromHeader(name string, header string) *Span {
 	if c == nil {
 		return nil
 	}
-	traceID, parentSpanID, options, _, ok := traceInfoFromHeader(header)
-	if !ok {
-		traceID = nextTraceID()
-	}
-	t := &trace{
-		traceID:       traceID,
-		client:        c,
-		globalOptions: options,
-		localOptions:  options,
+	var p remoteParent
+	traceID, parentSpanID, options, optionsOk, ok := traceInfoFromHeader(header)
+	if ok {
+		p = remoteParent{traceID: traceID, spanID: parentSpanID, options: options, optionsOk: optionsOk}
+	} else {
+		p, ok = parentFromTraceparent(header)
 	}
-	span := startNewChild(name, t, parentSpanID)
-	span.span.Kind = spanKindServer
-	span.rootSpan = true
-	configureSpanFromPolicy(span, c.policy, ok)
-	return span
+	return c.newServerSpan(name, nil, p, ok)
 }
 
 // SpanFromRequest returns a new trace span for an HTTP request or nil
 // iff the client is nil.
 //
-// If the incoming HTTP request contains a trace context header, the trace ID,
-// parent span ID, and tracing options will be read from that header.
-// Otherwise, a new trace ID is made and the parent span ID is zero.
+// If the incoming HTTP request contains an X-Cloud-Trace-Context header, or
+// else a W3C traceparent header, the trace ID, parent span ID, and tracing
+// options will be read from that header. Otherwise, a new trace ID is made and
+// the parent span ID is zero. A W3C tracestate header is propagated to child
+// requests.
 //
 // If a non-nil sampling policy has been set in the client, it can override the
 // options set in the header and choose whether to trace the request.
@@ -277,17 +287,30 @@ func (c *Client) SpanFromRequest(r *http.Request) *Span {
 	if c == nil {
 		return nil
 	}
-	traceID, parentSpanID, options, _, ok := traceInfoFromHeader(r.Header.Get(httpHeader))
+	p, ok := parentFromHTTPHeader(r.Header)
+	return c.newServerSpan("", r, p, ok)
+}
+
+// newServerSpan returns a root span for an incoming request with the given
+// remote parent, if ok. If r is non-nil, the span is named for and labeled
+// with the request; otherwise it has the given name.
+func (c *Client) newServerSpan(name string, r *http.Request, p remoteParent, ok bool) *Span {
 	if !ok {
-		traceID = nextTraceID()
+		p = remoteParent{traceID: nextTraceID()}
 	}
 	t := &trace{
-		traceID:       traceID,
+		traceID:       p.traceID,
 		client:        c,
-		globalOptions: options,
-		localOptions:  options,
+		globalOptions: p.options,
+		localOptions:  p.options,
+		tracestate:    p.tracestate,
+	}
+	var span *Span
+	if r != nil {
+		span = startNewChildWithRequest(r, t, p.spanID)
+	} else {
+		span = startNewChild(name, t, p.spanID)
 	}
-	span := startNewChildWithRequest(r, t, parentSpanID)
 	span.span.Kind = spanKindServer
 	span.rootSpan = true
 	configureSpanFromPolicy(span, c.policy, ok)
@@ -413,6 +436,7 @@ type trace struct {
 	traceID       string
 	globalOptions optionFlags // options that will be passed to any child requests
 	localOptions  optionFlags // options applied in this server
+	tracestate    string      // W3C tracestate passed to any child requests
 	spans         []*Span     // finished spans for this trace.
 }
 
@@ -429,13 +453,13 @@ func (t *trace) finish(s *Span, wait bool, opts ...FinishOption) error {
 	t.mu.Unlock()
 	if s.rootSpan {
 		if wait {
-			return t.client.upload([]*api.Trace{t.constructTrace(spans)})
+			return t.client.export([]*api.Trace{t.constructTrace(spans)})
 		}
 		go func() {
 			tr := t.constructTrace(spans)
 			err := t.client.bundler.Add(tr, 1+len(spans))
 			if err == bundler.ErrOversizedItem {
-				err = t.client.upload([]*api.Trace{tr})
+				err = t.client.export([]*api.Trace{tr})
 			}
 			if err != nil {
 				log.Println("error uploading trace:", err)
@@ -476,9 +500,8 @@ func (t *trace) constructTrace(spans []*Span) *api.Trace {
 	}
 }
 
-func (c *Client) upload(traces []*api.Trace) error {
-	_, err := c.service.Projects.PatchTraces(c.projectID, &api.Traces{Traces: traces}).Do()
-	return err
+func (c *Client) export(traces []*api.Trace) error {
+	return c.exporter.ExportTraces(context.Background(), traces)
 }
 
 // Span contains information about one span of a trace.
@@ -529,8 +552,9 @@ func (s *Span) NewChild(name string) *Span {
 //
 // Some labels in the span are set from the outgoing *http.Request r.
 //
-// A header is set in r so that the trace context is propagated to the
-// destination.  The parent span ID in that header is set as follows:
+// Headers are set in r so that the trace context is propagated to the
+// destination: X-Cloud-Trace-Context, and W3C traceparent and tracestate
+// headers. The parent span ID in those headers is set as follows:
 // - If the request is being traced, then the ID of s is used.
 // - If the request is not being traced, but there was a trace context header
 //   in the incoming request for this trace (the request passed to
@@ -547,11 +571,13 @@ func (s *Span) NewRemoteChild(r *http.Request) *Span {
 		return nil
 	}
 	if !s.Traced() {
-		r.Header[httpHeader] = []string{spanHeader(s.trace.traceID, s.span.ParentSpanId, s.trace.globalOptions)}
+		// A traceparent header cannot have a zero parent span ID, so none is
+		// set if there is no parent.
+		setPropagationHeaders(r.Header, s.trace, s.span.ParentSpanId)
 		return s
 	}
 	newSpan := startNewChildWithRequest(r, s.trace, s.span.SpanId)
-	r.Header[httpHeader] = []string{spanHeader(s.trace.traceID, newSpan.span.SpanId, s.trace.globalOptions)}
+	setPropagationHeaders(r.Header, s.trace, newSpan.span.SpanId)
 	return newSpan
 }
 
@@ -571,6 +597,18 @@ func (s *Span) Header() string {
 	return spanHeader(s.trace.traceID, s.span.SpanId, s.trace.globalOptions)
 }
 
+// Traceparent returns the value of the W3C traceparent header that should
+// be used to propagate the span, or "" if the trace ID is not in the W3C
+// format.
+//
+// Deprecated: see https://cloud.google.com/trace/docs/setup/go.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return traceparent(s.trace.traceID, s.span.SpanId, s.trace.globalOptions)
+}
+
 func startNewChildWithRequest(r *http.Request, trace *trace, parentSpanID uint64) *Span {
 	name := r.URL.Host + r.URL.Path // drop scheme and query params
 	newSpan := startNewChild(name, trace, parentSpanID)
@@ -680,7 +718,7 @@ func (u withResponse) modifySpan(s *Span) {
 //
 // If s is a root span (one created by SpanFromRequest) then s, and all its
 // descendant spans that have finished, are uploaded to the Google Stackdriver
-// Trace server asynchronously.
+// Trace server, or given to the client's Exporter, asynchronously.
 //
 // Deprecated: see https://cloud.google.com/trace/docs/setup/go.
 func (s *Span) Finish(opts ...FinishOption) {
@@ -694,7 +732,7 @@ func (s *Span) Finish(opts ...FinishOption) {
 }
 
 // FinishWait is like Finish, but if s is a root span, it waits until uploading
-// is finished, then returns an error if one occurred.
+// or exporting is finished, then returns an error if one occurred.
 //
 // Deprecated: see https://cloud.google.com/trace/docs/setup/go.
 func (s *Span) FinishWait(opts ...FinishOption) error {
@@ -0,0 +1,126 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Header names of the W3C Trace Context format. See
+// https://www.w3.org/TR/trace-context/.
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// remoteParent is the trace context propagated by the caller of a request.
+type remoteParent struct {
+	traceID    string
+	spanID     uint64
+	options    optionFlags
+	optionsOk  bool   // whether options were present
+	tracestate string // W3C tracestate, propagated unchanged
+}
+
+// parentFromHTTPHeader reads the trace context from the X-Cloud-Trace-Context
+// header of an incoming request, or from its traceparent and tracestate headers
+// if that is missing or invalid. It reports whether a trace context was found.
+func parentFromHTTPHeader(h http.Header) (remoteParent, bool) {
+	tp, tpOK := parentFromTraceparent(h.Get(traceparentHeader))
+	if tpOK {
+		tp.tracestate = strings.Join(h[http.CanonicalHeaderKey(tracestateHeader)], ",")
+	}
+	traceID, spanID, options, optionsOk, ok := traceInfoFromHeader(h.Get(httpHeader))
+	if ok {
+		p := remoteParent{traceID: traceID, spanID: spanID, options: options, optionsOk: optionsOk}
+		if tpOK && strings.EqualFold(tp.traceID, traceID) {
+			p.tracestate = tp.tracestate
+		}
+		return p, true
+	}
+	return tp, tpOK
+}
+
+// parentFromTraceparent parses the value of a W3C traceparent header, of the
+// form "00-<trace ID>-<parent span ID>-<flags>" with the fields in hex.
+func parentFromTraceparent(h string) (remoteParent, bool) {
+	// Later versions may append fields, separated by dashes.
+	if len(h) < 55 || len(h) > 200 || (len(h) > 55 && h[55] != '-') {
+		return remoteParent{}, false
+	}
+	version, traceID, spanID, flags := h[0:2], h[3:35], h[36:52], h[53:55]
+	if h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return remoteParent{}, false
+	}
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(h) != 55) {
+		return remoteParent{}, false
+	}
+	if !isLowerHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return remoteParent{}, false
+	}
+	if !isLowerHex(spanID) || !isLowerHex(flags) {
+		return remoteParent{}, false
+	}
+	sid, err := strconv.ParseUint(spanID, 16, 64)
+	if err != nil || sid == 0 {
+		return remoteParent{}, false
+	}
+	f, err := strconv.ParseUint(flags, 16, 8)
+	if err != nil {
+		return remoteParent{}, false
+	}
+	return remoteParent{
+		traceID:   traceID,
+		spanID:    sid,
+		options:   optionFlags(f) & optionTrace,
+		optionsOk: true,
+	}, true
+}
+
+// traceparent returns the value of a W3C traceparent header for a span. Only
+// the trace option is propagated. It returns "" if the trace ID or span ID
+// cannot be represented in that format.
+func traceparent(traceID string, spanID uint64, options optionFlags) string {
+	traceID = strings.ToLower(traceID)
+	if len(traceID) != 32 || !isLowerHex(traceID) || spanID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%016x-%02x", traceID, spanID, options&optionTrace)
+}
+
+// setPropagationHeaders sets the headers of an outgoing request that
+// propagate the trace context of t, with the given parent span ID.
+func setPropagationHeaders(h http.Header, t *trace, spanID uint64) {
+	h[httpHeader] = []string{spanHeader(t.traceID, spanID, t.globalOptions)}
+	if tp := traceparent(t.traceID, spanID, t.globalOptions); tp != "" {
+		h.Set(traceparentHeader, tp)
+		if t.tracestate != "" {
+			h.Set(tracestateHeader, t.tracestate)
+		}
+	}
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
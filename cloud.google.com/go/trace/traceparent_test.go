@@ -0,0 +1,150 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	w3cTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w3cParent  = "00-" + w3cTraceID + "-00f067aa0ba902b7-01"
+)
+
+func TestParentFromTraceparent(t *testing.T) {
+	for _, test := range []struct {
+		header string
+		want   remoteParent
+		wantOK bool
+	}{
+		{
+			header: w3cParent,
+			want:   remoteParent{traceID: w3cTraceID, spanID: 0x00f067aa0ba902b7, options: optionTrace, optionsOk: true},
+			wantOK: true,
+		},
+		{
+			header: "00-" + w3cTraceID + "-00f067aa0ba902b7-00",
+			want:   remoteParent{traceID: w3cTraceID, spanID: 0x00f067aa0ba902b7, optionsOk: true},
+			wantOK: true,
+		},
+		{
+			// Unknown flags are ignored, and later versions may add fields.
+			header: "01-" + w3cTraceID + "-00f067aa0ba902b7-03-extra",
+			want:   remoteParent{traceID: w3cTraceID, spanID: 0x00f067aa0ba902b7, options: optionTrace, optionsOk: true},
+			wantOK: true,
+		},
+		{header: ""},
+		{header: "00-" + w3cTraceID + "-00f067aa0ba902b7-01-extra"}, // version 00 has no more fields
+		{header: "ff-" + w3cTraceID + "-00f067aa0ba902b7-01"},       // invalid version
+		{header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{header: "00-" + w3cTraceID + "-0000000000000000-01"},
+		{header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"}, // upper case
+		{header: "00_" + w3cTraceID + "_00f067aa0ba902b7_01"},
+	} {
+		got, ok := parentFromTraceparent(test.header)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("%q: got (%+v, %t), want (%+v, %t)", test.header, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestTraceparentPropagation(t *testing.T) {
+	tc := newTestClient(&noopTransport{})
+
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set("traceparent", w3cParent)
+	req.Header.Set("tracestate", "vendor=value")
+	span := tc.SpanFromRequest(req)
+	if got := span.TraceID(); got != w3cTraceID {
+		t.Errorf("got trace ID %q, want %q", got, w3cTraceID)
+	}
+	if !span.Traced() {
+		t.Error("span from a sampled traceparent is not traced")
+	}
+	if got, want := span.span.ParentSpanId, uint64(0x00f067aa0ba902b7); got != want {
+		t.Errorf("got parent span ID %x, want %x", got, want)
+	}
+
+	out, _ := http.NewRequest("GET", "http://example.com/bar", nil)
+	child := span.NewRemoteChild(out)
+	wantTP := fmt.Sprintf("00-%s-%016x-01", w3cTraceID, child.span.SpanId)
+	if got := out.Header.Get("traceparent"); got != wantTP {
+		t.Errorf("got traceparent %q, want %q", got, wantTP)
+	}
+	if got, want := out.Header.Get("tracestate"), "vendor=value"; got != want {
+		t.Errorf("got tracestate %q, want %q", got, want)
+	}
+	if got, want := out.Header.Get(httpHeader), fmt.Sprintf("%s/%d;o=1", w3cTraceID, child.span.SpanId); got != want {
+		t.Errorf("got %s %q, want %q", httpHeader, got, want)
+	}
+
+	// SpanFromHeader accepts either format.
+	span = tc.SpanFromHeader("name", w3cParent)
+	if got := span.TraceID(); got != w3cTraceID {
+		t.Errorf("SpanFromHeader: got trace ID %q, want %q", got, w3cTraceID)
+	}
+	if got, want := span.Traceparent(), fmt.Sprintf("00-%s-%016x-01", w3cTraceID, span.span.SpanId); got != want {
+		t.Errorf("got Traceparent %q, want %q", got, want)
+	}
+}
+
+func TestCloudHeaderPreferred(t *testing.T) {
+	tc := newTestClient(&noopTransport{})
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set(httpHeader, "0123456789ABCDEF0123456789ABCDEF/42;o=1")
+	req.Header.Set("traceparent", w3cParent)
+	req.Header.Set("tracestate", "vendor=value")
+	span := tc.SpanFromRequest(req)
+	if got, want := span.TraceID(), "0123456789ABCDEF0123456789ABCDEF"; got != want {
+		t.Errorf("got trace ID %q, want %q", got, want)
+	}
+
+	// The tracestate belongs to a different trace, so it is dropped. The trace
+	// ID is converted to lower case for the traceparent header.
+	out, _ := http.NewRequest("GET", "http://example.com/bar", nil)
+	span.NewRemoteChild(out)
+	if re := regexp.MustCompile("^00-0123456789abcdef0123456789abcdef-[0-9a-f]{16}-01$"); !re.MatchString(out.Header.Get("traceparent")) {
+		t.Errorf("got traceparent %q, want match for %s", out.Header.Get("traceparent"), re)
+	}
+	if got := out.Header.Get("tracestate"); got != "" {
+		t.Errorf("got tracestate %q, want none", got)
+	}
+
+	// Untraced spans without a parent propagate no traceparent header.
+	span = tc.SpanFromRequest(&http.Request{Header: http.Header{}, URL: req.URL})
+	out, _ = http.NewRequest("GET", "http://example.com/bar", nil)
+	span.NewRemoteChild(out)
+	if got := out.Header.Get("traceparent"); got != "" {
+		t.Errorf("got traceparent %q, want none", got)
+	}
+}
+
+func TestParentFromMetadata(t *testing.T) {
+	md := metadata.Pairs("traceparent", w3cParent, "tracestate", "a=1")
+	p, ok := parentFromMetadata(metadata.NewIncomingContext(context.Background(), md))
+	want := remoteParent{traceID: w3cTraceID, spanID: 0x00f067aa0ba902b7, options: optionTrace, optionsOk: true, tracestate: "a=1"}
+	if !ok || p != want {
+		t.Errorf("got (%+v, %t), want (%+v, true)", p, ok, want)
+	}
+	if _, ok := parentFromMetadata(context.Background()); ok {
+		t.Error("no metadata: got ok, want not ok")
+	}
+}
@@ -54,12 +54,13 @@ func (RXWindow) EnumDescriptor() ([]byte, []int) {
 type ErrorType int32
 
 const (
-	ErrorType_GENERIC                ErrorType = 0
-	ErrorType_OTAA                   ErrorType = 1
-	ErrorType_DATA_UP_FCNT           ErrorType = 2
-	ErrorType_DATA_UP_MIC            ErrorType = 3
-	ErrorType_DEVICE_QUEUE_ITEM_SIZE ErrorType = 4
-	ErrorType_DEVICE_QUEUE_ITEM_FCNT ErrorType = 5
+	ErrorType_GENERIC                   ErrorType = 0
+	ErrorType_OTAA                      ErrorType = 1
+	ErrorType_DATA_UP_FCNT              ErrorType = 2
+	ErrorType_DATA_UP_MIC               ErrorType = 3
+	ErrorType_DEVICE_QUEUE_ITEM_SIZE    ErrorType = 4
+	ErrorType_DEVICE_QUEUE_ITEM_FCNT    ErrorType = 5
+	ErrorType_DEVICE_QUEUE_ITEM_EXPIRED ErrorType = 6
 )
 
 var ErrorType_name = map[int32]string{
@@ -69,15 +70,17 @@ var ErrorType_name = map[int32]string{
 	3: "DATA_UP_MIC",
 	4: "DEVICE_QUEUE_ITEM_SIZE",
 	5: "DEVICE_QUEUE_ITEM_FCNT",
+	6: "DEVICE_QUEUE_ITEM_EXPIRED",
 }
 
 var ErrorType_value = map[string]int32{
-	"GENERIC":                0,
-	"OTAA":                   1,
-	"DATA_UP_FCNT":           2,
-	"DATA_UP_MIC":            3,
-	"DEVICE_QUEUE_ITEM_SIZE": 4,
-	"DEVICE_QUEUE_ITEM_FCNT": 5,
+	"GENERIC":                   0,
+	"OTAA":                      1,
+	"DATA_UP_FCNT":              2,
+	"DATA_UP_MIC":               3,
+	"DEVICE_QUEUE_ITEM_SIZE":    4,
+	"DEVICE_QUEUE_ITEM_FCNT":    5,
+	"DEVICE_QUEUE_ITEM_EXPIRED": 6,
 }
 
 func (x ErrorType) String() string {
@@ -584,6 +587,207 @@ func (m *SetDeviceLocationRequest) GetLocation() *common.Location {
 	return nil
 }
 
+type ReEncryptDeviceQueueItemsRequest struct {
+	// Device EUI (8 bytes).
+	DevEui []byte `protobuf:"bytes,1,opt,name=dev_eui,json=devEui,proto3" json:"dev_eui,omitempty"`
+	// Device-queue items to re-encrypt.
+	Items                []*ReEncryptDeviceQueueItem `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
+	XXX_unrecognized     []byte                      `json:"-"`
+	XXX_sizecache        int32                       `json:"-"`
+}
+
+func (m *ReEncryptDeviceQueueItemsRequest) Reset()         { *m = ReEncryptDeviceQueueItemsRequest{} }
+func (m *ReEncryptDeviceQueueItemsRequest) String() string { return proto.CompactTextString(m) }
+func (*ReEncryptDeviceQueueItemsRequest) ProtoMessage()    {}
+func (*ReEncryptDeviceQueueItemsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_426943aecdb4a493, []int{7}
+}
+func (m *ReEncryptDeviceQueueItemsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsRequest.Unmarshal(m, b)
+}
+func (m *ReEncryptDeviceQueueItemsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReEncryptDeviceQueueItemsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReEncryptDeviceQueueItemsRequest.Merge(dst, src)
+}
+func (m *ReEncryptDeviceQueueItemsRequest) XXX_Size() int {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsRequest.Size(m)
+}
+func (m *ReEncryptDeviceQueueItemsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReEncryptDeviceQueueItemsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReEncryptDeviceQueueItemsRequest proto.InternalMessageInfo
+
+func (m *ReEncryptDeviceQueueItemsRequest) GetDevEui() []byte {
+	if m != nil {
+		return m.DevEui
+	}
+	return nil
+}
+
+func (m *ReEncryptDeviceQueueItemsRequest) GetItems() []*ReEncryptDeviceQueueItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type ReEncryptDeviceQueueItem struct {
+	// The encrypted FRMPayload bytes.
+	FrmPayload []byte `protobuf:"bytes,1,opt,name=frm_payload,json=frmPayload,proto3" json:"frm_payload,omitempty"`
+	// The frame-counter used to encrypt the FRMPayload.
+	FCnt uint32 `protobuf:"varint,2,opt,name=f_cnt,json=fCnt,proto3" json:"f_cnt,omitempty"`
+	// The FPort of the payload.
+	FPort uint32 `protobuf:"varint,3,opt,name=f_port,json=fPort,proto3" json:"f_port,omitempty"`
+	// The frame-counter to re-encrypt the FRMPayload with.
+	NewFCnt              uint32   `protobuf:"varint,4,opt,name=new_f_cnt,json=newFCnt,proto3" json:"new_f_cnt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReEncryptDeviceQueueItem) Reset()         { *m = ReEncryptDeviceQueueItem{} }
+func (m *ReEncryptDeviceQueueItem) String() string { return proto.CompactTextString(m) }
+func (*ReEncryptDeviceQueueItem) ProtoMessage()    {}
+func (*ReEncryptDeviceQueueItem) Descriptor() ([]byte, []int) {
+	return fileDescriptor_426943aecdb4a493, []int{8}
+}
+func (m *ReEncryptDeviceQueueItem) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReEncryptDeviceQueueItem.Unmarshal(m, b)
+}
+func (m *ReEncryptDeviceQueueItem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReEncryptDeviceQueueItem.Marshal(b, m, deterministic)
+}
+func (dst *ReEncryptDeviceQueueItem) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReEncryptDeviceQueueItem.Merge(dst, src)
+}
+func (m *ReEncryptDeviceQueueItem) XXX_Size() int {
+	return xxx_messageInfo_ReEncryptDeviceQueueItem.Size(m)
+}
+func (m *ReEncryptDeviceQueueItem) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReEncryptDeviceQueueItem.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReEncryptDeviceQueueItem proto.InternalMessageInfo
+
+func (m *ReEncryptDeviceQueueItem) GetFrmPayload() []byte {
+	if m != nil {
+		return m.FrmPayload
+	}
+	return nil
+}
+
+func (m *ReEncryptDeviceQueueItem) GetFCnt() uint32 {
+	if m != nil {
+		return m.FCnt
+	}
+	return 0
+}
+
+func (m *ReEncryptDeviceQueueItem) GetFPort() uint32 {
+	if m != nil {
+		return m.FPort
+	}
+	return 0
+}
+
+func (m *ReEncryptDeviceQueueItem) GetNewFCnt() uint32 {
+	if m != nil {
+		return m.NewFCnt
+	}
+	return 0
+}
+
+type ReEncryptDeviceQueueItemsResponse struct {
+	// Re-encrypted device-queue items, in the order of the request items.
+	Items                []*ReEncryptedDeviceQueueItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *ReEncryptDeviceQueueItemsResponse) Reset()         { *m = ReEncryptDeviceQueueItemsResponse{} }
+func (m *ReEncryptDeviceQueueItemsResponse) String() string { return proto.CompactTextString(m) }
+func (*ReEncryptDeviceQueueItemsResponse) ProtoMessage()    {}
+func (*ReEncryptDeviceQueueItemsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_426943aecdb4a493, []int{9}
+}
+func (m *ReEncryptDeviceQueueItemsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsResponse.Unmarshal(m, b)
+}
+func (m *ReEncryptDeviceQueueItemsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsResponse.Marshal(b, m, deterministic)
+}
+func (dst *ReEncryptDeviceQueueItemsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReEncryptDeviceQueueItemsResponse.Merge(dst, src)
+}
+func (m *ReEncryptDeviceQueueItemsResponse) XXX_Size() int {
+	return xxx_messageInfo_ReEncryptDeviceQueueItemsResponse.Size(m)
+}
+func (m *ReEncryptDeviceQueueItemsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReEncryptDeviceQueueItemsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReEncryptDeviceQueueItemsResponse proto.InternalMessageInfo
+
+func (m *ReEncryptDeviceQueueItemsResponse) GetItems() []*ReEncryptedDeviceQueueItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type ReEncryptedDeviceQueueItem struct {
+	// The re-encrypted FRMPayload bytes.
+	FrmPayload []byte `protobuf:"bytes,1,opt,name=frm_payload,json=frmPayload,proto3" json:"frm_payload,omitempty"`
+	// The frame-counter used to encrypt the FRMPayload.
+	FCnt                 uint32   `protobuf:"varint,2,opt,name=f_cnt,json=fCnt,proto3" json:"f_cnt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReEncryptedDeviceQueueItem) Reset()         { *m = ReEncryptedDeviceQueueItem{} }
+func (m *ReEncryptedDeviceQueueItem) String() string { return proto.CompactTextString(m) }
+func (*ReEncryptedDeviceQueueItem) ProtoMessage()    {}
+func (*ReEncryptedDeviceQueueItem) Descriptor() ([]byte, []int) {
+	return fileDescriptor_426943aecdb4a493, []int{10}
+}
+func (m *ReEncryptedDeviceQueueItem) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReEncryptedDeviceQueueItem.Unmarshal(m, b)
+}
+func (m *ReEncryptedDeviceQueueItem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReEncryptedDeviceQueueItem.Marshal(b, m, deterministic)
+}
+func (dst *ReEncryptedDeviceQueueItem) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReEncryptedDeviceQueueItem.Merge(dst, src)
+}
+func (m *ReEncryptedDeviceQueueItem) XXX_Size() int {
+	return xxx_messageInfo_ReEncryptedDeviceQueueItem.Size(m)
+}
+func (m *ReEncryptedDeviceQueueItem) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReEncryptedDeviceQueueItem.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReEncryptedDeviceQueueItem proto.InternalMessageInfo
+
+func (m *ReEncryptedDeviceQueueItem) GetFrmPayload() []byte {
+	if m != nil {
+		return m.FrmPayload
+	}
+	return nil
+}
+
+func (m *ReEncryptedDeviceQueueItem) GetFCnt() uint32 {
+	if m != nil {
+		return m.FCnt
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*DeviceActivationContext)(nil), "as.DeviceActivationContext")
 	proto.RegisterType((*HandleUplinkDataRequest)(nil), "as.HandleUplinkDataRequest")
@@ -592,6 +796,10 @@ func init() {
 	proto.RegisterType((*HandleDownlinkACKRequest)(nil), "as.HandleDownlinkACKRequest")
 	proto.RegisterType((*SetDeviceStatusRequest)(nil), "as.SetDeviceStatusRequest")
 	proto.RegisterType((*SetDeviceLocationRequest)(nil), "as.SetDeviceLocationRequest")
+	proto.RegisterType((*ReEncryptDeviceQueueItemsRequest)(nil), "as.ReEncryptDeviceQueueItemsRequest")
+	proto.RegisterType((*ReEncryptDeviceQueueItem)(nil), "as.ReEncryptDeviceQueueItem")
+	proto.RegisterType((*ReEncryptDeviceQueueItemsResponse)(nil), "as.ReEncryptDeviceQueueItemsResponse")
+	proto.RegisterType((*ReEncryptedDeviceQueueItem)(nil), "as.ReEncryptedDeviceQueueItem")
 	proto.RegisterEnum("as.RXWindow", RXWindow_name, RXWindow_value)
 	proto.RegisterEnum("as.ErrorType", ErrorType_name, ErrorType_value)
 }
@@ -620,6 +828,9 @@ type ApplicationServerServiceClient interface {
 	SetDeviceStatus(ctx context.Context, in *SetDeviceStatusRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	// SetDeviceLocation updates the device-location for a device.
 	SetDeviceLocation(ctx context.Context, in *SetDeviceLocationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// ReEncryptDeviceQueueItems re-encrypts the given device-queue items
+	// using their new frame-counter.
+	ReEncryptDeviceQueueItems(ctx context.Context, in *ReEncryptDeviceQueueItemsRequest, opts ...grpc.CallOption) (*ReEncryptDeviceQueueItemsResponse, error)
 }
 
 type applicationServerServiceClient struct {
@@ -684,6 +895,15 @@ func (c *applicationServerServiceClient) SetDeviceLocation(ctx context.Context,
 	return out, nil
 }
 
+func (c *applicationServerServiceClient) ReEncryptDeviceQueueItems(ctx context.Context, in *ReEncryptDeviceQueueItemsRequest, opts ...grpc.CallOption) (*ReEncryptDeviceQueueItemsResponse, error) {
+	out := new(ReEncryptDeviceQueueItemsResponse)
+	err := c.cc.Invoke(ctx, "/as.ApplicationServerService/ReEncryptDeviceQueueItems", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ApplicationServerServiceServer is the server API for ApplicationServerService service.
 type ApplicationServerServiceServer interface {
 	// HandleUplinkData handles uplink data received from an end-device.
@@ -698,6 +918,9 @@ type ApplicationServerServiceServer interface {
 	SetDeviceStatus(context.Context, *SetDeviceStatusRequest) (*empty.Empty, error)
 	// SetDeviceLocation updates the device-location for a device.
 	SetDeviceLocation(context.Context, *SetDeviceLocationRequest) (*empty.Empty, error)
+	// ReEncryptDeviceQueueItems re-encrypts the given device-queue items
+	// using their new frame-counter.
+	ReEncryptDeviceQueueItems(context.Context, *ReEncryptDeviceQueueItemsRequest) (*ReEncryptDeviceQueueItemsResponse, error)
 }
 
 func RegisterApplicationServerServiceServer(s *grpc.Server, srv ApplicationServerServiceServer) {
@@ -812,6 +1035,24 @@ func _ApplicationServerService_SetDeviceLocation_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ApplicationServerService_ReEncryptDeviceQueueItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReEncryptDeviceQueueItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServerServiceServer).ReEncryptDeviceQueueItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/as.ApplicationServerService/ReEncryptDeviceQueueItems",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServerServiceServer).ReEncryptDeviceQueueItems(ctx, req.(*ReEncryptDeviceQueueItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ApplicationServerService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "as.ApplicationServerService",
 	HandlerType: (*ApplicationServerServiceServer)(nil),
@@ -840,6 +1081,10 @@ var _ApplicationServerService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetDeviceLocation",
 			Handler:    _ApplicationServerService_SetDeviceLocation_Handler,
 		},
+		{
+			MethodName: "ReEncryptDeviceQueueItems",
+			Handler:    _ApplicationServerService_ReEncryptDeviceQueueItems_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "as.proto",
@@ -848,62 +1093,71 @@ var _ApplicationServerService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("as.proto", fileDescriptor_426943aecdb4a493) }
 
 var fileDescriptor_426943aecdb4a493 = []byte{
-	// 907 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x5f, 0x93, 0xe2, 0xc4,
-	0x17, 0x1d, 0xfe, 0x33, 0x97, 0x99, 0xdd, 0xfc, 0x7a, 0x7e, 0x0b, 0x19, 0x76, 0x4b, 0x31, 0xbe,
-	0x8c, 0x5b, 0x1a, 0x4a, 0x2c, 0x5f, 0x7c, 0xb1, 0x28, 0x88, 0x2b, 0x35, 0xbb, 0x2b, 0x06, 0x70,
-	0xa6, 0x7c, 0xe9, 0x6a, 0x92, 0x0b, 0xc6, 0x09, 0xe9, 0xd8, 0x34, 0x30, 0x29, 0xcb, 0x0f, 0xe4,
-	0x97, 0xf2, 0xc1, 0x4f, 0xe1, 0xa3, 0x95, 0x4e, 0xf8, 0x33, 0x3b, 0x03, 0xf8, 0x02, 0xdd, 0xf7,
-	0x9e, 0x3e, 0x7d, 0x72, 0x72, 0xef, 0x0d, 0x94, 0xd9, 0xdc, 0x0c, 0x05, 0x97, 0x9c, 0x64, 0xd9,
-	0xbc, 0xfe, 0x72, 0xca, 0xf9, 0xd4, 0xc7, 0xa6, 0x8a, 0x8c, 0x17, 0x93, 0x26, 0xce, 0x42, 0x19,
-	0x25, 0x80, 0xfa, 0xd7, 0x53, 0x4f, 0xfe, 0xb2, 0x18, 0x9b, 0x0e, 0x9f, 0x35, 0xc7, 0x82, 0x3b,
-	0x8c, 0x89, 0xa6, 0xcf, 0x05, 0x9b, 0xa3, 0x58, 0xa2, 0x68, 0xb2, 0xd0, 0x6b, 0x3a, 0x7c, 0x36,
-	0xe3, 0x41, 0xfa, 0x97, 0x1e, 0xfb, 0xe2, 0xf8, 0xb1, 0xe9, 0xaa, 0x39, 0x5d, 0x25, 0x70, 0x03,
-	0xa1, 0xd6, 0xc5, 0xa5, 0xe7, 0x60, 0xdb, 0x91, 0xde, 0x92, 0x49, 0x8f, 0x07, 0x1d, 0x1e, 0x48,
-	0xbc, 0x97, 0xe4, 0x12, 0xca, 0x2e, 0x2e, 0x29, 0x73, 0x5d, 0xa1, 0x67, 0x1a, 0x99, 0xab, 0x33,
-	0xbb, 0xe4, 0xe2, 0xb2, 0xed, 0xba, 0x82, 0x34, 0xe1, 0x94, 0x85, 0x21, 0x9d, 0xd3, 0x3b, 0x8c,
-	0xf4, 0x6c, 0x23, 0x73, 0x55, 0x69, 0x5d, 0x98, 0xa9, 0x8c, 0x6b, 0x8c, 0xac, 0x60, 0x89, 0x3e,
-	0x0f, 0xd1, 0x2e, 0xb1, 0x30, 0x1c, 0x5c, 0x63, 0x64, 0xfc, 0x95, 0x85, 0xda, 0xf7, 0x2c, 0x70,
-	0x7d, 0x1c, 0x85, 0xbe, 0x17, 0xdc, 0x75, 0x99, 0x64, 0x36, 0xfe, 0xb6, 0xc0, 0xb9, 0x24, 0x35,
-	0x88, 0x79, 0x29, 0x2e, 0xbc, 0xf4, 0x9a, 0xa2, 0x8b, 0x4b, 0x6b, 0xe1, 0xc5, 0x02, 0x7e, 0xe5,
-	0x5e, 0xa0, 0x32, 0xd9, 0x44, 0x40, 0xbc, 0x8f, 0x53, 0x17, 0x50, 0x98, 0x50, 0x27, 0x90, 0x7a,
-	0xae, 0x91, 0xb9, 0x3a, 0xb7, 0xf3, 0x93, 0x4e, 0x20, 0xc9, 0x0b, 0x28, 0x4e, 0x68, 0xc8, 0x85,
-	0xd4, 0xf3, 0x2a, 0x5a, 0x98, 0xf4, 0xb9, 0x90, 0x44, 0x83, 0x1c, 0x73, 0x85, 0x5e, 0x68, 0x64,
-	0xae, 0xca, 0x76, 0xbc, 0x24, 0xcf, 0x20, 0xeb, 0x0a, 0xbd, 0xa8, 0x40, 0x59, 0x57, 0x90, 0xcf,
-	0xa0, 0x24, 0xef, 0xa9, 0x17, 0x4c, 0xb8, 0x5e, 0x52, 0x0f, 0xa3, 0x99, 0xd3, 0x95, 0x99, 0x28,
-	0x1d, 0xde, 0xf6, 0x82, 0x09, 0xb7, 0x8b, 0xf2, 0x3e, 0xfe, 0x8f, 0xa1, 0x22, 0x85, 0x96, 0x1b,
-	0xb9, 0x87, 0x50, 0x3b, 0x85, 0x8a, 0x04, 0x4a, 0x20, 0xef, 0x32, 0xc9, 0xf4, 0x53, 0x25, 0x5d,
-	0xad, 0xc9, 0x0d, 0x5c, 0xba, 0xca, 0x6e, 0xca, 0x36, 0x7e, 0x53, 0x27, 0x31, 0x5c, 0x07, 0x75,
-	0xf7, 0x4b, 0x93, 0xcd, 0xcd, 0x3d, 0xef, 0xc4, 0xae, 0xb9, 0x4f, 0x27, 0x8c, 0x3f, 0x33, 0xf0,
-	0x51, 0x62, 0x70, 0x5f, 0xf0, 0x50, 0x78, 0x28, 0x99, 0x88, 0x52, 0x59, 0xa9, 0xcf, 0x1f, 0x43,
-	0x65, 0xc6, 0x1c, 0x1a, 0xb2, 0xc8, 0xe7, 0xcc, 0x4d, 0xbd, 0x86, 0x19, 0x73, 0xfa, 0x49, 0x24,
-	0x36, 0x6a, 0xe6, 0x39, 0xa9, 0xd5, 0xf1, 0x72, 0xd7, 0x98, 0xdc, 0x7f, 0x37, 0x26, 0x7f, 0xd8,
-	0x18, 0xe3, 0x77, 0x20, 0x89, 0x54, 0x4b, 0x08, 0x2e, 0x8e, 0x96, 0xc1, 0x27, 0x90, 0x97, 0x51,
-	0x88, 0x4a, 0xc1, 0xb3, 0xd6, 0x79, 0x6c, 0x8f, 0x3a, 0x38, 0x8c, 0x42, 0xb4, 0x55, 0x8a, 0xfc,
-	0x1f, 0x0a, 0x18, 0x87, 0xd4, 0x8b, 0x3f, 0xb5, 0x93, 0xcd, 0xb6, 0x48, 0x0a, 0xdb, 0x22, 0x31,
-	0x7c, 0xd0, 0x93, 0xcb, 0xbb, 0x7c, 0x15, 0xc4, 0xe2, 0xda, 0x9d, 0xeb, 0xa3, 0x12, 0x36, 0x4c,
-	0xd9, 0x9d, 0x72, 0x33, 0xe0, 0x8c, 0x39, 0x77, 0x01, 0x5f, 0xf9, 0xe8, 0x4e, 0xd1, 0x55, 0xfa,
-	0xca, 0xf6, 0x83, 0x98, 0xf1, 0x4f, 0x06, 0xaa, 0x03, 0x94, 0xc9, 0xeb, 0x1c, 0x48, 0x26, 0x17,
-	0xf3, 0xa3, 0x97, 0xe9, 0x50, 0x1a, 0x33, 0x29, 0x51, 0x44, 0xe9, 0x75, 0xeb, 0x2d, 0xa9, 0x42,
-	0x71, 0xc6, 0xc4, 0xd4, 0x0b, 0xd4, 0x5d, 0x05, 0x3b, 0xdd, 0x91, 0x16, 0xbc, 0xc0, 0x7b, 0x89,
-	0x22, 0x60, 0x3e, 0x0d, 0xf9, 0x0a, 0x05, 0x9d, 0xf3, 0x85, 0x70, 0x50, 0xd9, 0x51, 0xb6, 0x2f,
-	0xd6, 0xc9, 0x7e, 0x9c, 0x1b, 0xa8, 0x14, 0xf9, 0x06, 0x2e, 0x53, 0x5a, 0xea, 0xe3, 0x12, 0x7d,
-	0xba, 0x08, 0xd8, 0x92, 0x79, 0x3e, 0x1b, 0xfb, 0x98, 0xf6, 0x4a, 0x2d, 0x05, 0xbc, 0x8d, 0xf3,
-	0xa3, 0x6d, 0x9a, 0x7c, 0x0a, 0xe7, 0x0f, 0xce, 0xaa, 0x56, 0xca, 0xda, 0x67, 0xbb, 0x78, 0x83,
-	0x81, 0xbe, 0x79, 0xf2, 0xb7, 0xdc, 0x51, 0xd5, 0x7a, 0xf4, 0xd9, 0x3f, 0x87, 0xb2, 0x9f, 0x62,
-	0xd3, 0xb9, 0xa2, 0xad, 0xe7, 0xca, 0x86, 0x63, 0x83, 0x78, 0xfd, 0x0a, 0xca, 0xf6, 0xed, 0x8d,
-	0x17, 0xb8, 0x7c, 0x45, 0x4a, 0x90, 0xb3, 0x6f, 0xbf, 0xd4, 0x4e, 0x92, 0x45, 0x4b, 0xcb, 0xbc,
-	0xfe, 0x03, 0x4e, 0x37, 0x75, 0x42, 0x2a, 0x50, 0x7a, 0x63, 0xbd, 0xb7, 0xec, 0x5e, 0x47, 0x3b,
-	0x21, 0x65, 0xc8, 0xff, 0x30, 0x6c, 0xb7, 0xb5, 0x0c, 0xd1, 0xe0, 0xac, 0xdb, 0x1e, 0xb6, 0xe9,
-	0xa8, 0x4f, 0xbf, 0xeb, 0xbc, 0x1f, 0x6a, 0x59, 0xf2, 0x1c, 0x2a, 0xeb, 0xc8, 0xbb, 0x5e, 0x47,
-	0xcb, 0x91, 0x3a, 0x54, 0xbb, 0xd6, 0x4f, 0xbd, 0x8e, 0x45, 0x7f, 0x1c, 0x59, 0x23, 0x8b, 0xf6,
-	0x86, 0xd6, 0x3b, 0x3a, 0xe8, 0xfd, 0x6c, 0x69, 0xf9, 0xa7, 0x73, 0x8a, 0xa8, 0xd0, 0xfa, 0x3b,
-	0x07, 0x7a, 0x3b, 0x0c, 0x7d, 0x2f, 0x11, 0x3b, 0x50, 0xf3, 0x37, 0xfe, 0xf5, 0x1c, 0x24, 0x3d,
-	0xd0, 0x3e, 0x1c, 0x87, 0x44, 0x35, 0xfe, 0x9e, 0x21, 0x59, 0xaf, 0x9a, 0xc9, 0xb7, 0xc2, 0x5c,
-	0x7f, 0x2b, 0x4c, 0x2b, 0xfe, 0x56, 0x18, 0x27, 0xe4, 0x66, 0x3d, 0x59, 0x1f, 0x35, 0x3e, 0x31,
-	0xb6, 0x8c, 0xfb, 0xa6, 0xc2, 0x01, 0xe2, 0x6f, 0xa1, 0xb2, 0xd3, 0xa6, 0xa4, 0xba, 0x25, 0xdb,
-	0xed, 0xdb, 0x03, 0x04, 0xd7, 0xf0, 0xbf, 0x47, 0xad, 0x46, 0x5e, 0x6d, 0x69, 0x1e, 0x77, 0xe0,
-	0x01, 0xb2, 0x37, 0xf0, 0xfc, 0x83, 0x46, 0x22, 0xf5, 0x98, 0xea, 0xe9, 0xee, 0x3a, 0xac, 0xea,
-	0x51, 0x5d, 0x26, 0xaa, 0xf6, 0x95, 0xeb, 0x7e, 0xb2, 0x71, 0x51, 0x45, 0xbe, 0xfa, 0x37, 0x00,
-	0x00, 0xff, 0xff, 0x17, 0xb3, 0x4b, 0xa1, 0xd8, 0x07, 0x00, 0x00,
+	// 1052 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0xdd, 0x72, 0xdb, 0xc4,
+	0x17, 0x8f, 0xfc, 0x9d, 0x93, 0xb4, 0xd5, 0x7f, 0xfb, 0x6f, 0xac, 0xb8, 0xa1, 0xb8, 0x02, 0x66,
+	0x42, 0x07, 0xec, 0xc1, 0xc0, 0x0d, 0x37, 0x8c, 0xc7, 0x56, 0x8b, 0x27, 0x6d, 0x71, 0x65, 0x87,
+	0x04, 0x6e, 0x34, 0x6b, 0xe9, 0xd8, 0xa8, 0x91, 0xb4, 0x62, 0xbd, 0xb6, 0xe3, 0xe1, 0x92, 0xd7,
+	0xe0, 0x05, 0x78, 0x19, 0x1e, 0x81, 0xe7, 0xe0, 0x92, 0xd9, 0x95, 0xfc, 0x91, 0x0f, 0xdb, 0x0c,
+	0x37, 0xf6, 0xee, 0x9e, 0xdf, 0x9e, 0x8f, 0x9f, 0xce, 0xf9, 0x2d, 0x94, 0xe8, 0xb8, 0x16, 0x73,
+	0x26, 0x18, 0xc9, 0xd0, 0x71, 0xe5, 0xe9, 0x88, 0xb1, 0x51, 0x80, 0x75, 0x75, 0x32, 0x98, 0x0c,
+	0xeb, 0x18, 0xc6, 0x62, 0x9e, 0x00, 0x2a, 0x5f, 0x8f, 0x7c, 0xf1, 0xf3, 0x64, 0x50, 0x73, 0x59,
+	0x58, 0x1f, 0x70, 0xe6, 0x52, 0xca, 0xeb, 0x01, 0xe3, 0x74, 0x8c, 0x7c, 0x8a, 0xbc, 0x4e, 0x63,
+	0xbf, 0xee, 0xb2, 0x30, 0x64, 0x51, 0xfa, 0x97, 0x5e, 0xfb, 0x7c, 0xf7, 0xb5, 0xd1, 0xac, 0x3e,
+	0x9a, 0x25, 0x70, 0x13, 0xa1, 0xdc, 0xc6, 0xa9, 0xef, 0x62, 0xd3, 0x15, 0xfe, 0x94, 0x0a, 0x9f,
+	0x45, 0x2d, 0x16, 0x09, 0xbc, 0x16, 0xe4, 0x18, 0x4a, 0x1e, 0x4e, 0x1d, 0xea, 0x79, 0xdc, 0xd0,
+	0xaa, 0xda, 0xe9, 0xa1, 0x5d, 0xf4, 0x70, 0xda, 0xf4, 0x3c, 0x4e, 0xea, 0xb0, 0x4f, 0xe3, 0xd8,
+	0x19, 0x3b, 0x57, 0x38, 0x37, 0x32, 0x55, 0xed, 0xf4, 0xa0, 0xf1, 0xb8, 0x96, 0xa6, 0x71, 0x86,
+	0x73, 0x2b, 0x9a, 0x62, 0xc0, 0x62, 0xb4, 0x8b, 0x34, 0x8e, 0x7b, 0x67, 0x38, 0x37, 0xff, 0xca,
+	0x40, 0xf9, 0x3b, 0x1a, 0x79, 0x01, 0x9e, 0xc7, 0x81, 0x1f, 0x5d, 0xb5, 0xa9, 0xa0, 0x36, 0xfe,
+	0x32, 0xc1, 0xb1, 0x20, 0x65, 0x90, 0x7e, 0x1d, 0x9c, 0xf8, 0x69, 0x98, 0x82, 0x87, 0x53, 0x6b,
+	0xe2, 0xcb, 0x04, 0xde, 0x33, 0x3f, 0x52, 0x96, 0x4c, 0x92, 0x80, 0xdc, 0x4b, 0xd3, 0x63, 0xc8,
+	0x0f, 0x1d, 0x37, 0x12, 0x46, 0xb6, 0xaa, 0x9d, 0x3e, 0xb0, 0x73, 0xc3, 0x56, 0x24, 0xc8, 0x13,
+	0x28, 0x0c, 0x9d, 0x98, 0x71, 0x61, 0xe4, 0xd4, 0x69, 0x7e, 0xd8, 0x65, 0x5c, 0x10, 0x1d, 0xb2,
+	0xd4, 0xe3, 0x46, 0xbe, 0xaa, 0x9d, 0x96, 0x6c, 0xb9, 0x24, 0x0f, 0x21, 0xe3, 0x71, 0xa3, 0xa0,
+	0x40, 0x19, 0x8f, 0x93, 0x4f, 0xa1, 0x28, 0xae, 0x1d, 0x3f, 0x1a, 0x32, 0xa3, 0xa8, 0x8a, 0xd1,
+	0x6b, 0xa3, 0x59, 0x2d, 0xc9, 0xb4, 0x7f, 0xd9, 0x89, 0x86, 0xcc, 0x2e, 0x88, 0x6b, 0xf9, 0x2f,
+	0xa1, 0x3c, 0x85, 0x96, 0xaa, 0xd9, 0x9b, 0x50, 0x3b, 0x85, 0xf2, 0x04, 0x4a, 0x20, 0xe7, 0x51,
+	0x41, 0x8d, 0x7d, 0x95, 0xba, 0x5a, 0x93, 0x0b, 0x38, 0xf6, 0x14, 0xdd, 0x0e, 0x5d, 0xf2, 0xed,
+	0xb8, 0x09, 0xe1, 0x06, 0xa8, 0xd8, 0x4f, 0x6b, 0x74, 0x5c, 0xdb, 0xf0, 0x4d, 0xec, 0xb2, 0x77,
+	0xbf, 0xc1, 0xfc, 0x43, 0x83, 0x67, 0x09, 0xc1, 0x5d, 0xce, 0x62, 0xee, 0xa3, 0xa0, 0x7c, 0x9e,
+	0xa6, 0x95, 0xf2, 0xfc, 0x21, 0x1c, 0x84, 0xd4, 0x75, 0x62, 0x3a, 0x0f, 0x18, 0xf5, 0x52, 0xae,
+	0x21, 0xa4, 0x6e, 0x37, 0x39, 0x91, 0x44, 0x85, 0xbe, 0x9b, 0x52, 0x2d, 0x97, 0xeb, 0xc4, 0x64,
+	0xff, 0x3d, 0x31, 0xb9, 0xed, 0xc4, 0x98, 0xbf, 0x02, 0x49, 0x52, 0xb5, 0x38, 0x67, 0x7c, 0x67,
+	0x1b, 0x3c, 0x87, 0x9c, 0x98, 0xc7, 0xa8, 0x32, 0x78, 0xd8, 0x78, 0x20, 0xe9, 0x51, 0x17, 0xfb,
+	0xf3, 0x18, 0x6d, 0x65, 0x22, 0xff, 0x87, 0x3c, 0xca, 0x23, 0xf5, 0xe1, 0xf7, 0xed, 0x64, 0xb3,
+	0x6a, 0x92, 0xfc, 0xaa, 0x49, 0xcc, 0x00, 0x8c, 0x24, 0x78, 0x9b, 0xcd, 0x22, 0x99, 0x5c, 0xb3,
+	0x75, 0xb6, 0x33, 0x85, 0xa5, 0xa7, 0xcc, 0x5a, 0xbb, 0x99, 0x70, 0x48, 0xdd, 0xab, 0x88, 0xcd,
+	0x02, 0xf4, 0x46, 0xe8, 0xa9, 0xfc, 0x4a, 0xf6, 0x8d, 0x33, 0xf3, 0x6f, 0x0d, 0x8e, 0x7a, 0x28,
+	0x92, 0xcf, 0xd9, 0x13, 0x54, 0x4c, 0xc6, 0x3b, 0x83, 0x19, 0x50, 0x1c, 0x50, 0x21, 0x90, 0xcf,
+	0xd3, 0x70, 0x8b, 0x2d, 0x39, 0x82, 0x42, 0x48, 0xf9, 0xc8, 0x8f, 0x54, 0xac, 0xbc, 0x9d, 0xee,
+	0x48, 0x03, 0x9e, 0xe0, 0xb5, 0x40, 0x1e, 0xd1, 0xc0, 0x89, 0xd9, 0x0c, 0xb9, 0x33, 0x66, 0x13,
+	0xee, 0xa2, 0xa2, 0xa3, 0x64, 0x3f, 0x5e, 0x18, 0xbb, 0xd2, 0xd6, 0x53, 0x26, 0xf2, 0x0d, 0x1c,
+	0xa7, 0x6e, 0x9d, 0x00, 0xa7, 0x18, 0x38, 0x93, 0x88, 0x4e, 0xa9, 0x1f, 0xd0, 0x41, 0x80, 0xe9,
+	0xac, 0x94, 0x53, 0xc0, 0x6b, 0x69, 0x3f, 0x5f, 0x99, 0xc9, 0x47, 0xf0, 0xe0, 0xc6, 0x5d, 0x35,
+	0x4a, 0x19, 0xfb, 0x70, 0x1d, 0x6f, 0x52, 0x30, 0x96, 0x95, 0xbf, 0x66, 0xae, 0xea, 0xd6, 0x9d,
+	0xb5, 0x7f, 0x06, 0xa5, 0x20, 0xc5, 0xa6, 0xba, 0xa2, 0x2f, 0x74, 0x65, 0xe9, 0x63, 0x89, 0x30,
+	0x19, 0x54, 0x6d, 0xb4, 0x22, 0x97, 0xcf, 0xe3, 0x34, 0xd0, 0xbb, 0x09, 0x4e, 0xb0, 0x23, 0x30,
+	0xdc, 0x4d, 0x73, 0x03, 0xf2, 0xbe, 0x04, 0x1a, 0x19, 0xd5, 0xae, 0x27, 0xb2, 0xaf, 0x36, 0x79,
+	0xb3, 0x13, 0xa8, 0xf9, 0x9b, 0x06, 0xc6, 0x26, 0x8c, 0x9c, 0xaf, 0x21, 0x0f, 0x6f, 0xcf, 0xd7,
+	0x90, 0x87, 0x8b, 0xf9, 0xba, 0xb7, 0x8b, 0x56, 0xa2, 0x95, 0x5d, 0x17, 0xad, 0x0a, 0xec, 0x47,
+	0x38, 0x73, 0x12, 0x7c, 0x22, 0x67, 0xc5, 0x08, 0x67, 0x2f, 0x65, 0x0b, 0xff, 0x08, 0xcf, 0xb7,
+	0x94, 0x3d, 0x8e, 0x59, 0x34, 0x46, 0xf2, 0xd5, 0xa2, 0x3c, 0x4d, 0x95, 0xf7, 0xec, 0x46, 0x79,
+	0xe8, 0x6d, 0x28, 0xd0, 0x86, 0xca, 0x66, 0xd0, 0x7f, 0xab, 0xf0, 0xc5, 0x09, 0x94, 0xec, 0xcb,
+	0x0b, 0x3f, 0xf2, 0xd8, 0x8c, 0x14, 0x21, 0x6b, 0x5f, 0x7e, 0xa1, 0xef, 0x25, 0x8b, 0x86, 0xae,
+	0xbd, 0xf8, 0x5d, 0x83, 0xfd, 0xe5, 0x38, 0x93, 0x03, 0x28, 0xbe, 0xb2, 0xde, 0x5a, 0x76, 0xa7,
+	0xa5, 0xef, 0x91, 0x12, 0xe4, 0xbe, 0xef, 0x37, 0x9b, 0xba, 0x46, 0x74, 0x38, 0x6c, 0x37, 0xfb,
+	0x4d, 0xe7, 0xbc, 0xeb, 0xbc, 0x6c, 0xbd, 0xed, 0xeb, 0x19, 0xf2, 0x08, 0x0e, 0x16, 0x27, 0x6f,
+	0x3a, 0x2d, 0x3d, 0x4b, 0x2a, 0x70, 0xd4, 0xb6, 0x7e, 0xe8, 0xb4, 0x2c, 0xe7, 0xdd, 0xb9, 0x75,
+	0x6e, 0x39, 0x9d, 0xbe, 0xf5, 0xc6, 0xe9, 0x75, 0x7e, 0xb2, 0xf4, 0xdc, 0xfd, 0x36, 0xe5, 0x28,
+	0x4f, 0x3e, 0x80, 0xe3, 0xbb, 0x36, 0xeb, 0xb2, 0xdb, 0xb1, 0xad, 0xb6, 0x5e, 0x68, 0xfc, 0x99,
+	0x03, 0xa3, 0x19, 0xc7, 0x81, 0x9f, 0xb4, 0x5c, 0x4f, 0xbd, 0xa2, 0xf2, 0xd7, 0x77, 0x91, 0x74,
+	0x40, 0xbf, 0xfd, 0xa8, 0x11, 0x25, 0xdf, 0x1b, 0x9e, 0xba, 0xca, 0x51, 0x2d, 0x79, 0xf1, 0x6b,
+	0x8b, 0x17, 0xbf, 0x66, 0xc9, 0x17, 0xdf, 0xdc, 0x23, 0x17, 0x50, 0xde, 0x20, 0xdf, 0xc4, 0x5c,
+	0x79, 0xdc, 0xa4, 0xed, 0x5b, 0x1c, 0x7f, 0x0b, 0x07, 0x6b, 0x62, 0x4b, 0x8e, 0x56, 0xce, 0xd6,
+	0xd5, 0x77, 0x8b, 0x83, 0x33, 0xf8, 0xdf, 0x1d, 0xc1, 0x24, 0x27, 0x2b, 0x37, 0x77, 0x75, 0x74,
+	0x8b, 0xb3, 0x57, 0xf0, 0xe8, 0x96, 0x1c, 0x92, 0x8a, 0x74, 0x75, 0xbf, 0x46, 0x6e, 0xcf, 0xea,
+	0x8e, 0xba, 0x24, 0x59, 0x6d, 0x12, 0x9d, 0x2d, 0xce, 0xde, 0xc3, 0xf1, 0xc6, 0x81, 0x22, 0x1f,
+	0x6f, 0x13, 0x86, 0x65, 0xa6, 0x9f, 0xec, 0x40, 0x25, 0x53, 0x69, 0xee, 0x0d, 0x0a, 0x2a, 0xfa,
+	0x97, 0xff, 0x0c, 0x00, 0x98, 0x2a, 0xe0, 0x73, 0x0a, 0x0a, 0x00, 0x00,
 }
@@ -2169,10 +2169,20 @@ type DeviceQueueItem struct {
 	FPort uint32 `protobuf:"varint,4,opt,name=f_port,json=fPort,proto3" json:"f_port,omitempty"`
 	// When set to true, LoRa Server will wait for the device to ack the
 	// received frame.
-	Confirmed            bool     `protobuf:"varint,5,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Confirmed bool `protobuf:"varint,5,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	// Priority of the payload.
+	// Items with a higher priority are sent before items with a lower
+	// priority. When an item overtakes items with a lower frame-counter,
+	// LoRa Server renumbers the queue in transmission order and requests the
+	// application-server to re-encrypt the renumbered payloads.
+	Priority uint32 `protobuf:"varint,6,opt,name=priority,proto3" json:"priority,omitempty"`
+	// The payload expires after this timestamp (optional).
+	// Expired items are removed from the queue and reported to the
+	// application-server.
+	ExpireAt             *timestamp.Timestamp `protobuf:"bytes,7,opt,name=expire_at,json=expireAt,proto3" json:"expire_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *DeviceQueueItem) Reset()         { *m = DeviceQueueItem{} }
@@ -2234,6 +2244,20 @@ func (m *DeviceQueueItem) GetConfirmed() bool {
 	return false
 }
 
+func (m *DeviceQueueItem) GetPriority() uint32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *DeviceQueueItem) GetExpireAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpireAt
+	}
+	return nil
+}
+
 type CreateDeviceQueueItemRequest struct {
 	Item                 *DeviceQueueItem `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
@@ -5799,194 +5823,195 @@ var _NetworkServerService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("ns.proto", fileDescriptor_3b280de855f92a4a) }
 
 var fileDescriptor_3b280de855f92a4a = []byte{
-	// 3016 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x5a, 0x4b, 0x73, 0xdb, 0xc8,
-	0xf1, 0x17, 0xa9, 0x77, 0x4b, 0xa4, 0xa1, 0x91, 0x1f, 0x34, 0x2d, 0xaf, 0x64, 0xac, 0x77, 0xad,
-	0xf5, 0x7a, 0xa9, 0xff, 0x5f, 0x5b, 0xae, 0xda, 0x47, 0xd6, 0x29, 0x2e, 0x45, 0xd9, 0x5a, 0xdb,
-	0xb2, 0x0d, 0x5a, 0xde, 0x57, 0x55, 0x10, 0x08, 0x18, 0xd2, 0x28, 0x11, 0x00, 0x17, 0x18, 0x8a,
-	0x56, 0xaa, 0x72, 0x48, 0xe5, 0x98, 0x43, 0x2e, 0xc9, 0x39, 0xc7, 0xe4, 0x92, 0xca, 0x3d, 0x1f,
-	0x21, 0x87, 0x5c, 0x72, 0xdb, 0xcf, 0x90, 0x53, 0x3e, 0x41, 0x6a, 0x30, 0x83, 0xc1, 0x83, 0x03,
-	0x90, 0x5e, 0xdb, 0xe5, 0x9c, 0x24, 0x4c, 0x77, 0xff, 0xa6, 0xa7, 0xbb, 0x67, 0xa6, 0xa7, 0x9b,
-	0xb0, 0xe4, 0x06, 0x8d, 0x81, 0xef, 0x11, 0x0f, 0x95, 0xdd, 0xa0, 0xbe, 0xd9, 0xf3, 0xbc, 0x5e,
-	0x1f, 0xef, 0x84, 0x23, 0xc7, 0xc3, 0xee, 0x0e, 0xb1, 0x1d, 0x1c, 0x10, 0xc3, 0x19, 0x30, 0xa6,
-	0xfa, 0x95, 0x2c, 0x03, 0x76, 0x06, 0xe4, 0x8c, 0x13, 0x6f, 0xf7, 0x6c, 0xf2, 0x7c, 0x78, 0xdc,
-	0x30, 0x3d, 0x67, 0xe7, 0xd8, 0xf7, 0x4c, 0xc3, 0xf0, 0x77, 0xfa, 0x9e, 0x6f, 0x04, 0xd8, 0x3f,
-	0xc5, 0xfe, 0x8e, 0x31, 0xb0, 0x77, 0x4c, 0xcf, 0x71, 0x3c, 0x97, 0xff, 0xe1, 0x62, 0x1f, 0x4d,
-	0x16, 0xeb, 0x8d, 0x76, 0x7a, 0x23, 0xce, 0x5e, 0x1d, 0xf8, 0x5e, 0xd7, 0xee, 0x63, 0xae, 0xb7,
-	0xfa, 0x1d, 0x5c, 0x69, 0xf9, 0xd8, 0x20, 0xb8, 0x83, 0xfd, 0x53, 0xdb, 0xc4, 0x8f, 0x19, 0x59,
-	0xc3, 0x3f, 0x0c, 0x71, 0x40, 0xd0, 0xe7, 0x70, 0x2e, 0x60, 0x04, 0x9d, 0x0b, 0xd6, 0x4a, 0x5b,
-	0xa5, 0xed, 0x95, 0x5d, 0xd4, 0x70, 0x83, 0x46, 0x46, 0xa6, 0x1a, 0xa4, 0xbe, 0xd5, 0x06, 0x6c,
-	0xc8, 0xb1, 0x83, 0x81, 0xe7, 0x06, 0x18, 0x55, 0xa1, 0x6c, 0x5b, 0x21, 0xde, 0xaa, 0x56, 0xb6,
-	0x2d, 0xf5, 0x26, 0xd4, 0xee, 0x62, 0x22, 0x57, 0x24, 0xcb, 0xfb, 0xcf, 0x12, 0x5c, 0x96, 0x30,
-	0x73, 0xe4, 0x57, 0x51, 0x1b, 0x7d, 0x0a, 0x60, 0x86, 0x6a, 0x5b, 0xba, 0x41, 0x6a, 0xe5, 0x50,
-	0xae, 0xde, 0x60, 0xae, 0x6b, 0x44, 0xae, 0x6b, 0x3c, 0x8d, 0x7c, 0xab, 0x2d, 0x73, 0xee, 0x26,
-	0xa1, 0xa2, 0xc3, 0x81, 0x15, 0x89, 0xce, 0x4e, 0x16, 0xe5, 0xdc, 0x4d, 0x42, 0x1d, 0x71, 0x14,
-	0x7e, 0xbc, 0x01, 0x47, 0x7c, 0x04, 0x57, 0xf6, 0x70, 0x1f, 0xe7, 0x61, 0x67, 0x6d, 0x2b, 0x62,
-	0x42, 0xf3, 0x86, 0xc4, 0x76, 0x7b, 0xe3, 0xaa, 0xf8, 0x8c, 0x20, 0x53, 0x25, 0x23, 0x53, 0xf5,
-	0x53, 0xdf, 0x71, 0x4c, 0x64, 0xb1, 0x0b, 0x63, 0x42, 0xae, 0x48, 0x4e, 0x4c, 0xe4, 0x20, 0xbf,
-	0x8a, 0xda, 0x6f, 0x3b, 0x26, 0xde, 0x80, 0x23, 0x44, 0x4c, 0x4c, 0x67, 0xdb, 0x67, 0x50, 0x67,
-	0x7e, 0xdb, 0xc3, 0x92, 0x08, 0xfa, 0x04, 0xaa, 0x16, 0x96, 0x04, 0xe7, 0x1a, 0x55, 0x24, 0x2d,
-	0x51, 0xb1, 0x70, 0x26, 0x34, 0xa5, 0xb8, 0x39, 0xe1, 0xf0, 0x01, 0x5c, 0xba, 0x8b, 0x89, 0x54,
-	0x87, 0x2c, 0xeb, 0x3f, 0x4a, 0x61, 0xe8, 0xc8, 0x71, 0x7f, 0xb2, 0xc2, 0x6f, 0x29, 0x12, 0x9e,
-	0x41, 0x9d, 0x45, 0xc2, 0x6b, 0x36, 0xff, 0x2d, 0xa8, 0xb3, 0x28, 0x98, 0xca, 0xa4, 0xbf, 0x29,
-	0xc3, 0x02, 0x63, 0x44, 0x97, 0x60, 0xd1, 0xc2, 0xa7, 0x3a, 0x1e, 0xda, 0x9c, 0xbe, 0x60, 0xe1,
-	0xd3, 0xf6, 0xd0, 0x46, 0x37, 0x61, 0x2d, 0xad, 0x8b, 0x6e, 0x5b, 0xa1, 0x99, 0x56, 0xb5, 0x73,
-	0xa9, 0xb9, 0x0f, 0x2c, 0x74, 0x0b, 0x50, 0xe6, 0x50, 0xa3, 0xcc, 0xb3, 0x21, 0xb3, 0x92, 0x3e,
-	0xc3, 0x18, 0x77, 0x26, 0xdc, 0x29, 0xf7, 0x1c, 0xe3, 0x4e, 0x47, 0xf7, 0x81, 0x85, 0x6e, 0x80,
-	0x12, 0x9c, 0xd8, 0x03, 0xbd, 0xab, 0x9b, 0x2e, 0xd1, 0xcd, 0xe7, 0xd8, 0x3c, 0xa9, 0xcd, 0x6f,
-	0x95, 0xb6, 0x97, 0xb4, 0x0a, 0x1d, 0xdf, 0x6f, 0xb9, 0xa4, 0x45, 0x07, 0xd1, 0x47, 0x80, 0x7c,
-	0xdc, 0xc5, 0x3e, 0x76, 0x4d, 0xac, 0x1b, 0x7d, 0x62, 0x93, 0xa1, 0x85, 0x6b, 0x0b, 0x5b, 0xa5,
-	0xed, 0x92, 0xb6, 0x26, 0x28, 0x4d, 0x4e, 0x50, 0x3f, 0x85, 0xf5, 0x64, 0xc0, 0x46, 0xa6, 0x52,
-	0x61, 0x81, 0xad, 0x8e, 0x9b, 0x1e, 0x62, 0xd3, 0x6b, 0x9c, 0xa2, 0x7e, 0x08, 0x8a, 0x08, 0xc8,
-	0x48, 0x2e, 0xcf, 0x8e, 0xea, 0x5f, 0x4b, 0xb0, 0x96, 0xe0, 0xe6, 0x71, 0x3b, 0xc5, 0x34, 0x6f,
-	0x29, 0x42, 0x3f, 0x85, 0xf5, 0x64, 0x84, 0xbe, 0x8c, 0x5d, 0x1a, 0xb0, 0x9e, 0x0c, 0xc2, 0x89,
-	0xa6, 0xf9, 0x7b, 0x19, 0x14, 0xc6, 0xda, 0x34, 0x89, 0x7d, 0x6a, 0x10, 0xdb, 0x73, 0xf3, 0x03,
-	0xf2, 0x32, 0x2c, 0x51, 0x82, 0x61, 0x59, 0x3e, 0x8f, 0x43, 0xca, 0xd8, 0xb4, 0x2c, 0x1f, 0x5d,
-	0x87, 0x73, 0x81, 0xee, 0x8e, 0x4e, 0xf4, 0x40, 0xb7, 0x5d, 0xa2, 0x9f, 0xe0, 0x33, 0x1e, 0x7c,
-	0x2b, 0xc1, 0xe1, 0xe8, 0xa4, 0x73, 0xe0, 0x92, 0xfb, 0xf8, 0x8c, 0x72, 0x75, 0x33, 0x5c, 0x2c,
-	0xe8, 0x56, 0xba, 0x09, 0xae, 0x6b, 0x50, 0x61, 0x3c, 0xd8, 0x35, 0x43, 0x9e, 0xf9, 0x90, 0x07,
-	0xdc, 0xd1, 0x49, 0xa7, 0xed, 0x9a, 0x94, 0xa5, 0x06, 0x4b, 0x2c, 0x1a, 0x87, 0x83, 0x30, 0xbe,
-	0x2a, 0xda, 0x42, 0xb7, 0xe5, 0x92, 0xa3, 0x01, 0xda, 0x84, 0x55, 0x97, 0x47, 0xaa, 0xe5, 0x8d,
-	0xdc, 0xda, 0x62, 0x48, 0x5d, 0x76, 0x69, 0x94, 0xee, 0x79, 0x23, 0x97, 0x32, 0x18, 0x49, 0x86,
-	0x25, 0xc6, 0x60, 0x08, 0x06, 0x59, 0xb8, 0x2f, 0x4b, 0xc2, 0x5d, 0xfd, 0x0e, 0x2e, 0x70, 0xab,
-	0x65, 0xcc, 0xdd, 0x14, 0x1b, 0xd7, 0x10, 0x56, 0xe5, 0x4e, 0x3b, 0x1f, 0x3b, 0x2d, 0xb6, 0xb8,
-	0xa6, 0x58, 0x99, 0x11, 0x75, 0x17, 0x2e, 0xed, 0x61, 0x43, 0x8a, 0x9e, 0xeb, 0xcc, 0xdb, 0x50,
-	0x17, 0x61, 0x9e, 0x00, 0x9f, 0x24, 0xf6, 0x4b, 0xb8, 0x22, 0x15, 0xe3, 0xfb, 0xe4, 0x35, 0x2c,
-	0xe6, 0x36, 0xcb, 0x3c, 0x0c, 0xd7, 0xf2, 0x9c, 0x3d, 0x16, 0x30, 0x02, 0x3e, 0x19, 0x53, 0xa5,
-	0x54, 0x4c, 0xa9, 0x36, 0x6c, 0xb1, 0xf3, 0xe1, 0x61, 0xb3, 0xd5, 0xf2, 0x1c, 0xc7, 0x70, 0xad,
-	0x27, 0x43, 0x3c, 0xc4, 0x07, 0x04, 0x3b, 0x93, 0x56, 0x85, 0x14, 0x98, 0x35, 0xf9, 0x99, 0x56,
-	0xd1, 0xe8, 0xbf, 0xa8, 0x0e, 0x4b, 0x26, 0x43, 0x09, 0x6a, 0xf3, 0x5b, 0xb3, 0xdb, 0xab, 0x9a,
-	0xf8, 0x56, 0x7f, 0x2c, 0xc1, 0xd5, 0x0e, 0x76, 0xad, 0xc7, 0xbe, 0x37, 0xf0, 0x6d, 0x4c, 0x0c,
-	0xff, 0xec, 0xb1, 0x71, 0xd6, 0xf7, 0x0c, 0x2b, 0x9a, 0x68, 0x13, 0x56, 0x1c, 0xc3, 0xd4, 0x07,
-	0x6c, 0x94, 0x4f, 0x06, 0x8e, 0x61, 0x72, 0x3e, 0x3a, 0xa1, 0x63, 0x9b, 0x7c, 0x5f, 0xd0, 0x7f,
-	0xd1, 0x35, 0x58, 0xed, 0x19, 0x04, 0x8f, 0x8c, 0x33, 0xdd, 0x31, 0xcc, 0xa0, 0x36, 0x1b, 0x4e,
-	0xba, 0xc2, 0xc7, 0x1e, 0x1a, 0x66, 0x80, 0x6e, 0xc3, 0xc5, 0x81, 0xd7, 0x37, 0x7c, 0xfb, 0x57,
-	0xa1, 0xa5, 0x74, 0xdb, 0x3d, 0xc5, 0x7e, 0x40, 0x2d, 0x3c, 0x17, 0x46, 0xdc, 0x85, 0x24, 0xf5,
-	0x20, 0x22, 0xa2, 0x0d, 0x58, 0xee, 0xfa, 0x54, 0x31, 0xd7, 0x64, 0xbb, 0xa3, 0xa2, 0xc5, 0x03,
-	0xf4, 0xae, 0xb1, 0x7c, 0xbe, 0x2d, 0xca, 0x96, 0xaf, 0xfe, 0xa9, 0x04, 0x8b, 0x77, 0xd9, 0xa4,
-	0xd9, 0x7b, 0x08, 0xdd, 0x82, 0xa5, 0xbe, 0x67, 0x32, 0xa7, 0xb2, 0xf3, 0x4d, 0x69, 0xf0, 0x47,
-	0xd1, 0x03, 0x3e, 0xae, 0x09, 0x0e, 0x7a, 0x6f, 0x44, 0x2b, 0x1a, 0xbf, 0x65, 0x38, 0x25, 0xbe,
-	0x37, 0xb6, 0x61, 0xe1, 0xd8, 0x33, 0x7c, 0x2b, 0xa8, 0xcd, 0x6d, 0xcd, 0x86, 0xc8, 0x6e, 0xd0,
-	0xe0, 0x8a, 0x7c, 0x49, 0x09, 0x1a, 0xa7, 0xab, 0x47, 0xb0, 0x9a, 0x1c, 0xa7, 0x5e, 0xed, 0x0e,
-	0x7a, 0x86, 0x2e, 0x54, 0x5d, 0xa0, 0x9f, 0xec, 0xe2, 0xea, 0xda, 0x2e, 0xd6, 0xc5, 0x73, 0x30,
-	0x3c, 0x1f, 0x98, 0xcd, 0x15, 0x4a, 0x11, 0x07, 0xea, 0x7d, 0x7c, 0xa6, 0x7e, 0x01, 0xe7, 0x59,
-	0x00, 0x71, 0xf0, 0xc8, 0x97, 0xef, 0xc1, 0x22, 0x57, 0x96, 0x07, 0xf2, 0x4a, 0x42, 0x33, 0x2d,
-	0xa2, 0xa9, 0xef, 0x86, 0xd7, 0x46, 0x46, 0x36, 0x7b, 0x91, 0xff, 0xad, 0x0c, 0x28, 0xc9, 0xc5,
-	0xc3, 0x7a, 0xba, 0x29, 0xde, 0xce, 0x05, 0x83, 0xee, 0x40, 0xa5, 0x6b, 0xfb, 0x01, 0xd1, 0x03,
-	0x8c, 0x5d, 0x2a, 0x3d, 0x37, 0x51, 0x7a, 0x25, 0x14, 0xe8, 0x60, 0xec, 0x36, 0x09, 0xfa, 0x19,
-	0xac, 0xf6, 0x8d, 0x84, 0xf8, 0xfc, 0x44, 0x71, 0xa0, 0xfc, 0x4c, 0x9a, 0x7a, 0x85, 0x5d, 0x6f,
-	0x3f, 0xcd, 0x2b, 0xef, 0xc3, 0x79, 0x76, 0xc5, 0x4d, 0x70, 0xcc, 0xef, 0xca, 0x22, 0xa8, 0x3a,
-	0xc4, 0x20, 0x01, 0xfa, 0x04, 0x96, 0x45, 0xd8, 0xf0, 0x19, 0x0a, 0xed, 0x25, 0x98, 0x51, 0x03,
-	0xd6, 0xfd, 0x17, 0xfa, 0xc0, 0x30, 0x4f, 0x30, 0x09, 0x74, 0x1f, 0x9b, 0xd8, 0x3e, 0xc5, 0x2c,
-	0x15, 0x9b, 0xd7, 0xd6, 0xfc, 0x17, 0x8f, 0x19, 0x45, 0xe3, 0x04, 0xf4, 0x31, 0x5c, 0x94, 0xf0,
-	0xeb, 0xde, 0x49, 0xe8, 0xa6, 0x79, 0x6d, 0x7d, 0x4c, 0xe4, 0xd1, 0x09, 0x9d, 0x84, 0x48, 0x26,
-	0x99, 0x63, 0x93, 0x90, 0xb1, 0x49, 0x6e, 0x01, 0x4a, 0xf0, 0x63, 0xc7, 0x26, 0x04, 0x5b, 0xa1,
-	0x2b, 0xe6, 0x35, 0x45, 0xb0, 0xb7, 0xd9, 0xb8, 0xfa, 0x9f, 0x12, 0x5c, 0x8c, 0xc3, 0x34, 0x34,
-	0x48, 0x64, 0xb8, 0xab, 0x00, 0xd1, 0xa6, 0x16, 0x06, 0x5c, 0xe6, 0x23, 0x07, 0x74, 0x31, 0x4b,
-	0xb6, 0x4b, 0xb0, 0x7f, 0x6a, 0xf4, 0xc3, 0x15, 0x57, 0x77, 0x2f, 0x51, 0xbf, 0x34, 0x7b, 0x3d,
-	0x1f, 0xf7, 0xf8, 0xb9, 0xc4, 0xc8, 0x9a, 0x60, 0x44, 0x2d, 0x38, 0x17, 0x10, 0xc3, 0x27, 0xf1,
-	0x46, 0x9d, 0x22, 0x42, 0xab, 0xa1, 0x88, 0xf8, 0x46, 0x3f, 0x87, 0x0a, 0x76, 0xad, 0x04, 0xc4,
-	0xe4, 0x30, 0x5d, 0xc5, 0xae, 0x25, 0xbe, 0xd4, 0x56, 0xf8, 0xc4, 0x49, 0xaf, 0x99, 0xef, 0xcf,
-	0x6d, 0x58, 0xf0, 0x71, 0x30, 0xec, 0x93, 0x5a, 0x69, 0xec, 0x6c, 0x62, 0x9c, 0x9c, 0xae, 0xfe,
-	0xb1, 0x04, 0xe7, 0xd8, 0x1d, 0x27, 0x2e, 0x9f, 0xfc, 0x5b, 0x67, 0x13, 0x56, 0xba, 0xbe, 0x23,
-	0x6e, 0x09, 0x76, 0x30, 0x41, 0xd7, 0x77, 0xa2, 0x5b, 0x62, 0x1d, 0xe6, 0xc3, 0xbc, 0x22, 0x34,
-	0x47, 0x45, 0x9b, 0xa3, 0x59, 0x0b, 0xba, 0x00, 0x0b, 0x5d, 0x7d, 0xe0, 0xf9, 0x84, 0x5f, 0x57,
-	0xf3, 0xdd, 0xc7, 0x9e, 0x4f, 0xe8, 0x29, 0x6f, 0x7a, 0x6e, 0xd7, 0xf6, 0x1d, 0xee, 0xd8, 0x25,
-	0x2d, 0x1e, 0x50, 0xef, 0x46, 0xcf, 0xff, 0x8c, 0x72, 0x91, 0x5b, 0x6f, 0xc0, 0x9c, 0x4d, 0xb0,
-	0xc3, 0x23, 0x7d, 0x3d, 0xbe, 0xaa, 0x63, 0xce, 0x90, 0x41, 0xfd, 0x1c, 0xb6, 0xf6, 0xfb, 0xc3,
-	0xe0, 0x79, 0x82, 0xba, 0xef, 0xf9, 0x7b, 0xf8, 0xb4, 0x7d, 0x74, 0x30, 0x31, 0x79, 0xb8, 0x03,
-	0xef, 0x8a, 0xe4, 0x41, 0x00, 0x07, 0xd3, 0xcb, 0x3f, 0x81, 0xeb, 0xc5, 0xf2, 0xdc, 0x5f, 0x1f,
-	0xc0, 0x3c, 0x55, 0x36, 0xe0, 0xee, 0x92, 0x2e, 0x87, 0x71, 0x70, 0x95, 0x0e, 0xf1, 0x8b, 0x30,
-	0x9d, 0xeb, 0xdb, 0xee, 0x09, 0x4d, 0xd9, 0xa6, 0x57, 0xe9, 0xf3, 0x50, 0xa5, 0x02, 0x79, 0xae,
-	0x92, 0x70, 0x65, 0x29, 0x76, 0xa5, 0xda, 0x84, 0xad, 0x0e, 0xf1, 0xb1, 0xe1, 0xec, 0xfb, 0x86,
-	0x83, 0x1f, 0x78, 0x3d, 0xba, 0x96, 0xcc, 0x49, 0x55, 0xbc, 0xe1, 0xd4, 0xbf, 0x94, 0xe0, 0x5a,
-	0x01, 0x06, 0x9f, 0xfd, 0x0e, 0x28, 0xc3, 0x01, 0x55, 0x4e, 0xef, 0x52, 0x2e, 0x3d, 0xc0, 0x44,
-	0x94, 0x2c, 0x7a, 0xa3, 0xc6, 0x51, 0x48, 0x0b, 0x01, 0x3a, 0x98, 0xdc, 0x9b, 0xd1, 0xaa, 0xc3,
-	0xd4, 0x08, 0xfa, 0x0c, 0xaa, 0x16, 0x5f, 0x1e, 0x43, 0xe0, 0xb7, 0xcf, 0x1a, 0x95, 0x16, 0x0b,
-	0xa7, 0x84, 0x7b, 0x33, 0x5a, 0xc5, 0x4a, 0x0e, 0x7c, 0xb9, 0x08, 0xf3, 0xa1, 0x88, 0xfa, 0x19,
-	0x6c, 0x8e, 0x6b, 0x3a, 0x65, 0xb6, 0xfa, 0xe7, 0x92, 0xcc, 0x54, 0x99, 0x47, 0xda, 0xff, 0xc4,
-	0x2a, 0x9f, 0x85, 0x37, 0xfc, 0x33, 0x96, 0x7b, 0x09, 0xd5, 0x6a, 0xb0, 0x18, 0xe5, 0x6a, 0x54,
-	0xa3, 0x65, 0x2d, 0xfa, 0x44, 0xef, 0xd3, 0xb3, 0xa5, 0x17, 0x65, 0x54, 0xd5, 0xdd, 0x6a, 0x94,
-	0x51, 0x69, 0xe1, 0xa8, 0xc6, 0xa9, 0xea, 0x6f, 0x4b, 0x50, 0xbd, 0x9b, 0x4a, 0x9a, 0xc6, 0xd2,
-	0x33, 0x9a, 0xb3, 0x3e, 0x37, 0x5c, 0x17, 0xf7, 0x83, 0x5a, 0x79, 0x6b, 0x76, 0xbb, 0xa2, 0x89,
-	0x6f, 0xd4, 0x86, 0x2a, 0x7e, 0x41, 0x7c, 0x43, 0x17, 0x1c, 0xb3, 0xe1, 0xde, 0x78, 0x27, 0x71,
-	0x94, 0x71, 0xdc, 0x36, 0xe5, 0x6b, 0x31, 0x36, 0xad, 0x82, 0x13, 0x5f, 0x81, 0xfa, 0xaf, 0x12,
-	0xd4, 0xf3, 0xb9, 0xd1, 0x2e, 0x80, 0xe3, 0x59, 0xc3, 0x7e, 0x9c, 0xf7, 0x57, 0x77, 0x51, 0xb4,
-	0xa0, 0x87, 0x82, 0xa2, 0x25, 0xb8, 0xd2, 0xe9, 0x69, 0x39, 0x9b, 0x9e, 0x6e, 0xc0, 0xf2, 0xb1,
-	0xe1, 0x5a, 0x23, 0xdb, 0x22, 0xcf, 0xf9, 0x31, 0x18, 0x0f, 0x50, 0xb3, 0x1e, 0xdb, 0xc4, 0x37,
-	0x08, 0xe6, 0x87, 0x61, 0xf4, 0x89, 0x3e, 0x84, 0xb5, 0x60, 0xe0, 0x63, 0xc3, 0xb2, 0xdd, 0x9e,
-	0xde, 0x35, 0x4c, 0xe2, 0xf9, 0x2c, 0x91, 0xaf, 0x68, 0x8a, 0x20, 0xec, 0xb3, 0xf1, 0xb8, 0xf0,
-	0x9a, 0x5e, 0x5a, 0xa2, 0xde, 0x97, 0x49, 0x64, 0x93, 0xf5, 0xbe, 0x8c, 0x4c, 0x35, 0x9d, 0xd9,
-	0xc6, 0x85, 0xd7, 0x2c, 0x76, 0x61, 0xe1, 0x55, 0xae, 0x48, 0x4e, 0xe1, 0x35, 0x07, 0xf9, 0x55,
-	0xd4, 0x7e, 0xdb, 0x85, 0xd7, 0x37, 0xe0, 0x08, 0x51, 0x78, 0x9d, 0xce, 0xb6, 0x3f, 0x96, 0xa1,
-	0xfa, 0x70, 0xd8, 0x27, 0xb6, 0x69, 0x04, 0xe4, 0xae, 0xef, 0x0d, 0x07, 0x63, 0xfb, 0xed, 0x12,
-	0x2c, 0x3a, 0x66, 0xb2, 0xc0, 0xb1, 0xe0, 0x98, 0x61, 0x7d, 0x63, 0x13, 0x56, 0x1d, 0x93, 0x97,
-	0x2e, 0xe2, 0xe2, 0xc6, 0xb2, 0x63, 0x1e, 0x8e, 0x4e, 0x3a, 0xf7, 0xf1, 0x59, 0x7c, 0x1b, 0xcc,
-	0x25, 0x2e, 0xf6, 0xdb, 0x00, 0x3d, 0x3a, 0x8f, 0x4e, 0xce, 0x06, 0x38, 0xbc, 0xc2, 0xab, 0xbb,
-	0x17, 0xe9, 0xc2, 0xd2, 0x6a, 0x3c, 0x3d, 0x1b, 0x60, 0x6d, 0xb9, 0x17, 0xfd, 0x9b, 0x7d, 0xc0,
-	0xa5, 0xf7, 0xd3, 0x62, 0x76, 0x3f, 0x6d, 0x83, 0x32, 0xa0, 0x5b, 0x22, 0xe8, 0x7b, 0x44, 0x1f,
-	0x60, 0xdf, 0xf6, 0x2c, 0x5e, 0xd4, 0xa8, 0xd2, 0xf1, 0x4e, 0xdf, 0x23, 0x8f, 0xc3, 0xd1, 0x9c,
-	0x22, 0xe1, 0xf2, 0x4b, 0x15, 0x09, 0x41, 0x5e, 0x24, 0x8c, 0x37, 0x5c, 0x7a, 0x69, 0x09, 0x3f,
-	0x3b, 0x11, 0x41, 0x0f, 0x57, 0x9a, 0xf4, 0x73, 0x46, 0xa6, 0xea, 0xa4, 0xbe, 0xe3, 0x0d, 0x97,
-	0xc5, 0x2e, 0xdc, 0x70, 0x72, 0x45, 0x72, 0x36, 0x5c, 0x0e, 0xf2, 0xab, 0xa8, 0xfd, 0xb6, 0x37,
-	0xdc, 0x1b, 0x70, 0x84, 0xd8, 0x70, 0xd3, 0xd9, 0xd6, 0x86, 0xad, 0xa6, 0x65, 0xb1, 0x2b, 0xfd,
-	0xa9, 0x27, 0x97, 0xc9, 0x4d, 0xa5, 0x6f, 0x01, 0xca, 0x28, 0x1a, 0x97, 0xbf, 0x95, 0xb4, 0x5e,
-	0x07, 0x96, 0xea, 0xc2, 0x7b, 0x1a, 0x76, 0xbc, 0x53, 0x9e, 0x0d, 0xef, 0xfb, 0x9e, 0xf3, 0x46,
-	0xe7, 0xfb, 0x7d, 0x09, 0x90, 0x98, 0x20, 0x7e, 0x18, 0xc8, 0x41, 0x4a, 0x72, 0x90, 0xf8, 0xcc,
-	0x28, 0x4b, 0x1f, 0x03, 0xb3, 0xc9, 0xc7, 0x40, 0xe6, 0x65, 0x31, 0x97, 0x7d, 0x59, 0xa8, 0x7d,
-	0xd8, 0x6a, 0xbb, 0x3f, 0x50, 0x4d, 0xc6, 0xf5, 0x8a, 0x16, 0x7f, 0x0f, 0xce, 0xc7, 0xea, 0x85,
-	0xbc, 0x7a, 0xe2, 0x8d, 0x90, 0x3e, 0x99, 0x62, 0xe1, 0x78, 0x49, 0x62, 0x4c, 0xfd, 0x1e, 0x3e,
-	0x0c, 0x1f, 0x0d, 0x69, 0xf6, 0x7d, 0xcf, 0x97, 0x5b, 0xfd, 0xa5, 0xec, 0xa2, 0xfe, 0x02, 0x1a,
-	0xc9, 0x2d, 0x99, 0x7a, 0x17, 0xbc, 0x0e, 0xfc, 0x5f, 0xc3, 0xce, 0xd4, 0xf8, 0xfc, 0x20, 0xf8,
-	0x0a, 0x2e, 0xc8, 0x2c, 0x17, 0xbd, 0x47, 0xf2, 0x4c, 0xb7, 0x3e, 0x6e, 0xba, 0xe0, 0xe6, 0x06,
-	0x2c, 0x69, 0xdf, 0x7c, 0x6d, 0xbb, 0x96, 0x37, 0x42, 0x8b, 0x30, 0xab, 0x7d, 0xf3, 0xff, 0xca,
-	0x0c, 0xfb, 0x67, 0x57, 0x29, 0xdd, 0xec, 0xc3, 0xba, 0xe4, 0x6d, 0x8d, 0x00, 0x16, 0x3a, 0xed,
-	0xd6, 0xa3, 0xc3, 0x3d, 0x65, 0x86, 0xfe, 0xff, 0xf0, 0xe0, 0xf0, 0xe8, 0x69, 0x5b, 0x29, 0xa1,
-	0x25, 0x98, 0xbb, 0xf7, 0xe8, 0x48, 0x53, 0xca, 0x14, 0x61, 0xaf, 0xf9, 0xad, 0x32, 0x4b, 0x87,
-	0xbe, 0x6e, 0xb7, 0xef, 0x2b, 0x73, 0x68, 0x19, 0xe6, 0x1f, 0x3e, 0x3a, 0x7c, 0x7a, 0x4f, 0x99,
-	0x47, 0x2b, 0xb0, 0xf8, 0xe4, 0xa8, 0xa9, 0x3d, 0x6d, 0x6b, 0xca, 0x02, 0xe5, 0xf8, 0xb6, 0xdd,
-	0xd4, 0x94, 0xc5, 0x9b, 0x8d, 0x44, 0x18, 0x8b, 0xbb, 0x88, 0x32, 0xb7, 0x1e, 0x34, 0x3b, 0x1d,
-	0xbd, 0xa5, 0xcc, 0xc4, 0x1f, 0x5f, 0x2a, 0xa5, 0xdd, 0x7f, 0x6f, 0xc2, 0xf9, 0x43, 0x4c, 0x46,
-	0x9e, 0x7f, 0xd2, 0x09, 0x7f, 0x16, 0xc1, 0xfb, 0xe0, 0xe8, 0xfb, 0xa8, 0xd6, 0x96, 0x6e, 0x8c,
-	0xa3, 0x4d, 0x6a, 0x99, 0x82, 0xdf, 0x45, 0xd4, 0xb7, 0xf2, 0x19, 0x98, 0xed, 0xd5, 0x19, 0xa4,
-	0x85, 0x95, 0xb8, 0x0c, 0xf2, 0x46, 0x98, 0x21, 0xe4, 0xfc, 0xca, 0xa1, 0x7e, 0x35, 0x87, 0x2a,
-	0x30, 0x9f, 0x44, 0x65, 0x28, 0x99, 0xc2, 0x05, 0xbf, 0x1f, 0xa8, 0x5f, 0x1c, 0x3b, 0x87, 0xdb,
-	0xce, 0x80, 0x9c, 0x31, 0x48, 0xd9, 0x8f, 0x03, 0x18, 0x64, 0xc1, 0xcf, 0x06, 0x0a, 0x20, 0x85,
-	0x59, 0xd3, 0xbd, 0xe5, 0xa4, 0x59, 0xa5, 0x5d, 0xe7, 0xa4, 0x59, 0xe5, 0x5d, 0x7c, 0x61, 0xd6,
-	0x0c, 0x72, 0x64, 0x56, 0x39, 0xec, 0xd5, 0x1c, 0xea, 0xb8, 0x59, 0x65, 0x0a, 0x17, 0xb4, 0xe0,
-	0xa7, 0x31, 0xab, 0x0c, 0xb2, 0xa0, 0xf3, 0x5e, 0x00, 0xf9, 0x4d, 0xba, 0xf5, 0x18, 0x21, 0xbe,
-	0x13, 0x1b, 0x4d, 0xd6, 0xc5, 0xad, 0x6f, 0xe6, 0xd2, 0xc5, 0xfa, 0x1f, 0x25, 0x3a, 0x93, 0x11,
-	0xec, 0x15, 0x6e, 0x34, 0x29, 0xe6, 0x86, 0x9c, 0x98, 0x00, 0x5c, 0x97, 0xf4, 0xab, 0x99, 0xaa,
-	0xf9, 0x8d, 0xec, 0x82, 0xb5, 0x3f, 0x4a, 0xf7, 0x08, 0x53, 0x80, 0xf9, 0x1d, 0xec, 0x02, 0xc0,
-	0x26, 0xac, 0x26, 0x6d, 0x82, 0x2e, 0x65, 0xad, 0x34, 0x19, 0xe2, 0x33, 0x58, 0x16, 0x26, 0x40,
-	0xe7, 0x53, 0x16, 0x89, 0x84, 0x2f, 0x64, 0x46, 0x85, 0x81, 0x9a, 0xb0, 0x9a, 0xb4, 0x03, 0x9b,
-	0x5e, 0xd2, 0x40, 0x2d, 0x5e, 0x41, 0x72, 0xe5, 0x0c, 0x42, 0xd2, 0x48, 0x2d, 0x80, 0x68, 0x43,
-	0x35, 0xdd, 0x0c, 0x44, 0x97, 0xc3, 0x32, 0xa9, 0xac, 0x85, 0x57, 0x00, 0x73, 0x00, 0x4a, 0xb6,
-	0xef, 0xc7, 0xc2, 0x27, 0xa7, 0x1b, 0x58, 0x1c, 0xe3, 0x92, 0xbe, 0x1e, 0xf3, 0x73, 0x7e, 0x9f,
-	0x90, 0xc5, 0x78, 0x41, 0x43, 0x50, 0x9d, 0x41, 0x1d, 0xb8, 0x20, 0x2d, 0x3d, 0xa2, 0xad, 0xac,
-	0xe7, 0xb3, 0x19, 0x48, 0xe1, 0x49, 0x77, 0x39, 0xb7, 0x0c, 0x89, 0xae, 0x53, 0xe0, 0x49, 0x55,
-	0xca, 0x02, 0xf0, 0x00, 0x36, 0x8a, 0xca, 0x8c, 0xe8, 0x46, 0x6a, 0xd1, 0xf9, 0x85, 0xcc, 0xfa,
-	0xf6, 0x64, 0x46, 0x61, 0x26, 0x36, 0x69, 0x6e, 0x21, 0x51, 0x4c, 0x3a, 0xa9, 0x54, 0x29, 0x26,
-	0x9d, 0x58, 0x93, 0x54, 0x67, 0xd0, 0x57, 0xe1, 0xf9, 0x93, 0xea, 0xb5, 0xa2, 0x1c, 0xbb, 0x88,
-	0xa3, 0x47, 0xda, 0x99, 0x65, 0x2e, 0xc9, 0x6d, 0xc0, 0x32, 0x97, 0x4c, 0xea, 0xcf, 0x16, 0xb8,
-	0xe4, 0x08, 0x2e, 0xca, 0x3b, 0xae, 0xe8, 0x1a, 0xfb, 0x1d, 0x5e, 0x41, 0x37, 0xb6, 0x00, 0xb6,
-	0x05, 0x95, 0x54, 0x71, 0x06, 0xd5, 0x62, 0x3d, 0xd3, 0x75, 0xd8, 0x02, 0x90, 0x2f, 0x00, 0xe2,
-	0x22, 0x0c, 0x8a, 0x4e, 0x9e, 0x31, 0xf1, 0xcc, 0xb0, 0xb0, 0x5b, 0x0b, 0x2a, 0xa9, 0x9a, 0x07,
-	0xd3, 0x41, 0xd6, 0xf4, 0x2a, 0x5e, 0x48, 0xaa, 0xb8, 0xc1, 0x40, 0x64, 0xad, 0xaf, 0x69, 0xd2,
-	0x87, 0x4c, 0x9d, 0x71, 0x73, 0xcc, 0x28, 0xf9, 0xe9, 0x83, 0xbc, 0x16, 0x25, 0xd2, 0x87, 0x0c,
-	0xf2, 0x46, 0xda, 0x2a, 0x39, 0xe9, 0x43, 0x2e, 0xe6, 0x93, 0x4c, 0x73, 0x50, 0x92, 0x3e, 0xc8,
-	0x91, 0xa7, 0x48, 0x1f, 0x64, 0x90, 0x05, 0xf5, 0xa3, 0x02, 0xc8, 0x07, 0x70, 0x2e, 0xd3, 0x58,
-	0x42, 0xf5, 0xf4, 0xca, 0x92, 0x1d, 0xb6, 0xfa, 0x15, 0x29, 0x4d, 0xac, 0xb9, 0x0f, 0x97, 0x73,
-	0xeb, 0xfd, 0x6c, 0x9b, 0x4d, 0x6a, 0x29, 0xd4, 0xdf, 0x9b, 0xc0, 0x15, 0xcd, 0xf5, 0x7f, 0x25,
-	0x64, 0x43, 0x2d, 0xaf, 0xec, 0x8e, 0xde, 0x95, 0xc3, 0xa4, 0x6f, 0x9c, 0xeb, 0xc5, 0x4c, 0x89,
-	0xa9, 0x44, 0xf4, 0x65, 0xaa, 0x6e, 0x89, 0xe8, 0x93, 0x3e, 0xe7, 0x92, 0xd1, 0x27, 0x7f, 0x8f,
-	0x89, 0xe8, 0xcb, 0x20, 0x47, 0xd1, 0x27, 0x87, 0xbd, 0x9a, 0x43, 0x1d, 0x8f, 0x3e, 0x99, 0xc2,
-	0x05, 0x55, 0x95, 0x69, 0xa2, 0x4f, 0x06, 0x59, 0x50, 0x4c, 0x29, 0xbe, 0x29, 0x73, 0xcb, 0x2a,
-	0x2c, 0x5e, 0x26, 0x55, 0x5d, 0x0a, 0xc0, 0x31, 0xbc, 0x53, 0x5c, 0x48, 0x41, 0x1f, 0x84, 0x3f,
-	0x89, 0x9d, 0xa6, 0xd8, 0x52, 0xbc, 0x86, 0xdc, 0x6a, 0x05, 0x5b, 0xc3, 0xa4, 0x62, 0x46, 0x01,
-	0xf8, 0x0f, 0x70, 0x7d, 0x9a, 0xe2, 0x04, 0xda, 0x11, 0x59, 0xc5, 0x74, 0x65, 0x8c, 0x82, 0x29,
-	0xff, 0x50, 0x82, 0x1b, 0x53, 0xd6, 0x14, 0xd0, 0x6e, 0x36, 0x0c, 0x27, 0x17, 0x38, 0xea, 0x1f,
-	0xbf, 0x94, 0x8c, 0x08, 0xe8, 0x3b, 0xe1, 0x45, 0xc6, 0x5b, 0x57, 0xb9, 0x79, 0x40, 0x74, 0x93,
-	0x65, 0x5a, 0x5c, 0xea, 0xcc, 0xf1, 0x42, 0xc8, 0xf9, 0xf1, 0x7f, 0x03, 0x00, 0x00, 0xff, 0xff,
-	0xde, 0xb2, 0x20, 0xd3, 0x9e, 0x31, 0x00, 0x00,
+	// 3034 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x5a, 0xcb, 0x73, 0xdb, 0xc8,
+	0xd1, 0x37, 0xa8, 0x27, 0x5b, 0x22, 0x4d, 0x8d, 0x6c, 0x8b, 0xa6, 0xe5, 0x15, 0x8d, 0xf5, 0xae,
+	0xb5, 0x5e, 0x2f, 0xf5, 0x7d, 0xda, 0x72, 0x7d, 0xfb, 0xf8, 0xd6, 0x29, 0x2e, 0x45, 0xd9, 0xda,
+	0xf5, 0x13, 0xb4, 0xbc, 0xaf, 0xaa, 0x20, 0x10, 0x30, 0xa4, 0x51, 0x22, 0x00, 0x2e, 0x30, 0x94,
+	0xac, 0x54, 0xe5, 0x90, 0xca, 0x31, 0x87, 0x5c, 0x72, 0xcf, 0x31, 0xb9, 0xa4, 0x72, 0xcf, 0x9f,
+	0x90, 0x43, 0x2e, 0xb9, 0xed, 0x9f, 0x90, 0xca, 0x29, 0x7f, 0x41, 0x6a, 0x30, 0x83, 0xc1, 0x83,
+	0x03, 0x90, 0x5e, 0xaf, 0xcb, 0x39, 0x49, 0x98, 0xee, 0xfe, 0x4d, 0x77, 0x4f, 0xcf, 0x4c, 0x4f,
+	0x37, 0x61, 0xd9, 0x0d, 0x5a, 0x23, 0xdf, 0x23, 0x1e, 0x2a, 0xb9, 0x41, 0x63, 0x6b, 0xe0, 0x79,
+	0x83, 0x21, 0xde, 0x09, 0x47, 0x8e, 0xc6, 0xfd, 0x1d, 0x62, 0x3b, 0x38, 0x20, 0x86, 0x33, 0x62,
+	0x4c, 0x8d, 0x2b, 0x59, 0x06, 0xec, 0x8c, 0xc8, 0x19, 0x27, 0xde, 0x1e, 0xd8, 0xe4, 0xf9, 0xf8,
+	0xa8, 0x65, 0x7a, 0xce, 0xce, 0x91, 0xef, 0x99, 0x86, 0xe1, 0xef, 0x0c, 0x3d, 0xdf, 0x08, 0xb0,
+	0x7f, 0x82, 0xfd, 0x1d, 0x63, 0x64, 0xef, 0x98, 0x9e, 0xe3, 0x78, 0x2e, 0xff, 0xc3, 0xc5, 0x3e,
+	0x98, 0x2e, 0x36, 0x38, 0xdd, 0x19, 0x9c, 0x72, 0xf6, 0xea, 0xc8, 0xf7, 0xfa, 0xf6, 0x10, 0x73,
+	0xbd, 0xd5, 0x6f, 0xe1, 0x4a, 0xc7, 0xc7, 0x06, 0xc1, 0x3d, 0xec, 0x9f, 0xd8, 0x26, 0x7e, 0xcc,
+	0xc8, 0x1a, 0xfe, 0x7e, 0x8c, 0x03, 0x82, 0x3e, 0x85, 0xf3, 0x01, 0x23, 0xe8, 0x5c, 0xb0, 0xae,
+	0x34, 0x95, 0xed, 0x95, 0x5d, 0xd4, 0x72, 0x83, 0x56, 0x46, 0xa6, 0x1a, 0xa4, 0xbe, 0xd5, 0x16,
+	0x6c, 0xca, 0xb1, 0x83, 0x91, 0xe7, 0x06, 0x18, 0x55, 0xa1, 0x64, 0x5b, 0x21, 0xde, 0xaa, 0x56,
+	0xb2, 0x2d, 0xf5, 0x26, 0xd4, 0xef, 0x62, 0x22, 0x57, 0x24, 0xcb, 0xfb, 0x77, 0x05, 0x2e, 0x4b,
+	0x98, 0x39, 0xf2, 0xab, 0xa8, 0x8d, 0x3e, 0x06, 0x30, 0x43, 0xb5, 0x2d, 0xdd, 0x20, 0xf5, 0x52,
+	0x28, 0xd7, 0x68, 0xb1, 0xa5, 0x6b, 0x45, 0x4b, 0xd7, 0x7a, 0x1a, 0xad, 0xad, 0x56, 0xe6, 0xdc,
+	0x6d, 0x42, 0x45, 0xc7, 0x23, 0x2b, 0x12, 0x9d, 0x9b, 0x2e, 0xca, 0xb9, 0xdb, 0x84, 0x2e, 0xc4,
+	0x61, 0xf8, 0xf1, 0x1a, 0x16, 0xe2, 0x03, 0xb8, 0xb2, 0x87, 0x87, 0x98, 0xe0, 0xd9, 0x7c, 0x2b,
+	0x62, 0x42, 0xf3, 0xc6, 0xc4, 0x76, 0x07, 0x93, 0xaa, 0xf8, 0x8c, 0x20, 0x53, 0x25, 0x23, 0x53,
+	0xf5, 0x53, 0xdf, 0x71, 0x4c, 0x64, 0xb1, 0x0b, 0x63, 0x42, 0xae, 0x48, 0x4e, 0x4c, 0xe4, 0x20,
+	0xbf, 0x8a, 0xda, 0x6f, 0x3a, 0x26, 0x5e, 0xc3, 0x42, 0x88, 0x98, 0x98, 0xcd, 0xb7, 0xcf, 0xa0,
+	0xc1, 0xd6, 0x6d, 0x0f, 0x4b, 0x22, 0xe8, 0x23, 0xa8, 0x5a, 0x58, 0x12, 0x9c, 0x6b, 0x54, 0x91,
+	0xb4, 0x44, 0xc5, 0xc2, 0x99, 0xd0, 0x94, 0xe2, 0xe6, 0x84, 0xc3, 0x7b, 0xb0, 0x71, 0x17, 0x13,
+	0xa9, 0x0e, 0x59, 0xd6, 0xbf, 0x29, 0x50, 0x9f, 0xe4, 0xe5, 0xb8, 0x3f, 0x5a, 0xe1, 0x37, 0x14,
+	0x09, 0xcf, 0xa0, 0xc1, 0x22, 0xe1, 0x27, 0x76, 0xff, 0x2d, 0x68, 0xb0, 0x28, 0x98, 0xc9, 0xa5,
+	0xbf, 0x2e, 0xc1, 0x22, 0x63, 0x44, 0x1b, 0xb0, 0x64, 0xe1, 0x13, 0x1d, 0x8f, 0x6d, 0x4e, 0x5f,
+	0xb4, 0xf0, 0x49, 0x77, 0x6c, 0xa3, 0x9b, 0xb0, 0x96, 0xd6, 0x45, 0xb7, 0xad, 0xd0, 0x4d, 0xab,
+	0xda, 0xf9, 0xd4, 0xdc, 0x07, 0x16, 0xba, 0x05, 0x28, 0x73, 0xa8, 0x51, 0xe6, 0xb9, 0x90, 0xb9,
+	0x96, 0x3e, 0xc3, 0x18, 0x77, 0x26, 0xdc, 0x29, 0xf7, 0x3c, 0xe3, 0x4e, 0x47, 0xf7, 0x81, 0x85,
+	0x6e, 0x40, 0x2d, 0x38, 0xb6, 0x47, 0x7a, 0x5f, 0x37, 0x5d, 0xa2, 0x9b, 0xcf, 0xb1, 0x79, 0x5c,
+	0x5f, 0x68, 0x2a, 0xdb, 0xcb, 0x5a, 0x85, 0x8e, 0xef, 0x77, 0x5c, 0xd2, 0xa1, 0x83, 0xe8, 0x03,
+	0x40, 0x3e, 0xee, 0x63, 0x1f, 0xbb, 0x26, 0xd6, 0x8d, 0x21, 0xb1, 0xc9, 0xd8, 0xc2, 0xf5, 0xc5,
+	0xa6, 0xb2, 0xad, 0x68, 0x6b, 0x82, 0xd2, 0xe6, 0x04, 0xf5, 0x63, 0x58, 0x4f, 0x06, 0x6c, 0xe4,
+	0x2a, 0x15, 0x16, 0x99, 0x75, 0xdc, 0xf5, 0x10, 0xbb, 0x5e, 0xe3, 0x14, 0xf5, 0x7d, 0xa8, 0x89,
+	0x80, 0x8c, 0xe4, 0xf2, 0xfc, 0xa8, 0xfe, 0x59, 0x81, 0xb5, 0x04, 0x37, 0x8f, 0xdb, 0x19, 0xa6,
+	0x79, 0x43, 0x11, 0xfa, 0x31, 0xac, 0x27, 0x23, 0xf4, 0x65, 0xfc, 0xd2, 0x82, 0xf5, 0x64, 0x10,
+	0x4e, 0x75, 0xcd, 0x5f, 0x4b, 0x50, 0x63, 0xac, 0x6d, 0x93, 0xd8, 0x27, 0x06, 0xb1, 0x3d, 0x37,
+	0x3f, 0x20, 0x2f, 0xc3, 0x32, 0x25, 0x18, 0x96, 0xe5, 0xf3, 0x38, 0xa4, 0x8c, 0x6d, 0xcb, 0xf2,
+	0xd1, 0x75, 0x38, 0x1f, 0xe8, 0xee, 0xe9, 0xb1, 0x1e, 0xe8, 0xb6, 0x4b, 0xf4, 0x63, 0x7c, 0xc6,
+	0x83, 0x6f, 0x25, 0x78, 0x78, 0x7a, 0xdc, 0x3b, 0x70, 0xc9, 0x97, 0xf8, 0x8c, 0x72, 0xf5, 0x33,
+	0x5c, 0x2c, 0xe8, 0x56, 0xfa, 0x09, 0xae, 0x6b, 0x50, 0x61, 0x3c, 0xd8, 0x35, 0x43, 0x9e, 0x85,
+	0x90, 0x07, 0xdc, 0xd3, 0xe3, 0x5e, 0xd7, 0x35, 0x29, 0x4b, 0x1d, 0x96, 0x59, 0x34, 0x8e, 0x47,
+	0x61, 0x7c, 0x55, 0xb4, 0xc5, 0x7e, 0xc7, 0x25, 0x87, 0x23, 0xb4, 0x05, 0xab, 0x2e, 0x8f, 0x54,
+	0xcb, 0x3b, 0x75, 0xeb, 0x4b, 0x21, 0xb5, 0xec, 0xd2, 0x28, 0xdd, 0xf3, 0x4e, 0x5d, 0xca, 0x60,
+	0x24, 0x19, 0x96, 0x19, 0x83, 0x21, 0x18, 0x64, 0xe1, 0x5e, 0x96, 0x84, 0xbb, 0xfa, 0x2d, 0x5c,
+	0xe4, 0x5e, 0xcb, 0xb8, 0xbb, 0x2d, 0x36, 0xae, 0x21, 0xbc, 0xca, 0x17, 0xed, 0x42, 0xbc, 0x68,
+	0xb1, 0xc7, 0xb5, 0x9a, 0x95, 0x19, 0x51, 0x77, 0x61, 0x63, 0x0f, 0x1b, 0x52, 0xf4, 0xdc, 0xc5,
+	0xbc, 0x0d, 0x0d, 0x11, 0xe6, 0x09, 0xf0, 0x69, 0x62, 0xbf, 0x80, 0x2b, 0x52, 0x31, 0xbe, 0x4f,
+	0x7e, 0x02, 0x63, 0x6e, 0xb3, 0xcc, 0xc3, 0x70, 0x2d, 0xcf, 0xd9, 0x63, 0x01, 0x23, 0xe0, 0x93,
+	0x31, 0xa5, 0xa4, 0x62, 0x4a, 0xb5, 0xa1, 0xc9, 0xce, 0x87, 0x07, 0xed, 0x4e, 0xc7, 0x73, 0x1c,
+	0xc3, 0xb5, 0x9e, 0x8c, 0xf1, 0x18, 0x1f, 0x10, 0xec, 0x4c, 0xb3, 0x0a, 0xd5, 0x60, 0xce, 0xe4,
+	0x67, 0x5a, 0x45, 0xa3, 0xff, 0xa2, 0x06, 0x2c, 0x9b, 0x0c, 0x25, 0xa8, 0x2f, 0x34, 0xe7, 0xb6,
+	0x57, 0x35, 0xf1, 0xad, 0xfe, 0xa0, 0xc0, 0xd5, 0x1e, 0x76, 0xad, 0xc7, 0xbe, 0x37, 0xf2, 0x6d,
+	0x4c, 0x0c, 0xff, 0xec, 0xb1, 0x71, 0x36, 0xf4, 0x0c, 0x2b, 0x9a, 0x68, 0x0b, 0x56, 0x1c, 0xc3,
+	0xd4, 0x47, 0x6c, 0x94, 0x4f, 0x06, 0x8e, 0x61, 0x72, 0x3e, 0x3a, 0xa1, 0x63, 0x9b, 0x7c, 0x5f,
+	0xd0, 0x7f, 0xd1, 0x35, 0x58, 0x1d, 0x18, 0x04, 0x9f, 0x1a, 0x67, 0xba, 0x63, 0x98, 0x41, 0x7d,
+	0x2e, 0x9c, 0x74, 0x85, 0x8f, 0x3d, 0x30, 0xcc, 0x00, 0xdd, 0x86, 0x4b, 0x23, 0x6f, 0x68, 0xf8,
+	0xf6, 0x2f, 0x43, 0x4f, 0xe9, 0xb6, 0x7b, 0x82, 0xfd, 0x80, 0x7a, 0x78, 0x3e, 0x8c, 0xb8, 0x8b,
+	0x49, 0xea, 0x41, 0x44, 0x44, 0x9b, 0x50, 0xee, 0xfb, 0x54, 0x31, 0xd7, 0x64, 0xbb, 0xa3, 0xa2,
+	0xc5, 0x03, 0xf4, 0xae, 0xb1, 0x7c, 0xbe, 0x2d, 0x4a, 0x96, 0xaf, 0xfe, 0x41, 0x81, 0xa5, 0xbb,
+	0x6c, 0xd2, 0xec, 0x3d, 0x84, 0x6e, 0xc1, 0xf2, 0xd0, 0x33, 0xd9, 0xa2, 0xb2, 0xf3, 0xad, 0xd6,
+	0xe2, 0x8f, 0xa2, 0xfb, 0x7c, 0x5c, 0x13, 0x1c, 0xf4, 0xde, 0x88, 0x2c, 0x9a, 0xbc, 0x65, 0x38,
+	0x25, 0xbe, 0x37, 0xb6, 0x61, 0xf1, 0xc8, 0x33, 0x7c, 0x2b, 0xa8, 0xcf, 0x37, 0xe7, 0x42, 0x64,
+	0x37, 0x68, 0x71, 0x45, 0x3e, 0xa7, 0x04, 0x8d, 0xd3, 0xd5, 0x43, 0x58, 0x4d, 0x8e, 0xd3, 0x55,
+	0xed, 0x8f, 0x06, 0x86, 0x2e, 0x54, 0x5d, 0xa4, 0x9f, 0xec, 0xe2, 0xea, 0xdb, 0x2e, 0xd6, 0xc5,
+	0x73, 0x30, 0x3c, 0x1f, 0x98, 0xcf, 0x6b, 0x94, 0x22, 0x0e, 0xd4, 0x2f, 0xf1, 0x99, 0xfa, 0x19,
+	0x5c, 0x60, 0x01, 0xc4, 0xc1, 0xa3, 0xb5, 0x7c, 0x07, 0x96, 0xb8, 0xb2, 0x3c, 0x90, 0x57, 0x12,
+	0x9a, 0x69, 0x11, 0x4d, 0x7d, 0x3b, 0xbc, 0x36, 0x32, 0xb2, 0xd9, 0x8b, 0xfc, 0x2f, 0x25, 0x40,
+	0x49, 0x2e, 0x1e, 0xd6, 0xb3, 0x4d, 0xf1, 0x66, 0x2e, 0x18, 0x74, 0x07, 0x2a, 0x7d, 0xdb, 0x0f,
+	0x88, 0x1e, 0x60, 0xec, 0x52, 0xe9, 0xf9, 0xa9, 0xd2, 0x2b, 0xa1, 0x40, 0x0f, 0x63, 0xb7, 0x4d,
+	0xd0, 0xff, 0xc3, 0xea, 0xd0, 0x48, 0x88, 0x2f, 0x4c, 0x15, 0x87, 0xa1, 0x11, 0x49, 0xd3, 0x55,
+	0x61, 0xd7, 0xdb, 0x8f, 0x5b, 0x95, 0x77, 0xe1, 0x02, 0xbb, 0xe2, 0xa6, 0x2c, 0xcc, 0x6f, 0x4b,
+	0x22, 0xa8, 0x7a, 0xc4, 0x20, 0x01, 0xfa, 0x08, 0xca, 0x22, 0x6c, 0xea, 0xca, 0x54, 0x95, 0x63,
+	0x66, 0xd4, 0x82, 0x75, 0xff, 0x85, 0x3e, 0x32, 0xcc, 0x63, 0x4c, 0x02, 0xdd, 0xc7, 0x26, 0xb6,
+	0x4f, 0x30, 0x4b, 0xc5, 0x16, 0xb4, 0x35, 0xff, 0xc5, 0x63, 0x46, 0xd1, 0x38, 0x01, 0x7d, 0x08,
+	0x97, 0x24, 0xfc, 0xba, 0x77, 0x1c, 0x2e, 0xd3, 0x82, 0xb6, 0x3e, 0x21, 0xf2, 0xe8, 0x98, 0x4e,
+	0x42, 0x24, 0x93, 0xcc, 0xb3, 0x49, 0xc8, 0xc4, 0x24, 0xb7, 0x00, 0x25, 0xf8, 0xb1, 0x63, 0x13,
+	0x82, 0xad, 0x70, 0x29, 0x16, 0xb4, 0x9a, 0x60, 0xef, 0xb2, 0x71, 0xf5, 0xdf, 0x0a, 0x5c, 0x8a,
+	0xc3, 0x34, 0x74, 0x48, 0xe4, 0xb8, 0xab, 0x00, 0xd1, 0xa6, 0x16, 0x0e, 0x2c, 0xf3, 0x91, 0x03,
+	0x6a, 0xcc, 0xb2, 0xed, 0x12, 0xec, 0x9f, 0x18, 0xc3, 0xd0, 0xe2, 0xea, 0xee, 0x06, 0x5d, 0x97,
+	0xf6, 0x60, 0xe0, 0xe3, 0x01, 0x3f, 0x97, 0x18, 0x59, 0x13, 0x8c, 0xa8, 0x03, 0xe7, 0x03, 0x62,
+	0xf8, 0x24, 0xde, 0xa8, 0x33, 0x44, 0x68, 0x35, 0x14, 0x11, 0xdf, 0xe8, 0x67, 0x50, 0xc1, 0xae,
+	0x95, 0x80, 0x98, 0x1e, 0xa6, 0xab, 0xd8, 0xb5, 0xc4, 0x97, 0xda, 0x81, 0x8d, 0x09, 0x9b, 0xf9,
+	0xfe, 0xdc, 0x86, 0x45, 0x1f, 0x07, 0xe3, 0x21, 0xa9, 0x2b, 0x13, 0x67, 0x13, 0xe3, 0xe4, 0x74,
+	0xf5, 0x9f, 0x0a, 0x9c, 0x67, 0x77, 0x9c, 0xb8, 0x7c, 0xf2, 0x6f, 0x9d, 0x2d, 0x58, 0xe9, 0xfb,
+	0x8e, 0xb8, 0x25, 0xd8, 0xc1, 0x04, 0x7d, 0xdf, 0x89, 0x6e, 0x89, 0x75, 0x58, 0x08, 0xf3, 0x8a,
+	0xd0, 0x1d, 0x15, 0x6d, 0x9e, 0x66, 0x2d, 0xe8, 0x22, 0x2c, 0xf6, 0xf5, 0x91, 0xe7, 0x13, 0x7e,
+	0x5d, 0x2d, 0xf4, 0x1f, 0x7b, 0x3e, 0xa1, 0xa7, 0xbc, 0xe9, 0xb9, 0x7d, 0xdb, 0x77, 0xf8, 0xc2,
+	0x2e, 0x6b, 0xf1, 0x00, 0xbd, 0xce, 0x46, 0xbe, 0xed, 0xf9, 0x36, 0x39, 0xe3, 0x67, 0xbd, 0xf8,
+	0x46, 0xff, 0x07, 0x65, 0xfc, 0x62, 0x64, 0xfb, 0x98, 0xee, 0xce, 0xa5, 0xa9, 0x5e, 0x5b, 0x66,
+	0xcc, 0x6d, 0xa2, 0xde, 0x8d, 0x6a, 0x0a, 0x19, 0x8b, 0xa3, 0x58, 0xb9, 0x01, 0xf3, 0x36, 0xc1,
+	0x0e, 0xdf, 0x3e, 0xeb, 0xf1, 0xfd, 0x1f, 0x73, 0x86, 0x0c, 0xea, 0xa7, 0xd0, 0xdc, 0x1f, 0x8e,
+	0x83, 0xe7, 0x09, 0xea, 0xbe, 0xe7, 0xef, 0xe1, 0x93, 0xee, 0xe1, 0xc1, 0xd4, 0x8c, 0xe4, 0x0e,
+	0xbc, 0x2d, 0x32, 0x12, 0x01, 0x1c, 0xcc, 0x2e, 0xff, 0x04, 0xae, 0x17, 0xcb, 0xf3, 0x20, 0x78,
+	0x0f, 0x16, 0xa8, 0xb2, 0x01, 0x8f, 0x01, 0xa9, 0x39, 0x8c, 0x83, 0xab, 0xf4, 0x10, 0xbf, 0x08,
+	0x73, 0xc4, 0xa1, 0xed, 0x1e, 0xd3, 0x3c, 0x70, 0x76, 0x95, 0x3e, 0x85, 0xeb, 0xc5, 0xf2, 0x5c,
+	0x25, 0x11, 0x1f, 0x4a, 0x1c, 0x1f, 0x6a, 0x1b, 0x9a, 0x3d, 0xe2, 0x63, 0xc3, 0xd9, 0xf7, 0x0d,
+	0x07, 0xdf, 0xf7, 0x06, 0xd4, 0x96, 0xcc, 0xf1, 0x57, 0xbc, 0x8b, 0xd5, 0x3f, 0x29, 0x70, 0xad,
+	0x00, 0x83, 0xcf, 0x7e, 0x07, 0x6a, 0xe3, 0x11, 0x55, 0x4e, 0xef, 0x53, 0x2e, 0x3d, 0xc0, 0x44,
+	0xd4, 0x41, 0x06, 0xa7, 0xad, 0xc3, 0x90, 0x16, 0x02, 0xf4, 0x30, 0xb9, 0x77, 0x4e, 0xab, 0x8e,
+	0x53, 0x23, 0xe8, 0x13, 0xa8, 0x5a, 0xdc, 0x3c, 0x86, 0xc0, 0xaf, 0xb4, 0x35, 0x2a, 0x2d, 0x0c,
+	0xa7, 0x84, 0x7b, 0xe7, 0xb4, 0x8a, 0x95, 0x1c, 0xf8, 0x7c, 0x09, 0x16, 0x42, 0x11, 0xf5, 0x13,
+	0xd8, 0x9a, 0xd4, 0x74, 0xc6, 0x14, 0xf8, 0x8f, 0x0a, 0x34, 0xf3, 0x85, 0xff, 0x9b, 0xac, 0x7c,
+	0x16, 0xa6, 0x0d, 0xcf, 0x58, 0x42, 0x27, 0x54, 0xab, 0xc3, 0x52, 0x94, 0x00, 0x52, 0x8d, 0xca,
+	0x5a, 0xf4, 0x89, 0xde, 0xa5, 0x07, 0xd6, 0x20, 0x4a, 0xd3, 0xaa, 0xbb, 0xd5, 0x28, 0x4d, 0xd3,
+	0xc2, 0x51, 0x8d, 0x53, 0xd5, 0xdf, 0x28, 0x50, 0xbd, 0x9b, 0xca, 0xc4, 0x26, 0x72, 0x3e, 0x9a,
+	0x08, 0x3f, 0x37, 0x5c, 0x17, 0x0f, 0x83, 0x7a, 0xa9, 0x39, 0x47, 0x4f, 0x8e, 0xe8, 0x1b, 0x75,
+	0xa1, 0x8a, 0x5f, 0x10, 0xdf, 0xd0, 0x05, 0xc7, 0x5c, 0xb8, 0x37, 0xde, 0x4a, 0x9c, 0x8f, 0x1c,
+	0xb7, 0x4b, 0xf9, 0x3a, 0x8c, 0x4d, 0xab, 0xe0, 0xc4, 0x57, 0xa0, 0xfe, 0x43, 0x81, 0x46, 0x3e,
+	0x37, 0xda, 0x05, 0x70, 0x3c, 0x6b, 0x3c, 0x8c, 0x1f, 0x13, 0xd5, 0x5d, 0x14, 0x19, 0xf4, 0x40,
+	0x50, 0xb4, 0x04, 0x57, 0x3a, 0xe7, 0x2d, 0x65, 0x73, 0xde, 0x4d, 0x28, 0x1f, 0x19, 0xae, 0x75,
+	0x6a, 0x5b, 0xe4, 0x39, 0x3f, 0x5b, 0xe3, 0x01, 0xea, 0xd6, 0x23, 0x9b, 0xf8, 0x06, 0xc1, 0xfc,
+	0x84, 0x8d, 0x3e, 0xd1, 0xfb, 0xb0, 0x16, 0x8c, 0x7c, 0x6c, 0x58, 0xb4, 0x16, 0xd2, 0x37, 0x4c,
+	0xe2, 0xf9, 0xec, 0x75, 0x50, 0xd1, 0x6a, 0x82, 0xb0, 0xcf, 0xc6, 0xe3, 0x6a, 0x6e, 0xda, 0xb4,
+	0x44, 0x11, 0x31, 0x93, 0x1d, 0x27, 0x8b, 0x88, 0x19, 0x99, 0x6a, 0x3a, 0x5d, 0x8e, 0xab, 0xb9,
+	0x59, 0xec, 0xc2, 0x6a, 0xae, 0x5c, 0x91, 0x9c, 0x6a, 0x6e, 0x0e, 0xf2, 0xab, 0xa8, 0xfd, 0xa6,
+	0xab, 0xb9, 0xaf, 0x61, 0x21, 0x44, 0x35, 0x77, 0x36, 0xdf, 0xfe, 0x50, 0x82, 0xea, 0x83, 0xf1,
+	0x90, 0xd8, 0xa6, 0x11, 0x90, 0xbb, 0xbe, 0x37, 0x1e, 0x4d, 0xec, 0xb7, 0x0d, 0x58, 0x72, 0xcc,
+	0x64, 0xd5, 0x64, 0xd1, 0x31, 0xc3, 0xa2, 0xc9, 0x16, 0xac, 0x3a, 0x26, 0xaf, 0x87, 0xc4, 0x15,
+	0x93, 0xb2, 0x63, 0xd2, 0x62, 0x08, 0x2d, 0x73, 0x88, 0xdb, 0x60, 0x3e, 0x91, 0x2d, 0xdc, 0x06,
+	0x18, 0xd0, 0x79, 0x74, 0x72, 0x36, 0xc2, 0x61, 0x5e, 0x50, 0xdd, 0xbd, 0x44, 0x0d, 0x4b, 0xab,
+	0xf1, 0xf4, 0x6c, 0x84, 0xb5, 0xf2, 0x20, 0xfa, 0x37, 0xfb, 0x2a, 0x4c, 0xef, 0xa7, 0xa5, 0xec,
+	0x7e, 0xda, 0x86, 0xda, 0x88, 0x6e, 0x89, 0x60, 0xe8, 0x11, 0x7d, 0x84, 0x7d, 0xdb, 0xb3, 0x78,
+	0xa5, 0xa4, 0x4a, 0xc7, 0x7b, 0x43, 0x8f, 0x3c, 0x0e, 0x47, 0x73, 0x2a, 0x8f, 0xe5, 0x97, 0xaa,
+	0x3c, 0x82, 0xbc, 0xf2, 0x18, 0x6f, 0xb8, 0xb4, 0x69, 0x89, 0x75, 0x76, 0x22, 0x82, 0x1e, 0x5a,
+	0x9a, 0x5c, 0xe7, 0x8c, 0x4c, 0xd5, 0x49, 0x7d, 0xc7, 0x1b, 0x2e, 0x8b, 0x5d, 0xb8, 0xe1, 0xe4,
+	0x8a, 0xe4, 0x6c, 0xb8, 0x1c, 0xe4, 0x57, 0x51, 0xfb, 0x4d, 0x6f, 0xb8, 0xd7, 0xb0, 0x10, 0x62,
+	0xc3, 0xcd, 0xe6, 0x5b, 0x1b, 0x9a, 0x6d, 0xcb, 0x62, 0x57, 0xfa, 0x53, 0x4f, 0x2e, 0x93, 0x9b,
+	0x9f, 0xdf, 0x02, 0x94, 0x51, 0x34, 0xae, 0xa9, 0xd7, 0xd2, 0x7a, 0x1d, 0x58, 0xaa, 0x0b, 0xef,
+	0x68, 0xd8, 0xf1, 0x4e, 0x78, 0x36, 0xbc, 0xef, 0x7b, 0xce, 0x6b, 0x9d, 0xef, 0x77, 0x0a, 0x20,
+	0x31, 0x41, 0xfc, 0xda, 0x90, 0x83, 0x28, 0x72, 0x90, 0xf8, 0xcc, 0x28, 0x49, 0x5f, 0x18, 0x73,
+	0xc9, 0x17, 0x46, 0xe6, 0xb9, 0x32, 0x9f, 0x7d, 0xae, 0xa8, 0x43, 0x68, 0x76, 0xdd, 0xef, 0xa9,
+	0x26, 0x93, 0x7a, 0x45, 0xc6, 0xdf, 0x83, 0x0b, 0xb1, 0x7a, 0x21, 0xaf, 0x9e, 0x78, 0x23, 0xa4,
+	0x4f, 0xa6, 0x58, 0x18, 0x39, 0x13, 0x63, 0xea, 0x77, 0xf0, 0x7e, 0xf8, 0x68, 0x48, 0xb3, 0xef,
+	0x7b, 0xbe, 0xdc, 0xeb, 0x2f, 0xe5, 0x17, 0xf5, 0xe7, 0xd0, 0x4a, 0x6e, 0xc9, 0xd4, 0xbb, 0xe0,
+	0xa7, 0xc0, 0xff, 0x15, 0xec, 0xcc, 0x8c, 0xcf, 0x0f, 0x82, 0x2f, 0xe0, 0xa2, 0xcc, 0x73, 0xd1,
+	0x7b, 0x24, 0xcf, 0x75, 0xeb, 0x93, 0xae, 0x0b, 0x6e, 0x6e, 0xc2, 0xb2, 0xf6, 0xf5, 0x57, 0xb6,
+	0x6b, 0x79, 0xa7, 0x68, 0x09, 0xe6, 0xb4, 0xaf, 0xff, 0xb7, 0x76, 0x8e, 0xfd, 0xb3, 0x5b, 0x53,
+	0x6e, 0x0e, 0x61, 0x5d, 0xf2, 0x60, 0x47, 0x00, 0x8b, 0xbd, 0x6e, 0xe7, 0xd1, 0xc3, 0xbd, 0xda,
+	0x39, 0xfa, 0xff, 0x83, 0x83, 0x87, 0x87, 0x4f, 0xbb, 0x35, 0x05, 0x2d, 0xc3, 0xfc, 0xbd, 0x47,
+	0x87, 0x5a, 0xad, 0x44, 0x11, 0xf6, 0xda, 0xdf, 0xd4, 0xe6, 0xe8, 0xd0, 0x57, 0xdd, 0xee, 0x97,
+	0xb5, 0x79, 0x54, 0x86, 0x85, 0x07, 0x8f, 0x1e, 0x3e, 0xbd, 0x57, 0x5b, 0x40, 0x2b, 0xb0, 0xf4,
+	0xe4, 0xb0, 0xad, 0x3d, 0xed, 0x6a, 0xb5, 0x45, 0xca, 0xf1, 0x4d, 0xb7, 0xad, 0xd5, 0x96, 0x6e,
+	0xb6, 0x00, 0xa5, 0x2d, 0x0e, 0x2f, 0xa0, 0x15, 0x58, 0xea, 0xdc, 0x6f, 0xf7, 0x7a, 0x7a, 0xa7,
+	0x76, 0x2e, 0xfe, 0xf8, 0xbc, 0xa6, 0xec, 0xfe, 0x6b, 0x0b, 0x2e, 0x3c, 0xc4, 0xe4, 0xd4, 0xf3,
+	0x8f, 0x7b, 0xe1, 0x6f, 0x2d, 0x78, 0x73, 0x1d, 0x7d, 0x17, 0x15, 0xf0, 0xd2, 0xdd, 0x76, 0xb4,
+	0x45, 0x3d, 0x53, 0xf0, 0x63, 0x8b, 0x46, 0x33, 0x9f, 0x81, 0xf9, 0x5e, 0x3d, 0x87, 0xb4, 0xb0,
+	0xbc, 0x97, 0x41, 0xde, 0xa4, 0x82, 0x79, 0x3f, 0x9d, 0x68, 0x5c, 0xcd, 0xa1, 0x0a, 0xcc, 0x27,
+	0x51, 0x6d, 0x4b, 0xa6, 0x70, 0xc1, 0x8f, 0x12, 0x1a, 0x97, 0x26, 0xce, 0xe1, 0x2e, 0xfd, 0x41,
+	0x0b, 0x83, 0x94, 0xfd, 0xe2, 0x80, 0x41, 0x16, 0xfc, 0x16, 0xa1, 0x00, 0x52, 0xb8, 0x35, 0xdd,
+	0xb0, 0x4e, 0xba, 0x55, 0xda, 0xca, 0x6e, 0x34, 0xf3, 0x19, 0x32, 0x6e, 0xcd, 0x20, 0x47, 0x6e,
+	0x95, 0xc3, 0x5e, 0xcd, 0xa1, 0x4e, 0xba, 0x55, 0xa6, 0x70, 0x41, 0x5f, 0x7f, 0x16, 0xb7, 0xca,
+	0x20, 0x0b, 0xda, 0xf9, 0x05, 0x90, 0x5f, 0xa7, 0xfb, 0x99, 0x11, 0xe2, 0x5b, 0xb1, 0xd3, 0x64,
+	0xad, 0xe1, 0xc6, 0x56, 0x2e, 0x5d, 0xd8, 0xff, 0x28, 0xd1, 0xee, 0x8c, 0x60, 0xaf, 0x70, 0xa7,
+	0x49, 0x31, 0x37, 0xe5, 0xc4, 0x04, 0xe0, 0xba, 0xa4, 0x09, 0xce, 0x54, 0xcd, 0xef, 0x8e, 0x17,
+	0xd8, 0xfe, 0x28, 0xdd, 0x78, 0x4c, 0x01, 0xe6, 0xb7, 0xc5, 0x0b, 0x00, 0xdb, 0xb0, 0x9a, 0xf4,
+	0x09, 0xda, 0xc8, 0x7a, 0x69, 0x3a, 0xc4, 0x27, 0x50, 0x16, 0x2e, 0x40, 0x17, 0x52, 0x1e, 0x89,
+	0x84, 0x2f, 0x66, 0x46, 0x85, 0x83, 0xda, 0xb0, 0x9a, 0xf4, 0x03, 0x9b, 0x5e, 0xd2, 0x95, 0x2d,
+	0xb6, 0x20, 0x69, 0x39, 0x83, 0x90, 0x74, 0x67, 0x0b, 0x20, 0xba, 0x50, 0x4d, 0x77, 0x18, 0xd1,
+	0xe5, 0xb0, 0xf6, 0x2a, 0xeb, 0x0b, 0x16, 0xc0, 0x1c, 0xd0, 0x26, 0x6f, 0xba, 0x99, 0xc8, 0xc2,
+	0x27, 0xa7, 0xc5, 0x58, 0x1c, 0xe3, 0x92, 0x66, 0x21, 0x5b, 0xe7, 0xfc, 0xe6, 0x63, 0x63, 0x2b,
+	0x97, 0x2e, 0x3c, 0xde, 0x83, 0x8b, 0xd2, 0xd2, 0x23, 0x6a, 0x66, 0x57, 0x3e, 0x9b, 0x81, 0x14,
+	0x9e, 0x74, 0x97, 0x73, 0xcb, 0x90, 0xe8, 0x3a, 0x05, 0x9e, 0x56, 0xa5, 0x2c, 0x00, 0x0f, 0x60,
+	0xb3, 0xa8, 0xcc, 0x88, 0x6e, 0xa4, 0x8c, 0xce, 0x2f, 0x64, 0x36, 0xb6, 0xa7, 0x33, 0x0a, 0x37,
+	0xb1, 0x49, 0x73, 0x0b, 0x89, 0x62, 0xd2, 0x69, 0xa5, 0xca, 0xc6, 0xf6, 0x74, 0x46, 0x31, 0xe9,
+	0x17, 0x50, 0xcb, 0x36, 0x70, 0x51, 0x8e, 0x5f, 0xc4, 0xd1, 0x23, 0x6d, 0xf7, 0xb2, 0x25, 0xc9,
+	0xed, 0xea, 0xb2, 0x25, 0x99, 0xd6, 0xf4, 0x2d, 0x58, 0x92, 0x43, 0xb8, 0x24, 0x6f, 0xe3, 0xa2,
+	0x6b, 0xec, 0xc7, 0x7d, 0x05, 0x2d, 0xde, 0x02, 0xd8, 0x0e, 0x54, 0x52, 0xc5, 0x19, 0x54, 0x8f,
+	0xf5, 0x4c, 0xd7, 0x61, 0x0b, 0x40, 0x3e, 0x03, 0x88, 0x8b, 0x30, 0x28, 0x3a, 0x79, 0x26, 0xc4,
+	0x33, 0xc3, 0xc2, 0x6f, 0x1d, 0xa8, 0xa4, 0x6a, 0x1e, 0x4c, 0x07, 0x59, 0x27, 0xad, 0xd8, 0x90,
+	0x54, 0x71, 0x83, 0x81, 0xc8, 0xfa, 0x69, 0xb3, 0xa4, 0x0f, 0x99, 0x3a, 0xe3, 0xd6, 0x84, 0x53,
+	0xf2, 0xd3, 0x07, 0x79, 0x2d, 0x4a, 0xa4, 0x0f, 0x19, 0xe4, 0xcd, 0xb4, 0x57, 0x72, 0xd2, 0x87,
+	0x5c, 0xcc, 0x27, 0x99, 0x8e, 0xa3, 0x24, 0x7d, 0x90, 0x23, 0xcf, 0x90, 0x3e, 0xc8, 0x20, 0x0b,
+	0xea, 0x47, 0x05, 0x90, 0xf7, 0xe1, 0x7c, 0xa6, 0x5b, 0x85, 0x1a, 0x69, 0xcb, 0x92, 0x6d, 0xbb,
+	0xc6, 0x15, 0x29, 0x4d, 0xd8, 0x3c, 0x84, 0xcb, 0xb9, 0xf5, 0x7e, 0xb6, 0xcd, 0xa6, 0xb5, 0x14,
+	0x1a, 0xef, 0x4c, 0xe1, 0x8a, 0xe6, 0xfa, 0x1f, 0x05, 0xd9, 0x50, 0xcf, 0x2b, 0xbb, 0xa3, 0xb7,
+	0xe5, 0x30, 0xe9, 0x1b, 0xe7, 0x7a, 0x31, 0x53, 0x62, 0x2a, 0x11, 0x7d, 0x99, 0xaa, 0x5b, 0x22,
+	0xfa, 0xa4, 0xcf, 0xb9, 0x46, 0x33, 0x9f, 0x21, 0x13, 0x7d, 0x19, 0xe4, 0x28, 0xfa, 0xe4, 0xb0,
+	0x57, 0x73, 0xa8, 0x93, 0xd1, 0x27, 0x53, 0xb8, 0xa0, 0xaa, 0x32, 0x4b, 0xf4, 0xc9, 0x20, 0x0b,
+	0x8a, 0x29, 0xc5, 0x37, 0x65, 0x6e, 0x59, 0x85, 0xc5, 0xcb, 0xb4, 0xaa, 0x4b, 0x01, 0x38, 0x86,
+	0xb7, 0x8a, 0x0b, 0x29, 0xe8, 0x3d, 0x3a, 0xc3, 0x4c, 0xc5, 0x96, 0x62, 0x1b, 0x72, 0xab, 0x15,
+	0xcc, 0x86, 0x69, 0xc5, 0x8c, 0x02, 0xf0, 0xef, 0xe1, 0xfa, 0x2c, 0xc5, 0x09, 0xb4, 0x23, 0xb2,
+	0x8a, 0xd9, 0xca, 0x18, 0x05, 0x53, 0xfe, 0x5e, 0x81, 0x1b, 0x33, 0xd6, 0x14, 0xd0, 0x6e, 0x36,
+	0x0c, 0xa7, 0x17, 0x38, 0x1a, 0x1f, 0xbe, 0x94, 0x8c, 0x08, 0xe8, 0x3b, 0x00, 0x71, 0xeb, 0x2a,
+	0x37, 0x0f, 0x88, 0x6e, 0xb2, 0x4c, 0x8b, 0x4b, 0x3d, 0x77, 0xb4, 0x18, 0x72, 0x7e, 0xf8, 0x9f,
+	0x01, 0x00, 0xcf, 0xaf, 0x59, 0x94, 0xf3, 0x31, 0x00, 0x00,
 }
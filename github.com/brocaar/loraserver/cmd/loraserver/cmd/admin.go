@@ -0,0 +1,534 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/loraserver/internal/common"
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/storage"
+	"github.com/brocaar/lorawan"
+)
+
+var adminDryRun bool
+var adminExportFile string
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Device-session and queue administration commands",
+	Long: `Administration commands operating directly on the configured Redis and PostgreSQL databases.
+	Commands that modify data support the --dry-run flag, in which case the modifications are only logged.`,
+}
+
+var adminListDSCmd = &cobra.Command{
+	Use:     "list-ds",
+	Short:   "List the DevEUIs of all device-sessions",
+	Example: `loraserver admin list-ds`,
+	Run: func(cmd *cobra.Command, args []string) {
+		adminSetup(false)
+
+		devEUIs, err := storage.GetDevEUIsForDeviceSessions(config.C.Redis.Pool)
+		if err != nil {
+			log.WithError(err).Fatal("get device-sessions error")
+		}
+
+		for _, devEUI := range devEUIs {
+			fmt.Println(devEUI)
+		}
+	},
+}
+
+var adminExportDSCmd = &cobra.Command{
+	Use:     "export-ds",
+	Short:   "Export device-sessions as JSON (all device-sessions when no DevEUIs are given)",
+	Example: `loraserver admin export-ds --file sessions.json 0102030405060708`,
+	Run: func(cmd *cobra.Command, args []string) {
+		adminSetup(false)
+
+		devEUIs, err := adminParseDevEUIs(args)
+		if err != nil {
+			log.WithError(err).Fatal("decode DevEUI error")
+		}
+
+		sessions, err := adminExportDeviceSessions(devEUIs)
+		if err != nil {
+			log.WithError(err).Fatal("export device-sessions error")
+		}
+
+		b, err := json.MarshalIndent(sessions, "", "    ")
+		if err != nil {
+			log.WithError(err).Fatal("json marshal error")
+		}
+
+		if adminExportFile == "" {
+			fmt.Println(string(b))
+			return
+		}
+
+		if err := ioutil.WriteFile(adminExportFile, b, 0600); err != nil {
+			log.WithError(err).Fatal("write file error")
+		}
+
+		log.WithFields(log.Fields{
+			"file":  adminExportFile,
+			"count": len(sessions),
+		}).Info("device-sessions exported")
+	},
+}
+
+var adminImportDSCmd = &cobra.Command{
+	Use:     "import-ds",
+	Short:   "Import device-sessions from a JSON file (as created by export-ds)",
+	Example: `loraserver admin import-ds sessions.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("path to JSON file must be given as an argument")
+		}
+
+		adminSetup(false)
+
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			log.WithError(err).Fatal("read file error")
+		}
+
+		var sessions []storage.DeviceSession
+		if err := json.Unmarshal(b, &sessions); err != nil {
+			log.WithError(err).Fatal("json unmarshal error")
+		}
+
+		if err := adminImportDeviceSessions(sessions); err != nil {
+			log.WithError(err).Fatal("import device-sessions error")
+		}
+	},
+}
+
+var adminResetFCntCmd = &cobra.Command{
+	Use:     "reset-fcnt",
+	Short:   "Reset the frame-counters of the device-session",
+	Example: `loraserver admin reset-fcnt 0102030405060708`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("hex encoded DevEUI must be given as an argument")
+		}
+
+		adminSetup(false)
+
+		devEUIs, err := adminParseDevEUIs(args)
+		if err != nil {
+			log.WithError(err).Fatal("decode DevEUI error")
+		}
+
+		if err := adminResetFCnt(devEUIs[0]); err != nil {
+			log.WithError(err).Fatal("reset frame-counters error")
+		}
+	},
+}
+
+var adminSetDevAddrCmd = &cobra.Command{
+	Use:     "set-devaddr",
+	Short:   "Set the DevAddr of the device-session (a random DevAddr is used when no DevAddr is given)",
+	Example: `loraserver admin set-devaddr 0102030405060708 01020304`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 && len(args) != 2 {
+			log.Fatalf("hex encoded DevEUI and optionally DevAddr must be given as arguments")
+		}
+
+		adminSetup(false)
+
+		devEUIs, err := adminParseDevEUIs(args[:1])
+		if err != nil {
+			log.WithError(err).Fatal("decode DevEUI error")
+		}
+
+		var devAddr lorawan.DevAddr
+		if len(args) == 2 {
+			if err := devAddr.UnmarshalText([]byte(args[1])); err != nil {
+				log.WithError(err).Fatal("decode DevAddr error")
+			}
+		} else {
+			devAddr, err = storage.GetRandomDevAddr(config.C.Redis.Pool, config.C.NetworkServer.NetID)
+			if err != nil {
+				log.WithError(err).Fatal("get random DevAddr error")
+			}
+		}
+
+		if err := adminSetDevAddr(devEUIs[0], devAddr); err != nil {
+			log.WithError(err).Fatal("set DevAddr error")
+		}
+	},
+}
+
+var adminDeviceQueueCmd = &cobra.Command{
+	Use:     "device-queue",
+	Short:   "Print the device-queue as JSON",
+	Example: `loraserver admin device-queue 0102030405060708`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("hex encoded DevEUI must be given as an argument")
+		}
+
+		adminSetup(true)
+
+		devEUIs, err := adminParseDevEUIs(args)
+		if err != nil {
+			log.WithError(err).Fatal("decode DevEUI error")
+		}
+
+		items, err := storage.GetDeviceQueueItemsForDevEUI(config.C.PostgreSQL.DB, devEUIs[0])
+		if err != nil {
+			log.WithError(err).Fatal("get device-queue items error")
+		}
+
+		adminPrintJSON(items)
+	},
+}
+
+var adminFlushDeviceQueueCmd = &cobra.Command{
+	Use:     "flush-device-queue",
+	Short:   "Flush the device-queue",
+	Example: `loraserver admin flush-device-queue 0102030405060708`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("hex encoded DevEUI must be given as an argument")
+		}
+
+		adminSetup(true)
+
+		devEUIs, err := adminParseDevEUIs(args)
+		if err != nil {
+			log.WithError(err).Fatal("decode DevEUI error")
+		}
+
+		if err := adminFlushDeviceQueue(devEUIs[0]); err != nil {
+			log.WithError(err).Fatal("flush device-queue error")
+		}
+	},
+}
+
+var adminMulticastQueueCmd = &cobra.Command{
+	Use:     "multicast-queue",
+	Short:   "Print the multicast-queue as JSON",
+	Example: `loraserver admin multicast-queue 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("multicast-group ID must be given as an argument")
+		}
+
+		adminSetup(true)
+
+		id, err := uuid.FromString(args[0])
+		if err != nil {
+			log.WithError(err).Fatal("decode multicast-group ID error")
+		}
+
+		items, err := storage.GetMulticastQueueItemsForMulticastGroup(config.C.PostgreSQL.DB, id)
+		if err != nil {
+			log.WithError(err).Fatal("get multicast-queue items error")
+		}
+
+		adminPrintJSON(items)
+	},
+}
+
+var adminFlushMulticastQueueCmd = &cobra.Command{
+	Use:     "flush-multicast-queue",
+	Short:   "Flush the multicast-queue",
+	Example: `loraserver admin flush-multicast-queue 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatalf("multicast-group ID must be given as an argument")
+		}
+
+		adminSetup(true)
+
+		id, err := uuid.FromString(args[0])
+		if err != nil {
+			log.WithError(err).Fatal("decode multicast-group ID error")
+		}
+
+		if err := adminFlushMulticastQueue(id); err != nil {
+			log.WithError(err).Fatal("flush multicast-queue error")
+		}
+	},
+}
+
+var adminPurgeCmd = &cobra.Command{
+	Use:     "purge",
+	Short:   "Purge gateway rx-info sets and pending mac-commands of devices without device-session",
+	Example: `loraserver admin purge --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		adminSetup(false)
+
+		if err := adminPurgeDeviceGatewayRXInfoSets(); err != nil {
+			log.WithError(err).Fatal("purge device gateway rx-info sets error")
+		}
+
+		if err := adminPurgePendingMACCommands(); err != nil {
+			log.WithError(err).Fatal("purge pending mac-commands error")
+		}
+	},
+}
+
+func init() {
+	adminCmd.PersistentFlags().BoolVar(&adminDryRun, "dry-run", false, "only log the modifications, without applying them")
+	adminExportDSCmd.Flags().StringVar(&adminExportFile, "file", "", "path of the file to write to (default stdout)")
+
+	adminCmd.AddCommand(adminListDSCmd)
+	adminCmd.AddCommand(adminExportDSCmd)
+	adminCmd.AddCommand(adminImportDSCmd)
+	adminCmd.AddCommand(adminResetFCntCmd)
+	adminCmd.AddCommand(adminSetDevAddrCmd)
+	adminCmd.AddCommand(adminDeviceQueueCmd)
+	adminCmd.AddCommand(adminFlushDeviceQueueCmd)
+	adminCmd.AddCommand(adminMulticastQueueCmd)
+	adminCmd.AddCommand(adminFlushMulticastQueueCmd)
+	adminCmd.AddCommand(adminPurgeCmd)
+}
+
+// adminSetup sets up the Redis connection pool and, when withDB is set,
+// the PostgreSQL connection.
+func adminSetup(withDB bool) {
+	config.C.Redis.Pool = common.NewRedisPool(
+		config.C.Redis.URL,
+		config.C.Redis.MaxIdle,
+		config.C.Redis.IdleTimeout,
+	)
+
+	if withDB {
+		db, err := common.OpenDatabase(config.C.PostgreSQL.DSN)
+		if err != nil {
+			log.WithError(err).Fatal("database connection error")
+		}
+		config.C.PostgreSQL.DB = db
+	}
+}
+
+func adminParseDevEUIs(args []string) ([]lorawan.EUI64, error) {
+	var out []lorawan.EUI64
+	for _, arg := range args {
+		var devEUI lorawan.EUI64
+		if err := devEUI.UnmarshalText([]byte(arg)); err != nil {
+			return nil, errors.Wrapf(err, "decode %s error", arg)
+		}
+		out = append(out, devEUI)
+	}
+	return out, nil
+}
+
+func adminSaveDeviceSession(ds storage.DeviceSession) error {
+	if adminDryRun {
+		log.WithFields(log.Fields{
+			"dev_eui":  ds.DevEUI,
+			"dev_addr": ds.DevAddr,
+		}).Info("dry-run: device-session would be saved")
+		return nil
+	}
+
+	return storage.SaveDeviceSession(config.C.Redis.Pool, ds)
+}
+
+// adminExportDeviceSessions returns the device-sessions for the given DevEUIs,
+// or all device-sessions when no DevEUIs are given.
+func adminExportDeviceSessions(devEUIs []lorawan.EUI64) ([]storage.DeviceSession, error) {
+	if len(devEUIs) == 0 {
+		var err error
+		devEUIs, err = storage.GetDevEUIsForDeviceSessions(config.C.Redis.Pool)
+		if err != nil {
+			return nil, errors.Wrap(err, "get device-sessions error")
+		}
+	}
+
+	sessions := []storage.DeviceSession{}
+	for _, devEUI := range devEUIs {
+		ds, err := storage.GetDeviceSession(config.C.Redis.Pool, devEUI)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get device-session %s error", devEUI)
+		}
+		sessions = append(sessions, ds)
+	}
+
+	return sessions, nil
+}
+
+func adminImportDeviceSessions(sessions []storage.DeviceSession) error {
+	for _, ds := range sessions {
+		if err := adminSaveDeviceSession(ds); err != nil {
+			return errors.Wrapf(err, "save device-session %s error", ds.DevEUI)
+		}
+	}
+
+	return nil
+}
+
+func adminResetFCnt(devEUI lorawan.EUI64) error {
+	ds, err := storage.GetDeviceSession(config.C.Redis.Pool, devEUI)
+	if err != nil {
+		return errors.Wrap(err, "get device-session error")
+	}
+
+	ds.FCntUp = 0
+	ds.NFCntDown = 0
+	ds.AFCntDown = 0
+	ds.ConfFCnt = 0
+	ds.UplinkHistory = nil
+
+	return adminSaveDeviceSession(ds)
+}
+
+// adminSetDevAddr sets the DevAddr of the device-session. The DevEUI is
+// removed from the set of the previous DevAddr, so that the previous DevAddr
+// no longer matches the device-session.
+func adminSetDevAddr(devEUI lorawan.EUI64, devAddr lorawan.DevAddr) error {
+	ds, err := storage.GetDeviceSession(config.C.Redis.Pool, devEUI)
+	if err != nil {
+		return errors.Wrap(err, "get device-session error")
+	}
+
+	logFields := log.Fields{
+		"dev_eui":      ds.DevEUI,
+		"old_dev_addr": ds.DevAddr,
+		"new_dev_addr": devAddr,
+	}
+
+	if adminDryRun {
+		log.WithFields(logFields).Info("dry-run: DevAddr would be set")
+		return nil
+	}
+
+	if _, err := storage.SetDeviceSessionDevAddr(config.C.Redis.Pool, ds, devAddr); err != nil {
+		return errors.Wrap(err, "set device-session DevAddr error")
+	}
+
+	log.WithFields(logFields).Info("DevAddr set")
+	return nil
+}
+
+func adminFlushDeviceQueue(devEUI lorawan.EUI64) error {
+	items, err := storage.GetDeviceQueueItemsForDevEUI(config.C.PostgreSQL.DB, devEUI)
+	if err != nil {
+		return errors.Wrap(err, "get device-queue items error")
+	}
+
+	logFields := log.Fields{
+		"dev_eui": devEUI,
+		"count":   len(items),
+	}
+
+	if adminDryRun {
+		log.WithFields(logFields).Info("dry-run: device-queue would be flushed")
+		return nil
+	}
+
+	if err := storage.FlushDeviceQueueForDevEUI(config.C.PostgreSQL.DB, devEUI); err != nil {
+		return errors.Wrap(err, "flush device-queue error")
+	}
+
+	log.WithFields(logFields).Info("device-queue flushed")
+	return nil
+}
+
+func adminFlushMulticastQueue(id uuid.UUID) error {
+	items, err := storage.GetMulticastQueueItemsForMulticastGroup(config.C.PostgreSQL.DB, id)
+	if err != nil {
+		return errors.Wrap(err, "get multicast-queue items error")
+	}
+
+	logFields := log.Fields{
+		"multicast_group_id": id,
+		"count":              len(items),
+	}
+
+	if adminDryRun {
+		log.WithFields(logFields).Info("dry-run: multicast-queue would be flushed")
+		return nil
+	}
+
+	if err := storage.FlushMulticastQueueForMulticastGroup(config.C.PostgreSQL.DB, id); err != nil {
+		return errors.Wrap(err, "flush multicast-queue error")
+	}
+
+	log.WithFields(logFields).Info("multicast-queue flushed")
+	return nil
+}
+
+func adminPrintJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		log.WithError(err).Fatal("json marshal error")
+	}
+
+	fmt.Println(string(b))
+}
+
+func adminPurgeDeviceGatewayRXInfoSets() error {
+	devEUIs, err := storage.GetDevEUIsForDeviceGatewayRXInfoSets(config.C.Redis.Pool)
+	if err != nil {
+		return errors.Wrap(err, "get device gateway rx-info sets error")
+	}
+
+	for _, devEUI := range devEUIs {
+		exists, err := storage.DeviceSessionExists(config.C.Redis.Pool, devEUI)
+		if err != nil {
+			return errors.Wrap(err, "device-session exists error")
+		}
+		if exists {
+			continue
+		}
+
+		if adminDryRun {
+			log.WithField("dev_eui", devEUI).Info("dry-run: device gateway rx-info set would be purged")
+			continue
+		}
+
+		if err := storage.DeleteDeviceGatewayRXInfoSet(config.C.Redis.Pool, devEUI); err != nil && err != storage.ErrDoesNotExist {
+			return errors.Wrap(err, "delete device gateway rx-info set error")
+		}
+	}
+
+	return nil
+}
+
+func adminPurgePendingMACCommands() error {
+	devEUIs, err := storage.GetDevEUIsForPendingMACCommands(config.C.Redis.Pool)
+	if err != nil {
+		return errors.Wrap(err, "get pending mac-commands error")
+	}
+
+	for _, devEUI := range devEUIs {
+		exists, err := storage.DeviceSessionExists(config.C.Redis.Pool, devEUI)
+		if err != nil {
+			return errors.Wrap(err, "device-session exists error")
+		}
+		if exists {
+			continue
+		}
+
+		cids, err := storage.GetPendingMACCommandCIDs(config.C.Redis.Pool, devEUI)
+		if err != nil {
+			return errors.Wrap(err, "get pending mac-command CIDs error")
+		}
+
+		for _, cid := range cids {
+			if adminDryRun {
+				log.WithFields(log.Fields{
+					"dev_eui": devEUI,
+					"cid":     cid,
+				}).Info("dry-run: pending mac-command would be purged")
+				continue
+			}
+
+			if err := storage.DeletePendingMACCommand(config.C.Redis.Pool, devEUI, cid); err != nil && err != storage.ErrDoesNotExist {
+				return errors.Wrap(err, "delete pending mac-command error")
+			}
+		}
+	}
+
+	return nil
+}
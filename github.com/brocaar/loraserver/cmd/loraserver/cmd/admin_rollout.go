@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/storage"
+)
+
+var adminRolloutCanary []string
+var adminRolloutStable int
+var adminRolloutTarget int
+
+var adminRolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Gateway-profile revision and rollout commands",
+	Long: `Commands to roll out gateway-profile revisions to a set of canary gateways first.
+	Once a rollout exists for a gateway-profile, new revisions are only pushed to the gateways by starting a new rollout.`,
+}
+
+var adminRolloutRevisionsCmd = &cobra.Command{
+	Use:     "revisions",
+	Short:   "Print the revisions of the gateway-profile as JSON",
+	Example: `loraserver admin rollout revisions 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := adminRolloutSetup(args)
+
+		revisions, err := storage.GetGatewayProfileRevisions(config.C.PostgreSQL.DB, id)
+		if err != nil {
+			log.WithError(err).Fatal("get gateway-profile revisions error")
+		}
+
+		adminPrintJSON(revisions)
+	},
+}
+
+var adminRolloutStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the rollout of a gateway-profile revision to the given canary gateways",
+	Long: `Start the rollout of a gateway-profile revision to the given canary gateways.
+	When no target revision is given, the latest revision is used. When no stable revision is given,
+	the revision that is currently deployed is used.`,
+	Example: `loraserver admin rollout start 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5 --canary 0102030405060708`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := adminRolloutSetup(args)
+
+		canaryIDs, err := adminParseDevEUIs(adminRolloutCanary)
+		if err != nil {
+			log.WithError(err).Fatal("decode gateway ID error")
+		}
+
+		err = storage.Transaction(config.C.PostgreSQL.DB, func(tx sqlx.Ext) error {
+			rollout := storage.GatewayProfileRollout{
+				GatewayProfileID: id,
+				StableRevision:   adminRolloutStable,
+				TargetRevision:   adminRolloutTarget,
+				CanaryGatewayIDs: canaryIDs,
+				State:            storage.RolloutStateCanary,
+			}
+
+			latest, err := storage.GetLatestGatewayProfileRevision(tx, id)
+			if err != nil {
+				return errors.Wrap(err, "get latest gateway-profile revision error")
+			}
+
+			if rollout.TargetRevision == 0 {
+				rollout.TargetRevision = latest.Revision
+			}
+
+			if rollout.StableRevision == 0 {
+				current, err := storage.GetGatewayProfileRollout(tx, id, true)
+				switch err {
+				case nil:
+					rollout.StableRevision = current.GetDefaultRevision()
+				case storage.ErrDoesNotExist:
+					// without rollout, the gateways received the latest
+					// revision
+					rollout.StableRevision = latest.Revision
+				default:
+					return errors.Wrap(err, "get gateway-profile rollout error")
+				}
+			}
+
+			for _, rev := range []int{rollout.StableRevision, rollout.TargetRevision} {
+				if _, err := storage.GetGatewayProfileRevision(tx, id, rev); err != nil {
+					return errors.Wrapf(err, "get gateway-profile revision %d error", rev)
+				}
+			}
+
+			if adminDryRun {
+				log.WithFields(log.Fields{
+					"gateway_profile_id": id,
+					"stable_revision":    rollout.StableRevision,
+					"target_revision":    rollout.TargetRevision,
+					"canary_gateway_ids": rollout.CanaryGatewayIDs,
+				}).Info("dry-run: gateway-profile rollout would be started")
+				return nil
+			}
+
+			return storage.CreateGatewayProfileRollout(tx, &rollout)
+		})
+		if err != nil {
+			log.WithError(err).Fatal("start gateway-profile rollout error")
+		}
+	},
+}
+
+var adminRolloutPromoteCmd = &cobra.Command{
+	Use:     "promote",
+	Short:   "Push the target revision of the rollout to all gateways",
+	Example: `loraserver admin rollout promote 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := adminRolloutSetup(args)
+
+		if err := adminSetRolloutState(id, storage.RolloutStateCompleted); err != nil {
+			log.WithError(err).Fatal("promote gateway-profile rollout error")
+		}
+	},
+}
+
+var adminRolloutRollbackCmd = &cobra.Command{
+	Use:     "rollback",
+	Short:   "Push the stable revision of the rollout to all gateways",
+	Example: `loraserver admin rollout rollback 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := adminRolloutSetup(args)
+
+		if err := adminSetRolloutState(id, storage.RolloutStateRolledBack); err != nil {
+			log.WithError(err).Fatal("rollback gateway-profile rollout error")
+		}
+	},
+}
+
+var adminRolloutStatusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Print the rollout and the gateway-configuration pushes as JSON",
+	Example: `loraserver admin rollout status 5a3bd0ba-1e6b-4e23-a0e3-dba5b1a4e3e5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := adminRolloutSetup(args)
+
+		rollout, err := storage.GetGatewayProfileRollout(config.C.PostgreSQL.DB, id, false)
+		if err != nil {
+			log.WithError(err).Fatal("get gateway-profile rollout error")
+		}
+
+		pushes, err := storage.GetGatewayConfigurationPushesForGatewayProfile(config.C.PostgreSQL.DB, id)
+		if err != nil {
+			log.WithError(err).Fatal("get gateway-configuration pushes error")
+		}
+
+		adminPrintJSON(struct {
+			Rollout storage.GatewayProfileRollout
+			Pushes  []storage.GatewayConfigurationPush
+		}{rollout, pushes})
+	},
+}
+
+func init() {
+	adminRolloutStartCmd.Flags().StringSliceVar(&adminRolloutCanary, "canary", nil, "IDs of the canary gateways")
+	adminRolloutStartCmd.Flags().IntVar(&adminRolloutStable, "stable", 0, "stable revision (default currently deployed revision)")
+	adminRolloutStartCmd.Flags().IntVar(&adminRolloutTarget, "target", 0, "target revision (default latest revision)")
+
+	adminRolloutCmd.AddCommand(adminRolloutRevisionsCmd)
+	adminRolloutCmd.AddCommand(adminRolloutStartCmd)
+	adminRolloutCmd.AddCommand(adminRolloutPromoteCmd)
+	adminRolloutCmd.AddCommand(adminRolloutRollbackCmd)
+	adminRolloutCmd.AddCommand(adminRolloutStatusCmd)
+
+	adminCmd.AddCommand(adminRolloutCmd)
+}
+
+// adminRolloutSetup sets up the database connections and returns the
+// gateway-profile ID given as argument.
+func adminRolloutSetup(args []string) uuid.UUID {
+	if len(args) != 1 {
+		log.Fatalf("gateway-profile ID must be given as an argument")
+	}
+
+	adminSetup(true)
+
+	id, err := uuid.FromString(args[0])
+	if err != nil {
+		log.WithError(err).Fatal("decode gateway-profile ID error")
+	}
+
+	return id
+}
+
+func adminSetRolloutState(id uuid.UUID, state storage.RolloutState) error {
+	return storage.Transaction(config.C.PostgreSQL.DB, func(tx sqlx.Ext) error {
+		rollout, err := storage.GetGatewayProfileRollout(tx, id, true)
+		if err != nil {
+			return errors.Wrap(err, "get gateway-profile rollout error")
+		}
+
+		logFields := log.Fields{
+			"gateway_profile_id": id,
+			"from_state":         rollout.State,
+			"to_state":           state,
+		}
+
+		if adminDryRun {
+			log.WithFields(logFields).Info("dry-run: gateway-profile rollout state would be updated")
+			return nil
+		}
+
+		rollout.State = state
+		return storage.UpdateGatewayProfileRollout(tx, &rollout)
+	})
+}
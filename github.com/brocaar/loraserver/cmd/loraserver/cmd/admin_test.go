@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/brocaar/loraserver/internal/maccommand"
+	"github.com/brocaar/loraserver/internal/storage"
+	"github.com/brocaar/loraserver/internal/test"
+	"github.com/brocaar/lorawan"
+)
+
+type AdminTestSuite struct {
+	suite.Suite
+	test.DatabaseTestSuiteBase
+
+	device storage.Device
+	ds     storage.DeviceSession
+}
+
+func (ts *AdminTestSuite) SetupTest() {
+	ts.DatabaseTestSuiteBase.SetupTest()
+	test.MustResetDB(ts.DB())
+	adminDryRun = false
+
+	assert := require.New(ts.T())
+
+	sp := storage.ServiceProfile{}
+	assert.NoError(storage.CreateServiceProfile(ts.DB(), &sp))
+
+	dp := storage.DeviceProfile{}
+	assert.NoError(storage.CreateDeviceProfile(ts.DB(), &dp))
+
+	rp := storage.RoutingProfile{}
+	assert.NoError(storage.CreateRoutingProfile(ts.DB(), &rp))
+
+	ts.device = storage.Device{
+		DevEUI:           lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		ServiceProfileID: sp.ID,
+		DeviceProfileID:  dp.ID,
+		RoutingProfileID: rp.ID,
+	}
+	assert.NoError(storage.CreateDevice(ts.DB(), &ts.device))
+
+	ts.ds = storage.DeviceSession{
+		DevEUI:    ts.device.DevEUI,
+		DevAddr:   lorawan.DevAddr{1, 2, 3, 4},
+		FCntUp:    10,
+		NFCntDown: 5,
+		AFCntDown: 3,
+		ConfFCnt:  2,
+	}
+	assert.NoError(storage.SaveDeviceSession(ts.RedisPool(), ts.ds))
+}
+
+func (ts *AdminTestSuite) TearDownTest() {
+	adminDryRun = false
+	ts.DatabaseTestSuiteBase.TearDownTest()
+}
+
+func (ts *AdminTestSuite) TestExportImportDeviceSessions() {
+	assert := require.New(ts.T())
+
+	sessions, err := adminExportDeviceSessions(nil)
+	assert.NoError(err)
+	assert.Len(sessions, 1)
+	assert.Equal(ts.ds.DevEUI, sessions[0].DevEUI)
+
+	sessions, err = adminExportDeviceSessions([]lorawan.EUI64{ts.ds.DevEUI})
+	assert.NoError(err)
+	assert.Len(sessions, 1)
+
+	_, err = adminExportDeviceSessions([]lorawan.EUI64{{8, 7, 6, 5, 4, 3, 2, 1}})
+	assert.Error(err)
+
+	assert.NoError(storage.DeleteDeviceSession(ts.RedisPool(), ts.ds.DevEUI))
+	assert.NoError(adminImportDeviceSessions(sessions))
+
+	ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.ds.DevEUI)
+	assert.NoError(err)
+	assert.Equal(ts.ds.DevAddr, ds.DevAddr)
+	assert.EqualValues(10, ds.FCntUp)
+}
+
+func (ts *AdminTestSuite) TestResetFCnt() {
+	ts.T().Run("Dry-run", func(t *testing.T) {
+		assert := require.New(t)
+		adminDryRun = true
+		defer func() { adminDryRun = false }()
+
+		assert.NoError(adminResetFCnt(ts.ds.DevEUI))
+
+		ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+		assert.EqualValues(10, ds.FCntUp)
+	})
+
+	ts.T().Run("Reset", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(adminResetFCnt(ts.ds.DevEUI))
+
+		ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+		assert.EqualValues(0, ds.FCntUp)
+		assert.EqualValues(0, ds.NFCntDown)
+		assert.EqualValues(0, ds.AFCntDown)
+		assert.EqualValues(0, ds.ConfFCnt)
+	})
+
+	ts.T().Run("Unknown device", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Error(adminResetFCnt(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}))
+	})
+}
+
+func (ts *AdminTestSuite) TestSetDevAddr() {
+	newDevAddr := lorawan.DevAddr{4, 3, 2, 1}
+
+	ts.T().Run("Dry-run", func(t *testing.T) {
+		assert := require.New(t)
+		adminDryRun = true
+		defer func() { adminDryRun = false }()
+
+		assert.NoError(adminSetDevAddr(ts.ds.DevEUI, newDevAddr))
+
+		ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+		assert.Equal(ts.ds.DevAddr, ds.DevAddr)
+	})
+
+	ts.T().Run("Set", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(adminSetDevAddr(ts.ds.DevEUI, newDevAddr))
+
+		ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+		assert.Equal(newDevAddr, ds.DevAddr)
+
+		sessions, err := storage.GetDeviceSessionsForDevAddr(ts.RedisPool(), newDevAddr)
+		assert.NoError(err)
+		assert.Len(sessions, 1)
+
+		// the old DevAddr must no longer refer to the device-session
+		c := ts.RedisPool().Get()
+		defer c.Close()
+		n, err := redis.Int(c.Do("SCARD", fmt.Sprintf("lora:ns:devaddr:%s", ts.ds.DevAddr)))
+		assert.NoError(err)
+		assert.Equal(0, n)
+	})
+}
+
+func (ts *AdminTestSuite) TestFlushDeviceQueue() {
+	assert := require.New(ts.T())
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(storage.CreateDeviceQueueItem(ts.DB(), &storage.DeviceQueueItem{
+			DevEUI:     ts.device.DevEUI,
+			FRMPayload: []byte{1, 2, 3},
+			FCnt:       uint32(i),
+			FPort:      10,
+		}))
+	}
+
+	ts.T().Run("Dry-run", func(t *testing.T) {
+		assert := require.New(t)
+		adminDryRun = true
+		defer func() { adminDryRun = false }()
+
+		assert.NoError(adminFlushDeviceQueue(ts.device.DevEUI))
+
+		items, err := storage.GetDeviceQueueItemsForDevEUI(ts.DB(), ts.device.DevEUI)
+		assert.NoError(err)
+		assert.Len(items, 2)
+	})
+
+	ts.T().Run("Flush", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(adminFlushDeviceQueue(ts.device.DevEUI))
+
+		items, err := storage.GetDeviceQueueItemsForDevEUI(ts.DB(), ts.device.DevEUI)
+		assert.NoError(err)
+		assert.Len(items, 0)
+	})
+}
+
+func (ts *AdminTestSuite) TestPurge() {
+	assert := require.New(ts.T())
+
+	orphan := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+	for _, devEUI := range []lorawan.EUI64{ts.ds.DevEUI, orphan} {
+		assert.NoError(storage.SaveDeviceGatewayRXInfoSet(ts.RedisPool(), storage.DeviceGatewayRXInfoSet{
+			DevEUI: devEUI,
+		}))
+		assert.NoError(storage.SetPendingMACCommand(ts.RedisPool(), devEUI, maccommand.RequestRelayConf(maccommand.RelayConfReqPayload{})))
+	}
+
+	ts.T().Run("Dry-run", func(t *testing.T) {
+		assert := require.New(t)
+		adminDryRun = true
+		defer func() { adminDryRun = false }()
+
+		assert.NoError(adminPurgeDeviceGatewayRXInfoSets())
+		assert.NoError(adminPurgePendingMACCommands())
+
+		_, err := storage.GetDeviceGatewayRXInfoSet(ts.RedisPool(), orphan)
+		assert.NoError(err)
+
+		cids, err := storage.GetPendingMACCommandCIDs(ts.RedisPool(), orphan)
+		assert.NoError(err)
+		assert.Len(cids, 1)
+	})
+
+	ts.T().Run("Purge", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(adminPurgeDeviceGatewayRXInfoSets())
+		assert.NoError(adminPurgePendingMACCommands())
+
+		_, err := storage.GetDeviceGatewayRXInfoSet(ts.RedisPool(), orphan)
+		assert.Equal(storage.ErrDoesNotExist, err)
+
+		cids, err := storage.GetPendingMACCommandCIDs(ts.RedisPool(), orphan)
+		assert.NoError(err)
+		assert.Len(cids, 0)
+
+		// the data of the device with device-session is kept
+		_, err = storage.GetDeviceGatewayRXInfoSet(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+
+		cids, err = storage.GetPendingMACCommandCIDs(ts.RedisPool(), ts.ds.DevEUI)
+		assert.NoError(err)
+		assert.Len(cids, 1)
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	suite.Run(t, new(AdminTestSuite))
+}
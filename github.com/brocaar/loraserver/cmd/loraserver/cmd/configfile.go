@@ -318,6 +318,17 @@ get_downlink_data_delay="{{ .NetworkServer.GetDownlinkDataDelay }}"
   # Class-C runs.
   scheduler_interval="{{ .NetworkServer.Scheduler.SchedulerInterval }}"
 
+  # Payload priority
+  #
+  # This defines which payload wins when the application payload and the
+  # pending mac-commands do not fit together within a single downlink.
+  # Valid options are:
+  #  * application:  the application payload is sent, mac-commands that do
+  #                  not fit are sent with a next downlink
+  #  * mac_commands: the mac-commands are sent, the application payload
+  #                  stays in the queue for a next downlink
+  payload_priority="{{ .NetworkServer.Scheduler.PayloadPriority }}"
+
     # Class-C settings.
     [network_server.scheduler.class_c]
     # Downlink lock duration
@@ -371,6 +382,26 @@ get_downlink_data_delay="{{ .NetworkServer.GetDownlinkDataDelay }}"
   aggregation_intervals=[{{ if .NetworkServer.Gateway.Stats.AggregationIntervals|len }}"{{ end }}{{ range $index, $element := .NetworkServer.Gateway.Stats.AggregationIntervals }}{{ if $index }}", "{{ end }}{{ $element }}{{ end }}{{ if .NetworkServer.Gateway.Stats.AggregationIntervals|len }}"{{ end }}]
 
 
+  # Gateway-profile rollout settings.
+  #
+  # Gateway-profile revisions can be rolled out to a set of canary gateways
+  # first (see the 'loraserver admin rollout' command).
+  [network_server.gateway.rollout]
+  # Rollback timeout
+  #
+  # When a canary gateway does not confirm the pushed configuration
+  # within this duration (by reporting the new configuration version in its
+  # stats), the rollout is rolled back to the stable revision.
+  # Set this to 0s to disable automatic rollbacks.
+  rollback_timeout="{{ .NetworkServer.Gateway.Rollout.RollbackTimeout }}"
+
+  # Check interval
+  #
+  # This defines the interval in which LoRa Server checks for rollouts
+  # that must be rolled back.
+  check_interval="{{ .NetworkServer.Gateway.Rollout.CheckInterval }}"
+
+
   # Backend defines the gateway backend settings.
   #
   # The gateway backend handles the communication with the gateway(s) part of
@@ -476,6 +507,80 @@ get_downlink_data_delay="{{ .NetworkServer.GetDownlinkDataDelay }}"
     uplink_retention_duration="{{ .NetworkServer.Gateway.Backend.GCPPubSub.UplinkRetentionDuration }}"
 
 
+  # Integration settings.
+  #
+  # The integration publishes network-server events (join, up, down, ack,
+  # error, mac_command and gateway_stats) as protobuf encoded messages to the
+  # configured sinks. These events are published in addition to the
+  # application-server and network-controller API calls.
+  [network_server.integration]
+  # Sinks.
+  #
+  # The sinks to publish the events to. Use the section names of the
+  # sinks below, e.g. ["nats", "kafka", "file"]. Leave empty to disable.
+  sinks=[{{ range $index, $element := .NetworkServer.Integration.Sinks }}{{ if $index }}, {{ end }}"{{ $element }}"{{ end }}]
+
+  # Buffer size.
+  #
+  # The number of events buffered per sink. Events are retried until the
+  # sink has accepted them. When the buffer of a sink is full, new events
+  # are dropped for that sink.
+  buffer_size={{ .NetworkServer.Integration.BufferSize }}
+
+  # Retry interval.
+  #
+  # The interval between retries when publishing to a sink fails.
+  retry_interval="{{ .NetworkServer.Integration.RetryInterval }}"
+
+    # NATS sink settings.
+    [network_server.integration.nats]
+    # NATS server (hostname:port).
+    server="{{ .NetworkServer.Integration.NATS.Server }}"
+
+    # Username (optional).
+    username="{{ .NetworkServer.Integration.NATS.Username }}"
+
+    # Password (optional).
+    password="{{ .NetworkServer.Integration.NATS.Password }}"
+
+    # Subject template.
+    #
+    # The template used to generate the subject to which the event is
+    # published. The .Type field contains the event type and the .Key field
+    # the DevEUI or Gateway ID (in case of gateway_stats).
+    subject_template="{{ .NetworkServer.Integration.NATS.SubjectTemplate }}"
+
+    # Timeout for connecting and publishing.
+    timeout="{{ .NetworkServer.Integration.NATS.Timeout }}"
+
+    # Kafka sink settings.
+    #
+    # Events are produced with the DevEUI or Gateway ID (in case of
+    # gateway_stats) as message key, so that the events of a device are
+    # written to the same partition. The sink waits for the acknowledgement
+    # of the partition leader (acks=1).
+    [network_server.integration.kafka]
+    # Kafka brokers (hostname:port), used to retrieve the cluster metadata.
+    brokers=[{{ range $index, $element := .NetworkServer.Integration.Kafka.Brokers }}{{ if $index }}, {{ end }}"{{ $element }}"{{ end }}]
+
+    # Topic template.
+    #
+    # The template used to generate the topic to which the event is
+    # published. The .Type field contains the event type and the .Key field
+    # the DevEUI or Gateway ID (in case of gateway_stats).
+    topic_template="{{ .NetworkServer.Integration.Kafka.TopicTemplate }}"
+
+    # Timeout for connecting and publishing.
+    timeout="{{ .NetworkServer.Integration.Kafka.Timeout }}"
+
+    # File sink settings.
+    #
+    # Events are appended to the file as JSON, one event per line.
+    [network_server.integration.file]
+    # Path to the events file.
+    path="{{ .NetworkServer.Integration.File.Path }}"
+
+
   # Geolocation settings.
   #
   # When set, LoRa Server will use the configured geolocation server to
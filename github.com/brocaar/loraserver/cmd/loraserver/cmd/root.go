@@ -64,6 +64,8 @@ func init() {
 
 	viper.SetDefault("network_server.gateway.stats.aggregation_intervals", []string{"minute", "hour", "day"})
 	viper.SetDefault("network_server.gateway.stats.create_gateway_on_stats", true)
+	viper.SetDefault("network_server.gateway.rollout.rollback_timeout", 5*time.Minute)
+	viper.SetDefault("network_server.gateway.rollout.check_interval", time.Minute)
 	viper.SetDefault("network_server.gateway.backend.mqtt.server", "tcp://localhost:1883")
 
 	viper.SetDefault("join_server.default.server", "http://localhost:8003")
@@ -78,6 +80,7 @@ func init() {
 	viper.SetDefault("network_server.gateway.backend.type", "mqtt")
 
 	viper.SetDefault("network_server.scheduler.scheduler_interval", 1*time.Second)
+	viper.SetDefault("network_server.scheduler.payload_priority", "application")
 	viper.SetDefault("network_server.scheduler.class_c.downlink_lock_duration", 2*time.Second)
 	viper.SetDefault("network_server.gateway.backend.mqtt.uplink_topic_template", "gateway/+/rx")
 	viper.SetDefault("network_server.gateway.backend.mqtt.downlink_topic_template", "gateway/{{ .MAC }}/tx")
@@ -88,9 +91,20 @@ func init() {
 
 	viper.SetDefault("network_server.gateway.backend.gcp_pub_sub.uplink_retention_duration", time.Hour*24)
 
+	viper.SetDefault("network_server.integration.buffer_size", 1000)
+	viper.SetDefault("network_server.integration.retry_interval", time.Second)
+	viper.SetDefault("network_server.integration.nats.server", "localhost:4222")
+	viper.SetDefault("network_server.integration.nats.subject_template", "loraserver.{{ .Type }}.{{ .Key }}")
+	viper.SetDefault("network_server.integration.nats.timeout", 5*time.Second)
+	viper.SetDefault("network_server.integration.kafka.brokers", []string{"localhost:9092"})
+	viper.SetDefault("network_server.integration.kafka.topic_template", "loraserver.{{ .Type }}")
+	viper.SetDefault("network_server.integration.kafka.timeout", 5*time.Second)
+	viper.SetDefault("network_server.integration.file.path", "/var/lib/loraserver/events.log")
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(printDSCmd)
+	rootCmd.AddCommand(adminCmd)
 }
 
 // Execute executes the root command.
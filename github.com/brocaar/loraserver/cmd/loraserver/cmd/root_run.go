@@ -36,6 +36,10 @@ import (
 	"github.com/brocaar/loraserver/internal/config"
 	"github.com/brocaar/loraserver/internal/downlink"
 	"github.com/brocaar/loraserver/internal/gateway"
+	"github.com/brocaar/loraserver/internal/integration"
+	"github.com/brocaar/loraserver/internal/integration/file"
+	"github.com/brocaar/loraserver/internal/integration/kafka"
+	"github.com/brocaar/loraserver/internal/integration/nats"
 	"github.com/brocaar/loraserver/internal/migrations"
 	"github.com/brocaar/loraserver/internal/migrations/code"
 	"github.com/brocaar/loraserver/internal/storage"
@@ -59,6 +63,7 @@ func run(cmd *cobra.Command, args []string) error {
 		setRedisPool,
 		setPostgreSQLConnection,
 		setGatewayBackend,
+		setIntegration,
 		setApplicationServer,
 		setGeolocationServer,
 		setJoinServer,
@@ -69,6 +74,7 @@ func run(cmd *cobra.Command, args []string) error {
 		startLoRaServer(server),
 		startStatsServer(gwStats),
 		startQueueScheduler,
+		startRolloutMonitor,
 	}
 
 	for _, t := range tasks {
@@ -89,6 +95,9 @@ func run(cmd *cobra.Command, args []string) error {
 		if err := gwStats.Stop(); err != nil {
 			log.Fatal(err)
 		}
+		if err := config.C.NetworkServer.Integration.Handler.Close(); err != nil {
+			log.Fatal(err)
+		}
 		exitChan <- struct{}{}
 	}()
 	select {
@@ -235,6 +244,44 @@ func setGatewayBackend() error {
 	return nil
 }
 
+func setIntegration() error {
+	var sinks []integration.Sink
+
+	for _, t := range config.C.NetworkServer.Integration.Sinks {
+		var s integration.Sink
+		var err error
+
+		switch t {
+		case "nats":
+			s, err = nats.NewSink(config.C.NetworkServer.Integration.NATS)
+		case "kafka":
+			s, err = kafka.NewSink(config.C.NetworkServer.Integration.Kafka)
+		case "file":
+			s, err = file.NewSink(config.C.NetworkServer.Integration.File)
+		default:
+			return fmt.Errorf("unexpected integration sink type: %s", t)
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "integration sink setup failed")
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		log.Info("no integration sinks configured")
+		return nil
+	}
+
+	log.WithField("sinks", config.C.NetworkServer.Integration.Sinks).Info("setup integration sinks")
+	config.C.NetworkServer.Integration.Handler = integration.NewHandler(integration.Config{
+		BufferSize:    config.C.NetworkServer.Integration.BufferSize,
+		RetryInterval: config.C.NetworkServer.Integration.RetryInterval,
+	}, sinks...)
+
+	return nil
+}
+
 func setApplicationServer() error {
 	config.C.ApplicationServer.Pool = asclient.NewPool()
 	return nil
@@ -404,6 +451,18 @@ func startQueueScheduler() error {
 	return nil
 }
 
+func startRolloutMonitor() error {
+	if config.C.NetworkServer.Gateway.Rollout.RollbackTimeout == 0 {
+		log.Info("gateway-profile rollout monitor is disabled")
+		return nil
+	}
+
+	log.Info("starting gateway-profile rollout monitor")
+	go gateway.RolloutMonitorLoop()
+
+	return nil
+}
+
 func mustGetTransportCredentials(tlsCert, tlsKey, caCert string, verifyClientCert bool) credentials.TransportCredentials {
 	cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
 	if err != nil {
@@ -28,6 +28,7 @@ var errToCode = map[error]codes.Code{
 	storage.ErrInvalidName:                    codes.InvalidArgument,
 	storage.ErrInvalidAggregationInterval:     codes.InvalidArgument,
 	storage.ErrInvalidFPort:                   codes.InvalidArgument,
+	storage.ErrInvalidPriority:                codes.InvalidArgument,
 }
 
 func errToRPCError(err error) error {
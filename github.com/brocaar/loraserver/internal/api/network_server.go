@@ -1278,6 +1278,15 @@ func (n *NetworkServerAPI) CreateDeviceQueueItem(ctx context.Context, req *ns.Cr
 		FCnt:       req.Item.FCnt,
 		FPort:      uint8(req.Item.FPort),
 		Confirmed:  req.Item.Confirmed,
+		Priority:   int(req.Item.Priority),
+	}
+
+	if req.Item.ExpireAt != nil {
+		expireAt, err := ptypes.Timestamp(req.Item.ExpireAt)
+		if err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+		}
+		qi.ExpireAt = &expireAt
 	}
 
 	// When the device is operating in Class-B and has a beacon lock, calculate
@@ -1352,6 +1361,14 @@ func (n *NetworkServerAPI) GetDeviceQueueItemsForDevEUI(ctx context.Context, req
 			FCnt:       items[i].FCnt,
 			FPort:      uint32(items[i].FPort),
 			Confirmed:  items[i].Confirmed,
+			Priority:   uint32(items[i].Priority),
+		}
+
+		if items[i].ExpireAt != nil {
+			qi.ExpireAt, err = ptypes.TimestampProto(*items[i].ExpireAt)
+			if err != nil {
+				return nil, errToRPCError(err)
+			}
 		}
 
 		out.Items = append(out.Items, &qi)
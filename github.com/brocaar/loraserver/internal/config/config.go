@@ -13,6 +13,10 @@ import (
 	"github.com/brocaar/loraserver/internal/backend/gateway/gcppubsub"
 	"github.com/brocaar/loraserver/internal/backend/gateway/mqtt"
 	"github.com/brocaar/loraserver/internal/common"
+	"github.com/brocaar/loraserver/internal/integration"
+	"github.com/brocaar/loraserver/internal/integration/file"
+	"github.com/brocaar/loraserver/internal/integration/kafka"
+	"github.com/brocaar/loraserver/internal/integration/nats"
 	"github.com/brocaar/lorawan"
 	"github.com/brocaar/lorawan/band"
 )
@@ -85,6 +89,7 @@ type Config struct {
 
 		Scheduler struct {
 			SchedulerInterval time.Duration `mapstructure:"scheduler_interval"`
+			PayloadPriority   string        `mapstructure:"payload_priority"`
 
 			ClassC struct {
 				DownlinkLockDuration time.Duration `mapstructure:"downlink_lock_duration"`
@@ -106,6 +111,11 @@ type Config struct {
 				AggregationIntervals []string `mapstructure:"aggregation_intervals"`
 			}
 
+			Rollout struct {
+				RollbackTimeout time.Duration `mapstructure:"rollback_timeout"`
+				CheckInterval   time.Duration `mapstructure:"check_interval"`
+			}
+
 			Backend struct {
 				Type      string           `mapstructure:"type"`
 				Backend   backend.Gateway  `mapstructure:"-"`
@@ -113,6 +123,16 @@ type Config struct {
 				GCPPubSub gcppubsub.Config `mapstructure:"gcp_pub_sub"`
 			}
 		}
+
+		Integration struct {
+			Sinks         []string             `mapstructure:"sinks"`
+			BufferSize    int                  `mapstructure:"buffer_size"`
+			RetryInterval time.Duration        `mapstructure:"retry_interval"`
+			Handler       *integration.Handler `mapstructure:"-"`
+			NATS          nats.Config          `mapstructure:"nats"`
+			Kafka         kafka.Config         `mapstructure:"kafka"`
+			File          file.Config          `mapstructure:"file"`
+		} `mapstructure:"integration"`
 	} `mapstructure:"network_server"`
 
 	GeolocationServer struct {
@@ -171,6 +191,12 @@ var SpreadFactorToRequiredSNRTable = map[int]float64{
 // C holds the global configuration.
 var C Config
 
+// Payload priorities, see NetworkServer.Scheduler.PayloadPriority.
+const (
+	PayloadPriorityApplication = "application"
+	PayloadPriorityMACCommands = "mac_commands"
+)
+
 // SchedulerBatchSize contains the batch size of the Class-C scheduler
 var SchedulerBatchSize = 100
 
@@ -15,6 +15,7 @@ import (
 	"github.com/brocaar/loraserver/internal/config"
 	"github.com/brocaar/loraserver/internal/framelog"
 	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/integration"
 	"github.com/brocaar/loraserver/internal/maccommand"
 	"github.com/brocaar/loraserver/internal/models"
 	"github.com/brocaar/loraserver/internal/storage"
@@ -53,12 +54,32 @@ var setMACCommandsSet = setMACCommands(
 	getMACCommandsFromQueue,
 )
 
+var setPayloadsSet = setPayloads(
+	getNextDeviceQueueItem,
+	setMACCommandsSet,
+)
+
+var setRelayedMACCommandsSet = setMACCommands(
+	requestCustomChannelReconfiguration,
+	requestChannelMaskReconfiguration,
+	requestADRChange,
+	requestDevStatus,
+	requestRejoinParamSetup,
+	setPingSlotParameters,
+	setRelayedRXParameters,
+	getMACCommandsFromQueue,
+)
+
+var setRelayedPayloadsSet = setPayloads(
+	getNextDeviceQueueItem,
+	setRelayedMACCommandsSet,
+)
+
 var responseTasks = []func(*dataContext) error{
 	getDeviceProfile,
 	setDataTXInfo,
 	setToken,
-	getNextDeviceQueueItem,
-	setMACCommandsSet,
+	setPayloadsSet,
 	stopOnNothingToSend,
 	setPHYPayloads,
 	sendDownlinkFrame,
@@ -66,6 +87,29 @@ var responseTasks = []func(*dataContext) error{
 	saveRemainingFrames,
 }
 
+var relayedResponseTasks = []func(*dataContext) error{
+	getDeviceProfile,
+	checkRelayRXDelay,
+	setTXInfoForRX1,
+	setRelayedMaxPayloadSize,
+	setRelayedPayloadsSet,
+	stopOnNothingToSend,
+	setPHYPayloads,
+	saveDeviceSession,
+}
+
+var forwardResponseTasks = []func(*dataContext) error{
+	getDeviceProfile,
+	setDataTXInfo,
+	setToken,
+	setForwardedPayloadSize,
+	setMACCommandsSet,
+	setPHYPayloads,
+	sendDownlinkFrame,
+	saveDeviceSession,
+	saveRemainingFrames,
+}
+
 var scheduleNextQueueItemTasks = []func(*dataContext) error{
 	getDeviceProfile,
 	getServiceProfile,
@@ -82,8 +126,7 @@ var scheduleNextQueueItemTasks = []func(*dataContext) error{
 		returnInvalidDeviceClassError,
 	),
 	setToken,
-	getNextDeviceQueueItem,
-	setMACCommandsSet,
+	setPayloadsSet,
 	stopOnNothingToSend,
 	setPHYPayloads,
 	sendDownlinkFrame,
@@ -134,6 +177,14 @@ type dataContext struct {
 	// total size fits within the FRMPayload or FOpts.
 	MACCommands []storage.MACCommandBlock
 
+	// RelayRXPacket holds the received uplink packet of the relay, in case
+	// the uplink of the device was forwarded by a relay.
+	RelayRXPacket *models.RXPacket
+
+	// RelayDeviceSession holds the device-session of the relay, in case the
+	// uplink of the device was forwarded by a relay.
+	RelayDeviceSession *storage.DeviceSession
+
 	// Downlink frames to be emitted (this can be a slice e.g. to first try
 	// using RX1 parameters, failing that RX2 parameters).
 	// Only the first item will be emitted, the other(s) will be enqueued
@@ -155,7 +206,7 @@ func (ctx dataContext) Validate() error {
 		return ErrFPortMustNotBeZero
 	}
 
-	if ctx.FPort > 224 {
+	if ctx.FPort > 224 && ctx.FPort != models.RelayFPort {
 		return ErrInvalidAppFPort
 	}
 
@@ -210,6 +261,66 @@ func HandleResponse(rxPacket models.RXPacket, sp storage.ServiceProfile, ds stor
 	return nil
 }
 
+// HandleRelayedResponse handles a downlink response for a device of which
+// the uplink was forwarded by a relay. Instead of sending the downlink to the
+// gateway, it returns the PHYPayload which must be forwarded by the relay.
+// In case there is nothing to send, nil is returned.
+func HandleRelayedResponse(rxPacket models.RXPacket, sp storage.ServiceProfile, ds storage.DeviceSession, relayRXPacket models.RXPacket, relayDS storage.DeviceSession, adr, mustSend, ack bool, macCommands []storage.MACCommandBlock) ([]byte, error) {
+	ctx := dataContext{
+		ServiceProfile:     sp,
+		DeviceSession:      ds,
+		ACK:                ack,
+		MustSend:           mustSend,
+		RXPacket:           &rxPacket,
+		MACCommands:        macCommands,
+		RelayRXPacket:      &relayRXPacket,
+		RelayDeviceSession: &relayDS,
+	}
+
+	for _, t := range relayedResponseTasks {
+		if err := t(&ctx); err != nil {
+			if err == ErrAbort {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+	}
+
+	if len(ctx.DownlinkFrames) == 0 {
+		return nil, nil
+	}
+
+	return ctx.DownlinkFrames[0].DownlinkFrame.PhyPayload, nil
+}
+
+// HandleForwardResponse handles a downlink response for a relay, forwarding
+// the given PHYPayload of a device which is served by the relay.
+func HandleForwardResponse(rxPacket models.RXPacket, sp storage.ServiceProfile, ds storage.DeviceSession, adr, ack bool, macCommands []storage.MACCommandBlock, phyPayload []byte) error {
+	ctx := dataContext{
+		ServiceProfile: sp,
+		DeviceSession:  ds,
+		ACK:            ack,
+		MustSend:       true,
+		RXPacket:       &rxPacket,
+		MACCommands:    macCommands,
+		FPort:          models.RelayFPort,
+		Data:           phyPayload,
+	}
+
+	for _, t := range forwardResponseTasks {
+		if err := t(&ctx); err != nil {
+			if err == ErrAbort {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
 // HandleScheduleNextQueueItem handles scheduling the next device-queue item.
 func HandleScheduleNextQueueItem(ds storage.DeviceSession) error {
 	ctx := dataContext{
@@ -290,19 +401,97 @@ func setPingSlotParameters(ctx *dataContext) error {
 }
 
 func setRXParameters(ctx *dataContext) error {
+	return setRXParametersForRXDelay(ctx, config.C.NetworkServer.NetworkSettings.RX1Delay)
+}
+
+// setRelayedRXParameters sets the rx parameters of a device of which the
+// uplink was forwarded by a relay. The relay must be able to receive the
+// downlink within its own rx window, before forwarding it within the rx1
+// window of the device.
+func setRelayedRXParameters(ctx *dataContext) error {
+	return setRXParametersForRXDelay(ctx, getRXDelay(*ctx.RelayDeviceSession)+1)
+}
+
+func setRXParametersForRXDelay(ctx *dataContext, rxDelay int) error {
 	if ctx.DeviceSession.RX2Frequency != config.C.NetworkServer.NetworkSettings.RX2Frequency || ctx.DeviceSession.RX2DR != uint8(config.C.NetworkServer.NetworkSettings.RX2DR) || ctx.DeviceSession.RX1DROffset != uint8(config.C.NetworkServer.NetworkSettings.RX1DROffset) {
 		block := maccommand.RequestRXParamSetup(config.C.NetworkServer.NetworkSettings.RX1DROffset, config.C.NetworkServer.NetworkSettings.RX2Frequency, config.C.NetworkServer.NetworkSettings.RX2DR)
 		ctx.MACCommands = append(ctx.MACCommands, block)
 	}
 
-	if ctx.DeviceSession.RXDelay != uint8(config.C.NetworkServer.NetworkSettings.RX1Delay) {
-		block := maccommand.RequestRXTimingSetup(config.C.NetworkServer.NetworkSettings.RX1Delay)
+	if ctx.DeviceSession.RXDelay != uint8(rxDelay) {
+		block := maccommand.RequestRXTimingSetup(rxDelay)
 		ctx.MACCommands = append(ctx.MACCommands, block)
 	}
 
 	return nil
 }
 
+// checkRelayRXDelay validates that the relay is able to forward the downlink
+// to the device. The relay receives the downlink within its rx1 window,
+// which must not be later than the rx2 window of the device.
+func checkRelayRXDelay(ctx *dataContext) error {
+	if getRXDelay(ctx.DeviceSession)+1 <= getRXDelay(*ctx.RelayDeviceSession) {
+		log.WithFields(log.Fields{
+			"dev_eui":        ctx.DeviceSession.DevEUI,
+			"relay_dev_eui":  ctx.RelayDeviceSession.DevEUI,
+			"rx_delay":       ctx.DeviceSession.RXDelay,
+			"relay_rx_delay": ctx.RelayDeviceSession.RXDelay,
+		}).Warning("relay is unable to forward downlink within rx window of device")
+		return ErrRelayRXDelay
+	}
+
+	return nil
+}
+
+// setRelayedMaxPayloadSize limits the remaining payload size to what can be
+// forwarded by the relay within the rx1 window of the relay.
+func setRelayedMaxPayloadSize(ctx *dataContext) error {
+	relayDP, err := storage.GetAndCacheDeviceProfile(config.C.PostgreSQL.DB, config.C.Redis.Pool, ctx.RelayDeviceSession.DeviceProfileID)
+	if err != nil {
+		return errors.Wrap(err, "get relay device-profile error")
+	}
+
+	uplinkDR, err := helpers.GetDataRateIndex(true, ctx.RelayRXPacket.TXInfo, config.C.NetworkServer.Band.Band)
+	if err != nil {
+		return errors.Wrap(err, "get data-rate index error")
+	}
+
+	rx1DR, err := config.C.NetworkServer.Band.Band.GetRX1DataRateIndex(uplinkDR, int(ctx.RelayDeviceSession.RX1DROffset))
+	if err != nil {
+		return errors.Wrap(err, "get rx1 data-rate index error")
+	}
+
+	plSize, err := config.C.NetworkServer.Band.Band.GetMaxPayloadSizeForDataRateIndex(relayDP.MACVersion, relayDP.RegParamsRevision, rx1DR)
+	if err != nil {
+		return errors.Wrap(err, "get max-payload size error")
+	}
+
+	// the forwarded PHYPayload contains the MHDR (1), FHDR (7, excluding
+	// FOpts), FPort (1) and MIC (4) on top of the FRMPayload and FOpts
+	maxSize := plSize.N - 13
+	for i := range ctx.DownlinkFrames {
+		if ctx.DownlinkFrames[i].RemainingPayloadSize > maxSize {
+			ctx.DownlinkFrames[i].RemainingPayloadSize = maxSize
+		}
+	}
+
+	return nil
+}
+
+// setForwardedPayloadSize subtracts the size of the forwarded PHYPayload
+// from the remaining payload size.
+func setForwardedPayloadSize(ctx *dataContext) error {
+	for i := range ctx.DownlinkFrames {
+		ctx.DownlinkFrames[i].RemainingPayloadSize = ctx.DownlinkFrames[i].RemainingPayloadSize - len(ctx.Data)
+	}
+
+	if len(ctx.DownlinkFrames) > 0 && ctx.DownlinkFrames[0].RemainingPayloadSize < 0 {
+		return ErrMaxPayloadSizeExceeded
+	}
+
+	return nil
+}
+
 func setDataTXInfo(ctx *dataContext) error {
 	if rxWindow := config.C.NetworkServer.NetworkSettings.RXWindow; rxWindow == 0 || rxWindow == 1 {
 		if err := setTXInfoForRX1(ctx); err != nil {
@@ -524,6 +713,25 @@ func getNextDeviceQueueItem(ctx *dataContext) error {
 		return errors.Wrap(err, "get next device-queue item for max payload error")
 	}
 
+	// When the mac-commands have priority, they are already set. In case the
+	// item does not fit together with these mac-commands, it stays in the
+	// queue for a next downlink.
+	if config.C.NetworkServer.Scheduler.PayloadPriority == config.PayloadPriorityMACCommands && len(ctx.MACCommands) > 0 {
+		var macSize int
+		for _, block := range ctx.MACCommands {
+			s, err := block.Size()
+			if err != nil {
+				return errors.Wrap(err, "get mac-command block size error")
+			}
+			macSize += s
+		}
+
+		if macSize > 15 || len(qi.FRMPayload) > remainingPayloadSize-macSize {
+			ctx.MoreData = true
+			return nil
+		}
+	}
+
 	ctx.Confirmed = qi.Confirmed
 	ctx.Data = qi.FRMPayload
 	ctx.FPort = qi.FPort
@@ -592,6 +800,26 @@ func getNextDeviceQueueItem(ctx *dataContext) error {
 	return nil
 }
 
+// setPayloads executes the device-queue and mac-command tasks in the order
+// defined by the payload priority, so that the payload with the highest
+// priority gets the available payload size first.
+func setPayloads(queueTask, macCommandsTask func(*dataContext) error) func(*dataContext) error {
+	return func(ctx *dataContext) error {
+		tasks := []func(*dataContext) error{queueTask, macCommandsTask}
+		if config.C.NetworkServer.Scheduler.PayloadPriority == config.PayloadPriorityMACCommands {
+			tasks = []func(*dataContext) error{macCommandsTask, queueTask}
+		}
+
+		for _, t := range tasks {
+			if err := t(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
 func filterIncompatibleMACCommands(macCommands []storage.MACCommandBlock) []storage.MACCommandBlock {
 	for _, mapping := range incompatibleMACCommands {
 		var seen bool
@@ -770,7 +998,7 @@ func setPHYPayloads(ctx *dataContext) error {
 	}
 
 	var fCnt uint32
-	if ctx.DeviceSession.GetMACVersion() == lorawan.LoRaWAN1_0 || ctx.FPort == 0 {
+	if ctx.DeviceSession.GetMACVersion() == lorawan.LoRaWAN1_0 || ctx.FPort == 0 || ctx.FPort == models.RelayFPort {
 		fCnt = ctx.DeviceSession.NFCntDown
 		ctx.DeviceSession.NFCntDown++
 	} else {
@@ -845,8 +1073,8 @@ func setPHYPayloads(ctx *dataContext) error {
 			phy.MHDR.MType = lorawan.ConfirmedDataDown
 		}
 
-		// encrypt FRMPayload mac-commands
-		if ctx.FPort == 0 {
+		// encrypt FRMPayload mac-commands and forwarded frames
+		if ctx.FPort == 0 || ctx.FPort == models.RelayFPort {
 			if err := phy.EncryptFRMPayload(ctx.DeviceSession.NwkSEncKey); err != nil {
 				return errors.Wrap(err, "encrypt frmpayload error")
 			}
@@ -893,6 +1121,10 @@ func sendDownlinkFrame(ctx *dataContext) error {
 		log.WithError(err).Error("log downlink frame for gateway error")
 	}
 
+	if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventDownlink, ctx.DeviceSession.DevEUI, &ctx.DownlinkFrames[0].DownlinkFrame); err != nil {
+		log.WithError(err).Error("publish downlink event error")
+	}
+
 	// log for device (with decrypted mac-commands)
 	if err := func() error {
 		var phy lorawan.PHYPayload
@@ -900,8 +1132,8 @@ func sendDownlinkFrame(ctx *dataContext) error {
 			return err
 		}
 
-		// decrypt FRMPayload mac-commands
-		if ctx.FPort == 0 {
+		// decrypt FRMPayload mac-commands and forwarded frames
+		if ctx.FPort == 0 || ctx.FPort == models.RelayFPort {
 			if err := phy.DecryptFRMPayload(ctx.DeviceSession.NwkSEncKey); err != nil {
 				return errors.Wrap(err, "decrypt frmpayload error")
 			}
@@ -1002,3 +1234,12 @@ func saveRemainingFrames(ctx *dataContext) error {
 func returnInvalidDeviceClassError(ctx *dataContext) error {
 	return errors.New("the device is in an invalid device-class for this action")
 }
+
+// getRXDelay returns the effective rx1 delay (in seconds) of the given
+// device-session.
+func getRXDelay(ds storage.DeviceSession) int {
+	if ds.RXDelay == 0 {
+		return 1
+	}
+	return int(ds.RXDelay)
+}
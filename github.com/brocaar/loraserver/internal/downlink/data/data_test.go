@@ -6,7 +6,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/models"
 	"github.com/brocaar/loraserver/internal/storage"
 	"github.com/brocaar/loraserver/internal/test"
 	"github.com/brocaar/lorawan"
@@ -50,6 +53,7 @@ func (ts *GetNextDeviceQueueItemTestSuite) SetupSuite() {
 func (ts *GetNextDeviceQueueItemTestSuite) TestGetNextDeviceQueueItem() {
 	tests := []struct {
 		Name                        string
+		PayloadPriority             string
 		DeviceQueueItems            []storage.DeviceQueueItem
 		DataContext                 dataContext
 		ExpectedDataContext         dataContext
@@ -207,12 +211,77 @@ func (ts *GetNextDeviceQueueItemTestSuite) TestGetNextDeviceQueueItem() {
 				IsPending:  true,
 			},
 		},
+		{
+			Name:            "mac-commands have priority and item does not fit",
+			PayloadPriority: config.PayloadPriorityMACCommands,
+			DeviceQueueItems: []storage.DeviceQueueItem{
+				{
+					DevEUI:     ts.Device.DevEUI,
+					FRMPayload: []byte{1, 2, 3, 4},
+					FCnt:       10,
+					FPort:      1,
+				},
+			},
+			DataContext: dataContext{
+				DeviceSession: storage.DeviceSession{
+					RoutingProfileID: ts.Device.RoutingProfileID,
+					DevEUI:           ts.Device.DevEUI,
+					NFCntDown:        10,
+				},
+				MACCommands: []storage.MACCommandBlock{
+					{
+						CID: lorawan.DevStatusReq,
+						MACCommands: []lorawan.MACCommand{
+							{CID: lorawan.DevStatusReq},
+						},
+					},
+				},
+				DownlinkFrames: []downlinkFrame{
+					{
+						RemainingPayloadSize: 4,
+					},
+				},
+			},
+			ExpectedDataContext: dataContext{
+				DeviceSession: storage.DeviceSession{
+					RoutingProfileID: ts.Device.RoutingProfileID,
+					DevEUI:           ts.Device.DevEUI,
+					NFCntDown:        10,
+				},
+				MACCommands: []storage.MACCommandBlock{
+					{
+						CID: lorawan.DevStatusReq,
+						MACCommands: []lorawan.MACCommand{
+							{CID: lorawan.DevStatusReq},
+						},
+					},
+				},
+				MoreData: true,
+				DownlinkFrames: []downlinkFrame{
+					{
+						RemainingPayloadSize: 4,
+					},
+				},
+			},
+			// the item stays in the queue
+			ExpectedNextDeviceQueueItem: &storage.DeviceQueueItem{
+				DevEUI:     ts.Device.DevEUI,
+				FRMPayload: []byte{1, 2, 3, 4},
+				FPort:      1,
+				FCnt:       10,
+			},
+		},
 	}
 
 	for _, tst := range tests {
 		ts.T().Run(tst.Name, func(t *testing.T) {
 			assert := require.New(t)
 
+			config.C.NetworkServer.Scheduler.PayloadPriority = tst.PayloadPriority
+			defer func() {
+				config.C.NetworkServer.Scheduler.PayloadPriority = ""
+			}()
+
 			assert.NoError(storage.FlushDeviceQueueForDevEUI(ts.DB(), ts.Device.DevEUI))
 			for i := range tst.DeviceQueueItems {
 				assert.NoError(storage.CreateDeviceQueueItem(ts.DB(), &tst.DeviceQueueItems[i]))
@@ -763,6 +832,77 @@ func TestSetMACCommandsSet(t *testing.T) {
 	suite.Run(t, new(SetMACCommandsSetTestSuite))
 }
 
+type HandleRelayedResponseTestSuite struct {
+	suite.Suite
+	test.DatabaseTestSuiteBase
+}
+
+func (ts *HandleRelayedResponseTestSuite) TestHandleRelayedResponse() {
+	assert := require.New(ts.T())
+
+	config.C.NetworkServer.Band.Name = band.EU_863_870
+	config.C.NetworkServer.Band.Band, _ = band.GetConfig(config.C.NetworkServer.Band.Name, false, lorawan.DwellTimeNoLimit)
+	config.C.NetworkServer.NetworkSettings.RX1Delay = 0
+	config.C.NetworkServer.NetworkSettings.RX2Frequency = 869525000
+	config.C.NetworkServer.NetworkSettings.RX2DR = 0
+	config.C.NetworkServer.NetworkSettings.RX1DROffset = 0
+
+	dp := storage.DeviceProfile{MACVersion: "1.0.2"}
+	assert.NoError(storage.CreateDeviceProfile(ts.DB(), &dp))
+
+	ds := storage.DeviceSession{
+		MACVersion:            "1.0.2",
+		DeviceProfileID:       dp.ID,
+		DevEUI:                lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		DevAddr:               lorawan.DevAddr{1, 2, 3, 4},
+		EnabledUplinkChannels: []int{0, 1, 2},
+		RX2Frequency:          869525000,
+	}
+	relayDS := storage.DeviceSession{
+		MACVersion:      "1.0.2",
+		DeviceProfileID: dp.ID,
+		DevEUI:          lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		DevAddr:         lorawan.DevAddr{4, 3, 2, 1},
+		RXDelay:         2,
+	}
+
+	rxPacket := models.RXPacket{
+		TXInfo: &gw.UplinkTXInfo{
+			Frequency: 868100000,
+		},
+		RXInfoSet: []*gw.UplinkRXInfo{
+			{GatewayId: []byte{1, 2, 1, 2, 1, 2, 1, 2}},
+		},
+	}
+	assert.NoError(helpers.SetUplinkTXInfoDataRate(rxPacket.TXInfo, 0, config.C.NetworkServer.Band.Band))
+
+	b, err := HandleRelayedResponse(rxPacket, storage.ServiceProfile{}, ds, rxPacket, relayDS, false, false, true, nil)
+	assert.NoError(err)
+	assert.NotNil(b)
+
+	var phy lorawan.PHYPayload
+	assert.NoError(phy.UnmarshalBinary(b))
+	assert.NoError(phy.DecodeFOptsToMACCommands())
+
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	assert.True(ok)
+	assert.True(macPL.FHDR.FCtrl.ACK)
+
+	// the device must open its rx1 window after the rx1 window of the relay
+	assert.Equal([]lorawan.Payload{
+		&lorawan.MACCommand{
+			CID: lorawan.RXTimingSetupReq,
+			Payload: &lorawan.RXTimingSetupReqPayload{
+				Delay: 3,
+			},
+		},
+	}, macPL.FHDR.FOpts)
+}
+
+func TestHandleRelayedResponse(t *testing.T) {
+	suite.Run(t, new(HandleRelayedResponseTestSuite))
+}
+
 func TestFilterIncompatibleMACCommands(t *testing.T) {
 	tests := []struct {
 		Name        string
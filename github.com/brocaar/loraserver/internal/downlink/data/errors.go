@@ -11,4 +11,5 @@ var (
 	ErrNoLastRXInfoSet        = errors.New("no last RX-Info set available")
 	ErrInvalidDataRate        = errors.New("invalid data-rate")
 	ErrMaxPayloadSizeExceeded = errors.New("maximum payload size exceeded")
+	ErrRelayRXDelay           = errors.New("rx delay of device must not be less than rx delay of relay")
 )
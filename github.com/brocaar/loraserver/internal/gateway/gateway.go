@@ -17,6 +17,7 @@ import (
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/loraserver/internal/config"
 	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/integration"
 	"github.com/brocaar/loraserver/internal/storage"
 	"github.com/brocaar/lorawan"
 )
@@ -61,6 +62,11 @@ func handleStatsPackets(wg *sync.WaitGroup) {
 
 			var gatewayID lorawan.EUI64
 			copy(gatewayID[:], stats.GatewayId)
+
+			if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventGatewayStats, gatewayID, &stats); err != nil {
+				log.WithError(err).Error("publish gateway stats event error")
+			}
+
 			if err := storage.FlushGatewayCache(config.C.Redis.Pool, gatewayID); err != nil {
 				log.WithError(err).Error("flush gateway cache error")
 			}
@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/storage"
+)
+
+// RolloutMonitorLoop starts an infinite loop which rolls back the
+// gateway-profile canary rollouts for which a gateway did not confirm
+// the pushed configuration within the configured rollback timeout.
+func RolloutMonitorLoop() {
+	for {
+		log.Debug("running gateway-profile rollout monitor")
+		if err := RollbackFailedRollouts(); err != nil {
+			log.WithError(err).Error("gateway-profile rollout monitor error")
+		}
+		time.Sleep(config.C.NetworkServer.Gateway.Rollout.CheckInterval)
+	}
+}
+
+// RollbackFailedRollouts rolls back the failed gateway-profile canary
+// rollouts. Gateways of rolled back gateway-profiles receive the stable
+// revision on their next stats report.
+func RollbackFailedRollouts() error {
+	_, err := storage.RollbackFailedGatewayProfileRollouts(config.C.PostgreSQL.DB, config.C.NetworkServer.Gateway.Rollout.RollbackTimeout)
+	return err
+}
@@ -0,0 +1,70 @@
+// Package file implements an event sink writing the events to a local file.
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/integration"
+)
+
+// Config holds the file sink configuration.
+type Config struct {
+	Path string
+}
+
+// Sink implements a file event sink.
+// Events are appended to the file as JSON, one event per line. The payload
+// is the base64 encoded protobuf message.
+type Sink struct {
+	sync.Mutex
+
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewSink creates a new file sink.
+func NewSink(c Config) (integration.Sink, error) {
+	log.WithField("path", c.Path).Info("integration/file: opening event file")
+
+	f, err := os.OpenFile(c.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/file: open file error")
+	}
+
+	return &Sink{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}, nil
+}
+
+// Publish writes the given event to the file.
+// The file is synced before returning, so that the event is persisted
+// once Publish returns.
+func (s *Sink) Publish(e integration.Event) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.enc.Encode(e); err != nil {
+		return errors.Wrap(err, "integration/file: write event error")
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return errors.Wrap(err, "integration/file: sync file error")
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (s *Sink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	log.Info("integration/file: closing sink")
+	return s.file.Close()
+}
@@ -0,0 +1,49 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSink(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "integration")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.log")
+	events := []integration.Event{
+		{Type: integration.EventUplink, Key: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, Payload: []byte{1, 2, 3}},
+		{Type: integration.EventGatewayStats, Key: lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}, Payload: []byte{4, 5, 6}},
+	}
+
+	sink, err := NewSink(Config{Path: path})
+	assert.NoError(err)
+	for _, e := range events {
+		assert.NoError(sink.Publish(e))
+	}
+	assert.NoError(sink.Close())
+
+	f, err := os.Open(path)
+	assert.NoError(err)
+	defer f.Close()
+
+	var out []integration.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e integration.Event
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &e))
+		out = append(out, e)
+	}
+	assert.Equal(events, out)
+}
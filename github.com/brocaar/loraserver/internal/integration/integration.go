@@ -0,0 +1,227 @@
+// Package integration implements the publishing of network-server events
+// (e.g. joins, uplinks, downlink acknowledgements, errors and gateway stats)
+// to external event sinks.
+package integration
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lorawan"
+)
+
+// EventType defines the type of the event.
+type EventType string
+
+// Available event types.
+const (
+	EventJoin         EventType = "join"
+	EventUplink       EventType = "up"
+	EventDownlink     EventType = "down"
+	EventACK          EventType = "ack"
+	EventError        EventType = "error"
+	EventMACCommand   EventType = "mac_command"
+	EventGatewayStats EventType = "gateway_stats"
+)
+
+// ErrBufferFull is returned when the event could not be buffered for one
+// or more sinks.
+var ErrBufferFull = errors.New("integration: sink buffer is full")
+
+// Event contains a single event published by the network-server.
+type Event struct {
+	// Type of the event.
+	Type EventType `json:"type"`
+
+	// Key holds the DevEUI or gateway ID (in case of gateway events) to
+	// which the event relates.
+	Key lorawan.EUI64 `json:"key"`
+
+	// Time at which the event was created.
+	Time time.Time `json:"time"`
+
+	// Payload holds the protobuf encoded event message.
+	Payload []byte `json:"payload"`
+}
+
+// Sink is the interface of an event sink.
+// Publish must only return nil once the event has been accepted by the
+// sink, as the Handler will retry the event on error.
+type Sink interface {
+	Publish(Event) error // publish the given event
+	Close() error        // close the sink
+}
+
+// Config holds the handler configuration.
+type Config struct {
+	// BufferSize defines the number of events that are buffered per sink.
+	BufferSize int `mapstructure:"buffer_size"`
+
+	// RetryInterval defines the interval between delivery retries.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+}
+
+// Handler publishes events to the configured sinks.
+// Every sink has its own buffer and publish routine, so that an unavailable
+// sink does not block the other sinks. Events are delivered at least once
+// to each sink, as long as the event was accepted into the sink buffer and
+// the handler has not been closed.
+// A nil Handler is valid and discards all events.
+type Handler struct {
+	sync.RWMutex
+
+	config  Config
+	workers []*sinkWorker
+	closing chan struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type sinkWorker struct {
+	sink   Sink
+	events chan Event
+}
+
+// NewHandler creates a new Handler for the given sinks.
+func NewHandler(c Config, sinks ...Sink) *Handler {
+	if c.BufferSize <= 0 {
+		c.BufferSize = 100
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = time.Second
+	}
+
+	h := Handler{
+		config:  c,
+		closing: make(chan struct{}),
+	}
+
+	for _, s := range sinks {
+		w := sinkWorker{
+			sink:   s,
+			events: make(chan Event, c.BufferSize),
+		}
+		h.workers = append(h.workers, &w)
+
+		h.wg.Add(1)
+		go h.publishLoop(&w)
+	}
+
+	return &h
+}
+
+// Publish encodes the given message and enqueues it for publishing to all
+// sinks. It does not block when a sink buffer is full, in which case
+// ErrBufferFull is returned (the event is still enqueued for the sinks
+// that had buffer capacity left).
+func (h *Handler) Publish(t EventType, key lorawan.EUI64, msg proto.Message) error {
+	if h == nil {
+		return nil
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "integration: marshal event error")
+	}
+
+	e := Event{
+		Type:    t,
+		Key:     key,
+		Time:    time.Now(),
+		Payload: b,
+	}
+
+	h.RLock()
+	defer h.RUnlock()
+
+	if h.closed {
+		return errors.New("integration: handler is closed")
+	}
+
+	var full bool
+	for _, w := range h.workers {
+		select {
+		case w.events <- e:
+		default:
+			full = true
+		}
+	}
+
+	if full {
+		return ErrBufferFull
+	}
+
+	return nil
+}
+
+// Close closes the handler. It waits until the buffered events have been
+// published, or until one delivery attempt has been made for each buffered
+// event in case of an unavailable sink, after which the sinks are closed.
+func (h *Handler) Close() error {
+	if h == nil {
+		return nil
+	}
+
+	h.Lock()
+	if h.closed {
+		h.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.closing)
+	for _, w := range h.workers {
+		close(w.events)
+	}
+	h.Unlock()
+
+	h.wg.Wait()
+
+	for _, w := range h.workers {
+		if err := w.sink.Close(); err != nil {
+			return errors.Wrap(err, "integration: close sink error")
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) publishLoop(w *sinkWorker) {
+	defer h.wg.Done()
+
+	for e := range w.events {
+		h.publish(w.sink, e)
+	}
+}
+
+// publish publishes the event to the given sink, retrying on error until
+// the event has been accepted or the handler is closing.
+func (h *Handler) publish(s Sink, e Event) {
+	for {
+		err := s.Publish(e)
+		if err == nil {
+			return
+		}
+
+		logFields := log.Fields{
+			"type": e.Type,
+			"key":  e.Key,
+		}
+
+		select {
+		case <-h.closing:
+			log.WithFields(logFields).WithError(err).Error("integration: publish event error, handler is closing, dropping event")
+			return
+		default:
+			log.WithFields(logFields).WithError(err).Warning("integration: publish event error, will retry")
+		}
+
+		select {
+		case <-h.closing:
+		case <-time.After(h.config.RetryInterval):
+		}
+	}
+}
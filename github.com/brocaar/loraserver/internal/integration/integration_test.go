@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/as"
+	"github.com/brocaar/lorawan"
+)
+
+type testSink struct {
+	sync.Mutex
+
+	failures int
+	events   []Event
+	closed   bool
+}
+
+func (s *testSink) Publish(e Event) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("publish error")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	req := as.HandleUplinkDataRequest{
+		DevEui: devEUI[:],
+		FCnt:   10,
+		Data:   []byte{1, 2, 3},
+	}
+
+	t.Run("Publish to multiple sinks", func(t *testing.T) {
+		assert := require.New(t)
+
+		s1 := &testSink{}
+		s2 := &testSink{failures: 2}
+		h := NewHandler(Config{RetryInterval: time.Millisecond}, s1, s2)
+
+		assert.NoError(h.Publish(EventUplink, devEUI, &req))
+
+		// wait for the retries of the second sink
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(h.Close())
+
+		for _, s := range []*testSink{s1, s2} {
+			assert.True(s.closed)
+			assert.Len(s.events, 1)
+			assert.Equal(EventUplink, s.events[0].Type)
+			assert.Equal(devEUI, s.events[0].Key)
+
+			var out as.HandleUplinkDataRequest
+			assert.NoError(proto.Unmarshal(s.events[0].Payload, &out))
+			assert.True(proto.Equal(&req, &out))
+		}
+	})
+
+	t.Run("Buffer full", func(t *testing.T) {
+		assert := require.New(t)
+
+		s := &testSink{failures: 1}
+		h := NewHandler(Config{BufferSize: 1, RetryInterval: time.Hour}, s)
+
+		// the first event is taken by the publish routine, which is waiting
+		// for the retry interval, the second event fills the buffer
+		assert.NoError(h.Publish(EventUplink, devEUI, &req))
+		time.Sleep(10 * time.Millisecond)
+		assert.NoError(h.Publish(EventUplink, devEUI, &req))
+		assert.Equal(ErrBufferFull, h.Publish(EventUplink, devEUI, &req))
+
+		// on close, each buffered event gets one more delivery attempt
+		assert.NoError(h.Close())
+		assert.Len(s.events, 2)
+		assert.Error(h.Publish(EventUplink, devEUI, &req))
+	})
+
+	t.Run("Nil handler", func(t *testing.T) {
+		assert := require.New(t)
+
+		var h *Handler
+		assert.NoError(h.Publish(EventUplink, devEUI, &req))
+		assert.NoError(h.Close())
+	})
+}
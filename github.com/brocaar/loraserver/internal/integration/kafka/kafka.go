@@ -0,0 +1,420 @@
+// Package kafka implements an event sink publishing to a Kafka cluster, using
+// the Kafka wire protocol (version 0 of the Metadata and Produce APIs).
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/integration"
+)
+
+const clientID = "loraserver"
+
+// Kafka API keys.
+const (
+	apiKeyProduce  int16 = 0
+	apiKeyMetadata int16 = 3
+)
+
+// Config holds the Kafka sink configuration.
+type Config struct {
+	Brokers       []string
+	TopicTemplate string        `mapstructure:"topic_template"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+}
+
+// Sink implements a Kafka event sink.
+// Events are produced with acks=1, so that Publish only returns once the
+// partition leader has written the message. The DevEUI or gateway ID is used
+// as message key and determines the partition, so that the events of a device
+// are kept in order.
+type Sink struct {
+	sync.Mutex
+
+	config        Config
+	topicTemplate *template.Template
+
+	correlationID int32
+	conns         map[string]*brokerConn
+	partitions    map[string][]partition
+}
+
+type brokerConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+type partition struct {
+	ID     int32
+	Leader string // address of the leader broker
+}
+
+// NewSink creates a new Kafka sink.
+// The connections to the brokers are setup on the first publish and
+// re-established after errors.
+func NewSink(c Config) (integration.Sink, error) {
+	if len(c.Brokers) == 0 {
+		return nil, errors.New("integration/kafka: at least one broker must be configured")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	t, err := template.New("topic").Parse(c.TopicTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/kafka: parse topic template error")
+	}
+
+	return &Sink{
+		config:        c,
+		topicTemplate: t,
+		conns:         make(map[string]*brokerConn),
+		partitions:    make(map[string][]partition),
+	}, nil
+}
+
+// Publish publishes the given event.
+func (s *Sink) Publish(e integration.Event) error {
+	topic := bytes.NewBuffer(nil)
+	if err := s.topicTemplate.Execute(topic, struct {
+		Type integration.EventType
+		Key  string
+	}{e.Type, e.Key.String()}); err != nil {
+		return errors.Wrap(err, "integration/kafka: execute topic template error")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	partitions, ok := s.partitions[topic.String()]
+	if !ok {
+		var err error
+		partitions, err = s.getPartitions(topic.String())
+		if err != nil {
+			s.closeConns()
+			return err
+		}
+		s.partitions[topic.String()] = partitions
+	}
+
+	key := []byte(e.Key.String())
+	h := fnv.New32a()
+	h.Write(key)
+	p := partitions[h.Sum32()%uint32(len(partitions))]
+
+	if err := s.produce(p.Leader, topic.String(), p.ID, key, e.Payload); err != nil {
+		// the leader of the partition might have moved, refresh the
+		// metadata on the next publish
+		s.closeConns()
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connections to the Kafka brokers.
+func (s *Sink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	log.Info("integration/kafka: closing sink")
+	s.closeConns()
+	return nil
+}
+
+// getPartitions returns the partitions of the given topic, using the first
+// configured broker that is available.
+func (s *Sink) getPartitions(topic string) ([]partition, error) {
+	var body bytes.Buffer
+	writeInt32(&body, 1)
+	writeString(&body, topic)
+
+	var resp *bytes.Reader
+	var err error
+	for _, addr := range s.config.Brokers {
+		resp, err = s.request(addr, apiKeyMetadata, body.Bytes())
+		if err == nil {
+			break
+		}
+		log.WithError(err).WithField("broker", addr).Warning("integration/kafka: get metadata error")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/kafka: get metadata error")
+	}
+
+	brokers := make(map[int32]string)
+	var partitions []partition
+
+	d := decoder{r: resp}
+	for i := d.int32(); i > 0; i-- {
+		nodeID := d.int32()
+		host := d.string()
+		port := d.int32()
+		brokers[nodeID] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	for i := d.int32(); i > 0; i-- {
+		topicErr := d.int16()
+		name := d.string()
+		for j := d.int32(); j > 0; j-- {
+			partErr := d.int16()
+			id := d.int32()
+			leader := d.int32()
+			d.int32Array() // replicas
+			d.int32Array() // isr
+
+			if name != topic || partErr != 0 {
+				continue
+			}
+			addr, ok := brokers[leader]
+			if !ok {
+				continue
+			}
+			partitions = append(partitions, partition{ID: id, Leader: addr})
+		}
+
+		if d.err == nil && name == topic && topicErr != 0 {
+			return nil, fmt.Errorf("integration/kafka: topic %s metadata error code: %d", topic, topicErr)
+		}
+	}
+	if d.err != nil {
+		return nil, errors.Wrap(d.err, "integration/kafka: decode metadata error")
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("integration/kafka: topic %s has no available partitions", topic)
+	}
+
+	return partitions, nil
+}
+
+// produce writes a single message to the given topic partition.
+func (s *Sink) produce(addr, topic string, partitionID int32, key, value []byte) error {
+	var msg bytes.Buffer
+	msg.Write([]byte{0, 0}) // magic byte and attributes
+	writeBytes(&msg, key)
+	writeBytes(&msg, value)
+
+	var msgSet bytes.Buffer
+	writeInt64(&msgSet, 0) // offset, assigned by the broker
+	writeInt32(&msgSet, int32(msg.Len()+4))
+	writeInt32(&msgSet, int32(crc32.ChecksumIEEE(msg.Bytes())))
+	msgSet.Write(msg.Bytes())
+
+	var body bytes.Buffer
+	writeInt16(&body, 1) // acks
+	writeInt32(&body, int32(s.config.Timeout/time.Millisecond))
+	writeInt32(&body, 1)
+	writeString(&body, topic)
+	writeInt32(&body, 1)
+	writeInt32(&body, partitionID)
+	writeInt32(&body, int32(msgSet.Len()))
+	body.Write(msgSet.Bytes())
+
+	resp, err := s.request(addr, apiKeyProduce, body.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "integration/kafka: produce error")
+	}
+
+	d := decoder{r: resp}
+	for i := d.int32(); i > 0; i-- {
+		d.string()
+		for j := d.int32(); j > 0; j-- {
+			d.int32()
+			errCode := d.int16()
+			d.int64()
+
+			if d.err == nil && errCode != 0 {
+				return fmt.Errorf("integration/kafka: produce error code: %d", errCode)
+			}
+		}
+	}
+	if d.err != nil {
+		return errors.Wrap(d.err, "integration/kafka: decode produce response error")
+	}
+
+	return nil
+}
+
+// request sends the given request to the broker and returns the response
+// body.
+func (s *Sink) request(addr string, apiKey int16, body []byte) (*bytes.Reader, error) {
+	c, err := s.getConn(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.correlationID++
+
+	var req bytes.Buffer
+	writeInt16(&req, apiKey)
+	writeInt16(&req, 0) // api version
+	writeInt32(&req, s.correlationID)
+	writeString(&req, clientID)
+	req.Write(body)
+
+	var b bytes.Buffer
+	writeInt32(&b, int32(req.Len()))
+	b.Write(req.Bytes())
+
+	if err := c.conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		s.closeConn(addr)
+		return nil, errors.Wrap(err, "set deadline error")
+	}
+
+	if _, err := c.conn.Write(b.Bytes()); err != nil {
+		s.closeConn(addr)
+		return nil, errors.Wrap(err, "write request error")
+	}
+
+	var size int32
+	if err := binary.Read(c.reader, binary.BigEndian, &size); err != nil {
+		s.closeConn(addr)
+		return nil, errors.Wrap(err, "read response size error")
+	}
+	if size < 4 {
+		s.closeConn(addr)
+		return nil, fmt.Errorf("invalid response size: %d", size)
+	}
+
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, resp); err != nil {
+		s.closeConn(addr)
+		return nil, errors.Wrap(err, "read response error")
+	}
+
+	if id := int32(binary.BigEndian.Uint32(resp)); id != s.correlationID {
+		s.closeConn(addr)
+		return nil, fmt.Errorf("expected correlation id %d, got: %d", s.correlationID, id)
+	}
+
+	return bytes.NewReader(resp[4:]), nil
+}
+
+func (s *Sink) getConn(addr string) (*brokerConn, error) {
+	if c, ok := s.conns[addr]; ok {
+		return c, nil
+	}
+
+	log.WithField("broker", addr).Info("integration/kafka: connecting to kafka broker")
+
+	conn, err := net.DialTimeout("tcp", addr, s.config.Timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial error")
+	}
+
+	c := brokerConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+	s.conns[addr] = &c
+	return &c, nil
+}
+
+func (s *Sink) closeConn(addr string) {
+	c, ok := s.conns[addr]
+	if !ok {
+		return
+	}
+	if err := c.conn.Close(); err != nil {
+		log.WithError(err).WithField("broker", addr).Error("integration/kafka: close connection error")
+	}
+	delete(s.conns, addr)
+}
+
+// closeConns closes all broker connections and forgets the topic metadata.
+func (s *Sink) closeConns() {
+	for addr := range s.conns {
+		s.closeConn(addr)
+	}
+	s.partitions = make(map[string][]partition)
+}
+
+func writeInt16(b *bytes.Buffer, v int16) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func writeInt32(b *bytes.Buffer, v int32) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func writeInt64(b *bytes.Buffer, v int64) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func writeString(b *bytes.Buffer, s string) {
+	writeInt16(b, int16(len(s)))
+	b.WriteString(s)
+}
+
+func writeBytes(b *bytes.Buffer, v []byte) {
+	if v == nil {
+		writeInt32(b, -1)
+		return
+	}
+	writeInt32(b, int32(len(v)))
+	b.Write(v)
+}
+
+// decoder decodes the fields of a Kafka response. After the first error,
+// all reads return zero values and the error is kept in err.
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *decoder) read(v interface{}) {
+	if d.err != nil {
+		return
+	}
+	d.err = binary.Read(d.r, binary.BigEndian, v)
+}
+
+func (d *decoder) int16() int16 {
+	var v int16
+	d.read(&v)
+	return v
+}
+
+func (d *decoder) int32() int32 {
+	var v int32
+	d.read(&v)
+	return v
+}
+
+func (d *decoder) int64() int64 {
+	var v int64
+	d.read(&v)
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if d.err != nil || n < 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		d.err = err
+		return ""
+	}
+	return string(b)
+}
+
+func (d *decoder) int32Array() {
+	for i := d.int32(); i > 0; i-- {
+		d.int32()
+	}
+}
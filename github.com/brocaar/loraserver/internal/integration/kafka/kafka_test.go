@@ -0,0 +1,239 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+type testMessage struct {
+	Topic     string
+	Partition int32
+	Key       []byte
+	Value     []byte
+}
+
+// testBroker implements a minimal Kafka broker, sufficient for testing the
+// publish flow. It is the leader of all partitions of every topic.
+type testBroker struct {
+	ln         net.Listener
+	partitions int32
+	errorCode  chan int16
+	messages   chan testMessage
+}
+
+func newTestBroker(partitions int32) (*testBroker, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	b := testBroker{
+		ln:         ln,
+		partitions: partitions,
+		errorCode:  make(chan int16, 1),
+		messages:   make(chan testMessage, 10),
+	}
+	go b.serve()
+	return &b, nil
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handleConn(conn)
+	}
+}
+
+func (b *testBroker) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		req := make([]byte, size)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+
+		d := decoder{r: bytes.NewReader(req)}
+		apiKey := d.int16()
+		d.int16() // api version
+		correlationID := d.int32()
+		d.string() // client id
+
+		var resp bytes.Buffer
+		writeInt32(&resp, correlationID)
+
+		switch apiKey {
+		case apiKeyMetadata:
+			d.int32()
+			topic := d.string()
+
+			host, port, _ := net.SplitHostPort(b.ln.Addr().String())
+			p, _ := strconv.Atoi(port)
+
+			writeInt32(&resp, 1)
+			writeInt32(&resp, 1)
+			writeString(&resp, host)
+			writeInt32(&resp, int32(p))
+
+			writeInt32(&resp, 1)
+			writeInt16(&resp, 0)
+			writeString(&resp, topic)
+			writeInt32(&resp, b.partitions)
+			for i := int32(0); i < b.partitions; i++ {
+				writeInt16(&resp, 0)
+				writeInt32(&resp, i)
+				writeInt32(&resp, 1)
+				writeInt32(&resp, 1)
+				writeInt32(&resp, 1)
+				writeInt32(&resp, 1)
+				writeInt32(&resp, 1)
+			}
+		case apiKeyProduce:
+			d.int16() // acks
+			d.int32() // timeout
+			d.int32()
+			topic := d.string()
+			d.int32()
+			partition := d.int32()
+			d.int32() // message set size
+			d.int64() // offset
+			d.int32() // message size
+			crc := d.int32()
+			rest := make([]byte, d.r.Len())
+			d.r.Read(rest)
+			if uint32(crc) != crc32.ChecksumIEEE(rest) {
+				return
+			}
+
+			md := decoder{r: bytes.NewReader(rest[2:])}
+			key := readBytes(&md)
+			value := readBytes(&md)
+
+			var errCode int16
+			select {
+			case errCode = <-b.errorCode:
+			default:
+				b.messages <- testMessage{Topic: topic, Partition: partition, Key: key, Value: value}
+			}
+
+			writeInt32(&resp, 1)
+			writeString(&resp, topic)
+			writeInt32(&resp, 1)
+			writeInt32(&resp, partition)
+			writeInt16(&resp, errCode)
+			writeInt64(&resp, 0)
+		default:
+			return
+		}
+
+		var out bytes.Buffer
+		writeInt32(&out, int32(resp.Len()))
+		out.Write(resp.Bytes())
+		if _, err := conn.Write(out.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func readBytes(d *decoder) []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	d.r.Read(b)
+	return b
+}
+
+func TestSink(t *testing.T) {
+	assert := require.New(t)
+
+	broker, err := newTestBroker(3)
+	assert.NoError(err)
+	defer broker.ln.Close()
+
+	sink, err := NewSink(Config{
+		Brokers:       []string{broker.ln.Addr().String()},
+		TopicTemplate: "loraserver.{{ .Type }}",
+		Timeout:       time.Second,
+	})
+	assert.NoError(err)
+	defer sink.Close()
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	var partition int32
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(sink.Publish(integration.Event{
+			Type:    integration.EventUplink,
+			Key:     devEUI,
+			Payload: []byte{1, 2, 3, byte(i)},
+		}))
+
+		msg := <-broker.messages
+		assert.Equal("loraserver.up", msg.Topic)
+		assert.Equal([]byte("0102030405060708"), msg.Key)
+		assert.Equal([]byte{1, 2, 3, byte(i)}, msg.Value)
+
+		// the events of a device are produced to the same partition
+		if i == 0 {
+			partition = msg.Partition
+		}
+		assert.Equal(partition, msg.Partition)
+	}
+
+	t.Run("Produce error", func(t *testing.T) {
+		assert := require.New(t)
+
+		broker.errorCode <- 6 // NOT_LEADER_FOR_PARTITION
+		assert.Error(sink.Publish(integration.Event{
+			Type: integration.EventUplink,
+			Key:  devEUI,
+		}))
+
+		// the metadata is refreshed and the retry succeeds
+		assert.NoError(sink.Publish(integration.Event{
+			Type: integration.EventUplink,
+			Key:  devEUI,
+		}))
+		msg := <-broker.messages
+		assert.Equal(partition, msg.Partition)
+	})
+
+	t.Run("Broker unavailable", func(t *testing.T) {
+		assert := require.New(t)
+
+		sink, err := NewSink(Config{
+			Brokers:       []string{"127.0.0.1:1"},
+			TopicTemplate: "loraserver.{{ .Type }}",
+			Timeout:       time.Second,
+		})
+		assert.NoError(err)
+		assert.Error(sink.Publish(integration.Event{Type: integration.EventUplink}))
+	})
+
+	t.Run("No brokers", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := NewSink(Config{TopicTemplate: "loraserver.{{ .Type }}"})
+		assert.Error(err)
+	})
+}
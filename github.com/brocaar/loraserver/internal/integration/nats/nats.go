@@ -0,0 +1,211 @@
+// Package nats implements an event sink publishing to a NATS server, using
+// the NATS client protocol.
+package nats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/integration"
+)
+
+// Config holds the NATS sink configuration.
+type Config struct {
+	Server          string
+	Username        string
+	Password        string
+	SubjectTemplate string        `mapstructure:"subject_template"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// Sink implements a NATS event sink.
+// Every published event is followed by a PING, so that Publish only returns
+// once the NATS server has processed the PUB message.
+type Sink struct {
+	sync.Mutex
+
+	config          Config
+	subjectTemplate *template.Template
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+type connectOptions struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+// NewSink creates a new NATS sink.
+// The connection to the NATS server is setup on the first publish and
+// re-established after connection errors.
+func NewSink(c Config) (integration.Sink, error) {
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	t, err := template.New("subject").Parse(c.SubjectTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/nats: parse subject template error")
+	}
+
+	return &Sink{
+		config:          c,
+		subjectTemplate: t,
+	}, nil
+}
+
+// Publish publishes the given event.
+func (s *Sink) Publish(e integration.Event) error {
+	subject := bytes.NewBuffer(nil)
+	if err := s.subjectTemplate.Execute(subject, struct {
+		Type integration.EventType
+		Key  string
+	}{e.Type, e.Key.String()}); err != nil {
+		return errors.Wrap(err, "integration/nats: execute subject template error")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.publish(subject.String(), e.Payload); err != nil {
+		s.closeConn()
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection to the NATS server.
+func (s *Sink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	log.Info("integration/nats: closing sink")
+	s.closeConn()
+	return nil
+}
+
+func (s *Sink) connect() error {
+	log.WithField("server", s.config.Server).Info("integration/nats: connecting to nats server")
+
+	conn, err := net.DialTimeout("tcp", s.config.Server, s.config.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "integration/nats: dial error")
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+
+	if err := s.conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		s.closeConn()
+		return errors.Wrap(err, "integration/nats: set deadline error")
+	}
+
+	line, err := s.readLine()
+	if err != nil {
+		s.closeConn()
+		return errors.Wrap(err, "integration/nats: read info error")
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		s.closeConn()
+		return fmt.Errorf("integration/nats: expected INFO, got: %s", line)
+	}
+
+	opts, err := json.Marshal(connectOptions{
+		Name: "loraserver",
+		User: s.config.Username,
+		Pass: s.config.Password,
+	})
+	if err != nil {
+		s.closeConn()
+		return errors.Wrap(err, "integration/nats: marshal connect options error")
+	}
+
+	if _, err := fmt.Fprintf(s.conn, "CONNECT %s\r\nPING\r\n", opts); err != nil {
+		s.closeConn()
+		return errors.Wrap(err, "integration/nats: send connect error")
+	}
+
+	if err := s.waitForPong(); err != nil {
+		s.closeConn()
+		return err
+	}
+
+	return nil
+}
+
+func (s *Sink) publish(subject string, payload []byte) error {
+	if err := s.conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		return errors.Wrap(err, "integration/nats: set deadline error")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "PUB %s %d\r\n", subject, len(payload))
+	b.Write(payload)
+	b.WriteString("\r\nPING\r\n")
+
+	if _, err := s.conn.Write(b.Bytes()); err != nil {
+		return errors.Wrap(err, "integration/nats: publish error")
+	}
+
+	return s.waitForPong()
+}
+
+// waitForPong reads from the connection until a PONG is received.
+func (s *Sink) waitForPong() error {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return errors.Wrap(err, "integration/nats: read error")
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return errors.Wrap(err, "integration/nats: send pong error")
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("integration/nats: server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (s *Sink) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *Sink) closeConn() {
+	if s.conn == nil {
+		return
+	}
+	if err := s.conn.Close(); err != nil {
+		log.WithError(err).Error("integration/nats: close connection error")
+	}
+	s.conn = nil
+	s.reader = nil
+}
@@ -0,0 +1,123 @@
+package nats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+type testMessage struct {
+	Subject string
+	Payload []byte
+}
+
+// testServer implements a minimal NATS server, sufficient for testing the
+// publish flow.
+type testServer struct {
+	ln       net.Listener
+	messages chan testMessage
+}
+
+func newTestServer() (*testServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := testServer{
+		ln:       ln,
+		messages: make(chan testMessage, 10),
+	}
+	go s.serve()
+	return &s, nil
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *testServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PUB":
+			size, _ := strconv.Atoi(parts[len(parts)-1])
+			b := make([]byte, size+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return
+			}
+			s.messages <- testMessage{Subject: parts[1], Payload: b[:size]}
+		}
+	}
+}
+
+func TestSink(t *testing.T) {
+	assert := require.New(t)
+
+	server, err := newTestServer()
+	assert.NoError(err)
+	defer server.ln.Close()
+
+	sink, err := NewSink(Config{
+		Server:          server.ln.Addr().String(),
+		SubjectTemplate: "loraserver.{{ .Type }}.{{ .Key }}",
+		Timeout:         time.Second,
+	})
+	assert.NoError(err)
+	defer sink.Close()
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(sink.Publish(integration.Event{
+			Type:    integration.EventUplink,
+			Key:     lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			Payload: []byte{1, 2, 3, byte(i)},
+		}))
+
+		msg := <-server.messages
+		assert.Equal("loraserver.up.0102030405060708", msg.Subject)
+		assert.Equal([]byte{1, 2, 3, byte(i)}, msg.Payload)
+	}
+
+	t.Run("Server unavailable", func(t *testing.T) {
+		assert := require.New(t)
+
+		sink, err := NewSink(Config{
+			Server:          "127.0.0.1:1",
+			SubjectTemplate: "loraserver.{{ .Type }}.{{ .Key }}",
+			Timeout:         time.Second,
+		})
+		assert.NoError(err)
+		assert.Error(sink.Publish(integration.Event{Type: integration.EventUplink}))
+	})
+}
@@ -36,6 +36,14 @@ func Handle(ds *storage.DeviceSession, dp storage.DeviceProfile, sp storage.Serv
 		return handleResetInd(ds, dp, block)
 	case lorawan.RejoinParamSetupAns:
 		return handleRejoinParamSetupAns(ds, block, pending)
+	case RelayConfAns:
+		return handleRelayConfAns(ds, block, pending)
+	case UpdateUplinkListAns:
+		return handleUpdateUplinkListAns(ds, block, pending)
+	case CtrlUplinkListAns:
+		return handleCtrlUplinkListAns(ds, block, pending)
+	case NotifyNewEndDeviceReq:
+		return handleNotifyNewEndDeviceReq(ds, block)
 	default:
 		return nil, fmt.Errorf("undefined CID %d", block.CID)
 	}
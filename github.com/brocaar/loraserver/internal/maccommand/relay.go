@@ -0,0 +1,446 @@
+package maccommand
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/storage"
+	"github.com/brocaar/lorawan"
+)
+
+// Relay mac-commands, as specified by the LoRaWAN Relay specification.
+const (
+	RelayConfReq          lorawan.CID = 0x40
+	RelayConfAns          lorawan.CID = 0x40
+	EndDeviceConfReq      lorawan.CID = 0x41
+	EndDeviceConfAns      lorawan.CID = 0x41
+	FilterListReq         lorawan.CID = 0x42
+	FilterListAns         lorawan.CID = 0x42
+	UpdateUplinkListReq   lorawan.CID = 0x43
+	UpdateUplinkListAns   lorawan.CID = 0x43
+	CtrlUplinkListReq     lorawan.CID = 0x44
+	CtrlUplinkListAns     lorawan.CID = 0x44
+	ConfigureFwdLimitReq  lorawan.CID = 0x45
+	ConfigureFwdLimitAns  lorawan.CID = 0x45
+	NotifyNewEndDeviceReq lorawan.CID = 0x46
+)
+
+// RelayConfReqPayload represents the RelayConfReq payload.
+type RelayConfReqPayload struct {
+	StartStop         bool
+	CADPeriodicity    uint8
+	DefaultChIdx      uint8
+	SecondChIdx       uint8
+	SecondChDR        uint8
+	SecondChAckOffset uint8
+	SecondChFreq      int
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p RelayConfReqPayload) MarshalBinary() ([]byte, error) {
+	if p.CADPeriodicity > 7 {
+		return nil, errors.New("max value of CADPeriodicity is 7")
+	}
+	if p.DefaultChIdx > 1 {
+		return nil, errors.New("max value of DefaultChIdx is 1")
+	}
+	if p.SecondChIdx > 3 {
+		return nil, errors.New("max value of SecondChIdx is 3")
+	}
+	if p.SecondChDR > 15 {
+		return nil, errors.New("max value of SecondChDR is 15")
+	}
+	if p.SecondChAckOffset > 7 {
+		return nil, errors.New("max value of SecondChAckOffset is 7")
+	}
+	if p.SecondChFreq < 0 || p.SecondChFreq/100 >= (1<<24) {
+		return nil, errors.New("max value of SecondChFreq is 2^24-1 * 100")
+	}
+
+	settings := uint16(p.SecondChAckOffset) | uint16(p.SecondChDR)<<3 | uint16(p.SecondChIdx)<<7 | uint16(p.DefaultChIdx)<<9 | uint16(p.CADPeriodicity)<<10
+	if p.StartStop {
+		settings |= 1 << 13
+	}
+	freq := uint32(p.SecondChFreq / 100)
+
+	return []byte{
+		byte(settings), byte(settings >> 8),
+		byte(freq), byte(freq >> 8), byte(freq >> 16),
+	}, nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *RelayConfReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 5 {
+		return errors.New("5 bytes of data are expected")
+	}
+
+	settings := binary.LittleEndian.Uint16(data[0:2])
+	p.SecondChAckOffset = uint8(settings & 0x07)
+	p.SecondChDR = uint8((settings >> 3) & 0x0f)
+	p.SecondChIdx = uint8((settings >> 7) & 0x03)
+	p.DefaultChIdx = uint8((settings >> 9) & 0x01)
+	p.CADPeriodicity = uint8((settings >> 10) & 0x07)
+	p.StartStop = settings&(1<<13) != 0
+	p.SecondChFreq = int(uint32(data[2])|uint32(data[3])<<8|uint32(data[4])<<16) * 100
+
+	return nil
+}
+
+// RelayConfAnsPayload represents the RelayConfAns payload.
+type RelayConfAnsPayload struct {
+	SecondChAckOffsetACK bool
+	SecondChDRACK        bool
+	SecondChIdxACK       bool
+	DefaultChIdxACK      bool
+	CADPeriodicityACK    bool
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p RelayConfAnsPayload) MarshalBinary() ([]byte, error) {
+	var b byte
+	for i, ack := range []bool{p.SecondChAckOffsetACK, p.SecondChDRACK, p.SecondChIdxACK, p.DefaultChIdxACK, p.CADPeriodicityACK} {
+		if ack {
+			b |= 1 << uint(i)
+		}
+	}
+	return []byte{b}, nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *RelayConfAnsPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 {
+		return errors.New("1 byte of data is expected")
+	}
+
+	p.SecondChAckOffsetACK = data[0]&(1<<0) != 0
+	p.SecondChDRACK = data[0]&(1<<1) != 0
+	p.SecondChIdxACK = data[0]&(1<<2) != 0
+	p.DefaultChIdxACK = data[0]&(1<<3) != 0
+	p.CADPeriodicityACK = data[0]&(1<<4) != 0
+
+	return nil
+}
+
+// UpdateUplinkListReqPayload represents the UpdateUplinkListReq payload.
+type UpdateUplinkListReqPayload struct {
+	UplinkListIdx uint8
+	UplinkLimit   uint8
+	DevAddr       lorawan.DevAddr
+	WFCnt         uint32
+	RootWorSKey   lorawan.AES128Key
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p UpdateUplinkListReqPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 26)
+	b[0] = p.UplinkListIdx
+	b[1] = p.UplinkLimit
+
+	devAddrB, err := p.DevAddr.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(b[2:6], devAddrB)
+	binary.LittleEndian.PutUint32(b[6:10], p.WFCnt)
+	copy(b[10:26], p.RootWorSKey[:])
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *UpdateUplinkListReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 26 {
+		return errors.New("26 bytes of data are expected")
+	}
+
+	p.UplinkListIdx = data[0]
+	p.UplinkLimit = data[1]
+	if err := p.DevAddr.UnmarshalBinary(data[2:6]); err != nil {
+		return err
+	}
+	p.WFCnt = binary.LittleEndian.Uint32(data[6:10])
+	copy(p.RootWorSKey[:], data[10:26])
+
+	return nil
+}
+
+// CtrlUplinkListAction defines the action of a CtrlUplinkListReq.
+type CtrlUplinkListAction uint8
+
+// Available CtrlUplinkListReq actions.
+const (
+	CtrlUplinkListReadWFCnt  CtrlUplinkListAction = 0
+	CtrlUplinkListRemoveItem CtrlUplinkListAction = 1
+)
+
+// CtrlUplinkListReqPayload represents the CtrlUplinkListReq payload.
+type CtrlUplinkListReqPayload struct {
+	UplinkListIdx    uint8
+	CtrlUplinkAction CtrlUplinkListAction
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p CtrlUplinkListReqPayload) MarshalBinary() ([]byte, error) {
+	if p.UplinkListIdx > 15 {
+		return nil, errors.New("max value of UplinkListIdx is 15")
+	}
+	if p.CtrlUplinkAction > 15 {
+		return nil, errors.New("max value of CtrlUplinkAction is 15")
+	}
+
+	return []byte{p.UplinkListIdx | byte(p.CtrlUplinkAction)<<4}, nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *CtrlUplinkListReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 {
+		return errors.New("1 byte of data is expected")
+	}
+
+	p.UplinkListIdx = data[0] & 0x0f
+	p.CtrlUplinkAction = CtrlUplinkListAction(data[0] >> 4)
+
+	return nil
+}
+
+// CtrlUplinkListAnsPayload represents the CtrlUplinkListAns payload.
+type CtrlUplinkListAnsPayload struct {
+	UplinkListIdxACK bool
+	WFCnt            uint32
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p CtrlUplinkListAnsPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 5)
+	if p.UplinkListIdxACK {
+		b[0] = 1
+	}
+	binary.LittleEndian.PutUint32(b[1:5], p.WFCnt)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *CtrlUplinkListAnsPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 5 {
+		return errors.New("5 bytes of data are expected")
+	}
+
+	p.UplinkListIdxACK = data[0]&0x01 != 0
+	p.WFCnt = binary.LittleEndian.Uint32(data[1:5])
+
+	return nil
+}
+
+// NotifyNewEndDeviceReqPayload represents the NotifyNewEndDeviceReq payload.
+type NotifyNewEndDeviceReqPayload struct {
+	DevAddr lorawan.DevAddr
+	SNR     int
+	RSSI    int
+}
+
+// MarshalBinary marshals the object in binary form.
+func (p NotifyNewEndDeviceReqPayload) MarshalBinary() ([]byte, error) {
+	if p.SNR < -20 || p.SNR > 11 {
+		return nil, errors.New("SNR must be between -20 and 11")
+	}
+	if p.RSSI < -142 || p.RSSI > -15 {
+		return nil, errors.New("RSSI must be between -142 and -15")
+	}
+
+	devAddrB, err := p.DevAddr.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	// the RSSI is encoded as -15 - RSSI so that it fits in 7 bits
+	powerLevel := uint16(p.SNR+20) | uint16(-15-p.RSSI)<<5
+	return append(devAddrB, byte(powerLevel), byte(powerLevel>>8)), nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *NotifyNewEndDeviceReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != 6 {
+		return errors.New("6 bytes of data are expected")
+	}
+
+	if err := p.DevAddr.UnmarshalBinary(data[0:4]); err != nil {
+		return err
+	}
+
+	powerLevel := binary.LittleEndian.Uint16(data[4:6])
+	p.SNR = int(powerLevel&0x1f) - 20
+	p.RSSI = -15 - int((powerLevel>>5)&0x7f)
+
+	return nil
+}
+
+// RequestRelayConf returns a mac-command block for (re)configuring the
+// relay mode of a relay device.
+func RequestRelayConf(req RelayConfReqPayload) storage.MACCommandBlock {
+	return storage.MACCommandBlock{
+		CID: RelayConfReq,
+		MACCommands: []lorawan.MACCommand{
+			{
+				CID:     RelayConfReq,
+				Payload: &req,
+			},
+		},
+	}
+}
+
+// RequestUpdateUplinkList returns a mac-command block for adding or updating
+// an end-device in the trusted uplink list of a relay.
+func RequestUpdateUplinkList(req UpdateUplinkListReqPayload) storage.MACCommandBlock {
+	return storage.MACCommandBlock{
+		CID: UpdateUplinkListReq,
+		MACCommands: []lorawan.MACCommand{
+			{
+				CID:     UpdateUplinkListReq,
+				Payload: &req,
+			},
+		},
+	}
+}
+
+// RequestCtrlUplinkList returns a mac-command block for reading the WFCnt
+// of, or removing an end-device from the trusted uplink list of a relay.
+func RequestCtrlUplinkList(req CtrlUplinkListReqPayload) storage.MACCommandBlock {
+	return storage.MACCommandBlock{
+		CID: CtrlUplinkListReq,
+		MACCommands: []lorawan.MACCommand{
+			{
+				CID:     CtrlUplinkListReq,
+				Payload: &req,
+			},
+		},
+	}
+}
+
+func handleRelayConfAns(ds *storage.DeviceSession, block storage.MACCommandBlock, pendingBlock *storage.MACCommandBlock) ([]storage.MACCommandBlock, error) {
+	if len(block.MACCommands) != 1 {
+		return nil, errors.New("exactly one mac-command expected")
+	}
+	if pendingBlock == nil || len(pendingBlock.MACCommands) == 0 {
+		return nil, errors.New("expected pending mac-command")
+	}
+
+	var pl RelayConfAnsPayload
+	if err := decodeRelayPayload(block.MACCommands[0], &pl); err != nil {
+		return nil, err
+	}
+
+	if !pl.SecondChAckOffsetACK || !pl.SecondChDRACK || !pl.SecondChIdxACK || !pl.DefaultChIdxACK || !pl.CADPeriodicityACK {
+		log.WithFields(log.Fields{
+			"dev_eui":                  ds.DevEUI,
+			"second_ch_ack_offset_ack": pl.SecondChAckOffsetACK,
+			"second_ch_dr_ack":         pl.SecondChDRACK,
+			"second_ch_idx_ack":        pl.SecondChIdxACK,
+			"default_ch_idx_ack":       pl.DefaultChIdxACK,
+			"cad_periodicity_ack":      pl.CADPeriodicityACK,
+		}).Warning("relay_conf request not acknowledged")
+		return nil, nil
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui": ds.DevEUI,
+	}).Info("relay_conf request acknowledged")
+
+	return nil, nil
+}
+
+func handleUpdateUplinkListAns(ds *storage.DeviceSession, block storage.MACCommandBlock, pendingBlock *storage.MACCommandBlock) ([]storage.MACCommandBlock, error) {
+	if pendingBlock == nil || len(pendingBlock.MACCommands) == 0 {
+		return nil, errors.New("expected pending mac-command")
+	}
+
+	var req UpdateUplinkListReqPayload
+	if err := decodeRelayPayload(pendingBlock.MACCommands[0], &req); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui":         ds.DevEUI,
+		"uplink_list_idx": req.UplinkListIdx,
+		"dev_addr":        req.DevAddr,
+	}).Info("update_uplink_list request acknowledged")
+
+	return nil, nil
+}
+
+func handleCtrlUplinkListAns(ds *storage.DeviceSession, block storage.MACCommandBlock, pendingBlock *storage.MACCommandBlock) ([]storage.MACCommandBlock, error) {
+	if len(block.MACCommands) != 1 {
+		return nil, errors.New("exactly one mac-command expected")
+	}
+	if pendingBlock == nil || len(pendingBlock.MACCommands) == 0 {
+		return nil, errors.New("expected pending mac-command")
+	}
+
+	var req CtrlUplinkListReqPayload
+	if err := decodeRelayPayload(pendingBlock.MACCommands[0], &req); err != nil {
+		return nil, err
+	}
+
+	var pl CtrlUplinkListAnsPayload
+	if err := decodeRelayPayload(block.MACCommands[0], &pl); err != nil {
+		return nil, err
+	}
+
+	if !pl.UplinkListIdxACK {
+		log.WithFields(log.Fields{
+			"dev_eui":         ds.DevEUI,
+			"uplink_list_idx": req.UplinkListIdx,
+		}).Warning("ctrl_uplink_list request not acknowledged")
+		return nil, nil
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui":            ds.DevEUI,
+		"uplink_list_idx":    req.UplinkListIdx,
+		"ctrl_uplink_action": req.CtrlUplinkAction,
+		"w_f_cnt":            pl.WFCnt,
+	}).Info("ctrl_uplink_list request acknowledged")
+
+	return nil, nil
+}
+
+func handleNotifyNewEndDeviceReq(ds *storage.DeviceSession, block storage.MACCommandBlock) ([]storage.MACCommandBlock, error) {
+	for _, cmd := range block.MACCommands {
+		var pl NotifyNewEndDeviceReqPayload
+		if err := decodeRelayPayload(cmd, &pl); err != nil {
+			return nil, err
+		}
+
+		log.WithFields(log.Fields{
+			"dev_eui":  ds.DevEUI,
+			"dev_addr": pl.DevAddr,
+			"snr":      pl.SNR,
+			"rssi":     pl.RSSI,
+		}).Info("relay reported new end-device")
+	}
+
+	return nil, nil
+}
+
+// decodeRelayPayload decodes the payload of the given mac-command into pl.
+// As the relay mac-commands are not known to the lorawan package, the
+// payload might be decoded into an other (raw) payload type, in which case
+// it is re-encoded and decoded as the given payload type.
+func decodeRelayPayload(cmd lorawan.MACCommand, pl lorawan.MACCommandPayload) error {
+	if cmd.Payload == nil {
+		return errors.New("mac-command payload must not be nil")
+	}
+
+	b, err := cmd.Payload.MarshalBinary()
+	if err != nil {
+		return errors.Wrap(err, "marshal mac-command payload error")
+	}
+
+	if err := pl.UnmarshalBinary(b); err != nil {
+		return errors.Wrap(err, "unmarshal mac-command payload error")
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package maccommand
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/internal/storage"
+	"github.com/brocaar/lorawan"
+)
+
+func TestRelayPayloads(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Payload  lorawan.MACCommandPayload
+		Decoded  lorawan.MACCommandPayload
+		Expected []byte
+	}{
+		{
+			Name: "RelayConfReq",
+			Payload: &RelayConfReqPayload{
+				StartStop:         true,
+				CADPeriodicity:    2,
+				DefaultChIdx:      1,
+				SecondChIdx:       1,
+				SecondChDR:        3,
+				SecondChAckOffset: 5,
+				SecondChFreq:      868100000,
+			},
+			Decoded:  &RelayConfReqPayload{},
+			Expected: []byte{0x9d, 0x2a, 0x28, 0x76, 0x84},
+		},
+		{
+			Name: "RelayConfAns",
+			Payload: &RelayConfAnsPayload{
+				SecondChAckOffsetACK: true,
+				SecondChIdxACK:       true,
+				CADPeriodicityACK:    true,
+			},
+			Decoded:  &RelayConfAnsPayload{},
+			Expected: []byte{0x15},
+		},
+		{
+			Name: "UpdateUplinkListReq",
+			Payload: &UpdateUplinkListReqPayload{
+				UplinkListIdx: 1,
+				UplinkLimit:   2,
+				DevAddr:       lorawan.DevAddr{1, 2, 3, 4},
+				WFCnt:         10,
+				RootWorSKey:   lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			},
+			Decoded:  &UpdateUplinkListReqPayload{},
+			Expected: []byte{1, 2, 4, 3, 2, 1, 10, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+		{
+			Name: "CtrlUplinkListReq",
+			Payload: &CtrlUplinkListReqPayload{
+				UplinkListIdx:    3,
+				CtrlUplinkAction: CtrlUplinkListRemoveItem,
+			},
+			Decoded:  &CtrlUplinkListReqPayload{},
+			Expected: []byte{0x13},
+		},
+		{
+			Name: "CtrlUplinkListAns",
+			Payload: &CtrlUplinkListAnsPayload{
+				UplinkListIdxACK: true,
+				WFCnt:            258,
+			},
+			Decoded:  &CtrlUplinkListAnsPayload{},
+			Expected: []byte{0x01, 0x02, 0x01, 0x00, 0x00},
+		},
+		{
+			Name: "NotifyNewEndDeviceReq",
+			Payload: &NotifyNewEndDeviceReqPayload{
+				DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+				SNR:     5,
+				RSSI:    -100,
+			},
+			Decoded:  &NotifyNewEndDeviceReqPayload{},
+			Expected: []byte{4, 3, 2, 1, 0xb9, 0x0a},
+		},
+		{
+			Name: "NotifyNewEndDeviceReq min RSSI",
+			Payload: &NotifyNewEndDeviceReqPayload{
+				DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+				SNR:     11,
+				RSSI:    -142,
+			},
+			Decoded:  &NotifyNewEndDeviceReqPayload{},
+			Expected: []byte{4, 3, 2, 1, 0xff, 0x0f},
+		},
+		{
+			Name: "NotifyNewEndDeviceReq max RSSI",
+			Payload: &NotifyNewEndDeviceReqPayload{
+				DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+				SNR:     -20,
+				RSSI:    -15,
+			},
+			Decoded:  &NotifyNewEndDeviceReqPayload{},
+			Expected: []byte{4, 3, 2, 1, 0x00, 0x00},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := tst.Payload.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(tst.Expected, b)
+
+			assert.NoError(tst.Decoded.(encoding.BinaryUnmarshaler).UnmarshalBinary(b))
+			assert.Equal(tst.Payload, tst.Decoded)
+		})
+	}
+}
+
+func TestNotifyNewEndDeviceReqPayloadRSSI(t *testing.T) {
+	assert := require.New(t)
+
+	for _, rssi := range []int{-143, -14} {
+		pl := NotifyNewEndDeviceReqPayload{RSSI: rssi}
+		_, err := pl.MarshalBinary()
+		assert.Error(err)
+	}
+}
+
+func TestRequestRelayConf(t *testing.T) {
+	assert := require.New(t)
+
+	req := RelayConfReqPayload{
+		StartStop:    true,
+		SecondChFreq: 868100000,
+	}
+	block := RequestRelayConf(req)
+
+	assert.Equal(storage.MACCommandBlock{
+		CID: RelayConfReq,
+		MACCommands: []lorawan.MACCommand{
+			{
+				CID:     RelayConfReq,
+				Payload: &req,
+			},
+		},
+	}, block)
+}
+
+func TestHandleRelayConfAns(t *testing.T) {
+	pending := RequestRelayConf(RelayConfReqPayload{StartStop: true})
+
+	tests := []struct {
+		Name          string
+		Received      storage.MACCommandBlock
+		Pending       *storage.MACCommandBlock
+		ExpectedError string
+	}{
+		{
+			Name: "acknowledged (raw payload)",
+			Received: storage.MACCommandBlock{
+				CID: RelayConfAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     RelayConfAns,
+						Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{0x1f}},
+					},
+				},
+			},
+			Pending: &pending,
+		},
+		{
+			Name: "not acknowledged",
+			Received: storage.MACCommandBlock{
+				CID: RelayConfAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     RelayConfAns,
+						Payload: &RelayConfAnsPayload{SecondChDRACK: true},
+					},
+				},
+			},
+			Pending: &pending,
+		},
+		{
+			Name: "no pending request",
+			Received: storage.MACCommandBlock{
+				CID: RelayConfAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     RelayConfAns,
+						Payload: &RelayConfAnsPayload{},
+					},
+				},
+			},
+			ExpectedError: "expected pending mac-command",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var ds storage.DeviceSession
+			resp, err := handleRelayConfAns(&ds, tst.Received, tst.Pending)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Nil(resp)
+		})
+	}
+}
+
+func TestRelayCIDs(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(lorawan.CID(0x40), RelayConfReq)
+	assert.Equal(lorawan.CID(0x41), EndDeviceConfReq)
+	assert.Equal(lorawan.CID(0x42), FilterListReq)
+	assert.Equal(lorawan.CID(0x43), UpdateUplinkListReq)
+	assert.Equal(lorawan.CID(0x44), CtrlUplinkListReq)
+	assert.Equal(lorawan.CID(0x45), ConfigureFwdLimitReq)
+	assert.Equal(lorawan.CID(0x46), NotifyNewEndDeviceReq)
+}
+
+func TestHandleCtrlUplinkListAns(t *testing.T) {
+	pending := RequestCtrlUplinkList(CtrlUplinkListReqPayload{UplinkListIdx: 2})
+
+	tests := []struct {
+		Name          string
+		Received      storage.MACCommandBlock
+		Pending       *storage.MACCommandBlock
+		ExpectedError string
+	}{
+		{
+			Name: "acknowledged (raw payload)",
+			Received: storage.MACCommandBlock{
+				CID: CtrlUplinkListAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     CtrlUplinkListAns,
+						Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{0x01, 0x0a, 0x00, 0x00, 0x00}},
+					},
+				},
+			},
+			Pending: &pending,
+		},
+		{
+			Name: "not acknowledged",
+			Received: storage.MACCommandBlock{
+				CID: CtrlUplinkListAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     CtrlUplinkListAns,
+						Payload: &CtrlUplinkListAnsPayload{},
+					},
+				},
+			},
+			Pending: &pending,
+		},
+		{
+			Name: "invalid payload size",
+			Received: storage.MACCommandBlock{
+				CID: CtrlUplinkListAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     CtrlUplinkListAns,
+						Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{0x01}},
+					},
+				},
+			},
+			Pending:       &pending,
+			ExpectedError: "unmarshal mac-command payload error: 5 bytes of data are expected",
+		},
+		{
+			Name: "no pending request",
+			Received: storage.MACCommandBlock{
+				CID: CtrlUplinkListAns,
+				MACCommands: []lorawan.MACCommand{
+					{
+						CID:     CtrlUplinkListAns,
+						Payload: &CtrlUplinkListAnsPayload{},
+					},
+				},
+			},
+			ExpectedError: "expected pending mac-command",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var ds storage.DeviceSession
+			resp, err := handleCtrlUplinkListAns(&ds, tst.Received, tst.Pending)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Nil(resp)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// RelayFPort defines the FPort used for the frames exchanged between the
+// network-server and a relay (forwarded uplink and downlink frames).
+const RelayFPort = 226
+
+// forwardUplinkReqHeaderSize defines the size of the uplink meta-data and
+// frequency which are prepended to the forwarded PHYPayload.
+const forwardUplinkReqHeaderSize = 6
+
+// ForwardUplinkReq contains an uplink frame which was received by a relay
+// and forwarded to the network-server.
+type ForwardUplinkReq struct {
+	// WORChannel contains the wake-on-radio channel used by the end-device.
+	WORChannel uint8
+
+	// RSSI contains the RSSI of the end-device uplink, as measured by the
+	// relay (-142 - -15 dBm).
+	RSSI int
+
+	// SNR contains the SNR of the end-device uplink, as measured by the
+	// relay (-20 - 11 dB).
+	SNR int
+
+	// DR contains the data-rate of the end-device uplink.
+	DR int
+
+	// Frequency (Hz) of the end-device uplink.
+	Frequency int
+
+	// PHYPayload contains the end-device uplink PHYPayload.
+	PHYPayload lorawan.PHYPayload
+}
+
+// MarshalBinary marshals the object in binary form.
+func (r ForwardUplinkReq) MarshalBinary() ([]byte, error) {
+	if r.DR < 0 || r.DR > 15 {
+		return nil, errors.New("models: max value of DR is 15")
+	}
+	if r.SNR < -20 || r.SNR > 11 {
+		return nil, errors.New("models: SNR must be between -20 and 11")
+	}
+	if r.RSSI < -142 || r.RSSI > -15 {
+		return nil, errors.New("models: RSSI must be between -142 and -15")
+	}
+	if r.WORChannel > 3 {
+		return nil, errors.New("models: max value of WORChannel is 3")
+	}
+	if r.Frequency < 0 || r.Frequency/100 >= (1<<24) {
+		return nil, errors.New("models: max value of Frequency is 2^24-1 * 100")
+	}
+
+	phyB, err := r.PHYPayload.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	// uplink meta-data, the RSSI is encoded as -15 - RSSI so that it fits
+	// in 7 bits
+	meta := uint32(r.DR) | uint32(r.SNR+20)<<4 | uint32(-15-r.RSSI)<<9 | uint32(r.WORChannel)<<16
+	freq := uint32(r.Frequency / 100)
+
+	out := []byte{
+		byte(meta), byte(meta >> 8), byte(meta >> 16),
+		byte(freq), byte(freq >> 8), byte(freq >> 16),
+	}
+
+	return append(out, phyB...), nil
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (r *ForwardUplinkReq) UnmarshalBinary(data []byte) error {
+	if len(data) <= forwardUplinkReqHeaderSize {
+		return errors.New("models: at least 7 bytes are expected")
+	}
+
+	meta := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+	freq := uint32(data[3]) | uint32(data[4])<<8 | uint32(data[5])<<16
+
+	r.DR = int(meta & 0x0f)
+	r.SNR = int((meta>>4)&0x1f) - 20
+	r.RSSI = -15 - int((meta>>9)&0x7f)
+	r.WORChannel = uint8((meta >> 16) & 0x03)
+	r.Frequency = int(freq) * 100
+
+	return r.PHYPayload.UnmarshalBinary(data[forwardUplinkReqHeaderSize:])
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestForwardUplinkReq(t *testing.T) {
+	assert := require.New(t)
+
+	fPort := uint8(10)
+	req := ForwardUplinkReq{
+		WORChannel: 1,
+		RSSI:       -100,
+		SNR:        5,
+		DR:         3,
+		Frequency:  868100000,
+		PHYPayload: lorawan.PHYPayload{
+			MHDR: lorawan.MHDR{
+				MType: lorawan.UnconfirmedDataUp,
+				Major: lorawan.LoRaWANR1,
+			},
+			MACPayload: &lorawan.MACPayload{
+				FHDR: lorawan.FHDR{
+					DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+					FCnt:    10,
+				},
+				FPort: &fPort,
+				FRMPayload: []lorawan.Payload{
+					&lorawan.DataPayload{Bytes: []byte{1, 2, 3}},
+				},
+			},
+			MIC: lorawan.MIC{1, 2, 3, 4},
+		},
+	}
+
+	b, err := req.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal([]byte{0x93, 0xab, 0x01, 0x28, 0x76, 0x84}, b[0:6])
+
+	var reqDecoded ForwardUplinkReq
+	assert.NoError(reqDecoded.UnmarshalBinary(b))
+	assert.Equal(req.WORChannel, reqDecoded.WORChannel)
+	assert.Equal(req.RSSI, reqDecoded.RSSI)
+	assert.Equal(req.SNR, reqDecoded.SNR)
+	assert.Equal(req.DR, reqDecoded.DR)
+	assert.Equal(req.Frequency, reqDecoded.Frequency)
+
+	phyB, err := reqDecoded.PHYPayload.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal(b[6:], phyB)
+
+	t.Run("RSSI boundaries", func(t *testing.T) {
+		assert := require.New(t)
+
+		for _, rssi := range []int{-142, -15} {
+			r := req
+			r.RSSI = rssi
+			r.WORChannel = 3
+			b, err := r.MarshalBinary()
+			assert.NoError(err)
+
+			var decoded ForwardUplinkReq
+			assert.NoError(decoded.UnmarshalBinary(b))
+			assert.Equal(rssi, decoded.RSSI)
+			assert.Equal(r.WORChannel, decoded.WORChannel)
+			assert.Equal(r.SNR, decoded.SNR)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		assert := require.New(t)
+
+		for _, rssi := range []int{-143, -14} {
+			r := req
+			r.RSSI = rssi
+			_, err := r.MarshalBinary()
+			assert.Error(err)
+		}
+
+		req.SNR = 12
+		_, err := req.MarshalBinary()
+		assert.Error(err)
+
+		assert.Error(reqDecoded.UnmarshalBinary([]byte{1, 2, 3, 4, 5, 6}))
+	})
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/brocaar/loraserver/internal/gps"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/brocaar/loraserver/api/as"
 	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/integration"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -30,13 +32,21 @@ type DeviceQueueItem struct {
 	IsPending               bool           `db:"is_pending"`
 	EmitAtTimeSinceGPSEpoch *time.Duration `db:"emit_at_time_since_gps_epoch"`
 	TimeoutAfter            *time.Time     `db:"timeout_after"`
+	Priority                int            `db:"priority"`
+	ExpireAt                *time.Time     `db:"expire_at"`
 }
 
+// DeviceQueueItemMaxPriority defines the max. device-queue item priority.
+const DeviceQueueItemMaxPriority = 255
+
 // Validate validates the DeviceQueueItem.
 func (d DeviceQueueItem) Validate() error {
 	if d.FPort == 0 {
 		return ErrInvalidFPort
 	}
+	if d.Priority < 0 || d.Priority > DeviceQueueItemMaxPriority {
+		return ErrInvalidPriority
+	}
 	return nil
 }
 
@@ -61,8 +71,10 @@ func CreateDeviceQueueItem(db sqlx.Queryer, qi *DeviceQueueItem) error {
             confirmed,
             emit_at_time_since_gps_epoch,
             is_pending,
-            timeout_after
-        ) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+            timeout_after,
+            priority,
+            expire_at
+        ) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         returning id`,
 		qi.CreatedAt,
 		qi.UpdatedAt,
@@ -74,14 +86,17 @@ func CreateDeviceQueueItem(db sqlx.Queryer, qi *DeviceQueueItem) error {
 		qi.EmitAtTimeSinceGPSEpoch,
 		qi.IsPending,
 		qi.TimeoutAfter,
+		qi.Priority,
+		qi.ExpireAt,
 	)
 	if err != nil {
 		return handlePSQLError(err, "insert error")
 	}
 
 	log.WithFields(log.Fields{
-		"dev_eui": qi.DevEUI,
-		"f_cnt":   qi.FCnt,
+		"dev_eui":  qi.DevEUI,
+		"f_cnt":    qi.FCnt,
+		"priority": qi.Priority,
 	}).Info("device-queue item created")
 
 	return nil
@@ -112,7 +127,9 @@ func UpdateDeviceQueueItem(db sqlx.Execer, qi *DeviceQueueItem) error {
             confirmed = $7,
             emit_at_time_since_gps_epoch = $8,
             is_pending = $9,
-            timeout_after = $10
+            timeout_after = $10,
+            priority = $11,
+            expire_at = $12
         where
             id = $1`,
 		qi.ID,
@@ -125,6 +142,8 @@ func UpdateDeviceQueueItem(db sqlx.Execer, qi *DeviceQueueItem) error {
 		qi.EmitAtTimeSinceGPSEpoch,
 		qi.IsPending,
 		qi.TimeoutAfter,
+		qi.Priority,
+		qi.ExpireAt,
 	)
 	if err != nil {
 		return handlePSQLError(err, "update error")
@@ -184,7 +203,9 @@ func FlushDeviceQueueForDevEUI(db sqlx.Execer, devEUI lorawan.EUI64) error {
 }
 
 // GetNextDeviceQueueItemForDevEUI returns the next device-queue item for the
-// given DevEUI, ordered by f_cnt (note that the f_cnt should never roll over).
+// given DevEUI. A pending item is always returned first, after that the items
+// are ordered by priority (highest first) and f_cnt (note that the f_cnt
+// should never roll over).
 func GetNextDeviceQueueItemForDevEUI(db sqlx.Queryer, devEUI lorawan.EUI64) (DeviceQueueItem, error) {
 	var qi DeviceQueueItem
 	err := sqlx.Get(db, &qi, `
@@ -195,6 +216,8 @@ func GetNextDeviceQueueItemForDevEUI(db sqlx.Queryer, devEUI lorawan.EUI64) (Dev
         where
             dev_eui = $1
         order by
+            is_pending desc,
+            priority desc,
             f_cnt
         limit 1`,
 		devEUI[:],
@@ -224,6 +247,7 @@ func GetPendingDeviceQueueItemForDevEUI(db sqlx.Queryer, devEUI lorawan.EUI64) (
         where
             dev_eui = $1
         order by
+            is_pending desc,
             f_cnt
         limit 1`,
 		devEUI[:],
@@ -265,10 +289,13 @@ func GetDeviceQueueItemsForDevEUI(db sqlx.Queryer, devEUI lorawan.EUI64) ([]Devi
 // device-queue for the given DevEUI item respecting:
 // * maxPayloadSize: the maximum payload size
 // * fCnt: the current expected frame-counter
-// In case the payload exceeds the max payload size or when the payload
-// frame-counter is behind the actual frame-counter, the payload will be removed
-// from the queue and the next one will be retrieved. In such a case, the
-// application-server will be notified.
+// In case the payload exceeds the max payload size, when the payload
+// frame-counter is behind the actual frame-counter or when the payload has
+// expired, the payload will be removed from the queue and the next one will
+// be retrieved. In such a case, the application-server will be notified.
+// When the returned item overtakes items with a lower frame-counter because
+// of its priority, the queue is renumbered in transmission order (see
+// renumberDeviceQueueItems).
 func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lorawan.EUI64, maxPayloadSize int, fCnt uint32, routingProfileID uuid.UUID) (DeviceQueueItem, error) {
 	for {
 		qi, err := GetNextDeviceQueueItemForDevEUI(db, devEUI)
@@ -276,7 +303,7 @@ func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lo
 			return DeviceQueueItem{}, errors.Wrap(err, "get next device-queue item error")
 		}
 
-		if qi.FCnt < fCnt || len(qi.FRMPayload) > maxPayloadSize || (qi.TimeoutAfter != nil && qi.TimeoutAfter.Before(time.Now())) {
+		if qi.FCnt < fCnt || len(qi.FRMPayload) > maxPayloadSize || (qi.TimeoutAfter != nil && qi.TimeoutAfter.Before(time.Now())) || (!qi.IsPending && qi.ExpireAt != nil && qi.ExpireAt.Before(time.Now())) {
 			rp, err := GetRoutingProfile(db, routingProfileID)
 			if err != nil {
 				return DeviceQueueItem{}, errors.Wrap(err, "get routing-profile error")
@@ -297,11 +324,40 @@ func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lo
 					"device_queue_item_fcnt": qi.FCnt,
 				}).Warning("device-queue item discarded due to timeout")
 
-				_, err = asClient.HandleDownlinkACK(context.Background(), &as.HandleDownlinkACKRequest{
+				ackReq := as.HandleDownlinkACKRequest{
 					DevEui:       devEUI[:],
 					FCnt:         qi.FCnt,
 					Acknowledged: false,
-				})
+				}
+
+				if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventACK, devEUI, &ackReq); err != nil {
+					log.WithError(err).Error("publish ack event error")
+				}
+
+				_, err = asClient.HandleDownlinkACK(context.Background(), &ackReq)
+				if err != nil {
+					return DeviceQueueItem{}, errors.Wrap(err, "application-server client error")
+				}
+			} else if !qi.IsPending && qi.ExpireAt != nil && qi.ExpireAt.Before(time.Now()) {
+				// expired
+				log.WithFields(log.Fields{
+					"dev_eui":                devEUI,
+					"device_queue_item_fcnt": qi.FCnt,
+					"expire_at":              qi.ExpireAt,
+				}).Warning("device-queue item discarded as it has expired")
+
+				errReq := as.HandleErrorRequest{
+					DevEui: devEUI[:],
+					Type:   as.ErrorType_DEVICE_QUEUE_ITEM_EXPIRED,
+					FCnt:   qi.FCnt,
+					Error:  "payload has expired",
+				}
+
+				if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventError, devEUI, &errReq); err != nil {
+					log.WithError(err).Error("publish error event error")
+				}
+
+				_, err = asClient.HandleError(context.Background(), &errReq)
 				if err != nil {
 					return DeviceQueueItem{}, errors.Wrap(err, "application-server client error")
 				}
@@ -313,12 +369,18 @@ func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lo
 					"device_queue_item_fcnt": qi.FCnt,
 				}).Warning("device-queue item discarded due to invalid fCnt")
 
-				_, err = asClient.HandleError(context.Background(), &as.HandleErrorRequest{
+				errReq := as.HandleErrorRequest{
 					DevEui: devEUI[:],
 					Type:   as.ErrorType_DEVICE_QUEUE_ITEM_FCNT,
 					FCnt:   qi.FCnt,
 					Error:  "invalid frame-counter",
-				})
+				}
+
+				if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventError, devEUI, &errReq); err != nil {
+					log.WithError(err).Error("publish error event error")
+				}
+
+				_, err = asClient.HandleError(context.Background(), &errReq)
 				if err != nil {
 					return DeviceQueueItem{}, errors.Wrap(err, "application-server client error")
 				}
@@ -331,12 +393,18 @@ func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lo
 					"device_queue_item_payload_size": len(qi.FRMPayload),
 				}).Warning("device-queue item discarded as it exceeds the max payload size")
 
-				_, err = asClient.HandleError(context.Background(), &as.HandleErrorRequest{
+				errReq := as.HandleErrorRequest{
 					DevEui: devEUI[:],
 					Type:   as.ErrorType_DEVICE_QUEUE_ITEM_SIZE,
 					FCnt:   qi.FCnt,
 					Error:  "payload exceeds max payload size",
-				})
+				}
+
+				if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventError, devEUI, &errReq); err != nil {
+					log.WithError(err).Error("publish error event error")
+				}
+
+				_, err = asClient.HandleError(context.Background(), &errReq)
 				if err != nil {
 					return DeviceQueueItem{}, errors.Wrap(err, "application-server client error")
 				}
@@ -346,10 +414,131 @@ func GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(db sqlx.Ext, devEUI lo
 			continue
 		}
 
+		if !qi.IsPending && qi.FCnt > fCnt {
+			var count int
+			err = sqlx.Get(db, &count, `
+                select
+                    count(*)
+                from
+                    device_queue
+                where
+                    dev_eui = $1
+                    and is_pending = false
+                    and f_cnt >= $2
+                    and f_cnt < $3`,
+				devEUI[:],
+				fCnt,
+				qi.FCnt,
+			)
+			if err != nil {
+				return DeviceQueueItem{}, handlePSQLError(err, "select error")
+			}
+
+			if count != 0 {
+				if err := renumberDeviceQueueItems(db, devEUI, fCnt, routingProfileID); err != nil {
+					return DeviceQueueItem{}, errors.Wrap(err, "renumber device-queue items error")
+				}
+
+				qi, err = GetDeviceQueueItem(db, qi.ID)
+				if err != nil {
+					return DeviceQueueItem{}, errors.Wrap(err, "get device-queue item error")
+				}
+			}
+		}
+
 		return qi, nil
 	}
 }
 
+// renumberDeviceQueueItems assigns the frame-counters fCnt, fCnt+1, ... to
+// the device-queue items of the given DevEUI in the order in which they will
+// be transmitted (priority first, then f_cnt). As the FRMPayload is encrypted
+// using the frame-counter, the application-server re-encrypts the payloads of
+// which the frame-counter changes. Pending items and items with a
+// frame-counter behind fCnt are not renumbered.
+func renumberDeviceQueueItems(db sqlx.Ext, devEUI lorawan.EUI64, fCnt uint32, routingProfileID uuid.UUID) error {
+	var items []DeviceQueueItem
+	err := sqlx.Select(db, &items, `
+        select
+            *
+        from
+            device_queue
+        where
+            dev_eui = $1
+            and is_pending = false
+            and f_cnt >= $2
+        order by
+            priority desc,
+            f_cnt`,
+		devEUI[:],
+		fCnt,
+	)
+	if err != nil {
+		return handlePSQLError(err, "select error")
+	}
+
+	var renumbered []*DeviceQueueItem
+	req := as.ReEncryptDeviceQueueItemsRequest{
+		DevEui: devEUI[:],
+	}
+	for i := range items {
+		newFCnt := fCnt + uint32(i)
+		if items[i].FCnt == newFCnt {
+			continue
+		}
+
+		req.Items = append(req.Items, &as.ReEncryptDeviceQueueItem{
+			FrmPayload: items[i].FRMPayload,
+			FCnt:       items[i].FCnt,
+			FPort:      uint32(items[i].FPort),
+			NewFCnt:    newFCnt,
+		})
+		renumbered = append(renumbered, &items[i])
+	}
+
+	if len(renumbered) == 0 {
+		return nil
+	}
+
+	rp, err := GetRoutingProfile(db, routingProfileID)
+	if err != nil {
+		return errors.Wrap(err, "get routing-profile error")
+	}
+	asClient, err := config.C.ApplicationServer.Pool.Get(rp.ASID, []byte(rp.CACert), []byte(rp.TLSCert), []byte(rp.TLSKey))
+	if err != nil {
+		return errors.Wrap(err, "get application-server client error")
+	}
+
+	resp, err := asClient.ReEncryptDeviceQueueItems(context.Background(), &req)
+	if err != nil {
+		return errors.Wrap(err, "application-server client error")
+	}
+	if len(resp.Items) != len(renumbered) {
+		return fmt.Errorf("expected %d re-encrypted device-queue items, got %d", len(renumbered), len(resp.Items))
+	}
+
+	for i, qi := range renumbered {
+		if resp.Items[i].FCnt != req.Items[i].NewFCnt {
+			return fmt.Errorf("expected re-encrypted device-queue item with f_cnt %d, got %d", req.Items[i].NewFCnt, resp.Items[i].FCnt)
+		}
+
+		log.WithFields(log.Fields{
+			"dev_eui":   devEUI,
+			"f_cnt":     qi.FCnt,
+			"new_f_cnt": resp.Items[i].FCnt,
+			"priority":  qi.Priority,
+		}).Info("device-queue item renumbered")
+
+		qi.FRMPayload = resp.Items[i].FrmPayload
+		qi.FCnt = resp.Items[i].FCnt
+		if err := UpdateDeviceQueueItem(db, qi); err != nil {
+			return errors.Wrap(err, "update device-queue item error")
+		}
+	}
+
+	return nil
+}
+
 // GetDevicesWithClassBOrClassCDeviceQueueItems returns a slice of devices that qualify
 // for downlink Class-C transmission.
 // The device records will be locked for update so that multiple instances can
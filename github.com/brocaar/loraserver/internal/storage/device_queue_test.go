@@ -28,6 +28,13 @@ func TestDeviceQueueItemValidate(t *testing.T) {
 				},
 				ExpectedError: ErrInvalidFPort,
 			},
+			{
+				Item: DeviceQueueItem{
+					FPort:    1,
+					Priority: 256,
+				},
+				ExpectedError: ErrInvalidPriority,
+			},
 			{
 				Item: DeviceQueueItem{
 					FPort: 1,
@@ -163,6 +170,31 @@ func TestDeviceQueue(t *testing.T) {
 					})
 				})
 
+				Convey("Given the last item in the queue has a higher priority", func() {
+					items[1].Priority = 10
+					So(UpdateDeviceQueueItem(config.C.PostgreSQL.DB, &items[1]), ShouldBeNil)
+
+					Convey("Then GetNextDeviceQueueItemForDevEUI returns the item with the highest priority", func() {
+						qi, err := GetNextDeviceQueueItemForDevEUI(config.C.PostgreSQL.DB, d.DevEUI)
+						So(err, ShouldBeNil)
+						So(qi.ID, ShouldEqual, items[1].ID)
+						So(qi.Priority, ShouldEqual, 10)
+					})
+
+					Convey("Given the first item is pending", func() {
+						ts := time.Now().Add(-time.Minute)
+						items[0].IsPending = true
+						items[0].TimeoutAfter = &ts
+						So(UpdateDeviceQueueItem(config.C.PostgreSQL.DB, &items[0]), ShouldBeNil)
+
+						Convey("Then GetNextDeviceQueueItemForDevEUI returns the pending item", func() {
+							qi, err := GetNextDeviceQueueItemForDevEUI(config.C.PostgreSQL.DB, d.DevEUI)
+							So(err, ShouldBeNil)
+							So(qi.ID, ShouldEqual, items[0].ID)
+						})
+					})
+				})
+
 				Convey("Then FlushDeviceQueueForDevEUI flushes the queue", func() {
 					So(FlushDeviceQueueForDevEUI(db, d.DevEUI), ShouldBeNil)
 					items, err := GetDeviceQueueItemsForDevEUI(db, d.DevEUI)
@@ -292,6 +324,73 @@ func TestDeviceQueue(t *testing.T) {
 					},
 				}
 
+				Convey("Given the first non-pending item has expired", func() {
+					expireAt := time.Now().Add(-time.Second)
+					items[1].ExpireAt = &expireAt
+					So(UpdateDeviceQueueItem(config.C.PostgreSQL.DB, &items[1]), ShouldBeNil)
+
+					Convey("Then it is discarded and reported as expired", func() {
+						qi, err := GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(config.C.PostgreSQL.DB, d.DevEUI, 7, 100, rp.ID)
+						So(err, ShouldBeNil)
+						So(qi.ID, ShouldEqual, items[2].ID)
+
+						So(asClient.HandleDownlinkACKChan, ShouldHaveLength, 1)
+						So(<-asClient.HandleDownlinkACKChan, ShouldResemble, as.HandleDownlinkACKRequest{DevEui: d.DevEUI[:], FCnt: items[0].FCnt, Acknowledged: false})
+
+						So(asClient.HandleErrorChan, ShouldHaveLength, 1)
+						So(<-asClient.HandleErrorChan, ShouldResemble, as.HandleErrorRequest{DevEui: d.DevEUI[:], Type: as.ErrorType_DEVICE_QUEUE_ITEM_EXPIRED, Error: "payload has expired", FCnt: 101})
+					})
+				})
+
+				Convey("Given the last item has a higher priority", func() {
+					items[4].Priority = 10
+					So(UpdateDeviceQueueItem(config.C.PostgreSQL.DB, &items[4]), ShouldBeNil)
+
+					asClient.ReEncryptDeviceQueueItemsResponse = as.ReEncryptDeviceQueueItemsResponse{
+						Items: []*as.ReEncryptedDeviceQueueItem{
+							{FrmPayload: []byte{4, 3, 2, 1}, FCnt: 101},
+							{FrmPayload: []byte{7, 6, 5, 4, 3, 2, 1}, FCnt: 102},
+							{FrmPayload: []byte{6, 5, 4, 3, 2, 1}, FCnt: 103},
+							{FrmPayload: []byte{5, 4, 3, 2, 1}, FCnt: 104},
+						},
+					}
+
+					Convey("Then it is sent first using the next frame-counter", func() {
+						qi, err := GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(config.C.PostgreSQL.DB, d.DevEUI, 7, 101, rp.ID)
+						So(err, ShouldBeNil)
+						So(qi.ID, ShouldEqual, items[4].ID)
+						So(qi.FCnt, ShouldEqual, 101)
+						So(qi.FRMPayload, ShouldResemble, []byte{4, 3, 2, 1})
+
+						So(asClient.ReEncryptDeviceQueueItemsChan, ShouldHaveLength, 1)
+						So(<-asClient.ReEncryptDeviceQueueItemsChan, ShouldResemble, as.ReEncryptDeviceQueueItemsRequest{
+							DevEui: d.DevEUI[:],
+							Items: []*as.ReEncryptDeviceQueueItem{
+								{FrmPayload: []byte{1, 2, 3, 4}, FCnt: 104, FPort: 4, NewFCnt: 101},
+								{FrmPayload: []byte{1, 2, 3, 4, 5, 6, 7}, FCnt: 101, FPort: 1, NewFCnt: 102},
+								{FrmPayload: []byte{1, 2, 3, 4, 5, 6}, FCnt: 102, FPort: 2, NewFCnt: 103},
+								{FrmPayload: []byte{1, 2, 3, 4, 5}, FCnt: 103, FPort: 3, NewFCnt: 104},
+							},
+						})
+
+						Convey("Then the overtaken items are renumbered", func() {
+							for i, expected := range []struct {
+								FCnt       uint32
+								FRMPayload []byte
+							}{
+								{102, []byte{7, 6, 5, 4, 3, 2, 1}},
+								{103, []byte{6, 5, 4, 3, 2, 1}},
+								{104, []byte{5, 4, 3, 2, 1}},
+							} {
+								qi, err := GetDeviceQueueItem(config.C.PostgreSQL.DB, items[i+1].ID)
+								So(err, ShouldBeNil)
+								So(qi.FCnt, ShouldEqual, expected.FCnt)
+								So(qi.FRMPayload, ShouldResemble, expected.FRMPayload)
+							}
+						})
+					})
+				})
+
 				for i, test := range tests {
 					Convey(fmt.Sprintf("Testing: %s [%d]", test.Name, i), func() {
 						qi, err := GetNextDeviceQueueItemForDevEUIMaxPayloadSizeAndFCnt(config.C.PostgreSQL.DB, d.DevEUI, test.MaxFRMPayload, test.FCnt, rp.ID)
@@ -333,6 +333,45 @@ func SaveDeviceSession(p *redis.Pool, s DeviceSession) error {
 	return nil
 }
 
+// SetDeviceSessionDevAddr saves the device-session using the given DevAddr
+// and removes the DevEUI from the set of its previous DevAddr, so that
+// uplinks using the previous DevAddr no longer match the device-session.
+func SetDeviceSessionDevAddr(p *redis.Pool, s DeviceSession, devAddr lorawan.DevAddr) (DeviceSession, error) {
+	oldDevAddr := s.DevAddr
+	s.DevAddr = devAddr
+
+	dsPB := deviceSessionToPB(s)
+	b, err := proto.Marshal(&dsPB)
+	if err != nil {
+		return s, errors.Wrap(err, "protobuf encode error")
+	}
+
+	c := p.Get()
+	defer c.Close()
+	exp := int64(config.C.NetworkServer.DeviceSessionTTL) / int64(time.Millisecond)
+
+	c.Send("MULTI")
+	c.Send("PSETEX", fmt.Sprintf(deviceSessionKeyTempl, s.DevEUI), exp, b)
+	// the previous DevAddr is kept when it is still used by the pending
+	// rejoin device-session
+	if oldDevAddr != devAddr && (s.PendingRejoinDeviceSession == nil || s.PendingRejoinDeviceSession.DevAddr != oldDevAddr) {
+		c.Send("SREM", fmt.Sprintf(devAddrKeyTempl, oldDevAddr), s.DevEUI[:])
+	}
+	c.Send("SADD", fmt.Sprintf(devAddrKeyTempl, devAddr), s.DevEUI[:])
+	c.Send("PEXPIRE", fmt.Sprintf(devAddrKeyTempl, devAddr), exp)
+	if _, err := c.Do("EXEC"); err != nil {
+		return s, errors.Wrap(err, "exec error")
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui":      s.DevEUI,
+		"old_dev_addr": oldDevAddr,
+		"dev_addr":     devAddr,
+	}).Info("device-session DevAddr set")
+
+	return s, nil
+}
+
 // GetDeviceSession returns the device-session for the given DevEUI.
 func GetDeviceSession(p *redis.Pool, devEUI lorawan.EUI64) (DeviceSession, error) {
 	var dsPB DeviceSessionPB
@@ -576,6 +615,92 @@ func GetDeviceGatewayRXInfoSet(p *redis.Pool, devEUI lorawan.EUI64) (DeviceGatew
 	return deviceGatewayRXInfoSetFromPB(rxInfoSetPB), nil
 }
 
+// GetDevEUIsForDeviceSessions returns the DevEUIs of all the stored
+// device-sessions.
+func GetDevEUIsForDeviceSessions(p *redis.Pool) ([]lorawan.EUI64, error) {
+	return scanDevEUIs(p, fmt.Sprintf(deviceSessionKeyTempl, "*"), 4)
+}
+
+// GetDevEUIsForDeviceGatewayRXInfoSets returns the DevEUIs of all the stored
+// device gateway rx-info sets.
+func GetDevEUIsForDeviceGatewayRXInfoSets(p *redis.Pool) ([]lorawan.EUI64, error) {
+	return scanDevEUIs(p, fmt.Sprintf(deviceGatewayRXInfoSetKeyTempl, "*"), 5)
+}
+
+// scanDevEUIs returns the unique DevEUIs of the keys matching the given
+// pattern. As the pattern also matches keys with additional parts
+// (e.g. lora:ns:device:* also matches lora:ns:device:*:gwrx), only keys
+// with the given number of (colon separated) parts are returned.
+// The DevEUI must be the fourth part of the key.
+func scanDevEUIs(p *redis.Pool, pattern string, parts int) ([]lorawan.EUI64, error) {
+	keys, err := scanKeys(p, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []lorawan.EUI64
+	seen := make(map[lorawan.EUI64]struct{})
+
+	for _, key := range keys {
+		keyParts := strings.Split(key, ":")
+		if len(keyParts) != parts {
+			continue
+		}
+
+		var devEUI lorawan.EUI64
+		if err := devEUI.UnmarshalText([]byte(keyParts[3])); err != nil {
+			log.WithField("key", key).WithError(err).Warning("decode DevEUI from key error")
+			continue
+		}
+
+		if _, ok := seen[devEUI]; ok {
+			continue
+		}
+		seen[devEUI] = struct{}{}
+		out = append(out, devEUI)
+	}
+
+	return out, nil
+}
+
+// scanKeys returns the keys matching the given pattern. It uses SCAN instead
+// of KEYS so that it does not block the Redis server.
+func scanKeys(p *redis.Pool, pattern string) ([]string, error) {
+	var out []string
+
+	c := p.Get()
+	defer c.Close()
+
+	cursor := 0
+	for {
+		values, err := redis.Values(c.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 1000))
+		if err != nil {
+			return nil, errors.Wrap(err, "scan error")
+		}
+
+		if len(values) != 2 {
+			return nil, errors.New("unexpected scan response")
+		}
+
+		cursor, err = redis.Int(values[0], nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "get cursor error")
+		}
+
+		keys, err := redis.Strings(values[1], nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "get keys error")
+		}
+		out = append(out, keys...)
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return out, nil
+}
+
 // GetDeviceGatewayRXInfoSetForDevEUIs returns the DeviceGatewayRXInfoSet
 // objects for the given Device EUIs.
 func GetDeviceGatewayRXInfoSetForDevEUIs(p *redis.Pool, devEUIs []lorawan.EUI64) ([]DeviceGatewayRXInfoSet, error) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/require"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -379,3 +380,76 @@ func (ts *StorageTestSuite) TestDeviceGatewayRXInfoSet() {
 		})
 	})
 }
+
+func (ts *StorageTestSuite) TestGetDevEUIsForDeviceSessions() {
+	assert := require.New(ts.T())
+
+	devEUIs := []lorawan.EUI64{
+		{1, 2, 3, 4, 5, 6, 7, 8},
+		{2, 2, 3, 4, 5, 6, 7, 8},
+	}
+
+	for _, devEUI := range devEUIs {
+		assert.NoError(SaveDeviceSession(ts.RedisPool(), DeviceSession{
+			DevEUI:  devEUI,
+			DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+		}))
+	}
+	assert.NoError(SaveDeviceGatewayRXInfoSet(ts.RedisPool(), DeviceGatewayRXInfoSet{
+		DevEUI: lorawan.EUI64{3, 2, 3, 4, 5, 6, 7, 8},
+	}))
+
+	out, err := GetDevEUIsForDeviceSessions(ts.RedisPool())
+	assert.NoError(err)
+	assert.ElementsMatch(devEUIs, out)
+
+	out, err = GetDevEUIsForDeviceGatewayRXInfoSets(ts.RedisPool())
+	assert.NoError(err)
+	assert.Equal([]lorawan.EUI64{{3, 2, 3, 4, 5, 6, 7, 8}}, out)
+}
+
+func (ts *StorageTestSuite) TestSetDeviceSessionDevAddr() {
+	assert := require.New(ts.T())
+
+	devAddrMembers := func(devAddr lorawan.DevAddr) [][]byte {
+		c := ts.RedisPool().Get()
+		defer c.Close()
+
+		members, err := redis.ByteSlices(c.Do("SMEMBERS", fmt.Sprintf(devAddrKeyTempl, devAddr)))
+		assert.NoError(err)
+		return members
+	}
+
+	ds := DeviceSession{
+		DevEUI:  lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		DevAddr: lorawan.DevAddr{1, 2, 3, 4},
+	}
+	assert.NoError(SaveDeviceSession(ts.RedisPool(), ds))
+
+	ds, err := SetDeviceSessionDevAddr(ts.RedisPool(), ds, lorawan.DevAddr{4, 3, 2, 1})
+	assert.NoError(err)
+	assert.Equal(lorawan.DevAddr{4, 3, 2, 1}, ds.DevAddr)
+
+	stored, err := GetDeviceSession(ts.RedisPool(), ds.DevEUI)
+	assert.NoError(err)
+	assert.Equal(lorawan.DevAddr{4, 3, 2, 1}, stored.DevAddr)
+
+	assert.Len(devAddrMembers(lorawan.DevAddr{1, 2, 3, 4}), 0)
+	assert.Equal([][]byte{ds.DevEUI[:]}, devAddrMembers(lorawan.DevAddr{4, 3, 2, 1}))
+
+	ts.T().Run("Previous DevAddr in use by pending rejoin device-session", func(t *testing.T) {
+		assert := require.New(t)
+
+		ds.PendingRejoinDeviceSession = &DeviceSession{
+			DevEUI:  ds.DevEUI,
+			DevAddr: ds.DevAddr,
+		}
+		assert.NoError(SaveDeviceSession(ts.RedisPool(), ds))
+
+		ds, err := SetDeviceSessionDevAddr(ts.RedisPool(), ds, lorawan.DevAddr{5, 6, 7, 8})
+		assert.NoError(err)
+
+		assert.Equal([][]byte{ds.DevEUI[:]}, devAddrMembers(lorawan.DevAddr{4, 3, 2, 1}))
+		assert.Equal([][]byte{ds.DevEUI[:]}, devAddrMembers(lorawan.DevAddr{5, 6, 7, 8}))
+	})
+}
@@ -15,6 +15,7 @@ var (
 	ErrInvalidAggregationInterval     = errors.New("invalid aggregation interval")
 	ErrInvalidName                    = errors.New("invalid gateway name")
 	ErrInvalidFPort                   = errors.New("invalid fPort (must be > 0)")
+	ErrInvalidPriority                = errors.New("invalid priority (must be <= 255)")
 )
 
 func handlePSQLError(err error, description string) error {
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -34,6 +35,27 @@ type GatewayProfile struct {
 	ExtraChannels []ExtraChannel `db:"-"`
 }
 
+// GatewayProfileRevision defines a revision of a gateway-profile. A new
+// revision is created on every gateway-profile create or update.
+type GatewayProfileRevision struct {
+	GatewayProfileID uuid.UUID      `db:"gateway_profile_id"`
+	Revision         int            `db:"revision"`
+	CreatedAt        time.Time      `db:"created_at"`
+	Channels         []int64        `db:"channels"`
+	ExtraChannels    []ExtraChannel `db:"-"`
+}
+
+// GetGatewayProfile returns the gateway-profile as it was at the time of
+// this revision.
+func (r GatewayProfileRevision) GetGatewayProfile() GatewayProfile {
+	return GatewayProfile{
+		ID:            r.GatewayProfileID,
+		UpdatedAt:     r.CreatedAt,
+		Channels:      r.Channels,
+		ExtraChannels: r.ExtraChannels,
+	}
+}
+
 // GetVersion returns the gateway-profile version.
 func (p GatewayProfile) GetVersion() string {
 	return p.UpdatedAt.UTC().Format(time.RFC3339Nano)
@@ -93,6 +115,10 @@ func CreateGatewayProfile(db sqlx.Execer, c *GatewayProfile) error {
 		}
 	}
 
+	if err := createGatewayProfileRevision(db, *c); err != nil {
+		return errors.Wrap(err, "create gateway-profile revision error")
+	}
+
 	log.WithFields(log.Fields{
 		"id": c.ID,
 	}).Info("gateway-profile created")
@@ -223,6 +249,10 @@ func UpdateGatewayProfile(db sqlx.Execer, c *GatewayProfile) error {
 		}
 	}
 
+	if err := createGatewayProfileRevision(db, *c); err != nil {
+		return errors.Wrap(err, "create gateway-profile revision error")
+	}
+
 	log.WithFields(log.Fields{
 		"id": c.ID,
 	}).Info("gateway-profile updated")
@@ -257,3 +287,144 @@ func DeleteGatewayProfile(db sqlx.Execer, id uuid.UUID) error {
 
 	return nil
 }
+
+// createGatewayProfileRevision creates a new revision for the given
+// gateway-profile, using the next available revision number.
+func createGatewayProfileRevision(db sqlx.Execer, c GatewayProfile) error {
+	extraChannels := c.ExtraChannels
+	if extraChannels == nil {
+		extraChannels = []ExtraChannel{}
+	}
+
+	ecJSON, err := json.Marshal(extraChannels)
+	if err != nil {
+		return errors.Wrap(err, "marshal extra channels error")
+	}
+
+	_, err = db.Exec(`
+		insert into gateway_profile_revision (
+			gateway_profile_id,
+			revision,
+			created_at,
+			channels,
+			extra_channels
+		)
+		select
+			$1,
+			coalesce(max(revision), 0) + 1,
+			$2,
+			$3,
+			$4
+		from gateway_profile_revision
+		where
+			gateway_profile_id = $1`,
+		c.ID,
+		c.UpdatedAt,
+		pq.Array(c.Channels),
+		ecJSON,
+	)
+	if err != nil {
+		return handlePSQLError(err, "insert error")
+	}
+
+	return nil
+}
+
+// GetGatewayProfileRevision returns the given revision of the gateway-profile.
+func GetGatewayProfileRevision(db sqlx.Queryer, id uuid.UUID, revision int) (GatewayProfileRevision, error) {
+	return scanGatewayProfileRevision(db.QueryRowx(`
+		select
+			gateway_profile_id,
+			revision,
+			created_at,
+			channels,
+			extra_channels
+		from gateway_profile_revision
+		where
+			gateway_profile_id = $1
+			and revision = $2`,
+		id,
+		revision,
+	))
+}
+
+// GetLatestGatewayProfileRevision returns the latest revision of the
+// gateway-profile.
+func GetLatestGatewayProfileRevision(db sqlx.Queryer, id uuid.UUID) (GatewayProfileRevision, error) {
+	return scanGatewayProfileRevision(db.QueryRowx(`
+		select
+			gateway_profile_id,
+			revision,
+			created_at,
+			channels,
+			extra_channels
+		from gateway_profile_revision
+		where
+			gateway_profile_id = $1
+		order by revision desc
+		limit 1`,
+		id,
+	))
+}
+
+// GetGatewayProfileRevisions returns all the revisions of the gateway-profile,
+// ordered by revision.
+func GetGatewayProfileRevisions(db sqlx.Queryer, id uuid.UUID) ([]GatewayProfileRevision, error) {
+	rows, err := db.Query(`
+		select
+			gateway_profile_id,
+			revision,
+			created_at,
+			channels,
+			extra_channels
+		from gateway_profile_revision
+		where
+			gateway_profile_id = $1
+		order by revision`,
+		id,
+	)
+	if err != nil {
+		return nil, handlePSQLError(err, "select error")
+	}
+	defer rows.Close()
+
+	var out []GatewayProfileRevision
+	for rows.Next() {
+		r, err := scanGatewayProfileRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGatewayProfileRevision(row scanner) (GatewayProfileRevision, error) {
+	var r GatewayProfileRevision
+	var ecJSON []byte
+
+	err := row.Scan(
+		&r.GatewayProfileID,
+		&r.Revision,
+		&r.CreatedAt,
+		pq.Array(&r.Channels),
+		&ecJSON,
+	)
+	if err != nil {
+		return r, handlePSQLError(err, "select error")
+	}
+
+	if err := json.Unmarshal(ecJSON, &r.ExtraChannels); err != nil {
+		return r, errors.Wrap(err, "unmarshal extra channels error")
+	}
+	if len(r.ExtraChannels) == 0 {
+		r.ExtraChannels = nil
+	}
+
+	return r, nil
+}
@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lorawan"
+)
+
+// RolloutState defines the state of a gateway-profile rollout.
+type RolloutState string
+
+// Rollout states.
+const (
+	RolloutStateCanary     RolloutState = "CANARY"
+	RolloutStateCompleted  RolloutState = "COMPLETED"
+	RolloutStateRolledBack RolloutState = "ROLLED_BACK"
+)
+
+// GatewayProfileRollout defines the rollout of a gateway-profile revision.
+// During the canary state, only the canary gateways receive the target
+// revision, all other gateways keep the stable revision.
+type GatewayProfileRollout struct {
+	GatewayProfileID uuid.UUID       `db:"gateway_profile_id"`
+	CreatedAt        time.Time       `db:"created_at"`
+	UpdatedAt        time.Time       `db:"updated_at"`
+	StableRevision   int             `db:"stable_revision"`
+	TargetRevision   int             `db:"target_revision"`
+	CanaryGatewayIDs []lorawan.EUI64 `db:"-"`
+	State            RolloutState    `db:"state"`
+}
+
+// GetRevisionForGateway returns the revision that must be deployed to the
+// given gateway.
+func (r GatewayProfileRollout) GetRevisionForGateway(gatewayID lorawan.EUI64) int {
+	if r.State == RolloutStateCanary {
+		for _, id := range r.CanaryGatewayIDs {
+			if id == gatewayID {
+				return r.TargetRevision
+			}
+		}
+	}
+
+	return r.GetDefaultRevision()
+}
+
+// GetDefaultRevision returns the revision that must be deployed to the
+// non-canary gateways.
+func (r GatewayProfileRollout) GetDefaultRevision() int {
+	if r.State == RolloutStateCompleted {
+		return r.TargetRevision
+	}
+	return r.StableRevision
+}
+
+// GatewayConfigurationPush defines the last gateway-configuration pushed
+// to a gateway. ConfirmedAt is set once the gateway reports the pushed
+// version in its stats.
+type GatewayConfigurationPush struct {
+	GatewayID        lorawan.EUI64 `db:"gateway_id"`
+	GatewayProfileID uuid.UUID     `db:"gateway_profile_id"`
+	Revision         int           `db:"revision"`
+	Version          string        `db:"version"`
+	PushedAt         time.Time     `db:"pushed_at"`
+	ConfirmedAt      *time.Time    `db:"confirmed_at"`
+}
+
+// CreateGatewayProfileRollout creates the given gateway-profile rollout.
+// When a rollout already exists for the gateway-profile, it will be replaced.
+func CreateGatewayProfileRollout(db sqlx.Execer, r *GatewayProfileRollout) error {
+	now := time.Now()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+
+	_, err := db.Exec(`
+		insert into gateway_profile_rollout (
+			gateway_profile_id,
+			created_at,
+			updated_at,
+			stable_revision,
+			target_revision,
+			canary_gateway_ids,
+			state
+		) values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict (gateway_profile_id)
+			do update set
+				created_at = $2,
+				updated_at = $3,
+				stable_revision = $4,
+				target_revision = $5,
+				canary_gateway_ids = $6,
+				state = $7`,
+		r.GatewayProfileID,
+		r.CreatedAt,
+		r.UpdatedAt,
+		r.StableRevision,
+		r.TargetRevision,
+		eui64SliceToByteaArray(r.CanaryGatewayIDs),
+		r.State,
+	)
+	if err != nil {
+		return handlePSQLError(err, "insert error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_profile_id": r.GatewayProfileID,
+		"stable_revision":    r.StableRevision,
+		"target_revision":    r.TargetRevision,
+		"state":              r.State,
+	}).Info("gateway-profile rollout created")
+
+	return nil
+}
+
+// GetGatewayProfileRollout returns the rollout for the given gateway-profile.
+// When forUpdate is set to true, the row will be locked (select ... for update).
+func GetGatewayProfileRollout(db sqlx.Queryer, gatewayProfileID uuid.UUID, forUpdate bool) (GatewayProfileRollout, error) {
+	var fu string
+	if forUpdate {
+		fu = " for update"
+	}
+
+	var r GatewayProfileRollout
+	var canaryIDs pq.ByteaArray
+
+	err := db.QueryRowx(`
+		select
+			gateway_profile_id,
+			created_at,
+			updated_at,
+			stable_revision,
+			target_revision,
+			canary_gateway_ids,
+			state
+		from gateway_profile_rollout
+		where
+			gateway_profile_id = $1`+fu,
+		gatewayProfileID,
+	).Scan(
+		&r.GatewayProfileID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.StableRevision,
+		&r.TargetRevision,
+		&canaryIDs,
+		&r.State,
+	)
+	if err != nil {
+		return r, handlePSQLError(err, "select error")
+	}
+
+	r.CanaryGatewayIDs = byteaArrayToEUI64Slice(canaryIDs)
+
+	return r, nil
+}
+
+// UpdateGatewayProfileRollout updates the given gateway-profile rollout.
+func UpdateGatewayProfileRollout(db sqlx.Execer, r *GatewayProfileRollout) error {
+	r.UpdatedAt = time.Now()
+
+	res, err := db.Exec(`
+		update gateway_profile_rollout
+		set
+			updated_at = $2,
+			stable_revision = $3,
+			target_revision = $4,
+			canary_gateway_ids = $5,
+			state = $6
+		where
+			gateway_profile_id = $1`,
+		r.GatewayProfileID,
+		r.UpdatedAt,
+		r.StableRevision,
+		r.TargetRevision,
+		eui64SliceToByteaArray(r.CanaryGatewayIDs),
+		r.State,
+	)
+	if err != nil {
+		return handlePSQLError(err, "update error")
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get rows affected error")
+	}
+	if ra == 0 {
+		return ErrDoesNotExist
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_profile_id": r.GatewayProfileID,
+		"stable_revision":    r.StableRevision,
+		"target_revision":    r.TargetRevision,
+		"state":              r.State,
+	}).Info("gateway-profile rollout updated")
+
+	return nil
+}
+
+// RollbackFailedGatewayProfileRollouts rolls back the canary rollouts for
+// which at least one gateway did not confirm the target revision within
+// the given timeout after it was pushed. It returns the IDs of the
+// gateway-profiles that were rolled back.
+func RollbackFailedGatewayProfileRollouts(db sqlx.Queryer, timeout time.Duration) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := sqlx.Select(db, &ids, `
+		update gateway_profile_rollout r
+		set
+			state = $1,
+			updated_at = now()
+		where
+			r.state = $2
+			and exists (
+				select 1
+				from gateway_configuration_push p
+				where
+					p.gateway_profile_id = r.gateway_profile_id
+					and p.revision = r.target_revision
+					and p.confirmed_at is null
+					and p.pushed_at < $3
+			)
+		returning r.gateway_profile_id`,
+		RolloutStateRolledBack,
+		RolloutStateCanary,
+		time.Now().Add(-timeout),
+	)
+	if err != nil {
+		return nil, handlePSQLError(err, "update error")
+	}
+
+	for _, id := range ids {
+		log.WithField("gateway_profile_id", id).Warning("gateway-profile rollout rolled back")
+	}
+
+	return ids, nil
+}
+
+// SaveGatewayConfigurationPush saves the given gateway-configuration push.
+// When the version did not change since the previous push, the original
+// pushed_at timestamp is retained so that a gateway which never confirms
+// the configuration will eventually exceed the rollback timeout.
+func SaveGatewayConfigurationPush(db sqlx.Execer, p *GatewayConfigurationPush) error {
+	p.PushedAt = time.Now()
+	p.ConfirmedAt = nil
+
+	_, err := db.Exec(`
+		insert into gateway_configuration_push (
+			gateway_id,
+			gateway_profile_id,
+			revision,
+			version,
+			pushed_at,
+			confirmed_at
+		) values ($1, $2, $3, $4, $5, null)
+		on conflict (gateway_id)
+			do update set
+				gateway_profile_id = $2,
+				revision = $3,
+				version = $4,
+				pushed_at = case
+					when gateway_configuration_push.version = $4 then gateway_configuration_push.pushed_at
+					else $5
+				end,
+				confirmed_at = null`,
+		p.GatewayID[:],
+		p.GatewayProfileID,
+		p.Revision,
+		p.Version,
+		p.PushedAt,
+	)
+	if err != nil {
+		return handlePSQLError(err, "insert error")
+	}
+
+	return nil
+}
+
+// ConfirmGatewayConfigurationPush marks the gateway-configuration push of the
+// given gateway as confirmed, when the given version matches the pushed
+// version and it has not been confirmed yet.
+func ConfirmGatewayConfigurationPush(db sqlx.Execer, gatewayID lorawan.EUI64, version string) error {
+	_, err := db.Exec(`
+		update gateway_configuration_push
+		set
+			confirmed_at = $3
+		where
+			gateway_id = $1
+			and version = $2
+			and confirmed_at is null`,
+		gatewayID[:],
+		version,
+		time.Now(),
+	)
+	if err != nil {
+		return handlePSQLError(err, "update error")
+	}
+
+	return nil
+}
+
+// GetGatewayConfigurationPush returns the last gateway-configuration push
+// for the given gateway.
+func GetGatewayConfigurationPush(db sqlx.Queryer, gatewayID lorawan.EUI64) (GatewayConfigurationPush, error) {
+	var p GatewayConfigurationPush
+	err := sqlx.Get(db, &p, `
+		select
+			gateway_id,
+			gateway_profile_id,
+			revision,
+			version,
+			pushed_at,
+			confirmed_at
+		from gateway_configuration_push
+		where
+			gateway_id = $1`,
+		gatewayID[:],
+	)
+	if err != nil {
+		return p, handlePSQLError(err, "select error")
+	}
+
+	return p, nil
+}
+
+// GetGatewayConfigurationPushesForGatewayProfile returns the
+// gateway-configuration pushes for the given gateway-profile.
+func GetGatewayConfigurationPushesForGatewayProfile(db sqlx.Queryer, gatewayProfileID uuid.UUID) ([]GatewayConfigurationPush, error) {
+	var out []GatewayConfigurationPush
+	err := sqlx.Select(db, &out, `
+		select
+			gateway_id,
+			gateway_profile_id,
+			revision,
+			version,
+			pushed_at,
+			confirmed_at
+		from gateway_configuration_push
+		where
+			gateway_profile_id = $1
+		order by gateway_id`,
+		gatewayProfileID,
+	)
+	if err != nil {
+		return nil, handlePSQLError(err, "select error")
+	}
+
+	return out, nil
+}
+
+func eui64SliceToByteaArray(ids []lorawan.EUI64) pq.ByteaArray {
+	out := make(pq.ByteaArray, 0, len(ids))
+	for i := range ids {
+		out = append(out, ids[i][:])
+	}
+	return out
+}
+
+func byteaArrayToEUI64Slice(b pq.ByteaArray) []lorawan.EUI64 {
+	var out []lorawan.EUI64
+	for _, id := range b {
+		var eui lorawan.EUI64
+		copy(eui[:], id)
+		out = append(out, eui)
+	}
+	return out
+}
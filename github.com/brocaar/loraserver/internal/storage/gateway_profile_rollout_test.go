@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/test"
+	"github.com/brocaar/lorawan"
+)
+
+func (ts *StorageTestSuite) TestGatewayProfileRevision() {
+	assert := require.New(ts.T())
+
+	gp := GatewayProfile{
+		Channels: []int64{0, 1, 2},
+		ExtraChannels: []ExtraChannel{
+			{
+				Modulation:       ModulationLoRa,
+				Frequency:        868700000,
+				Bandwidth:        125,
+				SpreadingFactors: []int64{10, 11, 12},
+			},
+		},
+	}
+	assert.NoError(CreateGatewayProfile(ts.Tx(), &gp))
+
+	ts.T().Run("Create", func(t *testing.T) {
+		assert := require.New(t)
+
+		rev, err := GetLatestGatewayProfileRevision(ts.Tx(), gp.ID)
+		assert.NoError(err)
+		assert.Equal(1, rev.Revision)
+		assert.Equal(gp.Channels, rev.Channels)
+		assert.Equal(gp.ExtraChannels, rev.ExtraChannels)
+
+		gpGet, err := GetGatewayProfile(ts.Tx(), gp.ID)
+		assert.NoError(err)
+		assert.Equal(gpGet.GetVersion(), rev.GetGatewayProfile().GetVersion())
+	})
+
+	ts.T().Run("Update", func(t *testing.T) {
+		assert := require.New(t)
+
+		gp.Channels = []int64{0, 1}
+		gp.ExtraChannels = nil
+		assert.NoError(UpdateGatewayProfile(ts.Tx(), &gp))
+
+		revs, err := GetGatewayProfileRevisions(ts.Tx(), gp.ID)
+		assert.NoError(err)
+		assert.Len(revs, 2)
+		assert.Equal(1, revs[0].Revision)
+		assert.Equal([]int64{0, 1, 2}, revs[0].Channels)
+		assert.Equal(2, revs[1].Revision)
+		assert.Equal([]int64{0, 1}, revs[1].Channels)
+		assert.Nil(revs[1].ExtraChannels)
+
+		rev, err := GetGatewayProfileRevision(ts.Tx(), gp.ID, 1)
+		assert.NoError(err)
+		assert.Equal(revs[0], rev)
+
+		_, err = GetGatewayProfileRevision(ts.Tx(), gp.ID, 3)
+		assert.Equal(ErrDoesNotExist, err)
+	})
+}
+
+func (ts *StorageTestSuite) TestGatewayProfileRollout() {
+	assert := require.New(ts.T())
+
+	gwBackend := test.NewGatewayBackend()
+	config.C.NetworkServer.Gateway.Backend.Backend = gwBackend
+
+	gp := GatewayProfile{
+		Channels: []int64{0, 1, 2},
+	}
+	assert.NoError(CreateGatewayProfile(ts.Tx(), &gp))
+	gp.Channels = []int64{0, 1}
+	assert.NoError(UpdateGatewayProfile(ts.Tx(), &gp))
+
+	rev1, err := GetGatewayProfileRevision(ts.Tx(), gp.ID, 1)
+	assert.NoError(err)
+	rev2, err := GetGatewayProfileRevision(ts.Tx(), gp.ID, 2)
+	assert.NoError(err)
+
+	canary := Gateway{
+		GatewayID:        lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1},
+		GatewayProfileID: &gp.ID,
+	}
+	other := Gateway{
+		GatewayID:        lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2},
+		GatewayProfileID: &gp.ID,
+	}
+	assert.NoError(CreateGateway(ts.Tx(), &canary))
+	assert.NoError(CreateGateway(ts.Tx(), &other))
+
+	rollout := GatewayProfileRollout{
+		GatewayProfileID: gp.ID,
+		StableRevision:   1,
+		TargetRevision:   2,
+		CanaryGatewayIDs: []lorawan.EUI64{canary.GatewayID},
+		State:            RolloutStateCanary,
+	}
+
+	ts.T().Run("Create", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(CreateGatewayProfileRollout(ts.Tx(), &rollout))
+
+		rolloutGet, err := GetGatewayProfileRollout(ts.Tx(), gp.ID, false)
+		assert.NoError(err)
+		assert.Equal(rollout.CanaryGatewayIDs, rolloutGet.CanaryGatewayIDs)
+		assert.Equal(RolloutStateCanary, rolloutGet.State)
+		assert.Equal(2, rolloutGet.GetRevisionForGateway(canary.GatewayID))
+		assert.Equal(1, rolloutGet.GetRevisionForGateway(other.GatewayID))
+	})
+
+	ts.T().Run("Configuration update", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(handleConfigurationUpdate(ts.Tx(), canary, ""))
+		assert.NoError(handleConfigurationUpdate(ts.Tx(), other, ""))
+
+		pl := <-gwBackend.GatewayConfigPacketChan
+		assert.Equal(rev2.GetGatewayProfile().GetVersion(), pl.Version)
+		pl = <-gwBackend.GatewayConfigPacketChan
+		assert.Equal(rev1.GetGatewayProfile().GetVersion(), pl.Version)
+
+		push, err := GetGatewayConfigurationPush(ts.Tx(), canary.GatewayID)
+		assert.NoError(err)
+		assert.Equal(2, push.Revision)
+		assert.Nil(push.ConfirmedAt)
+
+		t.Run("Confirm", func(t *testing.T) {
+			assert := require.New(t)
+
+			assert.NoError(handleConfigurationUpdate(ts.Tx(), other, rev1.GetGatewayProfile().GetVersion()))
+			assert.Len(gwBackend.GatewayConfigPacketChan, 0)
+
+			push, err := GetGatewayConfigurationPush(ts.Tx(), other.GatewayID)
+			assert.NoError(err)
+			assert.NotNil(push.ConfirmedAt)
+		})
+
+		t.Run("Unconfirmed push does not reset the pushed timestamp", func(t *testing.T) {
+			assert := require.New(t)
+
+			assert.NoError(handleConfigurationUpdate(ts.Tx(), canary, ""))
+			<-gwBackend.GatewayConfigPacketChan
+
+			push2, err := GetGatewayConfigurationPush(ts.Tx(), canary.GatewayID)
+			assert.NoError(err)
+			assert.True(push.PushedAt.Equal(push2.PushedAt))
+		})
+	})
+
+	ts.T().Run("Rollback failed rollouts", func(t *testing.T) {
+		assert := require.New(t)
+
+		ids, err := RollbackFailedGatewayProfileRollouts(ts.Tx(), time.Hour)
+		assert.NoError(err)
+		assert.Len(ids, 0)
+
+		time.Sleep(10 * time.Millisecond)
+
+		ids, err = RollbackFailedGatewayProfileRollouts(ts.Tx(), time.Millisecond)
+		assert.NoError(err)
+		assert.Equal(ids[0], gp.ID)
+
+		rolloutGet, err := GetGatewayProfileRollout(ts.Tx(), gp.ID, false)
+		assert.NoError(err)
+		assert.Equal(RolloutStateRolledBack, rolloutGet.State)
+		assert.Equal(1, rolloutGet.GetRevisionForGateway(canary.GatewayID))
+	})
+
+	ts.T().Run("Update", func(t *testing.T) {
+		assert := require.New(t)
+
+		rollout.State = RolloutStateCompleted
+		assert.NoError(UpdateGatewayProfileRollout(ts.Tx(), &rollout))
+
+		rolloutGet, err := GetGatewayProfileRollout(ts.Tx(), gp.ID, false)
+		assert.NoError(err)
+		assert.Equal(RolloutStateCompleted, rolloutGet.State)
+		assert.Equal(2, rolloutGet.GetRevisionForGateway(other.GatewayID))
+
+		pushes, err := GetGatewayConfigurationPushesForGatewayProfile(ts.Tx(), gp.ID)
+		assert.NoError(err)
+		assert.Len(pushes, 2)
+	})
+}
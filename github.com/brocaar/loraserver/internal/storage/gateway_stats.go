@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -276,18 +277,22 @@ func aggregateGatewayStats(db sqlx.Execer, stats Stats) error {
 	return nil
 }
 
-func handleConfigurationUpdate(db sqlx.Queryer, g Gateway, currentVersion string) error {
+func handleConfigurationUpdate(db sqlx.Ext, g Gateway, currentVersion string) error {
 	if g.GatewayProfileID == nil {
 		log.WithField("gateway_id", g.GatewayID).Debug("gateway-profile is not set, skipping configuration update")
 		return nil
 	}
 
-	gwProfile, err := GetGatewayProfile(db, *g.GatewayProfileID)
+	gwProfile, revision, err := getGatewayProfileForGateway(db, g.GatewayID, *g.GatewayProfileID)
 	if err != nil {
 		return errors.Wrap(err, "get gateway-profile error")
 	}
 
 	if gwProfile.GetVersion() == currentVersion {
+		if err := ConfirmGatewayConfigurationPush(db, g.GatewayID, currentVersion); err != nil {
+			return errors.Wrap(err, "confirm gateway-configuration push error")
+		}
+
 		log.WithFields(log.Fields{
 			"gateway_id": g.GatewayID,
 			"version":    currentVersion,
@@ -295,15 +300,75 @@ func handleConfigurationUpdate(db sqlx.Queryer, g Gateway, currentVersion string
 		return nil
 	}
 
+	configPacket, err := getGatewayConfigurationPacket(g.GatewayID, gwProfile)
+	if err != nil {
+		return errors.Wrap(err, "get gateway-configuration packet error")
+	}
+
+	if err := config.C.NetworkServer.Gateway.Backend.Backend.SendGatewayConfigPacket(configPacket); err != nil {
+		return errors.Wrap(err, "send gateway-configuration packet error")
+	}
+
+	if revision == 0 {
+		return nil
+	}
+
+	if err := SaveGatewayConfigurationPush(db, &GatewayConfigurationPush{
+		GatewayID:        g.GatewayID,
+		GatewayProfileID: gwProfile.ID,
+		Revision:         revision,
+		Version:          gwProfile.GetVersion(),
+	}); err != nil {
+		return errors.Wrap(err, "save gateway-configuration push error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": g.GatewayID,
+		"revision":   revision,
+		"version":    gwProfile.GetVersion(),
+	}).Info("gateway-configuration pushed")
+
+	return nil
+}
+
+// getGatewayProfileForGateway returns the gateway-profile (revision) that
+// must be deployed to the given gateway. When a rollout exists for the
+// gateway-profile, the revision is selected by the rollout, else the latest
+// revision is returned. A revision of 0 is returned for gateway-profiles
+// without revisions.
+func getGatewayProfileForGateway(db sqlx.Queryer, gatewayID lorawan.EUI64, gatewayProfileID uuid.UUID) (GatewayProfile, int, error) {
+	var rev GatewayProfileRevision
+
+	rollout, err := GetGatewayProfileRollout(db, gatewayProfileID, false)
+	if err == nil {
+		rev, err = GetGatewayProfileRevision(db, gatewayProfileID, rollout.GetRevisionForGateway(gatewayID))
+	} else if err == ErrDoesNotExist {
+		rev, err = GetLatestGatewayProfileRevision(db, gatewayProfileID)
+	}
+
+	if err == nil {
+		return rev.GetGatewayProfile(), rev.Revision, nil
+	}
+	if err != ErrDoesNotExist {
+		return GatewayProfile{}, 0, errors.Wrap(err, "get gateway-profile revision error")
+	}
+
+	gwProfile, err := GetGatewayProfile(db, gatewayProfileID)
+	return gwProfile, 0, err
+}
+
+// getGatewayConfigurationPacket returns the gateway-configuration packet
+// for the given gateway-profile.
+func getGatewayConfigurationPacket(gatewayID lorawan.EUI64, gwProfile GatewayProfile) (gw.GatewayConfiguration, error) {
 	configPacket := gw.GatewayConfiguration{
-		GatewayId: g.GatewayID[:],
+		GatewayId: gatewayID[:],
 		Version:   gwProfile.GetVersion(),
 	}
 
 	for _, i := range gwProfile.Channels {
 		c, err := config.C.NetworkServer.Band.Band.GetUplinkChannel(int(i))
 		if err != nil {
-			return errors.Wrap(err, "get channel error")
+			return configPacket, errors.Wrap(err, "get channel error")
 		}
 
 		gwC := gw.ChannelConfiguration{
@@ -316,7 +381,7 @@ func handleConfigurationUpdate(db sqlx.Queryer, g Gateway, currentVersion string
 		for drI := c.MaxDR; drI >= c.MinDR; drI-- {
 			dr, err := config.C.NetworkServer.Band.Band.GetDataRate(drI)
 			if err != nil {
-				return errors.Wrap(err, "get data-rate error")
+				return configPacket, errors.Wrap(err, "get data-rate error")
 			}
 
 			modConfig.SpreadingFactors = append(modConfig.SpreadingFactors, uint32(dr.SpreadFactor))
@@ -364,9 +429,5 @@ func handleConfigurationUpdate(db sqlx.Queryer, g Gateway, currentVersion string
 		configPacket.Channels = append(configPacket.Channels, &gwC)
 	}
 
-	if err := config.C.NetworkServer.Gateway.Backend.Backend.SendGatewayConfigPacket(configPacket); err != nil {
-		return errors.Wrap(err, "send gateway-configuration packet error")
-	}
-
-	return nil
+	return configPacket, nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -17,8 +18,33 @@ import (
 const (
 	macCommandQueueTempl   = "lora:ns:device:%s:mac:queue"
 	macCommandPendingTempl = "lora:ns:device:%s:mac:pending:%d"
+
+	macCommandPendingScanTempl = "lora:ns:device:%s:mac:pending:*"
 )
 
+// relayMACPayloadSizes contains the payload sizes of the relay mac-commands,
+// by direction (uplink = true). These are not known by the lorawan package
+// and are decoded as raw payloads.
+var relayMACPayloadSizes = map[bool]map[lorawan.CID]int{
+	false: {
+		0x40: 5,  // RelayConfReq
+		0x41: 2,  // EndDeviceConfReq
+		0x42: 5,  // FilterListReq
+		0x43: 26, // UpdateUplinkListReq
+		0x44: 1,  // CtrlUplinkListReq
+		0x45: 5,  // ConfigureFwdLimitReq
+	},
+	true: {
+		0x40: 1, // RelayConfAns
+		0x41: 1, // EndDeviceConfAns
+		0x42: 1, // FilterListAns
+		0x43: 0, // UpdateUplinkListAns
+		0x44: 5, // CtrlUplinkListAns
+		0x45: 0, // ConfigureFwdLimitAns
+		0x46: 6, // NotifyNewEndDeviceReq
+	},
+}
+
 // MACCommandBlock defines a block of MAC commands that must be handled
 // together.
 type MACCommandBlock struct {
@@ -58,27 +84,58 @@ func (m MACCommands) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
 func (m *MACCommands) UnmarshalBinary(data []byte) error {
+	cmds, err := DecodeMACCommands(false, data)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, cmds...)
+	return nil
+}
+
+// DecodeMACCommands decodes the given (plaintext) mac-command bytes. Unlike
+// the lorawan package, it knows the payload sizes of the relay mac-commands.
+func DecodeMACCommands(uplink bool, data []byte) (MACCommands, error) {
+	var out MACCommands
 	var pLen int
 	for i := 0; i < len(data); i++ {
-		if _, s, err := lorawan.GetMACPayloadAndSize(false, lorawan.CID(data[i])); err != nil {
+		if _, s, err := lorawan.GetMACPayloadAndSize(uplink, lorawan.CID(data[i])); err != nil {
 			pLen = 0
 		} else {
 			pLen = s
 		}
 
+		relayPLen, isRelay := relayMACPayloadSizes[uplink][lorawan.CID(data[i])]
+		if isRelay {
+			pLen = relayPLen
+		}
+
 		// check if the remaining bytes are >= CID byte + payload size
 		if len(data[i:]) < pLen+1 {
-			return errors.New("not enough remaining bytes")
+			return nil, errors.New("not enough remaining bytes")
+		}
+
+		if isRelay {
+			mc := lorawan.MACCommand{
+				CID: lorawan.CID(data[i]),
+			}
+			if pLen > 0 {
+				mc.Payload = &lorawan.ProprietaryMACCommandPayload{
+					Bytes: append([]byte{}, data[i+1:i+1+pLen]...),
+				}
+			}
+			out = append(out, mc)
+			i += pLen
+			continue
 		}
 
 		var mc lorawan.MACCommand
-		if err := mc.UnmarshalBinary(false, data[i:i+1+pLen]); err != nil {
-			return err
+		if err := mc.UnmarshalBinary(uplink, data[i:i+1+pLen]); err != nil {
+			return nil, err
 		}
-		*m = append(*m, mc)
+		out = append(out, mc)
 		i += pLen
 	}
-	return nil
+	return out, nil
 }
 
 // FlushMACCommandQueue flushes the mac-command queue for the given DevEUI.
@@ -261,3 +318,30 @@ func DeletePendingMACCommand(p *redis.Pool, devEUI lorawan.EUI64, cid lorawan.CI
 
 	return nil
 }
+
+// GetPendingMACCommandCIDs returns the CIDs of the pending mac-commands for
+// the given DevEUI.
+func GetPendingMACCommandCIDs(p *redis.Pool, devEUI lorawan.EUI64) ([]lorawan.CID, error) {
+	keys, err := scanKeys(p, fmt.Sprintf(macCommandPendingScanTempl, devEUI))
+	if err != nil {
+		return nil, errors.Wrap(err, "get pending mac-command keys error")
+	}
+
+	var out []lorawan.CID
+	for _, key := range keys {
+		var cid int
+		if _, err := fmt.Sscanf(key[strings.LastIndex(key, ":")+1:], "%d", &cid); err != nil {
+			log.WithField("key", key).WithError(err).Warning("decode CID from key error")
+			continue
+		}
+		out = append(out, lorawan.CID(cid))
+	}
+
+	return out, nil
+}
+
+// GetDevEUIsForPendingMACCommands returns the DevEUIs of the devices having
+// one or multiple pending mac-commands.
+func GetDevEUIsForPendingMACCommands(p *redis.Pool) ([]lorawan.EUI64, error) {
+	return scanDevEUIs(p, fmt.Sprintf(macCommandPendingScanTempl, "*"), 7)
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/brocaar/loraserver/internal/common"
 	"github.com/brocaar/loraserver/internal/test"
 	"github.com/brocaar/lorawan"
@@ -91,3 +93,83 @@ func TestMACCommand(t *testing.T) {
 		})
 	})
 }
+
+func (ts *StorageTestSuite) TestGetPendingMACCommandCIDs() {
+	assert := require.New(ts.T())
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for _, cid := range []lorawan.CID{lorawan.LinkADRReq, lorawan.DevStatusReq} {
+		assert.NoError(SetPendingMACCommand(ts.RedisPool(), devEUI, MACCommandBlock{
+			CID: cid,
+			MACCommands: []lorawan.MACCommand{
+				{CID: cid},
+			},
+		}))
+	}
+
+	cids, err := GetPendingMACCommandCIDs(ts.RedisPool(), devEUI)
+	assert.NoError(err)
+	assert.ElementsMatch([]lorawan.CID{lorawan.LinkADRReq, lorawan.DevStatusReq}, cids)
+
+	devEUIs, err := GetDevEUIsForPendingMACCommands(ts.RedisPool())
+	assert.NoError(err)
+	assert.Equal([]lorawan.EUI64{devEUI}, devEUIs)
+}
+
+func TestDecodeMACCommands(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Uplink        bool
+		Bytes         []byte
+		Expected      MACCommands
+		ExpectedError string
+	}{
+		{
+			Name:   "downlink relay mac-commands",
+			Uplink: false,
+			Bytes:  []byte{0x44, 0x13, 0x45, 1, 2, 3, 4, 5, 0x06},
+			Expected: MACCommands{
+				{CID: 0x44, Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{0x13}}},
+				{CID: 0x45, Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{1, 2, 3, 4, 5}}},
+				{CID: lorawan.DevStatusReq},
+			},
+		},
+		{
+			Name:   "uplink relay mac-commands",
+			Uplink: true,
+			Bytes:  []byte{0x40, 0x1f, 0x43, 0x44, 1, 10, 0, 0, 0, 0x46, 4, 3, 2, 1, 0xb9, 0x0a, 0x06, 0xff, 0x05},
+			Expected: MACCommands{
+				{CID: 0x40, Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{0x1f}}},
+				{CID: 0x43},
+				{CID: 0x44, Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{1, 10, 0, 0, 0}}},
+				{CID: 0x46, Payload: &lorawan.ProprietaryMACCommandPayload{Bytes: []byte{4, 3, 2, 1, 0xb9, 0x0a}}},
+				{CID: lorawan.DevStatusAns, Payload: &lorawan.DevStatusAnsPayload{Battery: 255, Margin: 5}},
+			},
+		},
+		{
+			Name:          "truncated relay mac-command",
+			Uplink:        true,
+			Bytes:         []byte{0x44, 1, 10},
+			ExpectedError: "not enough remaining bytes",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			cmds, err := DecodeMACCommands(tst.Uplink, tst.Bytes)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Expected, cmds)
+
+			b, err := cmds.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+const deviceRelayKeyTempl = "lora:ns:device:%s:relay" // contains the relay forwarding the uplinks of a DevEUI
+
+// DeviceRelay contains the relay meta-data of a device of which the uplinks
+// are forwarded by a relay.
+type DeviceRelay struct {
+	DevEUI      lorawan.EUI64
+	RelayDevEUI lorawan.EUI64
+
+	// Meta-data of the last uplink as received by the relay.
+	WORChannel uint8
+	DR         int
+	Frequency  int
+	RSSI       int
+	SNR        int
+
+	// UpdatedAt contains the timestamp of the last forwarded uplink.
+	UpdatedAt time.Time
+}
+
+// SaveDeviceRelay saves the given DeviceRelay.
+func SaveDeviceRelay(p *redis.Pool, dr DeviceRelay) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dr); err != nil {
+		return errors.Wrap(err, "gob encode error")
+	}
+
+	c := p.Get()
+	defer c.Close()
+
+	exp := int64(config.C.NetworkServer.DeviceSessionTTL / time.Millisecond)
+	_, err := c.Do("PSETEX", fmt.Sprintf(deviceRelayKeyTempl, dr.DevEUI), exp, buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "psetex error")
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui":       dr.DevEUI,
+		"relay_dev_eui": dr.RelayDevEUI,
+	}).Info("device relay saved")
+
+	return nil
+}
+
+// GetDeviceRelay returns the DeviceRelay for the given DevEUI.
+// When the uplinks of the device are not forwarded by a relay,
+// ErrDoesNotExist is returned.
+func GetDeviceRelay(p *redis.Pool, devEUI lorawan.EUI64) (DeviceRelay, error) {
+	var dr DeviceRelay
+
+	c := p.Get()
+	defer c.Close()
+
+	val, err := redis.Bytes(c.Do("GET", fmt.Sprintf(deviceRelayKeyTempl, devEUI)))
+	if err != nil {
+		if err == redis.ErrNil {
+			return dr, ErrDoesNotExist
+		}
+		return dr, errors.Wrap(err, "get error")
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(val)).Decode(&dr); err != nil {
+		return dr, errors.Wrap(err, "gob decode error")
+	}
+
+	return dr, nil
+}
+
+// DeleteDeviceRelay deletes the DeviceRelay for the given DevEUI.
+func DeleteDeviceRelay(p *redis.Pool, devEUI lorawan.EUI64) error {
+	c := p.Get()
+	defer c.Close()
+
+	val, err := redis.Int(c.Do("DEL", fmt.Sprintf(deviceRelayKeyTempl, devEUI)))
+	if err != nil {
+		return errors.Wrap(err, "delete error")
+	}
+	if val == 0 {
+		return ErrDoesNotExist
+	}
+
+	log.WithFields(log.Fields{
+		"dev_eui": devEUI,
+	}).Info("device relay deleted")
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func (ts *StorageTestSuite) TestDeviceRelay() {
+	dr := DeviceRelay{
+		DevEUI:      lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		RelayDevEUI: lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		WORChannel:  1,
+		DR:          3,
+		Frequency:   868100000,
+		RSSI:        -110,
+		SNR:         5,
+		UpdatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	ts.T().Run("Does not exist", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := GetDeviceRelay(ts.RedisPool(), dr.DevEUI)
+		assert.Equal(ErrDoesNotExist, err)
+	})
+
+	ts.T().Run("Save", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(SaveDeviceRelay(ts.RedisPool(), dr))
+
+		t.Run("Get", func(t *testing.T) {
+			assert := require.New(t)
+
+			drGet, err := GetDeviceRelay(ts.RedisPool(), dr.DevEUI)
+			assert.NoError(err)
+			assert.Equal(dr, drGet)
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			assert := require.New(t)
+
+			assert.NoError(DeleteDeviceRelay(ts.RedisPool(), dr.DevEUI))
+			assert.Equal(ErrDoesNotExist, DeleteDeviceRelay(ts.RedisPool(), dr.DevEUI))
+		})
+	})
+}
@@ -249,30 +249,33 @@ type ApplicationClient struct {
 	SetDeviceStatusError    error
 	SetDeviceLocationErrror error
 
-	HandleDataUpChan        chan as.HandleUplinkDataRequest
-	HandleProprietaryUpChan chan as.HandleProprietaryUplinkRequest
-	HandleErrorChan         chan as.HandleErrorRequest
-	HandleDownlinkACKChan   chan as.HandleDownlinkACKRequest
-	SetDeviceStatusChan     chan as.SetDeviceStatusRequest
-	SetDeviceLocationChan   chan as.SetDeviceLocationRequest
-
-	HandleDataUpResponse        empty.Empty
-	HandleProprietaryUpResponse empty.Empty
-	HandleErrorResponse         empty.Empty
-	HandleDownlinkACKResponse   empty.Empty
-	SetDeviceStatusResponse     empty.Empty
-	SetDeviceLocationResponse   empty.Empty
+	HandleDataUpChan              chan as.HandleUplinkDataRequest
+	HandleProprietaryUpChan       chan as.HandleProprietaryUplinkRequest
+	HandleErrorChan               chan as.HandleErrorRequest
+	HandleDownlinkACKChan         chan as.HandleDownlinkACKRequest
+	SetDeviceStatusChan           chan as.SetDeviceStatusRequest
+	SetDeviceLocationChan         chan as.SetDeviceLocationRequest
+	ReEncryptDeviceQueueItemsChan chan as.ReEncryptDeviceQueueItemsRequest
+
+	HandleDataUpResponse              empty.Empty
+	HandleProprietaryUpResponse       empty.Empty
+	HandleErrorResponse               empty.Empty
+	HandleDownlinkACKResponse         empty.Empty
+	SetDeviceStatusResponse           empty.Empty
+	SetDeviceLocationResponse         empty.Empty
+	ReEncryptDeviceQueueItemsResponse as.ReEncryptDeviceQueueItemsResponse
 }
 
 // NewApplicationClient returns a new ApplicationClient.
 func NewApplicationClient() *ApplicationClient {
 	return &ApplicationClient{
-		HandleDataUpChan:        make(chan as.HandleUplinkDataRequest, 100),
-		HandleProprietaryUpChan: make(chan as.HandleProprietaryUplinkRequest, 100),
-		HandleErrorChan:         make(chan as.HandleErrorRequest, 100),
-		HandleDownlinkACKChan:   make(chan as.HandleDownlinkACKRequest, 100),
-		SetDeviceStatusChan:     make(chan as.SetDeviceStatusRequest, 100),
-		SetDeviceLocationChan:   make(chan as.SetDeviceLocationRequest, 100),
+		HandleDataUpChan:              make(chan as.HandleUplinkDataRequest, 100),
+		HandleProprietaryUpChan:       make(chan as.HandleProprietaryUplinkRequest, 100),
+		HandleErrorChan:               make(chan as.HandleErrorRequest, 100),
+		HandleDownlinkACKChan:         make(chan as.HandleDownlinkACKRequest, 100),
+		SetDeviceStatusChan:           make(chan as.SetDeviceStatusRequest, 100),
+		SetDeviceLocationChan:         make(chan as.SetDeviceLocationRequest, 100),
+		ReEncryptDeviceQueueItemsChan: make(chan as.ReEncryptDeviceQueueItemsRequest, 100),
 	}
 }
 
@@ -318,6 +321,12 @@ func (t *ApplicationClient) SetDeviceLocation(ctx context.Context, in *as.SetDev
 	return &t.SetDeviceLocationResponse, t.SetDeviceLocationErrror
 }
 
+// ReEncryptDeviceQueueItems method.
+func (t *ApplicationClient) ReEncryptDeviceQueueItems(ctx context.Context, in *as.ReEncryptDeviceQueueItemsRequest, opts ...grpc.CallOption) (*as.ReEncryptDeviceQueueItemsResponse, error) {
+	t.ReEncryptDeviceQueueItemsChan <- *in
+	return &t.ReEncryptDeviceQueueItemsResponse, nil
+}
+
 // NetworkControllerClient is a network-controller client for testing.
 type NetworkControllerClient struct {
 	HandleRXInfoChan           chan nc.HandleUplinkMetaDataRequest
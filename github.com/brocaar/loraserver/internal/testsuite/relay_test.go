@@ -0,0 +1,194 @@
+package testsuite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/loraserver/internal/config"
+	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/models"
+	"github.com/brocaar/loraserver/internal/storage"
+	"github.com/brocaar/loraserver/internal/uplink"
+	"github.com/brocaar/lorawan"
+)
+
+type RelayTestSuite struct {
+	IntegrationTestSuite
+
+	RelayDeviceSession storage.DeviceSession
+	RXInfo             gw.UplinkRXInfo
+	TXInfo             gw.UplinkTXInfo
+}
+
+func (ts *RelayTestSuite) SetupTest() {
+	ts.IntegrationTestSuite.SetupTest()
+	assert := require.New(ts.T())
+
+	ts.CreateGateway(storage.Gateway{
+		GatewayID: lorawan.EUI64{1, 2, 1, 2, 1, 2, 1, 2},
+	})
+
+	ts.CreateDevice(storage.Device{DevEUI: lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}})
+	ts.CreateDeviceSession(storage.DeviceSession{
+		MACVersion:            "1.0.2",
+		DevAddr:               lorawan.DevAddr{4, 3, 2, 1},
+		FNwkSIntKey:           lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		SNwkSIntKey:           lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		NwkSEncKey:            lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		FCntUp:                10,
+		EnabledUplinkChannels: []int{0, 1, 2},
+		RX2Frequency:          869525000,
+	})
+	ts.RelayDeviceSession = *ts.DeviceSession
+
+	ts.CreateDevice(storage.Device{DevEUI: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}})
+	ts.CreateDeviceSession(storage.DeviceSession{
+		MACVersion:            "1.0.2",
+		DevAddr:               lorawan.DevAddr{1, 2, 3, 4},
+		FNwkSIntKey:           lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SNwkSIntKey:           lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		NwkSEncKey:            lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		FCntUp:                8,
+		EnabledUplinkChannels: []int{0, 1, 2},
+		RX2Frequency:          869525000,
+	})
+
+	ts.RXInfo = gw.UplinkRXInfo{
+		GatewayId: ts.Gateway.GatewayID[:],
+		LoraSnr:   7,
+		Rssi:      -60,
+	}
+
+	ts.TXInfo = gw.UplinkTXInfo{
+		Frequency: 868100000,
+	}
+	assert.NoError(helpers.SetUplinkTXInfoDataRate(&ts.TXInfo, 0, config.C.NetworkServer.Band.Band))
+}
+
+func (ts *RelayTestSuite) TestForwardedUplink() {
+	assert := require.New(ts.T())
+
+	fPort := uint8(1)
+	devicePHY := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.ConfirmedDataUp,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.MACPayload{
+			FHDR: lorawan.FHDR{
+				DevAddr: ts.DeviceSession.DevAddr,
+				FCnt:    ts.DeviceSession.FCntUp,
+			},
+			FPort: &fPort,
+			FRMPayload: []lorawan.Payload{
+				&lorawan.DataPayload{Bytes: []byte{1, 2, 3, 4}},
+			},
+		},
+	}
+	assert.NoError(devicePHY.SetUplinkDataMIC(lorawan.LoRaWAN1_0, 0, 0, 0, ts.DeviceSession.FNwkSIntKey, ts.DeviceSession.SNwkSIntKey))
+
+	fwdB, err := models.ForwardUplinkReq{
+		RSSI:       -80,
+		SNR:        5,
+		DR:         0,
+		Frequency:  868100000,
+		PHYPayload: devicePHY,
+	}.MarshalBinary()
+	assert.NoError(err)
+
+	relayFPort := uint8(models.RelayFPort)
+	relayPHY := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.UnconfirmedDataUp,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.MACPayload{
+			FHDR: lorawan.FHDR{
+				DevAddr: ts.RelayDeviceSession.DevAddr,
+				FCnt:    ts.RelayDeviceSession.FCntUp,
+			},
+			FPort: &relayFPort,
+			FRMPayload: []lorawan.Payload{
+				&lorawan.DataPayload{Bytes: fwdB},
+			},
+		},
+	}
+	assert.NoError(relayPHY.EncryptFRMPayload(ts.RelayDeviceSession.NwkSEncKey))
+	assert.NoError(relayPHY.SetUplinkDataMIC(lorawan.LoRaWAN1_0, 0, 0, 0, ts.RelayDeviceSession.FNwkSIntKey, ts.RelayDeviceSession.SNwkSIntKey))
+
+	phyB, err := relayPHY.MarshalBinary()
+	assert.NoError(err)
+
+	assert.NoError(uplink.HandleRXPacket(gw.UplinkFrame{
+		RxInfo:     &ts.RXInfo,
+		TxInfo:     &ts.TXInfo,
+		PhyPayload: phyB,
+	}))
+
+	ts.T().Run("uplink of the device is sent to the application-server", func(t *testing.T) {
+		assert := require.New(t)
+
+		req := <-ts.ASClient.HandleDataUpChan
+		assert.Equal(ts.DeviceSession.DevEUI[:], req.DevEui)
+		assert.Equal(ts.DeviceSession.FCntUp, req.FCnt)
+		assert.Equal([]byte{1, 2, 3, 4}, req.Data)
+		assert.Len(req.RxInfo, 0)
+	})
+
+	ts.T().Run("device relay is stored", func(t *testing.T) {
+		assert := require.New(t)
+
+		dr, err := storage.GetDeviceRelay(ts.RedisPool(), ts.DeviceSession.DevEUI)
+		assert.NoError(err)
+		assert.Equal(ts.RelayDeviceSession.DevEUI, dr.RelayDevEUI)
+		assert.Equal(-80, dr.RSSI)
+		assert.Equal(5, dr.SNR)
+		assert.Equal(868100000, dr.Frequency)
+	})
+
+	ts.T().Run("frame-counters are incremented", func(t *testing.T) {
+		assert := require.New(t)
+
+		ds, err := storage.GetDeviceSession(ts.RedisPool(), ts.DeviceSession.DevEUI)
+		assert.NoError(err)
+		assert.Equal(ts.DeviceSession.FCntUp+1, ds.FCntUp)
+		assert.Equal(ts.DeviceSession.NFCntDown+1, ds.NFCntDown)
+
+		relayDS, err := storage.GetDeviceSession(ts.RedisPool(), ts.RelayDeviceSession.DevEUI)
+		assert.NoError(err)
+		assert.Equal(ts.RelayDeviceSession.FCntUp+1, relayDS.FCntUp)
+	})
+
+	ts.T().Run("acknowledgement of the device is forwarded by the relay", func(t *testing.T) {
+		assert := require.New(t)
+
+		downlinkFrame := <-ts.GWBackend.TXPacketChan
+		var downPHY lorawan.PHYPayload
+		assert.NoError(downPHY.UnmarshalBinary(downlinkFrame.PhyPayload))
+		assert.NoError(downPHY.DecryptFRMPayload(ts.RelayDeviceSession.NwkSEncKey))
+
+		macPL, ok := downPHY.MACPayload.(*lorawan.MACPayload)
+		assert.True(ok)
+		assert.Equal(ts.RelayDeviceSession.DevAddr, macPL.FHDR.DevAddr)
+		assert.NotNil(macPL.FPort)
+		assert.EqualValues(models.RelayFPort, *macPL.FPort)
+		assert.Len(macPL.FRMPayload, 1)
+
+		dataPL, ok := macPL.FRMPayload[0].(*lorawan.DataPayload)
+		assert.True(ok)
+
+		var fwdPHY lorawan.PHYPayload
+		assert.NoError(fwdPHY.UnmarshalBinary(dataPL.Bytes))
+		fwdMACPL, ok := fwdPHY.MACPayload.(*lorawan.MACPayload)
+		assert.True(ok)
+		assert.Equal(ts.DeviceSession.DevAddr, fwdMACPL.FHDR.DevAddr)
+		assert.True(fwdMACPL.FHDR.FCtrl.ACK)
+	})
+}
+
+func TestRelay(t *testing.T) {
+	suite.Run(t, new(RelayTestSuite))
+}
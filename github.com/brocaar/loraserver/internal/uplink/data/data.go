@@ -18,6 +18,7 @@ import (
 	"github.com/brocaar/loraserver/internal/downlink/data/classb"
 	"github.com/brocaar/loraserver/internal/framelog"
 	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/integration"
 	"github.com/brocaar/loraserver/internal/maccommand"
 	"github.com/brocaar/loraserver/internal/models"
 	"github.com/brocaar/loraserver/internal/storage"
@@ -26,30 +27,38 @@ import (
 
 const applicationClientTimeout = time.Second
 
-var tasks = []func(*dataContext) error{
-	setContextFromDataPHYPayload,
-	getDeviceSessionForPHYPayload,
-	decryptFOptsMACCommands,
-	decryptFRMPayloadMACCommands,
-	logUplinkFrame,
-	getDeviceProfile,
-	getServiceProfile,
-	getApplicationServerClientForDataUp,
-	resolveDeviceLocation,
-	setADR,
-	setUplinkDataRate,
-	setBeaconLocked,
-	sendRXInfoToNetworkController,
-	handleFOptsMACCommands,
-	handleFRMPayloadMACCommands,
-	storeDeviceGatewayRXInfoSet,
-	appendMetaDataToUplinkHistory,
-	sendFRMPayloadToApplicationServer,
-	setLastRXInfoSet,
-	syncUplinkFCnt,
-	saveDeviceSession,
-	handleUplinkACK,
-	handleDownlink,
+var tasks []func(*dataContext) error
+
+func init() {
+	// tasks is set by init as handleForwardUplinkReq refers to tasks (by
+	// handling the forwarded uplink through handle), which would otherwise
+	// be an initialization cycle.
+	tasks = []func(*dataContext) error{
+		setContextFromDataPHYPayload,
+		getDeviceSessionForPHYPayload,
+		decryptFOptsMACCommands,
+		decryptFRMPayloadMACCommands,
+		logUplinkFrame,
+		getDeviceProfile,
+		getServiceProfile,
+		getApplicationServerClientForDataUp,
+		resolveDeviceLocation,
+		setADR,
+		setUplinkDataRate,
+		setBeaconLocked,
+		sendRXInfoToNetworkController,
+		handleFOptsMACCommands,
+		handleFRMPayloadMACCommands,
+		storeDeviceGatewayRXInfoSet,
+		appendMetaDataToUplinkHistory,
+		sendFRMPayloadToApplicationServer,
+		setLastRXInfoSet,
+		syncUplinkFCnt,
+		saveDeviceSession,
+		handleUplinkACK,
+		handleForwardUplinkReq,
+		handleDownlink,
+	}
 }
 
 type dataContext struct {
@@ -60,21 +69,37 @@ type dataContext struct {
 	ServiceProfile          storage.ServiceProfile
 	ApplicationServerClient as.ApplicationServerServiceClient
 	MACCommandResponses     []storage.MACCommandBlock
+
+	// Relay holds the context of the relay, in case the uplink was forwarded
+	// by a relay.
+	Relay *dataContext
+
+	// ForwardPHYPayload holds the downlink PHYPayload which must be forwarded
+	// by the relay. In case of a relay, this is the downlink of the device
+	// of which the uplink was forwarded. In case of a relayed device, this
+	// is its own downlink.
+	ForwardPHYPayload []byte
 }
 
 // Handle handles an uplink data frame
 func Handle(rxPacket models.RXPacket) error {
+	_, err := handle(rxPacket, nil)
+	return err
+}
+
+func handle(rxPacket models.RXPacket, relay *dataContext) (*dataContext, error) {
 	ctx := dataContext{
 		RXPacket: rxPacket,
+		Relay:    relay,
 	}
 
 	for _, t := range tasks {
 		if err := t(&ctx); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return &ctx, nil
 }
 
 func setContextFromDataPHYPayload(ctx *dataContext) error {
@@ -314,29 +339,74 @@ func resolveDeviceLocation(ctx *dataContext) error {
 }
 
 func decryptFOptsMACCommands(ctx *dataContext) error {
-	if ctx.DeviceSession.GetMACVersion() == lorawan.LoRaWAN1_0 {
-		if err := ctx.RXPacket.PHYPayload.DecodeFOptsToMACCommands(); err != nil {
-			return errors.Wrap(err, "decode fOpts to mac-commands error")
-		}
-	} else {
-		if err := ctx.RXPacket.PHYPayload.DecryptFOpts(ctx.DeviceSession.NwkSEncKey); err != nil {
+	if ctx.DeviceSession.GetMACVersion() != lorawan.LoRaWAN1_0 {
+		// encrypting and decrypting are the same operation
+		if err := ctx.RXPacket.PHYPayload.EncryptFOpts(ctx.DeviceSession.NwkSEncKey); err != nil {
 			return errors.Wrap(err, "decrypt fOpts mac-commands error")
 		}
 	}
+
+	if len(ctx.MACPayload.FHDR.FOpts) == 0 {
+		return nil
+	}
+
+	cmds, err := decodeMACCommands(ctx.MACPayload.FHDR.FOpts)
+	if err != nil {
+		return errors.Wrap(err, "decode fOpts to mac-commands error")
+	}
+	ctx.MACPayload.FHDR.FOpts = cmds
+
 	return nil
 }
 
 func decryptFRMPayloadMACCommands(ctx *dataContext) error {
-	// only decrypt when FPort is equal to 0
-	if ctx.MACPayload.FPort != nil && *ctx.MACPayload.FPort == 0 {
-		if err := ctx.RXPacket.PHYPayload.DecryptFRMPayload(ctx.DeviceSession.NwkSEncKey); err != nil {
-			return errors.Wrap(err, "decrypt FRMPayload error")
-		}
+	// only decrypt when FPort is equal to 0 or in case of a forwarded uplink
+	if ctx.MACPayload.FPort == nil || (*ctx.MACPayload.FPort != 0 && *ctx.MACPayload.FPort != models.RelayFPort) {
+		return nil
 	}
 
+	// encrypting and decrypting are the same operation
+	if err := ctx.RXPacket.PHYPayload.EncryptFRMPayload(ctx.DeviceSession.NwkSEncKey); err != nil {
+		return errors.Wrap(err, "decrypt FRMPayload error")
+	}
+
+	if *ctx.MACPayload.FPort != 0 || len(ctx.MACPayload.FRMPayload) == 0 {
+		return nil
+	}
+
+	cmds, err := decodeMACCommands(ctx.MACPayload.FRMPayload)
+	if err != nil {
+		return errors.Wrap(err, "decode FRMPayload to mac-commands error")
+	}
+	ctx.MACPayload.FRMPayload = cmds
+
 	return nil
 }
 
+// decodeMACCommands decodes the given (decrypted) FOpts or FRMPayload into
+// mac-commands. The lorawan package does not know the sizes of the relay
+// mac-commands, therefore the decoding is done by the storage package.
+func decodeMACCommands(payloads []lorawan.Payload) ([]lorawan.Payload, error) {
+	if len(payloads) != 1 {
+		return nil, errors.New("exactly one payload expected")
+	}
+	dataPL, ok := payloads[0].(*lorawan.DataPayload)
+	if !ok {
+		return nil, fmt.Errorf("expected *lorawan.DataPayload, got %T", payloads[0])
+	}
+
+	cmds, err := storage.DecodeMACCommands(true, dataPL.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]lorawan.Payload, len(cmds))
+	for i := range cmds {
+		out[i] = &cmds[i]
+	}
+	return out, nil
+}
+
 func setBeaconLocked(ctx *dataContext) error {
 	// set the Class-B beacon locked
 	if ctx.DeviceSession.BeaconLocked == ctx.MACPayload.FHDR.FCtrl.ClassB {
@@ -418,7 +488,7 @@ func handleFRMPayloadMACCommands(ctx *dataContext) error {
 }
 
 func sendFRMPayloadToApplicationServer(ctx *dataContext) error {
-	if ctx.MACPayload.FPort == nil || (ctx.MACPayload.FPort != nil && *ctx.MACPayload.FPort == 0) {
+	if ctx.MACPayload.FPort == nil || (ctx.MACPayload.FPort != nil && (*ctx.MACPayload.FPort == 0 || *ctx.MACPayload.FPort == models.RelayFPort)) {
 		return nil
 	}
 
@@ -465,6 +535,10 @@ func sendFRMPayloadToApplicationServer(ctx *dataContext) error {
 
 	}
 
+	if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventUplink, ctx.DeviceSession.DevEUI, &publishDataUpReq); err != nil {
+		log.WithError(err).Error("publish uplink event error")
+	}
+
 	go func(asClient as.ApplicationServerServiceClient, publishDataUpReq as.HandleUplinkDataRequest) {
 		ctx := context.Background()
 		ctxTimeout, cancel := context.WithTimeout(ctx, applicationClientTimeout)
@@ -524,11 +598,17 @@ func handleUplinkACK(ctx *dataContext) error {
 		return errors.Wrap(err, "delete device-queue item error")
 	}
 
-	_, err = ctx.ApplicationServerClient.HandleDownlinkACK(context.Background(), &as.HandleDownlinkACKRequest{
+	ackReq := as.HandleDownlinkACKRequest{
 		DevEui:       ctx.DeviceSession.DevEUI[:],
 		FCnt:         qi.FCnt,
 		Acknowledged: true,
-	})
+	}
+
+	if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventACK, ctx.DeviceSession.DevEUI, &ackReq); err != nil {
+		log.WithError(err).Error("publish ack event error")
+	}
+
+	_, err = ctx.ApplicationServerClient.HandleDownlinkACK(context.Background(), &ackReq)
 	if err != nil {
 		return errors.Wrap(err, "application-server client error")
 	}
@@ -536,9 +616,120 @@ func handleUplinkACK(ctx *dataContext) error {
 	return nil
 }
 
+// handleForwardUplinkReq handles the uplink of a device, forwarded by a relay.
+// The uplink of the device is handled as if it was received by the gateways
+// that received the uplink of the relay, using the RSSI and SNR as measured
+// by the relay.
+func handleForwardUplinkReq(ctx *dataContext) error {
+	if ctx.MACPayload.FPort == nil || *ctx.MACPayload.FPort != models.RelayFPort {
+		return nil
+	}
+
+	if ctx.Relay != nil {
+		return errors.New("forwarding by multiple relays is not supported")
+	}
+
+	if len(ctx.MACPayload.FRMPayload) != 1 {
+		return errors.New("expected exactly one FRMPayload item")
+	}
+	dataPL, ok := ctx.MACPayload.FRMPayload[0].(*lorawan.DataPayload)
+	if !ok {
+		return fmt.Errorf("expected type *lorawan.DataPayload, got %T", ctx.MACPayload.FRMPayload[0])
+	}
+
+	var req models.ForwardUplinkReq
+	if err := req.UnmarshalBinary(dataPL.Bytes); err != nil {
+		return errors.Wrap(err, "unmarshal forward uplink request error")
+	}
+
+	switch req.PHYPayload.MHDR.MType {
+	case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp:
+	default:
+		return fmt.Errorf("forwarded uplink of type %s is not supported", req.PHYPayload.MHDR.MType)
+	}
+
+	rxPacket := models.RXPacket{
+		DR:         req.DR,
+		PHYPayload: req.PHYPayload,
+		TXInfo: &gw.UplinkTXInfo{
+			Frequency: uint32(req.Frequency),
+		},
+	}
+	if err := helpers.SetUplinkTXInfoDataRate(rxPacket.TXInfo, req.DR, config.C.NetworkServer.Band.Band); err != nil {
+		return errors.Wrap(err, "set uplink tx-info data-rate error")
+	}
+
+	for i := range ctx.RXPacket.RXInfoSet {
+		rxInfo := *ctx.RXPacket.RXInfoSet[i]
+		rxInfo.Rssi = int32(req.RSSI)
+		rxInfo.LoraSnr = float64(req.SNR)
+		rxPacket.RXInfoSet = append(rxPacket.RXInfoSet, &rxInfo)
+	}
+
+	deviceCtx, err := handle(rxPacket, ctx)
+	if err != nil {
+		return errors.Wrap(err, "handle forwarded uplink error")
+	}
+
+	if err := storage.SaveDeviceRelay(config.C.Redis.Pool, storage.DeviceRelay{
+		DevEUI:      deviceCtx.DeviceSession.DevEUI,
+		RelayDevEUI: ctx.DeviceSession.DevEUI,
+		WORChannel:  req.WORChannel,
+		DR:          req.DR,
+		Frequency:   req.Frequency,
+		RSSI:        req.RSSI,
+		SNR:         req.SNR,
+		UpdatedAt:   time.Now(),
+	}); err != nil {
+		log.WithError(err).Error("save device relay error")
+	}
+
+	ctx.ForwardPHYPayload = deviceCtx.ForwardPHYPayload
+
+	return nil
+}
+
 func handleDownlink(ctx *dataContext) error {
+	// the downlink of a relayed device is returned to the relay context,
+	// which will send it as part of its own downlink
+	if ctx.Relay != nil {
+		b, err := datadown.HandleRelayedResponse(
+			ctx.RXPacket,
+			ctx.ServiceProfile,
+			ctx.DeviceSession,
+			ctx.Relay.RXPacket,
+			ctx.Relay.DeviceSession,
+			ctx.MACPayload.FHDR.FCtrl.ADR,
+			ctx.MACPayload.FHDR.FCtrl.ADRACKReq,
+			ctx.RXPacket.PHYPayload.MHDR.MType == lorawan.ConfirmedDataUp,
+			ctx.MACCommandResponses,
+		)
+		if err != nil {
+			return errors.Wrap(err, "run relayed uplink response flow error")
+		}
+		ctx.ForwardPHYPayload = b
+		return nil
+	}
+
 	// handle downlink (ACK)
 	time.Sleep(config.C.NetworkServer.GetDownlinkDataDelay)
+
+	if len(ctx.ForwardPHYPayload) != 0 {
+		if err := datadown.HandleForwardResponse(
+			ctx.RXPacket,
+			ctx.ServiceProfile,
+			ctx.DeviceSession,
+			ctx.MACPayload.FHDR.FCtrl.ADR,
+			ctx.RXPacket.PHYPayload.MHDR.MType == lorawan.ConfirmedDataUp,
+			ctx.MACCommandResponses,
+			ctx.ForwardPHYPayload,
+		); err != nil {
+			return errors.Wrap(err, "run forward response flow error")
+		}
+
+		return nil
+	}
+
 	if err := datadown.HandleResponse(
 		ctx.RXPacket,
 		ctx.ServiceProfile,
@@ -637,23 +828,29 @@ func handleUplinkMACCommands(ds *storage.DeviceSession, dp storage.DeviceProfile
 			}
 		}
 
+		var data [][]byte
+		for _, cmd := range block.MACCommands {
+			b, err := cmd.MarshalBinary()
+			if err != nil {
+				log.WithFields(logFields).Errorf("marshal mac-command to binary error: %s", err)
+				continue
+			}
+			data = append(data, b)
+		}
+		macReq := nc.HandleUplinkMACCommandRequest{
+			DevEui:   ds.DevEUI[:],
+			Cid:      uint32(block.CID),
+			Commands: data,
+		}
+
+		if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventMACCommand, ds.DevEUI, &macReq); err != nil {
+			log.WithFields(logFields).WithError(err).Error("publish mac-command event error")
+		}
+
 		// report to external controller in case of proprietary mac-commands or
 		// in case when the request has been scheduled through the API.
 		if block.CID >= 0x80 || external {
-			var data [][]byte
-			for _, cmd := range block.MACCommands {
-				b, err := cmd.MarshalBinary()
-				if err != nil {
-					log.WithFields(logFields).Errorf("marshal mac-command to binary error: %s", err)
-					continue
-				}
-				data = append(data, b)
-			}
-			_, err = config.C.NetworkController.Client.HandleUplinkMACCommand(context.Background(), &nc.HandleUplinkMACCommandRequest{
-				DevEui:   ds.DevEUI[:],
-				Cid:      uint32(block.CID),
-				Commands: data,
-			})
+			_, err = config.C.NetworkController.Client.HandleUplinkMACCommand(context.Background(), &macReq)
 			if err != nil {
 				log.WithFields(logFields).Errorf("send mac-command to network-controller error: %s", err)
 			} else {
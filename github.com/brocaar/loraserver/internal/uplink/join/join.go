@@ -13,6 +13,7 @@ import (
 	joindown "github.com/brocaar/loraserver/internal/downlink/join"
 	"github.com/brocaar/loraserver/internal/framelog"
 	"github.com/brocaar/loraserver/internal/helpers"
+	"github.com/brocaar/loraserver/internal/integration"
 	"github.com/brocaar/loraserver/internal/models"
 	"github.com/brocaar/loraserver/internal/storage"
 	"github.com/brocaar/lorawan"
@@ -31,6 +32,7 @@ var tasks = []func(*context) error{
 	createDeviceSession,
 	createDeviceActivation,
 	sendJoinAcceptDownlink,
+	publishJoinEvent,
 }
 
 type context struct {
@@ -360,3 +362,16 @@ func sendJoinAcceptDownlink(ctx *context) error {
 
 	return nil
 }
+
+func publishJoinEvent(ctx *context) error {
+	uplinkFrameSet, err := framelog.CreateUplinkFrameSet(ctx.RXPacket)
+	if err != nil {
+		return errors.Wrap(err, "create uplink frame-set error")
+	}
+
+	if err := config.C.NetworkServer.Integration.Handler.Publish(integration.EventJoin, ctx.DeviceSession.DevEUI, &uplinkFrameSet); err != nil {
+		log.WithError(err).Error("publish join event error")
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+-- +migrate Up
+create table gateway_profile_revision (
+    gateway_profile_id uuid not null references gateway_profile on delete cascade,
+    revision integer not null,
+    created_at timestamp with time zone not null,
+    channels smallint[] not null,
+    extra_channels jsonb not null,
+    primary key(gateway_profile_id, revision)
+);
+
+insert into gateway_profile_revision (
+    gateway_profile_id,
+    revision,
+    created_at,
+    channels,
+    extra_channels
+)
+select
+    gp.gateway_profile_id,
+    1,
+    gp.updated_at,
+    gp.channels,
+    coalesce(
+        (select
+            json_agg(json_build_object(
+                'Modulation', ec.modulation,
+                'Frequency', ec.frequency,
+                'Bandwidth', ec.bandwidth,
+                'Bitrate', ec.bitrate,
+                'SpreadingFactors', ec.spreading_factors
+            ) order by ec.id)
+        from gateway_profile_extra_channel ec
+        where ec.gateway_profile_id = gp.gateway_profile_id),
+        '[]'
+    )::jsonb
+from gateway_profile gp;
+
+create table gateway_profile_rollout (
+    gateway_profile_id uuid primary key references gateway_profile on delete cascade,
+    created_at timestamp with time zone not null,
+    updated_at timestamp with time zone not null,
+    stable_revision integer not null,
+    target_revision integer not null,
+    canary_gateway_ids bytea[] not null,
+    state varchar(20) not null
+);
+
+create table gateway_configuration_push (
+    gateway_id bytea primary key references gateway on delete cascade,
+    gateway_profile_id uuid not null references gateway_profile on delete cascade,
+    revision integer not null,
+    version varchar(100) not null,
+    pushed_at timestamp with time zone not null,
+    confirmed_at timestamp with time zone
+);
+
+create index idx_gateway_configuration_push_gateway_profile_id on gateway_configuration_push(gateway_profile_id);
+
+-- +migrate Down
+drop index idx_gateway_configuration_push_gateway_profile_id;
+drop table gateway_configuration_push;
+drop table gateway_profile_rollout;
+drop table gateway_profile_revision;
@@ -0,0 +1,13 @@
+-- +migrate Up
+alter table device_queue
+    add column priority smallint not null default 0,
+    add column expire_at timestamp with time zone;
+
+create index idx_device_queue_expire_at on device_queue(expire_at);
+
+-- +migrate Down
+drop index idx_device_queue_expire_at;
+
+alter table device_queue
+    drop column expire_at,
+    drop column priority;
@@ -159,10 +159,17 @@ type PubsubMessage struct {
 	// The time at which the message was published, populated by the server when
 	// it receives the `Publish` call. It must not be populated by the
 	// publisher in a `Publish` call.
-	PublishTime          *timestamp.Timestamp `protobuf:"bytes,4,opt,name=publish_time,json=publishTime,proto3" json:"publish_time,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	PublishTime *timestamp.Timestamp `protobuf:"bytes,4,opt,name=publish_time,json=publishTime,proto3" json:"publish_time,omitempty"`
+	// Identifies related messages for which publish order should be respected.
+	// If a `Subscription` has `enable_message_ordering` set to `true`, messages
+	// published with the same non-empty `ordering_key` value will be delivered
+	// to subscribers in the order in which they are received by the Pub/Sub
+	// system. All `PubsubMessage`s published in a given `PublishRequest` must
+	// specify the same `ordering_key` value.
+	OrderingKey          string   `protobuf:"bytes,5,opt,name=ordering_key,json=orderingKey,proto3" json:"ordering_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PubsubMessage) Reset()         { *m = PubsubMessage{} }
@@ -218,6 +225,13 @@ func (m *PubsubMessage) GetPublishTime() *timestamp.Timestamp {
 	return nil
 }
 
+func (m *PubsubMessage) GetOrderingKey() string {
+	if m != nil {
+		return m.OrderingKey
+	}
+	return ""
+}
+
 // Request for the GetTopic method.
 type GetTopicRequest struct {
 	// The name of the topic to get.
@@ -856,6 +870,11 @@ type Subscription struct {
 	MessageRetentionDuration *duration.Duration `protobuf:"bytes,8,opt,name=message_retention_duration,json=messageRetentionDuration,proto3" json:"message_retention_duration,omitempty"`
 	// See <a href="/pubsub/docs/labels"> Creating and managing labels</a>.
 	Labels map[string]string `protobuf:"bytes,9,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// If true, messages published with the same `ordering_key` in `PubsubMessage`
+	// will be delivered to the subscribers in the order in which they
+	// are received by the Pub/Sub system. Otherwise, they may be delivered in
+	// any order.
+	EnableMessageOrdering bool `protobuf:"varint,10,opt,name=enable_message_ordering,json=enableMessageOrdering,proto3" json:"enable_message_ordering,omitempty"`
 	// A policy that specifies the conditions for this subscription's expiration.
 	// A subscription is considered active as long as any connected subscriber is
 	// successfully consuming messages from the subscription or is issuing
@@ -945,6 +964,13 @@ func (m *Subscription) GetLabels() map[string]string {
 	return nil
 }
 
+func (m *Subscription) GetEnableMessageOrdering() bool {
+	if m != nil {
+		return m.EnableMessageOrdering
+	}
+	return false
+}
+
 func (m *Subscription) GetExpirationPolicy() *ExpirationPolicy {
 	if m != nil {
 		return m.ExpirationPolicy
@@ -2452,148 +2478,150 @@ func init() {
 func init() { proto.RegisterFile("google/pubsub/v1/pubsub.proto", fileDescriptor_f602d910f9a348fe) }
 
 var fileDescriptor_f602d910f9a348fe = []byte{
-	// 2244 bytes of a gzipped FileDescriptorProto
+	// 2282 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x5a, 0xcd, 0x73, 0x1b, 0x49,
-	0x15, 0xcf, 0x48, 0x8e, 0x23, 0xbf, 0xb1, 0x63, 0xbb, 0xb1, 0x1d, 0x79, 0xf2, 0x65, 0x4f, 0x8c,
-	0xed, 0xc8, 0x89, 0x64, 0x2b, 0xb5, 0x61, 0x13, 0xe3, 0xa4, 0xec, 0x38, 0x64, 0x03, 0x09, 0x6b,
-	0xc6, 0x21, 0x54, 0x51, 0x29, 0x54, 0x23, 0xa9, 0xad, 0xcc, 0x6a, 0x34, 0x33, 0x3b, 0x33, 0xf2,
-	0xc6, 0x0b, 0xa1, 0xc2, 0x2e, 0xb5, 0x55, 0x14, 0x39, 0xb0, 0xcb, 0x75, 0x0f, 0x14, 0xdc, 0x38,
-	0xf2, 0x07, 0xc0, 0x9d, 0x2b, 0x07, 0xfe, 0x01, 0x8e, 0x5c, 0xb8, 0xc1, 0x8d, 0xea, 0x8f, 0x19,
-	0xcd, 0x47, 0x8f, 0x64, 0xd9, 0x9b, 0xdb, 0xa8, 0xfb, 0x75, 0xbf, 0xdf, 0xfb, 0xee, 0xf7, 0x4a,
-	0x70, 0xb9, 0x65, 0xdb, 0x2d, 0x13, 0x57, 0x9c, 0x6e, 0xdd, 0xeb, 0xd6, 0x2b, 0x87, 0x1b, 0xfc,
-	0xab, 0xec, 0xb8, 0xb6, 0x6f, 0xa3, 0x29, 0xb6, 0x5d, 0xe6, 0x8b, 0x87, 0x1b, 0xca, 0x25, 0x7e,
-	0x40, 0x77, 0x8c, 0x8a, 0x6e, 0x59, 0xb6, 0xaf, 0xfb, 0x86, 0x6d, 0x79, 0x8c, 0x5e, 0xb9, 0x12,
-	0x5c, 0x47, 0x7e, 0xd5, 0xbb, 0x07, 0x95, 0x66, 0xd7, 0xa5, 0x04, 0x7c, 0xff, 0x62, 0x72, 0x1f,
-	0x77, 0x1c, 0xff, 0x88, 0x6f, 0x2e, 0x24, 0x37, 0x0f, 0x0c, 0x6c, 0x36, 0x6b, 0x1d, 0xdd, 0x6b,
-	0x73, 0x8a, 0xab, 0x49, 0x0a, 0xdf, 0xe8, 0x60, 0xcf, 0xd7, 0x3b, 0x0e, 0x23, 0x50, 0x9f, 0xc3,
-	0xcc, 0x53, 0xec, 0x79, 0x7a, 0x0b, 0xef, 0xfb, 0xb6, 0xab, 0xb7, 0xf0, 0x9e, 0x6d, 0x1a, 0x8d,
-	0x23, 0x74, 0x0f, 0x2e, 0xea, 0xa6, 0x69, 0x7f, 0x82, 0x9b, 0x35, 0x07, 0xbb, 0x9e, 0xe1, 0xf9,
-	0xd8, 0x6a, 0xe0, 0x9a, 0x8b, 0x5b, 0x04, 0x7c, 0x51, 0x5a, 0xc8, 0xaf, 0x8e, 0x69, 0xf3, 0x9c,
-	0x64, 0xaf, 0x47, 0xa1, 0x31, 0x02, 0xf5, 0x3f, 0x12, 0x9c, 0x7d, 0x66, 0x3b, 0x46, 0x03, 0x21,
-	0x18, 0xb1, 0xf4, 0x0e, 0x2e, 0x4a, 0x0b, 0xd2, 0xea, 0x98, 0x46, 0xbf, 0xd1, 0x26, 0x8c, 0x9a,
-	0x7a, 0x1d, 0x9b, 0x5e, 0x31, 0xb7, 0x90, 0x5f, 0x95, 0xab, 0xd7, 0xca, 0x49, 0xb5, 0x95, 0xe9,
-	0xe1, 0xf2, 0x13, 0x4a, 0xf5, 0xd0, 0xf2, 0xdd, 0x23, 0x8d, 0x1f, 0x41, 0x2f, 0x60, 0xae, 0xc3,
-	0x20, 0xd7, 0x3c, 0x86, 0xb9, 0xe6, 0x50, 0xd0, 0xc5, 0xfc, 0x82, 0xb4, 0x2a, 0x57, 0x97, 0xd3,
-	0x97, 0x89, 0x44, 0xd4, 0x66, 0x3a, 0x82, 0x55, 0xe5, 0x0e, 0xc8, 0x11, 0xa6, 0x68, 0x0a, 0xf2,
-	0x6d, 0x7c, 0xc4, 0xc1, 0x93, 0x4f, 0x34, 0x03, 0x67, 0x0f, 0x75, 0xb3, 0x8b, 0x8b, 0x39, 0xba,
-	0xc6, 0x7e, 0xdc, 0xcd, 0xbd, 0x2f, 0xa9, 0x5f, 0xe6, 0x60, 0x62, 0x8f, 0xf2, 0xe4, 0xfc, 0x88,
-	0xec, 0x4d, 0xdd, 0xd7, 0xe9, 0xf1, 0x71, 0x8d, 0x7e, 0xa3, 0x0f, 0x01, 0x74, 0xdf, 0x77, 0x8d,
-	0x7a, 0xd7, 0xc7, 0x81, 0xfc, 0x95, 0x34, 0xe4, 0xd8, 0x45, 0xe5, 0xed, 0xf0, 0x04, 0xd3, 0x45,
-	0xe4, 0x0a, 0x74, 0x19, 0x20, 0xd0, 0x87, 0xd1, 0xa4, 0x3a, 0x18, 0xd3, 0xc6, 0xf8, 0xca, 0xe3,
-	0x26, 0xda, 0x82, 0x71, 0xa7, 0x5b, 0x37, 0x0d, 0xef, 0x65, 0x8d, 0x18, 0xbf, 0x38, 0x42, 0x95,
-	0xa4, 0x84, 0x1c, 0xb9, 0x67, 0x94, 0x9f, 0x05, 0x9e, 0xa1, 0xc9, 0x9c, 0x9e, 0xac, 0x28, 0x5b,
-	0x30, 0x99, 0x60, 0x3e, 0x94, 0x4e, 0x56, 0x60, 0xf2, 0x11, 0xf6, 0xa9, 0x31, 0x35, 0xfc, 0x71,
-	0x17, 0x7b, 0x3e, 0x21, 0xf6, 0xc9, 0x6f, 0x7e, 0x01, 0xfb, 0xa1, 0xbe, 0x91, 0x00, 0xfd, 0xd8,
-	0x69, 0xea, 0x3e, 0x8e, 0x11, 0xdf, 0x8c, 0x12, 0xcb, 0xd5, 0x0b, 0x19, 0x8e, 0xc2, 0x6f, 0x41,
-	0x9b, 0x20, 0x77, 0xe9, 0x25, 0x34, 0x08, 0x28, 0x1c, 0x91, 0xac, 0xdf, 0x23, 0x71, 0xf2, 0x54,
-	0xf7, 0xda, 0x1a, 0x30, 0x72, 0xf2, 0xad, 0x36, 0xe0, 0xfc, 0x1e, 0x93, 0xbc, 0x2f, 0x54, 0xb4,
-	0x09, 0x05, 0xae, 0xde, 0xc0, 0x7e, 0x57, 0x07, 0xd8, 0x4f, 0x0b, 0x0f, 0xa8, 0x55, 0x98, 0x0c,
-	0x99, 0x78, 0x8e, 0x6d, 0x79, 0x18, 0x5d, 0x05, 0xb9, 0x67, 0xc0, 0x20, 0xb6, 0x20, 0xb4, 0xa0,
-	0xa7, 0x1a, 0x30, 0xfd, 0xc4, 0xf0, 0x98, 0x16, 0xbd, 0x00, 0x5b, 0x11, 0xce, 0x39, 0xae, 0xfd,
-	0x11, 0x6e, 0xf8, 0x1c, 0x5d, 0xf0, 0x13, 0x5d, 0x84, 0x31, 0x87, 0x46, 0x87, 0xf1, 0x29, 0xb3,
-	0xc8, 0x59, 0xad, 0x40, 0x16, 0xf6, 0x8d, 0x4f, 0x31, 0xf1, 0x16, 0xba, 0xe9, 0xdb, 0x6d, 0x6c,
-	0x05, 0xde, 0x42, 0x56, 0x9e, 0x91, 0x05, 0xb5, 0x03, 0x28, 0xca, 0x8a, 0x23, 0xac, 0xc0, 0x28,
-	0x15, 0x9d, 0x81, 0xeb, 0x63, 0x06, 0x4e, 0x86, 0x96, 0x61, 0xd2, 0xc2, 0xaf, 0xfc, 0x5a, 0x84,
-	0x15, 0x73, 0x8d, 0x09, 0xb2, 0xbc, 0x17, 0xb2, 0xfb, 0x18, 0x2e, 0x87, 0xec, 0xf6, 0xbb, 0x75,
-	0xaf, 0xe1, 0x1a, 0x0e, 0x4d, 0x8f, 0xfd, 0x2d, 0x70, 0x1a, 0x09, 0x2d, 0xb8, 0x92, 0xc5, 0x92,
-	0x4b, 0xbb, 0x04, 0x13, 0x5e, 0x74, 0x83, 0x5b, 0x24, 0xbe, 0x78, 0x6c, 0x11, 0x3b, 0x30, 0xdf,
-	0xe3, 0x67, 0xe9, 0x8e, 0xf7, 0xd2, 0xf6, 0xdf, 0xa1, 0x78, 0x75, 0x50, 0x44, 0xec, 0xb8, 0x68,
-	0x97, 0x60, 0xcc, 0x0b, 0x16, 0xb9, 0x58, 0xbd, 0x85, 0x63, 0x8b, 0x54, 0x02, 0xb4, 0x8b, 0x4d,
-	0x9c, 0x08, 0x55, 0x71, 0x5c, 0x7f, 0x31, 0x02, 0xe3, 0x51, 0x35, 0x0b, 0xeb, 0x41, 0x78, 0x34,
-	0x17, 0x55, 0xc3, 0x16, 0xc8, 0x4e, 0xd7, 0x7b, 0x59, 0x6b, 0xd8, 0xd6, 0x81, 0xd1, 0xe2, 0x89,
-	0xeb, 0x92, 0x28, 0xd4, 0xbc, 0x97, 0x0f, 0x28, 0x8d, 0x06, 0x4e, 0xf8, 0x8d, 0xd6, 0x61, 0x46,
-	0x6f, 0xb4, 0x6b, 0x4d, 0xac, 0x37, 0x4d, 0xc3, 0xc2, 0x35, 0x0f, 0x37, 0x6c, 0xab, 0xe9, 0x15,
-	0xcf, 0x52, 0x85, 0x22, 0xbd, 0xd1, 0xde, 0xe5, 0x5b, 0xfb, 0x6c, 0x07, 0x55, 0x61, 0xd6, 0xc5,
-	0xbe, 0x6e, 0x58, 0x35, 0xbd, 0xd1, 0xc6, 0xcd, 0x5a, 0x18, 0xe5, 0xe7, 0x16, 0xa4, 0xd5, 0x82,
-	0xf6, 0x2d, 0xb6, 0xb9, 0x4d, 0xf6, 0x78, 0x60, 0x7b, 0xe8, 0x27, 0xa0, 0x04, 0xc1, 0xeb, 0x62,
-	0x1f, 0x5b, 0x44, 0xc6, 0x5a, 0x50, 0xc4, 0x8b, 0x05, 0x8a, 0x79, 0x3e, 0x95, 0x80, 0x76, 0x39,
-	0x81, 0x56, 0xe4, 0x87, 0xb5, 0xe0, 0x6c, 0xb0, 0x83, 0x76, 0xc2, 0x1a, 0x39, 0x46, 0x63, 0xae,
-	0x94, 0x16, 0x3c, 0xaa, 0x57, 0x61, 0xa9, 0xfc, 0x10, 0xa6, 0xf1, 0x2b, 0xc7, 0x60, 0x37, 0x06,
-	0x55, 0x52, 0xa6, 0x98, 0xd4, 0xf4, 0x75, 0x0f, 0x43, 0x52, 0x5e, 0x21, 0xa7, 0x70, 0x62, 0xe5,
-	0x34, 0xd5, 0xf1, 0x3e, 0x4c, 0x25, 0x19, 0xa0, 0x35, 0xc8, 0xfb, 0xbe, 0xc9, 0x73, 0x7b, 0x1f,
-	0x2d, 0x11, 0x2a, 0xf5, 0xaf, 0x12, 0x40, 0xcf, 0xd4, 0xe8, 0x1a, 0x4c, 0x50, 0xef, 0xc0, 0x56,
-	0xd3, 0xb1, 0x0d, 0x2b, 0xc8, 0x82, 0xe3, 0x64, 0xf1, 0x21, 0x5f, 0x43, 0x4f, 0x04, 0xc5, 0xf6,
-	0x46, 0x3f, 0x0f, 0xea, 0x57, 0x69, 0x4f, 0x5b, 0x0b, 0x1b, 0x30, 0xa9, 0xe1, 0x06, 0x36, 0x0e,
-	0x43, 0xf7, 0x41, 0xb3, 0x30, 0x4a, 0x7c, 0xd4, 0x68, 0x06, 0x41, 0xa3, 0x37, 0xda, 0x8f, 0x9b,
-	0xe8, 0x0e, 0x9c, 0xe3, 0x7e, 0xc1, 0x4b, 0xd8, 0xc0, 0x02, 0x13, 0xd0, 0xab, 0xdf, 0x85, 0xb9,
-	0x47, 0xd8, 0x8f, 0x7a, 0x46, 0x10, 0x9f, 0x2a, 0x8c, 0x47, 0x33, 0x58, 0xa0, 0xaf, 0xe8, 0x9a,
-	0xfa, 0xb5, 0x04, 0xf3, 0xac, 0x0a, 0x8b, 0x6e, 0xd8, 0x11, 0xdc, 0x20, 0x57, 0xaf, 0xf4, 0x77,
-	0xcc, 0x38, 0x87, 0xd3, 0x55, 0x68, 0x07, 0x8a, 0x24, 0xb9, 0x09, 0x2b, 0xc5, 0xbb, 0xa9, 0x87,
-	0xbf, 0x91, 0x58, 0xfa, 0x16, 0x57, 0x8a, 0x5d, 0x51, 0xa5, 0x18, 0xac, 0x91, 0x13, 0x56, 0x92,
-	0xfb, 0x30, 0xcf, 0xd2, 0xee, 0x49, 0xad, 0xfb, 0x0b, 0xb8, 0xf0, 0xd4, 0x6e, 0x1a, 0x07, 0x47,
-	0x91, 0x8c, 0x79, 0xfc, 0xe3, 0xc9, 0x7c, 0x9c, 0x1b, 0x2e, 0x1f, 0xab, 0x9f, 0x4b, 0x20, 0xef,
-	0x75, 0x4d, 0x73, 0x18, 0x96, 0x37, 0x01, 0xb9, 0xd8, 0xef, 0xba, 0x56, 0xcd, 0xe8, 0x74, 0x70,
-	0xd3, 0xd0, 0x7d, 0x6c, 0x1e, 0x51, 0xce, 0x05, 0x6d, 0x9a, 0xed, 0x3c, 0xee, 0x6d, 0xa0, 0x45,
-	0x18, 0xef, 0xe8, 0xaf, 0x7a, 0x79, 0x3b, 0x4f, 0x8d, 0x2d, 0x77, 0xf4, 0x57, 0x41, 0xbe, 0x56,
-	0x7f, 0x06, 0xe3, 0x0c, 0x04, 0x37, 0xe1, 0x0f, 0x61, 0xda, 0xe5, 0x41, 0xd9, 0x3b, 0xc7, 0xcc,
-	0xb8, 0x98, 0x16, 0x2d, 0x11, 0xbf, 0xda, 0x94, 0x1b, 0x5f, 0xf0, 0x88, 0xc3, 0x14, 0x99, 0x92,
-	0xb7, 0x7b, 0x05, 0x66, 0x18, 0x91, 0x2f, 0xc0, 0x39, 0x96, 0x12, 0xbc, 0xe2, 0x08, 0x2d, 0xd0,
-	0xa3, 0x34, 0x27, 0x78, 0x99, 0xf5, 0x2c, 0x9f, 0x55, 0xcf, 0xd4, 0x1f, 0x01, 0xda, 0x6e, 0xb4,
-	0x2d, 0xfb, 0x13, 0x13, 0x37, 0x5b, 0x27, 0x05, 0x91, 0x8b, 0x82, 0x50, 0x7f, 0x95, 0x83, 0x99,
-	0x7d, 0xdf, 0xc5, 0x7a, 0xc7, 0xb0, 0x5a, 0xc3, 0x5a, 0x33, 0xeb, 0x56, 0x74, 0x1b, 0x2e, 0x74,
-	0xa8, 0xce, 0x44, 0xd2, 0xe5, 0x57, 0xcf, 0x6a, 0xb3, 0x6c, 0x3b, 0x59, 0xb0, 0xdf, 0x4b, 0x9f,
-	0x8b, 0xeb, 0x6e, 0x26, 0x7e, 0x6e, 0x9b, 0xb1, 0xdb, 0x82, 0x8b, 0x1e, 0x95, 0xa1, 0xd6, 0xe7,
-	0x81, 0x50, 0x64, 0x24, 0xdb, 0x69, 0xb5, 0xb6, 0x60, 0x36, 0xa1, 0x82, 0x77, 0xe4, 0x4b, 0xff,
-	0x94, 0x60, 0xf6, 0x81, 0x8b, 0x49, 0x36, 0xe6, 0x6f, 0xb4, 0x40, 0xdb, 0xa2, 0x47, 0x54, 0xd2,
-	0x02, 0x39, 0x81, 0x05, 0x7e, 0x10, 0x3e, 0x2a, 0xf2, 0x14, 0xd6, 0xad, 0x34, 0x2c, 0x21, 0x43,
-	0xd1, 0xeb, 0xe2, 0x34, 0x8f, 0x81, 0xb7, 0x12, 0xcc, 0xf2, 0x3a, 0x93, 0x90, 0xec, 0x36, 0x14,
-	0x82, 0x07, 0x29, 0xaf, 0x2f, 0x8a, 0x20, 0x9b, 0x06, 0x87, 0x42, 0xda, 0xd3, 0xd5, 0x95, 0x7f,
-	0x4b, 0x50, 0x08, 0xee, 0x1c, 0xe2, 0x81, 0xba, 0x09, 0x32, 0x7d, 0x21, 0x61, 0xd6, 0x59, 0xe7,
-	0x07, 0x76, 0xd6, 0xc0, 0xc8, 0xc9, 0x02, 0xba, 0x17, 0x9a, 0x62, 0x84, 0x9a, 0x62, 0x39, 0x5b,
-	0xcc, 0x6f, 0x5a, 0xfb, 0xeb, 0x80, 0xc8, 0x1b, 0x21, 0xa1, 0x79, 0x25, 0xa1, 0xf9, 0xb1, 0x9e,
-	0x76, 0x55, 0x13, 0x66, 0x68, 0x15, 0x4c, 0xf6, 0x2f, 0xef, 0xa6, 0xe8, 0x1e, 0xc1, 0x6c, 0x82,
-	0x1b, 0x0f, 0xb0, 0xf7, 0x93, 0xed, 0x4b, 0x7f, 0xef, 0x38, 0x41, 0x6b, 0x73, 0x0b, 0x66, 0x79,
-	0x8d, 0x1d, 0x42, 0x3b, 0xbf, 0x95, 0x40, 0xde, 0xc7, 0xb8, 0x3d, 0x4c, 0x2e, 0x5c, 0x87, 0x11,
-	0xea, 0x34, 0xb9, 0x41, 0x4e, 0xf3, 0xc1, 0x19, 0x8d, 0x52, 0xa2, 0x4b, 0x11, 0x04, 0x54, 0x65,
-	0x1f, 0x9c, 0xe9, 0x61, 0xd8, 0x29, 0xc0, 0xa8, 0xaf, 0xbb, 0x2d, 0xec, 0xab, 0xe7, 0x61, 0x9c,
-	0x81, 0x61, 0x4a, 0xab, 0xfe, 0xad, 0x00, 0x63, 0x7c, 0xe4, 0x80, 0x5d, 0xf4, 0x11, 0xc8, 0x2c,
-	0xc2, 0xd9, 0x74, 0x2e, 0xab, 0x93, 0x57, 0xb2, 0x36, 0xd4, 0xeb, 0x9f, 0xfd, 0xe3, 0x5f, 0xbf,
-	0xcf, 0x5d, 0x53, 0xae, 0x54, 0x0e, 0x37, 0x2a, 0x3f, 0x27, 0xd1, 0xb1, 0xc5, 0x6d, 0xee, 0x55,
-	0x4a, 0x15, 0xd6, 0xff, 0x57, 0x4a, 0xaf, 0xef, 0x4a, 0x25, 0xf4, 0x1a, 0xe4, 0xc8, 0x48, 0x07,
-	0x2d, 0xa5, 0xaf, 0x4c, 0x4f, 0x7c, 0xb2, 0x19, 0x57, 0x28, 0xe3, 0xeb, 0xd5, 0x25, 0xca, 0x98,
-	0x32, 0x2a, 0xf7, 0x65, 0xff, 0x99, 0x04, 0xe7, 0xb8, 0xe0, 0x68, 0x41, 0xf8, 0x80, 0x8e, 0xcc,
-	0x7a, 0x94, 0xc5, 0x3e, 0x14, 0x4c, 0x93, 0x6a, 0x95, 0x22, 0xb8, 0xa1, 0xae, 0xf4, 0x10, 0x88,
-	0x99, 0xf3, 0x01, 0x1a, 0x01, 0x61, 0x43, 0x21, 0x18, 0x80, 0x21, 0x01, 0x8b, 0xc4, 0x70, 0x2c,
-	0x5b, 0xfa, 0x15, 0xca, 0x7b, 0x11, 0x5d, 0x1d, 0xc0, 0x1b, 0xbd, 0x91, 0x00, 0x7a, 0x23, 0x1c,
-	0x24, 0x18, 0xad, 0xa6, 0x66, 0x49, 0xca, 0x52, 0x7f, 0x22, 0x2e, 0x7e, 0x1c, 0x02, 0x67, 0x1e,
-	0x01, 0xf1, 0x9a, 0xa3, 0x40, 0x7f, 0x91, 0x60, 0x4e, 0x3c, 0x63, 0x41, 0x95, 0x3e, 0x9c, 0x44,
-	0xcf, 0x7a, 0x65, 0xfd, 0xf8, 0x07, 0x38, 0xcc, 0xf7, 0x28, 0xcc, 0x0a, 0xba, 0x39, 0x40, 0x53,
-	0x95, 0xf8, 0x2b, 0xfc, 0x8f, 0x52, 0x64, 0xf4, 0x15, 0xa6, 0x1e, 0xb4, 0xd6, 0x8f, 0x7f, 0x22,
-	0x1d, 0x2a, 0x37, 0x8e, 0x47, 0xcc, 0x81, 0x6e, 0x50, 0xa0, 0x6b, 0xe8, 0xfa, 0x40, 0xa0, 0x21,
-	0x1a, 0x1f, 0xe4, 0xc8, 0xe4, 0x45, 0x14, 0x51, 0xe9, 0xc1, 0x8c, 0x32, 0x97, 0x4a, 0x2e, 0x0f,
-	0x3b, 0x8e, 0x7f, 0x14, 0xd8, 0xb3, 0x34, 0xc8, 0xa5, 0xaa, 0xff, 0x9b, 0x06, 0xe0, 0xba, 0xae,
-	0x63, 0x17, 0x7d, 0x21, 0x01, 0xe2, 0xaf, 0x84, 0x68, 0x46, 0x1b, 0xd0, 0xf5, 0x28, 0x03, 0xf6,
-	0xd5, 0x75, 0x0a, 0xa7, 0xa4, 0x7c, 0x5b, 0x98, 0x58, 0x62, 0xc6, 0xe2, 0x01, 0xfe, 0x95, 0x44,
-	0xa7, 0xcb, 0x31, 0x14, 0xab, 0xc2, 0x18, 0x13, 0x74, 0x4c, 0x03, 0xf1, 0xc4, 0xfd, 0x28, 0xca,
-	0xbf, 0x1f, 0x2e, 0xf4, 0x87, 0x70, 0x90, 0x1d, 0xc3, 0xb5, 0x96, 0x95, 0xfc, 0x4e, 0x02, 0x6d,
-	0x8b, 0x42, 0xfb, 0x4e, 0xb5, 0x9a, 0x82, 0x56, 0x3e, 0x8e, 0xde, 0xbe, 0x96, 0xd8, 0x40, 0x39,
-	0x1e, 0x9a, 0x25, 0xb1, 0xf3, 0x0a, 0xa3, 0x72, 0xed, 0x58, 0xb4, 0xdc, 0xcf, 0xcb, 0x14, 0xed,
-	0x2a, 0x5a, 0xce, 0xcc, 0x1b, 0xf1, 0x48, 0xfc, 0x9d, 0x14, 0xcc, 0x17, 0x07, 0x69, 0x30, 0xb3,
-	0x1d, 0xce, 0xf4, 0x79, 0x6e, 0xd4, 0xd2, 0x90, 0x46, 0xfd, 0x93, 0x04, 0xd3, 0xa9, 0xae, 0x4e,
-	0xa4, 0xb1, 0xac, 0xd6, 0x2f, 0x13, 0xd0, 0xf7, 0x29, 0xa0, 0x5d, 0xf5, 0xfe, 0x50, 0x80, 0xee,
-	0x76, 0x92, 0x7c, 0x88, 0x5d, 0xbf, 0x94, 0x40, 0x8e, 0x34, 0x7c, 0xa2, 0xf4, 0x90, 0xee, 0x07,
-	0x33, 0x91, 0xed, 0x52, 0x64, 0xf7, 0xd4, 0x3b, 0xc3, 0x21, 0xd3, 0x7b, 0x1c, 0x08, 0xa6, 0x5f,
-	0x4b, 0x30, 0x42, 0x9a, 0x24, 0x74, 0x59, 0x54, 0x5f, 0xc3, 0xfe, 0x51, 0xe4, 0xf2, 0xd1, 0xde,
-	0x2a, 0x70, 0x79, 0xb5, 0x3a, 0x1c, 0x1a, 0xa7, 0x6b, 0x9a, 0x04, 0xc6, 0x01, 0x4c, 0xc4, 0x7a,
-	0x36, 0x24, 0x7a, 0x6e, 0x0b, 0xfa, 0x5a, 0x65, 0x65, 0x20, 0x1d, 0x07, 0x78, 0x66, 0x55, 0x5a,
-	0x97, 0x48, 0xf4, 0x4f, 0x25, 0x67, 0x2c, 0xe8, 0x7a, 0x96, 0x9f, 0xa4, 0xe6, 0x30, 0x99, 0xc6,
-	0x78, 0x4c, 0xc5, 0x7f, 0xa0, 0xde, 0x3b, 0x89, 0x9b, 0xf4, 0xd8, 0x10, 0x55, 0xfc, 0x12, 0xe4,
-	0xc8, 0xeb, 0x5f, 0xe4, 0x24, 0xe9, 0xe6, 0x40, 0xe9, 0xf3, 0xcc, 0x56, 0x6f, 0x52, 0x6c, 0x2b,
-	0x88, 0x25, 0xee, 0xa0, 0x58, 0xc5, 0x70, 0x05, 0x05, 0x8c, 0xc4, 0xd2, 0x5b, 0x09, 0x26, 0x62,
-	0xcf, 0x7b, 0x91, 0x2d, 0x44, 0xdd, 0x86, 0xc8, 0x16, 0xc2, 0x3e, 0x41, 0x2d, 0x51, 0x44, 0x4b,
-	0x48, 0xcd, 0xce, 0x38, 0x21, 0xf3, 0xcf, 0x25, 0x38, 0x1f, 0xef, 0x79, 0xd1, 0xca, 0x31, 0xbb,
-	0xe2, 0xbe, 0x5a, 0xb9, 0x41, 0x31, 0x2c, 0x2b, 0x8b, 0xe2, 0x72, 0x16, 0xd1, 0x08, 0x31, 0xca,
-	0x5b, 0x09, 0xce, 0xc7, 0x1b, 0x62, 0x11, 0x0a, 0x61, 0xcb, 0xdc, 0x17, 0x05, 0xcf, 0x77, 0xd5,
-	0x52, 0xcc, 0x36, 0xe5, 0x41, 0x70, 0xde, 0x48, 0x70, 0x3e, 0xde, 0x07, 0x89, 0xe0, 0x08, 0x3b,
-	0xa5, 0x4c, 0x17, 0xe6, 0x6e, 0x52, 0x3a, 0xa6, 0x9b, 0x90, 0xc4, 0x41, 0xfa, 0x18, 0x51, 0xe2,
-	0x88, 0x34, 0x5b, 0xc2, 0x5a, 0x19, 0x69, 0x7f, 0x4e, 0x9a, 0x38, 0x3c, 0x8c, 0xdb, 0x77, 0xa5,
-	0xd2, 0xce, 0x57, 0x12, 0xcc, 0x34, 0xec, 0x4e, 0x8a, 0xc9, 0x8e, 0xcc, 0x06, 0xf0, 0x7b, 0x44,
-	0xc8, 0x3d, 0xe9, 0xa7, 0xb7, 0x39, 0x41, 0xcb, 0x36, 0x75, 0xab, 0x55, 0xb6, 0xdd, 0x56, 0xa5,
-	0x85, 0x2d, 0xaa, 0x82, 0x0a, 0xdb, 0xd2, 0x1d, 0xc3, 0xeb, 0xfd, 0x6d, 0x64, 0x93, 0x7d, 0xfd,
-	0x57, 0x92, 0xfe, 0x9c, 0x9b, 0x7b, 0xc4, 0xce, 0x3e, 0x30, 0xed, 0x6e, 0x93, 0x74, 0x1d, 0xfb,
-	0xdd, 0x7a, 0xf9, 0xf9, 0xc6, 0xdf, 0x83, 0x8d, 0x17, 0x74, 0xe3, 0x05, 0xdb, 0x78, 0xf1, 0x7c,
-	0xa3, 0x3e, 0x4a, 0xef, 0xbd, 0xf5, 0xff, 0x00, 0x00, 0x00, 0xff, 0xff, 0x72, 0xd4, 0x01, 0xbe,
-	0x8d, 0x22, 0x00, 0x00,
+	0x15, 0xdf, 0x96, 0x6c, 0x47, 0x7e, 0x63, 0x27, 0x76, 0x63, 0x27, 0xf2, 0xe4, 0xcb, 0x99, 0x84,
+	0xd8, 0x51, 0x12, 0xc9, 0x51, 0x6a, 0xc3, 0x26, 0xc1, 0x49, 0xd9, 0x71, 0xc8, 0x86, 0x4d, 0x88,
+	0x19, 0x87, 0x50, 0x45, 0xa5, 0x50, 0x8d, 0xa4, 0xb6, 0x32, 0xab, 0xd1, 0xcc, 0xec, 0xcc, 0x28,
+	0x1b, 0x2f, 0x84, 0x0a, 0xbb, 0x14, 0x55, 0x14, 0x39, 0xc0, 0x72, 0xdd, 0x03, 0x05, 0x37, 0x8e,
+	0x14, 0xe7, 0xe5, 0xce, 0x95, 0x03, 0xff, 0x00, 0x47, 0x2e, 0xdc, 0xe0, 0x46, 0xf5, 0xc7, 0x8c,
+	0xe6, 0xa3, 0x47, 0xb2, 0x6c, 0x72, 0x1b, 0x75, 0xbf, 0xee, 0xf7, 0x7b, 0xdf, 0xfd, 0x9e, 0x0d,
+	0xa7, 0x3b, 0x8e, 0xd3, 0xb1, 0x48, 0xcd, 0xed, 0x37, 0xfd, 0x7e, 0xb3, 0xf6, 0xf2, 0x9a, 0xf8,
+	0xaa, 0xba, 0x9e, 0x13, 0x38, 0x78, 0x8e, 0x6f, 0x57, 0xc5, 0xe2, 0xcb, 0x6b, 0xea, 0x29, 0x71,
+	0xc0, 0x70, 0xcd, 0x9a, 0x61, 0xdb, 0x4e, 0x60, 0x04, 0xa6, 0x63, 0xfb, 0x9c, 0x5e, 0x3d, 0x13,
+	0x5e, 0x47, 0x7f, 0x35, 0xfb, 0xbb, 0xb5, 0x76, 0xdf, 0x63, 0x04, 0x62, 0xff, 0x64, 0x7a, 0x9f,
+	0xf4, 0xdc, 0x60, 0x4f, 0x6c, 0x2e, 0xa7, 0x37, 0x77, 0x4d, 0x62, 0xb5, 0x1b, 0x3d, 0xc3, 0xef,
+	0x0a, 0x8a, 0xb3, 0x69, 0x8a, 0xc0, 0xec, 0x11, 0x3f, 0x30, 0x7a, 0x2e, 0x27, 0xd0, 0x9e, 0xc1,
+	0xc2, 0x63, 0xe2, 0xfb, 0x46, 0x87, 0xec, 0x04, 0x8e, 0x67, 0x74, 0xc8, 0xb6, 0x63, 0x99, 0xad,
+	0x3d, 0x7c, 0x07, 0x4e, 0x1a, 0x96, 0xe5, 0x7c, 0x4a, 0xda, 0x0d, 0x97, 0x78, 0xbe, 0xe9, 0x07,
+	0xc4, 0x6e, 0x91, 0x86, 0x47, 0x3a, 0x14, 0x7c, 0x19, 0x2d, 0x17, 0x57, 0xa7, 0xf5, 0x25, 0x41,
+	0xb2, 0x3d, 0xa0, 0xd0, 0x39, 0x81, 0xf6, 0x6f, 0x04, 0x93, 0x4f, 0x1d, 0xd7, 0x6c, 0x61, 0x0c,
+	0x13, 0xb6, 0xd1, 0x23, 0x65, 0xb4, 0x8c, 0x56, 0xa7, 0x75, 0xf6, 0x8d, 0x6f, 0xc3, 0x94, 0x65,
+	0x34, 0x89, 0xe5, 0x97, 0x0b, 0xcb, 0xc5, 0x55, 0xa5, 0x7e, 0xbe, 0x9a, 0x56, 0x5b, 0x95, 0x1d,
+	0xae, 0x3e, 0x62, 0x54, 0xf7, 0xed, 0xc0, 0xdb, 0xd3, 0xc5, 0x11, 0xfc, 0x1c, 0x8e, 0xf7, 0x38,
+	0xe4, 0x86, 0xcf, 0x31, 0x37, 0x5c, 0x06, 0xba, 0x5c, 0x5c, 0x46, 0xab, 0x4a, 0xfd, 0x62, 0xf6,
+	0x32, 0x99, 0x88, 0xfa, 0x42, 0x4f, 0xb2, 0xaa, 0xde, 0x04, 0x25, 0xc6, 0x14, 0xcf, 0x41, 0xb1,
+	0x4b, 0xf6, 0x04, 0x78, 0xfa, 0x89, 0x17, 0x60, 0xf2, 0xa5, 0x61, 0xf5, 0x49, 0xb9, 0xc0, 0xd6,
+	0xf8, 0x8f, 0x5b, 0x85, 0x0f, 0x90, 0xf6, 0x97, 0x02, 0xcc, 0x6e, 0x33, 0x9e, 0x82, 0x1f, 0x95,
+	0xbd, 0x6d, 0x04, 0x06, 0x3b, 0x3e, 0xa3, 0xb3, 0x6f, 0xfc, 0x04, 0xc0, 0x08, 0x02, 0xcf, 0x6c,
+	0xf6, 0x03, 0x12, 0xca, 0x5f, 0xcb, 0x42, 0x4e, 0x5c, 0x54, 0xdd, 0x88, 0x4e, 0x70, 0x5d, 0xc4,
+	0xae, 0xc0, 0xa7, 0x01, 0x42, 0x7d, 0x98, 0x6d, 0xa6, 0x83, 0x69, 0x7d, 0x5a, 0xac, 0x3c, 0x6c,
+	0xe3, 0x75, 0x98, 0x71, 0xfb, 0x4d, 0xcb, 0xf4, 0x5f, 0x34, 0xa8, 0xf1, 0xcb, 0x13, 0x4c, 0x49,
+	0x6a, 0xc4, 0x51, 0x78, 0x46, 0xf5, 0x69, 0xe8, 0x19, 0xba, 0x22, 0xe8, 0xe9, 0x0a, 0x3e, 0x07,
+	0x33, 0x8e, 0xd7, 0x26, 0x9e, 0x69, 0x77, 0x1a, 0x54, 0x13, 0x93, 0xec, 0x7e, 0x25, 0x5c, 0xfb,
+	0x88, 0xec, 0xa9, 0xeb, 0x70, 0x2c, 0x85, 0x6f, 0x2c, 0xb5, 0xad, 0xc0, 0xb1, 0x07, 0x24, 0x60,
+	0xf6, 0xd6, 0xc9, 0x27, 0x7d, 0xe2, 0x07, 0x94, 0x38, 0xa0, 0xbf, 0xc5, 0x05, 0xfc, 0x87, 0xf6,
+	0x06, 0x01, 0xfe, 0x81, 0xdb, 0x36, 0x02, 0x92, 0x20, 0xbe, 0x1a, 0x27, 0x56, 0xea, 0x27, 0x72,
+	0x7c, 0x49, 0xdc, 0x82, 0x6f, 0x83, 0xd2, 0x67, 0x97, 0xb0, 0x38, 0x29, 0x17, 0x72, 0xd4, 0xf1,
+	0x1d, 0x1a, 0x4a, 0x8f, 0x0d, 0xbf, 0xab, 0x03, 0x27, 0xa7, 0xdf, 0x5a, 0x0b, 0x8e, 0x6e, 0x73,
+	0xe5, 0x0c, 0x85, 0x8a, 0x6f, 0x43, 0x49, 0x58, 0x20, 0x34, 0xf1, 0xd9, 0x11, 0x26, 0xd6, 0xa3,
+	0x03, 0x5a, 0x1d, 0x8e, 0x45, 0x4c, 0x7c, 0xd7, 0xb1, 0x7d, 0x82, 0xcf, 0x82, 0x32, 0xb0, 0x71,
+	0x18, 0x7e, 0x10, 0x19, 0xd9, 0xd7, 0x4c, 0x98, 0x7f, 0x64, 0xfa, 0x5c, 0x8b, 0x7e, 0x88, 0xad,
+	0x0c, 0x47, 0x5c, 0xcf, 0xf9, 0x98, 0xb4, 0x02, 0x81, 0x2e, 0xfc, 0x89, 0x4f, 0xc2, 0xb4, 0xcb,
+	0x02, 0xc8, 0xfc, 0x8c, 0x5b, 0x64, 0x52, 0x2f, 0xd1, 0x85, 0x1d, 0xf3, 0x33, 0x42, 0x1d, 0x8a,
+	0x6d, 0x06, 0x4e, 0x97, 0xd8, 0xa1, 0x43, 0xd1, 0x95, 0xa7, 0x74, 0x41, 0xeb, 0x01, 0x8e, 0xb3,
+	0x12, 0x08, 0x6b, 0x30, 0xc5, 0x44, 0xe7, 0xe0, 0x86, 0x98, 0x41, 0x90, 0xe1, 0x8b, 0x70, 0xcc,
+	0x26, 0xaf, 0x82, 0x46, 0x8c, 0x15, 0x77, 0x8d, 0x59, 0xba, 0xbc, 0x1d, 0xb1, 0xfb, 0x04, 0x4e,
+	0x47, 0xec, 0x76, 0xfa, 0x4d, 0xbf, 0xe5, 0x99, 0x2e, 0xcb, 0xa0, 0xc3, 0x2d, 0x70, 0x18, 0x09,
+	0x6d, 0x38, 0x93, 0xc7, 0x52, 0x48, 0x7b, 0x01, 0x66, 0xfd, 0xf8, 0x86, 0xb0, 0x48, 0x72, 0x71,
+	0xdf, 0x22, 0xf6, 0x60, 0x69, 0xc0, 0xcf, 0x36, 0x5c, 0xff, 0x85, 0x13, 0xbc, 0x43, 0xf1, 0x9a,
+	0xa0, 0xca, 0xd8, 0x09, 0xd1, 0x4e, 0xc1, 0xb4, 0x1f, 0x2e, 0x0a, 0xb1, 0x06, 0x0b, 0xfb, 0x16,
+	0xa9, 0x02, 0x78, 0x8b, 0x58, 0x24, 0x15, 0xaa, 0xf2, 0xb8, 0xfe, 0x7a, 0x02, 0x66, 0xe2, 0x6a,
+	0x96, 0x96, 0x8c, 0xe8, 0x68, 0x21, 0xae, 0x86, 0x75, 0x50, 0xdc, 0xbe, 0xff, 0xa2, 0xd1, 0x72,
+	0xec, 0x5d, 0xb3, 0x23, 0x72, 0xdb, 0x29, 0x59, 0xa8, 0xf9, 0x2f, 0xee, 0x31, 0x1a, 0x1d, 0xdc,
+	0xe8, 0x1b, 0xaf, 0xc1, 0x82, 0xd1, 0xea, 0x36, 0xda, 0xc4, 0x68, 0x5b, 0xa6, 0x4d, 0x1a, 0x3e,
+	0x69, 0x39, 0x76, 0xdb, 0x67, 0x49, 0x6e, 0x52, 0xc7, 0x46, 0xab, 0xbb, 0x25, 0xb6, 0x76, 0xf8,
+	0x0e, 0xae, 0xc3, 0xa2, 0x47, 0x02, 0xc3, 0xb4, 0x1b, 0x46, 0xab, 0x4b, 0xda, 0x8d, 0x28, 0xca,
+	0x8f, 0x2c, 0xa3, 0xd5, 0x92, 0xfe, 0x0d, 0xbe, 0xb9, 0x41, 0xf7, 0x44, 0x60, 0xfb, 0xf8, 0x87,
+	0xa0, 0x86, 0xc1, 0xeb, 0x91, 0x80, 0xd8, 0x54, 0xc6, 0x46, 0x58, 0xe7, 0xcb, 0x25, 0x86, 0x79,
+	0x29, 0x93, 0x80, 0xb6, 0x04, 0x81, 0x5e, 0x16, 0x87, 0xf5, 0xf0, 0x6c, 0xb8, 0x83, 0x37, 0xa3,
+	0x32, 0x3a, 0xcd, 0x62, 0xae, 0x92, 0x15, 0x3c, 0xae, 0x57, 0x69, 0x35, 0xbd, 0x01, 0x27, 0x88,
+	0x6d, 0x34, 0x2d, 0x12, 0x8a, 0xd2, 0x08, 0x53, 0x7b, 0x19, 0x98, 0x48, 0x8b, 0x7c, 0x5b, 0x48,
+	0xf3, 0x44, 0x6c, 0xe2, 0x27, 0x30, 0x4f, 0x5e, 0xb9, 0x26, 0x47, 0x12, 0x16, 0x60, 0x85, 0xc9,
+	0xa2, 0x65, 0x61, 0xdc, 0x8f, 0x48, 0x45, 0xf1, 0x9d, 0x23, 0xa9, 0x95, 0xc3, 0x14, 0xde, 0xbb,
+	0x30, 0x97, 0x66, 0x80, 0x2f, 0x43, 0x31, 0x08, 0xac, 0x32, 0x1a, 0xa5, 0x5d, 0x4a, 0xa5, 0x7d,
+	0x8d, 0x00, 0x06, 0x2e, 0x82, 0xcf, 0xc3, 0x2c, 0xf3, 0x2a, 0x62, 0xb7, 0x5d, 0xc7, 0xb4, 0xc3,
+	0xec, 0x39, 0x43, 0x17, 0xef, 0x8b, 0x35, 0xfc, 0x48, 0x52, 0xc7, 0xaf, 0x0c, 0xf3, 0xbc, 0x61,
+	0x45, 0xfc, 0xb0, 0x35, 0xb4, 0x05, 0xc7, 0x74, 0xd2, 0x22, 0xe6, 0xcb, 0xc8, 0xed, 0xf0, 0x22,
+	0x4c, 0x51, 0xdf, 0x36, 0xdb, 0x61, 0xb0, 0x19, 0xad, 0xee, 0xc3, 0x36, 0xbe, 0x09, 0x47, 0x84,
+	0xa1, 0x45, 0xe9, 0x1b, 0x59, 0x98, 0x42, 0x7a, 0xed, 0xdb, 0x70, 0xfc, 0x01, 0x09, 0xe2, 0x1e,
+	0x15, 0xc6, 0xb5, 0x06, 0x33, 0xf1, 0xcc, 0x17, 0xea, 0x2b, 0xbe, 0xa6, 0x7d, 0x85, 0x60, 0x89,
+	0x57, 0x6f, 0xd9, 0x0d, 0x9b, 0x92, 0x1b, 0x94, 0xfa, 0x99, 0xe1, 0x0e, 0x9d, 0xe4, 0x70, 0xb8,
+	0xca, 0xee, 0x42, 0x99, 0x26, 0x45, 0x69, 0x85, 0x79, 0x37, 0x75, 0xf4, 0x57, 0x08, 0x96, 0x24,
+	0x2c, 0x45, 0x1a, 0xde, 0x92, 0x55, 0x98, 0xd1, 0x1a, 0x39, 0x60, 0x05, 0xba, 0x0b, 0x4b, 0x3c,
+	0x5d, 0x1f, 0xd4, 0xba, 0x3f, 0x85, 0x13, 0x8f, 0x9d, 0xb6, 0xb9, 0xbb, 0x17, 0xcb, 0xb4, 0xfb,
+	0x3f, 0x9e, 0xce, 0xe3, 0x85, 0xf1, 0xf2, 0xb8, 0xf6, 0x05, 0x02, 0x65, 0xbb, 0x6f, 0x59, 0xe3,
+	0xb0, 0xbc, 0x0a, 0xd8, 0x23, 0x41, 0xdf, 0xb3, 0x1b, 0x66, 0xaf, 0x47, 0xda, 0xa6, 0x11, 0x10,
+	0x6b, 0x8f, 0x71, 0x2e, 0xe9, 0xf3, 0x7c, 0xe7, 0xe1, 0x60, 0x83, 0xbe, 0x83, 0x7b, 0xc6, 0xab,
+	0x41, 0xbe, 0x2f, 0x32, 0x63, 0x2b, 0x3d, 0xe3, 0x55, 0x98, 0xe7, 0xb5, 0x1f, 0xc3, 0x0c, 0x07,
+	0x21, 0x4c, 0xf8, 0x3d, 0x98, 0xf7, 0x44, 0x50, 0x0e, 0xce, 0x71, 0x33, 0x9e, 0xcb, 0x8a, 0x96,
+	0x8a, 0x5f, 0x7d, 0xce, 0x4b, 0x2e, 0xf8, 0xd4, 0x61, 0xca, 0x5c, 0xc9, 0x1b, 0x83, 0xc2, 0x34,
+	0x8e, 0xc8, 0x27, 0xe0, 0x08, 0x4f, 0x09, 0x7e, 0x79, 0x82, 0x15, 0xf6, 0x29, 0x96, 0x13, 0xfc,
+	0xdc, 0x3a, 0x58, 0xcc, 0xab, 0x83, 0xda, 0xf7, 0x01, 0x6f, 0xb4, 0xba, 0xb6, 0xf3, 0xa9, 0x45,
+	0xda, 0x9d, 0x83, 0x82, 0x28, 0xc4, 0x41, 0x68, 0x3f, 0x2f, 0xc0, 0xc2, 0x4e, 0xe0, 0x11, 0xa3,
+	0x67, 0xda, 0x9d, 0x71, 0xad, 0x99, 0x77, 0x2b, 0xad, 0x6f, 0x3d, 0xa6, 0x33, 0x99, 0x74, 0xc5,
+	0xd5, 0x49, 0x7d, 0x91, 0x6f, 0xa7, 0x0b, 0xfd, 0xfb, 0xd9, 0x73, 0x49, 0xdd, 0x2d, 0x24, 0xcf,
+	0x6d, 0x70, 0x76, 0xeb, 0x70, 0xd2, 0x67, 0x32, 0x34, 0x86, 0x3c, 0x2c, 0xca, 0x9c, 0x64, 0x23,
+	0xab, 0xd6, 0x0e, 0x2c, 0xa6, 0x54, 0xf0, 0x8e, 0x7c, 0xe9, 0x1f, 0x08, 0x16, 0xef, 0x79, 0x84,
+	0x66, 0x63, 0xf1, 0xb6, 0x0b, 0xb5, 0x2d, 0x7b, 0x7c, 0xa5, 0x2d, 0x50, 0x90, 0x58, 0xe0, 0xa3,
+	0xe8, 0x31, 0x52, 0x64, 0xb0, 0xae, 0x67, 0x61, 0x49, 0x19, 0xca, 0x5e, 0x25, 0x87, 0x79, 0x0c,
+	0xbc, 0x45, 0xb0, 0x28, 0xea, 0x4c, 0x4a, 0xb2, 0x1b, 0x50, 0x0a, 0x1f, 0xb2, 0x65, 0x94, 0x2a,
+	0x0e, 0x83, 0x6c, 0x1a, 0x1e, 0x8a, 0x68, 0x0f, 0x57, 0x57, 0xfe, 0x85, 0xa0, 0x14, 0xde, 0x39,
+	0xc6, 0xc3, 0xf6, 0x36, 0x28, 0xec, 0x85, 0x44, 0x78, 0xd3, 0x5e, 0x1c, 0xd9, 0xb4, 0x03, 0x27,
+	0xa7, 0x0b, 0xf8, 0x4e, 0x64, 0x8a, 0x89, 0xe5, 0xa2, 0x7c, 0x22, 0x12, 0x42, 0xfa, 0x7f, 0x6b,
+	0x7f, 0x0d, 0x30, 0x7d, 0x23, 0xa4, 0x34, 0xaf, 0xa6, 0x34, 0x3f, 0x3d, 0xd0, 0xae, 0x66, 0xc1,
+	0x02, 0xab, 0x82, 0xe9, 0xbe, 0xe7, 0xdd, 0x14, 0xdd, 0x3d, 0x58, 0x4c, 0x71, 0x13, 0x01, 0xf6,
+	0x41, 0xba, 0xed, 0x19, 0xee, 0x1d, 0x07, 0x68, 0x89, 0xae, 0xc3, 0xa2, 0xa8, 0xb1, 0x63, 0x68,
+	0xe7, 0xd7, 0x08, 0x94, 0x1d, 0x42, 0xba, 0xe3, 0xe4, 0xc2, 0x35, 0x98, 0x60, 0x4e, 0x53, 0x18,
+	0xe5, 0x34, 0x1f, 0xbe, 0xa7, 0x33, 0x4a, 0x7c, 0x2a, 0x86, 0x80, 0xa9, 0xec, 0xc3, 0xf7, 0x06,
+	0x18, 0x36, 0x4b, 0x30, 0x15, 0x18, 0x5e, 0x87, 0x04, 0xda, 0x51, 0x98, 0xe1, 0x60, 0xb8, 0xd2,
+	0xea, 0x7f, 0x2d, 0xc1, 0xb4, 0x18, 0x55, 0x10, 0x0f, 0x7f, 0x0c, 0x0a, 0x8f, 0x70, 0x3e, 0xf8,
+	0xcb, 0x9b, 0x00, 0xa8, 0x79, 0x1b, 0xda, 0xa5, 0xcf, 0xff, 0xfe, 0xcf, 0xdf, 0x15, 0xce, 0xab,
+	0x67, 0xe8, 0x58, 0xf5, 0x27, 0x34, 0x3a, 0xd6, 0x85, 0xcd, 0xfd, 0x5a, 0xa5, 0xc6, 0xe7, 0x06,
+	0xb5, 0xca, 0xeb, 0x5b, 0xa8, 0x82, 0x5f, 0x83, 0x12, 0x1b, 0x05, 0xe1, 0x0b, 0xd9, 0x2b, 0xb3,
+	0x93, 0xa2, 0x7c, 0xc6, 0x35, 0xc6, 0xf8, 0x52, 0xfd, 0x02, 0x63, 0xcc, 0x18, 0x55, 0x87, 0xb2,
+	0xff, 0x1c, 0xc1, 0x11, 0x21, 0x38, 0x5e, 0x96, 0x3e, 0xa0, 0x63, 0x33, 0x22, 0xf5, 0xdc, 0x10,
+	0x0a, 0xae, 0x49, 0xad, 0xce, 0x10, 0x5c, 0xd1, 0x56, 0x06, 0x08, 0xe4, 0xcc, 0xc5, 0x6c, 0x8e,
+	0x82, 0x70, 0xa0, 0x14, 0x0e, 0xce, 0xb0, 0x84, 0x45, 0x6a, 0xa8, 0x96, 0x2f, 0xfd, 0x0a, 0xe3,
+	0x7d, 0x0e, 0x9f, 0x1d, 0xc1, 0x1b, 0xbf, 0x41, 0x00, 0x83, 0xd1, 0x0f, 0x96, 0x4c, 0x6d, 0x33,
+	0x33, 0x28, 0xf5, 0xc2, 0x70, 0x22, 0x21, 0x7e, 0x12, 0x82, 0x60, 0x1e, 0x03, 0xf1, 0x5a, 0xa0,
+	0xc0, 0x7f, 0x46, 0x70, 0x5c, 0x3e, 0x9b, 0xc1, 0xb5, 0x21, 0x9c, 0x64, 0xcf, 0x7a, 0x75, 0x6d,
+	0xff, 0x07, 0x04, 0xcc, 0xf7, 0x19, 0xcc, 0x1a, 0xbe, 0x3a, 0x42, 0x53, 0xb5, 0xe4, 0x2b, 0xfc,
+	0x0f, 0x28, 0x36, 0x32, 0x8b, 0x52, 0x0f, 0xbe, 0x3c, 0x8c, 0x7f, 0x2a, 0x1d, 0xaa, 0x57, 0xf6,
+	0x47, 0x2c, 0x80, 0x5e, 0x63, 0x40, 0x2f, 0xe3, 0x4b, 0x23, 0x81, 0x46, 0x68, 0x02, 0x50, 0x62,
+	0x13, 0x1b, 0x59, 0x44, 0x65, 0x07, 0x3a, 0xea, 0xf1, 0x4c, 0x72, 0xb9, 0x4f, 0xff, 0x3e, 0x11,
+	0xda, 0xb3, 0x32, 0xca, 0xa5, 0xea, 0xff, 0x9d, 0x07, 0x10, 0xba, 0x6e, 0x12, 0x0f, 0xff, 0x12,
+	0x01, 0x16, 0xaf, 0x84, 0x78, 0x46, 0x1b, 0xd1, 0xf5, 0xa8, 0x23, 0xf6, 0xb5, 0x35, 0x06, 0xa7,
+	0xa2, 0x7e, 0x53, 0x9a, 0x58, 0x12, 0xc6, 0x12, 0x01, 0xfe, 0x25, 0x62, 0x53, 0xe9, 0x04, 0x8a,
+	0x55, 0x69, 0x8c, 0x49, 0x3a, 0xa6, 0x91, 0x78, 0x92, 0x7e, 0x14, 0xe7, 0x3f, 0x0c, 0x17, 0xfe,
+	0x7d, 0x34, 0x00, 0x4f, 0xe0, 0xba, 0x9c, 0x97, 0xfc, 0x0e, 0x02, 0x6d, 0x9d, 0x41, 0xfb, 0x56,
+	0xbd, 0x9e, 0x81, 0x56, 0xdd, 0x8f, 0xde, 0xbe, 0x42, 0x7c, 0x10, 0x9d, 0x0c, 0xcd, 0x8a, 0xdc,
+	0x79, 0xa5, 0x51, 0x79, 0x79, 0x5f, 0xb4, 0xc2, 0xcf, 0xab, 0x0c, 0xed, 0x2a, 0xbe, 0x98, 0x9b,
+	0x37, 0x92, 0x91, 0xf8, 0x1b, 0x14, 0xce, 0x25, 0x47, 0x69, 0x30, 0xb7, 0x1d, 0xce, 0xf5, 0x79,
+	0x61, 0xd4, 0xca, 0x98, 0x46, 0xfd, 0x23, 0x82, 0xf9, 0x4c, 0x57, 0x27, 0xd3, 0x58, 0x5e, 0xeb,
+	0x97, 0x0b, 0xe8, 0xbb, 0x0c, 0xd0, 0x96, 0x76, 0x77, 0x2c, 0x40, 0xb7, 0x7a, 0x69, 0x3e, 0xd4,
+	0xae, 0xbf, 0x45, 0xa0, 0xc4, 0x1a, 0x3e, 0x59, 0x7a, 0xc8, 0xf6, 0x83, 0xb9, 0xc8, 0xb6, 0x18,
+	0xb2, 0x3b, 0xda, 0xcd, 0xf1, 0x90, 0x19, 0x03, 0x0e, 0x14, 0xd3, 0x2f, 0x10, 0x4c, 0xd0, 0x26,
+	0x09, 0x9f, 0x96, 0xd5, 0x57, 0xcb, 0x1a, 0xe2, 0xf2, 0xf1, 0xde, 0x2a, 0x74, 0x79, 0xad, 0x3e,
+	0x1e, 0x1a, 0xb7, 0x6f, 0x59, 0x14, 0xc6, 0x2e, 0xcc, 0x26, 0x7a, 0x36, 0x2c, 0x7b, 0x6e, 0x4b,
+	0xfa, 0x5a, 0x75, 0x65, 0x24, 0x9d, 0x00, 0xf8, 0xde, 0x2a, 0x5a, 0x43, 0x34, 0xfa, 0xe7, 0xd2,
+	0x33, 0x16, 0x7c, 0x29, 0xcf, 0x4f, 0x32, 0x73, 0x98, 0x5c, 0x63, 0x3c, 0x64, 0xe2, 0xdf, 0xd3,
+	0xee, 0x1c, 0xc4, 0x4d, 0x06, 0x6c, 0xa8, 0x2a, 0x7e, 0x06, 0x4a, 0xec, 0xf5, 0x2f, 0x73, 0x92,
+	0x6c, 0x73, 0xa0, 0x0e, 0x79, 0x66, 0x6b, 0x57, 0x19, 0xb6, 0x15, 0xcc, 0x13, 0x77, 0x58, 0xac,
+	0x12, 0xb8, 0xc4, 0x1a, 0x8b, 0xa5, 0xb7, 0x08, 0x66, 0x13, 0xcf, 0x7b, 0x99, 0x2d, 0x64, 0xdd,
+	0x86, 0xba, 0x32, 0x92, 0x4e, 0xd8, 0xa2, 0xc2, 0x10, 0x5d, 0xc0, 0x5a, 0x7e, 0xc6, 0x89, 0x98,
+	0x7f, 0x81, 0xe0, 0x68, 0xb2, 0xe7, 0xc5, 0x2b, 0xfb, 0xec, 0x8a, 0x87, 0x6a, 0xe5, 0x0a, 0xc3,
+	0x70, 0x51, 0x3d, 0x27, 0x2f, 0x67, 0x31, 0x8d, 0x50, 0xa3, 0xbc, 0x45, 0x70, 0x34, 0xd9, 0x10,
+	0xcb, 0x50, 0x48, 0x5b, 0xe6, 0xa1, 0x28, 0x44, 0xbe, 0xab, 0x57, 0x12, 0xb6, 0xa9, 0x8e, 0x82,
+	0xf3, 0x06, 0xc1, 0xd1, 0x64, 0x1f, 0x24, 0x83, 0x23, 0xed, 0x94, 0x72, 0x5d, 0x58, 0xb8, 0x49,
+	0x65, 0x9f, 0x6e, 0x42, 0x13, 0x07, 0xed, 0x63, 0x64, 0x89, 0x23, 0xd6, 0x6c, 0xa9, 0x67, 0xf2,
+	0xb6, 0x0f, 0x97, 0x38, 0x7c, 0x42, 0xba, 0xb7, 0x50, 0x65, 0xf3, 0x4b, 0x04, 0x0b, 0x2d, 0xa7,
+	0x97, 0x61, 0xb2, 0xa9, 0xf0, 0x01, 0xfc, 0x36, 0x15, 0x72, 0x1b, 0xfd, 0xe8, 0x86, 0x20, 0xe8,
+	0x38, 0x96, 0x61, 0x77, 0xaa, 0x8e, 0xd7, 0xa9, 0x75, 0x88, 0xcd, 0x54, 0x50, 0xe3, 0x5b, 0x86,
+	0x6b, 0xfa, 0x83, 0xff, 0x48, 0xb9, 0xcd, 0xbf, 0xfe, 0x83, 0xd0, 0x9f, 0x0a, 0xc7, 0x1f, 0xf0,
+	0xb3, 0xf7, 0x2c, 0xa7, 0xdf, 0xa6, 0x5d, 0xc7, 0x4e, 0xbf, 0x59, 0x7d, 0x76, 0xed, 0x6f, 0xe1,
+	0xc6, 0x73, 0xb6, 0xf1, 0x9c, 0x6f, 0x3c, 0x7f, 0x76, 0xad, 0x39, 0xc5, 0xee, 0xbd, 0xfe, 0xbf,
+	0x01, 0x00, 0x24, 0x15, 0xcc, 0x62, 0xe8, 0x22, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.